@@ -4,6 +4,7 @@ package agent
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/onllm-dev/onwatch/internal/api"
@@ -22,6 +23,14 @@ type Agent struct {
 	sm           *SessionManager
 	notifier     *notify.NotificationEngine
 	pollingCheck func() bool
+	startGate    *StartGate
+	firstPolled  bool
+	breaker      *CircuitBreaker
+	latest       *LatestSnapshots
+	snapshotHook *SnapshotHook
+	firehose     *Firehose
+
+	pollMu sync.Mutex // serializes scheduled and on-demand polls
 }
 
 // SetPollingCheck sets a function that is called before each poll.
@@ -30,6 +39,34 @@ func (a *Agent) SetPollingCheck(fn func() bool) {
 	a.pollingCheck = fn
 }
 
+// SetStartGate makes the agent's first snapshot write wait its turn behind
+// other agents sharing the gate.
+func (a *Agent) SetStartGate(g *StartGate) {
+	a.startGate = g
+}
+
+// SetCircuitBreaker sets the breaker that pauses polling after repeated
+// auth/server failures.
+func (a *Agent) SetCircuitBreaker(b *CircuitBreaker) {
+	a.breaker = b
+}
+
+// SetLatestSnapshots sets the registry that holds the latest polled snapshot
+// and limits how often snapshots are stored.
+func (a *Agent) SetLatestSnapshots(l *LatestSnapshots) {
+	a.latest = l
+}
+
+// SetSnapshotHook sets the command run after each stored snapshot.
+func (a *Agent) SetSnapshotHook(h *SnapshotHook) {
+	a.snapshotHook = h
+}
+
+// SetFirehose sets the webhook firehose that receives each stored snapshot.
+func (a *Agent) SetFirehose(f *Firehose) {
+	a.firehose = f
+}
+
 // SetNotifier sets the notification engine for sending alerts.
 func (a *Agent) SetNotifier(n *notify.NotificationEngine) {
 	a.notifier = n
@@ -83,33 +120,45 @@ func (a *Agent) Run(ctx context.Context) error {
 }
 
 // poll performs a single poll cycle: fetch quotas, store snapshot, update tracker.
-func (a *Agent) poll(ctx context.Context) {
+func (a *Agent) poll(ctx context.Context) error {
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
 	if a.pollingCheck != nil && !a.pollingCheck() {
-		return // polling disabled for this provider
+		return ErrPollingDisabled
+	}
+	if !a.breaker.Allow() {
+		return ErrCircuitOpen
 	}
 
 	// Fetch quotas from API
+	pollStart := time.Now()
 	resp, err := a.client.FetchQuotas(ctx)
 	if err != nil {
 		if ctx.Err() != nil {
 			// Context cancelled during request - this is expected during shutdown
-			return
+			return ctx.Err()
 		}
 		a.logger.Error("Failed to fetch quotas", "error", err)
-		return
+		recordPoll(a.store, a.notifier, a.breaker, a.logger, "synthetic", pollStart, err)
+		return err
 	}
+	release := a.startGate.hold(&a.firstPolled)
+	defer release()
+	recordPoll(a.store, a.notifier, a.breaker, a.logger, "synthetic", pollStart, nil)
+	checkSchema(a.notifier, a.breaker, a.logger, "synthetic", resp.SchemaFields())
 
 	// Create snapshot from response
-	snapshot := &api.Snapshot{
-		CapturedAt: time.Now().UTC(),
-		Sub:        resp.Subscription,
-		Search:     resp.Search.Hourly,
-		ToolCall:   resp.ToolCallDiscounts,
-	}
+	snapshot := resp.ToSnapshot(time.Now().UTC())
 
-	// Store snapshot (always do this, even if tracker fails)
-	if _, err := a.store.InsertSnapshot(snapshot); err != nil {
-		a.logger.Error("Failed to insert snapshot", "error", err)
+	// Store snapshot when due (even if tracker fails)
+	if a.latest.Update("synthetic", snapshot, snapshot.CapturedAt) {
+		if _, err := a.store.InsertSnapshot(snapshot); err != nil {
+			a.logger.Error("Failed to insert snapshot", "error", err)
+		} else {
+			a.snapshotHook.Run("synthetic", snapshot)
+			a.firehose.Add("synthetic", snapshot)
+		}
 	}
 
 	// Process with tracker (log error but don't stop)
@@ -117,26 +166,10 @@ func (a *Agent) poll(ctx context.Context) {
 		a.logger.Error("Tracker processing failed", "error", err)
 	}
 
-	// Check notification thresholds
-	if a.notifier != nil {
-		for _, q := range []struct {
-			key      string
-			req, lim float64
-		}{
-			{"subscription", snapshot.Sub.Requests, snapshot.Sub.Limit},
-			{"search", snapshot.Search.Requests, snapshot.Search.Limit},
-			{"toolcall", snapshot.ToolCall.Requests, snapshot.ToolCall.Limit},
-		} {
-			if q.lim > 0 {
-				a.notifier.Check(notify.QuotaStatus{
-					Provider:    "synthetic",
-					QuotaKey:    q.key,
-					Utilization: (q.req / q.lim) * 100,
-					Limit:       q.lim,
-				})
-			}
-		}
-	}
+	// Writes done; let the next agent's first poll proceed
+	release()
+
+	a.checkThresholds(snapshot)
 
 	// Report to session manager for usage-based session detection
 	if a.sm != nil {
@@ -155,4 +188,73 @@ func (a *Agent) poll(ctx context.Context) {
 		"tool_requests", resp.ToolCallDiscounts.Requests,
 		"sub_renews_at", resp.Subscription.RenewsAt,
 	)
+	return nil
+}
+
+// checkThresholds passes each quota in snapshot to the notifier.
+func (a *Agent) checkThresholds(snapshot *api.Snapshot) {
+	if a.notifier != nil {
+		for _, q := range []struct {
+			key  string
+			info api.QuotaInfo
+		}{
+			{"subscription", snapshot.Sub},
+			{"search", snapshot.Search},
+			{"toolcall", snapshot.ToolCall},
+		} {
+			if q.info.Limit > 0 {
+				status := notify.QuotaStatus{
+					Provider:    "synthetic",
+					QuotaKey:    q.key,
+					Utilization: (q.info.Requests / q.info.Limit) * 100,
+					Limit:       q.info.Limit,
+				}
+				if !q.info.RenewsAt.IsZero() {
+					renewsAt := q.info.RenewsAt
+					status.ResetAt = &renewsAt
+				}
+				// Burn-rate projection drives exhaustion alerts
+				if a.tracker != nil {
+					if summary, err := a.tracker.UsageSummary(q.key); err == nil && summary != nil && summary.ProjectedUsage > 0 {
+						status.ProjectedUtil = summary.ProjectedUsage / q.info.Limit * 100
+					}
+				}
+				a.notifier.Check(status)
+			}
+		}
+	}
+}
+
+// recordPoll stores the outcome of a provider API poll for availability tracking
+// and updates the provider's circuit breaker, alerting when it opens.
+// After a successful poll it also runs the notifier's latency and budget checks.
+// Only transitions and periodic samples are persisted (see store.RecordPollOutcome).
+func recordPoll(s *store.Store, n *notify.NotificationEngine, b *CircuitBreaker, logger *slog.Logger, provider string, started time.Time, err error) {
+	latency := time.Since(started)
+	if b.Record(err) {
+		st := b.Status()
+		logger.Warn("Circuit opened, pausing polling", "provider", provider,
+			"failures", st.Failures, "retry_at", st.RetryAt, "error", st.LastError)
+		if n != nil {
+			n.NotifyCircuitOpen(provider, st.Failures, st.LastError, *st.RetryAt)
+		}
+	}
+	if err == nil && n != nil {
+		n.CheckLatency(provider, latency)
+		n.CheckBudget()
+	}
+	if s == nil {
+		return
+	}
+	outcome := store.PollOutcome{
+		Provider: provider,
+		Success:  err == nil,
+		Latency:  latency,
+	}
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+	if _, rerr := s.RecordPollOutcome(outcome); rerr != nil {
+		logger.Error("Failed to record poll outcome", "provider", provider, "error", rerr)
+	}
 }
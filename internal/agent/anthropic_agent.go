@@ -4,7 +4,9 @@ package agent
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/onllm-dev/onwatch/internal/api"
@@ -39,11 +41,19 @@ type AnthropicAgent struct {
 	lastToken    string
 	notifier     *notify.NotificationEngine
 	pollingCheck func() bool
+	startGate    *StartGate
+	firstPolled  bool
+	breaker      *CircuitBreaker
+	latest       *LatestSnapshots
+	snapshotHook *SnapshotHook
+	firehose     *Firehose
 
 	// Auth failure rate limiting
 	authFailCount   int    // consecutive auth failures (401 or 403)
 	authPaused      bool   // true when polling is paused due to auth failures
 	lastFailedToken string // token that caused the failures (to detect credential refresh)
+
+	pollMu sync.Mutex // serializes scheduled and on-demand polls
 }
 
 // SetPollingCheck sets a function that is called before each poll.
@@ -52,6 +62,34 @@ func (a *AnthropicAgent) SetPollingCheck(fn func() bool) {
 	a.pollingCheck = fn
 }
 
+// SetStartGate makes the agent's first snapshot write wait its turn behind
+// other agents sharing the gate.
+func (a *AnthropicAgent) SetStartGate(g *StartGate) {
+	a.startGate = g
+}
+
+// SetCircuitBreaker sets the breaker that pauses polling after repeated
+// auth/server failures.
+func (a *AnthropicAgent) SetCircuitBreaker(b *CircuitBreaker) {
+	a.breaker = b
+}
+
+// SetLatestSnapshots sets the registry that holds the latest polled snapshot
+// and limits how often snapshots are stored.
+func (a *AnthropicAgent) SetLatestSnapshots(l *LatestSnapshots) {
+	a.latest = l
+}
+
+// SetSnapshotHook sets the command run after each stored snapshot.
+func (a *AnthropicAgent) SetSnapshotHook(h *SnapshotHook) {
+	a.snapshotHook = h
+}
+
+// SetFirehose sets the webhook firehose that receives each stored snapshot.
+func (a *AnthropicAgent) SetFirehose(f *Firehose) {
+	a.firehose = f
+}
+
 // SetNotifier sets the notification engine for sending alerts.
 func (a *AnthropicAgent) SetNotifier(n *notify.NotificationEngine) {
 	a.notifier = n
@@ -122,14 +160,21 @@ func isAuthError(err error) bool {
 }
 
 // poll performs a single Anthropic poll cycle: fetch quotas, store snapshot, process with tracker.
-func (a *AnthropicAgent) poll(ctx context.Context) {
+func (a *AnthropicAgent) poll(ctx context.Context) error {
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
 	if a.pollingCheck != nil && !a.pollingCheck() {
-		return // polling disabled for this provider
+		return ErrPollingDisabled
+	}
+	if !a.breaker.Allow() {
+		return ErrCircuitOpen
 	}
 
 	// Proactive OAuth refresh: check if token expires soon and refresh via OAuth API
 	if a.credsRefresh != nil {
 		if creds := a.credsRefresh(); creds != nil {
+			a.breaker.RecordTokenExpiry(creds.ExpiresAt)
 			// Check if token is expiring soon or already expired
 			if creds.IsExpiringSoon(tokenRefreshThreshold) && creds.RefreshToken != "" {
 				a.logger.Info("Token expiring soon, attempting proactive OAuth refresh",
@@ -138,16 +183,27 @@ func (a *AnthropicAgent) poll(ctx context.Context) {
 				newTokens, err := api.RefreshAnthropicToken(ctx, creds.RefreshToken)
 				if err != nil {
 					a.logger.Error("Proactive OAuth refresh failed", "error", err)
+					a.breaker.RecordTokenRefresh(TokenSourceOAuth, err)
+					if creds.IsExpired() {
+						a.logger.Warn("Anthropic token has expired and could not be refreshed",
+							"expired_at", creds.ExpiresAt,
+							"action", "Re-authenticate with 'claude auth' if polling fails")
+					}
 					// Continue with existing token - it might still work
 				} else {
 					// CRITICAL: Save new tokens to disk IMMEDIATELY
 					if err := api.WriteAnthropicCredentials(newTokens.AccessToken, newTokens.RefreshToken, newTokens.ExpiresIn); err != nil {
 						a.logger.Error("Failed to save refreshed credentials", "error", err)
+						a.breaker.RecordTokenRefresh(TokenSourceOAuth, fmt.Errorf("saving credentials: %w", err))
 					} else {
 						a.client.SetToken(newTokens.AccessToken)
 						a.lastToken = newTokens.AccessToken
+						expiresAt := time.Now().Add(time.Duration(newTokens.ExpiresIn) * time.Second)
+						a.breaker.RecordTokenRefresh(TokenSourceOAuth, nil)
+						a.breaker.RecordTokenExpiry(expiresAt)
 						a.logger.Info("Proactively refreshed OAuth token",
-							"expires_in_hours", newTokens.ExpiresIn/3600)
+							"expires_in_hours", newTokens.ExpiresIn/3600,
+							"expires_at", expiresAt.UTC().Format(time.RFC3339))
 
 						// Reset auth failures since we have fresh credentials
 						if a.authPaused {
@@ -168,6 +224,9 @@ func (a *AnthropicAgent) poll(ctx context.Context) {
 		newToken = a.tokenRefresh()
 		if newToken != "" && newToken != a.lastToken {
 			a.client.SetToken(newToken)
+			if a.lastToken != "" {
+				a.breaker.RecordTokenRefresh(TokenSourceCredentials, nil)
+			}
 			a.lastToken = newToken
 			a.logger.Info("Anthropic token refreshed from credentials")
 
@@ -184,26 +243,31 @@ func (a *AnthropicAgent) poll(ctx context.Context) {
 	// If auth is paused, skip polling until credentials change
 	if a.authPaused {
 		// Only log periodically to avoid spamming logs
-		return
+		return ErrAuthPaused
 	}
 
+	pollStart := time.Now()
 	resp, err := a.client.FetchQuotas(ctx)
 	if err != nil {
 		if ctx.Err() != nil {
-			return
+			return ctx.Err()
 		}
 		// On auth error (401 or 403), force token re-read and retry once
 		if isAuthError(err) && a.tokenRefresh != nil {
 			a.logger.Warn("Anthropic auth error, forcing credential re-read", "error", err)
+			prevToken := a.lastToken
 			a.lastToken = "" // force re-read even if token hasn't changed on disk
 			if retryToken := a.tokenRefresh(); retryToken != "" {
+				if retryToken != prevToken {
+					a.breaker.RecordTokenRefresh(TokenSourceCredentials, nil)
+				}
 				a.client.SetToken(retryToken)
 				a.lastToken = retryToken
 				a.logger.Info("Retrying with refreshed token")
 				resp, err = a.client.FetchQuotas(ctx)
 				if err != nil {
 					if ctx.Err() != nil {
-						return
+						return ctx.Err()
 					}
 					// Retry also failed - count this as an auth failure
 					if isAuthError(err) {
@@ -223,30 +287,41 @@ func (a *AnthropicAgent) poll(ctx context.Context) {
 					} else {
 						a.logger.Error("Anthropic retry failed with non-auth error", "error", err)
 					}
-					return
+					recordPoll(a.store, a.notifier, a.breaker, a.logger, "anthropic", pollStart, err)
+					return err
 				}
 				// Retry succeeded — reset auth failure count and fall through
 				a.authFailCount = 0
 			} else {
 				a.logger.Error("No Anthropic token available after re-read")
-				return
+				recordPoll(a.store, a.notifier, a.breaker, a.logger, "anthropic", pollStart, err)
+				return err
 			}
 		} else {
 			a.logger.Error("Failed to fetch Anthropic quotas", "error", err)
-			return
+			recordPoll(a.store, a.notifier, a.breaker, a.logger, "anthropic", pollStart, err)
+			return err
 		}
 	} else {
 		// Success — reset auth failure count
 		a.authFailCount = 0
 	}
+	release := a.startGate.hold(&a.firstPolled)
+	defer release()
+	recordPoll(a.store, a.notifier, a.breaker, a.logger, "anthropic", pollStart, nil)
+	checkSchema(a.notifier, a.breaker, a.logger, "anthropic", resp.SchemaFields())
 
 	// Convert to snapshot and store
 	now := time.Now().UTC()
 	snapshot := resp.ToSnapshot(now)
 
-	if _, err := a.store.InsertAnthropicSnapshot(snapshot); err != nil {
-		a.logger.Error("Failed to insert Anthropic snapshot", "error", err)
-		return
+	if a.latest.Update("anthropic", snapshot, snapshot.CapturedAt) {
+		if _, err := a.store.InsertAnthropicSnapshot(snapshot); err != nil {
+			a.logger.Error("Failed to insert Anthropic snapshot", "error", err)
+			return err
+		}
+		a.snapshotHook.Run("anthropic", snapshot)
+		a.firehose.Add("anthropic", snapshot)
 	}
 
 	// Process with tracker (log error but don't stop)
@@ -256,16 +331,10 @@ func (a *AnthropicAgent) poll(ctx context.Context) {
 		}
 	}
 
-	// Check notification thresholds
-	if a.notifier != nil {
-		for _, q := range snapshot.Quotas {
-			a.notifier.Check(notify.QuotaStatus{
-				Provider:    "anthropic",
-				QuotaKey:    q.Name,
-				Utilization: q.Utilization,
-			})
-		}
-	}
+	// Writes done; let the next agent's first poll proceed
+	release()
+
+	a.checkThresholds(snapshot)
 
 	// Report to session manager — extract utilization values for change detection.
 	// Use fixed order matching UI columns: five_hour, seven_day, seven_day_sonnet
@@ -298,4 +367,27 @@ func (a *AnthropicAgent) poll(ctx context.Context) {
 		"quota_count", quotaCount,
 		"max_utilization", maxUtil,
 	)
+	return nil
+}
+
+// checkThresholds passes each quota in snapshot to the notifier, with its
+// burn-rate projection for exhaustion alerts.
+func (a *AnthropicAgent) checkThresholds(snapshot *api.AnthropicSnapshot) {
+	if a.notifier != nil {
+		for _, q := range snapshot.Quotas {
+			status := notify.QuotaStatus{
+				Provider:    "anthropic",
+				QuotaKey:    q.Name,
+				Utilization: q.Utilization,
+				ResetAt:     q.ResetsAt,
+			}
+			// Burn-rate projection drives exhaustion alerts
+			if a.tracker != nil {
+				if summary, err := a.tracker.UsageSummary(q.Name); err == nil && summary != nil {
+					status.ProjectedUtil = summary.ProjectedUtil
+				}
+			}
+			a.notifier.Check(status)
+		}
+	}
 }
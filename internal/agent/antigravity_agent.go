@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/onllm-dev/onwatch/internal/api"
@@ -24,10 +25,18 @@ type AntigravityAgent struct {
 	sm           *SessionManager
 	notifier     *notify.NotificationEngine
 	pollingCheck func() bool
+	startGate    *StartGate
+	firstPolled  bool
+	breaker      *CircuitBreaker
+	latest       *LatestSnapshots
+	snapshotHook *SnapshotHook
+	firehose     *Firehose
 
 	// Manual configuration for Docker environments
 	manualBaseURL   string
 	manualCSRFToken string
+
+	pollMu sync.Mutex // serializes scheduled and on-demand polls
 }
 
 // AntigravityAgentOption configures an AntigravityAgent.
@@ -48,6 +57,34 @@ func (a *AntigravityAgent) SetPollingCheck(fn func() bool) {
 	a.pollingCheck = fn
 }
 
+// SetStartGate makes the agent's first snapshot write wait its turn behind
+// other agents sharing the gate.
+func (a *AntigravityAgent) SetStartGate(g *StartGate) {
+	a.startGate = g
+}
+
+// SetCircuitBreaker sets the breaker that pauses polling after repeated
+// auth/server failures.
+func (a *AntigravityAgent) SetCircuitBreaker(b *CircuitBreaker) {
+	a.breaker = b
+}
+
+// SetLatestSnapshots sets the registry that holds the latest polled snapshot
+// and limits how often snapshots are stored.
+func (a *AntigravityAgent) SetLatestSnapshots(l *LatestSnapshots) {
+	a.latest = l
+}
+
+// SetSnapshotHook sets the command run after each stored snapshot.
+func (a *AntigravityAgent) SetSnapshotHook(h *SnapshotHook) {
+	a.snapshotHook = h
+}
+
+// SetFirehose sets the webhook firehose that receives each stored snapshot.
+func (a *AntigravityAgent) SetFirehose(f *Firehose) {
+	a.firehose = f
+}
+
 // SetNotifier sets the notification engine for sending alerts.
 func (a *AntigravityAgent) SetNotifier(n *notify.NotificationEngine) {
 	a.notifier = n
@@ -134,27 +171,44 @@ func (a *AntigravityAgent) Run(ctx context.Context) error {
 }
 
 // poll performs a single poll cycle: detect process, fetch quotas, store snapshot, update tracker.
-func (a *AntigravityAgent) poll(ctx context.Context) {
+func (a *AntigravityAgent) poll(ctx context.Context) error {
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
 	if a.pollingCheck != nil && !a.pollingCheck() {
-		return
+		return ErrPollingDisabled
+	}
+	if !a.breaker.Allow() {
+		return ErrCircuitOpen
 	}
 
+	pollStart := time.Now()
 	resp, err := a.client.FetchQuotas(ctx)
 	if err != nil {
 		if ctx.Err() != nil {
-			return
+			return ctx.Err()
 		}
 		a.logger.Error("Failed to fetch Antigravity quotas", "error", err)
-		return
+		recordPoll(a.store, a.notifier, a.breaker, a.logger, "antigravity", pollStart, err)
+		return err
 	}
+	release := a.startGate.hold(&a.firstPolled)
+	defer release()
+	recordPoll(a.store, a.notifier, a.breaker, a.logger, "antigravity", pollStart, nil)
+	checkSchema(a.notifier, a.breaker, a.logger, "antigravity", resp.SchemaFields())
 
 	// Convert API response to snapshot
 	now := time.Now().UTC()
 	snapshot := resp.ToSnapshot(now)
 
 	// Store snapshot
-	if _, err := a.store.InsertAntigravitySnapshot(snapshot); err != nil {
-		a.logger.Error("Failed to insert Antigravity snapshot", "error", err)
+	if a.latest.Update("antigravity", snapshot, snapshot.CapturedAt) {
+		if _, err := a.store.InsertAntigravitySnapshot(snapshot); err != nil {
+			a.logger.Error("Failed to insert Antigravity snapshot", "error", err)
+		} else {
+			a.snapshotHook.Run("antigravity", snapshot)
+			a.firehose.Add("antigravity", snapshot)
+		}
 	}
 
 	// Process with tracker
@@ -162,6 +216,9 @@ func (a *AntigravityAgent) poll(ctx context.Context) {
 		a.logger.Error("Antigravity tracker processing failed", "error", err)
 	}
 
+	// Writes done; let the next agent's first poll proceed
+	release()
+
 	// Check notification thresholds for models
 	if a.notifier != nil {
 		for _, m := range snapshot.Models {
@@ -169,12 +226,19 @@ func (a *AntigravityAgent) poll(ctx context.Context) {
 				continue // Skip unused models
 			}
 			utilization := (1.0 - m.RemainingFraction) * 100
-			a.notifier.Check(notify.QuotaStatus{
+			status := notify.QuotaStatus{
 				Provider:    "antigravity",
 				QuotaKey:    m.ModelID,
+				QuotaLabel:  api.AntigravityModelLabel(m.ModelID, m.Label),
 				Utilization: utilization,
 				Limit:       100, // Percentage-based
-			})
+				ResetAt:     m.ResetTime,
+			}
+			// Burn-rate projection drives exhaustion alerts
+			if summary, err := a.tracker.UsageSummary(m.ModelID); err == nil && summary != nil {
+				status.ProjectedUtil = summary.ProjectedUsage * 100
+			}
+			a.notifier.Check(status)
 		}
 	}
 
@@ -204,6 +268,7 @@ func (a *AntigravityAgent) poll(ctx context.Context) {
 			)
 		}
 	}
+	return nil
 }
 
 // IsConnected returns true if the agent has a valid connection to the language server.
@@ -0,0 +1,267 @@
+package agent
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+// Circuit breaker states.
+const (
+	CircuitClosed   = "closed"    // polling normally
+	CircuitOpen     = "open"      // polling stopped until the cooldown passes
+	CircuitHalfOpen = "half_open" // cooldown passed; the next poll is a trial
+)
+
+// breakerErrors are the failures that count toward opening a circuit: auth
+// rejections and server errors. Network blips and bad payloads do not.
+var breakerErrors = []error{
+	api.ErrUnauthorized, api.ErrServerError,
+	api.ErrZaiUnauthorized, api.ErrZaiServerError,
+	api.ErrAnthropicUnauthorized, api.ErrAnthropicForbidden, api.ErrAnthropicServerError,
+	api.ErrCopilotUnauthorized, api.ErrCopilotForbidden, api.ErrCopilotServerError,
+	api.ErrCodexUnauthorized, api.ErrCodexForbidden, api.ErrCodexServerError,
+	api.ErrAntigravityNotAuthenticated,
+}
+
+func isBreakerFailure(err error) bool {
+	for _, target := range breakerErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// CircuitStatus is a snapshot of a provider's circuit breaker.
+type CircuitStatus struct {
+	Provider  string     `json:"provider"`
+	State     string     `json:"state"`
+	Failures  int        `json:"consecutive_failures"`
+	LastError string     `json:"last_error,omitempty"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+	RetryAt   *time.Time `json:"retry_at,omitempty"`
+
+	// LastPollAt is when the provider was last polled, nil before the first
+	// poll; LastPollError is that poll's error, empty when it succeeded.
+	LastPollAt    *time.Time `json:"last_poll_at,omitempty"`
+	LastPollError string     `json:"last_poll_error,omitempty"`
+
+	// SchemaWarning is set while fields the provider used to return have
+	// been missing from its responses for several consecutive polls.
+	SchemaWarning string `json:"schema_warning,omitempty"`
+
+	// Token is the provider's credential refresh state, nil for providers
+	// whose agent does not refresh tokens.
+	Token *TokenStatus `json:"token,omitempty"`
+}
+
+// PollCounters are a provider's poll totals since the daemon started.
+type PollCounters struct {
+	Provider   string
+	Successes  int64
+	Errors     int64
+	LastPollAt time.Time // zero until the first poll
+}
+
+// CircuitBreaker stops polling a provider after threshold consecutive
+// auth/server failures, then allows a single trial poll every cooldown.
+type CircuitBreaker struct {
+	provider  string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	lastError string
+	openedAt  time.Time
+
+	pollSuccesses int64
+	pollErrors    int64
+	lastPollAt    time.Time
+	lastPollError string
+
+	schema schemaWatch
+	token  tokenWatch
+}
+
+// NewCircuitBreaker creates a closed breaker for provider.
+func NewCircuitBreaker(provider string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		provider:  provider,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     CircuitClosed,
+	}
+}
+
+// Allow reports whether the agent may poll. An open circuit moves to half-open
+// once the cooldown has passed. A nil breaker always allows.
+func (b *CircuitBreaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen {
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+	}
+	return true
+}
+
+// Record updates the breaker with a poll result and reports whether this
+// result opened the circuit from closed. A success closes it; a failed
+// half-open trial re-opens it without reporting again.
+func (b *CircuitBreaker) Record(err error) (opened bool) {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastPollAt = b.now()
+	if err == nil {
+		b.pollSuccesses++
+		b.lastPollError = ""
+	} else {
+		b.pollErrors++
+		b.lastPollError = err.Error()
+	}
+
+	if err == nil {
+		b.state = CircuitClosed
+		b.failures = 0
+		b.lastError = ""
+		return false
+	}
+	if !isBreakerFailure(err) {
+		return false
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	switch b.state {
+	case CircuitHalfOpen:
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	case CircuitClosed:
+		if b.threshold > 0 && b.failures >= b.threshold {
+			b.state = CircuitOpen
+			b.openedAt = b.now()
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns a snapshot of the breaker.
+func (b *CircuitBreaker) Status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := CircuitStatus{
+		Provider:      b.provider,
+		State:         b.state,
+		Failures:      b.failures,
+		LastError:     b.lastError,
+		SchemaWarning: b.schema.warning(),
+		Token:         b.token.status(b.now()),
+	}
+	if !b.lastPollAt.IsZero() {
+		last := b.lastPollAt
+		st.LastPollAt = &last
+		st.LastPollError = b.lastPollError
+	}
+	if b.state != CircuitClosed {
+		opened := b.openedAt
+		retry := b.openedAt.Add(b.cooldown)
+		st.OpenedAt = &opened
+		st.RetryAt = &retry
+	}
+	return st
+}
+
+// Counters returns the breaker's poll totals. Every recorded poll counts,
+// including failures that do not count toward opening the circuit.
+func (b *CircuitBreaker) Counters() PollCounters {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return PollCounters{
+		Provider:   b.provider,
+		Successes:  b.pollSuccesses,
+		Errors:     b.pollErrors,
+		LastPollAt: b.lastPollAt,
+	}
+}
+
+// CircuitBreakers holds one breaker per provider with shared settings.
+type CircuitBreakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewCircuitBreakers creates a registry whose breakers open after threshold
+// consecutive failures and retry every cooldown.
+func NewCircuitBreakers(threshold int, cooldown time.Duration) *CircuitBreakers {
+	return &CircuitBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*CircuitBreaker),
+	}
+}
+
+// For returns the breaker for provider, creating it on first use.
+func (c *CircuitBreakers) For(provider string) *CircuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[provider]
+	if !ok {
+		b = NewCircuitBreaker(provider, c.threshold, c.cooldown)
+		c.breakers[provider] = b
+	}
+	return b
+}
+
+// all returns a copy of the registered breakers.
+func (c *CircuitBreakers) all() []*CircuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	breakers := make([]*CircuitBreaker, 0, len(c.breakers))
+	for _, b := range c.breakers {
+		breakers = append(breakers, b)
+	}
+	return breakers
+}
+
+// Statuses returns every breaker's status, sorted by provider.
+func (c *CircuitBreakers) Statuses() []CircuitStatus {
+	breakers := c.all()
+	statuses := make([]CircuitStatus, 0, len(breakers))
+	for _, b := range breakers {
+		statuses = append(statuses, b.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Provider < statuses[j].Provider })
+	return statuses
+}
+
+// Counters returns every breaker's poll totals, sorted by provider.
+func (c *CircuitBreakers) Counters() []PollCounters {
+	breakers := c.all()
+	counters := make([]PollCounters, 0, len(breakers))
+	for _, b := range breakers {
+		counters = append(counters, b.Counters())
+	}
+	sort.Slice(counters, func(i, j int) bool { return counters[i].Provider < counters[j].Provider })
+	return counters
+}
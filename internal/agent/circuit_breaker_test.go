@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("anthropic", 3, 15*time.Minute)
+	b.now = func() time.Time { return now }
+
+	if b.Record(api.ErrAnthropicUnauthorized) || b.Record(api.ErrAnthropicUnauthorized) {
+		t.Fatal("circuit opened before reaching the threshold")
+	}
+	if !b.Record(fmt.Errorf("retry: %w", api.ErrAnthropicUnauthorized)) {
+		t.Fatal("expected third consecutive failure to open the circuit")
+	}
+	if b.Allow() {
+		t.Error("open circuit should not allow polling during cooldown")
+	}
+
+	st := b.Status()
+	if st.State != CircuitOpen || st.Failures != 3 || st.RetryAt == nil || !st.RetryAt.Equal(now.Add(15*time.Minute)) {
+		t.Errorf("unexpected status: %+v", st)
+	}
+
+	// Half-open trial fails: re-opens without reporting again
+	now = now.Add(16 * time.Minute)
+	if !b.Allow() {
+		t.Fatal("expected a trial poll after the cooldown")
+	}
+	if b.Status().State != CircuitHalfOpen {
+		t.Errorf("expected half-open, got %s", b.Status().State)
+	}
+	if b.Record(api.ErrAnthropicServerError) {
+		t.Error("failed trial should not report a new opening")
+	}
+	if b.Allow() {
+		t.Error("circuit should be open again after a failed trial")
+	}
+
+	// Successful trial closes it
+	now = now.Add(16 * time.Minute)
+	b.Allow()
+	b.Record(nil)
+	if st := b.Status(); st.State != CircuitClosed || st.Failures != 0 || st.OpenedAt != nil {
+		t.Errorf("expected closed circuit after success, got %+v", st)
+	}
+}
+
+func TestCircuitBreaker_IgnoresNetworkErrors(t *testing.T) {
+	b := NewCircuitBreaker("zai", 1, time.Minute)
+	if b.Record(api.ErrZaiNetworkError) {
+		t.Error("network errors should not open the circuit")
+	}
+	if st := b.Status(); st.State != CircuitClosed || st.Failures != 0 {
+		t.Errorf("expected untouched breaker, got %+v", st)
+	}
+}
+
+func TestCircuitBreaker_LastPoll(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("zai", 5, time.Minute)
+	b.now = func() time.Time { return now }
+	if st := b.Status(); st.LastPollAt != nil {
+		t.Errorf("expected no last poll before polling, got %v", st.LastPollAt)
+	}
+
+	b.Record(api.ErrZaiNetworkError)
+	st := b.Status()
+	if st.LastPollAt == nil || !st.LastPollAt.Equal(now) || st.LastPollError == "" {
+		t.Errorf("expected failed last poll at %v, got %+v", now, st)
+	}
+
+	now = now.Add(time.Minute)
+	b.Record(nil)
+	if st := b.Status(); !st.LastPollAt.Equal(now) || st.LastPollError != "" {
+		t.Errorf("expected successful last poll at %v, got %+v", now, st)
+	}
+}
+
+func TestCircuitBreaker_NilAllows(t *testing.T) {
+	var b *CircuitBreaker
+	if !b.Allow() || b.Record(api.ErrUnauthorized) {
+		t.Error("nil breaker should always allow and never open")
+	}
+}
+
+func TestCircuitBreakers_Statuses(t *testing.T) {
+	c := NewCircuitBreakers(1, time.Minute)
+	c.For("zai")
+	c.For("anthropic").Record(api.ErrAnthropicForbidden)
+	if c.For("anthropic") != c.For("anthropic") {
+		t.Error("For should return the same breaker per provider")
+	}
+
+	statuses := c.Statuses()
+	if len(statuses) != 2 || statuses[0].Provider != "anthropic" || statuses[1].Provider != "zai" {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	if statuses[0].State != CircuitOpen || statuses[1].State != CircuitClosed {
+		t.Errorf("unexpected states: %s, %s", statuses[0].State, statuses[1].State)
+	}
+}
+
+func TestCircuitBreakers_Counters(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCircuitBreakers(5, time.Minute)
+	c.For("zai")
+	b := c.For("anthropic")
+	b.now = func() time.Time { return now }
+	b.Record(nil)
+	b.Record(api.ErrAnthropicNetworkError)
+	b.Record(api.ErrAnthropicServerError)
+
+	counters := c.Counters()
+	if len(counters) != 2 || counters[0].Provider != "anthropic" || counters[1].Provider != "zai" {
+		t.Fatalf("unexpected counters: %+v", counters)
+	}
+	if got := counters[0]; got.Successes != 1 || got.Errors != 2 || !got.LastPollAt.Equal(now) {
+		t.Errorf("network errors should count as poll errors too, got %+v", got)
+	}
+	if got := counters[1]; got.Successes != 0 || got.Errors != 0 || !got.LastPollAt.IsZero() {
+		t.Errorf("expected no polls for zai, got %+v", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/onllm-dev/onwatch/internal/api"
@@ -33,6 +34,12 @@ type CodexAgent struct {
 	sm           *SessionManager
 	notifier     *notify.NotificationEngine
 	pollingCheck func() bool
+	startGate    *StartGate
+	firstPolled  bool
+	breaker      *CircuitBreaker
+	latest       *LatestSnapshots
+	snapshotHook *SnapshotHook
+	firehose     *Firehose
 	tokenRefresh CodexTokenRefreshFunc
 	lastToken    string
 
@@ -40,6 +47,8 @@ type CodexAgent struct {
 	authFailCount   int
 	authPaused      bool
 	lastFailedToken string
+
+	pollMu sync.Mutex // serializes scheduled and on-demand polls
 }
 
 // NewCodexAgent creates a new CodexAgent with the given dependencies.
@@ -62,6 +71,34 @@ func (a *CodexAgent) SetPollingCheck(fn func() bool) {
 	a.pollingCheck = fn
 }
 
+// SetStartGate makes the agent's first snapshot write wait its turn behind
+// other agents sharing the gate.
+func (a *CodexAgent) SetStartGate(g *StartGate) {
+	a.startGate = g
+}
+
+// SetCircuitBreaker sets the breaker that pauses polling after repeated
+// auth/server failures.
+func (a *CodexAgent) SetCircuitBreaker(b *CircuitBreaker) {
+	a.breaker = b
+}
+
+// SetLatestSnapshots sets the registry that holds the latest polled snapshot
+// and limits how often snapshots are stored.
+func (a *CodexAgent) SetLatestSnapshots(l *LatestSnapshots) {
+	a.latest = l
+}
+
+// SetSnapshotHook sets the command run after each stored snapshot.
+func (a *CodexAgent) SetSnapshotHook(h *SnapshotHook) {
+	a.snapshotHook = h
+}
+
+// SetFirehose sets the webhook firehose that receives each stored snapshot.
+func (a *CodexAgent) SetFirehose(f *Firehose) {
+	a.firehose = f
+}
+
 // SetNotifier sets notification engine for sending alerts.
 func (a *CodexAgent) SetNotifier(n *notify.NotificationEngine) {
 	a.notifier = n
@@ -98,9 +135,15 @@ func (a *CodexAgent) Run(ctx context.Context) error {
 	}
 }
 
-func (a *CodexAgent) poll(ctx context.Context) {
+func (a *CodexAgent) poll(ctx context.Context) error {
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
 	if a.pollingCheck != nil && !a.pollingCheck() {
-		return
+		return ErrPollingDisabled
+	}
+	if !a.breaker.Allow() {
+		return ErrCircuitOpen
 	}
 
 	// Refresh token before each poll (picks up rotated credentials from disk)
@@ -123,13 +166,14 @@ func (a *CodexAgent) poll(ctx context.Context) {
 
 	// If auth is paused, skip polling until credentials change.
 	if a.authPaused {
-		return
+		return ErrAuthPaused
 	}
 
+	pollStart := time.Now()
 	resp, err := a.client.FetchUsage(ctx)
 	if err != nil {
 		if ctx.Err() != nil {
-			return
+			return ctx.Err()
 		}
 
 		// On auth error, force token re-read and retry once.
@@ -143,7 +187,7 @@ func (a *CodexAgent) poll(ctx context.Context) {
 				resp, err = a.client.FetchUsage(ctx)
 				if err != nil {
 					if ctx.Err() != nil {
-						return
+						return ctx.Err()
 					}
 					if isCodexAuthError(err) {
 						a.authFailCount++
@@ -162,29 +206,40 @@ func (a *CodexAgent) poll(ctx context.Context) {
 					} else {
 						a.logger.Error("Codex retry failed with non-auth error", "error", err)
 					}
-					return
+					recordPoll(a.store, a.notifier, a.breaker, a.logger, "codex", pollStart, err)
+					return err
 				}
 				// Retry succeeded, reset auth failure count.
 				a.authFailCount = 0
 			} else {
 				a.logger.Error("No Codex token available after re-read")
-				return
+				recordPoll(a.store, a.notifier, a.breaker, a.logger, "codex", pollStart, err)
+				return err
 			}
 		} else {
 			a.logger.Error("Failed to fetch Codex usage", "error", err)
-			return
+			recordPoll(a.store, a.notifier, a.breaker, a.logger, "codex", pollStart, err)
+			return err
 		}
 	} else {
 		// Success, reset auth failure count.
 		a.authFailCount = 0
 	}
+	release := a.startGate.hold(&a.firstPolled)
+	defer release()
+	recordPoll(a.store, a.notifier, a.breaker, a.logger, "codex", pollStart, nil)
+	checkSchema(a.notifier, a.breaker, a.logger, "codex", resp.SchemaFields())
 
 	now := time.Now().UTC()
 	snapshot := resp.ToSnapshot(now)
 
-	if _, err := a.store.InsertCodexSnapshot(snapshot); err != nil {
-		a.logger.Error("Failed to insert Codex snapshot", "error", err)
-		return
+	if a.latest.Update("codex", snapshot, snapshot.CapturedAt) {
+		if _, err := a.store.InsertCodexSnapshot(snapshot); err != nil {
+			a.logger.Error("Failed to insert Codex snapshot", "error", err)
+			return err
+		}
+		a.snapshotHook.Run("codex", snapshot)
+		a.firehose.Add("codex", snapshot)
 	}
 
 	if a.tracker != nil {
@@ -193,14 +248,25 @@ func (a *CodexAgent) poll(ctx context.Context) {
 		}
 	}
 
+	// Writes done; let the next agent's first poll proceed
+	release()
+
 	if a.notifier != nil {
 		for _, q := range snapshot.Quotas {
-			a.notifier.Check(notify.QuotaStatus{
+			status := notify.QuotaStatus{
 				Provider:    "codex",
 				QuotaKey:    q.Name,
 				Utilization: q.Utilization,
 				Limit:       100,
-			})
+				ResetAt:     q.ResetsAt,
+			}
+			// Burn-rate projection drives exhaustion alerts
+			if a.tracker != nil {
+				if summary, err := a.tracker.UsageSummary(q.Name); err == nil && summary != nil {
+					status.ProjectedUtil = summary.ProjectedUtil
+				}
+			}
+			a.notifier.Check(status)
 		}
 	}
 
@@ -215,4 +281,5 @@ func (a *CodexAgent) poll(ctx context.Context) {
 	for _, q := range snapshot.Quotas {
 		a.logger.Info("Codex poll complete", "quota", q.Name, "utilization", q.Utilization, "plan", resp.PlanType)
 	}
+	return nil
 }
@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/onllm-dev/onwatch/internal/api"
@@ -21,6 +22,14 @@ type CopilotAgent struct {
 	sm           *SessionManager
 	notifier     *notify.NotificationEngine
 	pollingCheck func() bool
+	startGate    *StartGate
+	firstPolled  bool
+	breaker      *CircuitBreaker
+	latest       *LatestSnapshots
+	snapshotHook *SnapshotHook
+	firehose     *Firehose
+
+	pollMu sync.Mutex // serializes scheduled and on-demand polls
 }
 
 // SetPollingCheck sets a function that is called before each poll.
@@ -29,6 +38,34 @@ func (a *CopilotAgent) SetPollingCheck(fn func() bool) {
 	a.pollingCheck = fn
 }
 
+// SetStartGate makes the agent's first snapshot write wait its turn behind
+// other agents sharing the gate.
+func (a *CopilotAgent) SetStartGate(g *StartGate) {
+	a.startGate = g
+}
+
+// SetCircuitBreaker sets the breaker that pauses polling after repeated
+// auth/server failures.
+func (a *CopilotAgent) SetCircuitBreaker(b *CircuitBreaker) {
+	a.breaker = b
+}
+
+// SetLatestSnapshots sets the registry that holds the latest polled snapshot
+// and limits how often snapshots are stored.
+func (a *CopilotAgent) SetLatestSnapshots(l *LatestSnapshots) {
+	a.latest = l
+}
+
+// SetSnapshotHook sets the command run after each stored snapshot.
+func (a *CopilotAgent) SetSnapshotHook(h *SnapshotHook) {
+	a.snapshotHook = h
+}
+
+// SetFirehose sets the webhook firehose that receives each stored snapshot.
+func (a *CopilotAgent) SetFirehose(f *Firehose) {
+	a.firehose = f
+}
+
 // SetNotifier sets the notification engine for sending alerts.
 func (a *CopilotAgent) SetNotifier(n *notify.NotificationEngine) {
 	a.notifier = n
@@ -78,27 +115,44 @@ func (a *CopilotAgent) Run(ctx context.Context) error {
 }
 
 // poll performs a single poll cycle: fetch quotas, store snapshot, update tracker.
-func (a *CopilotAgent) poll(ctx context.Context) {
+func (a *CopilotAgent) poll(ctx context.Context) error {
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
 	if a.pollingCheck != nil && !a.pollingCheck() {
-		return
+		return ErrPollingDisabled
+	}
+	if !a.breaker.Allow() {
+		return ErrCircuitOpen
 	}
 
+	pollStart := time.Now()
 	resp, err := a.client.FetchQuotas(ctx)
 	if err != nil {
 		if ctx.Err() != nil {
-			return
+			return ctx.Err()
 		}
 		a.logger.Error("Failed to fetch Copilot quotas", "error", err)
-		return
+		recordPoll(a.store, a.notifier, a.breaker, a.logger, "copilot", pollStart, err)
+		return err
 	}
+	release := a.startGate.hold(&a.firstPolled)
+	defer release()
+	recordPoll(a.store, a.notifier, a.breaker, a.logger, "copilot", pollStart, nil)
+	checkSchema(a.notifier, a.breaker, a.logger, "copilot", resp.SchemaFields())
 
 	// Convert API response to snapshot
 	now := time.Now().UTC()
 	snapshot := resp.ToSnapshot(now)
 
 	// Store snapshot
-	if _, err := a.store.InsertCopilotSnapshot(snapshot); err != nil {
-		a.logger.Error("Failed to insert Copilot snapshot", "error", err)
+	if a.latest.Update("copilot", snapshot, snapshot.CapturedAt) {
+		if _, err := a.store.InsertCopilotSnapshot(snapshot); err != nil {
+			a.logger.Error("Failed to insert Copilot snapshot", "error", err)
+		} else {
+			a.snapshotHook.Run("copilot", snapshot)
+			a.firehose.Add("copilot", snapshot)
+		}
 	}
 
 	// Process with tracker
@@ -106,6 +160,9 @@ func (a *CopilotAgent) poll(ctx context.Context) {
 		a.logger.Error("Copilot tracker processing failed", "error", err)
 	}
 
+	// Writes done; let the next agent's first poll proceed
+	release()
+
 	// Check notification thresholds for non-unlimited quotas
 	if a.notifier != nil {
 		for _, q := range snapshot.Quotas {
@@ -114,12 +171,18 @@ func (a *CopilotAgent) poll(ctx context.Context) {
 			}
 			used := q.Entitlement - q.Remaining
 			utilization := float64(used) / float64(q.Entitlement) * 100
-			a.notifier.Check(notify.QuotaStatus{
+			status := notify.QuotaStatus{
 				Provider:    "copilot",
 				QuotaKey:    q.Name,
 				Utilization: utilization,
 				Limit:       float64(q.Entitlement),
-			})
+				ResetAt:     snapshot.ResetDate,
+			}
+			// Burn-rate projection drives exhaustion alerts
+			if summary, err := a.tracker.UsageSummary(q.Name); err == nil && summary != nil {
+				status.ProjectedUtil = float64(summary.ProjectedUsage) / float64(q.Entitlement) * 100
+			}
+			a.notifier.Check(status)
 		}
 	}
 
@@ -143,4 +206,5 @@ func (a *CopilotAgent) poll(ctx context.Context) {
 			)
 		}
 	}
+	return nil
 }
@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/notify"
+)
+
+// FirehoseFlushInterval is how often the firehose posts the snapshots stored
+// since its last delivery.
+const FirehoseFlushInterval = time.Minute
+
+// maxFirehosePending bounds the snapshots held for delivery. While the
+// webhook is failing, the oldest are dropped beyond this.
+const maxFirehosePending = 1000
+
+// maxFirehoseBackoff caps how many flush intervals the firehose waits between
+// attempts while the webhook keeps failing.
+const maxFirehoseBackoff = 32
+
+// FirehoseConfig is the firehose's current configuration.
+type FirehoseConfig struct {
+	Enabled   bool
+	URL       string
+	Providers []string // providers to send; empty sends every provider
+	Secret    string   // signs each batch with HMAC-SHA256 when set
+}
+
+func (c FirehoseConfig) sends(provider string) bool {
+	if !c.Enabled || c.URL == "" {
+		return false
+	}
+	return len(c.Providers) == 0 || slices.Contains(c.Providers, provider)
+}
+
+// FirehoseSnapshot is one stored snapshot in a firehose batch.
+type FirehoseSnapshot struct {
+	Provider string `json:"provider"`
+	Snapshot any    `json:"snapshot"`
+}
+
+// FirehoseBatch is the firehose webhook payload.
+type FirehoseBatch struct {
+	Event     string             `json:"event"` // always "snapshots"
+	SentAt    time.Time          `json:"sent_at"`
+	Dropped   int                `json:"dropped,omitempty"` // snapshots lost while deliveries were failing
+	Snapshots []FirehoseSnapshot `json:"snapshots"`
+}
+
+// Firehose posts every stored snapshot to a webhook, for users keeping their
+// own copy of the data. Snapshots are batched and sent once per flush
+// interval rather than one request per snapshot; a failed batch is kept and
+// retried with the next one, backing off while the webhook keeps failing.
+// The configuration is read on every snapshot, so changes apply without a
+// restart.
+type Firehose struct {
+	config   func() FirehoseConfig
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu           sync.Mutex
+	pending      []FirehoseSnapshot
+	dropped      int
+	sender       *notify.WebhookSender
+	senderURL    string
+	senderSecret string
+}
+
+// NewFirehose creates a firehose that reads its configuration from config and
+// delivers every interval once Run is started.
+func NewFirehose(config func() FirehoseConfig, interval time.Duration, logger *slog.Logger) *Firehose {
+	if interval <= 0 {
+		interval = FirehoseFlushInterval
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Firehose{config: config, interval: interval, logger: logger}
+}
+
+// Add queues provider's stored snapshot for the next delivery, if the
+// firehose is enabled for provider. A nil firehose does nothing.
+func (f *Firehose) Add(provider string, snapshot any) {
+	if f == nil || !f.config().sends(provider) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = append(f.pending, FirehoseSnapshot{Provider: provider, Snapshot: snapshot})
+	f.trim()
+}
+
+// trim drops the oldest pending snapshots beyond maxFirehosePending.
+// Requires f.mu.
+func (f *Firehose) trim() {
+	if over := len(f.pending) - maxFirehosePending; over > 0 {
+		f.pending = slices.Delete(f.pending, 0, over)
+		f.dropped += over
+	}
+}
+
+// Flush posts the pending snapshots as one batch. On failure they are kept
+// for the next flush. Pending snapshots are discarded if the firehose has
+// since been disabled.
+func (f *Firehose) Flush() error {
+	cfg := f.config()
+
+	f.mu.Lock()
+	batch, dropped := f.pending, f.dropped
+	f.pending, f.dropped = nil, 0
+	if len(batch) == 0 || !cfg.Enabled || cfg.URL == "" {
+		f.mu.Unlock()
+		return nil
+	}
+	if f.sender == nil || f.senderURL != cfg.URL || f.senderSecret != cfg.Secret {
+		f.sender = notify.NewSignedWebhookSender(cfg.URL, cfg.Secret)
+		f.senderURL, f.senderSecret = cfg.URL, cfg.Secret
+	}
+	sender := f.sender
+	f.mu.Unlock()
+
+	err := sender.Send(FirehoseBatch{
+		Event:     "snapshots",
+		SentAt:    time.Now().UTC(),
+		Dropped:   dropped,
+		Snapshots: batch,
+	})
+	if err != nil {
+		f.mu.Lock()
+		f.pending = append(batch, f.pending...)
+		f.dropped += dropped
+		f.trim()
+		f.mu.Unlock()
+		return err
+	}
+	f.logger.Debug("Firehose batch sent", "snapshots", len(batch))
+	return nil
+}
+
+// Run delivers pending snapshots every flush interval until ctx is
+// cancelled, then makes a final delivery. After a failed delivery it waits
+// firehoseBackoff intervals before trying again.
+func (f *Firehose) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	failures, skip := 0, 0
+	for {
+		select {
+		case <-ctx.Done():
+			if err := f.Flush(); err != nil {
+				f.logger.Error("Firehose delivery failed", "error", err)
+			}
+			return
+		case <-ticker.C:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if err := f.Flush(); err != nil {
+				failures++
+				wait := firehoseBackoff(failures)
+				skip = wait - 1
+				f.logger.Warn("Firehose delivery failed, retrying with the next batch",
+					"error", err, "retry_in", f.interval*time.Duration(wait))
+				continue
+			}
+			failures = 0
+		}
+	}
+}
+
+// firehoseBackoff returns how many flush intervals to wait after failures
+// consecutive failed deliveries: one after the first, then doubling up to
+// maxFirehoseBackoff.
+func firehoseBackoff(failures int) int {
+	wait := 1
+	for i := 1; i < failures && wait < maxFirehoseBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxFirehoseBackoff)
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/onllm-dev/onwatch/internal/notify"
+)
+
+func TestFirehose_BatchesAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	var batches []FirehoseBatch
+	failing := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(notify.SignatureHeader); got != notify.Signature("s3cret", body) {
+			t.Errorf("unexpected signature %q", got)
+		}
+		var b FirehoseBatch
+		json.Unmarshal(body, &b)
+		batches = append(batches, b)
+	}))
+	defer srv.Close()
+
+	cfg := FirehoseConfig{Enabled: true, URL: srv.URL, Providers: []string{"zai", "codex"}, Secret: "s3cret"}
+	f := NewFirehose(func() FirehoseConfig { return cfg }, 0, nil)
+
+	f.Add("zai", map[string]int{"usage": 1})
+	f.Add("anthropic", map[string]int{"usage": 2}) // not selected
+	f.Add("codex", map[string]int{"usage": 3})
+
+	if err := f.Flush(); err == nil {
+		t.Fatal("expected an error from the failing webhook")
+	}
+	f.Add("zai", map[string]int{"usage": 4})
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatalf("empty Flush: %v", err)
+	}
+
+	if len(batches) != 1 {
+		t.Fatalf("expected one batch, got %d", len(batches))
+	}
+	b := batches[0]
+	if b.Event != "snapshots" || len(b.Snapshots) != 3 {
+		t.Fatalf("expected 3 snapshots in a \"snapshots\" batch, got %+v", b)
+	}
+	for i, want := range []string{"zai", "codex", "zai"} {
+		if b.Snapshots[i].Provider != want {
+			t.Errorf("snapshot %d: provider %q, want %q", i, b.Snapshots[i].Provider, want)
+		}
+	}
+
+	// Disabled: nothing is queued or sent
+	cfg.Enabled = false
+	f.Add("zai", map[string]int{"usage": 5})
+	if err := f.Flush(); err != nil || len(batches) != 1 {
+		t.Errorf("disabled firehose sent a batch: err=%v batches=%d", err, len(batches))
+	}
+
+	var nilFirehose *Firehose
+	nilFirehose.Add("zai", nil)
+}
+
+func TestFirehose_DropsOldestBeyondLimit(t *testing.T) {
+	cfg := FirehoseConfig{Enabled: true, URL: "http://127.0.0.1:0"}
+	f := NewFirehose(func() FirehoseConfig { return cfg }, 0, nil)
+	for i := 0; i < maxFirehosePending+5; i++ {
+		f.Add("zai", i)
+	}
+	if len(f.pending) != maxFirehosePending || f.dropped != 5 {
+		t.Fatalf("expected %d pending and 5 dropped, got %d and %d", maxFirehosePending, len(f.pending), f.dropped)
+	}
+	if first := f.pending[0].Snapshot; first != 5 {
+		t.Errorf("expected the oldest snapshots dropped, first pending is %v", first)
+	}
+}
+
+func TestFirehoseBackoff(t *testing.T) {
+	for failures, want := range map[int]int{1: 1, 2: 2, 3: 4, 6: 32, 7: maxFirehoseBackoff, 100: maxFirehoseBackoff} {
+		if got := firehoseBackoff(failures); got != want {
+			t.Errorf("firehoseBackoff(%d) = %d, want %d", failures, got, want)
+		}
+	}
+}
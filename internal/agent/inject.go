@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+// ErrInvalidInjection is returned when an injected payload cannot be parsed
+// or contains no quotas.
+var ErrInvalidInjection = errors.New("agent: invalid injected payload")
+
+// The InjectSnapshot methods feed a provider API response (in the same JSON
+// format the provider returns) through the agent as if it had just been
+// polled: the snapshot is stored and checked against notification
+// thresholds. The stored row is flagged as injected, and each injection is
+// also recorded with its payload, so fake readings can be told apart from
+// real ones. Injected readings skip the tracker, so they never open, close or
+// count towards reset cycles, and session tracking is skipped too.
+// Injections hold the agent's poll lock, so they never interleave with a
+// scheduled or on-demand poll.
+
+// InjectSnapshot injects a Synthetic /v2/quotas response.
+func (a *Agent) InjectSnapshot(data []byte) (time.Time, error) {
+	var resp api.QuotaResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidInjection, err)
+	}
+	if resp.Subscription.Limit <= 0 && resp.Search.Hourly.Limit <= 0 && resp.ToolCallDiscounts.Limit <= 0 {
+		return time.Time{}, fmt.Errorf("%w: no quotas", ErrInvalidInjection)
+	}
+
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
+	snapshot := resp.ToSnapshot(time.Now().UTC())
+	snapshot.Injected = true
+	if _, err := a.store.InsertSnapshot(snapshot); err != nil {
+		return time.Time{}, err
+	}
+	if err := a.store.RecordInjectedSnapshot("synthetic", snapshot.CapturedAt, string(data)); err != nil {
+		return time.Time{}, err
+	}
+	a.logger.Warn("Injected debug snapshot", "provider", "synthetic", "capturedAt", snapshot.CapturedAt)
+
+	a.checkThresholds(snapshot)
+	return snapshot.CapturedAt, nil
+}
+
+// InjectSnapshot injects a Z.ai /monitor/usage/quota/limit response.
+func (a *ZaiAgent) InjectSnapshot(data []byte) (time.Time, error) {
+	resp, err := api.ParseZaiResponse(data)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidInjection, err)
+	}
+	if len(resp.Limits) == 0 {
+		return time.Time{}, fmt.Errorf("%w: no quotas", ErrInvalidInjection)
+	}
+
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
+	snapshot := resp.ToSnapshot(time.Now().UTC())
+	snapshot.Injected = true
+	if _, err := a.store.InsertZaiSnapshot(snapshot); err != nil {
+		return time.Time{}, err
+	}
+	if err := a.store.RecordInjectedSnapshot("zai", snapshot.CapturedAt, string(data)); err != nil {
+		return time.Time{}, err
+	}
+	a.logger.Warn("Injected debug snapshot", "provider", "zai", "capturedAt", snapshot.CapturedAt)
+
+	a.checkThresholds(snapshot)
+	return snapshot.CapturedAt, nil
+}
+
+// InjectSnapshot injects an Anthropic /api/oauth/usage response.
+func (a *AnthropicAgent) InjectSnapshot(data []byte) (time.Time, error) {
+	resp, err := api.ParseAnthropicResponse(data)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidInjection, err)
+	}
+	if len(resp.ActiveQuotaNames()) == 0 {
+		return time.Time{}, fmt.Errorf("%w: no quotas", ErrInvalidInjection)
+	}
+
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
+	snapshot := resp.ToSnapshot(time.Now().UTC())
+	snapshot.Injected = true
+	if _, err := a.store.InsertAnthropicSnapshot(snapshot); err != nil {
+		return time.Time{}, err
+	}
+	if err := a.store.RecordInjectedSnapshot("anthropic", snapshot.CapturedAt, string(data)); err != nil {
+		return time.Time{}, err
+	}
+	a.logger.Warn("Injected debug snapshot", "provider", "anthropic", "capturedAt", snapshot.CapturedAt)
+
+	a.checkThresholds(snapshot)
+	return snapshot.CapturedAt, nil
+}
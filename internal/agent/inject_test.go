@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/store"
+	"github.com/onllm-dev/onwatch/internal/tracker"
+)
+
+func TestAgent_InjectSnapshot(t *testing.T) {
+	str, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer str.Close()
+
+	logger := slog.New(slog.DiscardHandler)
+	ag := New(nil, str, tracker.New(str, logger), time.Minute, logger, nil)
+
+	payload, _ := json.Marshal(testResponse())
+	capturedAt, err := ag.InjectSnapshot(payload)
+	if err != nil {
+		t.Fatalf("InjectSnapshot: %v", err)
+	}
+
+	latest, err := str.QueryLatest()
+	if err != nil || latest == nil {
+		t.Fatalf("expected injected snapshot to be stored, got %v, %v", latest, err)
+	}
+	if latest.Sub.Requests != 100 || !latest.CapturedAt.Equal(capturedAt) {
+		t.Errorf("stored snapshot = %+v, want injected values at %v", latest.Sub, capturedAt)
+	}
+
+	injected, err := str.QueryInjectedSnapshots("synthetic", 10)
+	if err != nil || len(injected) != 1 || !injected[0].CapturedAt.Equal(capturedAt) {
+		t.Fatalf("expected injection to be recorded, got %+v, %v", injected, err)
+	}
+
+	if !latest.Injected {
+		t.Error("expected the stored snapshot to be flagged as injected")
+	}
+	if rows, _ := str.QueryRange(capturedAt.Add(-time.Second), capturedAt.Add(time.Second)); len(rows) != 1 || !rows[0].Injected {
+		t.Errorf("expected history rows to carry the injected flag, got %+v", rows)
+	}
+
+	// Fake readings stay out of reset cycles
+	if cycle, err := str.QueryActiveCycle("subscription"); err != nil || cycle != nil {
+		t.Errorf("expected no cycle from an injected reading, got %v, %v", cycle, err)
+	}
+}
+
+func TestAnthropicAgent_InjectSnapshot_RejectsEmptyPayload(t *testing.T) {
+	str, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer str.Close()
+
+	ag := NewAnthropicAgent(nil, str, nil, time.Minute, slog.New(slog.DiscardHandler), nil)
+	for _, payload := range []string{`not json`, `{"five_hour":null}`} {
+		if _, err := ag.InjectSnapshot([]byte(payload)); !errors.Is(err, ErrInvalidInjection) {
+			t.Errorf("InjectSnapshot(%s) error = %v, want ErrInvalidInjection", payload, err)
+		}
+	}
+	if injected, _ := str.QueryInjectedSnapshots("", 10); len(injected) != 0 {
+		t.Errorf("expected nothing recorded, got %+v", injected)
+	}
+}
+
+func TestAgent_InjectSnapshot_DuringPolls(t *testing.T) {
+	ag, str, _, _ := setupTest(t)
+	payload, _ := json.Marshal(testResponse())
+
+	// Run with -race: injections share the tracker and notifier with polls
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 5 {
+			ag.poll(context.Background())
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 5 {
+			if _, err := ag.InjectSnapshot(payload); err != nil {
+				t.Errorf("InjectSnapshot: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	if injected, _ := str.QueryInjectedSnapshots("synthetic", 10); len(injected) != 5 {
+		t.Errorf("expected 5 injections recorded, got %d", len(injected))
+	}
+}
@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"sync"
+	"time"
+)
+
+// LatestSnapshots keeps each provider's most recently polled snapshot in
+// memory and decides which polls are persisted, so agents can fetch every poll
+// interval (keeping reset detection and alerts timely) while writing a
+// snapshot only every store interval.
+type LatestSnapshots struct {
+	storeInterval time.Duration
+
+	mu          sync.Mutex
+	entries     map[string]latestEntry
+	subscribers map[chan string]struct{}
+}
+
+type latestEntry struct {
+	snapshot any
+	storedAt time.Time
+}
+
+// NewLatestSnapshots creates a registry that persists at most one snapshot per
+// provider every storeInterval. Zero or less stores every poll.
+func NewLatestSnapshots(storeInterval time.Duration) *LatestSnapshots {
+	return &LatestSnapshots{
+		storeInterval: storeInterval,
+		entries:       make(map[string]latestEntry),
+		subscribers:   make(map[chan string]struct{}),
+	}
+}
+
+// Update records snapshot as provider's latest and reports whether it is due
+// to be stored. The first snapshot is always due. A nil registry stores every
+// snapshot.
+func (l *LatestSnapshots) Update(provider string, snapshot any, capturedAt time.Time) (store bool) {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.entries[provider]
+	e.snapshot = snapshot
+	if l.storeInterval <= 0 || e.storedAt.IsZero() || capturedAt.Sub(e.storedAt) >= l.storeInterval {
+		e.storedAt = capturedAt
+		store = true
+	}
+	l.entries[provider] = e
+	for ch := range l.subscribers {
+		select {
+		case ch <- provider:
+		default: // subscriber is behind; it reads the latest snapshot anyway
+		}
+	}
+	return store
+}
+
+// Subscribe returns a channel that receives a provider's name after each of
+// its polls, and a function that ends the subscription. Notifications are
+// dropped while the channel's buffer is full, so a slow subscriber should
+// read the snapshot with Get rather than count notifications.
+func (l *LatestSnapshots) Subscribe() (<-chan string, func()) {
+	ch := make(chan string, 16)
+	if l == nil {
+		return ch, func() {}
+	}
+	l.mu.Lock()
+	l.subscribers[ch] = struct{}{}
+	l.mu.Unlock()
+	return ch, func() {
+		l.mu.Lock()
+		delete(l.subscribers, ch)
+		l.mu.Unlock()
+	}
+}
+
+// Get returns provider's most recently polled snapshot, or nil if it has not
+// been polled since the daemon started.
+func (l *LatestSnapshots) Get(provider string) any {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.entries[provider].snapshot
+}
+
+// Forget drops provider's in-memory snapshot, e.g. after its stored data was
+// cleared. The next poll is stored regardless of the store interval.
+func (l *LatestSnapshots) Forget(provider string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, provider)
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestSnapshots_StoreInterval(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLatestSnapshots(5 * time.Minute)
+
+	steps := []struct {
+		offset time.Duration
+		store  bool
+	}{
+		{0, true}, // first poll is always stored
+		{time.Minute, false},
+		{4 * time.Minute, false},
+		{5 * time.Minute, true},
+		{9 * time.Minute, false},
+		{10 * time.Minute, true},
+	}
+	for _, s := range steps {
+		snap := s.offset.String()
+		if got := l.Update("zai", snap, base.Add(s.offset)); got != s.store {
+			t.Errorf("Update at +%v = %v, want %v", s.offset, got, s.store)
+		}
+		if l.Get("zai") != snap {
+			t.Errorf("Get after +%v = %v, want the latest polled snapshot", s.offset, l.Get("zai"))
+		}
+	}
+	if l.Get("anthropic") != nil {
+		t.Error("expected nil for a provider that was never polled")
+	}
+
+	var unset *LatestSnapshots
+	if !unset.Update("zai", "x", base) || unset.Get("zai") != nil {
+		t.Error("nil registry should store every snapshot and hold nothing")
+	}
+	if every := NewLatestSnapshots(0); !every.Update("zai", "a", base) || !every.Update("zai", "b", base) {
+		t.Error("zero store interval should store every snapshot")
+	}
+}
+
+func TestLatestSnapshots_Subscribe(t *testing.T) {
+	l := NewLatestSnapshots(0)
+	ch, unsubscribe := l.Subscribe()
+
+	l.Update("zai", "a", time.Now())
+	select {
+	case p := <-ch:
+		if p != "zai" {
+			t.Errorf("notification = %q, want zai", p)
+		}
+	default:
+		t.Fatal("expected a notification after Update")
+	}
+
+	unsubscribe()
+	l.Update("zai", "b", time.Now())
+	select {
+	case p := <-ch:
+		t.Errorf("unexpected notification %q after unsubscribe", p)
+	default:
+	}
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reasons an on-demand poll did not reach the provider.
+var (
+	ErrPollingDisabled = errors.New("agent: polling disabled for this provider")
+	ErrCircuitOpen     = errors.New("agent: circuit open after repeated failures")
+	ErrAuthPaused      = errors.New("agent: polling paused after repeated auth failures")
+)
+
+// Poller is an agent that can be polled on demand, outside its schedule.
+type Poller interface {
+	PollNow(ctx context.Context) error
+}
+
+// PollNow runs one poll cycle immediately and returns its error. It waits
+// for a scheduled poll that is already running.
+func (a *Agent) PollNow(ctx context.Context) error { return a.poll(ctx) }
+
+// PollNow runs one poll cycle immediately and returns its error.
+func (a *ZaiAgent) PollNow(ctx context.Context) error { return a.poll(ctx) }
+
+// PollNow runs one poll cycle immediately and returns its error.
+func (a *AnthropicAgent) PollNow(ctx context.Context) error { return a.poll(ctx) }
+
+// PollNow runs one poll cycle immediately and returns its error.
+func (a *CopilotAgent) PollNow(ctx context.Context) error { return a.poll(ctx) }
+
+// PollNow runs one poll cycle immediately and returns its error.
+func (a *CodexAgent) PollNow(ctx context.Context) error { return a.poll(ctx) }
+
+// PollNow runs one poll cycle immediately and returns its error.
+func (a *AntigravityAgent) PollNow(ctx context.Context) error { return a.poll(ctx) }
+
+// PollResult is the outcome of one provider's on-demand poll.
+type PollResult struct {
+	Provider   string `json:"provider"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// PollAll polls every poller concurrently, at most limit at a time (no limit
+// if limit <= 0), and returns one result per provider sorted by name. All
+// polls share timeout, so the call takes about as long as the slowest
+// provider rather than the sum of them. A provider still waiting for a slot
+// when the deadline passes reports the context error.
+func PollAll(ctx context.Context, pollers map[string]Poller, limit int, timeout time.Duration) []PollResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if limit <= 0 {
+		limit = len(pollers)
+	}
+
+	sem := make(chan struct{}, max(limit, 1))
+	results := make([]PollResult, 0, len(pollers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for provider, p := range pollers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := PollResult{Provider: provider}
+			start := time.Now()
+			select {
+			case sem <- struct{}{}:
+				err := p.PollNow(ctx)
+				<-sem
+				if err == nil {
+					res.Success = true
+				} else {
+					res.Error = err.Error()
+				}
+			case <-ctx.Done():
+				res.Error = ctx.Err().Error()
+			}
+			res.DurationMs = time.Since(start).Milliseconds()
+
+			mu.Lock()
+			results = append(results, res)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Provider < results[j].Provider })
+	return results
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+	"github.com/onllm-dev/onwatch/internal/store"
+	"github.com/onllm-dev/onwatch/internal/tracker"
+)
+
+type fakePoller struct {
+	delay   time.Duration
+	err     error
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (f fakePoller) PollNow(ctx context.Context) error {
+	if f.running != nil {
+		n := f.running.Add(1)
+		defer f.running.Add(-1)
+		for {
+			p := f.peak.Load()
+			if n <= p || f.peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+	}
+	select {
+	case <-time.After(f.delay):
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestPollAll_ParallelWithPerProviderErrors(t *testing.T) {
+	pollers := map[string]Poller{
+		"zai":       fakePoller{delay: 150 * time.Millisecond},
+		"anthropic": fakePoller{delay: 150 * time.Millisecond, err: errors.New("boom")},
+		"synthetic": fakePoller{delay: 150 * time.Millisecond},
+	}
+
+	start := time.Now()
+	results := PollAll(context.Background(), pollers, 0, time.Second)
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("PollAll took %v; providers should be polled in parallel", elapsed)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	want := []struct {
+		provider string
+		success  bool
+	}{{"anthropic", false}, {"synthetic", true}, {"zai", true}}
+	for i, w := range want {
+		if results[i].Provider != w.provider || results[i].Success != w.success {
+			t.Errorf("result %d = %+v, want provider %s success %v", i, results[i], w.provider, w.success)
+		}
+	}
+	if results[0].Error != "boom" {
+		t.Errorf("anthropic error = %q, want boom", results[0].Error)
+	}
+}
+
+func TestPollAll_LimitAndDeadline(t *testing.T) {
+	var running, peak atomic.Int32
+	pollers := map[string]Poller{}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		pollers[name] = fakePoller{delay: 50 * time.Millisecond, running: &running, peak: &peak}
+	}
+	PollAll(context.Background(), pollers, 2, time.Second)
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", got)
+	}
+
+	slow := map[string]Poller{"slow": fakePoller{delay: time.Second}}
+	results := PollAll(context.Background(), slow, 1, 50*time.Millisecond)
+	if results[0].Success || results[0].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected the shared deadline to cut the poll short, got %+v", results[0])
+	}
+}
+
+func TestAgent_PollNow_Skipped(t *testing.T) {
+	str, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer str.Close()
+
+	logger := slog.New(slog.DiscardHandler)
+	ag := New(nil, str, tracker.New(str, logger), time.Minute, logger, nil)
+
+	ag.SetPollingCheck(func() bool { return false })
+	if err := ag.PollNow(context.Background()); !errors.Is(err, ErrPollingDisabled) {
+		t.Errorf("PollNow with polling disabled = %v, want ErrPollingDisabled", err)
+	}
+
+	ag.SetPollingCheck(nil)
+	breaker := NewCircuitBreaker("synthetic", 1, time.Hour)
+	breaker.Record(api.ErrUnauthorized)
+	ag.SetCircuitBreaker(breaker)
+	if err := ag.PollNow(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("PollNow with an open circuit = %v, want ErrCircuitOpen", err)
+	}
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+	"github.com/onllm-dev/onwatch/internal/notify"
+)
+
+// schemaChangePolls is how many consecutive polls a previously populated
+// response field must be missing before a possible schema change is flagged.
+const schemaChangePolls = 3
+
+// schemaWatch tracks which expected response fields a provider populates.
+// Only fields seen populated at least once are watched, so fields a plan
+// never uses don't raise warnings. Guarded by the owning breaker's mutex.
+type schemaWatch struct {
+	seen    map[string]bool
+	misses  map[string]int
+	flagged []string // fields missing for schemaChangePolls polls, sorted
+}
+
+// observe records one response's fields and returns the fields that were
+// flagged by this poll, nil when nothing new crossed the threshold.
+func (w *schemaWatch) observe(fields api.SchemaFields) []string {
+	if w.seen == nil {
+		w.seen = make(map[string]bool)
+		w.misses = make(map[string]int)
+	}
+	var newly []string
+	for name, ok := range fields {
+		if ok {
+			w.seen[name] = true
+			delete(w.misses, name)
+			continue
+		}
+		if !w.seen[name] {
+			continue
+		}
+		w.misses[name]++
+		if w.misses[name] == schemaChangePolls {
+			newly = append(newly, name)
+		}
+	}
+
+	w.flagged = w.flagged[:0]
+	for name, n := range w.misses {
+		if n >= schemaChangePolls {
+			w.flagged = append(w.flagged, name)
+		}
+	}
+	sort.Strings(w.flagged)
+	sort.Strings(newly)
+	return newly
+}
+
+// warning describes the flagged fields for agent-status, empty when none are.
+func (w *schemaWatch) warning() string {
+	if len(w.flagged) == 0 {
+		return ""
+	}
+	return "possible provider schema change: missing " + strings.Join(w.flagged, ", ")
+}
+
+// ObserveSchema records which expected fields a successfully parsed response
+// carried and returns the previously populated fields that have now been
+// missing for several consecutive polls. Each field is reported once until
+// it comes back. A nil breaker does nothing.
+func (b *CircuitBreaker) ObserveSchema(fields api.SchemaFields) []string {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.schema.observe(fields)
+}
+
+// checkSchema feeds a parsed response's fields to the provider's breaker and,
+// when previously populated fields have gone missing, logs a warning and sends
+// a "schema" alert.
+func checkSchema(n *notify.NotificationEngine, b *CircuitBreaker, logger *slog.Logger, provider string, fields api.SchemaFields) {
+	missing := b.ObserveSchema(fields)
+	if len(missing) == 0 {
+		return
+	}
+	logger.Warn("Possible provider schema change: expected fields missing from responses",
+		"provider", provider, "fields", missing, "polls", schemaChangePolls)
+	if n != nil {
+		n.NotifySchemaChange(provider, missing)
+	}
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+func TestCircuitBreaker_ObserveSchema(t *testing.T) {
+	b := NewCircuitBreaker("copilot", 10, 15*time.Minute)
+	ok := api.SchemaFields{"quota_snapshots": true, "quota_reset_date_utc": false}
+	broken := api.SchemaFields{"quota_snapshots": false, "quota_reset_date_utc": false}
+
+	// A field never populated is not watched
+	for i := 0; i < schemaChangePolls+1; i++ {
+		if got := b.ObserveSchema(ok); got != nil {
+			t.Fatalf("poll %d: unexpected flag %v", i, got)
+		}
+	}
+
+	for i := 1; i < schemaChangePolls; i++ {
+		if got := b.ObserveSchema(broken); got != nil {
+			t.Fatalf("flagged after %d misses: %v", i, got)
+		}
+	}
+	if got := b.ObserveSchema(broken); !slices.Equal(got, []string{"quota_snapshots"}) {
+		t.Fatalf("expected quota_snapshots flagged, got %v", got)
+	}
+	if got := b.ObserveSchema(broken); got != nil {
+		t.Errorf("field reported twice: %v", got)
+	}
+	if w := b.Status().SchemaWarning; w != "possible provider schema change: missing quota_snapshots" {
+		t.Errorf("unexpected warning %q", w)
+	}
+
+	// The field coming back clears the warning
+	b.ObserveSchema(ok)
+	if w := b.Status().SchemaWarning; w != "" {
+		t.Errorf("expected warning cleared, got %q", w)
+	}
+
+	var nilBreaker *CircuitBreaker
+	if got := nilBreaker.ObserveSchema(broken); got != nil {
+		t.Errorf("nil breaker: got %v", got)
+	}
+}
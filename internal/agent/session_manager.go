@@ -2,6 +2,7 @@ package agent
 
 import (
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,7 +15,7 @@ import (
 type SessionManager struct {
 	store       *store.Store
 	provider    string
-	idleTimeout time.Duration
+	idleTimeout atomic.Int64 // nanoseconds; changed at runtime via SetIdleTimeout
 	logger      *slog.Logger
 
 	sessionID        string    // empty = no active session
@@ -28,12 +29,24 @@ func NewSessionManager(store *store.Store, provider string, idleTimeout time.Dur
 	if logger == nil {
 		logger = slog.Default()
 	}
-	return &SessionManager{
-		store:       store,
-		provider:    provider,
-		idleTimeout: idleTimeout,
-		logger:      logger,
+	sm := &SessionManager{
+		store:    store,
+		provider: provider,
+		logger:   logger,
 	}
+	sm.idleTimeout.Store(int64(idleTimeout))
+	return sm
+}
+
+// SetIdleTimeout changes how long usage must stay unchanged before the active
+// session closes. It is safe to call while the agent is polling.
+func (sm *SessionManager) SetIdleTimeout(d time.Duration) {
+	sm.idleTimeout.Store(int64(d))
+}
+
+// IdleTimeout returns the current idle timeout.
+func (sm *SessionManager) IdleTimeout() time.Duration {
+	return time.Duration(sm.idleTimeout.Load())
 }
 
 // ReportPoll is called after each successful poll with current usage values.
@@ -88,7 +101,7 @@ func (sm *SessionManager) ReportPoll(values []float64) bool {
 
 	// No usage change
 	if sm.sessionID != "" {
-		if now.Sub(sm.lastActivityTime) > sm.idleTimeout {
+		if now.Sub(sm.lastActivityTime) > sm.IdleTimeout() {
 			// Idle timeout exceeded → close session
 			sm.closeSession(now)
 		} else {
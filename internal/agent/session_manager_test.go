@@ -88,6 +88,25 @@ func TestSessionManager_ClosesSessionAfterIdleTimeout(t *testing.T) {
 	}
 }
 
+func TestSessionManager_SetIdleTimeoutAppliesLive(t *testing.T) {
+	sm, str := newTestSessionManager(t, 10*time.Second)
+
+	sm.ReportPoll([]float64{100, 50, 500})
+	sm.ReportPoll([]float64{110, 50, 500}) // change → session starts
+
+	sm.SetIdleTimeout(50 * time.Millisecond)
+	if sm.IdleTimeout() != 50*time.Millisecond {
+		t.Fatalf("IdleTimeout() = %v, want 50ms", sm.IdleTimeout())
+	}
+	time.Sleep(100 * time.Millisecond)
+	sm.ReportPoll([]float64{110, 50, 500})
+
+	sessions, _ := str.QuerySessionHistory("synthetic")
+	if len(sessions) != 1 || sessions[0].EndedAt == nil {
+		t.Fatalf("Expected the session to close under the shortened timeout, got %+v", sessions)
+	}
+}
+
 func TestSessionManager_NewSessionAfterIdleGap(t *testing.T) {
 	sm, str := newTestSessionManager(t, 100*time.Millisecond)
 
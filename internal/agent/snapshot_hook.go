@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSnapshotHookTimeout bounds a snapshot hook run when no timeout is
+// configured.
+const DefaultSnapshotHookTimeout = 10 * time.Second
+
+// maxHookOutput is how much of a hook's output is logged.
+const maxHookOutput = 1024
+
+// SnapshotHook runs a local command after each stored snapshot, with
+// {"provider": ..., "snapshot": ...} as JSON on stdin and ONWATCH_PROVIDER set
+// in its environment. Runs happen in the background so a slow command never
+// delays polling; while one is still running, later snapshots of the same
+// provider are skipped. One hook is shared by every agent, so each provider
+// has its own run in flight.
+type SnapshotHook struct {
+	command string
+	timeout time.Duration
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	running map[string]bool // provider -> a run is in flight
+}
+
+// NewSnapshotHook creates a hook that runs command through the system shell,
+// or returns nil (a no-op hook) when command is empty.
+func NewSnapshotHook(command string, timeout time.Duration, logger *slog.Logger) *SnapshotHook {
+	if strings.TrimSpace(command) == "" {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultSnapshotHookTimeout
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SnapshotHook{command: command, timeout: timeout, logger: logger, running: map[string]bool{}}
+}
+
+// start marks a run for provider as in flight, reporting false when one
+// already is.
+func (h *SnapshotHook) start(provider string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.running[provider] {
+		return false
+	}
+	h.running[provider] = true
+	return true
+}
+
+func (h *SnapshotHook) done(provider string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.running, provider)
+}
+
+// isRunning reports whether a run for provider is in flight.
+func (h *SnapshotHook) isRunning(provider string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.running[provider]
+}
+
+// Run starts the command for provider's stored snapshot and returns without
+// waiting for it. A nil hook does nothing.
+func (h *SnapshotHook) Run(provider string, snapshot any) {
+	if h == nil {
+		return
+	}
+	payload, err := json.Marshal(map[string]any{"provider": provider, "snapshot": snapshot})
+	if err != nil {
+		h.logger.Error("Snapshot hook: failed to encode snapshot", "provider", provider, "error", err)
+		return
+	}
+	if !h.start(provider) {
+		h.logger.Warn("Snapshot hook still running, skipping", "provider", provider)
+		return
+	}
+	go func() {
+		defer h.done(provider)
+		h.exec(provider, payload)
+	}()
+}
+
+func (h *SnapshotHook) exec(provider string, payload []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", h.command)
+	}
+	cmd.Stdin = strings.NewReader(string(payload))
+	cmd.Env = append(os.Environ(), "ONWATCH_PROVIDER="+provider)
+	// Children of the shell can outlive it and hold the output open
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if len(output) > maxHookOutput {
+		output = output[:maxHookOutput] + "..."
+	}
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		h.logger.Error("Snapshot hook timed out", "provider", provider, "timeout", h.timeout, "output", output)
+	case err != nil:
+		h.logger.Error("Snapshot hook failed", "provider", provider, "error", err, "output", output)
+	default:
+		h.logger.Info("Snapshot hook ran", "provider", provider, "duration", time.Since(start).Round(time.Millisecond), "output", output)
+	}
+}
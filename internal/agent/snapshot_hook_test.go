@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	if NewSnapshotHook("  ", 0, nil) != nil {
+		t.Error("expected a nil hook for an empty command")
+	}
+	var off *SnapshotHook
+	off.Run("zai", map[string]int{"x": 1}) // nil hook is a no-op
+
+	out := filepath.Join(t.TempDir(), "snapshot.json")
+	h := NewSnapshotHook(`printf '%s ' "$ONWATCH_PROVIDER" > `+out+` && cat >> `+out, time.Second, nil)
+	h.Run("zai", map[string]int{"tokens": 42})
+	// Runs in the background; a second snapshot while it runs is skipped
+	h.Run("zai", map[string]int{"tokens": 43})
+
+	var data []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if !h.isRunning("zai") {
+			data, _ = os.ReadFile(out)
+			break
+		}
+	}
+	provider, payload, _ := strings.Cut(string(data), " ")
+	if provider != "zai" {
+		t.Fatalf("expected ONWATCH_PROVIDER=zai, got output %q", data)
+	}
+	var got struct {
+		Provider string         `json:"provider"`
+		Snapshot map[string]int `json:"snapshot"`
+	}
+	if err := json.Unmarshal([]byte(payload), &got); err != nil {
+		t.Fatalf("stdin was not the snapshot JSON: %v (%q)", err, payload)
+	}
+	if got.Provider != "zai" || got.Snapshot["tokens"] != 42 {
+		t.Errorf("unexpected payload %+v", got)
+	}
+
+	slow := NewSnapshotHook("sleep 5", 50*time.Millisecond, nil)
+	start := time.Now()
+	slow.exec("zai", []byte("{}"))
+	if time.Since(start) > 3*time.Second {
+		t.Error("expected the timeout to kill a slow command")
+	}
+}
+
+func TestSnapshotHook_ProvidersRunIndependently(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	dir := t.TempDir()
+	h := NewSnapshotHook(`sleep 0.2 && cat > `+dir+`/"$ONWATCH_PROVIDER".json`, 5*time.Second, nil)
+
+	// Agents poll back to back; one provider's run must not skip another's
+	h.Run("zai", map[string]int{"tokens": 1})
+	h.Run("codex", map[string]int{"five_hour": 2})
+	if !h.isRunning("zai") || !h.isRunning("codex") {
+		t.Fatal("expected both providers' runs in flight")
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if !h.isRunning("zai") && !h.isRunning("codex") {
+			break
+		}
+	}
+	for _, provider := range []string{"zai", "codex"} {
+		if _, err := os.Stat(filepath.Join(dir, provider+".json")); err != nil {
+			t.Errorf("expected the hook to run for %s: %v", provider, err)
+		}
+	}
+}
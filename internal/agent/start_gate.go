@@ -0,0 +1,27 @@
+package agent
+
+import "sync"
+
+// StartGate serializes the first snapshot write of agents that start at the
+// same time, so they can all fetch concurrently without contending for the
+// SQLite write lock. Only the first poll of each agent goes through the gate.
+type StartGate struct {
+	mu sync.Mutex
+}
+
+// NewStartGate creates a gate shared by agents launched together.
+func NewStartGate() *StartGate {
+	return &StartGate{}
+}
+
+// hold acquires the gate for an agent's first poll and returns an idempotent
+// release func. Later polls (done already set) and a nil gate get a no-op.
+func (g *StartGate) hold(done *bool) func() {
+	if g == nil || *done {
+		return func() {}
+	}
+	*done = true
+	g.mu.Lock()
+	var once sync.Once
+	return func() { once.Do(g.mu.Unlock) }
+}
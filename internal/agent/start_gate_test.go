@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartGate_SerializesFirstPollOnly(t *testing.T) {
+	g := NewStartGate()
+	var firstA, firstB bool
+
+	releaseA := g.hold(&firstA)
+
+	acquired := make(chan struct{})
+	go func() {
+		release := g.hold(&firstB)
+		defer release()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second agent entered the gate while the first held it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseA()
+	releaseA() // idempotent
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second agent never entered the gate")
+	}
+
+	// Later polls bypass the gate entirely
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.hold(&firstA)()
+}
+
+func TestStartGate_NilIsNoop(t *testing.T) {
+	var g *StartGate
+	var first bool
+	g.hold(&first)()
+	if first {
+		t.Error("nil gate should not mark the first poll")
+	}
+}
@@ -0,0 +1,100 @@
+package agent
+
+import "time"
+
+// Token refresh sources reported in TokenStatus.
+const (
+	TokenSourceCredentials = "credentials" // token re-read after rotation on disk
+	TokenSourceOAuth       = "oauth"       // token renewed by a proactive OAuth refresh
+)
+
+// TokenStatus describes an agent's credential refreshes, so it can be
+// confirmed that rotated tokens are being picked up.
+type TokenStatus struct {
+	LastRefreshAt      *time.Time `json:"last_refresh_at,omitempty"`
+	LastRefreshSource  string     `json:"last_refresh_source,omitempty"`
+	OAuthRefreshed     bool       `json:"oauth_refreshed"` // a proactive OAuth refresh succeeded since startup
+	LastOAuthRefreshAt *time.Time `json:"last_oauth_refresh_at,omitempty"`
+	LastOAuthError     string     `json:"last_oauth_error,omitempty"` // error of the latest OAuth refresh, empty once one succeeds
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`       // expiry of the token on disk, when known
+
+	// Warning is set while the token has expired and the last OAuth refresh
+	// attempt failed.
+	Warning string `json:"warning,omitempty"`
+}
+
+// tokenWatch tracks an agent's credential refreshes. Guarded by the owning
+// breaker's mutex.
+type tokenWatch struct {
+	tracked            bool
+	lastRefreshAt      time.Time
+	lastRefreshSource  string
+	lastOAuthRefreshAt time.Time
+	lastOAuthError     string
+	expiresAt          time.Time
+}
+
+// status returns the refresh state at now, nil when the agent never reported
+// any (providers without refreshable credentials).
+func (w *tokenWatch) status(now time.Time) *TokenStatus {
+	if !w.tracked {
+		return nil
+	}
+	st := &TokenStatus{
+		LastRefreshSource: w.lastRefreshSource,
+		OAuthRefreshed:    !w.lastOAuthRefreshAt.IsZero(),
+		LastOAuthError:    w.lastOAuthError,
+	}
+	if !w.lastRefreshAt.IsZero() {
+		t := w.lastRefreshAt
+		st.LastRefreshAt = &t
+	}
+	if !w.lastOAuthRefreshAt.IsZero() {
+		t := w.lastOAuthRefreshAt
+		st.LastOAuthRefreshAt = &t
+	}
+	if !w.expiresAt.IsZero() {
+		t := w.expiresAt
+		st.ExpiresAt = &t
+		if !now.Before(t) && w.lastOAuthError != "" {
+			st.Warning = "token expired and refresh failed: " + w.lastOAuthError
+		}
+	}
+	return st
+}
+
+// RecordTokenExpiry records when the current token expires. A nil breaker
+// does nothing.
+func (b *CircuitBreaker) RecordTokenExpiry(expiresAt time.Time) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.token.tracked = true
+	b.token.expiresAt = expiresAt
+}
+
+// RecordTokenRefresh records a token refresh from source, one of
+// TokenSourceCredentials or TokenSourceOAuth. err is the refresh error; only
+// OAuth refreshes can fail. A nil breaker does nothing.
+func (b *CircuitBreaker) RecordTokenRefresh(source string, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.token.tracked = true
+	if source == TokenSourceOAuth {
+		if err != nil {
+			b.token.lastOAuthError = err.Error()
+			return
+		}
+		b.token.lastOAuthError = ""
+		b.token.lastOAuthRefreshAt = b.now()
+	}
+	if err == nil {
+		b.token.lastRefreshAt = b.now()
+		b.token.lastRefreshSource = source
+	}
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_TokenRefresh(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("anthropic", 10, 15*time.Minute)
+	b.now = func() time.Time { return now }
+
+	if st := b.Status().Token; st != nil {
+		t.Fatalf("expected no token status before any refresh, got %+v", st)
+	}
+
+	b.RecordTokenExpiry(now.Add(time.Hour))
+	b.RecordTokenRefresh(TokenSourceCredentials, nil)
+	st := b.Status().Token
+	if st == nil || st.LastRefreshAt == nil || !st.LastRefreshAt.Equal(now) || st.LastRefreshSource != TokenSourceCredentials {
+		t.Fatalf("expected a credentials refresh at %v, got %+v", now, st)
+	}
+	if st.OAuthRefreshed || st.Warning != "" {
+		t.Errorf("unexpected OAuth refresh or warning: %+v", st)
+	}
+
+	// A failed OAuth refresh only warns once the token has expired
+	now = now.Add(50 * time.Minute)
+	b.RecordTokenRefresh(TokenSourceOAuth, errors.New("invalid_grant"))
+	if st := b.Status().Token; st.LastOAuthError != "invalid_grant" || st.Warning != "" {
+		t.Errorf("expected the OAuth error without a warning, got %+v", st)
+	}
+	now = now.Add(20 * time.Minute)
+	if w := b.Status().Token.Warning; !strings.Contains(w, "token expired and refresh failed: invalid_grant") {
+		t.Errorf("expected an expired token warning, got %q", w)
+	}
+
+	// A successful OAuth refresh clears the warning
+	b.RecordTokenRefresh(TokenSourceOAuth, nil)
+	b.RecordTokenExpiry(now.Add(8 * time.Hour))
+	st = b.Status().Token
+	if !st.OAuthRefreshed || st.LastOAuthRefreshAt == nil || !st.LastOAuthRefreshAt.Equal(now) {
+		t.Errorf("expected an OAuth refresh at %v, got %+v", now, st)
+	}
+	if st.LastRefreshSource != TokenSourceOAuth || st.LastOAuthError != "" || st.Warning != "" {
+		t.Errorf("unexpected state after OAuth refresh: %+v", st)
+	}
+
+	var nilBreaker *CircuitBreaker
+	nilBreaker.RecordTokenRefresh(TokenSourceOAuth, nil)
+	nilBreaker.RecordTokenExpiry(now)
+}
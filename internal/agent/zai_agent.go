@@ -4,6 +4,7 @@ package agent
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/onllm-dev/onwatch/internal/api"
@@ -22,6 +23,14 @@ type ZaiAgent struct {
 	sm           *SessionManager
 	notifier     *notify.NotificationEngine
 	pollingCheck func() bool
+	startGate    *StartGate
+	firstPolled  bool
+	breaker      *CircuitBreaker
+	latest       *LatestSnapshots
+	snapshotHook *SnapshotHook
+	firehose     *Firehose
+
+	pollMu sync.Mutex // serializes scheduled and on-demand polls
 }
 
 // SetPollingCheck sets a function that is called before each poll.
@@ -30,6 +39,34 @@ func (a *ZaiAgent) SetPollingCheck(fn func() bool) {
 	a.pollingCheck = fn
 }
 
+// SetStartGate makes the agent's first snapshot write wait its turn behind
+// other agents sharing the gate.
+func (a *ZaiAgent) SetStartGate(g *StartGate) {
+	a.startGate = g
+}
+
+// SetCircuitBreaker sets the breaker that pauses polling after repeated
+// auth/server failures.
+func (a *ZaiAgent) SetCircuitBreaker(b *CircuitBreaker) {
+	a.breaker = b
+}
+
+// SetLatestSnapshots sets the registry that holds the latest polled snapshot
+// and limits how often snapshots are stored.
+func (a *ZaiAgent) SetLatestSnapshots(l *LatestSnapshots) {
+	a.latest = l
+}
+
+// SetSnapshotHook sets the command run after each stored snapshot.
+func (a *ZaiAgent) SetSnapshotHook(h *SnapshotHook) {
+	a.snapshotHook = h
+}
+
+// SetFirehose sets the webhook firehose that receives each stored snapshot.
+func (a *ZaiAgent) SetFirehose(f *Firehose) {
+	a.firehose = f
+}
+
 // SetNotifier sets the notification engine for sending alerts.
 func (a *ZaiAgent) SetNotifier(n *notify.NotificationEngine) {
 	a.notifier = n
@@ -82,27 +119,43 @@ func (a *ZaiAgent) Run(ctx context.Context) error {
 }
 
 // poll performs a single Z.ai poll cycle: fetch quotas, store snapshot.
-func (a *ZaiAgent) poll(ctx context.Context) {
+func (a *ZaiAgent) poll(ctx context.Context) error {
+	a.pollMu.Lock()
+	defer a.pollMu.Unlock()
+
 	if a.pollingCheck != nil && !a.pollingCheck() {
-		return // polling disabled for this provider
+		return ErrPollingDisabled
+	}
+	if !a.breaker.Allow() {
+		return ErrCircuitOpen
 	}
 
+	pollStart := time.Now()
 	resp, err := a.client.FetchQuotas(ctx)
 	if err != nil {
 		if ctx.Err() != nil {
-			return
+			return ctx.Err()
 		}
 		a.logger.Error("Failed to fetch Z.ai quotas", "error", err)
-		return
+		recordPoll(a.store, a.notifier, a.breaker, a.logger, "zai", pollStart, err)
+		return err
 	}
+	release := a.startGate.hold(&a.firstPolled)
+	defer release()
+	recordPoll(a.store, a.notifier, a.breaker, a.logger, "zai", pollStart, nil)
+	checkSchema(a.notifier, a.breaker, a.logger, "zai", resp.SchemaFields())
 
 	// Convert to snapshot and store
 	now := time.Now().UTC()
 	snapshot := resp.ToSnapshot(now)
 
-	if _, err := a.store.InsertZaiSnapshot(snapshot); err != nil {
-		a.logger.Error("Failed to insert Z.ai snapshot", "error", err)
-		return
+	if a.latest.Update("zai", snapshot, snapshot.CapturedAt) {
+		if _, err := a.store.InsertZaiSnapshot(snapshot); err != nil {
+			a.logger.Error("Failed to insert Z.ai snapshot", "error", err)
+			return err
+		}
+		a.snapshotHook.Run("zai", snapshot)
+		a.firehose.Add("zai", snapshot)
 	}
 
 	// Process with tracker (log error but don't stop)
@@ -112,26 +165,10 @@ func (a *ZaiAgent) poll(ctx context.Context) {
 		}
 	}
 
-	// Check notification thresholds
-	if a.notifier != nil {
-		if snapshot.TokensUsage > 0 {
-			a.notifier.Check(notify.QuotaStatus{
-				Provider:    "zai",
-				QuotaKey:    "tokens",
-				Utilization: float64(snapshot.TokensPercentage),
-				Limit:       snapshot.TokensUsage,
-			})
-		}
-		if snapshot.TimeUsage > 0 {
-			pct := (snapshot.TimeCurrentValue / snapshot.TimeUsage) * 100
-			a.notifier.Check(notify.QuotaStatus{
-				Provider:    "zai",
-				QuotaKey:    "time",
-				Utilization: pct,
-				Limit:       snapshot.TimeUsage,
-			})
-		}
-	}
+	// Writes done; let the next agent's first poll proceed
+	release()
+
+	a.checkThresholds(snapshot)
 
 	// Report to session manager for usage-based session detection
 	if a.sm != nil {
@@ -149,4 +186,43 @@ func (a *ZaiAgent) poll(ctx context.Context) {
 		"tokens_limit", snapshot.TokensLimit,
 		"tokens_percentage", snapshot.TokensPercentage,
 	)
+	return nil
+}
+
+// checkThresholds passes the token and time quotas in snapshot to the notifier.
+func (a *ZaiAgent) checkThresholds(snapshot *api.ZaiSnapshot) {
+	if a.notifier != nil {
+		if snapshot.TokensUsage > 0 {
+			a.notifier.Check(notify.QuotaStatus{
+				Provider:      "zai",
+				QuotaKey:      "tokens",
+				Utilization:   float64(snapshot.TokensPercentage),
+				Limit:         snapshot.TokensUsage,
+				ProjectedUtil: a.projectedUtil("tokens"),
+			})
+		}
+		if snapshot.TimeUsage > 0 {
+			pct := (snapshot.TimeCurrentValue / snapshot.TimeUsage) * 100
+			a.notifier.Check(notify.QuotaStatus{
+				Provider:      "zai",
+				QuotaKey:      "time",
+				Utilization:   pct,
+				Limit:         snapshot.TimeUsage,
+				ProjectedUtil: a.projectedUtil("time"),
+			})
+		}
+	}
+}
+
+// projectedUtil returns quotaType's burn-rate projection at reset as a
+// percentage of its limit, 0 when unknown. It drives exhaustion alerts.
+func (a *ZaiAgent) projectedUtil(quotaType string) float64 {
+	if a.tracker == nil {
+		return 0
+	}
+	summary, err := a.tracker.UsageSummary(quotaType)
+	if err != nil || summary == nil || summary.CurrentLimit <= 0 {
+		return 0
+	}
+	return summary.ProjectedUsage / summary.CurrentLimit * 100
 }
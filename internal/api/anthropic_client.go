@@ -48,6 +48,22 @@ func WithAnthropicTimeout(timeout time.Duration) AnthropicOption {
 	}
 }
 
+// WithAnthropicDebugHTTP logs each request's URL, status and latency,
+// plus the raw response body at debug level. Credentials are never logged.
+func WithAnthropicDebugHTTP() AnthropicOption {
+	return func(c *AnthropicClient) {
+		c.httpClient.Transport = newDebugTransport(c.httpClient.Transport, c.logger)
+	}
+}
+
+// WithAnthropicCacheTTL returns a cached response body for repeated fetches within ttl
+// instead of calling the provider again. Zero or negative disables caching.
+func WithAnthropicCacheTTL(ttl time.Duration) AnthropicOption {
+	return func(c *AnthropicClient) {
+		c.httpClient.Transport = newCacheTransport(c.httpClient.Transport, ttl)
+	}
+}
+
 // NewAnthropicClient creates a new Anthropic API client.
 func NewAnthropicClient(token string, logger *slog.Logger, opts ...AnthropicOption) *AnthropicClient {
 	client := &AnthropicClient{
@@ -60,6 +76,7 @@ func NewAnthropicClient(token string, logger *slog.Logger, opts ...AnthropicOpti
 				IdleConnTimeout:       30 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
 				ForceAttemptHTTP2:     true,
+				TLSClientConfig:       sharedTLSConfig(),
 			},
 		},
 		token:   token,
@@ -154,6 +171,7 @@ func (c *AnthropicClient) FetchQuotas(ctx context.Context) (*AnthropicQuotaRespo
 	if err := json.Unmarshal(body, &quotaResp); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrAnthropicInvalidResponse, err)
 	}
+	logMissingFields(c.logger, "anthropic", quotaResp.SchemaFields(), body)
 
 	// Log active quota names on success
 	if names := quotaResp.ActiveQuotaNames(); len(names) > 0 {
@@ -70,6 +70,9 @@ func RefreshAnthropicToken(ctx context.Context, refreshToken string) (*OAuthToke
 	req.Header.Set("User-Agent", "onwatch/1.0")
 
 	client := &http.Client{Timeout: 30 * time.Second}
+	if tlsCfg := sharedTLSConfig(); tlsCfg != nil {
+		client.Transport = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsCfg}
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		if ctx.Err() != nil {
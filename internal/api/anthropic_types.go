@@ -33,6 +33,7 @@ type AnthropicSnapshot struct {
 	CapturedAt time.Time
 	Quotas     []AnthropicQuota
 	RawJSON    string
+	Injected   bool // debug reading from /api/debug/snapshot, not a poll
 }
 
 // anthropicDisplayNames maps API keys to human-readable labels.
@@ -66,6 +66,14 @@ func WithAntigravityTimeout(d time.Duration) AntigravityOption {
 	}
 }
 
+// WithAntigravityDebugHTTP logs each request's URL, status and latency,
+// plus the raw response body at debug level. Credentials are never logged.
+func WithAntigravityDebugHTTP() AntigravityOption {
+	return func(c *AntigravityClient) {
+		c.httpClient.Transport = newDebugTransport(c.httpClient.Transport, c.logger)
+	}
+}
+
 // NewAntigravityClient creates a new Antigravity API client.
 func NewAntigravityClient(logger *slog.Logger, opts ...AntigravityOption) *AntigravityClient {
 	client := &AntigravityClient{
@@ -200,6 +208,7 @@ func (c *AntigravityClient) FetchQuotas(ctx context.Context) (*AntigravityUserSt
 		}
 		return nil, ErrAntigravityNotAuthenticated
 	}
+	logMissingFields(c.logger, "antigravity", quotaResp.SchemaFields(), body)
 
 	if modelIDs := quotaResp.ActiveModelIDs(); len(modelIDs) > 0 {
 		c.logger.Debug("Antigravity quotas fetched successfully",
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -108,10 +109,7 @@ func GroupAntigravityModelsByLogicalQuota(models []AntigravityModelQuota) []Anti
 		}
 
 		acc.modelIDs = appendUniqueString(acc.modelIDs, m.ModelID)
-		label := CleanAntigravityLabel(m.Label)
-		if label == "" {
-			label = AntigravityDisplayName(m.ModelID)
-		}
+		label := AntigravityModelLabel(m.ModelID, m.Label)
 		if label != "" {
 			acc.labels = appendUniqueString(acc.labels, label)
 		}
@@ -325,10 +323,7 @@ func GroupModelsByQuota(models []AntigravityModelQuota) []AntigravityQuotaPool {
 		// Build pool name from model labels
 		var names []string
 		for _, m := range pd.models {
-			label := CleanAntigravityLabel(m.Label)
-			if label == "" {
-				label = AntigravityDisplayName(m.ModelID)
-			}
+			label := AntigravityModelLabel(m.ModelID, m.Label)
 			// Extract just the model family name (e.g., "Claude Sonnet" from "Claude Sonnet 4.6")
 			names = append(names, label)
 		}
@@ -436,12 +431,111 @@ func CleanAntigravityLabel(label string) string {
 	return strings.TrimSpace(label)
 }
 
-// AntigravityDisplayName returns the human-readable name for a model ID.
+// antigravityLabelOverrides holds user-configured model ID → label mappings
+// (ANTIGRAVITY_MODEL_LABELS). They win over both the API label and the
+// built-in names.
+var (
+	antigravityLabelMu        sync.RWMutex
+	antigravityLabelOverrides map[string]string
+)
+
+// SetAntigravityModelLabels replaces the configured model label overrides.
+func SetAntigravityModelLabels(labels map[string]string) {
+	overrides := make(map[string]string, len(labels))
+	for id, label := range labels {
+		id, label = strings.TrimSpace(id), strings.TrimSpace(label)
+		if id != "" && label != "" {
+			overrides[id] = label
+		}
+	}
+	antigravityLabelMu.Lock()
+	antigravityLabelOverrides = overrides
+	antigravityLabelMu.Unlock()
+}
+
+func antigravityLabelOverride(modelID string) (string, bool) {
+	antigravityLabelMu.RLock()
+	defer antigravityLabelMu.RUnlock()
+	label, ok := antigravityLabelOverrides[modelID]
+	return label, ok
+}
+
+// AntigravityDisplayName returns the human-readable name for a model ID:
+// a configured override, a built-in name, or a humanized form of the ID.
 func AntigravityDisplayName(modelID string) string {
+	if name, ok := antigravityLabelOverride(modelID); ok {
+		return name
+	}
 	if name, ok := antigravityDisplayNames[modelID]; ok {
 		return name
 	}
-	return modelID
+	return HumanizeAntigravityModelID(modelID)
+}
+
+// AntigravityModelLabel resolves the label shown for a model. A configured
+// override wins, then the label reported by the API, then AntigravityDisplayName.
+func AntigravityModelLabel(modelID, apiLabel string) string {
+	if name, ok := antigravityLabelOverride(modelID); ok {
+		return name
+	}
+	if label := CleanAntigravityLabel(apiLabel); label != "" {
+		return label
+	}
+	return AntigravityDisplayName(modelID)
+}
+
+// antigravityUpperWords are ID tokens rendered in upper case.
+var antigravityUpperWords = map[string]bool{"gpt": true, "oss": true}
+
+// HumanizeAntigravityModelID makes a best-effort readable name from a model
+// ID the built-in table does not know, so new models show up with a sensible
+// name straight away: "MODEL_PLACEHOLDER_M36" becomes "Model M36" and
+// "claude-5-opus-thinking" becomes "Claude 5 Opus".
+func HumanizeAntigravityModelID(modelID string) string {
+	id := strings.TrimSpace(modelID)
+	if id == "" {
+		return ""
+	}
+	upper := strings.ToUpper(id)
+	if strings.HasPrefix(upper, "MODEL_PLACEHOLDER_") {
+		return "Model " + strings.ToUpper(id[len("MODEL_PLACEHOLDER_"):])
+	}
+	if strings.HasPrefix(upper, "MODEL_") && len(id) > len("MODEL_") {
+		id = id[len("MODEL_"):]
+	}
+
+	tokens := strings.FieldsFunc(strings.ToLower(id), func(r rune) bool {
+		return r == '-' || r == '_' || r == ' ' || r == '/'
+	})
+	if n := len(tokens); n > 1 && tokens[n-1] == "thinking" {
+		tokens = tokens[:n-1]
+	}
+
+	var words []string
+	prevNumeric := false
+	for _, tok := range tokens {
+		numeric := isDigits(tok)
+		switch {
+		case numeric && prevNumeric:
+			// "4-5" is a version: 4.5
+			words[len(words)-1] += "." + tok
+		case antigravityUpperWords[tok]:
+			words = append(words, strings.ToUpper(tok))
+		default:
+			words = append(words, strings.ToUpper(tok[:1])+tok[1:])
+		}
+		prevNumeric = numeric
+	}
+	return strings.Join(words, " ")
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
 }
 
 // ActiveModelIDs returns sorted model IDs present in the response.
@@ -15,7 +15,11 @@ func TestAntigravityDisplayName(t *testing.T) {
 		// Note: Thinking suffix is intentionally removed - it's redundant for Claude models
 		{"claude-4-5-sonnet-thinking", "Claude 4.5 Sonnet"},
 		{"gemini-3-pro", "Gemini 3 Pro"},
-		{"unknown-model", "unknown-model"},
+		{"unknown-model", "Unknown Model"},
+		{"MODEL_PLACEHOLDER_M36", "Model M36"},
+		{"claude-5-1-opus-thinking", "Claude 5.1 Opus"},
+		{"gpt-oss-120b", "GPT OSS 120b"},
+		{"", ""},
 	}
 
 	for _, tt := range tests {
@@ -234,6 +238,25 @@ func TestAntigravityModelQuota_IsExhausted(t *testing.T) {
 	}
 }
 
+func TestAntigravityModelLabel_Overrides(t *testing.T) {
+	SetAntigravityModelLabels(map[string]string{"MODEL_PLACEHOLDER_M36": "Gemini 3.1 Pro"})
+	defer SetAntigravityModelLabels(nil)
+
+	tests := []struct {
+		modelID, apiLabel, expected string
+	}{
+		{"MODEL_PLACEHOLDER_M36", "Some API Label", "Gemini 3.1 Pro"},
+		{"MODEL_PLACEHOLDER_M37", "Claude Opus 4.6 (Thinking)", "Claude Opus 4.6"},
+		{"MODEL_PLACEHOLDER_M38", "", "Model M38"},
+		{"gemini-3-pro", "", "Gemini 3 Pro"},
+	}
+	for _, tt := range tests {
+		if got := AntigravityModelLabel(tt.modelID, tt.apiLabel); got != tt.expected {
+			t.Errorf("AntigravityModelLabel(%q, %q) = %q, want %q", tt.modelID, tt.apiLabel, got, tt.expected)
+		}
+	}
+}
+
 func TestCleanAntigravityLabel(t *testing.T) {
 	tests := []struct {
 		input    string
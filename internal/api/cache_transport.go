@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// cacheBodyLimit is the largest response body the cache stores. Larger bodies
+// are passed through uncached.
+const cacheBodyLimit = 1 << 16
+
+// maxCacheEntries bounds the cached responses; beyond it the entry closest to
+// expiry is dropped.
+const maxCacheEntries = 64
+
+type cachedResponse struct {
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// cacheTransport serves repeated GET requests for the same endpoint and
+// credentials from memory for ttl, so forced polls and retries in quick
+// succession don't count against provider rate limits. Only 200 responses
+// are cached.
+type cacheTransport struct {
+	next http.RoundTripper
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+func newCacheTransport(next http.RoundTripper, ttl time.Duration) http.RoundTripper {
+	if ttl <= 0 {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cacheTransport{
+		next:    next,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cachedResponse),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	t.mu.Lock()
+	entry, ok := t.entries[key]
+	if ok && t.now().After(entry.expires) {
+		delete(t.entries, key)
+		ok = false
+	}
+	t.mu.Unlock()
+	if ok {
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        entry.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, cacheBodyLimit+1))
+	if err != nil || len(body) > cacheBodyLimit {
+		// Too large (or unreadable) to cache: hand back what was read plus the rest
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	t.evict(key)
+	t.entries[key] = cachedResponse{header: resp.Header.Clone(), body: body, expires: t.now().Add(t.ttl)}
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// evict removes expired entries, then the one closest to expiry while the
+// cache is full, making room for key. Entries for requests that are never
+// repeated would otherwise stay forever. Callers hold t.mu.
+func (t *cacheTransport) evict(key string) {
+	now := t.now()
+	for k, e := range t.entries {
+		if now.After(e.expires) {
+			delete(t.entries, k)
+		}
+	}
+	if _, ok := t.entries[key]; ok || len(t.entries) < maxCacheEntries {
+		return
+	}
+	var oldestKey string
+	var oldest time.Time
+	for k, e := range t.entries {
+		if oldestKey == "" || e.expires.Before(oldest) {
+			oldestKey, oldest = k, e.expires
+		}
+	}
+	delete(t.entries, oldestKey)
+}
+
+// cacheKey identifies a request by URL and headers, so responses for
+// different tokens or accounts never mix. Credentials are hashed, not stored.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.String()))
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.Write([]byte{0})
+		h.Write([]byte(name))
+		for _, v := range req.Header[name] {
+			h.Write([]byte{0})
+			h.Write([]byte(v))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL_ReusesResponseWithinTTL(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(realAPIResponse))
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewClient("syn_test_key_12345", logger, WithBaseURL(server.URL), WithCacheTTL(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.FetchQuotas(context.Background())
+		if err != nil {
+			t.Fatalf("FetchQuotas() #%d failed: %v", i+1, err)
+		}
+		if resp.Subscription.Limit != 1350 {
+			t.Errorf("Subscription.Limit = %v, want 1350", resp.Subscription.Limit)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("server hits = %d, want 1", hits.Load())
+	}
+}
+
+func TestCacheTTL_ExpiresAndSeparatesKeys(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	tr := newCacheTransport(http.DefaultTransport, 30*time.Second).(*cacheTransport)
+	tr.now = func() time.Time { return now }
+	client := &http.Client{Transport: tr}
+
+	get := func(token string) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "ok" {
+			t.Errorf("body = %q, want ok", body)
+		}
+	}
+
+	get("a")
+	get("a")
+	get("b") // different credentials never share an entry
+	if hits.Load() != 2 {
+		t.Errorf("server hits = %d, want 2", hits.Load())
+	}
+
+	now = now.Add(31 * time.Second)
+	get("a")
+	if hits.Load() != 3 {
+		t.Errorf("server hits after expiry = %d, want 3", hits.Load())
+	}
+}
+
+func TestCacheTTL_EvictsUnrepeatedEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	tr := newCacheTransport(http.DefaultTransport, 30*time.Second).(*cacheTransport)
+	tr.now = func() time.Time { return now }
+	client := &http.Client{Transport: tr}
+
+	get := func(path string) {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	for i := 0; i < maxCacheEntries+10; i++ {
+		get(fmt.Sprintf("/quota?n=%d", i))
+	}
+	if n := len(tr.entries); n != maxCacheEntries {
+		t.Errorf("entries = %d, want the %d cap", n, maxCacheEntries)
+	}
+
+	// Expired entries are swept by the next insert
+	now = now.Add(31 * time.Second)
+	get("/quota?n=new")
+	if n := len(tr.entries); n != 1 {
+		t.Errorf("entries after expiry = %d, want 1", n)
+	}
+}
+
+func TestCacheTTL_SkipsErrors(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewZaiClient("zai_key", logger, WithZaiBaseURL(server.URL), WithZaiCacheTTL(time.Minute))
+	client.FetchQuotas(context.Background())
+	client.FetchQuotas(context.Background())
+	if hits.Load() != 2 {
+		t.Errorf("server hits = %d, want 2 (errors must not be cached)", hits.Load())
+	}
+}
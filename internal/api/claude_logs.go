@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ClaudeLogSource marks snapshots backfilled from Claude Code session logs.
+// It is stored in RawJSON so imported rows can be told apart from polled ones.
+const ClaudeLogSource = "claude-code-logs"
+
+// claudeFiveHourWindow is the length of Anthropic's five_hour quota window.
+const claudeFiveHourWindow = 5 * time.Hour
+
+// ClaudeUsageEntry is one assistant response recorded in a Claude Code session log.
+type ClaudeUsageEntry struct {
+	Timestamp time.Time
+	Tokens    int64 // input + output + cache creation; cache reads are not counted
+}
+
+// claudeLogLine is the subset of a Claude Code session log line we read.
+type claudeLogLine struct {
+	Timestamp string `json:"timestamp"`
+	RequestID string `json:"requestId"`
+	Message   *struct {
+		ID    string `json:"id"`
+		Usage *struct {
+			InputTokens         int64 `json:"input_tokens"`
+			OutputTokens        int64 `json:"output_tokens"`
+			CacheCreationTokens int64 `json:"cache_creation_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// ReadClaudeUsageLogs reads every session log (*.jsonl) under root, normally
+// ~/.claude, and returns its token usage sorted by time. Claude Code writes
+// one line per content block, so responses repeated under the same message
+// and request ID are counted once. Lines without usage or that fail to parse
+// are skipped.
+func ReadClaudeUsageLogs(root string) ([]ClaudeUsageEntry, error) {
+	dir := filepath.Join(root, "projects")
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = root
+	}
+
+	seen := make(map[string]bool)
+	var entries []ClaudeUsageEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".jsonl") {
+			return nil
+		}
+		fileEntries, err := readClaudeLogFile(path, seen)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, fileEntries...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("api: reading Claude logs: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries, nil
+}
+
+func readClaudeLogFile(path string, seen map[string]bool) ([]ClaudeUsageEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ClaudeUsageEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var line claudeLogLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if line.Message == nil || line.Message.Usage == nil {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339Nano, line.Timestamp)
+		if err != nil {
+			continue
+		}
+		if line.Message.ID != "" || line.RequestID != "" {
+			key := line.Message.ID + ":" + line.RequestID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		u := line.Message.Usage
+		tokens := u.InputTokens + u.OutputTokens + u.CacheCreationTokens
+		if tokens <= 0 {
+			continue
+		}
+		entries = append(entries, ClaudeUsageEntry{Timestamp: ts.UTC(), Tokens: tokens})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ClaudeWindowTokens returns the tokens used in [start, end].
+func ClaudeWindowTokens(entries []ClaudeUsageEntry, start, end time.Time) int64 {
+	var total int64
+	for _, e := range entries {
+		if !e.Timestamp.Before(start) && !e.Timestamp.After(end) {
+			total += e.Tokens
+		}
+	}
+	return total
+}
+
+// ClaudeUsageSnapshots turns log entries into estimated five_hour snapshots.
+// A window opens at the hour of the first message after the previous window
+// ended and lasts five hours, matching how Anthropic schedules resets.
+// Utilization is the window's running token total as a share of
+// fiveHourTokens, capped at 100. Entries in the same minute collapse into a
+// single snapshot taken at the last of them.
+func ClaudeUsageSnapshots(entries []ClaudeUsageEntry, fiveHourTokens float64) []*AnthropicSnapshot {
+	if fiveHourTokens <= 0 {
+		return nil
+	}
+	raw, _ := json.Marshal(map[string]interface{}{
+		"source":           ClaudeLogSource,
+		"five_hour_tokens": fiveHourTokens,
+	})
+
+	var snapshots []*AnthropicSnapshot
+	var windowEnd time.Time
+	var used int64
+	for _, e := range entries {
+		if windowEnd.IsZero() || !e.Timestamp.Before(windowEnd) {
+			windowEnd = e.Timestamp.Truncate(time.Hour).Add(claudeFiveHourWindow)
+			used = 0
+		}
+		used += e.Tokens
+
+		resetsAt := windowEnd
+		snap := &AnthropicSnapshot{
+			CapturedAt: e.Timestamp,
+			Quotas: []AnthropicQuota{{
+				Name:        "five_hour",
+				Utilization: min(float64(used)/fiveHourTokens*100, 100),
+				ResetsAt:    &resetsAt,
+			}},
+			RawJSON: string(raw),
+		}
+
+		if n := len(snapshots); n > 0 {
+			prev := snapshots[n-1]
+			if prev.CapturedAt.Truncate(time.Minute).Equal(e.Timestamp.Truncate(time.Minute)) &&
+				prev.Quotas[0].ResetsAt.Equal(resetsAt) {
+				snapshots[n-1] = snap
+				continue
+			}
+		}
+		snapshots = append(snapshots, snap)
+	}
+	return snapshots
+}
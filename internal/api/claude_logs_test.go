@@ -0,0 +1,87 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeClaudeLog(t *testing.T, root, name string, lines ...string) {
+	t.Helper()
+	dir := filepath.Join(root, "projects", "-home-user-project")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+}
+
+func TestReadClaudeUsageLogs_DedupesAndSkipsNoise(t *testing.T) {
+	root := t.TempDir()
+	writeClaudeLog(t, root, "b.jsonl",
+		`{"type":"assistant","timestamp":"2026-01-10T12:30:00.000Z","requestId":"req_2","message":{"id":"msg_2","usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":10,"cache_read_input_tokens":9999}}}`,
+	)
+	writeClaudeLog(t, root, "a.jsonl",
+		`{"type":"user","timestamp":"2026-01-10T12:00:00.000Z","message":{"role":"user","content":"hi"}}`,
+		`{"type":"assistant","timestamp":"2026-01-10T12:00:05.000Z","requestId":"req_1","message":{"id":"msg_1","usage":{"input_tokens":10,"output_tokens":20}}}`,
+		`{"type":"assistant","timestamp":"2026-01-10T12:00:06.000Z","requestId":"req_1","message":{"id":"msg_1","usage":{"input_tokens":10,"output_tokens":20}}}`,
+		`not json`,
+	)
+
+	entries, err := ReadClaudeUsageLogs(root)
+	if err != nil {
+		t.Fatalf("ReadClaudeUsageLogs: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Tokens != 30 || entries[1].Tokens != 160 {
+		t.Errorf("unexpected token counts: %+v", entries)
+	}
+	if !entries[0].Timestamp.Before(entries[1].Timestamp) {
+		t.Errorf("entries not sorted by time: %+v", entries)
+	}
+}
+
+func TestClaudeUsageSnapshots_Windows(t *testing.T) {
+	base := time.Date(2026, 1, 10, 12, 20, 0, 0, time.UTC)
+	entries := []ClaudeUsageEntry{
+		{Timestamp: base, Tokens: 100},
+		{Timestamp: base.Add(10 * time.Second), Tokens: 100}, // same minute: collapsed
+		{Timestamp: base.Add(2 * time.Hour), Tokens: 300},
+		{Timestamp: base.Add(5 * time.Hour), Tokens: 50}, // past 17:00: new window
+	}
+
+	snaps := ClaudeUsageSnapshots(entries, 1000)
+	if len(snaps) != 3 {
+		t.Fatalf("expected 3 snapshots, got %d", len(snaps))
+	}
+
+	want := []struct {
+		util     float64
+		resetsAt time.Time
+	}{
+		{20, time.Date(2026, 1, 10, 17, 0, 0, 0, time.UTC)},
+		{50, time.Date(2026, 1, 10, 17, 0, 0, 0, time.UTC)},
+		{5, time.Date(2026, 1, 10, 22, 0, 0, 0, time.UTC)},
+	}
+	for i, w := range want {
+		q := snaps[i].Quotas[0]
+		if q.Name != "five_hour" || q.Utilization != w.util || !q.ResetsAt.Equal(w.resetsAt) {
+			t.Errorf("snapshot %d = %s %.1f%% resets %v, want five_hour %.1f%% resets %v", i, q.Name, q.Utilization, q.ResetsAt, w.util, w.resetsAt)
+		}
+		if !strings.Contains(snaps[i].RawJSON, ClaudeLogSource) {
+			t.Errorf("snapshot %d RawJSON missing source marker: %s", i, snaps[i].RawJSON)
+		}
+	}
+	if !snaps[0].CapturedAt.Equal(base.Add(10 * time.Second)) {
+		t.Errorf("collapsed snapshot should use the last entry's time, got %v", snaps[0].CapturedAt)
+	}
+
+	if got := ClaudeUsageSnapshots(entries, 0); got != nil {
+		t.Errorf("expected no snapshots without a budget, got %d", len(got))
+	}
+}
@@ -46,6 +46,22 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithDebugHTTP logs each request's URL, status and latency,
+// plus the raw response body at debug level. Credentials are never logged.
+func WithDebugHTTP() Option {
+	return func(c *Client) {
+		c.httpClient.Transport = newDebugTransport(c.httpClient.Transport, c.logger)
+	}
+}
+
+// WithCacheTTL returns a cached response body for repeated fetches within ttl
+// instead of calling the provider again. Zero or negative disables caching.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = newCacheTransport(c.httpClient.Transport, ttl)
+	}
+}
+
 // NewClient creates a new API client.
 func NewClient(apiKey string, logger *slog.Logger, opts ...Option) *Client {
 	client := &Client{
@@ -58,6 +74,7 @@ func NewClient(apiKey string, logger *slog.Logger, opts ...Option) *Client {
 				IdleConnTimeout:       30 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
 				ForceAttemptHTTP2:     true,
+				TLSClientConfig:       sharedTLSConfig(),
 			},
 		},
 		apiKey:  apiKey,
@@ -134,6 +151,7 @@ func (c *Client) FetchQuotas(ctx context.Context) (*QuotaResponse, error) {
 	if err := json.Unmarshal(body, &quotaResp); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
 	}
+	logMissingFields(c.logger, "synthetic", quotaResp.SchemaFields(), body)
 
 	c.logger.Debug("quotas fetched successfully",
 		"subscription_requests", quotaResp.Subscription.Requests,
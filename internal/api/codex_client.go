@@ -54,6 +54,22 @@ func WithCodexTimeout(timeout time.Duration) CodexOption {
 	}
 }
 
+// WithCodexDebugHTTP logs each request's URL, status and latency,
+// plus the raw response body at debug level. Credentials are never logged.
+func WithCodexDebugHTTP() CodexOption {
+	return func(c *CodexClient) {
+		c.httpClient.Transport = newDebugTransport(c.httpClient.Transport, c.logger)
+	}
+}
+
+// WithCodexCacheTTL returns a cached response body for repeated fetches within ttl
+// instead of calling the provider again. Zero or negative disables caching.
+func WithCodexCacheTTL(ttl time.Duration) CodexOption {
+	return func(c *CodexClient) {
+		c.httpClient.Transport = newCacheTransport(c.httpClient.Transport, ttl)
+	}
+}
+
 // NewCodexClient creates a Codex usage API client.
 func NewCodexClient(token string, logger *slog.Logger, opts ...CodexOption) *CodexClient {
 	if logger == nil {
@@ -70,6 +86,7 @@ func NewCodexClient(token string, logger *slog.Logger, opts ...CodexOption) *Cod
 				IdleConnTimeout:       10 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
 				ForceAttemptHTTP2:     true,
+				TLSClientConfig:       sharedTLSConfig(),
 			},
 		},
 		token:   token,
@@ -216,6 +233,7 @@ func (c *CodexClient) FetchUsage(ctx context.Context) (*CodexUsageResponse, erro
 	if err := json.Unmarshal(body, &usageResp); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrCodexInvalidResponse, err)
 	}
+	logMissingFields(c.logger, "codex", usageResp.SchemaFields(), body)
 
 	return &usageResp, nil
 }
@@ -45,6 +45,22 @@ func WithCopilotTimeout(d time.Duration) CopilotOption {
 	}
 }
 
+// WithCopilotDebugHTTP logs each request's URL, status and latency,
+// plus the raw response body at debug level. Credentials are never logged.
+func WithCopilotDebugHTTP() CopilotOption {
+	return func(c *CopilotClient) {
+		c.httpClient.Transport = newDebugTransport(c.httpClient.Transport, c.logger)
+	}
+}
+
+// WithCopilotCacheTTL returns a cached response body for repeated fetches within ttl
+// instead of calling the provider again. Zero or negative disables caching.
+func WithCopilotCacheTTL(ttl time.Duration) CopilotOption {
+	return func(c *CopilotClient) {
+		c.httpClient.Transport = newCacheTransport(c.httpClient.Transport, ttl)
+	}
+}
+
 // NewCopilotClient creates a new Copilot API client.
 func NewCopilotClient(token string, logger *slog.Logger, opts ...CopilotOption) *CopilotClient {
 	client := &CopilotClient{
@@ -57,6 +73,7 @@ func NewCopilotClient(token string, logger *slog.Logger, opts ...CopilotOption)
 				IdleConnTimeout:       30 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
 				ForceAttemptHTTP2:     true,
+				TLSClientConfig:       sharedTLSConfig(),
 			},
 		},
 		token:   token,
@@ -134,6 +151,7 @@ func (c *CopilotClient) FetchQuotas(ctx context.Context) (*CopilotUserResponse,
 	if err := json.Unmarshal(body, &quotaResp); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrCopilotInvalidResponse, err)
 	}
+	logMissingFields(c.logger, "copilot", quotaResp.SchemaFields(), body)
 
 	// Log active quota names on success
 	if names := quotaResp.ActiveQuotaNames(); len(names) > 0 {
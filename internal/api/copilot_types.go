@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -64,6 +65,16 @@ func CopilotDisplayName(key string) string {
 	return key
 }
 
+// CopilotPlanDisplayName turns a plan key such as "individual_pro" into
+// "Individual Pro".
+func CopilotPlanDisplayName(plan string) string {
+	words := strings.Fields(strings.ReplaceAll(plan, "_", " "))
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
 // ActiveQuotaNames returns sorted names of quotas present in the response.
 // Nil entries are skipped.
 func (r CopilotUserResponse) ActiveQuotaNames() []string {
@@ -220,6 +220,19 @@ func TestCopilotDisplayName(t *testing.T) {
 	}
 }
 
+func TestCopilotPlanDisplayName(t *testing.T) {
+	tests := map[string]string{
+		"individual_pro": "Individual Pro",
+		"business":       "Business",
+		"":               "",
+	}
+	for plan, want := range tests {
+		if got := CopilotPlanDisplayName(plan); got != want {
+			t.Errorf("CopilotPlanDisplayName(%q) = %q, want %q", plan, got, want)
+		}
+	}
+}
+
 func TestCopilotRoundTrip(t *testing.T) {
 	// Test JSON round-trip: parse → ToSnapshot → verify raw JSON re-parses
 	raw := `{"login":"test","copilot_plan":"pro","quota_reset_date_utc":"2026-03-01T00:00:00.000Z","quota_snapshots":{"premium_interactions":{"entitlement":1500,"remaining":1000,"percent_remaining":66.667,"unlimited":false,"overage_count":0,"overage_permitted":false}}}`
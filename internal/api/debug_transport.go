@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// debugBodyLimit caps how much of a response body is logged.
+const debugBodyLimit = 1 << 16
+
+// debugTransport logs every provider request it carries: method, URL, status
+// and latency at info level, and the redacted request headers plus the raw
+// response body at debug level. It is enabled by ONWATCH_DEBUG_HTTP.
+type debugTransport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+}
+
+func newDebugTransport(next http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &debugTransport{next: next, logger: logger}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	if err != nil {
+		t.logger.Info("provider HTTP request failed",
+			"method", req.Method,
+			"url", req.URL.Redacted(),
+			"latency", latency,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	t.logger.Info("provider HTTP request",
+		"method", req.Method,
+		"url", req.URL.Redacted(),
+		"status", resp.StatusCode,
+		"latency", latency,
+	)
+
+	if t.logger.Enabled(req.Context(), slog.LevelDebug) {
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, debugBodyLimit))
+		// Hand the client the bytes we consumed followed by whatever is left
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+		t.logger.Debug("provider HTTP response body",
+			"url", req.URL.Redacted(),
+			"request_headers", redactHeaders(req.Header),
+			"body", string(body),
+			"truncated", len(body) == debugBodyLimit,
+			"read_error", readErr,
+		)
+	}
+
+	return resp, nil
+}
+
+// redactHeaders flattens headers for logging, masking any that carry credentials.
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if isSensitiveHeader(name) {
+			out[name] = "[REDACTED]"
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"authorization", "cookie", "token", "csrf", "api-key", "apikey", "secret"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugHTTP_LogsBodyWithoutToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(realAPIResponse))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient("syn_secret_key_abcdef", logger, WithBaseURL(server.URL), WithDebugHTTP())
+
+	resp, err := client.FetchQuotas(context.Background())
+	if err != nil {
+		t.Fatalf("FetchQuotas() failed: %v", err)
+	}
+	if resp.Subscription.Limit != 1350 {
+		t.Errorf("Subscription.Limit = %v, want 1350 (body must still reach the parser)", resp.Subscription.Limit)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "provider HTTP request") || !strings.Contains(out, "status=200") || !strings.Contains(out, "latency=") {
+		t.Errorf("expected request line with status and latency, got:\n%s", out)
+	}
+	if !strings.Contains(out, "provider HTTP response body") || !strings.Contains(out, "subscription") {
+		t.Errorf("expected raw response body at debug level, got:\n%s", out)
+	}
+	if strings.Contains(out, "syn_secret_key_abcdef") {
+		t.Error("bearer token leaked into debug log")
+	}
+}
+
+func TestDebugHTTP_InfoLevelOmitsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"limits":[]}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	client := NewZaiClient("zai_key", logger, WithZaiBaseURL(server.URL), WithZaiDebugHTTP())
+	client.FetchQuotas(context.Background())
+
+	out := buf.String()
+	if !strings.Contains(out, "provider HTTP request") {
+		t.Errorf("expected request line at info level, got:\n%s", out)
+	}
+	if strings.Contains(out, "response body") {
+		t.Errorf("body should only be logged at debug level, got:\n%s", out)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Set("X-Codeium-Csrf-Token", "xyz")
+	h.Set("Accept", "application/json")
+
+	got := redactHeaders(h)
+	if got["Authorization"] != "[REDACTED]" || got["X-Codeium-Csrf-Token"] != "[REDACTED]" {
+		t.Errorf("credentials not redacted: %v", got)
+	}
+	if got["Accept"] != "application/json" {
+		t.Errorf("Accept = %q, want application/json", got["Accept"])
+	}
+}
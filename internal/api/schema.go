@@ -0,0 +1,144 @@
+package api
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaFields reports, for each field onwatch reads from a provider's
+// response, whether the parsed response carried it with a non-zero value.
+// A field the provider used to populate that goes missing is the sign of a
+// changed response shape: auth still succeeds, but the values parse as zero.
+type SchemaFields map[string]bool
+
+// Missing returns the fields that were absent or zero, sorted.
+func (f SchemaFields) Missing() []string {
+	var missing []string
+	for name, ok := range f {
+		if !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// maxSchemaSnippet is how much of a response body is logged when expected
+// fields are missing.
+const maxSchemaSnippet = 512
+
+// logMissingFields logs, at debug level, the expected fields a parsed response
+// lacked together with the start of the raw body, so a changed shape can be
+// seen without enabling full HTTP debug logging.
+func logMissingFields(logger *slog.Logger, provider string, fields SchemaFields, body []byte) {
+	missing := fields.Missing()
+	if len(missing) == 0 {
+		return
+	}
+	snippet := strings.TrimSpace(string(body))
+	if len(snippet) > maxSchemaSnippet {
+		snippet = snippet[:maxSchemaSnippet] + "..."
+	}
+	logger.Debug("response missing expected fields",
+		"provider", provider,
+		"fields", missing,
+		"body", snippet,
+	)
+}
+
+// SchemaFields reports which of the subscription, search and tool call
+// fields the response carried.
+func (r QuotaResponse) SchemaFields() SchemaFields {
+	return SchemaFields{
+		"subscription.limit":      r.Subscription.Limit > 0,
+		"subscription.renewsAt":   !r.Subscription.RenewsAt.IsZero(),
+		"search.hourly.limit":     r.Search.Hourly.Limit > 0,
+		"toolCallDiscounts.limit": r.ToolCallDiscounts.Limit > 0,
+	}
+}
+
+// SchemaFields reports which of the token and time limit fields the
+// response carried.
+func (r ZaiQuotaResponse) SchemaFields() SchemaFields {
+	fields := SchemaFields{
+		"limits":                     len(r.Limits) > 0,
+		"TOKENS_LIMIT.usage":         false,
+		"TOKENS_LIMIT.nextResetTime": false,
+		"TIME_LIMIT.usage":           false,
+	}
+	for _, limit := range r.Limits {
+		switch limit.Type {
+		case "TOKENS_LIMIT":
+			fields["TOKENS_LIMIT.usage"] = limit.Usage > 0
+			fields["TOKENS_LIMIT.nextResetTime"] = limit.NextResetMs != nil && *limit.NextResetMs > 0
+		case "TIME_LIMIT":
+			fields["TIME_LIMIT.usage"] = limit.Usage > 0
+		}
+	}
+	return fields
+}
+
+// SchemaFields reports whether the response had active quotas and
+// utilization for the five-hour and seven-day windows.
+func (r AnthropicQuotaResponse) SchemaFields() SchemaFields {
+	hasUtilization := func(name string) bool {
+		entry := r[name]
+		return entry != nil && entry.Utilization != nil
+	}
+	return SchemaFields{
+		"quotas":                len(r.ActiveQuotaNames()) > 0,
+		"five_hour.utilization": hasUtilization("five_hour"),
+		"seven_day.utilization": hasUtilization("seven_day"),
+	}
+}
+
+// SchemaFields reports whether the response had quota snapshots, a reset
+// date and a premium interactions entitlement.
+func (r CopilotUserResponse) SchemaFields() SchemaFields {
+	premium := r.QuotaSnapshots["premium_interactions"]
+	return SchemaFields{
+		"quota_snapshots":                  len(r.ActiveQuotaNames()) > 0,
+		"quota_reset_date_utc":             r.QuotaResetDateUTC != "",
+		"premium_interactions.entitlement": premium != nil && (premium.Entitlement > 0 || premium.Unlimited),
+	}
+}
+
+// SchemaFields reports which of the plan and rate limit window fields the
+// response carried.
+func (r CodexUsageResponse) SchemaFields() SchemaFields {
+	primary := r.RateLimit.PrimaryWindow
+	return SchemaFields{
+		"plan_type":                       r.PlanType != "",
+		"rate_limit.primary_window":       primary != nil,
+		"rate_limit.primary_window.reset": primary != nil && primary.ResetAtUnix > 0,
+		"rate_limit.secondary_window":     r.RateLimit.SecondaryWindow != nil,
+	}
+}
+
+// SchemaFields reports whether the response had model quotas and plan
+// details.
+func (r AntigravityUserStatusResponse) SchemaFields() SchemaFields {
+	var plan bool
+	var resetTimes bool
+	if r.UserStatus != nil {
+		plan = r.UserStatus.PlanStatus != nil && r.UserStatus.PlanStatus.PlanInfo != nil
+		if r.UserStatus.CascadeModelConfigData != nil {
+			for _, cfg := range r.UserStatus.CascadeModelConfigData.ClientModelConfigs {
+				if cfg.QuotaInfo == nil || cfg.QuotaInfo.ResetTime == "" {
+					continue
+				}
+				if _, err := time.Parse(time.RFC3339, cfg.QuotaInfo.ResetTime); err == nil {
+					resetTimes = true
+					break
+				}
+			}
+		}
+	}
+	return SchemaFields{
+		"clientModelConfigs.quotaInfo":           len(r.ActiveModelIDs()) > 0,
+		"clientModelConfigs.quotaInfo.resetTime": resetTimes,
+		"planStatus.planInfo":                    plan,
+	}
+}
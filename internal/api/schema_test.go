@@ -0,0 +1,58 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFields_Missing(t *testing.T) {
+	var full QuotaResponse
+	if err := json.Unmarshal([]byte(realAPIResponse), &full); err != nil {
+		t.Fatal(err)
+	}
+	if missing := full.SchemaFields().Missing(); len(missing) != 0 {
+		t.Errorf("complete response reported missing fields: %v", missing)
+	}
+
+	// Subscription renamed upstream: the old key parses as zero
+	var renamed QuotaResponse
+	json.Unmarshal([]byte(`{"plan":{"limit":1350},"search":{"hourly":{"limit":250}},"toolCallDiscounts":{"limit":16200}}`), &renamed)
+	if got := renamed.SchemaFields().Missing(); !slices.Equal(got, []string{"subscription.limit", "subscription.renewsAt"}) {
+		t.Errorf("Missing() = %v", got)
+	}
+
+	var zai ZaiQuotaResponse
+	json.Unmarshal([]byte(`{"limits":[{"type":"TOKENS_LIMIT","usage":200000000,"nextResetTime":1770000000000}]}`), &zai)
+	if got := zai.SchemaFields().Missing(); !slices.Equal(got, []string{"TIME_LIMIT.usage"}) {
+		t.Errorf("zai Missing() = %v", got)
+	}
+}
+
+func TestClient_FetchQuotas_LogsMissingFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"plan":{"max":1350},"search":{"hourly":{"limit":250}},"toolCallDiscounts":{"limit":16200}}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient("syn_test_key_12345", logger, WithBaseURL(server.URL))
+
+	if _, err := client.FetchQuotas(context.Background()); err != nil {
+		t.Fatalf("FetchQuotas() failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"response missing expected fields", "subscription.limit", `\"plan\":{\"max\":1350}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in log:\n%s", want, out)
+		}
+	}
+}
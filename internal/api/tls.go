@@ -0,0 +1,96 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	tlsMu     sync.RWMutex
+	tlsConfig *tls.Config
+)
+
+// SetTLSConfig sets the TLS configuration used by provider clients created
+// after the call. Pass nil to go back to Go's defaults.
+func SetTLSConfig(cfg *tls.Config) {
+	tlsMu.Lock()
+	defer tlsMu.Unlock()
+	tlsConfig = cfg
+}
+
+// sharedTLSConfig returns a copy of the configured TLS settings, or nil.
+func sharedTLSConfig() *tls.Config {
+	tlsMu.RLock()
+	defer tlsMu.RUnlock()
+	if tlsConfig == nil {
+		return nil
+	}
+	return tlsConfig.Clone()
+}
+
+// LoadTLSConfig builds the provider-client TLS configuration from an optional
+// client certificate/key pair (for mutually-authenticated proxies) and an
+// optional PEM CA bundle, which is added to the system roots. It returns nil
+// when nothing is configured.
+func LoadTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("api: client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("api: loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		pool, err := loadCABundle(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+// loadCABundle returns the system roots plus every certificate in the PEM
+// file at path. A bundle with no certificates, or with one that does not
+// parse, is rejected rather than silently ignored.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("api: reading CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	count := 0
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("api: CA bundle %s: certificate %d: %w", path, count+1, err)
+		}
+		pool.AddCert(cert)
+		count++
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("api: CA bundle %s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert creates a self-signed certificate and key in dir and returns their paths.
+func writeTestCert(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func TestLoadTLSConfig_Empty(t *testing.T) {
+	cfg, err := LoadTLSConfig("", "", "")
+	if err != nil || cfg != nil {
+		t.Errorf("LoadTLSConfig() = %v, %v; want nil, nil", cfg, err)
+	}
+}
+
+func TestLoadTLSConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, _ := writeTestCert(t, dir, "client")
+
+	if _, err := LoadTLSConfig(certFile, "", ""); err == nil {
+		t.Error("expected error for certificate without key")
+	}
+	if _, err := LoadTLSConfig(certFile, filepath.Join(dir, "missing.key"), ""); err == nil {
+		t.Error("expected error for missing key file")
+	}
+	if _, err := LoadTLSConfig("", "", filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("expected error for missing CA bundle")
+	}
+}
+
+func TestSetTLSConfig_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, clientKey := writeTestCert(t, dir, "client")
+
+	clientPEM, _ := os.ReadFile(clientCert)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientPEM)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(realAPIResponse))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	// Trust the test server's certificate through a CA bundle file
+	caFile := filepath.Join(dir, "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Without the client certificate the handshake is rejected
+	caOnly, err := LoadTLSConfig("", "", caFile)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig() failed: %v", err)
+	}
+	SetTLSConfig(caOnly)
+	defer SetTLSConfig(nil)
+	if _, err := NewClient("syn_test_key_12345", logger, WithBaseURL(server.URL)).FetchQuotas(context.Background()); err == nil {
+		t.Error("expected handshake failure without client certificate")
+	}
+
+	full, err := LoadTLSConfig(clientCert, clientKey, caFile)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig() failed: %v", err)
+	}
+	SetTLSConfig(full)
+	resp, err := NewClient("syn_test_key_12345", logger, WithBaseURL(server.URL)).FetchQuotas(context.Background())
+	if err != nil {
+		t.Fatalf("FetchQuotas() with client certificate failed: %v", err)
+	}
+	if resp.Subscription.Limit != 1350 {
+		t.Errorf("Subscription.Limit = %v, want 1350", resp.Subscription.Limit)
+	}
+}
+
+func TestLoadTLSConfig_CABundleValidation(t *testing.T) {
+	dir := t.TempDir()
+
+	empty := filepath.Join(dir, "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate\n"), 0600)
+	if _, err := LoadTLSConfig("", "", empty); err == nil {
+		t.Error("expected error for bundle without certificates")
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.pem")
+	os.WriteFile(corrupt, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}), 0600)
+	if _, err := LoadTLSConfig("", "", corrupt); err == nil {
+		t.Error("expected error for unparseable certificate")
+	}
+
+	certFile, _ := writeTestCert(t, dir, "corp-ca")
+	cfg, err := LoadTLSConfig("", "", certFile)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig() failed: %v", err)
+	}
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 0 {
+		t.Errorf("expected RootCAs only, got %+v", cfg)
+	}
+}
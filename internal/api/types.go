@@ -1,14 +1,45 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // QuotaResponse represents the complete response from Synthetic API /v2/quotas
 type QuotaResponse struct {
-	Subscription      QuotaInfo  `json:"subscription"`
-	Search            SearchInfo `json:"search"`
-	ToolCallDiscounts QuotaInfo  `json:"toolCallDiscounts"`
+	Subscription      QuotaInfo    `json:"subscription"`
+	Search            SearchInfo   `json:"search"`
+	ToolCallDiscounts QuotaInfo    `json:"toolCallDiscounts"`
+	Credits           *CreditsInfo `json:"credits,omitempty"` // only on plans with prepaid credits
+}
+
+// CreditsInfo holds the prepaid credit balance some Synthetic plans report
+type CreditsInfo struct {
+	Balance *float64 `json:"balance"`
+}
+
+// CreditsBalance returns the credit balance, or nil when the plan has none
+func (r QuotaResponse) CreditsBalance() *float64 {
+	if r.Credits == nil || r.Credits.Balance == nil {
+		return nil
+	}
+	balance := *r.Credits.Balance
+	return &balance
+}
+
+// ToSnapshot converts a QuotaResponse to a Snapshot, keeping the response as RawJSON
+func (r QuotaResponse) ToSnapshot(capturedAt time.Time) *Snapshot {
+	snapshot := &Snapshot{
+		CapturedAt:     capturedAt,
+		Sub:            r.Subscription,
+		Search:         r.Search.Hourly,
+		ToolCall:       r.ToolCallDiscounts,
+		CreditsBalance: r.CreditsBalance(),
+	}
+	if raw, err := json.Marshal(r); err == nil {
+		snapshot.RawJSON = string(raw)
+	}
+	return snapshot
 }
 
 // QuotaInfo represents a single quota type (subscription, tool calls, etc.)
@@ -25,9 +56,12 @@ type SearchInfo struct {
 
 // Snapshot is the storage representation (flat, for SQLite)
 type Snapshot struct {
-	ID         int64
-	CapturedAt time.Time
-	Sub        QuotaInfo
-	Search     QuotaInfo
-	ToolCall   QuotaInfo
+	ID             int64
+	CapturedAt     time.Time
+	Sub            QuotaInfo
+	Search         QuotaInfo
+	ToolCall       QuotaInfo
+	CreditsBalance *float64 // nil when the plan has no credits
+	RawJSON        string
+	Injected       bool // debug reading from /api/debug/snapshot, not a poll
 }
@@ -203,3 +203,21 @@ func TestQuotaResponse_UnknownFields_Ignored(t *testing.T) {
 		t.Errorf("Subscription.Requests = %v, want 50", resp.Subscription.Requests)
 	}
 }
+
+func TestQuotaResponse_CreditsBalance(t *testing.T) {
+	var resp QuotaResponse
+	if err := json.Unmarshal([]byte(realAPIResponse), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if got := resp.CreditsBalance(); got != nil {
+		t.Errorf("CreditsBalance() = %v, want nil without a credits field", *got)
+	}
+
+	withCredits := `{"subscription": {"limit": 100, "requests": 5, "renewsAt": "2026-02-06T16:16:18Z"}, "credits": {"balance": 42.5}}`
+	if err := json.Unmarshal([]byte(withCredits), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if got := resp.CreditsBalance(); got == nil || *got != 42.5 {
+		t.Errorf("CreditsBalance() = %v, want 42.5", got)
+	}
+}
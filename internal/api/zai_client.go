@@ -46,6 +46,22 @@ func WithZaiTimeout(timeout time.Duration) ZaiOption {
 	}
 }
 
+// WithZaiDebugHTTP logs each request's URL, status and latency,
+// plus the raw response body at debug level. Credentials are never logged.
+func WithZaiDebugHTTP() ZaiOption {
+	return func(c *ZaiClient) {
+		c.httpClient.Transport = newDebugTransport(c.httpClient.Transport, c.logger)
+	}
+}
+
+// WithZaiCacheTTL returns a cached response body for repeated fetches within ttl
+// instead of calling the provider again. Zero or negative disables caching.
+func WithZaiCacheTTL(ttl time.Duration) ZaiOption {
+	return func(c *ZaiClient) {
+		c.httpClient.Transport = newCacheTransport(c.httpClient.Transport, ttl)
+	}
+}
+
 // NewZaiClient creates a new Z.ai API client.
 func NewZaiClient(apiKey string, logger *slog.Logger, opts ...ZaiOption) *ZaiClient {
 	client := &ZaiClient{
@@ -58,6 +74,7 @@ func NewZaiClient(apiKey string, logger *slog.Logger, opts ...ZaiOption) *ZaiCli
 				IdleConnTimeout:       30 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
 				ForceAttemptHTTP2:     true,
+				TLSClientConfig:       sharedTLSConfig(),
 			},
 		},
 		apiKey:  apiKey,
@@ -135,6 +152,7 @@ func (c *ZaiClient) FetchQuotas(ctx context.Context) (*ZaiQuotaResponse, error)
 
 	// The quota response is already parsed in the wrapper
 	quotaResp := wrapper.Data
+	logMissingFields(c.logger, "zai", quotaResp.SchemaFields(), body)
 
 	// Log usage info if we have limits
 	if len(quotaResp.Limits) > 0 {
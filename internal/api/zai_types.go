@@ -62,6 +62,9 @@ type ZaiSnapshot struct {
 	TimeRemaining    float64
 	TimePercentage   int
 	TimeUsageDetails string // JSON: [{"modelCode":"search-prime","usage":16}, ...]
+	// TimeNextResetTime is TIME_LIMIT's nextResetTime when the API reports
+	// one. It only feeds reset detection and is not stored.
+	TimeNextResetTime *time.Time
 	// TOKENS_LIMIT fields
 	TokensLimit         int
 	TokensUnit          int
@@ -71,6 +74,8 @@ type ZaiSnapshot struct {
 	TokensRemaining     float64
 	TokensPercentage    int
 	TokensNextResetTime *time.Time
+	RawJSON             string
+	Injected            bool // debug reading from /api/debug/snapshot, not a poll
 }
 
 // ToSnapshot converts ZaiQuotaResponse to ZaiSnapshot
@@ -93,6 +98,10 @@ func (r *ZaiQuotaResponse) ToSnapshot(capturedAt time.Time) *ZaiSnapshot {
 				b, _ := json.Marshal(limit.UsageDetails)
 				snapshot.TimeUsageDetails = string(b)
 			}
+			if limit.NextResetMs != nil {
+				t := time.UnixMilli(*limit.NextResetMs)
+				snapshot.TimeNextResetTime = &t
+			}
 		case "TOKENS_LIMIT":
 			snapshot.TokensLimit = limit.Unit * limit.Number
 			snapshot.TokensUnit = limit.Unit
@@ -108,6 +117,11 @@ func (r *ZaiQuotaResponse) ToSnapshot(capturedAt time.Time) *ZaiSnapshot {
 		}
 	}
 
+	// Store raw JSON for debugging/auditing
+	if raw, err := json.Marshal(r); err == nil {
+		snapshot.RawJSON = string(raw)
+	}
+
 	return snapshot
 }
 
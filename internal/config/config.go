@@ -5,6 +5,9 @@ package config
 import (
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -17,7 +20,8 @@ import (
 // Config holds all application configuration.
 type Config struct {
 	// Synthetic provider configuration
-	SyntheticAPIKey string // SYNTHETIC_API_KEY
+	SyntheticAPIKey  string // SYNTHETIC_API_KEY
+	SyntheticBaseURL string // SYNTHETIC_BASE_URL
 
 	// Z.ai provider configuration
 	ZaiAPIKey  string // ZAI_API_KEY
@@ -26,6 +30,7 @@ type Config struct {
 	// Anthropic provider configuration
 	AnthropicToken     string // ANTHROPIC_TOKEN or auto-detected
 	AnthropicAutoToken bool   // true if token was auto-detected
+	AnthropicBaseURL   string // ANTHROPIC_BASE_URL
 
 	// Copilot provider configuration
 	CopilotToken string // COPILOT_TOKEN (GitHub PAT with copilot scope)
@@ -39,20 +44,73 @@ type Config struct {
 	AntigravityCSRFToken string // ANTIGRAVITY_CSRF_TOKEN (for Docker)
 	AntigravityEnabled   bool   // true if auto-detection should be attempted
 
+	// AntigravityModelLabels maps Antigravity model IDs to display labels, from
+	// ANTIGRAVITY_MODEL_LABELS ("MODEL_PLACEHOLDER_M36=Gemini 3.1 Pro,...").
+	AntigravityModelLabels map[string]string
+
+	// Per-provider response cache TTL from SYNTHETIC_CACHE_TTL, ZAI_CACHE_TTL,
+	// ANTHROPIC_CACHE_TTL, COPILOT_CACHE_TTL and CODEX_CACHE_TTL (seconds).
+	// Providers without an entry always fetch fresh data.
+	CacheTTL map[string]time.Duration
+
 	// Shared configuration
 	PollInterval       time.Duration // ONWATCH_POLL_INTERVAL (seconds → Duration)
+	StoreInterval      time.Duration // ONWATCH_STORE_INTERVAL (seconds → Duration, minimum gap between stored snapshots; 0 = every poll)
 	Port               int           // ONWATCH_PORT
+	GRPCPort           int           // ONWATCH_GRPC_PORT (gRPC API port; 0 = disabled)
+	GRPCTLSCert        string        // ONWATCH_GRPC_TLS_CERT (PEM certificate to serve gRPC over TLS)
+	GRPCTLSKey         string        // ONWATCH_GRPC_TLS_KEY (PEM private key for GRPCTLSCert)
 	Host               string        // ONWATCH_HOST (bind address, default: 0.0.0.0)
-	SecureCookies      bool          // ONWATCH_SECURE_COOKIES (set Secure flag on cookies)
+	SecureCookies      string        // ONWATCH_SECURE_COOKIES (true, false or auto: Secure when the request came over HTTPS, directly or via X-Forwarded-Proto)
+	CookieSameSite     string        // ONWATCH_COOKIE_SAMESITE (lax, strict or none; default lax)
+	CookieDomain       string        // ONWATCH_COOKIE_DOMAIN (session cookie Domain; default: host-only)
+	CookiePath         string        // ONWATCH_COOKIE_PATH (session cookie Path; default /)
+	TLSClientCert      string        // ONWATCH_TLS_CLIENT_CERT (PEM client certificate for provider mTLS)
+	TLSClientKey       string        // ONWATCH_TLS_CLIENT_KEY (PEM private key for TLSClientCert)
+	CABundle           string        // ONWATCH_CA_BUNDLE (PEM CA certificates trusted by provider clients)
+	WebhookURL         string        // ONWATCH_WEBHOOK_URL (generic JSON webhook for events such as applied updates)
+	OnSnapshotCommand  string        // ONWATCH_ON_SNAPSHOT_COMMAND (shell command run with each stored snapshot as JSON on stdin; off when empty)
+	OnSnapshotTimeout  time.Duration // ONWATCH_ON_SNAPSHOT_TIMEOUT (seconds → Duration, kills a slow snapshot command; default 10)
 	AdminUser          string        // ONWATCH_ADMIN_USER
 	AdminPass          string        // ONWATCH_ADMIN_PASS
 	AdminPassHash      string        // SHA-256 hash of password (set after DB check)
 	DBPath             string        // ONWATCH_DB_PATH
 	DBPathExplicit     bool          // true if user explicitly set --db or ONWATCH_DB_PATH
+	DataDir            string        // ONWATCH_DATA_DIR (logs, PID file and scheduled exports; default: logs next to the DB, PID in ~/.onwatch)
 	LogLevel           string        // ONWATCH_LOG_LEVEL
+	DaemonLogLevel     string        // ONWATCH_DAEMON_LOG_LEVEL (background daemon only; default: LogLevel)
+	LogFile            string        // ONWATCH_LOG_FILE (background log path, default .onwatch.log in DataDir or next to the DB)
+	LogMaxSizeMB       int           // ONWATCH_LOG_MAX_SIZE (MB a log file grows to before it is rotated, default 10)
+	LogMaxFiles        int           // ONWATCH_LOG_MAX_FILES (rotated log files kept, default 3)
 	SessionIdleTimeout time.Duration // ONWATCH_SESSION_IDLE_TIMEOUT (seconds → Duration)
+	CircuitFailures    int           // ONWATCH_CIRCUIT_FAILURES (consecutive auth/5xx failures before pausing a provider)
+	CircuitCooldown    time.Duration // ONWATCH_CIRCUIT_COOLDOWN (seconds → Duration, wait before retrying a paused provider)
+	DebugHTTP          bool          // ONWATCH_DEBUG_HTTP (log provider requests, status, latency and bodies)
+	AllowDebugWrites   bool          // ONWATCH_ALLOW_DEBUG_WRITES (enable POST /api/debug/snapshot; never in production)
+	TrustedProxies     []string      // ONWATCH_TRUSTED_PROXIES (comma-separated proxy addresses/CIDRs whose X-Forwarded-For the IP allowlist honours)
 	DebugMode          bool          // --debug flag (foreground mode)
+	Foreground         bool          // --foreground flag (foreground for process supervisors, independent of debug mode)
 	TestMode           bool          // --test flag (test mode isolation)
+
+	// GitHub OAuth login, enabled when the client ID and secret are set
+	GitHubClientID       string   // ONWATCH_GITHUB_CLIENT_ID
+	GitHubClientSecret   string   // ONWATCH_GITHUB_CLIENT_SECRET
+	GitHubAllowedUsers   []string // ONWATCH_GITHUB_ALLOWED_USERS (comma-separated GitHub logins, lowercased)
+	GitHubAllowedOrgs    []string // ONWATCH_GITHUB_ALLOWED_ORGS (comma-separated organizations, lowercased)
+	GitHubDefaultRole    string   // ONWATCH_GITHUB_DEFAULT_ROLE (role of a GitHub user on first sign-in: admin or viewer)
+	DisablePasswordLogin bool     // ONWATCH_DISABLE_PASSWORD_LOGIN (GitHub sign-in only; needs GitHub OAuth)
+
+	// Scheduled data exports, enabled when ExportInterval is set
+	ExportInterval    time.Duration // ONWATCH_EXPORT_INTERVAL (seconds → Duration; each export covers one interval)
+	ExportFormat      string        // ONWATCH_EXPORT_FORMAT (csv or json, default csv)
+	ExportRetention   int           // ONWATCH_EXPORT_RETENTION (number of exports kept, default 30)
+	ExportDir         string        // ONWATCH_EXPORT_DIR (local directory target)
+	ExportS3Endpoint  string        // ONWATCH_EXPORT_S3_ENDPOINT (S3-compatible endpoint URL)
+	ExportS3Bucket    string        // ONWATCH_EXPORT_S3_BUCKET
+	ExportS3Region    string        // ONWATCH_EXPORT_S3_REGION (default us-east-1)
+	ExportS3Prefix    string        // ONWATCH_EXPORT_S3_PREFIX (object key prefix)
+	ExportS3AccessKey string        // ONWATCH_EXPORT_S3_ACCESS_KEY
+	ExportS3SecretKey string        // ONWATCH_EXPORT_S3_SECRET_KEY
 }
 
 // envWithFallback reads the primary env var, falling back to the legacy name.
@@ -64,13 +122,44 @@ func envWithFallback(primary, fallback string) string {
 	return os.Getenv(fallback)
 }
 
+// parseModelLabels parses comma-separated ID=Label pairs. Malformed pairs are
+// skipped. Returns nil when no pair is valid.
+func parseModelLabels(s string) map[string]string {
+	var labels map[string]string
+	for _, pair := range strings.Split(s, ",") {
+		id, label, ok := strings.Cut(pair, "=")
+		id, label = strings.TrimSpace(id), strings.TrimSpace(label)
+		if !ok || id == "" || label == "" {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[id] = label
+	}
+	return labels
+}
+
+// parseLowerList splits a comma-separated list, trimming and lowercasing each
+// entry and dropping empty ones.
+func parseLowerList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // flagValues holds parsed CLI flags.
 type flagValues struct {
-	interval int
-	port     int
-	db       string
-	debug    bool
-	test     bool
+	interval   int
+	port       int
+	db         string
+	debug      bool
+	foreground bool
+	test       bool
 }
 
 // Load reads configuration from .env file, environment variables, and CLI flags.
@@ -89,6 +178,8 @@ func loadWithArgs(args []string) (*Config, error) {
 		switch {
 		case arg == "--debug":
 			flags.debug = true
+		case arg == "--foreground":
+			flags.foreground = true
 		case arg == "--test":
 			flags.test = true
 		case strings.HasPrefix(arg, "--interval="):
@@ -137,6 +228,7 @@ func loadFromEnvAndFlags(flags *flagValues) (*Config, error) {
 
 	// Synthetic provider
 	cfg.SyntheticAPIKey = os.Getenv("SYNTHETIC_API_KEY")
+	cfg.SyntheticBaseURL = os.Getenv("SYNTHETIC_BASE_URL")
 
 	// Z.ai provider
 	cfg.ZaiAPIKey = os.Getenv("ZAI_API_KEY")
@@ -144,6 +236,7 @@ func loadFromEnvAndFlags(flags *flagValues) (*Config, error) {
 
 	// Anthropic provider
 	cfg.AnthropicToken = os.Getenv("ANTHROPIC_TOKEN")
+	cfg.AnthropicBaseURL = os.Getenv("ANTHROPIC_BASE_URL")
 
 	// Copilot provider
 	cfg.CopilotToken = os.Getenv("COPILOT_TOKEN")
@@ -158,6 +251,7 @@ func loadFromEnvAndFlags(flags *flagValues) (*Config, error) {
 	if cfg.AntigravityBaseURL != "" || os.Getenv("ANTIGRAVITY_ENABLED") == "true" {
 		cfg.AntigravityEnabled = true
 	}
+	cfg.AntigravityModelLabels = parseModelLabels(os.Getenv("ANTIGRAVITY_MODEL_LABELS"))
 
 	// Poll Interval (seconds) — ONWATCH_* first, SYNTRACK_* fallback
 	if flags.interval > 0 {
@@ -168,6 +262,13 @@ func loadFromEnvAndFlags(flags *flagValues) (*Config, error) {
 		}
 	}
 
+	// Store interval (seconds); polls in between only update the in-memory latest
+	if env := os.Getenv("ONWATCH_STORE_INTERVAL"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.StoreInterval = time.Duration(v) * time.Second
+		}
+	}
+
 	// Port
 	if flags.port > 0 {
 		cfg.Port = flags.port
@@ -177,6 +278,15 @@ func loadFromEnvAndFlags(flags *flagValues) (*Config, error) {
 		}
 	}
 
+	// gRPC API port
+	if env := os.Getenv("ONWATCH_GRPC_PORT"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.GRPCPort = v
+		}
+	}
+	cfg.GRPCTLSCert = os.Getenv("ONWATCH_GRPC_TLS_CERT")
+	cfg.GRPCTLSKey = os.Getenv("ONWATCH_GRPC_TLS_KEY")
+
 	// Admin credentials
 	cfg.AdminUser = envWithFallback("ONWATCH_ADMIN_USER", "SYNTRACK_ADMIN_USER")
 	cfg.AdminPass = envWithFallback("ONWATCH_ADMIN_PASS", "SYNTRACK_ADMIN_PASS")
@@ -192,13 +302,56 @@ func loadFromEnvAndFlags(flags *flagValues) (*Config, error) {
 
 	// Log Level
 	cfg.LogLevel = envWithFallback("ONWATCH_LOG_LEVEL", "SYNTRACK_LOG_LEVEL")
+	cfg.DaemonLogLevel = strings.ToLower(strings.TrimSpace(os.Getenv("ONWATCH_DAEMON_LOG_LEVEL")))
+
+	// Data directory for logs, PID file and scheduled exports
+	cfg.DataDir = strings.TrimSpace(os.Getenv("ONWATCH_DATA_DIR"))
+
+	// Log file and rotation
+	cfg.LogFile = strings.TrimSpace(os.Getenv("ONWATCH_LOG_FILE"))
+	if env := os.Getenv("ONWATCH_LOG_MAX_SIZE"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.LogMaxSizeMB = v
+		}
+	}
+	if env := os.Getenv("ONWATCH_LOG_MAX_FILES"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.LogMaxFiles = v
+		}
+	}
 
 	// Host (bind address)
 	cfg.Host = envWithFallback("ONWATCH_HOST", "SYNTRACK_HOST")
 
-	// Secure Cookies
+	// Session cookie attributes
 	if env := envWithFallback("ONWATCH_SECURE_COOKIES", "SYNTRACK_SECURE_COOKIES"); env != "" {
-		cfg.SecureCookies = strings.ToLower(env) == "true" || env == "1"
+		switch v := strings.ToLower(strings.TrimSpace(env)); v {
+		case "1":
+			cfg.SecureCookies = "true"
+		case "0":
+			cfg.SecureCookies = "false"
+		default:
+			cfg.SecureCookies = v
+		}
+	}
+	cfg.CookieSameSite = strings.ToLower(strings.TrimSpace(os.Getenv("ONWATCH_COOKIE_SAMESITE")))
+	cfg.CookieDomain = strings.TrimSpace(os.Getenv("ONWATCH_COOKIE_DOMAIN"))
+	cfg.CookiePath = strings.TrimSpace(os.Getenv("ONWATCH_COOKIE_PATH"))
+
+	// Provider client TLS (mTLS certificate and extra CAs)
+	cfg.TLSClientCert = os.Getenv("ONWATCH_TLS_CLIENT_CERT")
+	cfg.TLSClientKey = os.Getenv("ONWATCH_TLS_CLIENT_KEY")
+	cfg.CABundle = os.Getenv("ONWATCH_CA_BUNDLE")
+
+	// Generic event webhook
+	cfg.WebhookURL = strings.TrimSpace(os.Getenv("ONWATCH_WEBHOOK_URL"))
+
+	// Snapshot hook command; environment only, so the dashboard cannot run commands
+	cfg.OnSnapshotCommand = strings.TrimSpace(os.Getenv("ONWATCH_ON_SNAPSHOT_COMMAND"))
+	if env := os.Getenv("ONWATCH_ON_SNAPSHOT_TIMEOUT"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.OnSnapshotTimeout = time.Duration(v) * time.Second
+		}
 	}
 
 	// Session Idle Timeout (seconds)
@@ -208,9 +361,81 @@ func loadFromEnvAndFlags(flags *flagValues) (*Config, error) {
 		}
 	}
 
+	// Circuit breaker (consecutive failures, cooldown seconds)
+	if env := os.Getenv("ONWATCH_CIRCUIT_FAILURES"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.CircuitFailures = v
+		}
+	}
+	if env := os.Getenv("ONWATCH_CIRCUIT_COOLDOWN"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.CircuitCooldown = time.Duration(v) * time.Second
+		}
+	}
+
+	// Provider response cache TTLs (seconds)
+	for _, provider := range []string{"synthetic", "zai", "anthropic", "copilot", "codex"} {
+		env := os.Getenv(strings.ToUpper(provider) + "_CACHE_TTL")
+		if v, err := strconv.Atoi(env); err == nil && v > 0 {
+			if cfg.CacheTTL == nil {
+				cfg.CacheTTL = make(map[string]time.Duration)
+			}
+			cfg.CacheTTL[provider] = time.Duration(v) * time.Second
+		}
+	}
+
+	// Provider HTTP debug logging
+	if env := os.Getenv("ONWATCH_DEBUG_HTTP"); env != "" {
+		cfg.DebugHTTP = strings.ToLower(env) == "true" || env == "1"
+	}
+
+	// Debug snapshot injection
+	if env := os.Getenv("ONWATCH_ALLOW_DEBUG_WRITES"); env != "" {
+		cfg.AllowDebugWrites = strings.ToLower(env) == "true" || env == "1"
+	}
+
+	// Reverse proxies trusted to report the client address
+	cfg.TrustedProxies = parseLowerList(os.Getenv("ONWATCH_TRUSTED_PROXIES"))
+
+	// GitHub OAuth login
+	cfg.GitHubClientID = strings.TrimSpace(os.Getenv("ONWATCH_GITHUB_CLIENT_ID"))
+	cfg.GitHubClientSecret = strings.TrimSpace(os.Getenv("ONWATCH_GITHUB_CLIENT_SECRET"))
+	cfg.GitHubAllowedUsers = parseLowerList(os.Getenv("ONWATCH_GITHUB_ALLOWED_USERS"))
+	cfg.GitHubAllowedOrgs = parseLowerList(os.Getenv("ONWATCH_GITHUB_ALLOWED_ORGS"))
+	cfg.GitHubDefaultRole = strings.ToLower(strings.TrimSpace(os.Getenv("ONWATCH_GITHUB_DEFAULT_ROLE")))
+	if env := os.Getenv("ONWATCH_DISABLE_PASSWORD_LOGIN"); env != "" {
+		cfg.DisablePasswordLogin = strings.ToLower(env) == "true" || env == "1"
+	}
+
+	// Scheduled data exports
+	if env := os.Getenv("ONWATCH_EXPORT_INTERVAL"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.ExportInterval = time.Duration(v) * time.Second
+		}
+	}
+	cfg.ExportFormat = strings.ToLower(strings.TrimSpace(os.Getenv("ONWATCH_EXPORT_FORMAT")))
+	if env := os.Getenv("ONWATCH_EXPORT_RETENTION"); env != "" {
+		if v, err := strconv.Atoi(env); err == nil {
+			cfg.ExportRetention = v
+		}
+	}
+	cfg.ExportDir = strings.TrimSpace(os.Getenv("ONWATCH_EXPORT_DIR"))
+	cfg.ExportS3Endpoint = strings.TrimSpace(os.Getenv("ONWATCH_EXPORT_S3_ENDPOINT"))
+	cfg.ExportS3Bucket = strings.TrimSpace(os.Getenv("ONWATCH_EXPORT_S3_BUCKET"))
+	cfg.ExportS3Region = strings.TrimSpace(os.Getenv("ONWATCH_EXPORT_S3_REGION"))
+	cfg.ExportS3Prefix = strings.TrimSpace(os.Getenv("ONWATCH_EXPORT_S3_PREFIX"))
+	cfg.ExportS3AccessKey = strings.TrimSpace(os.Getenv("ONWATCH_EXPORT_S3_ACCESS_KEY"))
+	cfg.ExportS3SecretKey = strings.TrimSpace(os.Getenv("ONWATCH_EXPORT_S3_SECRET_KEY"))
+	if cfg.ExportDir == "" && cfg.ExportS3Bucket == "" && cfg.DataDir != "" {
+		cfg.ExportDir = filepath.Join(cfg.DataDir, "exports")
+	}
+
 	// Debug mode (CLI flag only)
 	cfg.DebugMode = flags.debug
 
+	// Foreground mode (CLI flag only)
+	cfg.Foreground = flags.foreground
+
 	// Test mode (CLI flag only)
 	cfg.TestMode = flags.test
 
@@ -255,12 +480,51 @@ func (c *Config) applyDefaults() {
 	if c.LogLevel == "" {
 		c.LogLevel = "info"
 	}
+	if c.LogMaxSizeMB <= 0 {
+		c.LogMaxSizeMB = 10
+	}
+	if c.LogMaxFiles <= 0 {
+		c.LogMaxFiles = 3
+	}
+	if c.SyntheticBaseURL == "" {
+		c.SyntheticBaseURL = "https://api.synthetic.new"
+	}
 	if c.ZaiBaseURL == "" {
 		c.ZaiBaseURL = "https://api.z.ai/api"
 	}
+	if c.AnthropicBaseURL == "" {
+		c.AnthropicBaseURL = "https://api.anthropic.com"
+	}
 	if c.SessionIdleTimeout == 0 {
 		c.SessionIdleTimeout = 600 * time.Second
 	}
+	if c.SecureCookies == "" {
+		c.SecureCookies = "auto"
+	}
+	if c.CookieSameSite == "" {
+		c.CookieSameSite = "lax"
+	}
+	if c.CookiePath == "" {
+		c.CookiePath = "/"
+	}
+	if c.CircuitFailures <= 0 {
+		c.CircuitFailures = 10
+	}
+	if c.CircuitCooldown <= 0 {
+		c.CircuitCooldown = 15 * time.Minute
+	}
+	if c.GitHubDefaultRole == "" {
+		c.GitHubDefaultRole = "admin"
+	}
+	if c.ExportFormat == "" {
+		c.ExportFormat = "csv"
+	}
+	if c.ExportRetention == 0 {
+		c.ExportRetention = 30
+	}
+	if c.ExportS3Region == "" {
+		c.ExportS3Region = "us-east-1"
+	}
 }
 
 // Validate checks the configuration for errors.
@@ -284,15 +548,113 @@ func (c *Config) Validate() error {
 	if c.PollInterval > maxInterval {
 		return fmt.Errorf("poll interval must be at most %v", maxInterval)
 	}
+	if c.StoreInterval < 0 {
+		return fmt.Errorf("store interval must not be negative")
+	}
+	if c.StoreInterval > 0 && c.StoreInterval < c.PollInterval {
+		return fmt.Errorf("store interval (%v) must be at least the poll interval (%v)", c.StoreInterval, c.PollInterval)
+	}
+	if c.StoreInterval > 24*time.Hour {
+		return fmt.Errorf("store interval must be at most %v", 24*time.Hour)
+	}
 
 	// Port range
 	if c.Port < 1024 || c.Port > 65535 {
 		return fmt.Errorf("port must be between 1024 and 65535")
 	}
+	if c.GRPCPort != 0 && (c.GRPCPort < 1024 || c.GRPCPort > 65535 || c.GRPCPort == c.Port) {
+		return fmt.Errorf("gRPC port must be between 1024 and 65535 and differ from the web port")
+	}
+	if (c.GRPCTLSCert == "") != (c.GRPCTLSKey == "") {
+		return fmt.Errorf("ONWATCH_GRPC_TLS_CERT and ONWATCH_GRPC_TLS_KEY must be set together")
+	}
+
+	// mTLS needs both halves of the key pair
+	if (c.TLSClientCert == "") != (c.TLSClientKey == "") {
+		return fmt.Errorf("ONWATCH_TLS_CLIENT_CERT and ONWATCH_TLS_CLIENT_KEY must be set together")
+	}
+
+	if c.SecureCookies != "" && c.SecureCookies != "true" && c.SecureCookies != "false" && c.SecureCookies != "auto" {
+		return fmt.Errorf("ONWATCH_SECURE_COOKIES must be true, false or auto")
+	}
+	switch c.CookieSameSite {
+	case "", "lax", "strict":
+	case "none":
+		// Browsers drop SameSite=None cookies without Secure
+		if c.SecureCookies == "false" {
+			return fmt.Errorf("ONWATCH_COOKIE_SAMESITE=none requires secure cookies")
+		}
+	default:
+		return fmt.Errorf("ONWATCH_COOKIE_SAMESITE must be lax, strict or none")
+	}
+	if c.CookiePath != "" && !strings.HasPrefix(c.CookiePath, "/") {
+		return fmt.Errorf("ONWATCH_COOKIE_PATH must start with /")
+	}
+
+	if c.WebhookURL != "" {
+		if u, err := url.Parse(c.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("ONWATCH_WEBHOOK_URL must be an http or https URL")
+		}
+	}
+	for _, p := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			return fmt.Errorf("ONWATCH_TRUSTED_PROXIES: invalid address or CIDR %q", p)
+		}
+	}
+	if c.OnSnapshotTimeout < 0 || c.OnSnapshotTimeout > 10*time.Minute {
+		return fmt.Errorf("ONWATCH_ON_SNAPSHOT_TIMEOUT must be between 0 and 600 seconds")
+	}
+	switch c.DaemonLogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("ONWATCH_DAEMON_LOG_LEVEL must be debug, info, warn or error")
+	}
+
+	// GitHub OAuth needs both credentials and an allowlist, or any GitHub user could sign in
+	if (c.GitHubClientID == "") != (c.GitHubClientSecret == "") {
+		return fmt.Errorf("ONWATCH_GITHUB_CLIENT_ID and ONWATCH_GITHUB_CLIENT_SECRET must be set together")
+	}
+	if c.GitHubOAuthEnabled() && len(c.GitHubAllowedUsers) == 0 && len(c.GitHubAllowedOrgs) == 0 {
+		return fmt.Errorf("GitHub login needs ONWATCH_GITHUB_ALLOWED_USERS or ONWATCH_GITHUB_ALLOWED_ORGS")
+	}
+	if c.GitHubDefaultRole != "" && c.GitHubDefaultRole != "admin" && c.GitHubDefaultRole != "viewer" {
+		return fmt.Errorf("ONWATCH_GITHUB_DEFAULT_ROLE must be admin or viewer")
+	}
+	if c.DisablePasswordLogin && !c.GitHubOAuthEnabled() {
+		return fmt.Errorf("ONWATCH_DISABLE_PASSWORD_LOGIN requires GitHub login (ONWATCH_GITHUB_CLIENT_ID and ONWATCH_GITHUB_CLIENT_SECRET)")
+	}
+
+	if c.ExportInterval != 0 {
+		if c.ExportInterval < 5*time.Minute || c.ExportInterval > 30*24*time.Hour {
+			return fmt.Errorf("ONWATCH_EXPORT_INTERVAL must be between 300 seconds and 30 days")
+		}
+		if c.ExportFormat != "csv" && c.ExportFormat != "json" {
+			return fmt.Errorf("ONWATCH_EXPORT_FORMAT must be csv or json")
+		}
+		if c.ExportRetention < 1 {
+			return fmt.Errorf("ONWATCH_EXPORT_RETENTION must be at least 1")
+		}
+		if (c.ExportDir == "") == (c.ExportS3Bucket == "") {
+			return fmt.Errorf("scheduled exports need either ONWATCH_EXPORT_DIR or ONWATCH_EXPORT_S3_BUCKET")
+		}
+		if c.ExportS3Bucket != "" {
+			if u, err := url.Parse(c.ExportS3Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("ONWATCH_EXPORT_S3_ENDPOINT must be an http or https URL")
+			}
+			if c.ExportS3AccessKey == "" || c.ExportS3SecretKey == "" {
+				return fmt.Errorf("ONWATCH_EXPORT_S3_ACCESS_KEY and ONWATCH_EXPORT_S3_SECRET_KEY are required for S3 exports")
+			}
+		}
+	}
 
 	return nil
 }
 
+// GitHubOAuthEnabled returns true if "Sign in with GitHub" is configured.
+func (c *Config) GitHubOAuthEnabled() bool {
+	return c.GitHubClientID != "" && c.GitHubClientSecret != ""
+}
+
 // AvailableProviders returns which providers are configured.
 func (c *Config) AvailableProviders() []string {
 	var providers []string
@@ -336,6 +698,28 @@ func (c *Config) HasProvider(name string) bool {
 	return false
 }
 
+// SetProviderKey sets the API key or token of a provider that uses one,
+// reporting false for other providers.
+func (c *Config) SetProviderKey(name, key string) bool {
+	switch name {
+	case "synthetic":
+		c.SyntheticAPIKey = key
+	case "zai":
+		c.ZaiAPIKey = key
+	case "anthropic":
+		c.AnthropicToken = key
+		c.AnthropicAutoToken = false
+	case "copilot":
+		c.CopilotToken = key
+	case "codex":
+		c.CodexToken = key
+		c.CodexAutoToken = false
+	default:
+		return false
+	}
+	return true
+}
+
 // HasMultipleProviders returns true if more than one provider is configured.
 func (c *Config) HasMultipleProviders() bool {
 	count := 0
@@ -376,6 +760,7 @@ func (c *Config) String() string {
 	// Redact Synthetic API key
 	syntheticKeyDisplay := redactAPIKey(c.SyntheticAPIKey, "syn_")
 	fmt.Fprintf(&sb, "  SyntheticAPIKey: %s,\n", syntheticKeyDisplay)
+	fmt.Fprintf(&sb, "  SyntheticBaseURL: %s,\n", c.SyntheticBaseURL)
 
 	// Redact Z.ai API key
 	zaiKeyDisplay := redactAPIKey(c.ZaiAPIKey, "")
@@ -388,19 +773,83 @@ func (c *Config) String() string {
 	if c.AnthropicAutoToken {
 		fmt.Fprintf(&sb, "  AnthropicAutoToken: true,\n")
 	}
+	fmt.Fprintf(&sb, "  AnthropicBaseURL: %s,\n", c.AnthropicBaseURL)
 
 	// Redact Copilot token
 	copilotDisplay := redactAPIKey(c.CopilotToken, "ghp_")
 	fmt.Fprintf(&sb, "  CopilotToken: %s,\n", copilotDisplay)
 
 	fmt.Fprintf(&sb, "  PollInterval: %v,\n", c.PollInterval)
+	fmt.Fprintf(&sb, "  StoreInterval: %v,\n", c.StoreInterval)
 	fmt.Fprintf(&sb, "  SessionIdleTimeout: %v,\n", c.SessionIdleTimeout)
+	fmt.Fprintf(&sb, "  Cookies: secure=%s samesite=%s path=%s", c.SecureCookies, c.CookieSameSite, c.CookiePath)
+	if c.CookieDomain != "" {
+		fmt.Fprintf(&sb, " domain=%s", c.CookieDomain)
+	}
+	fmt.Fprintf(&sb, ",\n")
+	fmt.Fprintf(&sb, "  CircuitFailures: %d,\n", c.CircuitFailures)
+	fmt.Fprintf(&sb, "  CircuitCooldown: %v,\n", c.CircuitCooldown)
 	fmt.Fprintf(&sb, "  Port: %d,\n", c.Port)
+	if c.GRPCPort != 0 {
+		fmt.Fprintf(&sb, "  GRPCPort: %d,\n", c.GRPCPort)
+	}
+	if c.GRPCTLSCert != "" {
+		fmt.Fprintf(&sb, "  GRPCTLSCert: %s,\n", c.GRPCTLSCert)
+	}
 	fmt.Fprintf(&sb, "  AdminUser: %s,\n", c.AdminUser)
 	fmt.Fprintf(&sb, "  AdminPass: ****,\n")
 	fmt.Fprintf(&sb, "  DBPath: %s,\n", c.DBPath)
+	if c.DataDir != "" {
+		fmt.Fprintf(&sb, "  DataDir: %s,\n", c.DataDir)
+	}
 	fmt.Fprintf(&sb, "  LogLevel: %s,\n", c.LogLevel)
+	if c.DaemonLogLevel != "" {
+		fmt.Fprintf(&sb, "  DaemonLogLevel: %s,\n", c.DaemonLogLevel)
+	}
+	if c.LogFile != "" {
+		fmt.Fprintf(&sb, "  LogFile: %s,\n", c.LogFile)
+	}
+	fmt.Fprintf(&sb, "  LogRotation: %dMB x %d,\n", c.LogMaxSizeMB, c.LogMaxFiles)
+	if len(c.CacheTTL) > 0 {
+		fmt.Fprintf(&sb, "  CacheTTL: %v,\n", c.CacheTTL)
+	}
+	if len(c.AntigravityModelLabels) > 0 {
+		fmt.Fprintf(&sb, "  AntigravityModelLabels: %v,\n", c.AntigravityModelLabels)
+	}
+	if c.TLSClientCert != "" {
+		fmt.Fprintf(&sb, "  TLSClientCert: %s,\n", c.TLSClientCert)
+	}
+	if c.CABundle != "" {
+		fmt.Fprintf(&sb, "  CABundle: %s,\n", c.CABundle)
+	}
+	if c.WebhookURL != "" {
+		// The URL may embed a token, so only show where it points
+		if u, err := url.Parse(c.WebhookURL); err == nil {
+			fmt.Fprintf(&sb, "  WebhookURL: %s://%s/...,\n", u.Scheme, u.Host)
+		}
+	}
+	if c.OnSnapshotCommand != "" {
+		// The command line may carry credentials, so only say it is set
+		fmt.Fprintf(&sb, "  OnSnapshotCommand: set (timeout %v),\n", c.OnSnapshotTimeout)
+	}
+	if c.GitHubOAuthEnabled() {
+		fmt.Fprintf(&sb, "  GitHubOAuth: users=%v orgs=%v role=%s passwordLogin=%v,\n",
+			c.GitHubAllowedUsers, c.GitHubAllowedOrgs, c.GitHubDefaultRole, !c.DisablePasswordLogin)
+	}
+	if c.ExportInterval != 0 {
+		target := c.ExportDir
+		if c.ExportS3Bucket != "" {
+			target = "s3://" + c.ExportS3Bucket + "/" + c.ExportS3Prefix
+		}
+		fmt.Fprintf(&sb, "  Export: every %v as %s to %s (keep %d),\n", c.ExportInterval, c.ExportFormat, target, c.ExportRetention)
+	}
+	fmt.Fprintf(&sb, "  DebugHTTP: %v,\n", c.DebugHTTP)
+	fmt.Fprintf(&sb, "  AllowDebugWrites: %v,\n", c.AllowDebugWrites)
+	if len(c.TrustedProxies) > 0 {
+		fmt.Fprintf(&sb, "  TrustedProxies: %v,\n", c.TrustedProxies)
+	}
 	fmt.Fprintf(&sb, "  DebugMode: %v,\n", c.DebugMode)
+	fmt.Fprintf(&sb, "  Foreground: %v,\n", c.Foreground)
 	fmt.Fprintf(&sb, "}")
 
 	return sb.String()
@@ -425,12 +874,71 @@ func redactAPIKey(key string, expectedPrefix string) string {
 	return key[:prefixLen+4] + "***...***" + key[len(key)-3:]
 }
 
+// LogPath returns the background log file: ONWATCH_LOG_FILE when set,
+// otherwise .onwatch.log (.onwatch-test.log in test mode) in DataDir, or
+// next to the DB without one.
+func (c *Config) LogPath() string {
+	if c.LogFile != "" {
+		return c.LogFile
+	}
+	logName := ".onwatch.log"
+	if c.TestMode {
+		logName = ".onwatch-test.log"
+	}
+	dir := c.DataDir
+	if dir == "" {
+		dir = filepath.Dir(c.DBPath)
+	}
+	return filepath.Join(dir, logName)
+}
+
+// DataDirFromEnv returns ONWATCH_DATA_DIR, reading .env first, for the
+// commands that locate the PID file before the full config is loaded.
+func DataDirFromEnv() string {
+	_ = godotenv.Load(".env")
+	return strings.TrimSpace(os.Getenv("ONWATCH_DATA_DIR"))
+}
+
+// PIDFileFromEnv returns ONWATCH_PID_FILE, reading .env first, for the
+// commands that locate the PID file before the full config is loaded.
+func PIDFileFromEnv() string {
+	_ = godotenv.Load(".env")
+	return strings.TrimSpace(os.Getenv("ONWATCH_PID_FILE"))
+}
+
+// StartupLogLevel returns the level logging starts at: DaemonLogLevel for
+// the background daemon when set, otherwise LogLevel. Unknown names mean
+// info. The level can still be changed at runtime.
+func (c *Config) StartupLogLevel(daemon bool) slog.Level {
+	name := c.LogLevel
+	if daemon && c.DaemonLogLevel != "" {
+		name = c.DaemonLogLevel
+	}
+	switch name {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// RunsInForeground reports whether onwatch stays attached to its terminal or
+// supervisor instead of daemonizing: with --debug or --foreground.
+func (c *Config) RunsInForeground() bool {
+	return c.DebugMode || c.Foreground
+}
+
 // LogWriter returns the appropriate log destination based on debug mode.
-// In debug mode: returns os.Stdout
+// In debug or foreground mode: returns os.Stdout
 // In Docker: returns os.Stdout (containers should log to stdout)
-// In background mode: returns a file handle to .onwatch.log
+// In background mode: returns the log file at LogPath, rotated once it
+// reaches LogMaxSizeMB with LogMaxFiles old files kept
 func (c *Config) LogWriter() (io.Writer, error) {
-	if c.DebugMode {
+	if c.RunsInForeground() {
 		return os.Stdout, nil
 	}
 
@@ -439,14 +947,15 @@ func (c *Config) LogWriter() (io.Writer, error) {
 		return os.Stdout, nil
 	}
 
-	// Background mode: log to file in same directory as DB
-	logName := ".onwatch.log"
-	if c.TestMode {
-		logName = ".onwatch-test.log"
+	// Background mode: log to a rotated file
+	maxSize, maxFiles := c.LogMaxSizeMB, c.LogMaxFiles
+	if maxSize <= 0 {
+		maxSize = 10
 	}
-	logPath := filepath.Join(filepath.Dir(c.DBPath), logName)
-
-	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if maxFiles <= 0 {
+		maxFiles = 3
+	}
+	file, err := openRotatingFile(c.LogPath(), int64(maxSize)<<20, maxFiles)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -64,6 +65,297 @@ func TestConfig_LoadsZaiFromEnv(t *testing.T) {
 	}
 }
 
+func TestConfig_BaseURLOverrides(t *testing.T) {
+	os.Setenv("ANTHROPIC_TOKEN", "anth_test_token")
+	os.Setenv("ANTHROPIC_BASE_URL", "https://gateway.internal/anthropic")
+	os.Setenv("SYNTHETIC_BASE_URL", "http://localhost:9999")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.AnthropicBaseURL != "https://gateway.internal/anthropic" {
+		t.Errorf("AnthropicBaseURL = %q, want %q", cfg.AnthropicBaseURL, "https://gateway.internal/anthropic")
+	}
+	if cfg.SyntheticBaseURL != "http://localhost:9999" {
+		t.Errorf("SyntheticBaseURL = %q, want %q", cfg.SyntheticBaseURL, "http://localhost:9999")
+	}
+}
+
+func TestConfig_BaseURLDefaults(t *testing.T) {
+	os.Setenv("ANTHROPIC_TOKEN", "anth_test_token")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.AnthropicBaseURL != "https://api.anthropic.com" {
+		t.Errorf("AnthropicBaseURL = %q, want default %q", cfg.AnthropicBaseURL, "https://api.anthropic.com")
+	}
+	if cfg.SyntheticBaseURL != "https://api.synthetic.new" {
+		t.Errorf("SyntheticBaseURL = %q, want default %q", cfg.SyntheticBaseURL, "https://api.synthetic.new")
+	}
+}
+
+func TestConfig_DebugHTTPFromEnv(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.DebugHTTP {
+		t.Error("DebugHTTP should be off by default")
+	}
+
+	os.Setenv("ONWATCH_DEBUG_HTTP", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.DebugHTTP {
+		t.Error("DebugHTTP = false, want true with ONWATCH_DEBUG_HTTP=true")
+	}
+}
+
+func TestConfig_AllowDebugWritesFromEnv(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.AllowDebugWrites {
+		t.Error("AllowDebugWrites should be off by default")
+	}
+
+	os.Setenv("ONWATCH_ALLOW_DEBUG_WRITES", "1")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.AllowDebugWrites {
+		t.Error("AllowDebugWrites = false, want true with ONWATCH_ALLOW_DEBUG_WRITES=1")
+	}
+}
+
+func TestConfig_CacheTTLFromEnv(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ZAI_CACHE_TTL", "30")
+	os.Setenv("CODEX_CACHE_TTL", "0")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.CacheTTL["zai"] != 30*time.Second {
+		t.Errorf("CacheTTL[zai] = %v, want 30s", cfg.CacheTTL["zai"])
+	}
+	if _, ok := cfg.CacheTTL["codex"]; ok {
+		t.Error("CacheTTL[codex] should be unset when 0")
+	}
+	if _, ok := cfg.CacheTTL["synthetic"]; ok {
+		t.Error("CacheTTL[synthetic] should be unset by default")
+	}
+}
+
+func TestConfig_AntigravityModelLabelsFromEnv(t *testing.T) {
+	os.Setenv("ANTIGRAVITY_ENABLED", "true")
+	os.Setenv("ANTIGRAVITY_MODEL_LABELS", "MODEL_PLACEHOLDER_M36=Gemini 3.1 Pro, bad-pair ,=x,gemini-3-flash = Flash")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	want := map[string]string{"MODEL_PLACEHOLDER_M36": "Gemini 3.1 Pro", "gemini-3-flash": "Flash"}
+	if len(cfg.AntigravityModelLabels) != len(want) {
+		t.Fatalf("AntigravityModelLabels = %v, want %v", cfg.AntigravityModelLabels, want)
+	}
+	for id, label := range want {
+		if cfg.AntigravityModelLabels[id] != label {
+			t.Errorf("AntigravityModelLabels[%q] = %q, want %q", id, cfg.AntigravityModelLabels[id], label)
+		}
+	}
+}
+
+func TestConfig_ValidatesTLSClientKeyPair(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_TLS_CLIENT_CERT", "/etc/onwatch/client.crt")
+	defer os.Clearenv()
+
+	if _, err := Load(); err == nil {
+		t.Error("Load() should fail when the client key is missing")
+	}
+
+	os.Setenv("ONWATCH_TLS_CLIENT_KEY", "/etc/onwatch/client.key")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.TLSClientKey != "/etc/onwatch/client.key" {
+		t.Errorf("TLSClientKey = %q, want %q", cfg.TLSClientKey, "/etc/onwatch/client.key")
+	}
+}
+
+func TestConfig_WebhookURL(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_WEBHOOK_URL", "ftp://hooks.example.com/x")
+	defer os.Clearenv()
+
+	if _, err := Load(); err == nil {
+		t.Error("Load() should reject a non-http webhook URL")
+	}
+
+	os.Setenv("ONWATCH_WEBHOOK_URL", "https://hooks.example.com/onwatch?token=secret")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.WebhookURL != "https://hooks.example.com/onwatch?token=secret" {
+		t.Errorf("WebhookURL = %q", cfg.WebhookURL)
+	}
+	if strings.Contains(cfg.String(), "secret") {
+		t.Error("String() should not print the webhook URL's path or query")
+	}
+}
+
+func TestConfig_GitHubOAuth(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_DISABLE_PASSWORD_LOGIN", "true")
+	defer os.Clearenv()
+
+	if _, err := Load(); err == nil {
+		t.Error("Load() should refuse to disable password login without GitHub OAuth")
+	}
+
+	os.Setenv("ONWATCH_GITHUB_CLIENT_ID", "Iv1.abc")
+	os.Setenv("ONWATCH_GITHUB_CLIENT_SECRET", "shh")
+	if _, err := Load(); err == nil {
+		t.Error("Load() should require an allowlist for GitHub OAuth")
+	}
+
+	os.Setenv("ONWATCH_GITHUB_ALLOWED_USERS", " Alice, bob ,")
+	os.Setenv("ONWATCH_GITHUB_ALLOWED_ORGS", "Acme")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.GitHubOAuthEnabled() || !cfg.DisablePasswordLogin {
+		t.Error("expected GitHub OAuth with password login disabled")
+	}
+	if len(cfg.GitHubAllowedUsers) != 2 || cfg.GitHubAllowedUsers[0] != "alice" || cfg.GitHubAllowedOrgs[0] != "acme" {
+		t.Errorf("allowlists = %v %v", cfg.GitHubAllowedUsers, cfg.GitHubAllowedOrgs)
+	}
+	if cfg.GitHubDefaultRole != "admin" {
+		t.Errorf("GitHubDefaultRole = %q, want admin", cfg.GitHubDefaultRole)
+	}
+	if strings.Contains(cfg.String(), "shh") {
+		t.Error("String() should not print the client secret")
+	}
+
+	os.Setenv("ONWATCH_GITHUB_DEFAULT_ROLE", "owner")
+	if _, err := Load(); err == nil {
+		t.Error("Load() should reject an unknown default role")
+	}
+}
+
+func TestConfig_GRPCPort(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_GRPC_PORT", "9212")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.GRPCPort != 9212 {
+		t.Errorf("GRPCPort = %d, want 9212", cfg.GRPCPort)
+	}
+
+	os.Setenv("ONWATCH_GRPC_PORT", "9211")
+	if _, err := Load(); err == nil {
+		t.Error("Load() should reject a gRPC port equal to the web port")
+	}
+
+	os.Setenv("ONWATCH_GRPC_PORT", "9212")
+	os.Setenv("ONWATCH_GRPC_TLS_CERT", "/etc/onwatch/grpc.crt")
+	if _, err := Load(); err == nil {
+		t.Error("Load() should require ONWATCH_GRPC_TLS_KEY with ONWATCH_GRPC_TLS_CERT")
+	}
+	os.Setenv("ONWATCH_GRPC_TLS_KEY", "/etc/onwatch/grpc.key")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.GRPCTLSCert != "/etc/onwatch/grpc.crt" || cfg.GRPCTLSKey != "/etc/onwatch/grpc.key" {
+		t.Errorf("gRPC TLS = %q, %q", cfg.GRPCTLSCert, cfg.GRPCTLSKey)
+	}
+}
+
+func TestConfig_ScheduledExport(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_EXPORT_INTERVAL", "86400")
+	defer os.Clearenv()
+
+	if _, err := Load(); err == nil {
+		t.Error("Load() should require an export target")
+	}
+
+	os.Setenv("ONWATCH_EXPORT_S3_BUCKET", "dumps")
+	os.Setenv("ONWATCH_EXPORT_S3_ENDPOINT", "https://s3.example.com")
+	if _, err := Load(); err == nil {
+		t.Error("Load() should require S3 keys")
+	}
+
+	os.Setenv("ONWATCH_EXPORT_S3_ACCESS_KEY", "AK")
+	os.Setenv("ONWATCH_EXPORT_S3_SECRET_KEY", "supersecret")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.ExportInterval != 24*time.Hour || cfg.ExportFormat != "csv" || cfg.ExportRetention != 30 || cfg.ExportS3Region != "us-east-1" {
+		t.Errorf("export config = %v %q %d %q", cfg.ExportInterval, cfg.ExportFormat, cfg.ExportRetention, cfg.ExportS3Region)
+	}
+	if strings.Contains(cfg.String(), "supersecret") {
+		t.Error("String() should not print the S3 secret key")
+	}
+
+	os.Setenv("ONWATCH_EXPORT_DIR", "/tmp/exports")
+	if _, err := Load(); err == nil {
+		t.Error("Load() should refuse both a directory and a bucket")
+	}
+}
+
+func TestConfig_StoreInterval(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_POLL_INTERVAL", "30")
+	os.Setenv("ONWATCH_STORE_INTERVAL", "20")
+	defer os.Clearenv()
+
+	if _, err := Load(); err == nil {
+		t.Error("Load() should reject a store interval shorter than the poll interval")
+	}
+
+	os.Setenv("ONWATCH_STORE_INTERVAL", "300")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.StoreInterval != 5*time.Minute {
+		t.Errorf("StoreInterval = %v, want 5m", cfg.StoreInterval)
+	}
+}
+
 func TestConfig_ZaiDefaults(t *testing.T) {
 	os.Setenv("ZAI_API_KEY", "zai_test_key")
 	defer os.Clearenv()
@@ -78,6 +370,25 @@ func TestConfig_ZaiDefaults(t *testing.T) {
 	}
 }
 
+func TestConfig_CircuitBreakerFromEnv(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_CIRCUIT_FAILURES", "5")
+	os.Setenv("ONWATCH_CIRCUIT_COOLDOWN", "120")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.CircuitFailures != 5 {
+		t.Errorf("CircuitFailures = %d, want 5", cfg.CircuitFailures)
+	}
+	if cfg.CircuitCooldown != 2*time.Minute {
+		t.Errorf("CircuitCooldown = %v, want 2m", cfg.CircuitCooldown)
+	}
+}
+
 func TestConfig_DefaultValues(t *testing.T) {
 	os.Setenv("SYNTHETIC_API_KEY", "syn_test_key_123")
 	defer os.Clearenv()
@@ -252,6 +563,35 @@ func TestConfig_ValidatesInterval_Maximum(t *testing.T) {
 	}
 }
 
+func TestConfig_DaemonLogLevel(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_LOG_LEVEL", "debug")
+	os.Setenv("ONWATCH_DAEMON_LOG_LEVEL", "WARN")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := cfg.StartupLogLevel(true); got != slog.LevelWarn {
+		t.Errorf("daemon: expected warn, got %s", got)
+	}
+	if got := cfg.StartupLogLevel(false); got != slog.LevelDebug {
+		t.Errorf("foreground: expected debug, got %s", got)
+	}
+
+	cfg.DaemonLogLevel = ""
+	if got := cfg.StartupLogLevel(true); got != slog.LevelDebug {
+		t.Errorf("daemon without override: expected debug, got %s", got)
+	}
+
+	os.Setenv("ONWATCH_DAEMON_LOG_LEVEL", "verbose")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ONWATCH_DAEMON_LOG_LEVEL") {
+		t.Errorf("expected ONWATCH_DAEMON_LOG_LEVEL error, got %v", err)
+	}
+}
+
 func TestConfig_ValidatesPort_Range(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -375,6 +715,33 @@ func TestConfig_DebugMode_Flag(t *testing.T) {
 	}
 }
 
+func TestConfig_Foreground_Flag(t *testing.T) {
+	os.Setenv("SYNTHETIC_API_KEY", "syn_test_key")
+	defer os.Clearenv()
+
+	cfg, err := loadWithArgs([]string{"--foreground"})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.Foreground || cfg.DebugMode {
+		t.Errorf("expected Foreground without DebugMode, got Foreground=%v DebugMode=%v", cfg.Foreground, cfg.DebugMode)
+	}
+	if !cfg.RunsInForeground() {
+		t.Error("RunsInForeground should be true with --foreground")
+	}
+	if writer, err := cfg.LogWriter(); err != nil || writer != os.Stdout {
+		t.Errorf("foreground mode should log to os.Stdout, got %v, %v", writer, err)
+	}
+
+	cfg, err = loadWithArgs(nil)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.RunsInForeground() {
+		t.Error("RunsInForeground should be false without --debug or --foreground")
+	}
+}
+
 func TestConfig_LogWriter(t *testing.T) {
 	cfg := &Config{
 		DebugMode: true,
@@ -400,6 +767,138 @@ func TestConfig_LogWriter(t *testing.T) {
 	}
 }
 
+func TestConfig_LogFileFromEnv(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_LOG_FILE", "/var/log/onwatch.log")
+	os.Setenv("ONWATCH_LOG_MAX_SIZE", "50")
+	os.Setenv("ONWATCH_LOG_MAX_FILES", "7")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.LogPath() != "/var/log/onwatch.log" {
+		t.Errorf("LogPath() = %q, want /var/log/onwatch.log", cfg.LogPath())
+	}
+	if cfg.LogMaxSizeMB != 50 || cfg.LogMaxFiles != 7 {
+		t.Errorf("rotation = %dMB x %d, want 50MB x 7", cfg.LogMaxSizeMB, cfg.LogMaxFiles)
+	}
+
+	cfg = &Config{DBPath: "/data/onwatch.db", TestMode: true}
+	if got := cfg.LogPath(); got != filepath.Join("/data", ".onwatch-test.log") {
+		t.Errorf("default LogPath() = %q", got)
+	}
+}
+
+func TestConfig_DataDir(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_DB_PATH", "/data/onwatch.db")
+	os.Setenv("ONWATCH_DATA_DIR", "/srv/onwatch")
+	os.Setenv("ONWATCH_EXPORT_INTERVAL", "3600")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := cfg.LogPath(); got != filepath.Join("/srv/onwatch", ".onwatch.log") {
+		t.Errorf("LogPath() = %q, want the log in the data directory", got)
+	}
+	if cfg.ExportDir != filepath.Join("/srv/onwatch", "exports") {
+		t.Errorf("ExportDir = %q, want exports in the data directory", cfg.ExportDir)
+	}
+
+	os.Setenv("ONWATCH_EXPORT_DIR", "/backups")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.ExportDir != "/backups" {
+		t.Errorf("ExportDir = %q, want the explicit directory", cfg.ExportDir)
+	}
+}
+
+func TestConfig_CookieAttributes(t *testing.T) {
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.SecureCookies != "auto" || cfg.CookieSameSite != "lax" || cfg.CookiePath != "/" || cfg.CookieDomain != "" {
+		t.Errorf("defaults = secure %q samesite %q path %q domain %q, want auto, lax, /", cfg.SecureCookies, cfg.CookieSameSite, cfg.CookiePath, cfg.CookieDomain)
+	}
+
+	os.Setenv("ONWATCH_SECURE_COOKIES", "1")
+	os.Setenv("ONWATCH_COOKIE_SAMESITE", "None")
+	os.Setenv("ONWATCH_COOKIE_DOMAIN", "example.com")
+	os.Setenv("ONWATCH_COOKIE_PATH", "/onwatch")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.SecureCookies != "true" || cfg.CookieSameSite != "none" || cfg.CookieDomain != "example.com" || cfg.CookiePath != "/onwatch" {
+		t.Errorf("configured = secure %q samesite %q path %q domain %q", cfg.SecureCookies, cfg.CookieSameSite, cfg.CookiePath, cfg.CookieDomain)
+	}
+
+	for _, tc := range []struct{ key, value string }{
+		{"ONWATCH_SECURE_COOKIES", "sometimes"},
+		{"ONWATCH_COOKIE_SAMESITE", "loose"},
+		{"ONWATCH_COOKIE_PATH", "onwatch"},
+	} {
+		os.Clearenv()
+		os.Setenv("ZAI_API_KEY", "zai_test_key")
+		os.Setenv(tc.key, tc.value)
+		if _, err := Load(); err == nil {
+			t.Errorf("%s=%s: expected a validation error", tc.key, tc.value)
+		}
+	}
+
+	// SameSite=None cookies are dropped by browsers unless Secure
+	os.Clearenv()
+	os.Setenv("ZAI_API_KEY", "zai_test_key")
+	os.Setenv("ONWATCH_SECURE_COOKIES", "false")
+	os.Setenv("ONWATCH_COOKIE_SAMESITE", "none")
+	if _, err := Load(); err == nil {
+		t.Error("SameSite=None without secure cookies should be rejected")
+	}
+}
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "onwatch.log")
+	rf, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("openRotatingFile: %v", err)
+	}
+	defer rf.Close()
+
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "dddddd\n",
+		path + ".1": "cccccc\n",
+		path + ".2": "bbbbbb\n",
+	}
+	for p, content := range want {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("read %s: %v", p, err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", p, data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 rotated files, found %s.3", path)
+	}
+}
+
 func TestConfig_LoadsAnthropicFromEnv(t *testing.T) {
 	os.Setenv("ANTHROPIC_TOKEN", "sk-ant-test-token-123")
 	defer os.Clearenv()
@@ -0,0 +1,171 @@
+// Package export writes scheduled usage data exports to a local directory or
+// an S3-compatible bucket and prunes old ones.
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const fileNamePrefix = "onwatch-export-"
+
+// FileName names an export file by its time, e.g.
+// onwatch-export-20260102T150405Z.csv. Names sort in time order.
+func FileName(at time.Time, format string) string {
+	return fileNamePrefix + at.UTC().Format("20060102T150405Z") + "." + format
+}
+
+// Target is where exports are written.
+type Target interface {
+	// Put writes an export file, replacing any file of the same name.
+	Put(ctx context.Context, name string, data []byte) error
+	// List returns the names of the export files written so far.
+	List(ctx context.Context) ([]string, error)
+	// Delete removes an export file.
+	Delete(ctx context.Context, name string) error
+}
+
+// WriteFunc writes the usage data between start and end in format.
+type WriteFunc func(w io.Writer, format string, start, end time.Time) error
+
+// Scheduler runs an export every interval, each covering the interval since
+// the previous one, and keeps the newest retention exports.
+type Scheduler struct {
+	interval  time.Duration
+	format    string
+	retention int
+	target    Target
+	write     WriteFunc
+	logger    *slog.Logger
+}
+
+// NewScheduler creates a scheduler. retention below 1 keeps every export.
+func NewScheduler(interval time.Duration, format string, retention int, target Target, write WriteFunc, logger *slog.Logger) *Scheduler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Scheduler{
+		interval:  interval,
+		format:    format,
+		retention: retention,
+		target:    target,
+		write:     write,
+		logger:    logger,
+	}
+}
+
+// Run exports every interval until ctx is cancelled. Failures are logged and
+// retried on the next tick.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			name, err := s.RunOnce(ctx, now)
+			if err != nil {
+				s.logger.Error("Scheduled export failed", "error", err)
+				continue
+			}
+			s.logger.Info("Scheduled export written", "file", name)
+		}
+	}
+}
+
+// RunOnce writes the export ending at now and prunes old exports. It returns
+// the new file's name.
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) (string, error) {
+	var buf bytes.Buffer
+	if err := s.write(&buf, s.format, now.Add(-s.interval), now); err != nil {
+		return "", fmt.Errorf("export: %w", err)
+	}
+	name := FileName(now, s.format)
+	if err := s.target.Put(ctx, name, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("export: %w", err)
+	}
+	if err := s.prune(ctx); err != nil {
+		return name, fmt.Errorf("export: prune: %w", err)
+	}
+	return name, nil
+}
+
+// prune deletes the oldest exports beyond the retention count.
+func (s *Scheduler) prune(ctx context.Context) error {
+	if s.retention < 1 {
+		return nil
+	}
+	names, err := s.target.List(ctx)
+	if err != nil {
+		return err
+	}
+	var exports []string
+	for _, name := range names {
+		if strings.HasPrefix(name, fileNamePrefix) {
+			exports = append(exports, name)
+		}
+	}
+	if len(exports) <= s.retention {
+		return nil
+	}
+	sort.Strings(exports)
+	for _, name := range exports[:len(exports)-s.retention] {
+		if err := s.target.Delete(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DirTarget writes exports to a local directory, creating it if needed.
+type DirTarget struct {
+	Dir string
+}
+
+// Put writes the file atomically, so a reader never sees a partial export.
+func (d DirTarget) Put(_ context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(d.Dir, 0o700); err != nil {
+		return fmt.Errorf("create export directory: %w", err)
+	}
+	tmp := filepath.Join(d.Dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(d.Dir, name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// List returns the regular files in the directory.
+func (d DirTarget) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("list export directory: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Delete removes a file from the directory.
+func (d DirTarget) Delete(_ context.Context, name string) error {
+	if err := os.Remove(filepath.Join(d.Dir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete %s: %w", name, err)
+	}
+	return nil
+}
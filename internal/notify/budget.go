@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/store"
+)
+
+// budgetCheckInterval limits how often CheckBudget recomputes spend; the cost
+// projection changes slowly and is called after every provider poll.
+const budgetCheckInterval = 15 * time.Minute
+
+// budgetScopeOverall is the provider name used for the all-provider cap.
+const budgetScopeOverall = "overall"
+
+// CheckBudget compares spend for the current billing month against the
+// configured budget caps, using the same projection as /api/cost/projection.
+// Each cap alerts once per billing month when actual spend reaches
+// AlertPercent, and (if IncludeProjected) once when projected spend does.
+func (e *NotificationEngine) CheckBudget() {
+	e.mu.RLock()
+	cfg := e.cfg
+	senders := notificationSenders{
+		mailer: e.mailer,
+		push:   e.pushSender,
+		matrix: e.matrix,
+		twilio: e.twilio,
+	}
+	e.mu.RUnlock()
+
+	if !cfg.Types.Budget || senders.none() {
+		return
+	}
+
+	e.stateMu.Lock()
+	if time.Since(e.lastBudgetCheck) < budgetCheckInterval {
+		e.stateMu.Unlock()
+		return
+	}
+	e.lastBudgetCheck = time.Now()
+	e.stateMu.Unlock()
+
+	budget, err := e.store.GetBudget()
+	if err != nil {
+		e.logger.Error("failed to load budget", "error", err)
+		return
+	}
+	pricing, err := e.store.GetPricing()
+	if err != nil {
+		e.logger.Error("failed to load pricing", "error", err)
+		return
+	}
+	if budget == nil || pricing == nil {
+		return
+	}
+
+	projection, err := e.store.ProjectCost(*pricing, time.Now().UTC())
+	if err != nil {
+		e.logger.Error("failed to project cost for budget check", "error", err)
+		return
+	}
+
+	unconverted := make(map[string]bool, len(projection.Unconverted))
+	for _, p := range projection.Unconverted {
+		unconverted[p] = true
+	}
+	for _, pc := range projection.Providers {
+		// Caps are in the display currency; skip amounts that could not be converted
+		if unconverted[pc.Provider] {
+			continue
+		}
+		if limit := budget.Providers[pc.Provider]; limit > 0 {
+			e.checkBudgetCap(senders, cfg, budget, projection, pc.Provider, pc.ToDate, pc.Projected, limit)
+		}
+	}
+	if budget.Overall > 0 {
+		e.checkBudgetCap(senders, cfg, budget, projection, budgetScopeOverall, projection.ToDate, projection.Projected, budget.Overall)
+	}
+}
+
+// checkBudgetCap sends at most one actual and one projected alert per billing
+// month for a single cap. Actual spend takes priority over the projection.
+func (e *NotificationEngine) checkBudgetCap(senders notificationSenders, cfg NotificationConfig, budget *store.BudgetConfig, projection *store.CostProjection, scope string, toDate, projected, limit float64) {
+	status := QuotaStatus{
+		Provider:       scope,
+		Limit:          limit,
+		SpendToDate:    toDate,
+		SpendProjected: projected,
+		Currency:       projection.Currency,
+	}
+	switch {
+	case toDate/limit*100 >= budget.AlertPercent:
+		status.QuotaKey = "budget"
+		status.Utilization = toDate / limit * 100
+	case budget.IncludeProjected && projected/limit*100 >= budget.AlertPercent:
+		status.QuotaKey = "budget_projected"
+		status.Utilization = projected / limit * 100
+	default:
+		return
+	}
+
+	entry, err := e.store.GetNotificationLogEntry(scope, status.QuotaKey, "budget")
+	if err != nil {
+		e.logger.Error("failed to check notification log", "error", err)
+		return
+	}
+	// Already sent this billing month
+	if entry != nil && !entry.SentAt.Before(projection.PeriodStart) {
+		return
+	}
+
+	channels := cfg.channelsFor("budget")
+	if !channels.Any() {
+		return
+	}
+	if e.deliver(senders, cfg, status, "budget", channels, "") {
+		if err := e.store.UpsertNotificationLog(scope, status.QuotaKey, "budget", status.Utilization); err != nil {
+			e.logger.Error("failed to log notification", "error", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationEngine_CheckBudget(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyBudget:      true,
+		CooldownMinutes:   30,
+	})
+	if err := engine.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	s.SetSetting("pricing", `{"billing_day":1,"providers":{"anthropic":{"monthly_fee":100},"codex":{"monthly_fee":20}}}`)
+
+	// Under the cap: nothing sent
+	s.SetSetting("budget", `{"providers":{"anthropic":200},"alert_percent":80}`)
+	engine.CheckBudget()
+	if mailCount.Load() != 0 {
+		t.Fatalf("Expected no budget alert under the cap, got %d", mailCount.Load())
+	}
+
+	// Anthropic at 100 of 110 and overall 120 of 150 both cross 80%
+	s.SetSetting("budget", `{"overall":150,"providers":{"anthropic":110},"alert_percent":80}`)
+	engine.lastBudgetCheck = time.Time{}
+	engine.CheckBudget()
+	if mailCount.Load() != 2 {
+		t.Fatalf("Expected 2 budget alerts, got %d", mailCount.Load())
+	}
+	if _, util, err := s.GetLastNotification("overall", "budget", "budget"); err != nil || util != 80 {
+		t.Errorf("Expected overall budget alert at 80%%, got %v (err %v)", util, err)
+	}
+
+	// Once per billing month
+	engine.lastBudgetCheck = time.Time{}
+	engine.CheckBudget()
+	if mailCount.Load() != 2 {
+		t.Errorf("Expected no repeat budget alerts, got %d", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_CheckBudget_Throttled(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyBudget:      true,
+	})
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.CheckBudget() // nothing configured yet, but starts the interval
+	s.SetSetting("pricing", `{"providers":{"codex":{"monthly_fee":50}}}`)
+	s.SetSetting("budget", `{"providers":{"codex":50}}`)
+	engine.CheckBudget()
+	if mailCount.Load() != 0 {
+		t.Errorf("Expected budget check to be throttled, got %d alerts", mailCount.Load())
+	}
+}
+
+func TestBuildBody_BudgetIncludesRemaining(t *testing.T) {
+	status := QuotaStatus{Provider: "overall", QuotaKey: "budget", Limit: 150, SpendToDate: 120, SpendProjected: 310, Utilization: 80}
+
+	subject := buildSubject(status, "budget")
+	if subject != "[BUDGET] Overall spend at $120.00 of $150.00 budget (80.0%)" {
+		t.Errorf("unexpected subject: %q", subject)
+	}
+	body := buildBody(status, "budget")
+	if !strings.Contains(body, "Remaining: $30.00") || !strings.Contains(body, "Projected month-end: $310.00") {
+		t.Errorf("expected remaining and projected spend in body, got %q", body)
+	}
+
+	status.QuotaKey = "budget_projected"
+	if got := buildSubject(status, "budget"); !strings.Contains(got, "on track for $310.00") {
+		t.Errorf("unexpected projected subject: %q", got)
+	}
+	if data := newTemplateData(status, "budget"); data.Remaining != "$30.00" {
+		t.Errorf("expected template Remaining $30.00, got %q", data.Remaining)
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	if got := formatMoney(9, ""); got != "$9.00" {
+		t.Errorf("formatMoney without currency = %q", got)
+	}
+	if got := formatMoney(9, "EUR"); got != "9.00 EUR" {
+		t.Errorf("formatMoney with currency = %q", got)
+	}
+}
@@ -0,0 +1,264 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"time"
+)
+
+// Chart dimensions for alert emails. Kept small so the attachment stays a few KB.
+const (
+	chartWidth   = 480
+	chartHeight  = 140
+	chartPadding = 8
+	chartWindow  = 24 * time.Hour
+)
+
+var (
+	chartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	chartGrid       = color.RGBA{0xe4, 0xe4, 0xe7, 0xff}
+	chartLine       = color.RGBA{0x25, 0x63, 0xeb, 0xff}
+	chartFill       = color.RGBA{0xdb, 0xea, 0xfe, 0xff}
+	chartWarning    = color.RGBA{0xd9, 0x77, 0x06, 0xff}
+	chartCritical   = color.RGBA{0xdc, 0x26, 0x26, 0xff}
+)
+
+// renderChartPNG draws a utilization line chart (0-100%) with dashed warning
+// and critical threshold lines. Needs at least two points.
+func renderChartPNG(points []float64, warning, critical float64) ([]byte, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("notify.renderChartPNG: need at least 2 points, got %d", len(points))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{chartBackground}, image.Point{}, draw.Src)
+
+	plotW := chartWidth - 2*chartPadding
+	plotH := chartHeight - 2*chartPadding
+	yFor := func(pct float64) int {
+		if pct < 0 {
+			pct = 0
+		}
+		if pct > 100 {
+			pct = 100
+		}
+		return chartPadding + plotH - int(pct/100*float64(plotH))
+	}
+	xFor := func(i int) int {
+		return chartPadding + i*(plotW-1)/(len(points)-1)
+	}
+
+	// Horizontal grid every 25%
+	for pct := 0.0; pct <= 100; pct += 25 {
+		y := yFor(pct)
+		for x := chartPadding; x < chartWidth-chartPadding; x++ {
+			img.Set(x, y, chartGrid)
+		}
+	}
+
+	// Area under the line, then the line itself (2px)
+	for i := 0; i < len(points)-1; i++ {
+		x0, y0, x1, y1 := xFor(i), yFor(points[i]), xFor(i+1), yFor(points[i+1])
+		for x := x0; x <= x1; x++ {
+			y := y0
+			if x1 != x0 {
+				y = y0 + (y1-y0)*(x-x0)/(x1-x0)
+			}
+			for yy := y + 1; yy < chartPadding+plotH; yy++ {
+				img.Set(x, yy, chartFill)
+			}
+		}
+	}
+	for i := 0; i < len(points)-1; i++ {
+		drawLine(img, xFor(i), yFor(points[i]), xFor(i+1), yFor(points[i+1]), chartLine)
+		drawLine(img, xFor(i), yFor(points[i])+1, xFor(i+1), yFor(points[i+1])+1, chartLine)
+	}
+
+	// Dashed threshold lines drawn last so they stay visible
+	for _, th := range []struct {
+		pct float64
+		c   color.RGBA
+	}{{warning, chartWarning}, {critical, chartCritical}} {
+		if th.pct <= 0 || th.pct > 100 {
+			continue
+		}
+		y := yFor(th.pct)
+		for x := chartPadding; x < chartWidth-chartPadding; x++ {
+			if (x/6)%2 == 0 {
+				img.Set(x, y, th.c)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("notify.renderChartPNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLine draws a 1px line using Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	errAcc := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * errAcc
+		if e2 >= dy {
+			errAcc += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			errAcc += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// downsample reduces points to at most max values by averaging buckets.
+func downsample(points []float64, max int) []float64 {
+	if len(points) <= max {
+		return points
+	}
+	out := make([]float64, 0, max)
+	for i := 0; i < max; i++ {
+		start := i * len(points) / max
+		end := (i + 1) * len(points) / max
+		sum := 0.0
+		for _, v := range points[start:end] {
+			sum += v
+		}
+		out = append(out, sum/float64(end-start))
+	}
+	return out
+}
+
+// quotaHistory returns utilization percentages for a provider quota over the
+// last chartWindow, oldest first, from the same range queries the dashboard uses.
+func (e *NotificationEngine) quotaHistory(provider, quotaKey string) ([]float64, error) {
+	end := time.Now().UTC()
+	start := end.Add(-chartWindow)
+	var points []float64
+
+	switch provider {
+	case "synthetic":
+		snaps, err := e.store.QueryRange(start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snaps {
+			q := s.Sub
+			switch quotaKey {
+			case "search":
+				q = s.Search
+			case "toolcall":
+				q = s.ToolCall
+			}
+			if q.Limit > 0 {
+				points = append(points, q.Requests/q.Limit*100)
+			}
+		}
+	case "anthropic":
+		snaps, err := e.store.QueryAnthropicRange(start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snaps {
+			for _, q := range s.Quotas {
+				if q.Name == quotaKey {
+					points = append(points, q.Utilization)
+				}
+			}
+		}
+	case "codex":
+		snaps, err := e.store.QueryCodexRange(start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snaps {
+			for _, q := range s.Quotas {
+				if q.Name == quotaKey {
+					points = append(points, q.Utilization)
+				}
+			}
+		}
+	case "copilot":
+		snaps, err := e.store.QueryCopilotRange(start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snaps {
+			for _, q := range s.Quotas {
+				if q.Name == quotaKey && q.Entitlement > 0 {
+					points = append(points, float64(q.Entitlement-q.Remaining)/float64(q.Entitlement)*100)
+				}
+			}
+		}
+	case "zai":
+		snaps, err := e.store.QueryZaiRange(start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snaps {
+			switch {
+			case quotaKey == "tokens":
+				points = append(points, float64(s.TokensPercentage))
+			case quotaKey == "time" && s.TimeUsage > 0:
+				points = append(points, s.TimeCurrentValue/s.TimeUsage*100)
+			}
+		}
+	case "antigravity":
+		snaps, err := e.store.QueryAntigravityRange(start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snaps {
+			for _, m := range s.Models {
+				if m.ModelID == quotaKey {
+					points = append(points, (1.0-m.RemainingFraction)*100)
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("notify.quotaHistory: unknown provider %q", provider)
+	}
+
+	return downsample(points, chartWidth/4), nil
+}
+
+// chartCID is the Content-ID of the chart image in alert emails.
+const chartCID = "usage-chart@onwatch"
+
+// buildChart renders the recent-history chart for the quota in status.
+func (e *NotificationEngine) buildChart(cfg NotificationConfig, status QuotaStatus) (InlineImage, error) {
+	points, err := e.quotaHistory(normalizeNotificationProvider(status.Provider), status.QuotaKey)
+	if err != nil {
+		return InlineImage{}, err
+	}
+	data, err := renderChartPNG(points, cfg.Warning, cfg.Critical)
+	if err != nil {
+		return InlineImage{}, err
+	}
+	return InlineImage{CID: chartCID, ContentType: "image/png", Data: data}, nil
+}
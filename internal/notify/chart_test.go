@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+func TestRenderChartPNG(t *testing.T) {
+	data, err := renderChartPNG([]float64{10, 35, 60, 82, 120}, 80, 95)
+	if err != nil {
+		t.Fatalf("renderChartPNG failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("output is not a valid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != chartWidth || b.Dy() != chartHeight {
+		t.Errorf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), chartWidth, chartHeight)
+	}
+
+	if _, err := renderChartPNG([]float64{50}, 80, 95); err == nil {
+		t.Error("expected error for a single point")
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	points := make([]float64, 1000)
+	for i := range points {
+		points[i] = float64(i % 10)
+	}
+	out := downsample(points, 100)
+	if len(out) != 100 {
+		t.Fatalf("len = %d, want 100", len(out))
+	}
+	if out[0] != 4.5 {
+		t.Errorf("bucket average = %v, want 4.5", out[0])
+	}
+	if short := downsample([]float64{1, 2}, 100); len(short) != 2 {
+		t.Errorf("short input should be returned as-is, got %v", short)
+	}
+}
+
+func TestNotificationEngine_QuotaHistory_Anthropic(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	now := time.Now().UTC()
+	for i, util := range []float64{20, 40, 60} {
+		s.InsertAnthropicSnapshot(&api.AnthropicSnapshot{
+			CapturedAt: now.Add(time.Duration(i-3) * time.Hour),
+			Quotas: []api.AnthropicQuota{
+				{Name: "five_hour", Utilization: util},
+				{Name: "seven_day", Utilization: 5},
+			},
+		})
+	}
+	// Outside the chart window
+	s.InsertAnthropicSnapshot(&api.AnthropicSnapshot{
+		CapturedAt: now.Add(-48 * time.Hour),
+		Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: 99}},
+	})
+
+	engine := newTestEngine(t, s)
+	points, err := engine.quotaHistory("anthropic", "five_hour")
+	if err != nil {
+		t.Fatalf("quotaHistory failed: %v", err)
+	}
+	if len(points) != 3 || points[0] != 20 || points[2] != 60 {
+		t.Errorf("points = %v, want [20 40 60]", points)
+	}
+
+	if _, err := engine.quotaHistory("unknown", "x"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+
+	chart, err := engine.buildChart(engine.Config(), QuotaStatus{Provider: "Anthropic", QuotaKey: "five_hour"})
+	if err != nil {
+		t.Fatalf("buildChart failed: %v", err)
+	}
+	if chart.CID != chartCID || chart.ContentType != "image/png" || len(chart.Data) == 0 {
+		t.Errorf("unexpected chart image: cid=%q type=%q len=%d", chart.CID, chart.ContentType, len(chart.Data))
+	}
+}
@@ -0,0 +1,39 @@
+package notify
+
+import "time"
+
+// NotifyCircuitOpen alerts that polling for a provider has been paused by its
+// circuit breaker after repeated auth or server failures. The breaker only
+// reports the closed-to-open transition, so no deduplication is needed here.
+func (e *NotificationEngine) NotifyCircuitOpen(provider string, failures int, lastErr string, retryAt time.Time) {
+	e.mu.RLock()
+	cfg := e.cfg
+	senders := notificationSenders{
+		mailer: e.mailer,
+		push:   e.pushSender,
+		matrix: e.matrix,
+		twilio: e.twilio,
+	}
+	e.mu.RUnlock()
+
+	if !cfg.Types.Circuit || senders.none() {
+		return
+	}
+	channels := cfg.channelsFor("circuit")
+	if !channels.Any() {
+		return
+	}
+
+	status := QuotaStatus{
+		Provider: normalizeNotificationProvider(provider),
+		QuotaKey: "circuit",
+		ResetAt:  &retryAt,
+		Failures: failures,
+		Error:    lastErr,
+	}
+	if e.deliver(senders, cfg, status, "circuit", channels, "") {
+		if err := e.store.UpsertNotificationLog(status.Provider, "circuit", "circuit", float64(failures)); err != nil {
+			e.logger.Error("failed to log notification", "error", err)
+		}
+	}
+}
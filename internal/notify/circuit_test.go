@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationEngine_NotifyCircuitOpen(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	// Enabled by default before any notification settings are saved
+	engine.NotifyCircuitOpen("copilot", 10, "copilot: unauthorized - invalid token", time.Now().Add(15*time.Minute))
+	if mailCount.Load() != 1 {
+		t.Fatalf("Expected 1 circuit alert, got %d", mailCount.Load())
+	}
+
+	// Settings saved without notify_circuit keep it on
+	storeNotificationConfig(t, s, notificationSettingsJSON{WarningThreshold: 80, CriticalThreshold: 95})
+	engine.Reload()
+	engine.NotifyCircuitOpen("copilot", 10, "copilot: unauthorized - invalid token", time.Now().Add(15*time.Minute))
+	if mailCount.Load() != 2 {
+		t.Fatalf("Expected circuit alerts to stay on without notify_circuit, got %d", mailCount.Load())
+	}
+
+	off := false
+	storeNotificationConfig(t, s, notificationSettingsJSON{WarningThreshold: 80, CriticalThreshold: 95, NotifyCircuit: &off})
+	engine.Reload()
+	engine.NotifyCircuitOpen("copilot", 10, "copilot: unauthorized - invalid token", time.Now().Add(15*time.Minute))
+	if mailCount.Load() != 2 {
+		t.Errorf("Expected no alert with notify_circuit disabled, got %d", mailCount.Load())
+	}
+}
+
+func TestBuildBody_Circuit(t *testing.T) {
+	retry := time.Date(2026, 3, 1, 12, 15, 0, 0, time.UTC)
+	status := QuotaStatus{Provider: "codex", QuotaKey: "circuit", Failures: 10, Error: "codex: unauthorized", ResetAt: &retry}
+
+	if got := buildSubject(status, "circuit"); got != "[CIRCUIT OPEN] Codex polling paused after 10 consecutive failures" {
+		t.Errorf("unexpected subject: %q", got)
+	}
+	body := buildBody(status, "circuit")
+	for _, want := range []string{"Last error: codex: unauthorized", "Next retry: 2026-03-01T12:15:00Z"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in body, got %q", want, body)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// alertEmailTemplate renders the HTML part of alert emails. Styles are inline
+// because most email clients strip <style> blocks.
+var alertEmailTemplate = template.Must(template.New("alert").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f4f4f5;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;color:#18181b;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="max-width:560px;margin:0 auto;background:#ffffff;border-radius:8px;overflow:hidden;">
+<tr><td style="background:{{.Color}};color:#ffffff;padding:16px 24px;font-size:16px;font-weight:600;">{{.Subject}}</td></tr>
+<tr><td style="padding:24px;">
+{{- if .ShowBar}}
+<div style="font-size:13px;color:#52525b;margin-bottom:6px;">{{.Quota}} &middot; {{.Percent}}% used</div>
+<div style="background:#e4e4e7;border-radius:4px;height:10px;overflow:hidden;margin-bottom:20px;">
+<div style="background:{{.Color}};width:{{.BarWidth}}%;height:10px;"></div>
+</div>
+{{- end}}
+{{- if .ChartSrc}}
+<div style="font-size:12px;color:#71717a;margin-bottom:4px;">Last 24 hours</div>
+<img src="{{.ChartSrc}}" width="480" height="140" alt="Recent usage chart" style="display:block;max-width:100%;height:auto;margin-bottom:20px;border:1px solid #e4e4e7;border-radius:4px;">
+{{- end}}
+<div style="font-size:14px;line-height:1.6;white-space:pre-wrap;">{{.Text}}</div>
+</td></tr>
+<tr><td style="padding:12px 24px;border-top:1px solid #e4e4e7;font-size:12px;color:#a1a1aa;">Sent by onWatch</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// statusColors maps notification types to banner/bar colors.
+var statusColors = map[string]string{
+	"warning":    "#d97706",
+	"critical":   "#dc2626",
+	"exhaustion": "#ea580c",
+	"reset":      "#16a34a",
+	"recovered":  "#16a34a",
+	"latency":    "#7c3aed",
+	"budget":     "#0891b2",
+	"circuit":    "#dc2626",
+	"update":     "#2563eb",
+	"schema":     "#d97706",
+}
+
+type alertEmailData struct {
+	Subject  string
+	Quota    string
+	Percent  string
+	BarWidth string // CSS-safe number, clamped to 0-100
+	ShowBar  bool
+	Color    template.CSS
+	ChartSrc template.URL // "cid:..." reference to an inline chart image; empty for none
+	Text     string
+}
+
+// buildAlertHTML renders the HTML email body for an alert. text is the
+// plaintext body (default or templated), shown below the usage bar. chartCID,
+// if set, references an inline chart image attached to the same message.
+func buildAlertHTML(status QuotaStatus, notifType, subject, text, chartCID string) (string, error) {
+	color, ok := statusColors[notifType]
+	if !ok {
+		color = "#52525b"
+	}
+	width := status.Utilization
+	if width < 0 {
+		width = 0
+	}
+	if width > 100 {
+		width = 100
+	}
+
+	// Drop the plaintext signature; the HTML layout has its own footer
+	text = strings.TrimSuffix(strings.TrimRight(text, "\n"), "-- Sent by onWatch")
+
+	var chartSrc template.URL
+	if chartCID != "" {
+		chartSrc = template.URL("cid:" + chartCID)
+	}
+
+	var sb strings.Builder
+	if err := alertEmailTemplate.Execute(&sb, alertEmailData{
+		Subject:  subject,
+		Quota:    status.quotaName(),
+		Percent:  fmt.Sprintf("%.1f", status.Utilization),
+		BarWidth: fmt.Sprintf("%.1f", width),
+		ShowBar:  notifType != "reset" && notifType != "latency" && notifType != "circuit" && notifType != "update" && notifType != "schema",
+		Color:    template.CSS(color),
+		ChartSrc: chartSrc,
+		Text:     strings.TrimSpace(text),
+	}); err != nil {
+		return "", fmt.Errorf("notify.buildAlertHTML: %w", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildAlertHTML_ColorAndBar(t *testing.T) {
+	status := QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 96.4}
+	html, err := buildAlertHTML(status, "critical", "[CRITICAL] Anthropic quota five_hour at 96.4%", buildBody(status, "critical"), "")
+	if err != nil {
+		t.Fatalf("buildAlertHTML failed: %v", err)
+	}
+
+	if !strings.Contains(html, statusColors["critical"]) {
+		t.Error("expected critical color in HTML")
+	}
+	if !strings.Contains(html, "width:96.4%") {
+		t.Error("expected usage bar at 96.4%")
+	}
+	if !strings.Contains(html, "Utilization: 96.4%") {
+		t.Error("expected plaintext details in HTML")
+	}
+	if strings.Count(html, "Sent by onWatch") != 1 {
+		t.Error("expected a single footer signature")
+	}
+}
+
+func TestBuildAlertHTML_EscapesAndClamps(t *testing.T) {
+	status := QuotaStatus{Provider: "codex", QuotaKey: "<script>", Utilization: 140}
+	html, err := buildAlertHTML(status, "warning", "<b>subject</b>", "<img src=x>", "")
+	if err != nil {
+		t.Fatalf("buildAlertHTML failed: %v", err)
+	}
+
+	for _, raw := range []string{"<script>", "<b>subject</b>", "<img src=x>"} {
+		if strings.Contains(html, raw) {
+			t.Errorf("expected %q to be escaped", raw)
+		}
+	}
+	if !strings.Contains(html, "width:100.0%") {
+		t.Error("expected usage bar clamped to 100%")
+	}
+}
+
+func TestBuildAlertHTML_ResetHasNoBar(t *testing.T) {
+	status := QuotaStatus{Provider: "codex", QuotaKey: "seven_day"}
+	html, err := buildAlertHTML(status, "reset", "[RESET] Codex quota seven_day has been reset", "Quota reset", "")
+	if err != nil {
+		t.Fatalf("buildAlertHTML failed: %v", err)
+	}
+	if strings.Contains(html, "% used") {
+		t.Error("reset emails should not show a usage bar")
+	}
+	if !strings.Contains(html, statusColors["reset"]) {
+		t.Error("expected reset color in HTML")
+	}
+}
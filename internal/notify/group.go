@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultAlertGroupWindow is how long threshold alerts are buffered when no
+// alert_group_seconds is configured.
+const defaultAlertGroupWindow = 5 * time.Second
+
+// groupedAlertTypes are the threshold crossings collected by the grouping
+// window. Resets, recoveries and escalations are sent immediately.
+var groupedAlertTypes = map[string]bool{"warning": true, "critical": true, "exhaustion": true}
+
+// pendingAlert is a threshold crossing waiting in the grouping buffer.
+type pendingAlert struct {
+	status    QuotaStatus
+	notifType string
+	channels  NotificationChannels
+}
+
+func (a pendingAlert) key() string {
+	return notificationOverrideKey(a.status.logProvider(), a.status.QuotaKey) + ":" + a.notifType
+}
+
+// enqueue adds a threshold crossing to the grouping buffer, starting the flush
+// timer if the buffer was empty. A crossing already waiting for the same
+// provider, quota and type is replaced with the newer status.
+func (e *NotificationEngine) enqueue(alert pendingAlert, window time.Duration) {
+	e.groupMu.Lock()
+	defer e.groupMu.Unlock()
+	for i, p := range e.pending {
+		if p.key() == alert.key() {
+			e.pending[i] = alert
+			return
+		}
+	}
+	e.pending = append(e.pending, alert)
+	if e.groupTimer == nil {
+		e.groupTimer = time.AfterFunc(window, e.FlushAlerts)
+	}
+}
+
+// dropPending discards buffered crossings for a quota that has just reset.
+// provider is the notification log provider key (see QuotaStatus.logProvider).
+func (e *NotificationEngine) dropPending(provider, quotaKey string) {
+	prefix := notificationOverrideKey(provider, quotaKey) + ":"
+	e.groupMu.Lock()
+	defer e.groupMu.Unlock()
+	kept := e.pending[:0]
+	for _, p := range e.pending {
+		if !strings.HasPrefix(p.key(), prefix) {
+			kept = append(kept, p)
+		}
+	}
+	e.pending = kept
+}
+
+// FlushAlerts sends the buffered threshold crossings now: a single crossing
+// as its usual message, several as one combined message per channel. It runs
+// when the grouping window elapses and should be called on shutdown so
+// buffered alerts are not lost.
+func (e *NotificationEngine) FlushAlerts() {
+	e.groupMu.Lock()
+	alerts := e.pending
+	e.pending = nil
+	if e.groupTimer != nil {
+		e.groupTimer.Stop()
+		e.groupTimer = nil
+	}
+	e.groupMu.Unlock()
+	if len(alerts) == 0 {
+		return
+	}
+
+	e.mu.RLock()
+	cfg := e.cfg
+	senders := notificationSenders{
+		mailer: e.mailer,
+		push:   e.pushSender,
+		matrix: e.matrix,
+		twilio: e.twilio,
+	}
+	users := e.users
+	e.mu.RUnlock()
+
+	// Each user gets their own message, sent to their own recipients
+	var order []string
+	byUser := make(map[string][]pendingAlert)
+	for _, a := range alerts {
+		if _, ok := byUser[a.status.User]; !ok {
+			order = append(order, a.status.User)
+		}
+		byUser[a.status.User] = append(byUser[a.status.User], a)
+	}
+	for _, user := range order {
+		s, c := senders, cfg
+		if user != "" {
+			i := slices.IndexFunc(users, func(u userTarget) bool { return u.username == user })
+			if i < 0 {
+				continue // preferences removed since the alert was queued
+			}
+			s, c = senders.forUser(users[i].prefs), cfg.forUser(users[i].prefs)
+		}
+		e.flushGroup(s, c, byUser[user])
+	}
+}
+
+// flushGroup sends the buffered alerts of one set of recipients.
+func (e *NotificationEngine) flushGroup(senders notificationSenders, cfg NotificationConfig, alerts []pendingAlert) {
+	if len(alerts) == 1 {
+		a := alerts[0]
+		if e.deliver(senders, cfg, a.status, a.notifType, a.channels, "") {
+			e.recordSent(a.status, a.notifType)
+		}
+		return
+	}
+
+	sent := e.deliverGroup(senders, cfg, alerts)
+	for i, a := range alerts {
+		if sent[i] {
+			e.recordSent(a.status, a.notifType)
+		}
+	}
+}
+
+// deliverGroup sends one combined message per channel listing every alert
+// routed to it. Each line is the alert's subject (or SMS text) rendered with
+// the channel's template. Returns which alerts reached at least one channel.
+func (e *NotificationEngine) deliverGroup(senders notificationSenders, cfg NotificationConfig, alerts []pendingAlert) []bool {
+	sent := make([]bool, len(alerts))
+
+	type groupChannel struct {
+		name    string
+		enabled func(NotificationChannels) bool
+		send    func(subject, body string) bool
+	}
+	channels := []groupChannel{
+		{"email", func(c NotificationChannels) bool { return c.Email && senders.mailer != nil }, func(subject, body string) bool {
+			if err := senders.mailer.Send(subject, body); err != nil {
+				e.logger.Error("failed to send grouped email notification", "error", err)
+				return false
+			}
+			return true
+		}},
+		{"push", func(c NotificationChannels) bool { return c.Push && senders.push != nil }, func(subject, body string) bool {
+			return e.sendPush(senders.push, subject, body)
+		}},
+		{"matrix", func(c NotificationChannels) bool { return c.Matrix && senders.matrix != nil }, func(subject, body string) bool {
+			if err := senders.matrix.Send(subject, body); err != nil {
+				e.logger.Error("failed to send grouped matrix notification", "error", err)
+				return false
+			}
+			return true
+		}},
+		{"sms", func(c NotificationChannels) bool { return c.SMS && senders.twilio != nil }, func(_, body string) bool {
+			if err := senders.twilio.Send(body); err != nil {
+				e.logger.Error("failed to send grouped sms notification", "error", err)
+				return false
+			}
+			return true
+		}},
+	}
+
+	for _, ch := range channels {
+		var members []int
+		var lines []string
+		for i, a := range alerts {
+			if !ch.enabled(a.channels) {
+				continue
+			}
+			subject, body, err := RenderMessage(ch.name, cfg.Templates[ch.name], a.status, a.notifType)
+			if err != nil {
+				e.logger.Warn("notification template failed, using default", "channel", ch.name, "error", err)
+			}
+			if ch.name == "sms" {
+				subject = body
+			}
+			members = append(members, i)
+			lines = append(lines, subject)
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		subject := groupSubject(alerts, members)
+		var body string
+		if ch.name == "sms" {
+			body = subject + "\n" + strings.Join(lines, "\n")
+		} else {
+			body = "- " + strings.Join(lines, "\n- ") +
+				fmt.Sprintf("\n\nTime: %s\n\n-- Sent by onWatch", time.Now().UTC().Format(time.RFC3339))
+		}
+		if ch.send(subject, body) {
+			for _, i := range members {
+				sent[i] = true
+			}
+		}
+	}
+	return sent
+}
+
+// groupSubject summarizes a combined message by its most severe alert, e.g.
+// "[CRITICAL] 3 quota alerts".
+func groupSubject(alerts []pendingAlert, members []int) string {
+	level := "warning"
+	for _, i := range members {
+		switch alerts[i].notifType {
+		case "critical":
+			level = "critical"
+		case "exhaustion":
+			if level == "warning" {
+				level = "exhaustion"
+			}
+		}
+	}
+	return fmt.Sprintf("[%s] %d quota alerts", strings.ToUpper(level), len(members))
+}
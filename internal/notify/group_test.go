@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotificationEngine_GroupsThresholdCrossings(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	engine.cfg.GroupWindow = time.Hour // flushed by hand below
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 82, Limit: 100})
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "seven_day", Utilization: 97, Limit: 100})
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "five_hour", Utilization: 85, Limit: 100})
+	// The same crossing again before the flush is not queued twice
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "five_hour", Utilization: 86, Limit: 100})
+
+	if mailCount.Load() != 0 {
+		t.Fatalf("Expected no email before the window closes, got %d", mailCount.Load())
+	}
+	if len(engine.pending) != 3 {
+		t.Fatalf("Expected 3 pending alerts, got %d", len(engine.pending))
+	}
+	if subject := groupSubject(engine.pending, []int{0, 1, 2}); subject != "[CRITICAL] 3 quota alerts" {
+		t.Errorf("groupSubject = %q", subject)
+	}
+
+	engine.FlushAlerts()
+	if mailCount.Load() != 1 {
+		t.Fatalf("Expected 1 combined email, got %d", mailCount.Load())
+	}
+	for _, c := range []struct{ provider, quota, level string }{
+		{"anthropic", "five_hour", "warning"},
+		{"anthropic", "seven_day", "critical"},
+		{"codex", "five_hour", "warning"},
+	} {
+		sentAt, _, err := s.GetLastNotification(c.provider, c.quota, c.level)
+		if err != nil || sentAt.IsZero() {
+			t.Errorf("Expected %s %s %s to be logged (err %v)", c.provider, c.quota, c.level, err)
+		}
+	}
+	_, util, _ := s.GetLastNotification("codex", "five_hour", "warning")
+	if util != 86 {
+		t.Errorf("Logged util = %v, want the newer 86", util)
+	}
+
+	// Logged alerts are deduped as usual once flushed
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 83, Limit: 100})
+	engine.FlushAlerts()
+	if mailCount.Load() != 1 {
+		t.Errorf("Expected no further email, got %d", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_GroupWindowTimer(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	engine.cfg.GroupWindow = 20 * time.Millisecond
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 82, Limit: 100})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mailCount.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if mailCount.Load() != 1 {
+		t.Fatalf("Expected the timer to send 1 email, got %d", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_ResetDropsPendingAlerts(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	engine.cfg.GroupWindow = time.Hour
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 82, Limit: 100})
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", ResetOccurred: true})
+	engine.FlushAlerts()
+
+	if mailCount.Load() != 0 {
+		t.Errorf("Expected the reset to discard the pending warning, got %d emails", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_Reload_AlertGroupSeconds(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := New(s, nil)
+	storeNotificationConfig(t, s, notificationSettingsJSON{WarningThreshold: 80, CriticalThreshold: 95})
+	if err := engine.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := engine.Config().GroupWindow; got != defaultAlertGroupWindow {
+		t.Errorf("GroupWindow = %v, want default %v", got, defaultAlertGroupWindow)
+	}
+
+	zero := 0
+	storeNotificationConfig(t, s, notificationSettingsJSON{WarningThreshold: 80, CriticalThreshold: 95, AlertGroupSeconds: &zero})
+	if err := engine.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := engine.Config().GroupWindow; got != 0 {
+		t.Errorf("GroupWindow = %v, want 0 (disabled)", got)
+	}
+}
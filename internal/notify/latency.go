@@ -0,0 +1,82 @@
+package notify
+
+import "time"
+
+// defaultLatencyPolls is how many consecutive slow polls trigger a latency alert
+// when no count is configured.
+const defaultLatencyPolls = 3
+
+// latencyQuotaKey is the notification log key used for latency alerts, which
+// are per provider rather than per quota.
+const latencyQuotaKey = "latency"
+
+// CheckLatency records the latency of a successful provider poll and sends a
+// "latency" alert once LatencyPolls consecutive polls exceed LatencyThreshold.
+// Alerts for the same provider are spaced at least Cooldown apart; the average
+// latency (ms) is stored as the log entry's utilization.
+func (e *NotificationEngine) CheckLatency(provider string, latency time.Duration) {
+	e.mu.RLock()
+	cfg := e.cfg
+	senders := notificationSenders{
+		mailer: e.mailer,
+		push:   e.pushSender,
+		matrix: e.matrix,
+		twilio: e.twilio,
+	}
+	e.mu.RUnlock()
+
+	if cfg.LatencyThreshold <= 0 || !cfg.Types.Latency {
+		return
+	}
+	polls := cfg.LatencyPolls
+	if polls <= 0 {
+		polls = defaultLatencyPolls
+	}
+	provider = normalizeNotificationProvider(provider)
+
+	// A single fast poll ends the streak
+	e.stateMu.Lock()
+	if latency < cfg.LatencyThreshold {
+		delete(e.slowPolls, provider)
+		e.stateMu.Unlock()
+		return
+	}
+	streak := append(e.slowPolls[provider], latency)
+	if len(streak) < polls {
+		e.slowPolls[provider] = streak
+		e.stateMu.Unlock()
+		return
+	}
+	delete(e.slowPolls, provider)
+	e.stateMu.Unlock()
+
+	var total time.Duration
+	for _, l := range streak {
+		total += l
+	}
+	avg := total / time.Duration(len(streak))
+
+	if senders.none() {
+		return
+	}
+	entry, err := e.store.GetNotificationLogEntry(provider, latencyQuotaKey, "latency")
+	if err != nil {
+		e.logger.Error("failed to check notification log", "error", err)
+		return
+	}
+	if entry != nil && time.Since(entry.SentAt) < cfg.Cooldown {
+		e.logger.Debug("latency alert in cooldown", "provider", provider, "sent_at", entry.SentAt)
+		return
+	}
+
+	channels := cfg.channelsFor("latency")
+	if !channels.Any() {
+		return
+	}
+	status := QuotaStatus{Provider: provider, QuotaKey: latencyQuotaKey, Latency: avg}
+	if e.deliver(senders, cfg, status, "latency", channels, "") {
+		if err := e.store.UpsertNotificationLog(provider, latencyQuotaKey, "latency", float64(avg.Milliseconds())); err != nil {
+			e.logger.Error("failed to log notification", "error", err)
+		}
+	}
+}
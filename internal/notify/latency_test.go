@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationEngine_CheckLatency_SustainedSlowPolls(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyLatency:     true,
+		LatencyThreshold:  2000,
+		LatencyPolls:      3,
+		CooldownMinutes:   30,
+	})
+	if err := engine.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	// A fast poll in between resets the streak
+	engine.CheckLatency("anthropic", 3*time.Second)
+	engine.CheckLatency("anthropic", 3*time.Second)
+	engine.CheckLatency("anthropic", 500*time.Millisecond)
+	engine.CheckLatency("anthropic", 3*time.Second)
+	engine.CheckLatency("anthropic", 4*time.Second)
+	if mailCount.Load() != 0 {
+		t.Fatalf("Expected no alert before 3 consecutive slow polls, got %d", mailCount.Load())
+	}
+
+	engine.CheckLatency("anthropic", 5*time.Second)
+	if mailCount.Load() != 1 {
+		t.Fatalf("Expected 1 latency alert, got %d", mailCount.Load())
+	}
+	_, avgMs, err := s.GetLastNotification("anthropic", "latency", "latency")
+	if err != nil {
+		t.Fatalf("GetLastNotification failed: %v", err)
+	}
+	if avgMs != 4000 {
+		t.Errorf("Expected logged average 4000ms, got %v", avgMs)
+	}
+
+	// Still slow, but within cooldown
+	for i := 0; i < 3; i++ {
+		engine.CheckLatency("anthropic", 5*time.Second)
+	}
+	if mailCount.Load() != 1 {
+		t.Errorf("Expected cooldown to suppress repeat alert, got %d", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_CheckLatency_Disabled(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyLatency:     true,
+		CooldownMinutes:   30,
+	})
+	engine.Reload()
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	for i := 0; i < 5; i++ {
+		engine.CheckLatency("codex", time.Minute)
+	}
+	if mailCount.Load() != 0 {
+		t.Errorf("Expected no alert without a latency threshold, got %d", mailCount.Load())
+	}
+}
+
+func TestBuildSubjectAndBody_Latency(t *testing.T) {
+	status := QuotaStatus{Provider: "codex", QuotaKey: "latency", Latency: 4200 * time.Millisecond}
+
+	subject := buildSubject(status, "latency")
+	if subject != "[LATENCY] Codex API slow: 4.2s average" {
+		t.Errorf("unexpected subject: %q", subject)
+	}
+	body := buildBody(status, "latency")
+	if !strings.Contains(body, "Average latency: 4.2s") {
+		t.Errorf("expected average latency in body, got %q", body)
+	}
+	if strings.Contains(body, "Utilization") {
+		t.Errorf("latency body should not include utilization, got %q", body)
+	}
+}
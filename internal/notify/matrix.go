@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MatrixConfig holds Matrix client-server API settings.
+type MatrixConfig struct {
+	HomeserverURL string // e.g. https://matrix.example.org
+	AccessToken   string // access token of the posting user (decrypted)
+	RoomID        string // target room ID, e.g. !abc123:example.org
+}
+
+// MatrixSender posts notifications to a Matrix room via the client-server API.
+type MatrixSender struct {
+	config MatrixConfig
+	logger *slog.Logger
+	client *http.Client
+	txnSeq atomic.Uint64
+}
+
+// NewMatrixSender creates a new Matrix sender with the given config.
+func NewMatrixSender(cfg MatrixConfig, logger *slog.Logger) *MatrixSender {
+	cfg.HomeserverURL = strings.TrimRight(cfg.HomeserverURL, "/")
+	return &MatrixSender{
+		config: cfg,
+		logger: logger,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{MaxIdleConns: 1, MaxIdleConnsPerHost: 1},
+		},
+	}
+}
+
+// withRoom returns a sender posting as the same user to another room.
+func (m *MatrixSender) withRoom(roomID string) *MatrixSender {
+	cfg := m.config
+	cfg.RoomID = roomID
+	return &MatrixSender{config: cfg, logger: m.logger, client: m.client}
+}
+
+// Send posts an m.room.message event with the subject as a bold heading.
+// Each call uses a fresh transaction ID so the homeserver never dedupes alerts.
+func (m *MatrixSender) Send(subject, body string) error {
+	payload, err := json.Marshal(map[string]string{
+		"msgtype":        "m.text",
+		"body":           subject + "\n\n" + body,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": "<strong>" + html.EscapeString(subject) + "</strong><br>" + strings.ReplaceAll(html.EscapeString(body), "\n", "<br>"),
+	})
+	if err != nil {
+		return fmt.Errorf("notify.MatrixSender.Send: marshal payload: %w", err)
+	}
+
+	txnID := fmt.Sprintf("onwatch-%d-%d", time.Now().UnixNano(), m.txnSeq.Add(1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.config.HomeserverURL, url.PathEscape(m.config.RoomID), url.PathEscape(txnID))
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("notify.MatrixSender.Send: create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+m.config.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify.MatrixSender.Send: HTTP PUT: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("notify.MatrixSender.Send: homeserver returned %d", resp.StatusCode)
+	}
+
+	if m.logger != nil {
+		m.logger.Info("matrix message sent", "subject", subject)
+	}
+	return nil
+}
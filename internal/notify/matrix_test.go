@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// mockMatrixServer records m.room.message events sent to it.
+func mockMatrixServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32, func() (string, string, map[string]string)) {
+	t.Helper()
+	var count atomic.Int32
+	var mu sync.Mutex
+	var lastPath, lastAuth string
+	var lastBody map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		lastPath = r.URL.EscapedPath()
+		lastAuth = r.Header.Get("Authorization")
+		lastBody = nil
+		json.Unmarshal(data, &lastBody)
+		mu.Unlock()
+		count.Add(1)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"event_id":"$abc"}`))
+	}))
+	return srv, &count, func() (string, string, map[string]string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return lastPath, lastAuth, lastBody
+	}
+}
+
+func TestMatrixSender_Send(t *testing.T) {
+	srv, count, last := mockMatrixServer(t, http.StatusOK)
+	defer srv.Close()
+
+	sender := NewMatrixSender(MatrixConfig{
+		HomeserverURL: srv.URL + "/",
+		AccessToken:   "syt_token",
+		RoomID:        "!room:example.org",
+	}, nil)
+
+	if err := sender.Send("[WARNING] quota", "Utilization: 82%\n<b>"); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if count.Load() != 1 {
+		t.Fatalf("requests = %d, want 1", count.Load())
+	}
+
+	path, auth, body := last()
+	if !strings.HasPrefix(path, "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/onwatch-") {
+		t.Errorf("unexpected path %q", path)
+	}
+	if auth != "Bearer syt_token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if body["msgtype"] != "m.text" {
+		t.Errorf("msgtype = %q, want m.text", body["msgtype"])
+	}
+	if !strings.Contains(body["body"], "Utilization: 82%") {
+		t.Errorf("body missing content: %q", body["body"])
+	}
+	if strings.Contains(body["formatted_body"], "<b>") {
+		t.Errorf("formatted_body should escape HTML: %q", body["formatted_body"])
+	}
+}
+
+func TestMatrixSender_Send_UniqueTxnIDs(t *testing.T) {
+	srv, _, last := mockMatrixServer(t, http.StatusOK)
+	defer srv.Close()
+
+	sender := NewMatrixSender(MatrixConfig{HomeserverURL: srv.URL, AccessToken: "t", RoomID: "!r:x"}, nil)
+	sender.Send("a", "b")
+	first, _, _ := last()
+	sender.Send("a", "b")
+	second, _, _ := last()
+	if first == second {
+		t.Errorf("expected distinct transaction IDs, both were %q", first)
+	}
+}
+
+func TestMatrixSender_Send_ErrorStatus(t *testing.T) {
+	srv, _, _ := mockMatrixServer(t, http.StatusForbidden)
+	defer srv.Close()
+
+	sender := NewMatrixSender(MatrixConfig{HomeserverURL: srv.URL, AccessToken: "bad", RoomID: "!r:x"}, nil)
+	err := sender.Send("subject", "body")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected 403 error, got %v", err)
+	}
+}
+
+// storeMatrixConfig saves encrypted Matrix settings matching the handler's format.
+func storeMatrixConfig(t *testing.T, s interface{ SetSetting(string, string) error }, key, homeserver, token, room string) {
+	t.Helper()
+	enc := func(v string) string {
+		out, err := EncryptForStorage(v, key)
+		if err != nil {
+			t.Fatalf("EncryptForStorage: %v", err)
+		}
+		return out
+	}
+	data, _ := json.Marshal(matrixSettingsJSON{
+		HomeserverURL: enc(homeserver),
+		AccessToken:   enc(token),
+		RoomID:        enc(room),
+	})
+	s.SetSetting("matrix", string(data))
+}
+
+func TestNotificationEngine_ConfigureMatrix_DecryptsAndSends(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	srv, count, last := mockMatrixServer(t, http.StatusOK)
+	defer srv.Close()
+
+	key, _ := GenerateEncryptionKey()
+	storeMatrixConfig(t, s, key, srv.URL, "secret-token", "!room:example.org")
+
+	engine := newTestEngine(t, s)
+	engine.SetEncryptionKey(key)
+	engine.Reload()
+	if err := engine.ConfigureMatrix(); err != nil {
+		t.Fatalf("ConfigureMatrix failed: %v", err)
+	}
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 96, Limit: 100})
+	if count.Load() != 1 {
+		t.Fatalf("expected 1 matrix message, got %d", count.Load())
+	}
+	_, auth, _ := last()
+	if auth != "Bearer secret-token" {
+		t.Errorf("Authorization = %q, want decrypted token", auth)
+	}
+
+	// Shared dedup: same quota+type does not fire again in this cycle
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 97, Limit: 100})
+	if count.Load() != 1 {
+		t.Errorf("expected dedup to suppress second message, got %d", count.Load())
+	}
+}
+
+func TestNotificationEngine_ConfigureMatrix_WrongKey(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	key, _ := GenerateEncryptionKey()
+	otherKey, _ := GenerateEncryptionKey()
+	storeMatrixConfig(t, s, key, "https://matrix.example.org", "tok", "!r:x")
+
+	engine := newTestEngine(t, s)
+	engine.SetEncryptionKey(otherKey)
+	if err := engine.ConfigureMatrix(); err == nil {
+		t.Error("expected decrypt error with wrong key")
+	}
+	if err := engine.SendTestMatrix(); err == nil {
+		t.Error("expected SendTestMatrix to fail when Matrix is not configured")
+	}
+}
+
+func TestNotificationEngine_Check_MatrixChannelDisabled(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	srv, count, _ := mockMatrixServer(t, http.StatusOK)
+	defer srv.Close()
+
+	key, _ := GenerateEncryptionKey()
+	storeMatrixConfig(t, s, key, srv.URL, "tok", "!r:x")
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+		Channels:          &NotificationChannels{Email: true, Push: true, Matrix: false},
+	})
+
+	engine := newTestEngine(t, s)
+	engine.SetEncryptionKey(key)
+	engine.Reload()
+	engine.ConfigureMatrix()
+
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "five_hour", Utilization: 99, Limit: 100})
+	if count.Load() != 0 {
+		t.Errorf("expected no matrix message when channel disabled, got %d", count.Load())
+	}
+}
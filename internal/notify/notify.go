@@ -11,16 +11,38 @@ import (
 	"github.com/onllm-dev/onwatch/internal/store"
 )
 
-// NotificationEngine evaluates quota statuses and sends alerts via email and push.
+// NotificationEngine evaluates quota statuses and sends alerts via email, push, Matrix, and SMS.
 type NotificationEngine struct {
 	store          *store.Store
 	logger         *slog.Logger
 	mailer         *SMTPMailer
 	pushSender     *PushSender
+	matrix         *MatrixSender
+	twilio         *TwilioSender
+	webhook        *WebhookSender
 	vapidPublicKey string
 	mu             sync.RWMutex
 	cfg            NotificationConfig
-	encryptionKey  string // hex-encoded key for decrypting SMTP passwords
+	users          []userTarget // users with their own preferences, alerted in addition to the global recipients
+	encryptionKey  string       // hex-encoded key for decrypting SMTP passwords and Matrix/Twilio credentials
+
+	// openAlerts caches the most severe open threshold alert per provider+quota
+	// ("warning"/"critical"), so Check knows when to emit a recovered message.
+	// Missing keys are loaded from the notification log on first use.
+	stateMu    sync.Mutex
+	openAlerts map[string]string
+
+	// slowPolls holds the current run of over-threshold poll latencies per provider.
+	slowPolls map[string][]time.Duration
+
+	// lastBudgetCheck throttles CheckBudget (see budgetCheckInterval).
+	lastBudgetCheck time.Time
+
+	// pending buffers threshold crossings until groupTimer fires FlushAlerts
+	// (see GroupWindow).
+	groupMu    sync.Mutex
+	pending    []pendingAlert
+	groupTimer *time.Timer
 }
 
 // NotificationConfig holds threshold and delivery settings.
@@ -29,14 +51,68 @@ type NotificationConfig struct {
 	Critical  float64                      // global critical threshold (default 95)
 	Overrides map[string]ThresholdOverride // per provider+quota overrides (legacy key: quota only)
 	Cooldown  time.Duration                // minimum time between notifications
-	Types     NotificationTypes            // which notification types are enabled
-	Channels  NotificationChannels         // which delivery channels are enabled
+	// GroupWindow buffers threshold alerts for this long and sends crossings
+	// that arrive together as one message; 0 sends each immediately.
+	GroupWindow time.Duration
+	Types       NotificationTypes               // which notification types are enabled
+	Channels    NotificationChannels            // which delivery channels are enabled
+	SMSLevels   map[string]bool                 // which notification types are sent by SMS ("critical", "exhaustion")
+	Routing     map[string]NotificationChannels // per-level channel routing; replaces Channels/SMSLevels when set
+	Templates   map[string]MessageTemplate      // per-channel message templates ("email", "push", "matrix", "sms")
+
+	EscalationAfter   time.Duration // re-send unacknowledged critical alerts after this long; 0 disables
+	EscalationChannel string        // extra channel added when escalating ("email", "push", "matrix", "sms"); "" for none
+
+	IncludeChart bool // attach a recent-usage chart to alert emails
+
+	LatencyThreshold time.Duration // poll latency above this counts as slow; 0 disables latency alerts
+	LatencyPolls     int           // consecutive slow polls before alerting (default 3)
+}
+
+// NotificationLevels lists the notification types that can be routed.
+var NotificationLevels = []string{"warning", "critical", "reset", "exhaustion", "recovered", "latency", "budget", "circuit", "update", "schema"}
+
+// channelsFor returns the delivery channels for a notification type.
+// Explicit routing wins; otherwise the global channel toggles apply, with SMS
+// limited to SMSLevels. SMS is never used for levels outside smsEligibleLevels.
+func (c NotificationConfig) channelsFor(notifType string) NotificationChannels {
+	ch, ok := c.Routing[notifType]
+	if !ok {
+		ch = c.Channels
+		ch.SMS = ch.SMS && c.SMSLevels[notifType]
+	}
+	ch.SMS = ch.SMS && smsEligibleLevels[notifType]
+	return ch
+}
+
+// Any reports whether at least one channel is enabled.
+func (c NotificationChannels) Any() bool {
+	return c.Email || c.Push || c.Matrix || c.SMS
 }
 
 // NotificationChannels controls which delivery channels are active.
 type NotificationChannels struct {
-	Email bool `json:"email"`
-	Push  bool `json:"push"`
+	Email  bool `json:"email"`
+	Push   bool `json:"push"`
+	Matrix bool `json:"matrix"`
+	SMS    bool `json:"sms"`
+}
+
+// defaultSMSLevels are the notification types sent by SMS when none are configured.
+// SMS costs money per message, so only the most urgent types are eligible.
+var defaultSMSLevels = []string{"critical", "exhaustion"}
+
+// smsEligibleLevels lists the notification types that may be routed to SMS.
+var smsEligibleLevels = map[string]bool{"critical": true, "exhaustion": true}
+
+func smsLevelSet(levels []string) map[string]bool {
+	set := make(map[string]bool, len(levels))
+	for _, l := range levels {
+		if smsEligibleLevels[l] {
+			set[l] = true
+		}
+	}
+	return set
 }
 
 // ThresholdOverride allows per-quota threshold customization.
@@ -48,32 +124,69 @@ type ThresholdOverride struct {
 
 // NotificationTypes controls which notification types are enabled.
 type NotificationTypes struct {
-	Warning  bool `json:"warning"`
-	Critical bool `json:"critical"`
-	Reset    bool `json:"reset"`
+	Warning    bool `json:"warning"`
+	Critical   bool `json:"critical"`
+	Reset      bool `json:"reset"`
+	Exhaustion bool `json:"exhaustion"`
+	Recovered  bool `json:"recovered"`
+	Latency    bool `json:"latency"`
+	Budget     bool `json:"budget"`
+	Circuit    bool `json:"circuit"`
+	Update     bool `json:"update"`
+	Schema     bool `json:"schema"`
 }
 
 // QuotaStatus represents the current state of a quota for notification evaluation.
 type QuotaStatus struct {
 	Provider      string
 	QuotaKey      string
+	QuotaLabel    string // name shown in messages, e.g. an Antigravity model's label; QuotaKey when empty
 	Utilization   float64
 	Limit         float64
+	ProjectedUtil float64    // projected utilization % at reset from the current burn rate; 0 if unknown
+	ResetAt       *time.Time // when the quota next resets; nil if unknown
 	ResetOccurred bool
+	Latency       time.Duration // average API latency, for "latency" alerts only
+	User          string        // user the alert is addressed to; "" for the global recipients
+
+	// Budget alerts only: Provider is the capped provider (or "overall"),
+	// Limit the monthly cap and Utilization the percentage of it spent.
+	SpendToDate    float64
+	SpendProjected float64
+	Currency       string // display currency code; "" formats amounts in dollars
+
+	// Circuit alerts only: consecutive failures and the last error. ResetAt
+	// holds the next retry time.
+	Failures int
+	Error    string
+
+	// Schema alerts only: the response fields that went missing. Error
+	// summarizes them.
+	Fields []string
+
+	// Update alerts only: the versions before and after a self-update and
+	// what started it.
+	FromVersion string
+	ToVersion   string
+	Trigger     string
 }
 
 // New creates a new NotificationEngine with default configuration.
 func New(s *store.Store, logger *slog.Logger) *NotificationEngine {
 	return &NotificationEngine{
-		store:  s,
-		logger: logger,
+		store:      s,
+		logger:     logger,
+		openAlerts: make(map[string]string),
+		slowPolls:  make(map[string][]time.Duration),
 		cfg: NotificationConfig{
-			Warning:   80,
-			Critical:  95,
-			Overrides: make(map[string]ThresholdOverride),
-			Cooldown:  30 * time.Minute,
-			Types:     NotificationTypes{Warning: true, Critical: true, Reset: false},
-			Channels:  NotificationChannels{Email: true, Push: true},
+			Warning:     80,
+			Critical:    95,
+			Overrides:   make(map[string]ThresholdOverride),
+			Cooldown:    30 * time.Minute,
+			GroupWindow: defaultAlertGroupWindow,
+			Types:       NotificationTypes{Warning: true, Critical: true, Reset: false, Circuit: true, Schema: true},
+			Channels:    NotificationChannels{Email: true, Push: true, Matrix: true, SMS: true},
+			SMSLevels:   smsLevelSet(defaultSMSLevels),
 		},
 	}
 }
@@ -97,18 +210,52 @@ func (e *NotificationEngine) Config() NotificationConfig {
 		overrides[k] = v
 	}
 	cfg.Overrides = overrides
+	smsLevels := make(map[string]bool, len(e.cfg.SMSLevels))
+	for k, v := range e.cfg.SMSLevels {
+		smsLevels[k] = v
+	}
+	cfg.SMSLevels = smsLevels
+	if e.cfg.Routing != nil {
+		routing := make(map[string]NotificationChannels, len(e.cfg.Routing))
+		for k, v := range e.cfg.Routing {
+			routing[k] = v
+		}
+		cfg.Routing = routing
+	}
+	if e.cfg.Templates != nil {
+		templates := make(map[string]MessageTemplate, len(e.cfg.Templates))
+		for k, v := range e.cfg.Templates {
+			templates[k] = v
+		}
+		cfg.Templates = templates
+	}
 	return cfg
 }
 
 // notificationSettingsJSON matches the JSON shape saved by the handler's UpdateSettings.
 type notificationSettingsJSON struct {
-	WarningThreshold  float64               `json:"warning_threshold"`
-	CriticalThreshold float64               `json:"critical_threshold"`
-	NotifyWarning     bool                  `json:"notify_warning"`
-	NotifyCritical    bool                  `json:"notify_critical"`
-	NotifyReset       bool                  `json:"notify_reset"`
-	CooldownMinutes   int                   `json:"cooldown_minutes"`
-	Channels          *NotificationChannels `json:"channels,omitempty"`
+	WarningThreshold  float64                         `json:"warning_threshold"`
+	CriticalThreshold float64                         `json:"critical_threshold"`
+	NotifyWarning     bool                            `json:"notify_warning"`
+	NotifyCritical    bool                            `json:"notify_critical"`
+	NotifyReset       bool                            `json:"notify_reset"`
+	NotifyExhaustion  bool                            `json:"notify_exhaustion"`
+	NotifyRecovered   bool                            `json:"notify_recovered"`
+	CooldownMinutes   int                             `json:"cooldown_minutes"`
+	AlertGroupSeconds *int                            `json:"alert_group_seconds,omitempty"`
+	Channels          *NotificationChannels           `json:"channels,omitempty"`
+	SMSLevels         []string                        `json:"sms_levels,omitempty"`
+	Routing           map[string]NotificationChannels `json:"routing,omitempty"`
+	EscalationMinutes int                             `json:"escalation_minutes,omitempty"`
+	EscalationChannel string                          `json:"escalation_channel,omitempty"`
+	AlertIncludeChart bool                            `json:"alert_include_chart"`
+	NotifyLatency     bool                            `json:"notify_latency"`
+	NotifyBudget      bool                            `json:"notify_budget"`
+	NotifyCircuit     *bool                           `json:"notify_circuit,omitempty"` // nil (never saved) keeps circuit alerts on
+	NotifyUpdate      bool                            `json:"notify_update"`
+	NotifySchema      *bool                           `json:"notify_schema,omitempty"` // nil (never saved) keeps schema alerts on
+	LatencyThreshold  int                             `json:"latency_threshold_ms,omitempty"`
+	LatencyPolls      int                             `json:"latency_polls,omitempty"`
 	Overrides         []struct {
 		QuotaKey   string  `json:"quota_key"`
 		Provider   string  `json:"provider"`
@@ -124,6 +271,18 @@ func (e *NotificationEngine) Reload() error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	e.users = e.loadUserTargets()
+
+	e.cfg.Templates = nil
+	if tv, err := e.store.GetSetting("notification_templates"); err == nil && tv != "" {
+		var templates map[string]MessageTemplate
+		if err := json.Unmarshal([]byte(tv), &templates); err != nil {
+			e.logger.Error("invalid notification templates JSON, using defaults", "error", err)
+		} else {
+			e.cfg.Templates = templates
+		}
+	}
+
 	v, err := e.store.GetSetting("notifications")
 	if err != nil || v == "" {
 		return nil // no notification settings saved yet, keep defaults
@@ -143,10 +302,20 @@ func (e *NotificationEngine) Reload() error {
 	if notif.CooldownMinutes > 0 {
 		e.cfg.Cooldown = time.Duration(notif.CooldownMinutes) * time.Minute
 	}
+	if notif.AlertGroupSeconds != nil && *notif.AlertGroupSeconds >= 0 {
+		e.cfg.GroupWindow = time.Duration(*notif.AlertGroupSeconds) * time.Second
+	}
 	e.cfg.Types = NotificationTypes{
-		Warning:  notif.NotifyWarning,
-		Critical: notif.NotifyCritical,
-		Reset:    notif.NotifyReset,
+		Warning:    notif.NotifyWarning,
+		Critical:   notif.NotifyCritical,
+		Reset:      notif.NotifyReset,
+		Exhaustion: notif.NotifyExhaustion,
+		Recovered:  notif.NotifyRecovered,
+		Latency:    notif.NotifyLatency,
+		Budget:     notif.NotifyBudget,
+		Circuit:    notif.NotifyCircuit == nil || *notif.NotifyCircuit,
+		Update:     notif.NotifyUpdate,
+		Schema:     notif.NotifySchema == nil || *notif.NotifySchema,
 	}
 
 	overrides := make(map[string]ThresholdOverride, len(notif.Overrides))
@@ -165,8 +334,28 @@ func (e *NotificationEngine) Reload() error {
 	if notif.Channels != nil {
 		e.cfg.Channels = *notif.Channels
 	} else {
-		// Default: both channels enabled
-		e.cfg.Channels = NotificationChannels{Email: true, Push: true}
+		// Default: all channels enabled
+		e.cfg.Channels = NotificationChannels{Email: true, Push: true, Matrix: true, SMS: true}
+	}
+
+	if len(notif.SMSLevels) > 0 {
+		e.cfg.SMSLevels = smsLevelSet(notif.SMSLevels)
+	} else {
+		e.cfg.SMSLevels = smsLevelSet(defaultSMSLevels)
+	}
+
+	e.cfg.EscalationAfter = time.Duration(notif.EscalationMinutes) * time.Minute
+	e.cfg.EscalationChannel = notif.EscalationChannel
+	e.cfg.IncludeChart = notif.AlertIncludeChart
+	e.cfg.LatencyThreshold = time.Duration(notif.LatencyThreshold) * time.Millisecond
+	e.cfg.LatencyPolls = notif.LatencyPolls
+
+	e.cfg.Routing = nil
+	if len(notif.Routing) > 0 {
+		e.cfg.Routing = make(map[string]NotificationChannels, len(notif.Routing))
+		for level, ch := range notif.Routing {
+			e.cfg.Routing[strings.ToLower(strings.TrimSpace(level))] = ch
+		}
 	}
 
 	return nil
@@ -258,6 +447,150 @@ func (e *NotificationEngine) ConfigureSMTP() error {
 	return nil
 }
 
+// matrixSettingsJSON matches the JSON shape saved by the handler's UpdateSettings.
+// All three fields are stored encrypted.
+type matrixSettingsJSON struct {
+	HomeserverURL string `json:"homeserver_url"`
+	AccessToken   string `json:"access_token"`
+	RoomID        string `json:"room_id"`
+}
+
+// ConfigureMatrix initializes or updates the Matrix sender from DB settings.
+// The handler stores Matrix config as a single JSON blob under key "matrix".
+func (e *NotificationEngine) ConfigureMatrix() error {
+	matrixJSON, err := e.store.GetSetting("matrix")
+	if err != nil {
+		return fmt.Errorf("notify.ConfigureMatrix: %w", err)
+	}
+	if matrixJSON == "" {
+		e.mu.Lock()
+		e.matrix = nil
+		e.mu.Unlock()
+		return nil
+	}
+
+	var m matrixSettingsJSON
+	if err := json.Unmarshal([]byte(matrixJSON), &m); err != nil {
+		return fmt.Errorf("notify.ConfigureMatrix: invalid matrix JSON: %w", err)
+	}
+
+	e.mu.RLock()
+	key := e.encryptionKey
+	e.mu.RUnlock()
+
+	cfg := MatrixConfig{}
+	for _, f := range []struct {
+		dst  *string
+		src  string
+		name string
+	}{
+		{&cfg.HomeserverURL, m.HomeserverURL, "homeserver_url"},
+		{&cfg.AccessToken, m.AccessToken, "access_token"},
+		{&cfg.RoomID, m.RoomID, "room_id"},
+	} {
+		v, err := DecryptFromStorage(f.src, key)
+		if err != nil {
+			return fmt.Errorf("notify.ConfigureMatrix: decrypt %s: %w", f.name, err)
+		}
+		*f.dst = v
+	}
+
+	if cfg.HomeserverURL == "" || cfg.AccessToken == "" || cfg.RoomID == "" {
+		e.mu.Lock()
+		e.matrix = nil
+		e.mu.Unlock()
+		return nil
+	}
+
+	e.mu.Lock()
+	e.matrix = NewMatrixSender(cfg, e.logger)
+	e.mu.Unlock()
+
+	return nil
+}
+
+// twilioSettingsJSON matches the JSON shape saved by the handler's UpdateSettings.
+// All fields are stored encrypted; ToNumbers is comma-separated.
+type twilioSettingsJSON struct {
+	AccountSID string `json:"account_sid"`
+	AuthToken  string `json:"auth_token"`
+	FromNumber string `json:"from_number"`
+	ToNumbers  string `json:"to_numbers"`
+}
+
+// ConfigureTwilio initializes or updates the Twilio SMS sender from DB settings.
+// The handler stores Twilio config as a single JSON blob under key "twilio".
+func (e *NotificationEngine) ConfigureTwilio() error {
+	twilioJSON, err := e.store.GetSetting("twilio")
+	if err != nil {
+		return fmt.Errorf("notify.ConfigureTwilio: %w", err)
+	}
+	if twilioJSON == "" {
+		e.mu.Lock()
+		e.twilio = nil
+		e.mu.Unlock()
+		return nil
+	}
+
+	var t twilioSettingsJSON
+	if err := json.Unmarshal([]byte(twilioJSON), &t); err != nil {
+		return fmt.Errorf("notify.ConfigureTwilio: invalid twilio JSON: %w", err)
+	}
+
+	e.mu.RLock()
+	key := e.encryptionKey
+	e.mu.RUnlock()
+
+	var toNumbers string
+	cfg := TwilioConfig{}
+	for _, f := range []struct {
+		dst  *string
+		src  string
+		name string
+	}{
+		{&cfg.AccountSID, t.AccountSID, "account_sid"},
+		{&cfg.AuthToken, t.AuthToken, "auth_token"},
+		{&cfg.FromNumber, t.FromNumber, "from_number"},
+		{&toNumbers, t.ToNumbers, "to_numbers"},
+	} {
+		v, err := DecryptFromStorage(f.src, key)
+		if err != nil {
+			return fmt.Errorf("notify.ConfigureTwilio: decrypt %s: %w", f.name, err)
+		}
+		*f.dst = v
+	}
+	for _, n := range strings.Split(toNumbers, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			cfg.ToNumbers = append(cfg.ToNumbers, n)
+		}
+	}
+
+	if cfg.AccountSID == "" || cfg.AuthToken == "" || cfg.FromNumber == "" || len(cfg.ToNumbers) == 0 {
+		e.mu.Lock()
+		e.twilio = nil
+		e.mu.Unlock()
+		return nil
+	}
+
+	e.mu.Lock()
+	e.twilio = NewTwilioSender(cfg, e.logger)
+	e.mu.Unlock()
+
+	return nil
+}
+
+// ConfigureWebhook sets the generic webhook that receives event payloads.
+// An empty url disables it.
+func (e *NotificationEngine) ConfigureWebhook(url string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if url == "" {
+		e.webhook = nil
+		return
+	}
+	e.webhook = NewWebhookSender(url)
+}
+
 // ConfigurePush initializes the push notification sender.
 // Loads or generates VAPID keys, stored in the settings table as "vapid_keys".
 func (e *NotificationEngine) ConfigurePush() error {
@@ -352,28 +685,61 @@ func (e *NotificationEngine) SendTestPush() error {
 	return nil
 }
 
+// notificationSenders is a snapshot of the configured delivery channels.
+// Nil fields mean the channel is not configured.
+type notificationSenders struct {
+	mailer *SMTPMailer
+	push   *PushSender
+	matrix *MatrixSender
+	twilio *TwilioSender
+}
+
+func (s notificationSenders) none() bool {
+	return s.mailer == nil && s.push == nil && s.matrix == nil && s.twilio == nil
+}
+
 // Check evaluates a quota status against thresholds and sends notifications if needed.
-// Runs synchronously -- no goroutines spawned.
+// The global settings are evaluated first, then each user with their own
+// preferences (see UserNotificationPrefs). Runs synchronously, except that
+// threshold alerts wait in the grouping buffer when GroupWindow is set (see
+// FlushAlerts).
 func (e *NotificationEngine) Check(status QuotaStatus) {
 	e.mu.RLock()
 	cfg := e.cfg
-	mailer := e.mailer
-	pushSender := e.pushSender
+	senders := notificationSenders{
+		mailer: e.mailer,
+		push:   e.pushSender,
+		matrix: e.matrix,
+		twilio: e.twilio,
+	}
+	users := e.users
 	e.mu.RUnlock()
 
+	e.check(senders, cfg, status)
+	for _, u := range users {
+		userStatus := status
+		userStatus.User = u.username
+		e.check(senders.forUser(u.prefs), cfg.forUser(u.prefs), userStatus)
+	}
+}
+
+// check evaluates a quota status for one set of recipients.
+func (e *NotificationEngine) check(senders notificationSenders, cfg NotificationConfig, status QuotaStatus) {
 	// Need at least one channel configured
-	if mailer == nil && pushSender == nil {
+	if senders.none() {
 		return
 	}
 
 	// Handle reset: clear notification log so alerts can fire again in the new cycle
 	provider := normalizeNotificationProvider(status.Provider)
 	if status.ResetOccurred {
-		if err := e.store.ClearNotificationLog(provider, status.QuotaKey); err != nil {
+		e.dropPending(status.logProvider(), status.QuotaKey)
+		e.resolve(senders, cfg, status)
+		if err := e.store.ClearNotificationLog(status.logProvider(), status.QuotaKey); err != nil {
 			e.logger.Error("failed to clear notification log on reset", "error", err)
 		}
 		if cfg.Types.Reset {
-			e.sendNotification(mailer, pushSender, cfg.Channels, status, "reset")
+			e.sendNotification(senders, cfg, status, "reset")
 		}
 		return
 	}
@@ -420,15 +786,81 @@ func (e *NotificationEngine) Check(status QuotaStatus) {
 
 	// Check critical first (higher priority)
 	if status.Utilization >= criticalThreshold && cfg.Types.Critical {
-		e.sendNotification(mailer, pushSender, cfg.Channels, status, "critical")
+		e.sendNotification(senders, cfg, status, "critical")
 		return
 	}
 
+	// Burn-rate exhaustion: the current rate runs the quota out before reset.
+	// Deduped separately from warning, so both can fire in the same cycle.
+	if status.ProjectedUtil >= 100 && cfg.Types.Exhaustion {
+		e.sendNotification(senders, cfg, status, "exhaustion")
+	}
+
 	// Check warning
 	if status.Utilization >= warningThreshold && cfg.Types.Warning {
-		e.sendNotification(mailer, pushSender, cfg.Channels, status, "warning")
+		e.sendNotification(senders, cfg, status, "warning")
+		return
+	}
+
+	// Back below both thresholds: close any open alert
+	if status.Utilization < warningThreshold && status.Utilization < criticalThreshold {
+		e.resolve(senders, cfg, status)
+	}
+}
+
+// openAlertLevel returns the cached open alert level for a provider+quota,
+// loading it from the notification log if not yet known.
+func (e *NotificationEngine) openAlertLevel(provider, quotaKey string) string {
+	key := notificationOverrideKey(provider, quotaKey)
+	e.stateMu.Lock()
+	level, ok := e.openAlerts[key]
+	e.stateMu.Unlock()
+	if ok {
+		return level
+	}
+
+	level, err := e.store.GetOpenAlertLevel(provider, quotaKey)
+	if err != nil {
+		e.logger.Error("failed to load open alert state", "error", err)
+		return ""
+	}
+	e.setOpenAlertLevel(provider, quotaKey, level)
+	return level
+}
+
+func (e *NotificationEngine) setOpenAlertLevel(provider, quotaKey, level string) {
+	key := notificationOverrideKey(provider, quotaKey)
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	if level == "warning" && e.openAlerts[key] == "critical" {
+		return // keep the most severe level
+	}
+	e.openAlerts[key] = level
+}
+
+// resolve closes the open warning/critical alert for a quota that has recovered
+// and sends a "recovered" notification if enabled. No-op if no alert is open.
+func (e *NotificationEngine) resolve(senders notificationSenders, cfg NotificationConfig, status QuotaStatus) {
+	provider := status.logProvider()
+	if e.openAlertLevel(provider, status.QuotaKey) == "" {
+		return
+	}
+
+	if err := e.store.ResolveNotificationLog(provider, status.QuotaKey); err != nil {
+		e.logger.Error("failed to resolve notification log", "error", err)
+		return
+	}
+	key := notificationOverrideKey(provider, status.QuotaKey)
+	e.stateMu.Lock()
+	e.openAlerts[key] = ""
+	e.stateMu.Unlock()
+
+	if !cfg.Types.Recovered {
 		return
 	}
+	if channels := cfg.channelsFor("recovered"); channels.Any() {
+		e.deliver(senders, cfg, status, "recovered", channels, "")
+	}
 }
 
 // SendTestEmail sends a test email to verify SMTP configuration.
@@ -446,31 +878,157 @@ func (e *NotificationEngine) SendTestEmail() error {
 	return mailer.Send(subject, body)
 }
 
-// sendNotification sends notifications via enabled channels.
-// Each provider+quota+type combination fires at most once per cycle.
+// SendTestMatrix posts a test message to verify Matrix configuration.
+func (e *NotificationEngine) SendTestMatrix() error {
+	e.mu.RLock()
+	matrix := e.matrix
+	e.mu.RUnlock()
+
+	if matrix == nil {
+		return fmt.Errorf("Matrix not configured")
+	}
+
+	return matrix.Send("[onWatch] Test Message", "If you see this, your Matrix settings are configured correctly.")
+}
+
+// SendTestSMS sends a test SMS to verify Twilio configuration.
+func (e *NotificationEngine) SendTestSMS() error {
+	e.mu.RLock()
+	twilio := e.twilio
+	e.mu.RUnlock()
+
+	if twilio == nil {
+		return fmt.Errorf("Twilio not configured")
+	}
+
+	return twilio.Send("[onWatch] Test SMS: your Twilio settings are configured correctly.")
+}
+
+// sendNotification sends notifications via the channels routed for notifType.
+// Each provider+quota+type combination fires at most once per cycle; an
+// unacknowledged critical alert may be escalated once (see escalate).
 // The notification_log entry is cleared on quota reset (see Check/resetOccurred).
-func (e *NotificationEngine) sendNotification(mailer *SMTPMailer, pushSender *PushSender, channels NotificationChannels, status QuotaStatus, notifType string) {
-	provider := normalizeNotificationProvider(status.Provider)
-	sentAt, _, err := e.store.GetLastNotification(provider, status.QuotaKey, notifType)
+func (e *NotificationEngine) sendNotification(senders notificationSenders, cfg NotificationConfig, status QuotaStatus, notifType string) {
+	provider := status.logProvider()
+	entry, err := e.store.GetNotificationLogEntry(provider, status.QuotaKey, notifType)
 	if err != nil {
 		e.logger.Error("failed to check notification log", "error", err)
 		return
 	}
 	// Already sent for this cycle — skip (log is cleared on reset)
-	if !sentAt.IsZero() {
+	if entry != nil {
 		e.logger.Debug("notification already sent for this cycle",
 			"quota", status.QuotaKey, "type", notifType,
-			"sent_at", sentAt)
+			"sent_at", entry.SentAt)
+		if notifType == "critical" {
+			e.escalate(senders, cfg, status, entry)
+		}
+		return
+	}
+
+	channels := cfg.channelsFor(notifType)
+	if !channels.Any() {
+		return
+	}
+
+	if cfg.GroupWindow > 0 && groupedAlertTypes[notifType] {
+		e.enqueue(pendingAlert{status: status, notifType: notifType, channels: channels}, cfg.GroupWindow)
+		return
+	}
+
+	// Log the notification only if at least one channel succeeded
+	if e.deliver(senders, cfg, status, notifType, channels, "") {
+		e.recordSent(status, notifType)
+	}
+}
+
+// recordSent logs a delivered alert so it fires once per cycle, and tracks it
+// as the open alert for recovered messages.
+func (e *NotificationEngine) recordSent(status QuotaStatus, notifType string) {
+	provider := status.logProvider()
+	if err := e.store.UpsertNotificationLog(provider, status.QuotaKey, notifType, status.Utilization); err != nil {
+		e.logger.Error("failed to log notification", "error", err)
+	}
+	if notifType == "warning" || notifType == "critical" {
+		e.setOpenAlertLevel(provider, status.QuotaKey, notifType)
+	}
+}
+
+// escalate re-sends an unacknowledged critical alert once cfg.EscalationAfter has
+// passed, adding cfg.EscalationChannel if set. It is only reached while the quota
+// is still critical, so recovered or acknowledged alerts never escalate.
+func (e *NotificationEngine) escalate(senders notificationSenders, cfg NotificationConfig, status QuotaStatus, entry *store.NotificationLogEntry) {
+	if cfg.EscalationAfter <= 0 || entry.AcknowledgedAt != nil || entry.EscalatedAt != nil || entry.ResolvedAt != nil {
+		return
+	}
+	if time.Since(entry.SentAt) < cfg.EscalationAfter {
 		return
 	}
 
-	subject := e.buildSubject(status, notifType)
-	body := e.buildBody(status, notifType)
+	channels := cfg.channelsFor("critical")
+	switch cfg.EscalationChannel {
+	case "email":
+		channels.Email = true
+	case "push":
+		channels.Push = true
+	case "matrix":
+		channels.Matrix = true
+	case "sms":
+		channels.SMS = true
+	}
+	if !channels.Any() {
+		return
+	}
+
+	if e.deliver(senders, cfg, status, "critical", channels, "[ESCALATED] ") {
+		if err := e.store.MarkNotificationEscalated(entry.ID); err != nil {
+			e.logger.Error("failed to mark notification escalated", "error", err)
+		}
+		e.logger.Info("escalated unacknowledged critical alert",
+			"provider", entry.Provider, "quota", status.QuotaKey, "sent_at", entry.SentAt)
+	}
+}
+
+// deliver sends one alert over the given channels, prefixing each subject (and
+// SMS text) with prefix. Returns true if at least one channel succeeded.
+func (e *NotificationEngine) deliver(senders notificationSenders, cfg NotificationConfig, status QuotaStatus, notifType string, channels NotificationChannels, prefix string) bool {
+	mailer, pushSender, matrix := senders.mailer, senders.push, senders.matrix
 	sent := false
 
+	// render applies the channel's template, falling back to the default wording
+	render := func(channel string) (string, string) {
+		subject, body, err := RenderMessage(channel, cfg.Templates[channel], status, notifType)
+		if err != nil {
+			e.logger.Warn("notification template failed, using default", "channel", channel, "error", err)
+		}
+		if channel == "sms" {
+			return prefix + subject, prefix + body
+		}
+		return prefix + subject, body
+	}
+
 	// Send via email if enabled and configured
 	if channels.Email && mailer != nil {
-		if err := mailer.Send(subject, body); err != nil {
+		subject, body := render("email")
+		var images []InlineImage
+		if cfg.IncludeChart && (notifType == "warning" || notifType == "critical" || notifType == "exhaustion" || notifType == "recovered") {
+			if chart, err := e.buildChart(cfg, status); err != nil {
+				e.logger.Debug("alert chart unavailable", "quota", status.QuotaKey, "error", err)
+			} else {
+				images = append(images, chart)
+			}
+		}
+		chartCID := ""
+		if len(images) > 0 {
+			chartCID = images[0].CID
+		}
+		send := func() error { return mailer.Send(subject, body) }
+		if html, err := buildAlertHTML(status, notifType, subject, body, chartCID); err != nil {
+			e.logger.Warn("failed to render HTML email, sending plaintext only", "error", err)
+		} else {
+			send = func() error { return mailer.SendMultipart(subject, body, html, images...) }
+		}
+		if err := send(); err != nil {
 			e.logger.Error("failed to send email notification", "error", err,
 				"quota", status.QuotaKey, "type", notifType)
 		} else {
@@ -480,34 +1038,62 @@ func (e *NotificationEngine) sendNotification(mailer *SMTPMailer, pushSender *Pu
 
 	// Send via push if enabled and configured
 	if channels.Push && pushSender != nil {
-		subs, err := e.store.GetPushSubscriptions()
-		if err != nil {
-			e.logger.Error("failed to get push subscriptions", "error", err)
+		subject, body := render("push")
+		if e.sendPush(pushSender, subject, body) {
+			sent = true
+		}
+	}
+
+	// Send via Matrix if enabled and configured
+	if channels.Matrix && matrix != nil {
+		subject, body := render("matrix")
+		if err := matrix.Send(subject, body); err != nil {
+			e.logger.Error("failed to send matrix notification", "error", err,
+				"quota", status.QuotaKey, "type", notifType)
 		} else {
-			for _, sub := range subs {
-				ps := PushSubscription{Endpoint: sub.Endpoint}
-				ps.Keys.P256dh = sub.P256dh
-				ps.Keys.Auth = sub.Auth
-				if err := pushSender.Send(ps, subject, body); err != nil {
-					e.logger.Error("failed to send push notification", "error", err,
-						"endpoint", sub.Endpoint)
-					// If subscription is gone (410), remove it
-					if strings.Contains(err.Error(), "410") {
-						e.store.DeletePushSubscription(sub.Endpoint)
-					}
-				} else {
-					sent = true
-				}
-			}
+			sent = true
 		}
 	}
 
-	// Log the notification only if at least one channel succeeded
-	if sent {
-		if err := e.store.UpsertNotificationLog(provider, status.QuotaKey, notifType, status.Utilization); err != nil {
-			e.logger.Error("failed to log notification", "error", err)
+	// Send via SMS (channelsFor already limits it to the costly-but-urgent levels)
+	if channels.SMS && senders.twilio != nil {
+		_, text := render("sms")
+		if err := senders.twilio.Send(text); err != nil {
+			e.logger.Error("failed to send sms notification", "error", err,
+				"quota", status.QuotaKey, "type", notifType)
+		} else {
+			sent = true
 		}
 	}
+
+	return sent
+}
+
+// sendPush sends a message to every push subscription, removing ones that are
+// gone. Returns true if at least one device received it.
+func (e *NotificationEngine) sendPush(pushSender *PushSender, subject, body string) bool {
+	subs, err := e.store.GetPushSubscriptions()
+	if err != nil {
+		e.logger.Error("failed to get push subscriptions", "error", err)
+		return false
+	}
+	sent := false
+	for _, sub := range subs {
+		ps := PushSubscription{Endpoint: sub.Endpoint}
+		ps.Keys.P256dh = sub.P256dh
+		ps.Keys.Auth = sub.Auth
+		if err := pushSender.Send(ps, subject, body); err != nil {
+			e.logger.Error("failed to send push notification", "error", err,
+				"endpoint", sub.Endpoint)
+			// If subscription is gone (410), remove it
+			if strings.Contains(err.Error(), "410") {
+				e.store.DeletePushSubscription(sub.Endpoint)
+			}
+		} else {
+			sent = true
+		}
+	}
+	return sent
 }
 
 func normalizeNotificationProvider(provider string) string {
@@ -530,32 +1116,121 @@ func titleCase(s string) string {
 	return strings.ToUpper(s[:1]) + s[1:]
 }
 
-// buildSubject creates the email subject line.
-func (e *NotificationEngine) buildSubject(status QuotaStatus, notifType string) string {
+// formatMoney formats an amount with its currency code, or as dollars when
+// no display currency is configured.
+func formatMoney(amount float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("$%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// buildSubject creates the default subject line.
+func buildSubject(status QuotaStatus, notifType string) string {
 	switch notifType {
 	case "critical":
 		return fmt.Sprintf("[CRITICAL] %s quota %s at %.1f%%",
-			titleCase(status.Provider), status.QuotaKey, status.Utilization)
+			titleCase(status.Provider), status.quotaName(), status.Utilization)
 	case "warning":
 		return fmt.Sprintf("[WARNING] %s quota %s at %.1f%%",
-			titleCase(status.Provider), status.QuotaKey, status.Utilization)
+			titleCase(status.Provider), status.quotaName(), status.Utilization)
 	case "reset":
 		return fmt.Sprintf("[RESET] %s quota %s has been reset",
-			titleCase(status.Provider), status.QuotaKey)
+			titleCase(status.Provider), status.quotaName())
+	case "exhaustion":
+		return fmt.Sprintf("[EXHAUSTION] %s quota %s on pace to run out before reset (%.1f%% now)",
+			titleCase(status.Provider), status.quotaName(), status.Utilization)
+	case "recovered":
+		return fmt.Sprintf("[RECOVERED] %s quota %s back to %.1f%%",
+			titleCase(status.Provider), status.quotaName(), status.Utilization)
+	case "latency":
+		return fmt.Sprintf("[LATENCY] %s API slow: %s average",
+			titleCase(status.Provider), status.Latency.Round(time.Millisecond))
+	case "circuit":
+		return fmt.Sprintf("[CIRCUIT OPEN] %s polling paused after %d consecutive failures",
+			titleCase(status.Provider), status.Failures)
+	case "schema":
+		return fmt.Sprintf("[SCHEMA] %s API response format may have changed",
+			titleCase(status.Provider))
+	case "update":
+		return fmt.Sprintf("[UPDATE] onWatch updated from v%s to v%s", status.FromVersion, status.ToVersion)
+	case "budget":
+		if status.QuotaKey == "budget_projected" {
+			return fmt.Sprintf("[BUDGET] %s spend on track for %s of %s budget",
+				titleCase(status.Provider), formatMoney(status.SpendProjected, status.Currency), formatMoney(status.Limit, status.Currency))
+		}
+		return fmt.Sprintf("[BUDGET] %s spend at %s of %s budget (%.1f%%)",
+			titleCase(status.Provider), formatMoney(status.SpendToDate, status.Currency), formatMoney(status.Limit, status.Currency), status.Utilization)
 	default:
-		return fmt.Sprintf("[%s] %s quota %s", notifType, status.Provider, status.QuotaKey)
+		return fmt.Sprintf("[%s] %s quota %s", notifType, status.Provider, status.quotaName())
 	}
 }
 
-// buildBody creates the email body text.
-func (e *NotificationEngine) buildBody(status QuotaStatus, notifType string) string {
+// buildBody creates the default message body text.
+func buildBody(status QuotaStatus, notifType string) string {
 	var sb strings.Builder
+	if notifType == "update" {
+		sb.WriteString(fmt.Sprintf("Previous version: %s\n", status.FromVersion))
+		sb.WriteString(fmt.Sprintf("New version: %s\n", status.ToVersion))
+		sb.WriteString(fmt.Sprintf("Trigger: %s\n", status.Trigger))
+		sb.WriteString("Run 'onwatch rollback' to return to the previous version.\n")
+		sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
+		sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
+		sb.WriteString("\n-- Sent by onWatch")
+		return sb.String()
+	}
 	sb.WriteString(fmt.Sprintf("Provider: %s\n", status.Provider))
-	sb.WriteString(fmt.Sprintf("Quota: %s\n", status.QuotaKey))
+	if notifType == "latency" {
+		sb.WriteString(fmt.Sprintf("Average latency: %s\n", status.Latency.Round(time.Millisecond)))
+		sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
+		sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
+		sb.WriteString("\n-- Sent by onWatch")
+		return sb.String()
+	}
+	if notifType == "circuit" {
+		sb.WriteString(fmt.Sprintf("Consecutive failures: %d\n", status.Failures))
+		if status.Error != "" {
+			sb.WriteString(fmt.Sprintf("Last error: %s\n", status.Error))
+		}
+		if status.ResetAt != nil {
+			sb.WriteString(fmt.Sprintf("Next retry: %s\n", status.ResetAt.UTC().Format(time.RFC3339)))
+		}
+		sb.WriteString("Check the provider's credentials; polling resumes automatically once a retry succeeds.\n")
+		sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
+		sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
+		sb.WriteString("\n-- Sent by onWatch")
+		return sb.String()
+	}
+	if notifType == "schema" {
+		sb.WriteString(fmt.Sprintf("Missing fields: %s\n", strings.Join(status.Fields, ", ")))
+		sb.WriteString("These fields were returned before but have been missing or empty for several polls, so usage may be recorded as zero.\n")
+		sb.WriteString("Check for an onWatch update; run with debug logging to see the raw response.\n")
+		sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
+		sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
+		sb.WriteString("\n-- Sent by onWatch")
+		return sb.String()
+	}
+	if notifType == "budget" {
+		sb.WriteString(fmt.Sprintf("Spend to date: %s\n", formatMoney(status.SpendToDate, status.Currency)))
+		sb.WriteString(fmt.Sprintf("Projected month-end: %s\n", formatMoney(status.SpendProjected, status.Currency)))
+		sb.WriteString(fmt.Sprintf("Budget: %s\n", formatMoney(status.Limit, status.Currency)))
+		sb.WriteString(fmt.Sprintf("Remaining: %s\n", formatMoney(status.Limit-status.SpendToDate, status.Currency)))
+		sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
+		sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
+		sb.WriteString("\n-- Sent by onWatch")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Quota: %s\n", status.quotaName()))
 	sb.WriteString(fmt.Sprintf("Utilization: %.1f%%\n", status.Utilization))
+	if status.ProjectedUtil > 0 {
+		sb.WriteString(fmt.Sprintf("Projected at reset: %.1f%%\n", status.ProjectedUtil))
+	}
 	if status.Limit > 0 {
 		sb.WriteString(fmt.Sprintf("Limit: %.0f\n", status.Limit))
 	}
+	if status.ResetAt != nil && !status.ResetAt.IsZero() {
+		sb.WriteString(fmt.Sprintf("Resets at: %s\n", status.ResetAt.UTC().Format(time.RFC3339)))
+	}
 	sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
 	sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
 	sb.WriteString("\n-- Sent by onWatch")
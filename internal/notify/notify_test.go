@@ -24,9 +24,13 @@ func newTestStore(t *testing.T) *store.Store {
 	return s
 }
 
+// newTestEngine returns an engine that sends alerts immediately; the grouping
+// window has its own tests.
 func newTestEngine(t *testing.T, s *store.Store) *NotificationEngine {
 	t.Helper()
-	return New(s, slog.Default())
+	e := New(s, slog.Default())
+	e.cfg.GroupWindow = 0
+	return e
 }
 
 // storeSMTPConfig saves SMTP settings as a single JSON blob under the "smtp" key,
@@ -666,3 +670,238 @@ func TestNotificationEngine_ConfigureSMTP_WithEncryptedPassword(t *testing.T) {
 	_ = receivedData
 	mu.Unlock()
 }
+
+func TestNotificationEngine_Check_RoutingBySeverity(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	srv, matrixCount, _ := mockMatrixServer(t, 200)
+	defer srv.Close()
+
+	key, _ := GenerateEncryptionKey()
+	storeMatrixConfig(t, s, key, srv.URL, "tok", "!r:x")
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+		Routing: map[string]NotificationChannels{
+			"warning":  {Matrix: true},
+			"critical": {Email: true},
+		},
+	})
+
+	engine := newTestEngine(t, s)
+	engine.SetEncryptionKey(key)
+	engine.Reload()
+	engine.ConfigureMatrix()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	// Warning goes to Matrix only
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 85})
+	if matrixCount.Load() != 1 || mailCount.Load() != 0 {
+		t.Fatalf("warning: matrix=%d email=%d, want 1/0", matrixCount.Load(), mailCount.Load())
+	}
+
+	// Critical goes to email only
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 97})
+	if matrixCount.Load() != 1 || mailCount.Load() != 1 {
+		t.Errorf("critical: matrix=%d email=%d, want 1/1", matrixCount.Load(), mailCount.Load())
+	}
+}
+
+func TestNotificationConfig_ChannelsFor(t *testing.T) {
+	cfg := NotificationConfig{
+		Channels:  NotificationChannels{Email: true, SMS: true},
+		SMSLevels: map[string]bool{"critical": true},
+	}
+	if ch := cfg.channelsFor("warning"); !ch.Email || ch.SMS {
+		t.Errorf("warning without routing = %+v, want email only", ch)
+	}
+	if ch := cfg.channelsFor("critical"); !ch.Email || !ch.SMS {
+		t.Errorf("critical without routing = %+v, want email+sms", ch)
+	}
+
+	cfg.Routing = map[string]NotificationChannels{"reset": {Push: true, SMS: true}}
+	if ch := cfg.channelsFor("reset"); !ch.Push || ch.Email || ch.SMS {
+		t.Errorf("routed reset = %+v, want push only (SMS ineligible)", ch)
+	}
+}
+
+func TestNotificationEngine_Check_EscalatesUnacknowledgedCritical(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyCritical:    true,
+		EscalationMinutes: 10,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	status := QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 97, Limit: 100}
+	engine.Check(status)
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected 1 email, got %d", mailCount.Load())
+	}
+
+	// Not yet due
+	engine.Check(status)
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected no escalation before escalation_minutes, got %d", mailCount.Load())
+	}
+
+	engine.mu.Lock()
+	engine.cfg.EscalationAfter = time.Nanosecond
+	engine.mu.Unlock()
+
+	engine.Check(status)
+	if mailCount.Load() != 2 {
+		t.Fatalf("expected escalation email, got %d", mailCount.Load())
+	}
+
+	// Escalates only once
+	engine.Check(status)
+	if mailCount.Load() != 2 {
+		t.Errorf("expected a single escalation, got %d", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_Check_NoEscalationWhenAcknowledgedOrRecovered(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyCritical:    true,
+		EscalationMinutes: 10,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	engine.mu.Lock()
+	engine.cfg.EscalationAfter = time.Nanosecond
+	engine.mu.Unlock()
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	// Recovered: below critical, so the open critical alert is not re-sent
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "five_hour", Utilization: 97, Limit: 100})
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "five_hour", Utilization: 50, Limit: 100})
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected no escalation after recovery, got %d", mailCount.Load())
+	}
+
+	// Acknowledged: still critical but acked
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 97, Limit: 100})
+	entry, _ := s.GetNotificationLogEntry("anthropic", "five_hour", "critical")
+	if entry == nil {
+		t.Fatal("expected critical log entry")
+	}
+	s.AcknowledgeNotification(entry.ID)
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 98, Limit: 100})
+	if mailCount.Load() != 2 {
+		t.Errorf("expected no escalation after ack, got %d", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_Check_RecoveredAfterWarning(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+		NotifyRecovered:   true,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	// Below threshold with nothing open: no recovered message
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 40, Limit: 100})
+	if mailCount.Load() != 0 {
+		t.Fatalf("expected no email, got %d", mailCount.Load())
+	}
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 85, Limit: 100})
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected warning email, got %d", mailCount.Load())
+	}
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 60, Limit: 100})
+	if mailCount.Load() != 2 {
+		t.Fatalf("expected recovered email, got %d", mailCount.Load())
+	}
+	entry, _ := s.GetNotificationLogEntry("anthropic", "five_hour", "warning")
+	if entry == nil || entry.ResolvedAt == nil {
+		t.Errorf("expected warning alert to be resolved, got %+v", entry)
+	}
+
+	// Already resolved: staying low sends nothing more
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 55, Limit: 100})
+	if mailCount.Load() != 2 {
+		t.Errorf("expected a single recovered email, got %d", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_Check_RecoveredOnReset(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyCritical:    true,
+		NotifyRecovered:   true,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "seven_day", Utilization: 97, Limit: 100})
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "seven_day", Utilization: 0, Limit: 100, ResetOccurred: true})
+	if mailCount.Load() != 2 {
+		t.Fatalf("expected critical + recovered emails, got %d", mailCount.Load())
+	}
+}
+
+func TestNotificationEngine_Check_RecoveredStateSurvivesRestart(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyRecovered:   true,
+	})
+	// An alert left open by a previous run
+	s.UpsertNotificationLog("anthropic", "seven_day", "critical", 97)
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "seven_day", Utilization: 30, Limit: 100})
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected recovered email for alert loaded from the log, got %d", mailCount.Load())
+	}
+	if level, _ := s.GetOpenAlertLevel("anthropic", "seven_day"); level != "" {
+		t.Errorf("expected no open alert after resolve, got %q", level)
+	}
+}
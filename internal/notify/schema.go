@@ -0,0 +1,40 @@
+package notify
+
+import "strings"
+
+// NotifySchemaChange alerts that fields a provider used to return have been
+// missing from its responses for several consecutive polls, which usually
+// means the provider changed its response format and onWatch is recording
+// zeros. The agent reports each field once until it returns, so no
+// deduplication is needed here.
+func (e *NotificationEngine) NotifySchemaChange(provider string, fields []string) {
+	e.mu.RLock()
+	cfg := e.cfg
+	senders := notificationSenders{
+		mailer: e.mailer,
+		push:   e.pushSender,
+		matrix: e.matrix,
+		twilio: e.twilio,
+	}
+	e.mu.RUnlock()
+
+	if !cfg.Types.Schema || senders.none() || len(fields) == 0 {
+		return
+	}
+	channels := cfg.channelsFor("schema")
+	if !channels.Any() {
+		return
+	}
+
+	status := QuotaStatus{
+		Provider: normalizeNotificationProvider(provider),
+		QuotaKey: "schema",
+		Fields:   fields,
+		Error:    "missing " + strings.Join(fields, ", "),
+	}
+	if e.deliver(senders, cfg, status, "schema", channels, "") {
+		if err := e.store.UpsertNotificationLog(status.Provider, "schema", "schema", float64(len(fields))); err != nil {
+			e.logger.Error("failed to log notification", "error", err)
+		}
+	}
+}
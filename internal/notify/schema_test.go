@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotificationEngine_NotifySchemaChange(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	// Enabled by default before any notification settings are saved
+	engine.NotifySchemaChange("anthropic", []string{"five_hour.utilization"})
+	if mailCount.Load() != 1 {
+		t.Fatalf("Expected 1 schema alert, got %d", mailCount.Load())
+	}
+
+	// Settings saved without notify_schema keep it on
+	storeNotificationConfig(t, s, notificationSettingsJSON{WarningThreshold: 80, CriticalThreshold: 95})
+	engine.Reload()
+	engine.NotifySchemaChange("anthropic", []string{"five_hour.utilization"})
+	if mailCount.Load() != 2 {
+		t.Fatalf("Expected schema alerts to stay on without notify_schema, got %d", mailCount.Load())
+	}
+
+	off := false
+	storeNotificationConfig(t, s, notificationSettingsJSON{WarningThreshold: 80, CriticalThreshold: 95, NotifySchema: &off})
+	engine.Reload()
+	engine.NotifySchemaChange("anthropic", []string{"five_hour.utilization"})
+	if mailCount.Load() != 2 {
+		t.Errorf("Expected no alert with notify_schema disabled, got %d", mailCount.Load())
+	}
+}
+
+func TestBuildBody_Schema(t *testing.T) {
+	status := QuotaStatus{Provider: "zai", QuotaKey: "schema", Fields: []string{"TIME_LIMIT.usage", "limits"}}
+
+	if got := buildSubject(status, "schema"); got != "[SCHEMA] Zai API response format may have changed" {
+		t.Errorf("unexpected subject: %q", got)
+	}
+	if body := buildBody(status, "schema"); !strings.Contains(body, "Missing fields: TIME_LIMIT.usage, limits") {
+		t.Errorf("expected missing fields in body, got %q", body)
+	}
+}
@@ -1,15 +1,31 @@
 package notify
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"log/slog"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
 	"net/smtp"
+	"net/textproto"
+	"regexp"
 	"strings"
 	"time"
 )
 
+// emailRegex validates email addresses.
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+
+// ValidEmailAddress reports whether addr is a bare email address, with no
+// display name, spaces or line breaks that could alter message headers.
+func ValidEmailAddress(addr string) bool {
+	return emailRegex.MatchString(addr)
+}
+
 // SMTPConfig holds SMTP connection settings.
 type SMTPConfig struct {
 	Host     string   // SMTP server hostname
@@ -36,10 +52,38 @@ func NewSMTPMailer(cfg SMTPConfig, logger *slog.Logger) *SMTPMailer {
 	return &SMTPMailer{config: cfg, logger: logger}
 }
 
+// withRecipients returns a mailer using the same server that sends to addrs.
+func (m *SMTPMailer) withRecipients(addrs []string) *SMTPMailer {
+	cfg := m.config
+	cfg.ToAddrs = addrs
+	return &SMTPMailer{config: cfg, logger: m.logger}
+}
+
 // Send sends an email with the given subject and plaintext body.
 func (m *SMTPMailer) Send(subject, body string) error {
-	msg := m.buildMessage(subject, body)
+	return m.send(subject, m.buildMessage(subject, body))
+}
 
+// InlineImage is an image attached to an HTML email and referenced as cid:<CID>.
+type InlineImage struct {
+	CID         string
+	ContentType string // e.g. "image/png"
+	Data        []byte
+}
+
+// SendMultipart sends a multipart/alternative email with a plaintext and an HTML body.
+// Clients that cannot render HTML fall back to the plaintext part. Inline images,
+// if any, wrap the message in multipart/related.
+func (m *SMTPMailer) SendMultipart(subject, textBody, htmlBody string, images ...InlineImage) error {
+	msg, err := m.buildMultipartMessage(subject, textBody, htmlBody, images...)
+	if err != nil {
+		return fmt.Errorf("notify.SendMultipart: %w", err)
+	}
+	return m.send(subject, msg)
+}
+
+// send delivers a fully built message to all recipients.
+func (m *SMTPMailer) send(subject, msg string) error {
 	client, err := m.connect()
 	if err != nil {
 		return fmt.Errorf("notify.Send: connect: %w", err)
@@ -167,3 +211,93 @@ func (m *SMTPMailer) buildMessage(subject, body string) string {
 	sb.WriteString(body)
 	return sb.String()
 }
+
+// buildMultipartMessage constructs an RFC 2822 multipart/alternative message,
+// nested in multipart/related when inline images are attached.
+// The plaintext part comes first so clients prefer the HTML part when they can render it.
+func (m *SMTPMailer) buildMultipartMessage(subject, textBody, htmlBody string, images ...InlineImage) (string, error) {
+	var alt bytes.Buffer
+	aw := multipart.NewWriter(&alt)
+
+	for _, p := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", textBody},
+		{"text/html; charset=UTF-8", htmlBody},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		pw, err := aw.CreatePart(header)
+		if err != nil {
+			return "", fmt.Errorf("create part: %w", err)
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return "", fmt.Errorf("write part: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return "", fmt.Errorf("close part: %w", err)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		return "", fmt.Errorf("close multipart: %w", err)
+	}
+
+	contentType := fmt.Sprintf("multipart/alternative; boundary=%q", aw.Boundary())
+	body := alt.Bytes()
+
+	if len(images) > 0 {
+		var related bytes.Buffer
+		rw := multipart.NewWriter(&related)
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", contentType)
+		pw, err := rw.CreatePart(header)
+		if err != nil {
+			return "", fmt.Errorf("create alternative part: %w", err)
+		}
+		pw.Write(body)
+
+		for _, img := range images {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Type", img.ContentType)
+			header.Set("Content-Transfer-Encoding", "base64")
+			header.Set("Content-ID", "<"+img.CID+">")
+			header.Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", img.CID+".png"))
+			pw, err := rw.CreatePart(header)
+			if err != nil {
+				return "", fmt.Errorf("create image part: %w", err)
+			}
+			if err := writeBase64Lines(pw, img.Data); err != nil {
+				return "", fmt.Errorf("write image part: %w", err)
+			}
+		}
+		if err := rw.Close(); err != nil {
+			return "", fmt.Errorf("close related: %w", err)
+		}
+		contentType = fmt.Sprintf("multipart/related; boundary=%q", rw.Boundary())
+		body = related.Bytes()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("From: %s <%s>\r\n", m.config.FromName, m.config.FromAddr))
+	sb.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(m.config.ToAddrs, ", ")))
+	sb.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString(fmt.Sprintf("Content-Type: %s\r\n", contentType))
+	sb.WriteString("\r\n")
+	sb.Write(body)
+	return sb.String(), nil
+}
+
+// writeBase64Lines writes data base64-encoded in 76-character lines (RFC 2045).
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
@@ -2,9 +2,15 @@ package notify
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"log/slog"
+	"mime"
+	"mime/multipart"
 	"net"
+	"net/mail"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -361,6 +367,99 @@ func TestSMTPMailer_Send_VerifyHeaders(t *testing.T) {
 	}
 }
 
+func TestSMTPMailer_BuildMultipartMessage(t *testing.T) {
+	mailer := NewSMTPMailer(SMTPConfig{
+		FromAddr: "alerts@onwatch.dev",
+		FromName: "onWatch",
+		ToAddrs:  []string{"admin@example.com"},
+	}, slog.Default())
+
+	longLine := strings.Repeat("x", 200)
+	raw, err := mailer.buildMultipartMessage("[WARNING] quota", "Utilization: 82.0%\n"+longLine, "<p>82.0% used</p>")
+	if err != nil {
+		t.Fatalf("buildMultipartMessage failed: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var types, bodies []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart failed: %v", err)
+		}
+		data, _ := io.ReadAll(part) // quoted-printable is decoded transparently
+		types = append(types, part.Header.Get("Content-Type"))
+		bodies = append(bodies, string(data))
+	}
+
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Fatalf("part types = %v, want text/plain then text/html", types)
+	}
+	// Line breaks are normalized to CRLF by the quoted-printable encoder
+	if strings.ReplaceAll(bodies[0], "\r\n", "\n") != "Utilization: 82.0%\n"+longLine {
+		t.Errorf("plaintext part = %q", bodies[0])
+	}
+	if bodies[1] != "<p>82.0% used</p>" {
+		t.Errorf("html part = %q", bodies[1])
+	}
+	for _, line := range strings.Split(raw, "\r\n") {
+		if len(line) > 998 {
+			t.Errorf("line exceeds RFC 5322 limit: %d chars", len(line))
+		}
+	}
+}
+
+func TestSMTPMailer_BuildMultipartMessage_InlineImage(t *testing.T) {
+	mailer := NewSMTPMailer(SMTPConfig{FromAddr: "alerts@onwatch.dev", ToAddrs: []string{"admin@example.com"}}, slog.Default())
+
+	imgData := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 50)
+	raw, err := mailer.buildMultipartMessage("subject", "text", `<img src="cid:chart@onwatch">`,
+		InlineImage{CID: "chart@onwatch", ContentType: "image/png", Data: imgData})
+	if err != nil {
+		t.Fatalf("buildMultipartMessage failed: %v", err)
+	}
+
+	msg, _ := mail.ReadMessage(strings.NewReader(raw))
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/related" {
+		t.Fatalf("Content-Type = %q, want multipart/related", mediaType)
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	first, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart failed: %v", err)
+	}
+	if ct, _, _ := mime.ParseMediaType(first.Header.Get("Content-Type")); ct != "multipart/alternative" {
+		t.Errorf("first part = %q, want multipart/alternative", ct)
+	}
+
+	img, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart failed: %v", err)
+	}
+	if img.Header.Get("Content-ID") != "<chart@onwatch>" {
+		t.Errorf("Content-ID = %q", img.Header.Get("Content-ID"))
+	}
+	encoded, _ := io.ReadAll(img)
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil || !bytes.Equal(decoded, imgData) {
+		t.Errorf("image data mismatch (err %v)", err)
+	}
+}
+
 // splitHostPort is a test helper to split "host:port" into parts.
 func splitHostPort(t *testing.T, addr string) (string, int) {
 	t.Helper()
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateChannels lists the delivery channels that accept message templates.
+var TemplateChannels = []string{"email", "push", "matrix", "sms"}
+
+// maxTemplateLength caps a single subject or body template.
+const maxTemplateLength = 4096
+
+// MessageTemplate is a user-defined subject/body pair for one delivery channel.
+// Empty fields use the built-in wording. SMS only uses Body.
+type MessageTemplate struct {
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body,omitempty"`
+}
+
+// TemplateData holds the variables available to message templates.
+type TemplateData struct {
+	Provider  string // provider name, e.g. "Anthropic"
+	Quota     string // quota key, e.g. "five_hour"
+	Label     string // quota name, e.g. "Claude Sonnet 4.5"; the key when it has no label
+	Percent   string // utilization with one decimal, e.g. "82.5"
+	ResetAt   string // RFC3339 reset time, or "unknown"
+	Status    string // WARNING, CRITICAL, RESET, EXHAUSTION, RECOVERED or LATENCY
+	Latency   string // average API latency for latency alerts, e.g. "4.2s"; empty otherwise
+	Remaining string // remaining budget for budget alerts, e.g. "$9.00" or "9.00 EUR"; empty otherwise
+}
+
+func newTemplateData(status QuotaStatus, notifType string) TemplateData {
+	latency := ""
+	if status.Latency > 0 {
+		latency = status.Latency.Round(time.Millisecond).String()
+	}
+	remaining := ""
+	if notifType == "budget" {
+		remaining = formatMoney(status.Limit-status.SpendToDate, status.Currency)
+	}
+	resetAt := "unknown"
+	if status.ResetAt != nil && !status.ResetAt.IsZero() {
+		resetAt = status.ResetAt.UTC().Format(time.RFC3339)
+	}
+	return TemplateData{
+		Provider:  titleCase(status.Provider),
+		Quota:     status.QuotaKey,
+		Label:     status.quotaName(),
+		Percent:   fmt.Sprintf("%.1f", status.Utilization),
+		ResetAt:   resetAt,
+		Status:    strings.ToUpper(notifType),
+		Latency:   latency,
+		Remaining: remaining,
+	}
+}
+
+// ValidateTemplate checks that text parses and renders against sample data.
+func ValidateTemplate(text string) error {
+	if len(text) > maxTemplateLength {
+		return fmt.Errorf("template exceeds %d characters", maxTemplateLength)
+	}
+	_, err := executeTemplate(text, TemplateData{})
+	return err
+}
+
+func executeTemplate(text string, data TemplateData) (string, error) {
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// defaultMessage returns the built-in subject and body for a channel.
+// SMS has no subject, so its body is the short subject line.
+func defaultMessage(channel string, status QuotaStatus, notifType string) (string, string) {
+	subject := buildSubject(status, notifType)
+	if channel == "sms" {
+		return subject, subject
+	}
+	return subject, buildBody(status, notifType)
+}
+
+// RenderMessage renders the subject and body for a channel. Empty templates
+// use the default wording; templates that fail to parse or execute also fall
+// back to the default, and the first error is returned for logging.
+func RenderMessage(channel string, tmpl MessageTemplate, status QuotaStatus, notifType string) (subject, body string, err error) {
+	subject, body = defaultMessage(channel, status, notifType)
+	data := newTemplateData(status, notifType)
+
+	if tmpl.Subject != "" && channel != "sms" {
+		if out, execErr := executeTemplate(tmpl.Subject, data); execErr != nil {
+			err = fmt.Errorf("notify.RenderMessage: subject: %w", execErr)
+		} else {
+			subject = out
+		}
+	}
+	if tmpl.Body != "" {
+		if out, execErr := executeTemplate(tmpl.Body, data); execErr != nil {
+			if err == nil {
+				err = fmt.Errorf("notify.RenderMessage: body: %w", execErr)
+			}
+		} else {
+			body = out
+		}
+	}
+	return subject, body, err
+}
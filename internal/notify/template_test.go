@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderMessage_Variables(t *testing.T) {
+	resetAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := QuotaStatus{Provider: "codex", QuotaKey: "seven_day", Utilization: 91.26, ResetAt: &resetAt}
+	tmpl := MessageTemplate{
+		Subject: "{{.Status}} {{.Provider}}/{{.Quota}}",
+		Body:    "{{.Percent}}% used, resets {{.ResetAt}}",
+	}
+
+	subject, body, err := RenderMessage("email", tmpl, status, "warning")
+	if err != nil {
+		t.Fatalf("RenderMessage() error: %v", err)
+	}
+	if subject != "WARNING Codex/seven_day" {
+		t.Errorf("subject = %q", subject)
+	}
+	if body != "91.3% used, resets 2026-03-01T12:00:00Z" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestRenderMessage_EmptyUsesDefault(t *testing.T) {
+	status := QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 96}
+
+	subject, body, err := RenderMessage("email", MessageTemplate{}, status, "critical")
+	if err != nil {
+		t.Fatalf("RenderMessage() error: %v", err)
+	}
+	if subject != buildSubject(status, "critical") {
+		t.Errorf("subject = %q, want default", subject)
+	}
+	if !strings.Contains(body, "Utilization: 96.0%") {
+		t.Errorf("body = %q, want default", body)
+	}
+
+	// SMS has no subject: its default text is the subject line
+	_, text, _ := RenderMessage("sms", MessageTemplate{}, status, "critical")
+	if text != subject {
+		t.Errorf("sms text = %q, want %q", text, subject)
+	}
+}
+
+func TestRenderMessage_QuotaLabel(t *testing.T) {
+	status := QuotaStatus{Provider: "antigravity", QuotaKey: "MODEL_PLACEHOLDER_M18", QuotaLabel: "Claude Sonnet 4.5", Utilization: 85}
+
+	subject, body, err := RenderMessage("email", MessageTemplate{}, status, "warning")
+	if err != nil {
+		t.Fatalf("RenderMessage() error: %v", err)
+	}
+	if subject != "[WARNING] Antigravity quota Claude Sonnet 4.5 at 85.0%" {
+		t.Errorf("subject = %q, want the model label", subject)
+	}
+	if !strings.Contains(body, "Quota: Claude Sonnet 4.5\n") {
+		t.Errorf("body = %q, want the model label", body)
+	}
+
+	tmpl := MessageTemplate{Subject: "{{.Label}} ({{.Quota}})"}
+	if subject, _, _ := RenderMessage("email", tmpl, status, "warning"); subject != "Claude Sonnet 4.5 (MODEL_PLACEHOLDER_M18)" {
+		t.Errorf("templated subject = %q", subject)
+	}
+	// Quotas without a label fall back to the key
+	status.QuotaLabel = ""
+	if subject, _, _ := RenderMessage("email", tmpl, status, "warning"); subject != "MODEL_PLACEHOLDER_M18 (MODEL_PLACEHOLDER_M18)" {
+		t.Errorf("unlabelled subject = %q", subject)
+	}
+}
+
+func TestRenderMessage_BrokenTemplateFallsBack(t *testing.T) {
+	status := QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 85}
+
+	for _, tmpl := range []MessageTemplate{
+		{Subject: "{{.Provider", Body: "{{if}}"},
+		{Subject: "{{.Missing}}", Body: "{{.Missing}}"},
+	} {
+		subject, body, err := RenderMessage("email", tmpl, status, "warning")
+		if err == nil {
+			t.Errorf("template %+v: expected error", tmpl)
+		}
+		if subject != buildSubject(status, "warning") {
+			t.Errorf("template %+v: subject = %q, want default", tmpl, subject)
+		}
+		if !strings.HasPrefix(body, "Provider: anthropic") {
+			t.Errorf("template %+v: body = %q, want default", tmpl, body)
+		}
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	if err := ValidateTemplate("{{.Status}}: {{.Provider}} {{.Quota}} at {{.Percent}}% ({{.ResetAt}})"); err != nil {
+		t.Errorf("valid template rejected: %v", err)
+	}
+	if err := ValidateTemplate("{{.Provider"); err == nil {
+		t.Error("expected parse error")
+	}
+	if err := ValidateTemplate("{{.Nope}}"); err == nil {
+		t.Error("expected unknown field error")
+	}
+	if err := ValidateTemplate(strings.Repeat("x", maxTemplateLength+1)); err == nil {
+		t.Error("expected length error")
+	}
+}
+
+func TestNotificationEngine_Check_UsesChannelTemplate(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	srv, count, forms := mockTwilioServer(t, http.StatusCreated)
+	defer srv.Close()
+
+	templates, _ := json.Marshal(map[string]MessageTemplate{
+		"sms": {Body: "{{.Status}}: {{.Provider}} {{.Quota}} {{.Percent}}%"},
+	})
+	s.SetSetting("notification_templates", string(templates))
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	setupTwilio(t, engine, s, srv.URL)
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 97})
+	if count.Load() != 1 {
+		t.Fatalf("expected 1 SMS, got %d", count.Load())
+	}
+	if body := forms()[0].Get("Body"); body != "CRITICAL: Anthropic five_hour 97.0%" {
+		t.Errorf("SMS body = %q, want rendered template", body)
+	}
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// twilioAPIBase is the Twilio REST API root.
+const twilioAPIBase = "https://api.twilio.com"
+
+// maxSMSLength caps message length, in characters, to a few segments to keep
+// costs predictable.
+const maxSMSLength = 320
+
+// TwilioConfig holds Twilio SMS settings.
+type TwilioConfig struct {
+	AccountSID string   // Twilio account SID (AC...)
+	AuthToken  string   // Twilio auth token (decrypted)
+	FromNumber string   // E.164 sender number
+	ToNumbers  []string // E.164 recipient numbers
+}
+
+// TwilioSender sends SMS notifications via the Twilio Messages API.
+type TwilioSender struct {
+	config  TwilioConfig
+	logger  *slog.Logger
+	client  *http.Client
+	baseURL string
+}
+
+// NewTwilioSender creates a new Twilio SMS sender with the given config.
+func NewTwilioSender(cfg TwilioConfig, logger *slog.Logger) *TwilioSender {
+	return &TwilioSender{
+		config: cfg,
+		logger: logger,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{MaxIdleConns: 1, MaxIdleConnsPerHost: 1},
+		},
+		baseURL: twilioAPIBase,
+	}
+}
+
+// withRecipients returns a sender using the same account that texts numbers.
+func (t *TwilioSender) withRecipients(numbers []string) *TwilioSender {
+	cfg := t.config
+	cfg.ToNumbers = numbers
+	return &TwilioSender{config: cfg, logger: t.logger, client: t.client, baseURL: t.baseURL}
+}
+
+// Send sends an SMS with the given text to every configured recipient.
+// Returns an error only if no recipient could be reached.
+func (t *TwilioSender) Send(text string) error {
+	if utf8.RuneCountInString(text) > maxSMSLength {
+		text = string([]rune(text)[:maxSMSLength-3]) + "..."
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json",
+		strings.TrimRight(t.baseURL, "/"), url.PathEscape(t.config.AccountSID))
+
+	var lastErr error
+	sent := 0
+	for _, to := range t.config.ToNumbers {
+		form := url.Values{}
+		form.Set("From", t.config.FromNumber)
+		form.Set("To", to)
+		form.Set("Body", text)
+
+		req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return fmt.Errorf("notify.TwilioSender.Send: create request: %w", err)
+		}
+		req.SetBasicAuth(t.config.AccountSID, t.config.AuthToken)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("notify.TwilioSender.Send: HTTP POST: %w", err)
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= 400 {
+			lastErr = fmt.Errorf("notify.TwilioSender.Send: Twilio returned %d", resp.StatusCode)
+			continue
+		}
+		sent++
+	}
+
+	if sent == 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("notify.TwilioSender.Send: no recipients configured")
+		}
+		return lastErr
+	}
+
+	if t.logger != nil {
+		t.logger.Info("sms sent", "recipients", sent)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+)
+
+// mockTwilioServer records Messages API calls.
+func mockTwilioServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32, func() []url.Values) {
+	t.Helper()
+	var count atomic.Int32
+	var mu sync.Mutex
+	var forms []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "AC00000000000000000000000000000000" || pass != "auth-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/2010-04-01/Accounts/AC00000000000000000000000000000000/Messages.json") {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		r.ParseForm()
+		mu.Lock()
+		forms = append(forms, r.PostForm)
+		mu.Unlock()
+		count.Add(1)
+		w.WriteHeader(status)
+	}))
+	return srv, &count, func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]url.Values(nil), forms...)
+	}
+}
+
+func newTestTwilioSender(baseURL string, to ...string) *TwilioSender {
+	sender := NewTwilioSender(TwilioConfig{
+		AccountSID: "AC00000000000000000000000000000000",
+		AuthToken:  "auth-token",
+		FromNumber: "+15550000000",
+		ToNumbers:  to,
+	}, nil)
+	sender.baseURL = baseURL
+	return sender
+}
+
+func TestTwilioSender_Send_AllRecipients(t *testing.T) {
+	srv, count, forms := mockTwilioServer(t, http.StatusCreated)
+	defer srv.Close()
+
+	sender := newTestTwilioSender(srv.URL, "+15551111111", "+15552222222")
+	if err := sender.Send("[CRITICAL] quota at 96%"); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if count.Load() != 2 {
+		t.Fatalf("requests = %d, want 2", count.Load())
+	}
+	got := forms()
+	if got[0].Get("To") != "+15551111111" || got[1].Get("To") != "+15552222222" {
+		t.Errorf("unexpected recipients: %v", got)
+	}
+	if got[0].Get("From") != "+15550000000" || got[0].Get("Body") != "[CRITICAL] quota at 96%" {
+		t.Errorf("unexpected form: %v", got[0])
+	}
+}
+
+func TestTwilioSender_Send_TruncatesLongMessages(t *testing.T) {
+	srv, _, forms := mockTwilioServer(t, http.StatusCreated)
+	defer srv.Close()
+
+	sender := newTestTwilioSender(srv.URL, "+15551111111")
+	sender.Send(strings.Repeat("x", 1000))
+	if body := forms()[0].Get("Body"); len(body) != maxSMSLength {
+		t.Errorf("body length = %d, want %d", len(body), maxSMSLength)
+	}
+
+	// Multi-byte characters are never split
+	sender.Send(strings.Repeat("é", 1000))
+	body := forms()[1].Get("Body")
+	if !utf8.ValidString(body) || utf8.RuneCountInString(body) != maxSMSLength {
+		t.Errorf("body = %d valid=%v characters, want %d", utf8.RuneCountInString(body), utf8.ValidString(body), maxSMSLength)
+	}
+}
+
+func TestTwilioSender_Send_ErrorStatus(t *testing.T) {
+	srv, _, _ := mockTwilioServer(t, http.StatusBadRequest)
+	defer srv.Close()
+
+	sender := newTestTwilioSender(srv.URL, "+15551111111")
+	if err := sender.Send("hi"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected 400 error, got %v", err)
+	}
+}
+
+// setupTwilio stores encrypted Twilio settings pointing at srv and configures the engine.
+func setupTwilio(t *testing.T, engine *NotificationEngine, s interface{ SetSetting(string, string) error }, srvURL string) {
+	t.Helper()
+	key, _ := GenerateEncryptionKey()
+	enc := func(v string) string {
+		out, _ := EncryptForStorage(v, key)
+		return out
+	}
+	data, _ := json.Marshal(twilioSettingsJSON{
+		AccountSID: enc("AC00000000000000000000000000000000"),
+		AuthToken:  enc("auth-token"),
+		FromNumber: enc("+15550000000"),
+		ToNumbers:  enc("+15551111111"),
+	})
+	s.SetSetting("twilio", string(data))
+	engine.SetEncryptionKey(key)
+	if err := engine.ConfigureTwilio(); err != nil {
+		t.Fatalf("ConfigureTwilio failed: %v", err)
+	}
+	engine.twilio.baseURL = srvURL
+}
+
+func TestNotificationEngine_Check_SMSOnlyForCritical(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	srv, count, _ := mockTwilioServer(t, http.StatusCreated)
+	defer srv.Close()
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	setupTwilio(t, engine, s, srv.URL)
+
+	// Warning does not go to SMS
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 85})
+	if count.Load() != 0 {
+		t.Fatalf("expected no SMS for warning, got %d", count.Load())
+	}
+
+	// Critical does
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 97})
+	if count.Load() != 1 {
+		t.Fatalf("expected 1 SMS for critical, got %d", count.Load())
+	}
+}
+
+func TestNotificationEngine_Check_SMSExhaustion(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	srv, count, forms := mockTwilioServer(t, http.StatusCreated)
+	defer srv.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyExhaustion:  true,
+		SMSLevels:         []string{"exhaustion"},
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	setupTwilio(t, engine, s, srv.URL)
+
+	// Not projected to run out: nothing sent
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "seven_day", Utilization: 40, ProjectedUtil: 70})
+	if count.Load() != 0 {
+		t.Fatalf("expected no SMS, got %d", count.Load())
+	}
+
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "seven_day", Utilization: 40, ProjectedUtil: 100})
+	if count.Load() != 1 {
+		t.Fatalf("expected 1 SMS for exhaustion, got %d", count.Load())
+	}
+	if body := forms()[0].Get("Body"); !strings.Contains(body, "[EXHAUSTION]") {
+		t.Errorf("unexpected SMS body %q", body)
+	}
+
+	// Deduped for the rest of the cycle
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "seven_day", Utilization: 45, ProjectedUtil: 100})
+	if count.Load() != 1 {
+		t.Errorf("expected exhaustion SMS to be deduped, got %d", count.Load())
+	}
+}
+
+func TestNotificationEngine_Reload_IgnoresIneligibleSMSLevels(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		SMSLevels:         []string{"warning", "critical"},
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+
+	cfg := engine.Config()
+	if cfg.SMSLevels["warning"] || !cfg.SMSLevels["critical"] {
+		t.Errorf("SMSLevels = %v, want only critical", cfg.SMSLevels)
+	}
+}
+
+func TestNotificationEngine_SendTestSMS_NotConfigured(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	if err := engine.SendTestSMS(); err == nil {
+		t.Error("expected error when Twilio is not configured")
+	}
+}
@@ -0,0 +1,62 @@
+package notify
+
+import "time"
+
+// UpdateEvent is the webhook payload sent after onWatch updates itself.
+type UpdateEvent struct {
+	Event       string    `json:"event"` // always "update_applied"
+	FromVersion string    `json:"from_version"`
+	ToVersion   string    `json:"to_version"`
+	Trigger     string    `json:"trigger"` // "manual" or "scheduled"
+	Source      string    `json:"source"`  // where it was started: "cli" or "dashboard"
+	AppliedAt   time.Time `json:"applied_at"`
+}
+
+// NotifyUpdateApplied reports a self-update: it posts ev to the webhook, if
+// one is configured, and sends an "update" notification when that type is
+// enabled. Delivery failures are logged, never returned, so they can't fail
+// the update itself.
+func (e *NotificationEngine) NotifyUpdateApplied(ev UpdateEvent) {
+	e.mu.RLock()
+	cfg := e.cfg
+	webhook := e.webhook
+	senders := notificationSenders{
+		mailer: e.mailer,
+		push:   e.pushSender,
+		matrix: e.matrix,
+		twilio: e.twilio,
+	}
+	e.mu.RUnlock()
+
+	ev.Event = "update_applied"
+	if ev.AppliedAt.IsZero() {
+		ev.AppliedAt = time.Now().UTC()
+	}
+
+	if webhook != nil {
+		if err := webhook.Send(ev); err != nil {
+			e.logger.Error("failed to send update webhook", "error", err)
+		} else {
+			e.logger.Info("Update webhook sent", "from", ev.FromVersion, "to", ev.ToVersion)
+		}
+	}
+
+	if !cfg.Types.Update || senders.none() {
+		return
+	}
+	channels := cfg.channelsFor("update")
+	if !channels.Any() {
+		return
+	}
+	trigger := ev.Trigger
+	if ev.Source != "" {
+		trigger += " (" + ev.Source + ")"
+	}
+	e.deliver(senders, cfg, QuotaStatus{
+		Provider:    "onwatch",
+		QuotaKey:    "update",
+		FromVersion: ev.FromVersion,
+		ToVersion:   ev.ToVersion,
+		Trigger:     trigger,
+	}, "update", channels, "")
+}
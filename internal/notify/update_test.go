@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotificationEngine_NotifyUpdateApplied(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	var got []UpdateEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev UpdateEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decode webhook payload: %v", err)
+		}
+		got = append(got, ev)
+	}))
+	defer srv.Close()
+	engine.ConfigureWebhook(srv.URL)
+
+	// Update notifications are off by default; the webhook always fires
+	engine.NotifyUpdateApplied(UpdateEvent{FromVersion: "2.1.0", ToVersion: "2.2.0", Trigger: "manual", Source: "cli"})
+	if len(got) != 1 {
+		t.Fatalf("expected 1 webhook call, got %d", len(got))
+	}
+	ev := got[0]
+	if ev.Event != "update_applied" || ev.FromVersion != "2.1.0" || ev.ToVersion != "2.2.0" || ev.Trigger != "manual" || ev.Source != "cli" || ev.AppliedAt.IsZero() {
+		t.Errorf("unexpected webhook payload: %+v", ev)
+	}
+	if mailCount.Load() != 0 {
+		t.Errorf("expected no email with notify_update disabled, got %d", mailCount.Load())
+	}
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{WarningThreshold: 80, CriticalThreshold: 95, NotifyUpdate: true})
+	engine.Reload()
+	engine.NotifyUpdateApplied(UpdateEvent{FromVersion: "2.1.0", ToVersion: "2.2.0", Trigger: "manual", Source: "dashboard"})
+	if mailCount.Load() != 1 {
+		t.Errorf("expected 1 update email, got %d", mailCount.Load())
+	}
+	if len(got) != 2 {
+		t.Errorf("expected 2 webhook calls, got %d", len(got))
+	}
+}
+
+func TestBuildBody_Update(t *testing.T) {
+	status := QuotaStatus{Provider: "onwatch", QuotaKey: "update", FromVersion: "2.1.0", ToVersion: "2.2.0", Trigger: "manual (cli)"}
+
+	if got := buildSubject(status, "update"); got != "[UPDATE] onWatch updated from v2.1.0 to v2.2.0" {
+		t.Errorf("unexpected subject: %q", got)
+	}
+	body := buildBody(status, "update")
+	for _, want := range []string{"Previous version: 2.1.0", "New version: 2.2.0", "Trigger: manual (cli)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in body, got %q", want, body)
+		}
+	}
+}
+
+func TestWebhookSender_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhookSender(srv.URL).Send(map[string]string{"event": "test"}); err == nil {
+		t.Error("expected error for 500 response")
+	}
+}
+
+func TestWebhookSender_Signature(t *testing.T) {
+	var got, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(SignatureHeader)
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer srv.Close()
+
+	if err := NewSignedWebhookSender(srv.URL, "s3cret").Send(map[string]string{"event": "test"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(body))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+
+	if err := NewWebhookSender(srv.URL).Send(map[string]string{"event": "test"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got != "" {
+		t.Errorf("expected no signature without a secret, got %q", got)
+	}
+}
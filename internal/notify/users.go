@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UserNotificationPrefs is the JSON shape stored per user in the users table.
+// Thresholds and channels left empty fall back to the global notification
+// settings. Recipients have no fallback: a user only receives alerts on the
+// channels they set an address for, so the global recipients are never
+// messaged twice. Push subscriptions are not tied to users and stay global.
+type UserNotificationPrefs struct {
+	Warning    float64               `json:"warning_threshold,omitempty"`
+	Critical   float64               `json:"critical_threshold,omitempty"`
+	Channels   *NotificationChannels `json:"channels,omitempty"`
+	Email      []string              `json:"email,omitempty"`       // recipient addresses, sent via the shared SMTP server
+	SMSNumbers []string              `json:"sms_numbers,omitempty"` // E.164 numbers, sent via the shared Twilio account
+	MatrixRoom string                `json:"matrix_room,omitempty"` // room ID, posted by the shared Matrix user
+}
+
+// Validate checks thresholds and recipient formats.
+func (p UserNotificationPrefs) Validate() error {
+	if p.Warning < 0 || p.Warning > 100 || p.Critical < 0 || p.Critical > 100 {
+		return fmt.Errorf("thresholds must be between 0 and 100")
+	}
+	if p.Warning > 0 && p.Critical > 0 && p.Warning >= p.Critical {
+		return fmt.Errorf("warning threshold must be less than critical threshold")
+	}
+	for _, addr := range p.Email {
+		if !ValidEmailAddress(addr) {
+			return fmt.Errorf("invalid email address: %s", addr)
+		}
+	}
+	for _, n := range p.SMSNumbers {
+		if !isE164(n) {
+			return fmt.Errorf("invalid phone number (E.164, e.g. +15551234567): %s", n)
+		}
+	}
+	if p.MatrixRoom != "" && !strings.HasPrefix(p.MatrixRoom, "!") {
+		return fmt.Errorf("matrix_room must be a room ID starting with '!'")
+	}
+	return nil
+}
+
+func isE164(n string) bool {
+	if len(n) < 8 || len(n) > 16 || n[0] != '+' {
+		return false
+	}
+	for _, c := range n[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// userTarget is a user with saved notification preferences.
+type userTarget struct {
+	username string
+	prefs    UserNotificationPrefs
+}
+
+// loadUserTargets reads every user's saved preferences, sorted by username.
+// Invalid entries are logged and skipped.
+func (e *NotificationEngine) loadUserTargets() []userTarget {
+	raw, err := e.store.ListUserNotificationPrefs()
+	if err != nil {
+		e.logger.Error("failed to load user notification preferences", "error", err)
+		return nil
+	}
+	targets := make([]userTarget, 0, len(raw))
+	for username, v := range raw {
+		var p UserNotificationPrefs
+		if err := json.Unmarshal([]byte(v), &p); err != nil {
+			e.logger.Error("invalid user notification preferences, ignoring", "user", username, "error", err)
+			continue
+		}
+		targets = append(targets, userTarget{username: username, prefs: p})
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].username < targets[j].username })
+	return targets
+}
+
+// forUser resolves the config for a user: their thresholds and channels
+// replace the global ones where set.
+func (c NotificationConfig) forUser(p UserNotificationPrefs) NotificationConfig {
+	if p.Warning > 0 {
+		c.Warning = p.Warning
+	}
+	if p.Critical > 0 {
+		c.Critical = p.Critical
+	}
+	if p.Channels != nil {
+		c.Channels = *p.Channels
+		c.Routing = nil
+	}
+	return c
+}
+
+// forUser returns the senders addressed to a user's own recipients. Channels
+// the user has no recipient for are left unconfigured.
+func (s notificationSenders) forUser(p UserNotificationPrefs) notificationSenders {
+	var out notificationSenders
+	if s.mailer != nil && len(p.Email) > 0 {
+		out.mailer = s.mailer.withRecipients(p.Email)
+	}
+	if s.matrix != nil && p.MatrixRoom != "" {
+		out.matrix = s.matrix.withRoom(p.MatrixRoom)
+	}
+	if s.twilio != nil && len(p.SMSNumbers) > 0 {
+		out.twilio = s.twilio.withRecipients(p.SMSNumbers)
+	}
+	return out
+}
+
+// quotaName returns the quota's label, or its key when it has none.
+func (s QuotaStatus) quotaName() string {
+	if s.QuotaLabel != "" {
+		return s.QuotaLabel
+	}
+	return s.QuotaKey
+}
+
+// logProvider is the provider key used in the notification log. Alerts for a
+// user are deduplicated separately from the global ones, as "provider@user".
+func (s QuotaStatus) logProvider() string {
+	p := normalizeNotificationProvider(s.Provider)
+	if s.User != "" {
+		p += "@" + s.User
+	}
+	return p
+}
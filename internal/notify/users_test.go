@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNotificationEngine_Check_UserPreferences(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	s.UpsertUser("ops", "hash")
+	prefs, _ := json.Marshal(UserNotificationPrefs{Warning: 60, Email: []string{"ops@example.com"}})
+	if err := s.SetUserNotificationPrefs("ops", string(prefs)); err != nil {
+		t.Fatalf("SetUserNotificationPrefs failed: %v", err)
+	}
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	// Above the user's threshold only
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 70, Limit: 100})
+	if mailCount.Load() != 1 {
+		t.Fatalf("Expected 1 email for the user, got %d", mailCount.Load())
+	}
+	if sentAt, _, _ := s.GetLastNotification("anthropic@ops", "five_hour", "warning"); sentAt.IsZero() {
+		t.Error("Expected the user's warning to be logged under anthropic@ops")
+	}
+	if sentAt, _, _ := s.GetLastNotification("anthropic", "five_hour", "warning"); !sentAt.IsZero() {
+		t.Error("Expected no global warning below the global threshold")
+	}
+
+	// Above the global threshold: the global recipients are alerted, the user is not alerted again
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 82, Limit: 100})
+	if mailCount.Load() != 2 {
+		t.Fatalf("Expected 2 emails, got %d", mailCount.Load())
+	}
+
+	// A reset clears the user's log too
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", ResetOccurred: true})
+	if sentAt, _, _ := s.GetLastNotification("anthropic@ops", "five_hour", "warning"); !sentAt.IsZero() {
+		t.Error("Expected the reset to clear the user's warning")
+	}
+}
+
+func TestNotificationEngine_Check_UserWithoutRecipients(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+
+	// Thresholds alone do not copy the global recipients
+	s.UpsertUser("ops", "hash")
+	s.SetUserNotificationPrefs("ops", `{"warning_threshold":60}`)
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 82, Limit: 100})
+	if mailCount.Load() != 1 {
+		t.Errorf("Expected only the global email, got %d", mailCount.Load())
+	}
+}
+
+func TestUserNotificationPrefs_Validate(t *testing.T) {
+	valid := UserNotificationPrefs{Warning: 70, Critical: 90, Email: []string{"a@b.co"}, SMSNumbers: []string{"+15551234567"}, MatrixRoom: "!room:example.org"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	for name, p := range map[string]UserNotificationPrefs{
+		"threshold range": {Warning: 120},
+		"threshold order": {Warning: 90, Critical: 80},
+		"email":           {Email: []string{"nobody"}},
+		"email header":    {Email: []string{"ops@example.com\r\nBcc: all@example.com"}},
+		"sms":             {SMSNumbers: []string{"555-1234"}},
+		"matrix":          {MatrixRoom: "#alias:example.org"},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of a signed webhook body, as
+// "sha256=<hex>", keyed with the sender's secret.
+const SignatureHeader = "X-Onwatch-Signature"
+
+// WebhookSender posts JSON event payloads to a generic webhook URL, for
+// monitoring and observability tools.
+type WebhookSender struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookSender creates a webhook sender posting to url.
+func NewWebhookSender(url string) *WebhookSender {
+	return &WebhookSender{
+		url: url,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{MaxIdleConns: 1, MaxIdleConnsPerHost: 1},
+		},
+	}
+}
+
+// NewSignedWebhookSender creates a webhook sender posting to url that signs
+// every body with secret in the SignatureHeader, so the receiver can reject
+// requests that did not come from onWatch. An empty secret sends unsigned.
+func NewSignedWebhookSender(url, secret string) *WebhookSender {
+	w := NewWebhookSender(url)
+	w.secret = secret
+	return w
+}
+
+// Signature returns the SignatureHeader value for body keyed with secret.
+func Signature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send posts payload as JSON. Any non-2xx response is an error.
+func (w *WebhookSender) Send(payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("notify.WebhookSender.Send: marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify.WebhookSender.Send: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "onwatch-webhook")
+	if w.secret != "" {
+		req.Header.Set(SignatureHeader, Signature(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify.WebhookSender.Send: HTTP POST: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify.WebhookSender.Send: webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -28,10 +28,11 @@ func (s *Store) InsertAnthropicSnapshot(snapshot *api.AnthropicSnapshot) (int64,
 	defer tx.Rollback()
 
 	result, err := tx.Exec(
-		`INSERT INTO anthropic_snapshots (captured_at, raw_json, quota_count) VALUES (?, ?, ?)`,
+		`INSERT INTO anthropic_snapshots (captured_at, raw_json, quota_count, injected) VALUES (?, ?, ?, ?)`,
 		snapshot.CapturedAt.Format(time.RFC3339Nano),
 		snapshot.RawJSON,
 		len(snapshot.Quotas),
+		snapshot.Injected,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert anthropic snapshot: %w", err)
@@ -69,8 +70,8 @@ func (s *Store) QueryLatestAnthropic() (*api.AnthropicSnapshot, error) {
 	var capturedAt string
 
 	err := s.db.QueryRow(
-		`SELECT id, captured_at, quota_count FROM anthropic_snapshots ORDER BY captured_at DESC LIMIT 1`,
-	).Scan(&snapshot.ID, &capturedAt, new(int))
+		`SELECT id, captured_at, quota_count, injected FROM anthropic_snapshots ORDER BY captured_at DESC LIMIT 1`,
+	).Scan(&snapshot.ID, &capturedAt, new(int), &snapshot.Injected)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -107,15 +108,43 @@ func (s *Store) QueryLatestAnthropic() (*api.AnthropicSnapshot, error) {
 	return &snapshot, rows.Err()
 }
 
+// QueryFirstAnthropicTime returns when the oldest Anthropic snapshot was
+// captured, or the zero time if there are none.
+func (s *Store) QueryFirstAnthropicTime() (time.Time, error) {
+	var capturedAt sql.NullString
+	err := s.db.QueryRow(`SELECT MIN(captured_at) FROM anthropic_snapshots`).Scan(&capturedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query first anthropic snapshot: %w", err)
+	}
+	if !capturedAt.Valid {
+		return time.Time{}, nil
+	}
+	t, _ := time.Parse(time.RFC3339Nano, capturedAt.String)
+	return t, nil
+}
+
+// AnthropicSnapshotExists reports whether a snapshot was captured at exactly capturedAt.
+func (s *Store) AnthropicSnapshotExists(capturedAt time.Time) (bool, error) {
+	var count int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM anthropic_snapshots WHERE captured_at = ?`,
+		capturedAt.Format(time.RFC3339Nano),
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check anthropic snapshot: %w", err)
+	}
+	return count > 0, nil
+}
+
 // QueryAnthropicRange returns Anthropic snapshots within a time range with optional limit.
 func (s *Store) QueryAnthropicRange(start, end time.Time, limit ...int) ([]*api.AnthropicSnapshot, error) {
-	query := `SELECT id, captured_at, quota_count FROM anthropic_snapshots
+	query := `SELECT id, captured_at, quota_count, injected FROM anthropic_snapshots
 		WHERE captured_at BETWEEN ? AND ? ORDER BY captured_at ASC`
 	args := []interface{}{start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano)}
 	if len(limit) > 0 && limit[0] > 0 {
-		query = `SELECT id, captured_at, quota_count
+		query = `SELECT id, captured_at, quota_count, injected
 			FROM (
-				SELECT id, captured_at, quota_count
+				SELECT id, captured_at, quota_count, injected
 				FROM anthropic_snapshots
 				WHERE captured_at BETWEEN ? AND ?
 				ORDER BY captured_at DESC
@@ -129,13 +158,37 @@ func (s *Store) QueryAnthropicRange(start, end time.Time, limit ...int) ([]*api.
 	if err != nil {
 		return nil, fmt.Errorf("failed to query anthropic range: %w", err)
 	}
+	return s.scanAnthropicSnapshots(rows)
+}
+
+// QueryAnthropicSnapshotsAfter returns up to limit Anthropic snapshots in
+// capture order (ties broken by id), starting after the snapshot captured at
+// after with id afterID. Pass the zero time and 0 for the first page, then
+// the last snapshot returned for the next.
+func (s *Store) QueryAnthropicSnapshotsAfter(after time.Time, afterID int64, limit int) ([]*api.AnthropicSnapshot, error) {
+	at := after.Format(time.RFC3339Nano)
+	rows, err := s.db.Query(
+		`SELECT id, captured_at, quota_count, injected FROM anthropic_snapshots
+		WHERE captured_at > ? OR (captured_at = ? AND id > ?)
+		ORDER BY captured_at ASC, id ASC LIMIT ?`,
+		at, at, afterID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query anthropic snapshots: %w", err)
+	}
+	return s.scanAnthropicSnapshots(rows)
+}
+
+// scanAnthropicSnapshots reads (id, captured_at, quota_count, injected) rows, closing
+// them, and loads each snapshot's quota values.
+func (s *Store) scanAnthropicSnapshots(rows *sql.Rows) ([]*api.AnthropicSnapshot, error) {
 	defer rows.Close()
 
 	var snapshots []*api.AnthropicSnapshot
 	for rows.Next() {
 		var snap api.AnthropicSnapshot
 		var capturedAt string
-		if err := rows.Scan(&snap.ID, &capturedAt, new(int)); err != nil {
+		if err := rows.Scan(&snap.ID, &capturedAt, new(int), &snap.Injected); err != nil {
 			return nil, fmt.Errorf("failed to scan anthropic snapshot: %w", err)
 		}
 		snap.CapturedAt, _ = time.Parse(time.RFC3339Nano, capturedAt)
@@ -145,6 +198,7 @@ func (s *Store) QueryAnthropicRange(start, end time.Time, limit ...int) ([]*api.
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	rows.Close()
 
 	// Load quota values for each snapshot
 	for _, snap := range snapshots {
@@ -222,6 +276,15 @@ func (s *Store) UpdateAnthropicCycle(quotaName string, peak, delta float64) erro
 	return nil
 }
 
+// DeleteAnthropicCycles removes every Anthropic reset cycle so they can be
+// rebuilt from snapshots.
+func (s *Store) DeleteAnthropicCycles() error {
+	if _, err := s.db.Exec(`DELETE FROM anthropic_reset_cycles`); err != nil {
+		return fmt.Errorf("failed to delete anthropic cycles: %w", err)
+	}
+	return nil
+}
+
 // QueryActiveAnthropicCycle returns the active cycle for an Anthropic quota.
 func (s *Store) QueryActiveAnthropicCycle(quotaName string) (*AnthropicResetCycle, error) {
 	var cycle AnthropicResetCycle
@@ -408,6 +471,11 @@ func (s *Store) QueryAnthropicCycleOverview(groupBy string, limit int) ([]CycleO
 			PeakValue:  c.PeakUtilization,
 			TotalDelta: c.TotalDelta,
 		}
+		stats, err := s.QueryCycleStats("anthropic", c.QuotaName, c.CycleStart, c.CycleEnd)
+		if err != nil {
+			return nil, fmt.Errorf("store.QueryAnthropicCycleOverview: %w", err)
+		}
+		row.Stats = &stats
 
 		// Determine the end boundary for the snapshot query
 		// For active cycles (no cycle_end), use current time
@@ -422,7 +490,7 @@ func (s *Store) QueryAnthropicCycleOverview(groupBy string, limit int) ([]CycleO
 		// Find the snapshot where the primary quota peaked within this cycle
 		var snapshotID int64
 		var capturedAt string
-		err := s.db.QueryRow(
+		err = s.db.QueryRow(
 			`SELECT s.id, s.captured_at FROM anthropic_snapshots s
 			JOIN anthropic_quota_values qv ON qv.snapshot_id = s.id
 			WHERE qv.quota_name = ? AND s.captured_at >= ? AND s.captured_at < ?
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -53,6 +54,47 @@ func TestStore_InsertAnthropicSnapshot(t *testing.T) {
 	}
 }
 
+func TestStore_AnthropicBackfillHelpers(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	first, err := s.QueryFirstAnthropicTime()
+	if err != nil || !first.IsZero() {
+		t.Fatalf("QueryFirstAnthropicTime on empty DB = %v, %v; want zero time", first, err)
+	}
+
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{base.Add(time.Hour), base} {
+		if _, err := s.InsertAnthropicSnapshot(&api.AnthropicSnapshot{CapturedAt: at}); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot failed: %v", err)
+		}
+	}
+
+	first, err = s.QueryFirstAnthropicTime()
+	if err != nil || !first.Equal(base) {
+		t.Errorf("QueryFirstAnthropicTime = %v, %v; want %v", first, err, base)
+	}
+	if ok, err := s.AnthropicSnapshotExists(base); err != nil || !ok {
+		t.Errorf("AnthropicSnapshotExists(base) = %v, %v; want true", ok, err)
+	}
+	if ok, err := s.AnthropicSnapshotExists(base.Add(time.Minute)); err != nil || ok {
+		t.Errorf("AnthropicSnapshotExists(base+1m) = %v, %v; want false", ok, err)
+	}
+
+	if _, err := s.CreateAnthropicCycle("five_hour", base, nil); err != nil {
+		t.Fatalf("CreateAnthropicCycle failed: %v", err)
+	}
+	if err := s.DeleteAnthropicCycles(); err != nil {
+		t.Fatalf("DeleteAnthropicCycles failed: %v", err)
+	}
+	if cycle, err := s.QueryActiveAnthropicCycle("five_hour"); err != nil || cycle != nil {
+		t.Errorf("expected no cycles after delete, got %+v, %v", cycle, err)
+	}
+}
+
 func TestStore_QueryLatestAnthropic_EmptyDB(t *testing.T) {
 	s, err := New(":memory:")
 	if err != nil {
@@ -202,6 +244,47 @@ func TestStore_QueryAnthropicRange_WithLimit(t *testing.T) {
 	}
 }
 
+func TestStore_QueryAnthropicSnapshotsAfter(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	// Inserted out of order, with two snapshots sharing a capture time
+	base := time.Date(2026, 2, 6, 12, 0, 0, 0, time.UTC)
+	for _, h := range []int{3, 0, 1, 1, 2} {
+		snapshot := &api.AnthropicSnapshot{
+			CapturedAt: base.Add(time.Duration(h) * time.Hour),
+			RawJSON:    "{}",
+			Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: float64(h)}},
+		}
+		if _, err := s.InsertAnthropicSnapshot(snapshot); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot failed: %v", err)
+		}
+	}
+
+	var got []float64
+	var after time.Time
+	var afterID int64
+	for pages := 0; pages < 10; pages++ {
+		page, err := s.QueryAnthropicSnapshotsAfter(after, afterID, 2)
+		if err != nil {
+			t.Fatalf("QueryAnthropicSnapshotsAfter failed: %v", err)
+		}
+		for _, snap := range page {
+			got = append(got, snap.Quotas[0].Utilization)
+		}
+		if len(page) < 2 {
+			break
+		}
+		after, afterID = page[len(page)-1].CapturedAt, page[len(page)-1].ID
+	}
+	if fmt.Sprint(got) != "[0 1 1 2 3]" {
+		t.Errorf("paged snapshots = %v, want [0 1 1 2 3]", got)
+	}
+}
+
 func TestStore_CreateAnthropicCycle(t *testing.T) {
 	s, err := New(":memory:")
 	if err != nil {
@@ -417,6 +417,46 @@ func (s *Store) QueryAllAntigravityModelIDs() ([]string, error) {
 	return ids, rows.Err()
 }
 
+// AntigravityModelInfo describes a model ID seen in Antigravity snapshots.
+type AntigravityModelInfo struct {
+	ModelID   string
+	Label     string // most recent label reported by the API (may be empty)
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// QueryAntigravityModels returns every model ID seen in stored snapshots,
+// with its latest API label and when it was first and last seen.
+func (s *Store) QueryAntigravityModels() ([]AntigravityModelInfo, error) {
+	rows, err := s.db.Query(
+		`SELECT v.model_id, MIN(s.captured_at), MAX(s.captured_at),
+			(SELECT v2.label FROM antigravity_model_values v2
+			 WHERE v2.model_id = v.model_id ORDER BY v2.id DESC LIMIT 1)
+		FROM antigravity_model_values v
+		JOIN antigravity_snapshots s ON s.id = v.snapshot_id
+		GROUP BY v.model_id ORDER BY v.model_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query antigravity models: %w", err)
+	}
+	defer rows.Close()
+
+	var models []AntigravityModelInfo
+	for rows.Next() {
+		var m AntigravityModelInfo
+		var firstSeen, lastSeen string
+		var label sql.NullString
+		if err := rows.Scan(&m.ModelID, &firstSeen, &lastSeen, &label); err != nil {
+			return nil, fmt.Errorf("failed to scan antigravity model: %w", err)
+		}
+		m.Label = label.String
+		m.FirstSeen, _ = time.Parse(time.RFC3339Nano, firstSeen)
+		m.LastSeen, _ = time.Parse(time.RFC3339Nano, lastSeen)
+		models = append(models, m)
+	}
+	return models, rows.Err()
+}
+
 // QueryAntigravityCycleOverview returns cycle overview rows for canonical Antigravity quota groups.
 func (s *Store) QueryAntigravityCycleOverview(groupBy string, limit int) ([]CycleOverviewRow, error) {
 	if groupBy == "" {
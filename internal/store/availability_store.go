@@ -0,0 +1,172 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// pollSampleInterval is how often an unchanged poll outcome is re-recorded.
+// Only state transitions and these periodic samples are stored, keeping
+// poll_outcomes small while still capturing latency over time.
+const pollSampleInterval = time.Hour
+
+// maxPollErrorLength caps stored error messages.
+const maxPollErrorLength = 500
+
+// PollOutcome is a recorded provider API poll result.
+type PollOutcome struct {
+	Provider   string
+	Success    bool
+	Latency    time.Duration
+	Error      string
+	RecordedAt time.Time
+}
+
+// Availability summarizes a provider's API reliability over a window.
+type Availability struct {
+	Provider             string    `json:"provider"`
+	Since                time.Time `json:"since"`
+	UptimePercent        float64   `json:"uptime_percent"`
+	Failures             int       `json:"failures"` // outages (transitions to failing) in the window
+	LongestOutageSeconds float64   `json:"longest_outage_seconds"`
+	ObservedSeconds      float64   `json:"observed_seconds"`
+	Failing              bool      `json:"failing"` // whether the most recent poll failed
+}
+
+// RecordPollOutcome stores a poll result if it differs from the provider's last
+// recorded outcome or the last record is older than pollSampleInterval.
+// Returns whether a row was written.
+func (s *Store) RecordPollOutcome(o PollOutcome) (bool, error) {
+	if o.RecordedAt.IsZero() {
+		o.RecordedAt = time.Now().UTC()
+	}
+	if len(o.Error) > maxPollErrorLength {
+		o.Error = o.Error[:maxPollErrorLength]
+	}
+
+	var lastSuccess int
+	var lastAt string
+	err := s.db.QueryRow(
+		`SELECT success, recorded_at FROM poll_outcomes
+		WHERE provider = ? ORDER BY recorded_at DESC, id DESC LIMIT 1`,
+		o.Provider,
+	).Scan(&lastSuccess, &lastAt)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("store.RecordPollOutcome: %w", err)
+	}
+	if err == nil {
+		last, _ := time.Parse(time.RFC3339Nano, lastAt)
+		if (lastSuccess == 1) == o.Success && o.RecordedAt.Sub(last) < pollSampleInterval {
+			return false, nil
+		}
+	}
+
+	success := 0
+	if o.Success {
+		success = 1
+	}
+	if _, err := s.db.Exec(
+		`INSERT INTO poll_outcomes (provider, success, latency_ms, error, recorded_at) VALUES (?, ?, ?, ?, ?)`,
+		o.Provider, success, o.Latency.Milliseconds(), o.Error, o.RecordedAt.UTC().Format(time.RFC3339Nano),
+	); err != nil {
+		return false, fmt.Errorf("store.RecordPollOutcome: insert: %w", err)
+	}
+	return true, nil
+}
+
+// QueryPollOutcomes returns a provider's recorded poll outcomes since the given time, oldest first.
+func (s *Store) QueryPollOutcomes(provider string, since time.Time) ([]PollOutcome, error) {
+	rows, err := s.db.Query(
+		`SELECT success, latency_ms, error, recorded_at FROM poll_outcomes
+		WHERE provider = ? AND recorded_at >= ? ORDER BY recorded_at ASC, id ASC`,
+		provider, since.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store.QueryPollOutcomes: %w", err)
+	}
+	defer rows.Close()
+
+	var outcomes []PollOutcome
+	for rows.Next() {
+		var o PollOutcome
+		var success int
+		var latencyMs int64
+		var recordedAt string
+		if err := rows.Scan(&success, &latencyMs, &o.Error, &recordedAt); err != nil {
+			return nil, fmt.Errorf("store.QueryPollOutcomes: scan: %w", err)
+		}
+		o.Provider = provider
+		o.Success = success == 1
+		o.Latency = time.Duration(latencyMs) * time.Millisecond
+		o.RecordedAt, _ = time.Parse(time.RFC3339Nano, recordedAt)
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, rows.Err()
+}
+
+// QueryAvailability computes uptime, outage count and longest outage for a provider
+// since the given time. Each recorded outcome holds until the next one (or now).
+// The last outcome before the window seeds the starting state.
+func (s *Store) QueryAvailability(provider string, since time.Time) (*Availability, error) {
+	since = since.UTC()
+	now := time.Now().UTC()
+	result := &Availability{Provider: provider, Since: since, UptimePercent: 100}
+
+	outcomes, err := s.QueryPollOutcomes(provider, since)
+	if err != nil {
+		return nil, fmt.Errorf("store.QueryAvailability: %w", err)
+	}
+
+	// Seed with the state in effect at the start of the window
+	var seed int
+	err = s.db.QueryRow(
+		`SELECT success FROM poll_outcomes
+		WHERE provider = ? AND recorded_at < ? ORDER BY recorded_at DESC, id DESC LIMIT 1`,
+		provider, since.Format(time.RFC3339Nano),
+	).Scan(&seed)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("store.QueryAvailability: seed: %w", err)
+	}
+	if err == nil {
+		outcomes = append([]PollOutcome{{Provider: provider, Success: seed == 1, RecordedAt: since}}, outcomes...)
+	}
+	if len(outcomes) == 0 {
+		return result, nil
+	}
+
+	var up, down, outage time.Duration
+	failing := false
+	for i, o := range outcomes {
+		end := now
+		if i+1 < len(outcomes) {
+			end = outcomes[i+1].RecordedAt
+		}
+		span := end.Sub(o.RecordedAt)
+		if span < 0 {
+			span = 0
+		}
+
+		if o.Success {
+			up += span
+			outage = 0
+		} else {
+			if !failing {
+				result.Failures++
+			}
+			down += span
+			outage += span
+			if secs := outage.Seconds(); secs > result.LongestOutageSeconds {
+				result.LongestOutageSeconds = secs
+			}
+		}
+		failing = !o.Success
+	}
+
+	result.Failing = failing
+	result.ObservedSeconds = (up + down).Seconds()
+	if total := up + down; total > 0 {
+		result.UptimePercent = float64(up) / float64(total) * 100
+	}
+	return result, nil
+}
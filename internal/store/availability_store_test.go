@@ -0,0 +1,116 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore_RecordPollOutcome_TransitionsAndSampling(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	base := time.Now().UTC().Add(-3 * time.Hour)
+	steps := []struct {
+		offset  time.Duration
+		success bool
+		want    bool
+	}{
+		{0, true, true},                         // first outcome
+		{time.Minute, true, false},              // unchanged, within sample interval
+		{2 * time.Minute, false, true},          // transition to failing
+		{3 * time.Minute, false, false},         // still failing
+		{4 * time.Minute, true, true},           // recovered
+		{4*time.Minute + time.Hour, true, true}, // periodic sample
+	}
+	for i, st := range steps {
+		written, err := s.RecordPollOutcome(PollOutcome{
+			Provider:   "anthropic",
+			Success:    st.success,
+			Latency:    250 * time.Millisecond,
+			RecordedAt: base.Add(st.offset),
+		})
+		if err != nil {
+			t.Fatalf("step %d: RecordPollOutcome failed: %v", i, err)
+		}
+		if written != st.want {
+			t.Errorf("step %d: written = %v, want %v", i, written, st.want)
+		}
+	}
+
+	outcomes, err := s.QueryPollOutcomes("anthropic", base.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("QueryPollOutcomes failed: %v", err)
+	}
+	if len(outcomes) != 4 {
+		t.Fatalf("expected 4 stored outcomes, got %d", len(outcomes))
+	}
+	if outcomes[0].Latency != 250*time.Millisecond {
+		t.Errorf("expected latency 250ms, got %v", outcomes[0].Latency)
+	}
+
+	// Other providers are independent
+	written, _ := s.RecordPollOutcome(PollOutcome{Provider: "codex", Success: true, RecordedAt: base})
+	if !written {
+		t.Error("expected first codex outcome to be written")
+	}
+}
+
+func TestStore_QueryAvailability(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	since := now.Add(-10 * time.Hour)
+
+	// Failing before the window, recovering 1h into it, then a 2h outage
+	record := func(success bool, at time.Time) {
+		t.Helper()
+		if _, err := s.RecordPollOutcome(PollOutcome{Provider: "synthetic", Success: success, RecordedAt: at, Error: "boom"}); err != nil {
+			t.Fatalf("RecordPollOutcome failed: %v", err)
+		}
+	}
+	record(false, since.Add(-time.Hour))
+	record(true, since.Add(time.Hour))
+	record(false, now.Add(-4*time.Hour))
+	record(true, now.Add(-2*time.Hour))
+
+	a, err := s.QueryAvailability("synthetic", since)
+	if err != nil {
+		t.Fatalf("QueryAvailability failed: %v", err)
+	}
+	if a.Failures != 2 {
+		t.Errorf("expected 2 outages, got %d", a.Failures)
+	}
+	// 3h down of 10h observed
+	if a.UptimePercent < 69.9 || a.UptimePercent > 70.1 {
+		t.Errorf("expected ~70%% uptime, got %.2f", a.UptimePercent)
+	}
+	if a.LongestOutageSeconds < 7199 || a.LongestOutageSeconds > 7201 {
+		t.Errorf("expected longest outage ~7200s, got %.0f", a.LongestOutageSeconds)
+	}
+	if a.Failing {
+		t.Error("expected provider not to be failing")
+	}
+}
+
+func TestStore_QueryAvailability_NoData(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	a, err := s.QueryAvailability("zai", time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("QueryAvailability failed: %v", err)
+	}
+	if a.UptimePercent != 100 || a.Failures != 0 || a.ObservedSeconds != 0 {
+		t.Errorf("expected empty 100%% availability, got %+v", a)
+	}
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// providerDataTables lists the tables holding only one provider's snapshots
+// and cycles. Per-snapshot value tables come before the snapshots they
+// reference.
+var providerDataTables = map[string][]string{
+	"synthetic":   {"quota_snapshots", "reset_cycles"},
+	"zai":         {"zai_snapshots", "zai_hourly_usage", "zai_reset_cycles"},
+	"anthropic":   {"anthropic_quota_values", "anthropic_snapshots", "anthropic_reset_cycles"},
+	"copilot":     {"copilot_quota_values", "copilot_snapshots", "copilot_reset_cycles"},
+	"codex":       {"codex_quota_values", "codex_snapshots", "codex_reset_cycles"},
+	"antigravity": {"antigravity_model_values", "antigravity_snapshots", "antigravity_reset_cycles"},
+}
+
+// sharedProviderTables are tables shared by all providers, keyed by a
+// provider column.
+var sharedProviderTables = []string{"sessions", "injected_snapshots"}
+
+// IsClearableProvider reports whether provider has data ClearProviderData can remove.
+func IsClearableProvider(provider string) bool {
+	_, ok := providerDataTables[provider]
+	return ok
+}
+
+// ClearableProviders returns the providers ClearProviderData accepts, sorted.
+func ClearableProviders() []string {
+	providers := make([]string, 0, len(providerDataTables))
+	for p := range providerDataTables {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// ClearProviderData deletes every snapshot, cycle and session stored for
+// provider in one transaction, leaving other providers' data, settings and
+// notification history intact. Returns the number of rows deleted per table.
+func (s *Store) ClearProviderData(provider string) (map[string]int64, error) {
+	tables, ok := providerDataTables[provider]
+	if !ok {
+		return nil, fmt.Errorf("store.ClearProviderData: unknown provider %q", provider)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("store.ClearProviderData: begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	deleted := make(map[string]int64, len(tables)+len(sharedProviderTables))
+	for _, table := range tables {
+		res, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s`, table))
+		if err != nil {
+			return nil, fmt.Errorf("store.ClearProviderData: %s: %w", table, err)
+		}
+		deleted[table], _ = res.RowsAffected()
+	}
+	for _, table := range sharedProviderTables {
+		res, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE provider = ?`, table), provider)
+		if err != nil {
+			return nil, fmt.Errorf("store.ClearProviderData: %s: %w", table, err)
+		}
+		deleted[table], _ = res.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("store.ClearProviderData: commit: %w", err)
+	}
+	return deleted, nil
+}
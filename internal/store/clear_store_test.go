@@ -0,0 +1,64 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+func TestStore_ClearProviderData(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := s.InsertZaiSnapshot(&api.ZaiSnapshot{CapturedAt: now}); err != nil {
+		t.Fatalf("InsertZaiSnapshot: %v", err)
+	}
+	if _, err := s.CreateZaiCycle("tokens", now, nil); err != nil {
+		t.Fatalf("CreateZaiCycle: %v", err)
+	}
+	if err := s.CreateSession("zai-1", now, 60, "zai"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	resp := api.QuotaResponse{Subscription: api.QuotaInfo{Limit: 100, Requests: 5, RenewsAt: now}}
+	if _, err := s.InsertSnapshot(resp.ToSnapshot(now)); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+	if err := s.CreateSession("syn-1", now, 60, "synthetic"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	deleted, err := s.ClearProviderData("zai")
+	if err != nil {
+		t.Fatalf("ClearProviderData: %v", err)
+	}
+	if deleted["zai_snapshots"] != 1 || deleted["zai_reset_cycles"] != 1 || deleted["sessions"] != 1 {
+		t.Errorf("unexpected deleted counts: %v", deleted)
+	}
+
+	if latest, _ := s.QueryLatestZai(); latest != nil {
+		t.Error("expected no Z.ai snapshots after clearing")
+	}
+	if cycle, _ := s.QueryActiveZaiCycle("tokens"); cycle != nil {
+		t.Error("expected no Z.ai cycles after clearing")
+	}
+	if sessions, _ := s.QuerySessionHistory("zai"); len(sessions) != 0 {
+		t.Errorf("expected no Z.ai sessions, got %d", len(sessions))
+	}
+
+	// Other providers are untouched
+	if latest, _ := s.QueryLatest(); latest == nil {
+		t.Error("Synthetic snapshot should survive clearing Z.ai")
+	}
+	if sessions, _ := s.QuerySessionHistory("synthetic"); len(sessions) != 1 {
+		t.Errorf("expected the Synthetic session to survive, got %d", len(sessions))
+	}
+
+	if _, err := s.ClearProviderData("nope"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
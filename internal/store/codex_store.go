@@ -536,6 +536,11 @@ func (s *Store) QueryCodexCycleOverview(groupBy string, limit int) ([]CycleOverv
 			PeakValue:  c.PeakUtilization,
 			TotalDelta: c.TotalDelta,
 		}
+		stats, err := s.QueryCycleStats("codex", c.QuotaName, c.CycleStart, c.CycleEnd)
+		if err != nil {
+			return nil, fmt.Errorf("store.QueryCodexCycleOverview: %w", err)
+		}
+		row.Stats = &stats
 
 		var endBoundary time.Time
 		if c.CycleEnd != nil {
@@ -546,7 +551,7 @@ func (s *Store) QueryCodexCycleOverview(groupBy string, limit int) ([]CycleOverv
 
 		var snapshotID int64
 		var capturedAt string
-		err := s.db.QueryRow(
+		err = s.db.QueryRow(
 			`SELECT s.id, s.captured_at FROM codex_snapshots s
 			JOIN codex_quota_values qv ON qv.snapshot_id = s.id
 			WHERE qv.quota_name = ? AND s.captured_at >= ? AND s.captured_at < ?
@@ -427,6 +427,11 @@ func (s *Store) QueryCopilotCycleOverview(groupBy string, limit int) ([]CycleOve
 			PeakValue:  float64(c.PeakUsed),
 			TotalDelta: float64(c.TotalDelta),
 		}
+		stats, err := s.QueryCycleStats("copilot", c.QuotaName, c.CycleStart, c.CycleEnd)
+		if err != nil {
+			return nil, fmt.Errorf("store.QueryCopilotCycleOverview: %w", err)
+		}
+		row.Stats = &stats
 
 		var endBoundary time.Time
 		if c.CycleEnd != nil {
@@ -438,7 +443,7 @@ func (s *Store) QueryCopilotCycleOverview(groupBy string, limit int) ([]CycleOve
 		// Find the snapshot where the primary quota had highest usage within this cycle
 		var snapshotID int64
 		var capturedAt string
-		err := s.db.QueryRow(
+		err = s.db.QueryRow(
 			`SELECT s.id, s.captured_at FROM copilot_snapshots s
 			JOIN copilot_quota_values qv ON qv.snapshot_id = s.id
 			WHERE qv.quota_name = ? AND s.captured_at >= ? AND s.captured_at < ?
@@ -0,0 +1,277 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ProviderPricing describes what a provider costs per billing month.
+// UnitPrices are charged per unit of tracked quota usage (requests for
+// Synthetic and Copilot, tokens/calls for Z.ai, utilization percentage points
+// for Anthropic, Codex and Antigravity), keyed by quota name.
+// Currency is the ISO 4217 code the provider bills in; empty means the
+// display currency.
+type ProviderPricing struct {
+	MonthlyFee float64            `json:"monthly_fee"`
+	UnitPrices map[string]float64 `json:"unit_prices,omitempty"`
+	Currency   string             `json:"currency,omitempty"`
+}
+
+// PricingConfig is the JSON shape stored under the "pricing" settings key.
+// With DisplayCurrency set, provider amounts are converted using FXRates
+// (display-currency units per one unit of the keyed currency) before totals
+// are summed. Without it, amounts are summed as-is.
+type PricingConfig struct {
+	BillingDay      int                        `json:"billing_day"` // day of month the billing cycle starts (1-28)
+	Providers       map[string]ProviderPricing `json:"providers"`
+	DisplayCurrency string                     `json:"display_currency,omitempty"`
+	FXRates         map[string]float64         `json:"fx_rates,omitempty"`
+}
+
+// rate returns the conversion factor from currency into the display currency.
+func (p PricingConfig) rate(currency string) (float64, bool) {
+	if p.DisplayCurrency == "" || currency == "" || currency == p.DisplayCurrency {
+		return 1, true
+	}
+	r, ok := p.FXRates[currency]
+	return r, ok && r > 0
+}
+
+// BudgetConfig is the JSON shape stored under the "budget" settings key.
+// Caps are monthly amounts in the same currency as the pricing config.
+type BudgetConfig struct {
+	Overall          float64            `json:"overall,omitempty"`   // cap across all priced providers; 0 for none
+	Providers        map[string]float64 `json:"providers,omitempty"` // per-provider caps
+	AlertPercent     float64            `json:"alert_percent"`       // alert when spend reaches this % of a cap (default 80)
+	IncludeProjected bool               `json:"include_projected"`   // also alert when projected month-end spend reaches it
+}
+
+// GetBudget returns the saved budget config, or nil if no cap is configured.
+func (s *Store) GetBudget() (*BudgetConfig, error) {
+	v, err := s.GetSetting("budget")
+	if err != nil {
+		return nil, fmt.Errorf("store.GetBudget: %w", err)
+	}
+	if v == "" {
+		return nil, nil
+	}
+	var cfg BudgetConfig
+	if err := json.Unmarshal([]byte(v), &cfg); err != nil {
+		return nil, fmt.Errorf("store.GetBudget: invalid JSON: %w", err)
+	}
+	if cfg.Overall <= 0 && len(cfg.Providers) == 0 {
+		return nil, nil
+	}
+	if cfg.AlertPercent <= 0 {
+		cfg.AlertPercent = 80
+	}
+	return &cfg, nil
+}
+
+// ProviderCost is one provider's spend for the current billing month.
+// ToDate/Projected are in the display currency; the Native fields are in the
+// provider's billing currency. The *Display fields are formatted copies
+// filled in by the API layer.
+type ProviderCost struct {
+	Provider        string  `json:"provider"`
+	ToDate          float64 `json:"to_date"`
+	Projected       float64 `json:"projected"`
+	Currency        string  `json:"currency,omitempty"`
+	NativeToDate    float64 `json:"native_to_date"`
+	NativeProjected float64 `json:"native_projected"`
+
+	ToDateDisplay          string `json:"to_date_display,omitempty"`
+	ProjectedDisplay       string `json:"projected_display,omitempty"`
+	NativeToDateDisplay    string `json:"native_to_date_display,omitempty"`
+	NativeProjectedDisplay string `json:"native_projected_display,omitempty"`
+}
+
+// CostProjection is the spend to date and projected end-of-month spend.
+// Providers whose currency has no FX rate are listed in Unconverted and left
+// out of the totals.
+type CostProjection struct {
+	PeriodStart time.Time      `json:"period_start"`
+	PeriodEnd   time.Time      `json:"period_end"`
+	Currency    string         `json:"currency,omitempty"`
+	Providers   []ProviderCost `json:"providers"`
+	ToDate      float64        `json:"to_date"`
+	Projected   float64        `json:"projected"`
+	Unconverted []string       `json:"unconverted,omitempty"`
+
+	ToDateDisplay    string `json:"to_date_display,omitempty"`
+	ProjectedDisplay string `json:"projected_display,omitempty"`
+}
+
+// GetPricing returns the saved pricing config, or nil if none is configured.
+func (s *Store) GetPricing() (*PricingConfig, error) {
+	v, err := s.GetSetting("pricing")
+	if err != nil {
+		return nil, fmt.Errorf("store.GetPricing: %w", err)
+	}
+	if v == "" {
+		return nil, nil
+	}
+	var cfg PricingConfig
+	if err := json.Unmarshal([]byte(v), &cfg); err != nil {
+		return nil, fmt.Errorf("store.GetPricing: invalid JSON: %w", err)
+	}
+	if len(cfg.Providers) == 0 {
+		return nil, nil
+	}
+	return &cfg, nil
+}
+
+// BillingPeriod returns the billing month containing now for a cycle that
+// starts on billingDay (clamped to 1-28) at 00:00 UTC.
+func BillingPeriod(billingDay int, now time.Time) (time.Time, time.Time) {
+	if billingDay < 1 {
+		billingDay = 1
+	}
+	if billingDay > 28 {
+		billingDay = 28
+	}
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), billingDay, 0, 0, 0, 0, time.UTC)
+	if now.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start, start.AddDate(0, 1, 0)
+}
+
+// cycleTables maps providers to their reset-cycle table and quota column.
+var cycleTables = map[string]struct{ table, key, filter string }{
+	"synthetic":   {"reset_cycles", "quota_type", "provider = 'synthetic'"},
+	"zai":         {"zai_reset_cycles", "quota_type", "1 = 1"},
+	"anthropic":   {"anthropic_reset_cycles", "quota_name", "1 = 1"},
+	"codex":       {"codex_reset_cycles", "quota_name", "1 = 1"},
+	"copilot":     {"copilot_reset_cycles", "quota_name", "1 = 1"},
+	"antigravity": {"antigravity_reset_cycles", "model_id", "1 = 1"},
+}
+
+// IsTrackedProvider reports whether provider has usage history that pricing can apply to.
+func IsTrackedProvider(provider string) bool {
+	_, ok := cycleTables[provider]
+	return ok
+}
+
+// QueryUsageBetween sums tracked usage (cycle total_delta) per quota for a
+// provider between start and end. Cycles straddling the window count in
+// proportion to their overlap; the active cycle runs until end.
+func (s *Store) QueryUsageBetween(provider string, start, end time.Time) (map[string]float64, error) {
+	t, ok := cycleTables[provider]
+	if !ok {
+		return nil, fmt.Errorf("store.QueryUsageBetween: unknown provider %q", provider)
+	}
+	start, end = start.UTC(), end.UTC()
+
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT %s, cycle_start, cycle_end, total_delta FROM %s
+		WHERE %s AND cycle_start < ? AND (cycle_end IS NULL OR cycle_end > ?)`,
+		t.key, t.table, t.filter),
+		end.Format(time.RFC3339Nano), start.Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store.QueryUsageBetween: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]float64)
+	for rows.Next() {
+		var quota, cycleStartStr string
+		var cycleEndStr *string
+		var delta float64
+		if err := rows.Scan(&quota, &cycleStartStr, &cycleEndStr, &delta); err != nil {
+			return nil, fmt.Errorf("store.QueryUsageBetween: scan: %w", err)
+		}
+		cycleStart, _ := time.Parse(time.RFC3339Nano, cycleStartStr)
+		cycleEnd := end
+		if cycleEndStr != nil {
+			cycleEnd, _ = time.Parse(time.RFC3339Nano, *cycleEndStr)
+		}
+
+		length := cycleEnd.Sub(cycleStart)
+		from, to := cycleStart, cycleEnd
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if length <= 0 || to.Sub(from) >= length {
+			usage[quota] += delta
+		} else if to.After(from) {
+			usage[quota] += delta * float64(to.Sub(from)) / float64(length)
+		}
+	}
+	return usage, rows.Err()
+}
+
+// ProjectCost computes spend to date for the billing month containing now and
+// extrapolates usage-based charges to the end of the month at the current rate.
+// Monthly fees count in full toward both figures.
+func (s *Store) ProjectCost(pricing PricingConfig, now time.Time) (*CostProjection, error) {
+	start, end := BillingPeriod(pricing.BillingDay, now)
+	result := &CostProjection{PeriodStart: start, PeriodEnd: end, Currency: pricing.DisplayCurrency, Providers: []ProviderCost{}}
+
+	elapsed := now.Sub(start)
+	scale := 0.0
+	if elapsed > 0 {
+		scale = float64(end.Sub(start)) / float64(elapsed)
+	}
+
+	for _, provider := range sortedKeys(pricing.Providers) {
+		p := pricing.Providers[provider]
+		variable := 0.0
+		if len(p.UnitPrices) > 0 {
+			usage, err := s.QueryUsageBetween(provider, start, now)
+			if err != nil {
+				return nil, fmt.Errorf("store.ProjectCost: %w", err)
+			}
+			for quota, price := range p.UnitPrices {
+				variable += usage[quota] * price
+			}
+		}
+		currency := p.Currency
+		if currency == "" {
+			currency = pricing.DisplayCurrency
+		}
+		cost := ProviderCost{
+			Provider:        provider,
+			Currency:        currency,
+			NativeToDate:    roundCents(p.MonthlyFee + variable),
+			NativeProjected: roundCents(p.MonthlyFee + variable*scale),
+		}
+		rate, ok := pricing.rate(currency)
+		if !ok {
+			// No rate: report native amounts only and keep them out of the totals
+			cost.ToDate, cost.Projected = cost.NativeToDate, cost.NativeProjected
+			result.Providers = append(result.Providers, cost)
+			result.Unconverted = append(result.Unconverted, provider)
+			continue
+		}
+		cost.ToDate = roundCents(cost.NativeToDate * rate)
+		cost.Projected = roundCents(cost.NativeProjected * rate)
+		result.Providers = append(result.Providers, cost)
+		result.ToDate += cost.ToDate
+		result.Projected += cost.Projected
+	}
+	result.ToDate = roundCents(result.ToDate)
+	result.Projected = roundCents(result.Projected)
+	return result, nil
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func sortedKeys(m map[string]ProviderPricing) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillingPeriod(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	start, end := BillingPeriod(15, now)
+	if !start.Equal(time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day 15: got %v - %v", start, end)
+	}
+
+	start, end = BillingPeriod(0, now)
+	if !start.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("default day: got %v - %v", start, end)
+	}
+}
+
+func TestStore_ProjectCost(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	// Straddles the period start: 2 of 11 days count
+	s.CreateCopilotCycle("premium_interactions", day(1).AddDate(0, 0, -9), nil)
+	s.CloseCopilotCycle("premium_interactions", day(3), 110, 110)
+	s.CreateCopilotCycle("premium_interactions", day(3), nil)
+	s.CloseCopilotCycle("premium_interactions", day(5), 100, 100)
+	s.CreateCopilotCycle("premium_interactions", day(5), nil)
+	s.UpdateCopilotCycle("premium_interactions", 50, 50)
+
+	usage, err := s.QueryUsageBetween("copilot", day(1), day(11))
+	if err != nil {
+		t.Fatalf("QueryUsageBetween failed: %v", err)
+	}
+	if got := usage["premium_interactions"]; got < 169.99 || got > 170.01 {
+		t.Errorf("expected 170 units, got %v", got)
+	}
+
+	projection, err := s.ProjectCost(PricingConfig{
+		BillingDay: 1,
+		Providers: map[string]ProviderPricing{
+			"copilot":   {MonthlyFee: 10, UnitPrices: map[string]float64{"premium_interactions": 0.04}},
+			"anthropic": {MonthlyFee: 100},
+		},
+	}, day(11))
+	if err != nil {
+		t.Fatalf("ProjectCost failed: %v", err)
+	}
+	if len(projection.Providers) != 2 || projection.Providers[1].Provider != "copilot" {
+		t.Fatalf("unexpected providers: %+v", projection.Providers)
+	}
+	// 170 * $0.04 = $6.80 so far; 10 of 31 days elapsed
+	copilot := projection.Providers[1]
+	if copilot.ToDate != 16.8 || copilot.Projected != 31.08 {
+		t.Errorf("copilot: expected 16.80 / 31.08, got %.2f / %.2f", copilot.ToDate, copilot.Projected)
+	}
+	if projection.ToDate != 116.8 || projection.Projected != 131.08 {
+		t.Errorf("total: expected 116.80 / 131.08, got %.2f / %.2f", projection.ToDate, projection.Projected)
+	}
+}
+
+func TestStore_ProjectCost_CurrencyConversion(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+	projection, err := s.ProjectCost(PricingConfig{
+		DisplayCurrency: "USD",
+		FXRates:         map[string]float64{"EUR": 1.1},
+		Providers: map[string]ProviderPricing{
+			"anthropic": {MonthlyFee: 100, Currency: "EUR"},
+			"codex":     {MonthlyFee: 20},
+			"zai":       {MonthlyFee: 50, Currency: "CNY"},
+		},
+	}, now)
+	if err != nil {
+		t.Fatalf("ProjectCost failed: %v", err)
+	}
+
+	anthropic := projection.Providers[0]
+	if anthropic.Currency != "EUR" || anthropic.NativeToDate != 100 || anthropic.ToDate != 110 {
+		t.Errorf("expected 100 EUR converted to 110 USD, got %+v", anthropic)
+	}
+	if projection.Providers[1].Currency != "USD" || projection.Providers[1].ToDate != 20 {
+		t.Errorf("expected codex in USD unchanged, got %+v", projection.Providers[1])
+	}
+	// CNY has no rate: reported natively, excluded from totals
+	if len(projection.Unconverted) != 1 || projection.Unconverted[0] != "zai" {
+		t.Errorf("expected zai to be unconverted, got %v", projection.Unconverted)
+	}
+	if projection.Currency != "USD" || projection.ToDate != 130 {
+		t.Errorf("expected total 130 USD, got %.2f %s", projection.ToDate, projection.Currency)
+	}
+}
+
+func TestStore_GetPricing_Unset(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	pricing, err := s.GetPricing()
+	if err != nil || pricing != nil {
+		t.Errorf("expected nil pricing, got %+v (err %v)", pricing, err)
+	}
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotCoverage summarizes how completely a provider was sampled over a
+// window, based on gaps between its stored snapshots.
+type SnapshotCoverage struct {
+	Provider          string    `json:"provider"`
+	Since             time.Time `json:"since"` // window start, or the first snapshot if later
+	Snapshots         int       `json:"snapshots"`
+	CoveragePercent   float64   `json:"coverage_percent"`
+	Gaps              int       `json:"gaps"`
+	LongestGapSeconds float64   `json:"longest_gap_seconds"`
+	ObservedSeconds   float64   `json:"observed_seconds"`
+}
+
+// QuerySnapshotCoverage reports the share of the window since since that is
+// covered by provider's snapshots. interval is the expected spacing between
+// snapshots; a stretch longer than gapThreshold without one (including the
+// stretch up to now) counts as missing, less the one interval that would have
+// passed anyway. The window starts at the first snapshot, so a fresh install
+// is not penalized for time before it existed. Returns nil without snapshots.
+func (s *Store) QuerySnapshotCoverage(provider string, since time.Time, interval, gapThreshold time.Duration) (*SnapshotCoverage, error) {
+	table, ok := rawSnapshotTables[provider]
+	if !ok {
+		return nil, fmt.Errorf("store.QuerySnapshotCoverage: unknown provider %q", provider)
+	}
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT captured_at FROM %s WHERE captured_at >= ? ORDER BY captured_at ASC`, table),
+		since.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store.QuerySnapshotCoverage: %w", err)
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var capturedAt string
+		if err := rows.Scan(&capturedAt); err != nil {
+			return nil, fmt.Errorf("store.QuerySnapshotCoverage: scan: %w", err)
+		}
+		if t, err := time.Parse(time.RFC3339Nano, capturedAt); err == nil {
+			times = append(times, t)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store.QuerySnapshotCoverage: %w", err)
+	}
+	if len(times) == 0 {
+		return nil, nil
+	}
+	coverage := snapshotCoverage(times, time.Now().UTC(), interval, gapThreshold)
+	coverage.Provider = provider
+	return coverage, nil
+}
+
+// CountSnapshotsSince returns how many snapshots provider has stored since
+// since.
+func (s *Store) CountSnapshotsSince(provider string, since time.Time) (int, error) {
+	table, ok := rawSnapshotTables[provider]
+	if !ok {
+		return 0, fmt.Errorf("store.CountSnapshotsSince: unknown provider %q", provider)
+	}
+	var count int
+	err := s.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE captured_at >= ?`, table),
+		since.UTC().Format(time.RFC3339Nano),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("store.CountSnapshotsSince: %w", err)
+	}
+	return count, nil
+}
+
+// snapshotCoverage computes coverage for ascending snapshot times up to now.
+func snapshotCoverage(times []time.Time, now time.Time, interval, gapThreshold time.Duration) *SnapshotCoverage {
+	result := &SnapshotCoverage{Since: times[0], Snapshots: len(times), CoveragePercent: 100}
+	observed := now.Sub(times[0])
+	if observed <= 0 {
+		return result
+	}
+
+	var missing time.Duration
+	for i, t := range times {
+		next := now
+		if i+1 < len(times) {
+			next = times[i+1]
+		}
+		gap := next.Sub(t)
+		if gap <= gapThreshold {
+			continue
+		}
+		result.Gaps++
+		missing += gap - interval
+		if secs := gap.Seconds(); secs > result.LongestGapSeconds {
+			result.LongestGapSeconds = secs
+		}
+	}
+
+	result.ObservedSeconds = observed.Seconds()
+	result.CoveragePercent = max(0, float64(observed-missing)/float64(observed)*100)
+	return result
+}
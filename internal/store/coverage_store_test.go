@@ -0,0 +1,83 @@
+package store
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+func TestStore_QuerySnapshotCoverage(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	if c, err := s.QuerySnapshotCoverage("zai", time.Now().Add(-time.Hour), time.Minute, 3*time.Minute); err != nil || c != nil {
+		t.Fatalf("expected nil coverage without snapshots, got %+v, %v", c, err)
+	}
+	if _, err := s.QuerySnapshotCoverage("nope", time.Now(), time.Minute, 3*time.Minute); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+
+	// Ten hours of minute polls with a four-hour hole in the middle
+	now := time.Now().UTC()
+	start := now.Add(-10 * time.Hour)
+	for at := start; at.Before(now); at = at.Add(time.Minute) {
+		if at.After(start.Add(3*time.Hour)) && at.Before(start.Add(7*time.Hour)) {
+			continue
+		}
+		if _, err := s.InsertZaiSnapshot(&api.ZaiSnapshot{CapturedAt: at}); err != nil {
+			t.Fatalf("InsertZaiSnapshot: %v", err)
+		}
+	}
+
+	c, err := s.QuerySnapshotCoverage("zai", now.Add(-24*time.Hour), time.Minute, 3*time.Minute)
+	if err != nil {
+		t.Fatalf("QuerySnapshotCoverage: %v", err)
+	}
+	if c.Gaps != 1 {
+		t.Errorf("Gaps = %d, want 1", c.Gaps)
+	}
+	if !c.Since.Equal(start) {
+		t.Errorf("Since = %v, want first snapshot %v", c.Since, start)
+	}
+	if math.Abs(c.CoveragePercent-60) > 1 {
+		t.Errorf("CoveragePercent = %.1f, want ~60", c.CoveragePercent)
+	}
+	if math.Abs(c.LongestGapSeconds-4*3600) > 120 {
+		t.Errorf("LongestGapSeconds = %.0f, want ~14400", c.LongestGapSeconds)
+	}
+}
+
+func TestSnapshotCoverage_StaleTail(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	times := []time.Time{now.Add(-2 * time.Hour), now.Add(-119 * time.Minute), now.Add(-118 * time.Minute)}
+	c := snapshotCoverage(times, now, time.Minute, 3*time.Minute)
+	if c.Gaps != 1 || c.CoveragePercent > 5 {
+		t.Errorf("expected polling that stopped to count as missing, got %+v", c)
+	}
+}
+
+func TestStore_CountSnapshotsSince(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	for i := 0; i < 5; i++ {
+		if _, err := s.InsertZaiSnapshot(&api.ZaiSnapshot{CapturedAt: now.Add(-time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatalf("InsertZaiSnapshot: %v", err)
+		}
+	}
+	if n, err := s.CountSnapshotsSince("zai", now.Add(-150*time.Minute)); err != nil || n != 3 {
+		t.Errorf("CountSnapshotsSince = %d, %v; want 3", n, err)
+	}
+	if _, err := s.CountSnapshotsSince("nope", now); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CycleStats is the average and median of one quota's value over the
+// snapshots of a cycle, in the unit of the cycle's peak. Together with the
+// peak they tell a cycle that spiked once from one that stayed busy.
+type CycleStats struct {
+	Avg     float64
+	Median  float64
+	Samples int
+}
+
+// cycleStatsQueries select one quota's value from each snapshot captured in
+// [start, end). Each takes the quota, start and end as arguments.
+var cycleStatsQueries = map[string]string{
+	"synthetic": `SELECT CASE ? WHEN 'search' THEN search_requests WHEN 'toolcall' THEN tool_requests ELSE sub_requests END
+		FROM quota_snapshots WHERE captured_at >= ? AND captured_at < ?`,
+	"zai": `SELECT CASE ? WHEN 'time' THEN time_current_value ELSE tokens_current_value END
+		FROM zai_snapshots WHERE captured_at >= ? AND captured_at < ?`,
+	"anthropic": `SELECT qv.utilization FROM anthropic_snapshots s
+		JOIN anthropic_quota_values qv ON qv.snapshot_id = s.id
+		WHERE qv.quota_name = ? AND s.captured_at >= ? AND s.captured_at < ?`,
+	"copilot": `SELECT qv.entitlement - qv.remaining FROM copilot_snapshots s
+		JOIN copilot_quota_values qv ON qv.snapshot_id = s.id
+		WHERE qv.quota_name = ? AND s.captured_at >= ? AND s.captured_at < ?`,
+	"codex": `SELECT qv.utilization FROM codex_snapshots s
+		JOIN codex_quota_values qv ON qv.snapshot_id = s.id
+		WHERE qv.quota_name = ? AND s.captured_at >= ? AND s.captured_at < ?`,
+	"antigravity": `SELECT 1.0 - mv.remaining_fraction FROM antigravity_snapshots s
+		JOIN antigravity_model_values mv ON mv.snapshot_id = s.id
+		WHERE mv.model_id = ? AND s.captured_at >= ? AND s.captured_at < ?`,
+}
+
+// QueryCycleStats returns the average and median of a provider quota's
+// value over the snapshots captured in a cycle. An active cycle (nil end)
+// runs to now. Samples is 0 when the cycle has no snapshots.
+func (s *Store) QueryCycleStats(provider, quota string, start time.Time, end *time.Time) (CycleStats, error) {
+	query, ok := cycleStatsQueries[provider]
+	if !ok {
+		return CycleStats{}, fmt.Errorf("store.QueryCycleStats: unknown provider %q", provider)
+	}
+	// A completed cycle's end is the first snapshot of the next one
+	endBoundary := time.Now().Add(time.Minute)
+	if end != nil {
+		endBoundary = *end
+	}
+
+	rows, err := s.db.Query(query, quota, start.Format(time.RFC3339Nano), endBoundary.Format(time.RFC3339Nano))
+	if err != nil {
+		return CycleStats{}, fmt.Errorf("store.QueryCycleStats: %w", err)
+	}
+	defer rows.Close()
+
+	var values []float64
+	for rows.Next() {
+		var v float64
+		if err := rows.Scan(&v); err != nil {
+			return CycleStats{}, fmt.Errorf("store.QueryCycleStats: scan: %w", err)
+		}
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return CycleStats{}, fmt.Errorf("store.QueryCycleStats: %w", err)
+	}
+	return cycleStats(values), nil
+}
+
+// cycleStats computes the average and median of values.
+func cycleStats(values []float64) CycleStats {
+	if len(values) == 0 {
+		return CycleStats{}
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	median := sorted[mid]
+	if len(sorted)%2 == 0 {
+		median = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return CycleStats{Avg: sum / float64(len(values)), Median: median, Samples: len(values)}
+}
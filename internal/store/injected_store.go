@@ -0,0 +1,54 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// InjectedSnapshot records a snapshot that was pushed through the debug API
+// rather than polled from the provider.
+type InjectedSnapshot struct {
+	Provider   string    `json:"provider"`
+	CapturedAt time.Time `json:"captured_at"`
+	Payload    string    `json:"payload"`
+}
+
+// RecordInjectedSnapshot marks the provider snapshot captured at capturedAt as injected.
+func (s *Store) RecordInjectedSnapshot(provider string, capturedAt time.Time, payload string) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO injected_snapshots (provider, captured_at, payload) VALUES (?, ?, ?)`,
+		provider, capturedAt.UTC().Format(time.RFC3339Nano), payload,
+	); err != nil {
+		return fmt.Errorf("store.RecordInjectedSnapshot: %w", err)
+	}
+	return nil
+}
+
+// QueryInjectedSnapshots returns the most recent injected snapshots, newest
+// first. An empty provider returns all providers.
+func (s *Store) QueryInjectedSnapshots(provider string, limit int) ([]InjectedSnapshot, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.Query(
+		`SELECT provider, captured_at, payload FROM injected_snapshots
+		WHERE ? = '' OR provider = ? ORDER BY captured_at DESC, id DESC LIMIT ?`,
+		provider, provider, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store.QueryInjectedSnapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var out []InjectedSnapshot
+	for rows.Next() {
+		var inj InjectedSnapshot
+		var capturedAt string
+		if err := rows.Scan(&inj.Provider, &capturedAt, &inj.Payload); err != nil {
+			return nil, fmt.Errorf("store.QueryInjectedSnapshots: scan: %w", err)
+		}
+		inj.CapturedAt, _ = time.Parse(time.RFC3339Nano, capturedAt)
+		out = append(out, inj)
+	}
+	return out, rows.Err()
+}
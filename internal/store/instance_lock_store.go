@@ -0,0 +1,103 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// InstanceLock identifies the onwatch process that owns the database.
+type InstanceLock struct {
+	PID         int
+	Hostname    string
+	StartedAt   time.Time
+	HeartbeatAt time.Time
+}
+
+// InstanceLockedError is returned by AcquireInstanceLock while another live
+// process holds the lock.
+type InstanceLockedError struct {
+	Holder InstanceLock
+}
+
+func (e *InstanceLockedError) Error() string {
+	return fmt.Sprintf("database is in use by another onwatch instance (PID %d on %s, started %s)",
+		e.Holder.PID, e.Holder.Hostname, e.Holder.StartedAt.Local().Format(time.RFC3339))
+}
+
+// AcquireInstanceLock claims the database for the process pid on hostname,
+// so that two daemons never write it at once. The lock is free when nobody
+// holds it, when this process already does, or when the holder has not sent
+// a heartbeat (RefreshInstanceLock) within staleAfter. takeover claims it
+// regardless, for a holder known to be gone or handing over.
+func (s *Store) AcquireInstanceLock(pid int, hostname string, staleAfter time.Duration, takeover bool) error {
+	now := time.Now().UTC()
+	nowStr := now.Format(time.RFC3339Nano)
+	result, err := s.db.Exec(
+		`INSERT INTO instance_lock (id, pid, hostname, started_at, heartbeat_at) VALUES (1, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET pid = excluded.pid, hostname = excluded.hostname,
+			started_at = excluded.started_at, heartbeat_at = excluded.heartbeat_at
+		WHERE ? OR (instance_lock.pid = excluded.pid AND instance_lock.hostname = excluded.hostname)
+			OR julianday(instance_lock.heartbeat_at) < julianday(?)`,
+		pid, hostname, nowStr, nowStr, takeover, now.Add(-staleAfter).Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return fmt.Errorf("store.AcquireInstanceLock: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	holder, err := s.GetInstanceLock()
+	if err != nil {
+		return err
+	}
+	if holder == nil {
+		// Released between the two statements
+		return s.AcquireInstanceLock(pid, hostname, staleAfter, takeover)
+	}
+	return &InstanceLockedError{Holder: *holder}
+}
+
+// RefreshInstanceLock records a heartbeat for the lock held by pid on
+// hostname. It returns false when the lock has passed to another process.
+func (s *Store) RefreshInstanceLock(pid int, hostname string) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE instance_lock SET heartbeat_at = ? WHERE id = 1 AND pid = ? AND hostname = ?`,
+		time.Now().UTC().Format(time.RFC3339Nano), pid, hostname,
+	)
+	if err != nil {
+		return false, fmt.Errorf("store.RefreshInstanceLock: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// ReleaseInstanceLock frees the lock if pid on hostname still holds it.
+func (s *Store) ReleaseInstanceLock(pid int, hostname string) error {
+	if _, err := s.db.Exec(
+		`DELETE FROM instance_lock WHERE id = 1 AND pid = ? AND hostname = ?`, pid, hostname,
+	); err != nil {
+		return fmt.Errorf("store.ReleaseInstanceLock: %w", err)
+	}
+	return nil
+}
+
+// GetInstanceLock returns the current lock holder, or nil when the database
+// is not locked.
+func (s *Store) GetInstanceLock() (*InstanceLock, error) {
+	var lock InstanceLock
+	var startedAt, heartbeatAt string
+	err := s.db.QueryRow(
+		`SELECT pid, hostname, started_at, heartbeat_at FROM instance_lock WHERE id = 1`,
+	).Scan(&lock.PID, &lock.Hostname, &startedAt, &heartbeatAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store.GetInstanceLock: %w", err)
+	}
+	lock.StartedAt, _ = time.Parse(time.RFC3339Nano, startedAt)
+	lock.HeartbeatAt, _ = time.Parse(time.RFC3339Nano, heartbeatAt)
+	return &lock, nil
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStore_InstanceLock(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.AcquireInstanceLock(100, "host-a", time.Minute, false); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	// Re-acquiring by the holder succeeds
+	if err := s.AcquireInstanceLock(100, "host-a", time.Minute, false); err != nil {
+		t.Fatalf("re-acquire: %v", err)
+	}
+
+	// A second instance is refused while the holder's heartbeat is fresh
+	err = s.AcquireInstanceLock(200, "host-b", time.Minute, false)
+	var locked *InstanceLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("expected InstanceLockedError, got %v", err)
+	}
+	if locked.Holder.PID != 100 || locked.Holder.Hostname != "host-a" {
+		t.Errorf("holder = %+v, want PID 100 on host-a", locked.Holder)
+	}
+
+	// Only the holder refreshes or releases
+	if held, err := s.RefreshInstanceLock(200, "host-b"); err != nil || held {
+		t.Errorf("refresh by non-holder = %v, %v; want false", held, err)
+	}
+	if err := s.ReleaseInstanceLock(200, "host-b"); err != nil {
+		t.Fatalf("release by non-holder: %v", err)
+	}
+	if held, err := s.RefreshInstanceLock(100, "host-a"); err != nil || !held {
+		t.Errorf("refresh by holder = %v, %v; want true", held, err)
+	}
+
+	// A stale lock is taken over
+	s.db.Exec(`UPDATE instance_lock SET heartbeat_at = ?`, time.Now().Add(-2*time.Minute).UTC().Format(time.RFC3339Nano))
+	if err := s.AcquireInstanceLock(200, "host-b", time.Minute, false); err != nil {
+		t.Fatalf("acquire stale lock: %v", err)
+	}
+	if held, _ := s.RefreshInstanceLock(100, "host-a"); held {
+		t.Error("previous holder should have lost the lock")
+	}
+
+	// takeover ignores a fresh holder
+	if err := s.AcquireInstanceLock(300, "host-a", time.Minute, true); err != nil {
+		t.Fatalf("takeover: %v", err)
+	}
+
+	if err := s.ReleaseInstanceLock(300, "host-a"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if holder, err := s.GetInstanceLock(); err != nil || holder != nil {
+		t.Errorf("after release: holder = %+v, err = %v", holder, err)
+	}
+}
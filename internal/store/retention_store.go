@@ -0,0 +1,83 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// snapshotTables lists every snapshot table with its per-snapshot value
+// table, if any. Value rows are deleted before the snapshots they reference.
+var snapshotTables = []struct{ snapshots, values string }{
+	{"quota_snapshots", ""},
+	{"zai_snapshots", ""},
+	{"anthropic_snapshots", "anthropic_quota_values"},
+	{"copilot_snapshots", "copilot_quota_values"},
+	{"codex_snapshots", "codex_quota_values"},
+	{"antigravity_snapshots", "antigravity_model_values"},
+	{"injected_snapshots", ""},
+}
+
+// PruneSnapshots deletes every provider's snapshots captured before before,
+// with their quota values, in one transaction. Reset cycles are left alone,
+// so cycle history outlives the raw snapshots. Returns the number of
+// snapshots deleted.
+func (s *Store) PruneSnapshots(before time.Time) (int64, error) {
+	cutoff := before.UTC().Format(time.RFC3339Nano)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("store.PruneSnapshots: begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, t := range snapshotTables {
+		if t.values != "" {
+			if _, err := tx.Exec(fmt.Sprintf(
+				`DELETE FROM %s WHERE snapshot_id IN (SELECT id FROM %s WHERE julianday(captured_at) < julianday(?))`,
+				t.values, t.snapshots), cutoff); err != nil {
+				return 0, fmt.Errorf("store.PruneSnapshots: %s: %w", t.values, err)
+			}
+		}
+		res, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE julianday(captured_at) < julianday(?)`, t.snapshots), cutoff)
+		if err != nil {
+			return 0, fmt.Errorf("store.PruneSnapshots: %s: %w", t.snapshots, err)
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("store.PruneSnapshots: commit: %w", err)
+	}
+	return total, nil
+}
+
+// PruneCycles deletes every provider's completed reset cycles that ended
+// before before. Active cycles are never deleted. Returns the number of
+// cycles deleted.
+func (s *Store) PruneCycles(before time.Time) (int64, error) {
+	cutoff := before.UTC().Format(time.RFC3339Nano)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("store.PruneCycles: begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, c := range cycleTables {
+		res, err := tx.Exec(fmt.Sprintf(
+			`DELETE FROM %s WHERE cycle_end IS NOT NULL AND julianday(cycle_end) < julianday(?)`, c.table), cutoff)
+		if err != nil {
+			return 0, fmt.Errorf("store.PruneCycles: %s: %w", c.table, err)
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("store.PruneCycles: commit: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+func TestStore_PruneSnapshots(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	old, recent := now.Add(-48*time.Hour), now.Add(-time.Hour)
+	for _, at := range []time.Time{old, recent} {
+		s.InsertSnapshot(&api.Snapshot{CapturedAt: at, Sub: api.QuotaInfo{Limit: 100, Requests: 5}})
+		s.InsertAnthropicSnapshot(&api.AnthropicSnapshot{CapturedAt: at, Quotas: []api.AnthropicQuota{{Name: "five_hour", Utilization: 10}}})
+	}
+	s.CreateCycle("subscription", old, now)
+	s.CloseCycle("subscription", old.Add(time.Hour), 5, 5)
+
+	n, err := s.PruneSnapshots(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("PruneSnapshots: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("deleted %d snapshots, want 2", n)
+	}
+	if snaps, _ := s.QueryRange(old.Add(-time.Minute), now); len(snaps) != 1 {
+		t.Errorf("expected 1 synthetic snapshot left, got %d", len(snaps))
+	}
+	snaps, _ := s.QueryAnthropicRange(old.Add(-time.Minute), now)
+	if len(snaps) != 1 || len(snaps[0].Quotas) != 1 {
+		t.Errorf("expected the recent Anthropic snapshot with its quota left, got %+v", snaps)
+	}
+	var values int
+	s.db.QueryRow(`SELECT COUNT(*) FROM anthropic_quota_values`).Scan(&values)
+	if values != 1 {
+		t.Errorf("expected the old snapshot's quota values deleted, %d left", values)
+	}
+	if cycles, _ := s.QueryCycleHistory("subscription"); len(cycles) != 1 {
+		t.Errorf("snapshot pruning must keep cycles, got %d", len(cycles))
+	}
+}
+
+func TestStore_PruneCycles(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	old := now.Add(-400 * 24 * time.Hour)
+	s.CreateCycle("subscription", old, old.Add(24*time.Hour))
+	s.CloseCycle("subscription", old.Add(24*time.Hour), 50, 50)
+	s.CreateCycle("subscription", now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+	s.CloseCycle("subscription", now.Add(-24*time.Hour), 20, 20)
+	// Active cycle started long ago is kept
+	s.CreateCycle("search", old, now.Add(time.Hour))
+	s.InsertSnapshot(&api.Snapshot{CapturedAt: old, Sub: api.QuotaInfo{Limit: 100}})
+
+	n, err := s.PruneCycles(now.Add(-365 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("PruneCycles: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("deleted %d cycles, want 1", n)
+	}
+	if cycles, _ := s.QueryCycleHistory("subscription"); len(cycles) != 1 {
+		t.Errorf("expected 1 subscription cycle left, got %d", len(cycles))
+	}
+	if active, _ := s.QueryActiveCycle("search"); active == nil {
+		t.Error("active cycle was pruned")
+	}
+	if snaps, _ := s.QueryRange(old.Add(-time.Minute), now); len(snaps) != 1 {
+		t.Errorf("cycle pruning must keep snapshots, got %d", len(snaps))
+	}
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// searchExcerptRadius is how many characters of raw JSON are kept on each
+// side of a search match.
+const searchExcerptRadius = 60
+
+// rawSnapshotTables maps each provider to the snapshot table holding its raw
+// API responses.
+var rawSnapshotTables = map[string]string{
+	"synthetic":   "quota_snapshots",
+	"zai":         "zai_snapshots",
+	"anthropic":   "anthropic_snapshots",
+	"copilot":     "copilot_snapshots",
+	"codex":       "codex_snapshots",
+	"antigravity": "antigravity_snapshots",
+}
+
+// RawSnapshotMatch is a snapshot whose raw API response matched a search.
+type RawSnapshotMatch struct {
+	Provider   string    `json:"provider"`
+	SnapshotID int64     `json:"snapshot_id"`
+	CapturedAt time.Time `json:"captured_at"`
+	Excerpt    string    `json:"excerpt"`
+}
+
+// IsSearchableProvider reports whether provider has raw snapshots to search.
+func IsSearchableProvider(provider string) bool {
+	_, ok := rawSnapshotTables[provider]
+	return ok
+}
+
+// SearchRawSnapshots returns snapshots whose stored raw JSON contains q
+// (case-insensitive for ASCII), newest first. An empty provider searches all
+// providers. Snapshots stored before raw JSON was kept never match.
+func (s *Store) SearchRawSnapshots(provider, q string, limit int) ([]RawSnapshotMatch, error) {
+	if q == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	providers := []string{provider}
+	if provider == "" {
+		providers = providers[:0]
+		for p := range rawSnapshotTables {
+			providers = append(providers, p)
+		}
+	}
+
+	pattern := "%" + escapeLike(q) + "%"
+	var out []RawSnapshotMatch
+	for _, p := range providers {
+		table, ok := rawSnapshotTables[p]
+		if !ok {
+			return nil, fmt.Errorf("store.SearchRawSnapshots: unknown provider %q", p)
+		}
+		rows, err := s.db.Query(fmt.Sprintf(
+			`SELECT id, captured_at, raw_json FROM %s
+			WHERE raw_json LIKE ? ESCAPE '\' ORDER BY captured_at DESC, id DESC LIMIT ?`, table),
+			pattern, limit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("store.SearchRawSnapshots: %s: %w", p, err)
+		}
+		for rows.Next() {
+			m := RawSnapshotMatch{Provider: p}
+			var capturedAt, raw string
+			if err := rows.Scan(&m.SnapshotID, &capturedAt, &raw); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("store.SearchRawSnapshots: scan: %w", err)
+			}
+			m.CapturedAt, _ = time.Parse(time.RFC3339Nano, capturedAt)
+			m.Excerpt = searchExcerpt(raw, q)
+			out = append(out, m)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("store.SearchRawSnapshots: %s: %w", p, err)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].CapturedAt.Equal(out[j].CapturedAt) {
+			return out[i].CapturedAt.After(out[j].CapturedAt)
+		}
+		return out[i].Provider < out[j].Provider
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// searchExcerpt returns the part of raw around the first match of q.
+func searchExcerpt(raw, q string) string {
+	idx := strings.Index(strings.ToLower(raw), strings.ToLower(q))
+	if idx < 0 {
+		return ""
+	}
+	end := min(idx+len(q)+searchExcerptRadius, len(raw))
+	start := min(max(idx-searchExcerptRadius, 0), end)
+	excerpt := strings.ToValidUTF8(raw[start:end], "")
+	if start > 0 {
+		excerpt = "…" + excerpt
+	}
+	if end < len(raw) {
+		excerpt += "…"
+	}
+	return excerpt
+}
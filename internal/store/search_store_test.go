@@ -0,0 +1,81 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/api"
+)
+
+func TestStore_SearchRawSnapshots(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := s.InsertAnthropicSnapshot(&api.AnthropicSnapshot{
+		CapturedAt: base,
+		Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: 10}},
+		RawJSON:    `{"five_hour":{"utilization":10},"extra_usage":{"is_enabled":false}}`,
+	}); err != nil {
+		t.Fatalf("InsertAnthropicSnapshot: %v", err)
+	}
+	if _, err := s.InsertAnthropicSnapshot(&api.AnthropicSnapshot{
+		CapturedAt: base.Add(time.Hour),
+		Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: 20}},
+		RawJSON:    `{"five_hour":{"utilization":20},"Extra_Usage":{"is_enabled":true}}`,
+	}); err != nil {
+		t.Fatalf("InsertAnthropicSnapshot: %v", err)
+	}
+	resp := api.QuotaResponse{Subscription: api.QuotaInfo{Limit: 100, Requests: 5, RenewsAt: base}}
+	if _, err := s.InsertSnapshot(resp.ToSnapshot(base.Add(30 * time.Minute))); err != nil {
+		t.Fatalf("InsertSnapshot: %v", err)
+	}
+
+	matches, err := s.SearchRawSnapshots("anthropic", "extra_usage", 0)
+	if err != nil {
+		t.Fatalf("SearchRawSnapshots: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(matches))
+	}
+	if !matches[0].CapturedAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("expected newest match first, got %v", matches[0].CapturedAt)
+	}
+	if !strings.Contains(matches[0].Excerpt, "Extra_Usage") {
+		t.Errorf("excerpt %q should contain the match", matches[0].Excerpt)
+	}
+
+	// Wildcards are matched literally
+	matches, err = s.SearchRawSnapshots("anthropic", "extra%usage", 0)
+	if err != nil {
+		t.Fatalf("SearchRawSnapshots: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("expected %% to match literally, got %d matches", len(matches))
+	}
+
+	// All providers, including Synthetic's persisted raw JSON
+	matches, err = s.SearchRawSnapshots("", "renewsAt", 0)
+	if err != nil {
+		t.Fatalf("SearchRawSnapshots: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Provider != "synthetic" {
+		t.Errorf("expected one synthetic match, got %+v", matches)
+	}
+
+	matches, err = s.SearchRawSnapshots("", "utilization", 1)
+	if err != nil {
+		t.Fatalf("SearchRawSnapshots: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("expected limit to cap matches, got %d", len(matches))
+	}
+
+	if _, err := s.SearchRawSnapshots("nope", "x", 0); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SessionUsageStats is the average and peak usage of sessions on one quota,
+// where a session's usage is its max minus its start value.
+type SessionUsageStats struct {
+	Avg  float64
+	Peak float64
+}
+
+// SessionStats aggregates the sessions started in a window.
+type SessionStats struct {
+	Sessions           int
+	AvgDurationSeconds float64 // active sessions count up to now
+	Sub                SessionUsageStats
+	Search             SessionUsageStats
+	Tool               SessionUsageStats
+	BusiestDay         string // YYYY-MM-DD (UTC) with the most sessions, "" without sessions
+	BusiestDaySessions int
+}
+
+// QuerySessionStats aggregates provider's sessions started since since in
+// SQL, without loading the rows. An empty provider covers all providers.
+func (s *Store) QuerySessionStats(provider string, since time.Time) (*SessionStats, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	where := `julianday(started_at) >= julianday(?)`
+	args := []interface{}{since.UTC().Format(time.RFC3339Nano)}
+	if provider != "" {
+		where += ` AND provider = ?`
+		args = append(args, provider)
+	}
+
+	stats := &SessionStats{}
+	err := s.db.QueryRow(
+		`SELECT COUNT(*),
+			COALESCE(AVG((julianday(COALESCE(ended_at, ?)) - julianday(started_at)) * 86400), 0),
+			COALESCE(AVG(MAX(0, max_sub_requests - start_sub_requests)), 0),
+			COALESCE(MAX(MAX(0, max_sub_requests - start_sub_requests)), 0),
+			COALESCE(AVG(MAX(0, max_search_requests - start_search_requests)), 0),
+			COALESCE(MAX(MAX(0, max_search_requests - start_search_requests)), 0),
+			COALESCE(AVG(MAX(0, max_tool_requests - start_tool_requests)), 0),
+			COALESCE(MAX(MAX(0, max_tool_requests - start_tool_requests)), 0)
+		FROM sessions WHERE `+where,
+		append([]interface{}{now}, args...)...,
+	).Scan(&stats.Sessions, &stats.AvgDurationSeconds,
+		&stats.Sub.Avg, &stats.Sub.Peak,
+		&stats.Search.Avg, &stats.Search.Peak,
+		&stats.Tool.Avg, &stats.Tool.Peak)
+	if err != nil {
+		return nil, fmt.Errorf("store.QuerySessionStats: %w", err)
+	}
+	if stats.Sessions == 0 {
+		return stats, nil
+	}
+
+	err = s.db.QueryRow(
+		`SELECT date(started_at) AS day, COUNT(*) FROM sessions WHERE `+where+`
+		GROUP BY day ORDER BY COUNT(*) DESC, day DESC LIMIT 1`,
+		args...,
+	).Scan(&stats.BusiestDay, &stats.BusiestDaySessions)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("store.QuerySessionStats: busiest day: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore_QuerySessionStats(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	day := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	sessions := []struct {
+		id       string
+		provider string
+		start    time.Time
+		length   time.Duration
+		start3   [3]float64
+		max3     [3]float64
+	}{
+		{"a", "synthetic", day, time.Hour, [3]float64{10, 0, 0}, [3]float64{30, 4, 0}},
+		{"b", "synthetic", day.Add(3 * time.Hour), 2 * time.Hour, [3]float64{30, 4, 0}, [3]float64{90, 4, 6}},
+		{"c", "synthetic", day.Add(24 * time.Hour), 3 * time.Hour, [3]float64{0, 0, 0}, [3]float64{10, 2, 0}},
+		{"d", "zai", day, time.Hour, [3]float64{0, 0, 0}, [3]float64{500, 0, 0}},
+		{"old", "synthetic", day.Add(-60 * 24 * time.Hour), time.Hour, [3]float64{0, 0, 0}, [3]float64{1000, 0, 0}},
+	}
+	for _, sess := range sessions {
+		if err := s.CreateSession(sess.id, sess.start, 60, sess.provider, sess.start3[0], sess.start3[1], sess.start3[2]); err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		if err := s.UpdateSessionMaxRequests(sess.id, sess.max3[0], sess.max3[1], sess.max3[2]); err != nil {
+			t.Fatalf("UpdateSessionMaxRequests: %v", err)
+		}
+		if err := s.CloseSession(sess.id, sess.start.Add(sess.length)); err != nil {
+			t.Fatalf("CloseSession: %v", err)
+		}
+	}
+
+	since := day.Add(-7 * 24 * time.Hour)
+	stats, err := s.QuerySessionStats("synthetic", since)
+	if err != nil {
+		t.Fatalf("QuerySessionStats: %v", err)
+	}
+	if stats.Sessions != 3 {
+		t.Errorf("Sessions = %d, want 3", stats.Sessions)
+	}
+	if d := stats.AvgDurationSeconds; d < 7199 || d > 7201 {
+		t.Errorf("AvgDurationSeconds = %.1f, want 7200", d)
+	}
+	if stats.Sub.Avg != 30 || stats.Sub.Peak != 60 {
+		t.Errorf("Sub = %+v, want avg 30 peak 60", stats.Sub)
+	}
+	if stats.Tool.Peak != 6 {
+		t.Errorf("Tool.Peak = %v, want 6", stats.Tool.Peak)
+	}
+	if stats.BusiestDay != "2026-03-10" || stats.BusiestDaySessions != 2 {
+		t.Errorf("BusiestDay = %s (%d), want 2026-03-10 (2)", stats.BusiestDay, stats.BusiestDaySessions)
+	}
+
+	all, err := s.QuerySessionStats("", since)
+	if err != nil {
+		t.Fatalf("QuerySessionStats all: %v", err)
+	}
+	if all.Sessions != 4 || all.BusiestDaySessions != 3 {
+		t.Errorf("all providers: %d sessions, busiest %d; want 4 and 3", all.Sessions, all.BusiestDaySessions)
+	}
+
+	empty, err := s.QuerySessionStats("codex", since)
+	if err != nil || empty.Sessions != 0 || empty.BusiestDay != "" {
+		t.Errorf("expected empty stats for codex, got %+v, %v", empty, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -54,6 +55,18 @@ type ResetCycle struct {
 	RenewsAt     time.Time
 	PeakRequests float64
 	TotalDelta   float64
+	Period       string // reset period classified by the tracker, e.g. "five_hour" or "weekly"; empty until known
+}
+
+// CycleWeek aggregates one calendar week (Monday 00:00 UTC) of reset cycles.
+type CycleWeek struct {
+	WeekStart time.Time
+	WeekEnd   time.Time
+	Cycles    int
+	Peak      float64        // highest per-cycle peak
+	Average   float64        // mean per-cycle peak
+	Total     float64        // sum of per-cycle usage deltas
+	Periods   map[string]int // cycle count per classified period
 }
 
 // CycleOverviewRow represents a single cycle with cross-quota data at peak time.
@@ -66,6 +79,7 @@ type CycleOverviewRow struct {
 	TotalDelta  float64
 	PeakTime    time.Time
 	CrossQuotas []CrossQuotaEntry
+	Stats       *CycleStats // average and median of the primary quota; nil for grouped rows
 }
 
 // CrossQuotaEntry holds a single quota's value at a given point in time.
@@ -133,7 +147,10 @@ func (s *Store) createTables() error {
 			search_renews_at TEXT NOT NULL,
 			tool_limit REAL NOT NULL,
 			tool_requests REAL NOT NULL,
-			tool_renews_at TEXT NOT NULL
+			tool_renews_at TEXT NOT NULL,
+			credits_balance REAL,
+			raw_json TEXT NOT NULL DEFAULT '',
+			injected INTEGER NOT NULL DEFAULT 0
 		);
 
 		CREATE TABLE IF NOT EXISTS reset_cycles (
@@ -185,6 +202,15 @@ func (s *Store) createTables() error {
 			updated_at TEXT NOT NULL
 		);
 
+		-- Single row naming the process that owns the database
+		CREATE TABLE IF NOT EXISTS instance_lock (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			pid INTEGER NOT NULL,
+			hostname TEXT NOT NULL,
+			started_at TEXT NOT NULL,
+			heartbeat_at TEXT NOT NULL
+		);
+
 		-- Z.ai-specific tables
 		CREATE TABLE IF NOT EXISTS zai_snapshots (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -205,7 +231,9 @@ func (s *Store) createTables() error {
 			tokens_current_value REAL NOT NULL,
 			tokens_remaining REAL NOT NULL,
 			tokens_percentage INTEGER NOT NULL,
-			tokens_next_reset TEXT
+			tokens_next_reset TEXT,
+			raw_json TEXT NOT NULL DEFAULT '',
+			injected INTEGER NOT NULL DEFAULT 0
 		);
 
 		CREATE TABLE IF NOT EXISTS zai_hourly_usage (
@@ -243,7 +271,8 @@ func (s *Store) createTables() error {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			captured_at TEXT NOT NULL,
 			raw_json TEXT NOT NULL DEFAULT '',
-			quota_count INTEGER NOT NULL DEFAULT 0
+			quota_count INTEGER NOT NULL DEFAULT 0,
+			injected INTEGER NOT NULL DEFAULT 0
 		);
 
 		CREATE TABLE IF NOT EXISTS anthropic_quota_values (
@@ -279,6 +308,9 @@ func (s *Store) createTables() error {
 			notification_type TEXT NOT NULL,
 			sent_at TEXT NOT NULL,
 			utilization REAL,
+			acknowledged_at TEXT,
+			escalated_at TEXT,
+			resolved_at TEXT,
 			UNIQUE(provider, quota_key, notification_type)
 		);
 
@@ -291,6 +323,26 @@ func (s *Store) createTables() error {
 			created_at TEXT NOT NULL
 		);
 
+		-- Provider poll outcomes (transitions + periodic samples) for availability tracking
+		CREATE TABLE IF NOT EXISTS poll_outcomes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			provider TEXT NOT NULL,
+			success INTEGER NOT NULL,
+			latency_ms INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			recorded_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_poll_outcomes_provider_time ON poll_outcomes(provider, recorded_at);
+
+		-- Snapshots injected through the debug API, so fake readings can be told apart
+		CREATE TABLE IF NOT EXISTS injected_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			provider TEXT NOT NULL,
+			captured_at TEXT NOT NULL,
+			payload TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS idx_injected_snapshots_provider_time ON injected_snapshots(provider, captured_at);
+
 		-- Copilot-specific tables
 		CREATE TABLE IF NOT EXISTS copilot_snapshots (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -432,6 +484,38 @@ func (s *Store) migrateSchema() error {
 		}
 	}
 
+	// Add credits_balance column to quota_snapshots if not exists
+	if _, err := s.db.Exec(`
+		ALTER TABLE quota_snapshots ADD COLUMN credits_balance REAL
+	`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add credits_balance to quota_snapshots: %w", err)
+		}
+	}
+
+	// Add raw_json column to quota_snapshots and zai_snapshots if not exists
+	for _, table := range []string{"quota_snapshots", "zai_snapshots"} {
+		if _, err := s.db.Exec(fmt.Sprintf(
+			`ALTER TABLE %s ADD COLUMN raw_json TEXT NOT NULL DEFAULT ''`, table,
+		)); err != nil {
+			if !strings.Contains(err.Error(), "duplicate column name") {
+				return fmt.Errorf("failed to add raw_json to %s: %w", table, err)
+			}
+		}
+	}
+
+	// Add injected column to snapshot tables if not exists; it flags debug
+	// readings fed through /api/debug/snapshot
+	for _, table := range []string{"quota_snapshots", "zai_snapshots", "anthropic_snapshots"} {
+		if _, err := s.db.Exec(fmt.Sprintf(
+			`ALTER TABLE %s ADD COLUMN injected INTEGER NOT NULL DEFAULT 0`, table,
+		)); err != nil {
+			if !strings.Contains(err.Error(), "duplicate column name") {
+				return fmt.Errorf("failed to add injected to %s: %w", table, err)
+			}
+		}
+	}
+
 	// Add provider column to reset_cycles if not exists
 	if _, err := s.db.Exec(`
 		ALTER TABLE reset_cycles ADD COLUMN provider TEXT NOT NULL DEFAULT 'synthetic'
@@ -441,6 +525,54 @@ func (s *Store) migrateSchema() error {
 		}
 	}
 
+	// Add period column to reset_cycles if not exists
+	if _, err := s.db.Exec(`
+		ALTER TABLE reset_cycles ADD COLUMN period TEXT NOT NULL DEFAULT ''
+	`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add period to reset_cycles: %w", err)
+		}
+	}
+
+	// Add notification_prefs column to users if not exists
+	if _, err := s.db.Exec(`
+		ALTER TABLE users ADD COLUMN notification_prefs TEXT NOT NULL DEFAULT ''
+	`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add notification_prefs to users: %w", err)
+		}
+	}
+
+	// Add role column to users if not exists. Existing rows, such as a stored
+	// password left behind by a renamed admin, become viewers; the configured
+	// admin is promoted by EnsureAdminUser on startup
+	if _, err := s.db.Exec(`
+		ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'viewer'
+	`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add role to users: %w", err)
+		}
+	}
+
+	// Add username column to auth_tokens if not exists; "" is the configured admin
+	if _, err := s.db.Exec(`
+		ALTER TABLE auth_tokens ADD COLUMN username TEXT NOT NULL DEFAULT ''
+	`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add username to auth_tokens: %w", err)
+		}
+	}
+
+	// Add created_at column to auth_tokens if not exists; "" for tokens
+	// issued before it was recorded
+	if _, err := s.db.Exec(`
+		ALTER TABLE auth_tokens ADD COLUMN created_at TEXT NOT NULL DEFAULT ''
+	`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add created_at to auth_tokens: %w", err)
+		}
+	}
+
 	// Add provider column to sessions if not exists
 	if _, err := s.db.Exec(`
 		ALTER TABLE sessions ADD COLUMN provider TEXT NOT NULL DEFAULT 'synthetic'
@@ -491,6 +623,17 @@ func (s *Store) migrateSchema() error {
 		return fmt.Errorf("failed to migrate notification_log provider scope: %w", err)
 	}
 
+	// Add acknowledgement/escalation/resolution tracking columns to notification_log
+	for _, col := range []string{"acknowledged_at", "escalated_at", "resolved_at"} {
+		if _, err := s.db.Exec(fmt.Sprintf(
+			`ALTER TABLE notification_log ADD COLUMN %s TEXT`, col,
+		)); err != nil {
+			if !strings.Contains(err.Error(), "duplicate column name") {
+				return fmt.Errorf("failed to add %s to notification_log: %w", col, err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -584,12 +727,13 @@ func (s *Store) InsertSnapshot(snapshot *api.Snapshot) (int64, error) {
 		`INSERT INTO quota_snapshots 
 		(captured_at, sub_limit, sub_requests, sub_renews_at, 
 		 search_limit, search_requests, search_renews_at,
-		 tool_limit, tool_requests, tool_renews_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		 tool_limit, tool_requests, tool_renews_at, credits_balance, raw_json, injected)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		snapshot.CapturedAt.Format(time.RFC3339Nano),
 		snapshot.Sub.Limit, snapshot.Sub.Requests, snapshot.Sub.RenewsAt.Format(time.RFC3339Nano),
 		snapshot.Search.Limit, snapshot.Search.Requests, snapshot.Search.RenewsAt.Format(time.RFC3339Nano),
 		snapshot.ToolCall.Limit, snapshot.ToolCall.Requests, snapshot.ToolCall.RenewsAt.Format(time.RFC3339Nano),
+		snapshot.CreditsBalance, snapshot.RawJSON, snapshot.Injected,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert snapshot: %w", err)
@@ -607,16 +751,17 @@ func (s *Store) InsertSnapshot(snapshot *api.Snapshot) (int64, error) {
 func (s *Store) QueryLatest() (*api.Snapshot, error) {
 	var snapshot api.Snapshot
 	var capturedAt, subRenewsAt, searchRenewsAt, toolRenewsAt string
+	var creditsBalance sql.NullFloat64
 
 	err := s.db.QueryRow(
 		`SELECT id, captured_at, sub_limit, sub_requests, sub_renews_at,
 		 search_limit, search_requests, search_renews_at,
-		 tool_limit, tool_requests, tool_renews_at
+		 tool_limit, tool_requests, tool_renews_at, credits_balance, injected
 		FROM quota_snapshots ORDER BY captured_at DESC LIMIT 1`,
 	).Scan(
 		&snapshot.ID, &capturedAt, &snapshot.Sub.Limit, &snapshot.Sub.Requests, &subRenewsAt,
 		&snapshot.Search.Limit, &snapshot.Search.Requests, &searchRenewsAt,
-		&snapshot.ToolCall.Limit, &snapshot.ToolCall.Requests, &toolRenewsAt,
+		&snapshot.ToolCall.Limit, &snapshot.ToolCall.Requests, &toolRenewsAt, &creditsBalance, &snapshot.Injected,
 	)
 
 	if err == sql.ErrNoRows {
@@ -630,16 +775,55 @@ func (s *Store) QueryLatest() (*api.Snapshot, error) {
 	snapshot.Sub.RenewsAt, _ = time.Parse(time.RFC3339Nano, subRenewsAt)
 	snapshot.Search.RenewsAt, _ = time.Parse(time.RFC3339Nano, searchRenewsAt)
 	snapshot.ToolCall.RenewsAt, _ = time.Parse(time.RFC3339Nano, toolRenewsAt)
+	if creditsBalance.Valid {
+		snapshot.CreditsBalance = &creditsBalance.Float64
+	}
 
 	return &snapshot, nil
 }
 
+// QueryCreditsSpent returns the Synthetic credits spent since since: the sum
+// of every drop between consecutive recorded balances, so top-ups in the
+// period do not hide what was spent. firstAt is when the first balance in the
+// period was recorded, zero if no snapshot in that period carried one.
+func (s *Store) QueryCreditsSpent(since time.Time) (spent float64, firstAt time.Time, err error) {
+	rows, err := s.db.Query(
+		`SELECT credits_balance, captured_at FROM quota_snapshots
+		WHERE captured_at >= ? AND credits_balance IS NOT NULL
+		ORDER BY captured_at ASC`,
+		since.Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to query credits balance: %w", err)
+	}
+	defer rows.Close()
+
+	var prev float64
+	for first := true; rows.Next(); first = false {
+		var balance float64
+		var capturedAt string
+		if err := rows.Scan(&balance, &capturedAt); err != nil {
+			return 0, time.Time{}, fmt.Errorf("failed to scan credits balance: %w", err)
+		}
+		if first {
+			firstAt, _ = time.Parse(time.RFC3339Nano, capturedAt)
+		} else if balance < prev {
+			spent += prev - balance
+		}
+		prev = balance
+	}
+	if err := rows.Err(); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to iterate credits balance: %w", err)
+	}
+	return spent, firstAt, nil
+}
+
 // QueryRange returns snapshots within a time range with optional limit.
 // Pass limit=0 for no limit.
 func (s *Store) QueryRange(start, end time.Time, limit ...int) ([]*api.Snapshot, error) {
 	query := `SELECT id, captured_at, sub_limit, sub_requests, sub_renews_at,
 		 search_limit, search_requests, search_renews_at,
-		 tool_limit, tool_requests, tool_renews_at
+		 tool_limit, tool_requests, tool_renews_at, injected
 		FROM quota_snapshots
 		WHERE captured_at BETWEEN ? AND ?
 		ORDER BY captured_at ASC`
@@ -647,11 +831,11 @@ func (s *Store) QueryRange(start, end time.Time, limit ...int) ([]*api.Snapshot,
 	if len(limit) > 0 && limit[0] > 0 {
 		query = `SELECT id, captured_at, sub_limit, sub_requests, sub_renews_at,
 			 search_limit, search_requests, search_renews_at,
-			 tool_limit, tool_requests, tool_renews_at
+			 tool_limit, tool_requests, tool_renews_at, injected
 			FROM (
 				SELECT id, captured_at, sub_limit, sub_requests, sub_renews_at,
 					search_limit, search_requests, search_renews_at,
-					tool_limit, tool_requests, tool_renews_at
+					tool_limit, tool_requests, tool_renews_at, injected
 				FROM quota_snapshots
 				WHERE captured_at BETWEEN ? AND ?
 				ORDER BY captured_at DESC
@@ -674,7 +858,7 @@ func (s *Store) QueryRange(start, end time.Time, limit ...int) ([]*api.Snapshot,
 		err := rows.Scan(
 			&snapshot.ID, &capturedAt, &snapshot.Sub.Limit, &snapshot.Sub.Requests, &subRenewsAt,
 			&snapshot.Search.Limit, &snapshot.Search.Requests, &searchRenewsAt,
-			&snapshot.ToolCall.Limit, &snapshot.ToolCall.Requests, &toolRenewsAt,
+			&snapshot.ToolCall.Limit, &snapshot.ToolCall.Requests, &toolRenewsAt, &snapshot.Injected,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
@@ -902,11 +1086,11 @@ func (s *Store) QueryActiveCycle(quotaType string) (*ResetCycle, error) {
 	var cycleStart, renewsAt string
 
 	err := s.db.QueryRow(
-		`SELECT id, quota_type, cycle_start, cycle_end, renews_at, peak_requests, total_delta
+		`SELECT id, quota_type, cycle_start, cycle_end, renews_at, peak_requests, total_delta, period
 		FROM reset_cycles WHERE quota_type = ? AND cycle_end IS NULL`,
 		quotaType,
 	).Scan(
-		&cycle.ID, &cycle.QuotaType, &cycleStart, &cycle.CycleEnd, &renewsAt, &cycle.PeakRequests, &cycle.TotalDelta,
+		&cycle.ID, &cycle.QuotaType, &cycleStart, &cycle.CycleEnd, &renewsAt, &cycle.PeakRequests, &cycle.TotalDelta, &cycle.Period,
 	)
 
 	if err == sql.ErrNoRows {
@@ -924,7 +1108,7 @@ func (s *Store) QueryActiveCycle(quotaType string) (*ResetCycle, error) {
 
 // QueryCycleHistory returns completed cycles for a quota type with optional limit.
 func (s *Store) QueryCycleHistory(quotaType string, limit ...int) ([]*ResetCycle, error) {
-	query := `SELECT id, quota_type, cycle_start, cycle_end, renews_at, peak_requests, total_delta
+	query := `SELECT id, quota_type, cycle_start, cycle_end, renews_at, peak_requests, total_delta, period
 		FROM reset_cycles WHERE quota_type = ? AND cycle_end IS NOT NULL ORDER BY cycle_start DESC`
 	args := []interface{}{quotaType}
 	if len(limit) > 0 && limit[0] > 0 {
@@ -943,7 +1127,7 @@ func (s *Store) QueryCycleHistory(quotaType string, limit ...int) ([]*ResetCycle
 		var cycleStart, cycleEnd, renewsAt string
 
 		err := rows.Scan(
-			&cycle.ID, &cycle.QuotaType, &cycleStart, &cycleEnd, &renewsAt, &cycle.PeakRequests, &cycle.TotalDelta,
+			&cycle.ID, &cycle.QuotaType, &cycleStart, &cycleEnd, &renewsAt, &cycle.PeakRequests, &cycle.TotalDelta, &cycle.Period,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan cycle: %w", err)
@@ -963,7 +1147,7 @@ func (s *Store) QueryCycleHistory(quotaType string, limit ...int) ([]*ResetCycle
 // QueryCyclesSince returns all cycles (completed and active) for a quota type since a given time
 func (s *Store) QueryCyclesSince(quotaType string, since time.Time) ([]*ResetCycle, error) {
 	rows, err := s.db.Query(
-		`SELECT id, quota_type, cycle_start, cycle_end, renews_at, peak_requests, total_delta
+		`SELECT id, quota_type, cycle_start, cycle_end, renews_at, peak_requests, total_delta, period
 		FROM reset_cycles WHERE quota_type = ? AND cycle_start >= ? ORDER BY cycle_start DESC`,
 		quotaType, since.Format(time.RFC3339Nano),
 	)
@@ -979,7 +1163,7 @@ func (s *Store) QueryCyclesSince(quotaType string, since time.Time) ([]*ResetCyc
 		var cycleEnd sql.NullString
 
 		err := rows.Scan(
-			&cycle.ID, &cycle.QuotaType, &cycleStart, &cycleEnd, &renewsAt, &cycle.PeakRequests, &cycle.TotalDelta,
+			&cycle.ID, &cycle.QuotaType, &cycleStart, &cycleEnd, &renewsAt, &cycle.PeakRequests, &cycle.TotalDelta, &cycle.Period,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan cycle: %w", err)
@@ -998,6 +1182,56 @@ func (s *Store) QueryCyclesSince(quotaType string, since time.Time) ([]*ResetCyc
 	return cycles, rows.Err()
 }
 
+// SetCyclePeriod records the reset period the tracker classified for a cycle.
+func (s *Store) SetCyclePeriod(id int64, period string) error {
+	if _, err := s.db.Exec(`UPDATE reset_cycles SET period = ? WHERE id = ?`, period, id); err != nil {
+		return fmt.Errorf("failed to set cycle period: %w", err)
+	}
+	return nil
+}
+
+// QuerySyntheticWeeklyCycles groups a quota type's cycles (completed and
+// active) into calendar weeks by cycle start, newest first, covering the last
+// weeks weeks including the current one. Weeks without cycles are left out.
+func (s *Store) QuerySyntheticWeeklyCycles(quotaType string, weeks int, now time.Time) ([]CycleWeek, error) {
+	if weeks <= 0 {
+		weeks = 12
+	}
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	thisWeek := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	since := thisWeek.AddDate(0, 0, -7*(weeks-1))
+
+	cycles, err := s.QueryCyclesSince(quotaType, since)
+	if err != nil {
+		return nil, fmt.Errorf("store.QuerySyntheticWeeklyCycles: %w", err)
+	}
+
+	var result []CycleWeek
+	for _, c := range cycles { // newest first
+		start := c.CycleStart.UTC()
+		weekStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		weekStart = weekStart.AddDate(0, 0, -((int(weekStart.Weekday()) + 6) % 7))
+		if len(result) == 0 || !result[len(result)-1].WeekStart.Equal(weekStart) {
+			result = append(result, CycleWeek{WeekStart: weekStart, WeekEnd: weekStart.AddDate(0, 0, 7), Periods: map[string]int{}})
+		}
+		w := &result[len(result)-1]
+		w.Cycles++
+		w.Peak = max(w.Peak, c.PeakRequests)
+		w.Average += c.PeakRequests
+		w.Total += c.TotalDelta
+		period := c.Period
+		if period == "" {
+			period = "unknown"
+		}
+		w.Periods[period]++
+	}
+	for i := range result {
+		result[i].Average /= float64(result[i].Cycles)
+	}
+	return result, nil
+}
+
 // QuerySyntheticCycleOverview returns cycles for a given quota type
 // with cross-quota snapshot data at the peak moment of each cycle.
 // Includes the currently active cycle (if any) at the top.
@@ -1034,6 +1268,11 @@ func (s *Store) QuerySyntheticCycleOverview(groupBy string, limit int) ([]CycleO
 			PeakValue:  c.PeakRequests,
 			TotalDelta: c.TotalDelta,
 		}
+		stats, err := s.QueryCycleStats("synthetic", c.QuotaType, c.CycleStart, c.CycleEnd)
+		if err != nil {
+			return nil, fmt.Errorf("store.QuerySyntheticCycleOverview: %w", err)
+		}
+		row.Stats = &stats
 
 		// Find the snapshot at peak time for the primary quota within this cycle
 		var peakCol string
@@ -1119,11 +1358,42 @@ func (s *Store) SetSetting(key, value string) error {
 	return nil
 }
 
-// SaveAuthToken persists a session token with its expiry.
+// GetSessionIdleTimeout returns the session idle timeout saved from the
+// dashboard, or 0 if none has been saved.
+func (s *Store) GetSessionIdleTimeout() (time.Duration, error) {
+	v, err := s.GetSetting("session_idle_timeout")
+	if err != nil {
+		return 0, fmt.Errorf("store.GetSessionIdleTimeout: %w", err)
+	}
+	if v == "" {
+		return 0, nil
+	}
+	minutes, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("store.GetSessionIdleTimeout: invalid value %q", v)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+// SetSessionIdleTimeout saves the session idle timeout in whole minutes.
+func (s *Store) SetSessionIdleTimeout(d time.Duration) error {
+	if err := s.SetSetting("session_idle_timeout", strconv.Itoa(int(d/time.Minute))); err != nil {
+		return fmt.Errorf("store.SetSessionIdleTimeout: %w", err)
+	}
+	return nil
+}
+
+// SaveAuthToken persists a session token of the configured admin with its expiry.
 func (s *Store) SaveAuthToken(token string, expiresAt time.Time) error {
+	return s.SaveUserAuthToken(token, "", expiresAt)
+}
+
+// SaveUserAuthToken persists a session token for a user with its expiry.
+// An empty username stands for the configured admin.
+func (s *Store) SaveUserAuthToken(token, username string, expiresAt time.Time) error {
 	_, err := s.db.Exec(
-		"INSERT OR REPLACE INTO auth_tokens (token, expires_at) VALUES (?, ?)",
-		token, expiresAt.Format(time.RFC3339Nano),
+		"INSERT OR REPLACE INTO auth_tokens (token, username, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		token, username, time.Now().UTC().Format(time.RFC3339Nano), expiresAt.Format(time.RFC3339Nano),
 	)
 	if err != nil {
 		return fmt.Errorf("store.SaveAuthToken: %w", err)
@@ -1133,16 +1403,47 @@ func (s *Store) SaveAuthToken(token string, expiresAt time.Time) error {
 
 // GetAuthTokenExpiry returns the expiry time for a token. Returns zero time and false if not found.
 func (s *Store) GetAuthTokenExpiry(token string) (time.Time, bool, error) {
-	var expiresAtStr string
-	err := s.db.QueryRow("SELECT expires_at FROM auth_tokens WHERE token = ?", token).Scan(&expiresAtStr)
+	_, t, found, err := s.GetAuthToken(token)
+	return t, found, err
+}
+
+// GetAuthToken returns the username ("" for the configured admin) and expiry
+// time of a token. Returns false if not found.
+func (s *Store) GetAuthToken(token string) (string, time.Time, bool, error) {
+	info, found, err := s.GetAuthTokenInfo(token)
+	return info.Username, info.ExpiresAt, found, err
+}
+
+// AuthTokenInfo describes a stored session token.
+type AuthTokenInfo struct {
+	Username  string    // "" for the configured admin
+	CreatedAt time.Time // zero for tokens issued before it was recorded
+	ExpiresAt time.Time
+}
+
+// GetAuthTokenInfo returns a stored session token. Returns false if not found.
+func (s *Store) GetAuthTokenInfo(token string) (AuthTokenInfo, bool, error) {
+	var info AuthTokenInfo
+	var createdAtStr, expiresAtStr string
+	err := s.db.QueryRow("SELECT username, created_at, expires_at FROM auth_tokens WHERE token = ?", token).Scan(&info.Username, &createdAtStr, &expiresAtStr)
 	if err == sql.ErrNoRows {
-		return time.Time{}, false, nil
+		return AuthTokenInfo{}, false, nil
 	}
 	if err != nil {
-		return time.Time{}, false, fmt.Errorf("store.GetAuthTokenExpiry: %w", err)
+		return AuthTokenInfo{}, false, fmt.Errorf("store.GetAuthTokenInfo: %w", err)
 	}
-	t, _ := time.Parse(time.RFC3339Nano, expiresAtStr)
-	return t, true, nil
+	info.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAtStr)
+	info.ExpiresAt, _ = time.Parse(time.RFC3339Nano, expiresAtStr)
+	return info, true, nil
+}
+
+// DeleteUserAuthTokens removes every session token of a user.
+func (s *Store) DeleteUserAuthTokens(username string) error {
+	_, err := s.db.Exec("DELETE FROM auth_tokens WHERE username = ?", username)
+	if err != nil {
+		return fmt.Errorf("store.DeleteUserAuthTokens: %w", err)
+	}
+	return nil
 }
 
 // DeleteAuthToken removes a session token.
@@ -1176,10 +1477,12 @@ func (s *Store) GetUser(username string) (string, error) {
 	return hash, nil
 }
 
-// UpsertUser inserts or updates a user's password hash.
+// UpsertUser inserts or updates a user's password hash, keeping the user's
+// other columns.
 func (s *Store) UpsertUser(username, passwordHash string) error {
 	_, err := s.db.Exec(
-		"INSERT OR REPLACE INTO users (username, password_hash, updated_at) VALUES (?, ?, ?)",
+		`INSERT INTO users (username, password_hash, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET password_hash = excluded.password_hash, updated_at = excluded.updated_at`,
 		username, passwordHash, time.Now().UTC().Format(time.RFC3339Nano),
 	)
 	if err != nil {
@@ -1188,6 +1491,166 @@ func (s *Store) UpsertUser(username, passwordHash string) error {
 	return nil
 }
 
+// EnsureAdminUser migrates the configured admin into the users table: the
+// user is added with the admin role if missing, or promoted back to admin,
+// keeping any stored password hash.
+func (s *Store) EnsureAdminUser(username, passwordHash string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO users (username, password_hash, role, updated_at) VALUES (?, ?, 'admin', ?)
+		ON CONFLICT(username) DO UPDATE SET role = 'admin'`,
+		username, passwordHash, time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return fmt.Errorf("store.EnsureAdminUser: %w", err)
+	}
+	return nil
+}
+
+// UserAccount is a dashboard user and their role.
+type UserAccount struct {
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// GetUserAccount returns a user with their role. Returns nil if not found.
+func (s *Store) GetUserAccount(username string) (*UserAccount, error) {
+	var u UserAccount
+	var updatedAt string
+	err := s.db.QueryRow(
+		"SELECT username, password_hash, role, updated_at FROM users WHERE username = ?", username,
+	).Scan(&u.Username, &u.PasswordHash, &u.Role, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store.GetUserAccount: %w", err)
+	}
+	u.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	return &u, nil
+}
+
+// ListUsers returns every user, sorted by username.
+func (s *Store) ListUsers() ([]UserAccount, error) {
+	rows, err := s.db.Query("SELECT username, password_hash, role, updated_at FROM users ORDER BY username")
+	if err != nil {
+		return nil, fmt.Errorf("store.ListUsers: %w", err)
+	}
+	defer rows.Close()
+
+	var users []UserAccount
+	for rows.Next() {
+		var u UserAccount
+		var updatedAt string
+		if err := rows.Scan(&u.Username, &u.PasswordHash, &u.Role, &updatedAt); err != nil {
+			return nil, fmt.Errorf("store.ListUsers: scan: %w", err)
+		}
+		u.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// CreateUser adds a user with a role. Returns false if the username is taken.
+func (s *Store) CreateUser(username, passwordHash, role string) (bool, error) {
+	res, err := s.db.Exec(
+		"INSERT OR IGNORE INTO users (username, password_hash, role, updated_at) VALUES (?, ?, ?, ?)",
+		username, passwordHash, role, time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return false, fmt.Errorf("store.CreateUser: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// SetUserRole changes a user's role. Returns false if the user does not exist.
+func (s *Store) SetUserRole(username, role string) (bool, error) {
+	res, err := s.db.Exec(
+		"UPDATE users SET role = ?, updated_at = ? WHERE username = ?",
+		role, time.Now().UTC().Format(time.RFC3339Nano), username,
+	)
+	if err != nil {
+		return false, fmt.Errorf("store.SetUserRole: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// DeleteUser removes a user and their session tokens. Returns false if the
+// user does not exist.
+func (s *Store) DeleteUser(username string) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("store.DeleteUser: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM users WHERE username = ?", username)
+	if err != nil {
+		return false, fmt.Errorf("store.DeleteUser: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM auth_tokens WHERE username = ?", username); err != nil {
+		return false, fmt.Errorf("store.DeleteUser: tokens: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("store.DeleteUser: commit: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetUserNotificationPrefs returns a user's notification preferences JSON.
+// Returns "" if the user has none or does not exist.
+func (s *Store) GetUserNotificationPrefs(username string) (string, error) {
+	var prefs string
+	err := s.db.QueryRow("SELECT notification_prefs FROM users WHERE username = ?", username).Scan(&prefs)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("store.GetUserNotificationPrefs: %w", err)
+	}
+	return prefs, nil
+}
+
+// SetUserNotificationPrefs stores a user's notification preferences JSON; ""
+// clears them. The user must exist.
+func (s *Store) SetUserNotificationPrefs(username, prefs string) error {
+	res, err := s.db.Exec(
+		"UPDATE users SET notification_prefs = ?, updated_at = ? WHERE username = ?",
+		prefs, time.Now().UTC().Format(time.RFC3339Nano), username,
+	)
+	if err != nil {
+		return fmt.Errorf("store.SetUserNotificationPrefs: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("store.SetUserNotificationPrefs: unknown user %q", username)
+	}
+	return nil
+}
+
+// ListUserNotificationPrefs returns the notification preferences JSON of every
+// user that has saved some, keyed by username.
+func (s *Store) ListUserNotificationPrefs() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT username, notification_prefs FROM users WHERE notification_prefs != ''")
+	if err != nil {
+		return nil, fmt.Errorf("store.ListUserNotificationPrefs: %w", err)
+	}
+	defer rows.Close()
+
+	prefs := make(map[string]string)
+	for rows.Next() {
+		var username, p string
+		if err := rows.Scan(&username, &p); err != nil {
+			return nil, fmt.Errorf("store.ListUserNotificationPrefs: scan: %w", err)
+		}
+		prefs[username] = p
+	}
+	return prefs, rows.Err()
+}
+
 // MigrateSessionsToUsageBased recomputes sessions from historical snapshot data
 // using usage-based idle detection. It deletes all existing sessions (which represent
 // agent runs, not actual usage) and creates new ones based on when API values changed.
@@ -1563,6 +2026,197 @@ func (s *Store) ClearNotificationLog(provider, quotaKey string) error {
 	return nil
 }
 
+// NotificationLogEntry is a sent alert with its acknowledgement state.
+type NotificationLogEntry struct {
+	ID             int64      `json:"id"`
+	Provider       string     `json:"provider"`
+	QuotaKey       string     `json:"quota_key"`
+	Type           string     `json:"type"`
+	SentAt         time.Time  `json:"sent_at"`
+	Utilization    float64    `json:"utilization"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	EscalatedAt    *time.Time `json:"escalated_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+const notificationLogColumns = `id, provider, quota_key, notification_type, sent_at, COALESCE(utilization, 0), acknowledged_at, escalated_at, resolved_at`
+
+func scanNotificationLogEntry(row interface{ Scan(...any) error }) (*NotificationLogEntry, error) {
+	var e NotificationLogEntry
+	var sentAt string
+	var ackAt, escAt, resAt sql.NullString
+	if err := row.Scan(&e.ID, &e.Provider, &e.QuotaKey, &e.Type, &sentAt, &e.Utilization, &ackAt, &escAt, &resAt); err != nil {
+		return nil, err
+	}
+	e.SentAt, _ = time.Parse(time.RFC3339Nano, sentAt)
+	e.AcknowledgedAt = parseNullTime(ackAt)
+	e.EscalatedAt = parseNullTime(escAt)
+	e.ResolvedAt = parseNullTime(resAt)
+	return &e, nil
+}
+
+func parseNullTime(v sql.NullString) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// GetNotificationLogEntry returns the log entry for a provider+quota+type pair, or nil if none exists.
+func (s *Store) GetNotificationLogEntry(provider, quotaKey, notifType string) (*NotificationLogEntry, error) {
+	if provider == "" {
+		provider = "legacy"
+	}
+	e, err := scanNotificationLogEntry(s.db.QueryRow(
+		`SELECT `+notificationLogColumns+` FROM notification_log
+		WHERE provider = ? AND quota_key = ? AND notification_type = ?`,
+		provider, quotaKey, notifType,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store.GetNotificationLogEntry: %w", err)
+	}
+	return e, nil
+}
+
+// QueryNotificationLog returns the most recent notification log entries, newest first.
+func (s *Store) QueryNotificationLog(limit int) ([]NotificationLogEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.Query(
+		`SELECT `+notificationLogColumns+` FROM notification_log ORDER BY sent_at DESC LIMIT ?`, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store.QueryNotificationLog: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []NotificationLogEntry
+	for rows.Next() {
+		e, err := scanNotificationLogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("store.QueryNotificationLog: scan: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+// AcknowledgeNotification marks a notification log entry as acknowledged.
+// Returns false if no entry with that ID exists.
+func (s *Store) AcknowledgeNotification(id int64) (bool, error) {
+	res, err := s.db.Exec(
+		`UPDATE notification_log SET acknowledged_at = COALESCE(acknowledged_at, ?) WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339Nano), id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("store.AcknowledgeNotification: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// AcknowledgeAllNotifications marks every unacknowledged notification log
+// entry as acknowledged and returns how many it marked.
+func (s *Store) AcknowledgeAllNotifications() (int64, error) {
+	res, err := s.db.Exec(
+		`UPDATE notification_log SET acknowledged_at = ? WHERE acknowledged_at IS NULL`,
+		time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("store.AcknowledgeAllNotifications: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// PruneNotificationLog deletes up to limit notification log entries sent
+// before before, oldest first, and returns how many it deleted. Open
+// warning/critical alerts are kept: their entries dedupe the alert for the
+// rest of the cycle.
+func (s *Store) PruneNotificationLog(before time.Time, limit int) (int64, error) {
+	res, err := s.db.Exec(
+		`DELETE FROM notification_log WHERE id IN (
+			SELECT id FROM notification_log
+			WHERE julianday(sent_at) < julianday(?)
+			AND NOT (notification_type IN ('warning', 'critical') AND resolved_at IS NULL)
+			ORDER BY sent_at LIMIT ?)`,
+		before.UTC().Format(time.RFC3339Nano), limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("store.PruneNotificationLog: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// GetOpenAlertLevel returns the most severe unresolved threshold alert ("critical"
+// or "warning") for a provider+quota, or "" if none is open.
+func (s *Store) GetOpenAlertLevel(provider, quotaKey string) (string, error) {
+	if provider == "" {
+		provider = "legacy"
+	}
+	rows, err := s.db.Query(
+		`SELECT notification_type FROM notification_log
+		WHERE provider = ? AND quota_key = ? AND resolved_at IS NULL
+		AND notification_type IN ('warning', 'critical')`,
+		provider, quotaKey,
+	)
+	if err != nil {
+		return "", fmt.Errorf("store.GetOpenAlertLevel: %w", err)
+	}
+	defer rows.Close()
+
+	level := ""
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return "", fmt.Errorf("store.GetOpenAlertLevel: scan: %w", err)
+		}
+		if t == "critical" || level == "" {
+			level = t
+		}
+	}
+	return level, rows.Err()
+}
+
+// ResolveNotificationLog closes the open warning/critical alerts for a provider+quota.
+// Entries are kept (so alerts stay deduped for the cycle) with resolved_at set.
+func (s *Store) ResolveNotificationLog(provider, quotaKey string) error {
+	if provider == "" {
+		provider = "legacy"
+	}
+	_, err := s.db.Exec(
+		`UPDATE notification_log SET resolved_at = ?
+		WHERE provider = ? AND quota_key = ? AND resolved_at IS NULL
+		AND notification_type IN ('warning', 'critical')`,
+		time.Now().UTC().Format(time.RFC3339Nano), provider, quotaKey,
+	)
+	if err != nil {
+		return fmt.Errorf("store.ResolveNotificationLog: %w", err)
+	}
+	return nil
+}
+
+// MarkNotificationEscalated records that a notification log entry was escalated.
+func (s *Store) MarkNotificationEscalated(id int64) error {
+	_, err := s.db.Exec(
+		`UPDATE notification_log SET escalated_at = ? WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339Nano), id,
+	)
+	if err != nil {
+		return fmt.Errorf("store.MarkNotificationEscalated: %w", err)
+	}
+	return nil
+}
+
 // SavePushSubscription stores a push notification subscription (upsert by endpoint).
 // Validates endpoint, p256dh, and auth before storing.
 func (s *Store) SavePushSubscription(endpoint, p256dh, auth string) error {
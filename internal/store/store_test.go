@@ -152,6 +152,49 @@ func TestStore_InsertSnapshot(t *testing.T) {
 	}
 }
 
+func TestStore_CreditsBalance(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	base := time.Now().UTC().Add(-5 * time.Hour)
+	// 100 -> 80 spends 20, the top-up to 150 is not spending, 150 -> 140
+	// spends 10.
+	balances := []*float64{nil}
+	for _, b := range []float64{100, 80, 150, 140} {
+		balances = append(balances, &b)
+	}
+	for i, balance := range balances {
+		snap := &api.Snapshot{CapturedAt: base.Add(time.Duration(i) * time.Hour), CreditsBalance: balance}
+		if _, err := s.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot failed: %v", err)
+		}
+	}
+
+	latest, err := s.QueryLatest()
+	if err != nil {
+		t.Fatalf("QueryLatest failed: %v", err)
+	}
+	if latest.CreditsBalance == nil || *latest.CreditsBalance != 140 {
+		t.Errorf("CreditsBalance = %v, want 140", latest.CreditsBalance)
+	}
+
+	spent, firstAt, err := s.QueryCreditsSpent(base.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("QueryCreditsSpent failed: %v", err)
+	}
+	if spent != 30 || !firstAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("spent = %v since %v, want 30 since %v", spent, firstAt, base.Add(time.Hour))
+	}
+
+	spent, firstAt, err = s.QueryCreditsSpent(time.Now().UTC().Add(time.Hour))
+	if err != nil || spent != 0 || !firstAt.IsZero() {
+		t.Errorf("expected nothing spent in an empty range, got %v since %v (err %v)", spent, firstAt, err)
+	}
+}
+
 func TestStore_QueryLatest_EmptyDB(t *testing.T) {
 	s, err := New(":memory:")
 	if err != nil {
@@ -926,6 +969,128 @@ func TestStore_NotificationLog_MigratesLegacySchema(t *testing.T) {
 	}
 }
 
+func TestStore_NotificationLog_AcknowledgeAndEscalate(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.UpsertNotificationLog("anthropic", "five_hour", "critical", 96.0); err != nil {
+		t.Fatalf("UpsertNotificationLog failed: %v", err)
+	}
+	entry, err := s.GetNotificationLogEntry("anthropic", "five_hour", "critical")
+	if err != nil || entry == nil {
+		t.Fatalf("GetNotificationLogEntry: entry=%v err=%v", entry, err)
+	}
+	if entry.AcknowledgedAt != nil || entry.EscalatedAt != nil {
+		t.Fatalf("new entry should be unacknowledged and unescalated: %+v", entry)
+	}
+
+	if err := s.MarkNotificationEscalated(entry.ID); err != nil {
+		t.Fatalf("MarkNotificationEscalated failed: %v", err)
+	}
+	found, err := s.AcknowledgeNotification(entry.ID)
+	if err != nil || !found {
+		t.Fatalf("AcknowledgeNotification: found=%v err=%v", found, err)
+	}
+
+	entries, err := s.QueryNotificationLog(10)
+	if err != nil {
+		t.Fatalf("QueryNotificationLog failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].AcknowledgedAt == nil || entries[0].EscalatedAt == nil {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	// A fresh alert for the same key starts unacknowledged again
+	if err := s.UpsertNotificationLog("anthropic", "five_hour", "critical", 97.0); err != nil {
+		t.Fatalf("UpsertNotificationLog failed: %v", err)
+	}
+	entry, _ = s.GetNotificationLogEntry("anthropic", "five_hour", "critical")
+	if entry.AcknowledgedAt != nil || entry.EscalatedAt != nil {
+		t.Errorf("re-sent entry should reset ack state: %+v", entry)
+	}
+
+	if found, _ := s.AcknowledgeNotification(9999); found {
+		t.Error("expected unknown ID to report not found")
+	}
+	if missing, _ := s.GetNotificationLogEntry("anthropic", "seven_day", "critical"); missing != nil {
+		t.Errorf("expected nil for missing entry, got %+v", missing)
+	}
+}
+
+func TestStore_AcknowledgeAllAndPruneNotifications(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	s.UpsertNotificationLog("anthropic", "five_hour", "critical", 96.0)
+	s.UpsertNotificationLog("anthropic", "seven_day", "warning", 82.0)
+	s.UpsertNotificationLog("synthetic", "subscription", "reset", 0)
+	s.ResolveNotificationLog("anthropic", "seven_day")
+
+	n, err := s.AcknowledgeAllNotifications()
+	if err != nil || n != 3 {
+		t.Fatalf("AcknowledgeAllNotifications: n=%d err=%v", n, err)
+	}
+	if n, _ := s.AcknowledgeAllNotifications(); n != 0 {
+		t.Errorf("expected nothing left to acknowledge, got %d", n)
+	}
+
+	future := time.Now().Add(time.Minute)
+	if n, _ := s.PruneNotificationLog(time.Now().Add(-time.Hour), 100); n != 0 {
+		t.Errorf("expected no entries older than an hour, got %d", n)
+	}
+	n, err = s.PruneNotificationLog(future, 1)
+	if err != nil || n != 1 {
+		t.Fatalf("PruneNotificationLog with limit 1: n=%d err=%v", n, err)
+	}
+	n, _ = s.PruneNotificationLog(future, 100)
+	if n != 1 {
+		t.Errorf("expected the remaining closed entry to be pruned, got %d", n)
+	}
+	entries, _ := s.QueryNotificationLog(10)
+	if len(entries) != 1 || entries[0].QuotaKey != "five_hour" {
+		t.Errorf("expected only the open critical alert to remain, got %+v", entries)
+	}
+}
+
+func TestStore_ResolveNotificationLog(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	s.UpsertNotificationLog("anthropic", "five_hour", "warning", 85)
+	if level, _ := s.GetOpenAlertLevel("anthropic", "five_hour"); level != "warning" {
+		t.Fatalf("open level = %q, want warning", level)
+	}
+	s.UpsertNotificationLog("anthropic", "five_hour", "critical", 96)
+	s.UpsertNotificationLog("anthropic", "five_hour", "reset", 0)
+	if level, _ := s.GetOpenAlertLevel("anthropic", "five_hour"); level != "critical" {
+		t.Fatalf("open level = %q, want critical", level)
+	}
+
+	if err := s.ResolveNotificationLog("anthropic", "five_hour"); err != nil {
+		t.Fatalf("ResolveNotificationLog failed: %v", err)
+	}
+	if level, _ := s.GetOpenAlertLevel("anthropic", "five_hour"); level != "" {
+		t.Errorf("open level = %q after resolve, want empty", level)
+	}
+	entry, _ := s.GetNotificationLogEntry("anthropic", "five_hour", "critical")
+	if entry == nil || entry.ResolvedAt == nil {
+		t.Errorf("expected resolved critical entry, got %+v", entry)
+	}
+	// Non-threshold entries are left alone
+	if reset, _ := s.GetNotificationLogEntry("anthropic", "five_hour", "reset"); reset == nil || reset.ResolvedAt != nil {
+		t.Errorf("reset entry should not be resolved, got %+v", reset)
+	}
+}
+
 func TestStore_QuerySyntheticCycleOverview_NoSnapshots(t *testing.T) {
 	s, err := New(":memory:")
 	if err != nil {
@@ -988,6 +1153,29 @@ func TestStore_SaveAuthToken_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestStore_GetAuthTokenInfo_RecordsCreation(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	before := time.Now().UTC().Add(-time.Second)
+	if err := s.SaveUserAuthToken("tok", "alice", before.Add(time.Hour)); err != nil {
+		t.Fatalf("SaveUserAuthToken failed: %v", err)
+	}
+	info, found, err := s.GetAuthTokenInfo("tok")
+	if err != nil || !found {
+		t.Fatalf("GetAuthTokenInfo = %v, %v", found, err)
+	}
+	if info.Username != "alice" {
+		t.Errorf("Username = %q, want alice", info.Username)
+	}
+	if info.CreatedAt.Before(before) || info.CreatedAt.After(time.Now().Add(time.Second)) {
+		t.Errorf("CreatedAt = %v, want about now", info.CreatedAt)
+	}
+}
+
 func TestStore_GetAuthTokenExpiry_NotFound(t *testing.T) {
 	s, err := New(":memory:")
 	if err != nil {
@@ -1165,6 +1353,118 @@ func TestStore_GetUser_NotFound(t *testing.T) {
 	}
 }
 
+func TestStore_UserAccounts(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	// A stored password hash alone grants no admin role
+	s.UpsertUser("admin", "hash")
+	if account, _ := s.GetUserAccount("admin"); account == nil || account.Role != "viewer" {
+		t.Fatalf("Expected a bare user row to be a viewer, got %+v", account)
+	}
+	s.EnsureAdminUser("admin", "hash")
+
+	// Migrating the configured admin adds them, or restores the admin role
+	// without touching the stored password
+	if err := s.EnsureAdminUser("root", "root-hash"); err != nil {
+		t.Fatalf("EnsureAdminUser failed: %v", err)
+	}
+	if account, _ := s.GetUserAccount("root"); account == nil || account.Role != "admin" || account.PasswordHash != "root-hash" {
+		t.Fatalf("Expected root to be migrated as admin, got %+v", account)
+	}
+	s.SetUserRole("root", "viewer")
+	s.EnsureAdminUser("root", "env-hash")
+	if account, _ := s.GetUserAccount("root"); account.Role != "admin" || account.PasswordHash != "root-hash" {
+		t.Errorf("Expected root promoted back to admin with its password kept, got %+v", account)
+	}
+	if _, err := s.DeleteUser("root"); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+
+	if created, err := s.CreateUser("alice", "h2", "viewer"); err != nil || !created {
+		t.Fatalf("CreateUser failed: created=%v err=%v", created, err)
+	}
+	if created, _ := s.CreateUser("alice", "h3", "admin"); created {
+		t.Error("Expected duplicate CreateUser to be refused")
+	}
+	if found, _ := s.SetUserRole("alice", "admin"); !found {
+		t.Error("Expected SetUserRole to find alice")
+	}
+	if found, _ := s.SetUserRole("nobody", "admin"); found {
+		t.Error("Expected SetUserRole to miss an unknown user")
+	}
+
+	// A password change keeps the role
+	s.UpsertUser("alice", "h4")
+	account, _ := s.GetUserAccount("alice")
+	if account.Role != "admin" || account.PasswordHash != "h4" {
+		t.Errorf("Unexpected account after password change: %+v", account)
+	}
+
+	users, err := s.ListUsers()
+	if err != nil || len(users) != 2 || users[0].Username != "admin" {
+		t.Fatalf("ListUsers = %+v, %v", users, err)
+	}
+
+	// Tokens carry the username and are removed with the user
+	expiry := time.Now().Add(time.Hour)
+	s.SaveUserAuthToken("tok-alice", "alice", expiry)
+	s.SaveAuthToken("tok-admin", expiry)
+	if username, _, found, _ := s.GetAuthToken("tok-alice"); !found || username != "alice" {
+		t.Errorf("GetAuthToken = %q, %v", username, found)
+	}
+	if found, _ := s.DeleteUser("alice"); !found {
+		t.Error("Expected DeleteUser to find alice")
+	}
+	if _, found, _ := s.GetAuthTokenExpiry("tok-alice"); found {
+		t.Error("Expected the deleted user's token to be removed")
+	}
+	if username, _, found, _ := s.GetAuthToken("tok-admin"); !found || username != "" {
+		t.Error("Expected the admin's token to remain")
+	}
+}
+
+func TestStore_UserNotificationPrefs(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.SetUserNotificationPrefs("ghost", `{}`); err == nil {
+		t.Error("Expected error for unknown user")
+	}
+
+	s.UpsertUser("admin", "hash")
+	s.UpsertUser("ops", "hash")
+	if err := s.SetUserNotificationPrefs("ops", `{"email":["ops@example.com"]}`); err != nil {
+		t.Fatalf("SetUserNotificationPrefs failed: %v", err)
+	}
+
+	// A password change keeps the preferences
+	if err := s.UpsertUser("ops", "new-hash"); err != nil {
+		t.Fatalf("UpsertUser failed: %v", err)
+	}
+	prefs, err := s.GetUserNotificationPrefs("ops")
+	if err != nil {
+		t.Fatalf("GetUserNotificationPrefs failed: %v", err)
+	}
+	if prefs != `{"email":["ops@example.com"]}` {
+		t.Errorf("prefs = %q after password change", prefs)
+	}
+
+	all, err := s.ListUserNotificationPrefs()
+	if err != nil {
+		t.Fatalf("ListUserNotificationPrefs failed: %v", err)
+	}
+	if len(all) != 1 || all["ops"] == "" {
+		t.Errorf("Expected only ops to have prefs, got %v", all)
+	}
+}
+
 // --- Session Tests ---
 
 func TestStore_CreateSession_WithStartValues(t *testing.T) {
@@ -1285,3 +1585,119 @@ func TestStore_QueryCyclesSince(t *testing.T) {
 		t.Error("Expected cycles in descending order by cycle_start")
 	}
 }
+
+func TestStore_QuerySyntheticWeeklyCycles(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	// Mondays: 2025-12-29, 2026-01-05, 2026-01-12
+	starts := []time.Time{
+		time.Date(2025, 12, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC),
+	}
+	peaks := []float64{900, 300, 100, 500}
+	for i, start := range starts {
+		id, err := s.CreateCycle("subscription", start, start.Add(5*time.Hour))
+		if err != nil {
+			t.Fatalf("CreateCycle %d failed: %v", i, err)
+		}
+		if i < 3 {
+			if err := s.SetCyclePeriod(id, "five_hour"); err != nil {
+				t.Fatalf("SetCyclePeriod failed: %v", err)
+			}
+			if err := s.CloseCycle("subscription", start.Add(5*time.Hour), peaks[i], peaks[i]/2); err != nil {
+				t.Fatalf("CloseCycle %d failed: %v", i, err)
+			}
+		} else if err := s.UpdateCycle("subscription", peaks[i], peaks[i]/2); err != nil {
+			t.Fatalf("UpdateCycle failed: %v", err)
+		}
+	}
+
+	now := time.Date(2026, 1, 14, 12, 0, 0, 0, time.UTC)
+	weeks, err := s.QuerySyntheticWeeklyCycles("subscription", 2, now)
+	if err != nil {
+		t.Fatalf("QuerySyntheticWeeklyCycles failed: %v", err)
+	}
+	if len(weeks) != 2 {
+		t.Fatalf("Expected 2 weeks, got %d", len(weeks))
+	}
+
+	current := weeks[0]
+	if !current.WeekStart.Equal(time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("WeekStart = %v, want 2026-01-12", current.WeekStart)
+	}
+	if current.Cycles != 2 || current.Peak != 500 || current.Average != 300 || current.Total != 300 {
+		t.Errorf("current week = %+v, want 2 cycles, peak 500, average 300, total 300", current)
+	}
+	if current.Periods["five_hour"] != 1 || current.Periods["unknown"] != 1 {
+		t.Errorf("Periods = %v, want one five_hour and one unknown", current.Periods)
+	}
+	if weeks[1].Cycles != 1 || weeks[1].Peak != 300 {
+		t.Errorf("previous week = %+v, want 1 cycle with peak 300", weeks[1])
+	}
+}
+
+func TestStore_QueryCycleStats(t *testing.T) {
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// A spike of 90 among lighter usage; the last snapshot belongs to the next cycle
+	for i, req := range []float64{10, 20, 90, 40, 500} {
+		snap := &api.Snapshot{
+			CapturedAt: base.Add(time.Duration(i) * time.Hour),
+			Sub:        api.QuotaInfo{Limit: 1000, Requests: req},
+			Search:     api.QuotaInfo{Limit: 250, Requests: float64(i)},
+		}
+		if _, err := s.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot failed: %v", err)
+		}
+	}
+
+	end := base.Add(4 * time.Hour)
+	stats, err := s.QueryCycleStats("synthetic", "subscription", base, &end)
+	if err != nil {
+		t.Fatalf("QueryCycleStats failed: %v", err)
+	}
+	if stats.Samples != 4 || stats.Avg != 40 || stats.Median != 30 {
+		t.Errorf("Expected 4 samples, avg 40, median 30, got %+v", stats)
+	}
+
+	stats, err = s.QueryCycleStats("synthetic", "search", base, &end)
+	if err != nil {
+		t.Fatalf("QueryCycleStats failed: %v", err)
+	}
+	if stats.Avg != 1.5 || stats.Median != 1.5 {
+		t.Errorf("Expected search avg and median 1.5, got %+v", stats)
+	}
+
+	// Active cycle runs to now
+	stats, err = s.QueryCycleStats("synthetic", "subscription", end, nil)
+	if err != nil {
+		t.Fatalf("QueryCycleStats failed: %v", err)
+	}
+	if stats.Samples != 1 || stats.Median != 500 {
+		t.Errorf("Expected the active cycle's single snapshot, got %+v", stats)
+	}
+
+	empty := base.Add(-time.Hour)
+	stats, err = s.QueryCycleStats("synthetic", "subscription", empty.Add(-time.Hour), &empty)
+	if err != nil {
+		t.Fatalf("QueryCycleStats failed: %v", err)
+	}
+	if stats.Samples != 0 {
+		t.Errorf("Expected no samples before the first snapshot, got %+v", stats)
+	}
+
+	if _, err := s.QueryCycleStats("unknown", "subscription", base, &end); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
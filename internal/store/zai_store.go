@@ -45,8 +45,8 @@ func (s *Store) InsertZaiSnapshot(snapshot *api.ZaiSnapshot) (int64, error) {
 		(provider, captured_at, time_limit, time_unit, time_number, time_usage,
 		 time_current_value, time_remaining, time_percentage, time_usage_details,
 		 tokens_limit, tokens_unit, tokens_number, tokens_usage,
-		 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset, raw_json, injected)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		"zai",
 		snapshot.CapturedAt.Format(time.RFC3339Nano),
 		snapshot.TimeLimit, snapshot.TimeUnit, snapshot.TimeNumber,
@@ -54,7 +54,7 @@ func (s *Store) InsertZaiSnapshot(snapshot *api.ZaiSnapshot) (int64, error) {
 		snapshot.TimeUsageDetails,
 		snapshot.TokensLimit, snapshot.TokensUnit, snapshot.TokensNumber,
 		snapshot.TokensUsage, snapshot.TokensCurrentValue, snapshot.TokensRemaining, snapshot.TokensPercentage,
-		tokensNextReset,
+		tokensNextReset, snapshot.RawJSON, snapshot.Injected,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert zai snapshot: %w", err)
@@ -78,7 +78,7 @@ func (s *Store) QueryLatestZai() (*api.ZaiSnapshot, error) {
 		`SELECT id, captured_at, time_limit, time_unit, time_number, time_usage,
 		 time_current_value, time_remaining, time_percentage, time_usage_details,
 		 tokens_limit, tokens_unit, tokens_number, tokens_usage,
-		 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset
+		 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset, injected
 		FROM zai_snapshots ORDER BY captured_at DESC LIMIT 1`,
 	).Scan(
 		&snapshot.ID, &capturedAt, &snapshot.TimeLimit, &snapshot.TimeUnit, &snapshot.TimeNumber,
@@ -86,7 +86,7 @@ func (s *Store) QueryLatestZai() (*api.ZaiSnapshot, error) {
 		&snapshot.TimeUsageDetails,
 		&snapshot.TokensLimit, &snapshot.TokensUnit, &snapshot.TokensNumber,
 		&snapshot.TokensUsage, &snapshot.TokensCurrentValue, &snapshot.TokensRemaining, &snapshot.TokensPercentage,
-		&tokensNextReset,
+		&tokensNextReset, &snapshot.Injected,
 	)
 
 	if err == sql.ErrNoRows {
@@ -110,7 +110,7 @@ func (s *Store) QueryZaiRange(start, end time.Time, limit ...int) ([]*api.ZaiSna
 	query := `SELECT id, captured_at, time_limit, time_unit, time_number, time_usage,
 		 time_current_value, time_remaining, time_percentage, time_usage_details,
 		 tokens_limit, tokens_unit, tokens_number, tokens_usage,
-		 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset
+		 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset, injected
 		FROM zai_snapshots
 		WHERE captured_at BETWEEN ? AND ?
 		ORDER BY captured_at ASC`
@@ -119,12 +119,12 @@ func (s *Store) QueryZaiRange(start, end time.Time, limit ...int) ([]*api.ZaiSna
 		query = `SELECT id, captured_at, time_limit, time_unit, time_number, time_usage,
 			 time_current_value, time_remaining, time_percentage, time_usage_details,
 			 tokens_limit, tokens_unit, tokens_number, tokens_usage,
-			 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset
+			 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset, injected
 			FROM (
 				SELECT id, captured_at, time_limit, time_unit, time_number, time_usage,
 					 time_current_value, time_remaining, time_percentage, time_usage_details,
 					 tokens_limit, tokens_unit, tokens_number, tokens_usage,
-					 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset
+					 tokens_current_value, tokens_remaining, tokens_percentage, tokens_next_reset, injected
 				FROM zai_snapshots
 				WHERE captured_at BETWEEN ? AND ?
 				ORDER BY captured_at DESC
@@ -151,7 +151,7 @@ func (s *Store) QueryZaiRange(start, end time.Time, limit ...int) ([]*api.ZaiSna
 			&snapshot.TimeUsageDetails,
 			&snapshot.TokensLimit, &snapshot.TokensUnit, &snapshot.TokensNumber,
 			&snapshot.TokensUsage, &snapshot.TokensCurrentValue, &snapshot.TokensRemaining, &snapshot.TokensPercentage,
-			&tokensNextReset,
+			&tokensNextReset, &snapshot.Injected,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan zai snapshot: %w", err)
@@ -406,6 +406,11 @@ func (s *Store) QueryZaiCycleOverview(groupBy string, limit int) ([]CycleOvervie
 			PeakValue:  float64(c.PeakValue),
 			TotalDelta: float64(c.TotalDelta),
 		}
+		stats, err := s.QueryCycleStats("zai", c.QuotaType, c.CycleStart, c.CycleEnd)
+		if err != nil {
+			return nil, fmt.Errorf("store.QueryZaiCycleOverview: %w", err)
+		}
+		row.Stats = &stats
 
 		var peakCol string
 		switch groupBy {
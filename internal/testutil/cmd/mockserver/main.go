@@ -11,6 +11,19 @@
 //	--syn-key     Expected Synthetic API key (default: syn_test_e2e_key)
 //	--zai-key     Expected Z.ai API key (default: zai_test_e2e_key)
 //	--anth-token  Expected Anthropic OAuth token (default: anth_test_e2e_token)
+//	--record      Proxy provider requests to the real APIs and save the
+//	              responses to this file
+//	--replay      Serve the responses saved by --record, in order (looping,
+//	              like /admin/scenario)
+//	--upstream-synthetic, --upstream-zai, --upstream-anthropic
+//	              Real endpoints --record proxies to
+//
+// A recording is a JSON array of /admin/scenario payloads
+// ([{"provider":"zai","responses":[...]}, ...]). To capture a payload that
+// triggers a parsing bug, point onWatch's base URLs at the mock server
+// started with --record and your real credentials, then replay the file
+// with --replay (or POST its entries to /admin/scenario). Recordings contain
+// real account data; keep them out of the repository.
 package main
 
 import (
@@ -38,9 +51,39 @@ func main() {
 	synKey := flag.String("syn-key", "syn_test_e2e_key", "Expected Synthetic API key")
 	zaiKey := flag.String("zai-key", "zai_test_e2e_key", "Expected Z.ai API key")
 	anthToken := flag.String("anth-token", "anth_test_e2e_token", "Expected Anthropic OAuth token")
+	recordPath := flag.String("record", "", "Proxy to the real provider APIs and save responses to this file")
+	replayPath := flag.String("replay", "", "Serve the responses in this recording, in order")
+	upstreams := map[string]*string{}
+	for _, p := range recordedProviders {
+		upstreams[p] = flag.String("upstream-"+p, defaultUpstreams[p], "Real "+p+" endpoint for --record")
+	}
 	flag.Parse()
 
+	if *recordPath != "" && *replayPath != "" {
+		log.Fatal("--record and --replay are mutually exclusive")
+	}
+
 	srv := newStandaloneServer(*synKey, *zaiKey, *anthToken)
+	writeTimeout := 5 * time.Second
+	if *recordPath != "" {
+		urls := map[string]string{}
+		for p, u := range upstreams {
+			urls[p] = *u
+		}
+		srv.recorder = newRecorder(*recordPath, urls)
+		writeTimeout = 35 * time.Second // upstream requests may be slow
+	}
+	if *replayPath != "" {
+		scenarios, err := loadRecording(*replayPath)
+		if err != nil {
+			log.Fatalf("failed to load recording: %v", err)
+		}
+		for _, sc := range scenarios {
+			if err := srv.setScenario(sc.Provider, sc.Responses); err != nil {
+				log.Fatalf("failed to load recording: %v", err)
+			}
+		}
+	}
 
 	addr := fmt.Sprintf(":%d", *port)
 	ln, err := net.Listen("tcp", addr)
@@ -51,7 +94,7 @@ func main() {
 	httpSrv := &http.Server{
 		Handler:      srv.mux,
 		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
+		WriteTimeout: writeTimeout,
 	}
 
 	go func() {
@@ -59,6 +102,12 @@ func main() {
 		log.Printf("  Synthetic key: %s", *synKey)
 		log.Printf("  Z.ai key:      %s", *zaiKey)
 		log.Printf("  Anthropic tok: %s", *anthToken)
+		if *recordPath != "" {
+			log.Printf("  Recording real responses to %s", *recordPath)
+		}
+		if *replayPath != "" {
+			log.Printf("  Replaying %s", *replayPath)
+		}
 		if err := httpSrv.Serve(ln); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("server error: %v", err)
 		}
@@ -99,6 +148,9 @@ type standaloneServer struct {
 	anthropicError     atomic.Int32
 	anthropicIdx       atomic.Int64
 	anthropicCount     atomic.Int64
+
+	// Set by --record: provider requests go to the real APIs
+	recorder *recorder
 }
 
 func newStandaloneServer(synKey, zaiKey, anthToken string) *standaloneServer {
@@ -126,6 +178,11 @@ func newStandaloneServer(synKey, zaiKey, anthToken string) *standaloneServer {
 func (s *standaloneServer) handleSynthetic(w http.ResponseWriter, r *http.Request) {
 	s.syntheticCount.Add(1)
 
+	if s.recorder != nil {
+		s.recorder.proxy("synthetic", w, r)
+		return
+	}
+
 	if errCode := s.syntheticError.Load(); errCode > 0 {
 		w.WriteHeader(int(errCode))
 		fmt.Fprintf(w, `{"error": "injected error %d"}`, errCode)
@@ -156,6 +213,11 @@ func (s *standaloneServer) handleSynthetic(w http.ResponseWriter, r *http.Reques
 func (s *standaloneServer) handleZai(w http.ResponseWriter, r *http.Request) {
 	s.zaiCount.Add(1)
 
+	if s.recorder != nil {
+		s.recorder.proxy("zai", w, r)
+		return
+	}
+
 	if errCode := s.zaiError.Load(); errCode > 0 {
 		w.WriteHeader(int(errCode))
 		fmt.Fprintf(w, `{"error": "injected error %d"}`, errCode)
@@ -186,6 +248,11 @@ func (s *standaloneServer) handleZai(w http.ResponseWriter, r *http.Request) {
 func (s *standaloneServer) handleAnthropic(w http.ResponseWriter, r *http.Request) {
 	s.anthropicCount.Add(1)
 
+	if s.recorder != nil {
+		s.recorder.proxy("anthropic", w, r)
+		return
+	}
+
 	if errCode := s.anthropicError.Load(); errCode > 0 {
 		w.WriteHeader(int(errCode))
 		fmt.Fprintf(w, `{"error": "injected error %d"}`, errCode)
@@ -235,27 +302,40 @@ func (s *standaloneServer) handleAdminScenario(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if err := s.setScenario(payload.Provider, payload.Responses); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"error": %q}`, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"ok": true}`)
+}
+
+// setScenario replaces a provider's response sequence, served from the
+// first response again.
+func (s *standaloneServer) setScenario(provider string, responses []string) error {
+	if len(responses) == 0 {
+		return fmt.Errorf("no responses for provider: %s", provider)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	switch strings.ToLower(payload.Provider) {
+	switch strings.ToLower(provider) {
 	case "synthetic":
-		s.syntheticResponses = payload.Responses
+		s.syntheticResponses = responses
 		s.syntheticIdx.Store(0)
 	case "zai":
-		s.zaiResponses = payload.Responses
+		s.zaiResponses = responses
 		s.zaiIdx.Store(0)
 	case "anthropic":
-		s.anthropicResponses = payload.Responses
+		s.anthropicResponses = responses
 		s.anthropicIdx.Store(0)
 	default:
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error": "unknown provider: %s"}`, payload.Provider)
-		return
+		return fmt.Errorf("unknown provider: %s", provider)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprint(w, `{"ok": true}`)
+	return nil
 }
 
 func (s *standaloneServer) handleAdminError(w http.ResponseWriter, r *http.Request) {
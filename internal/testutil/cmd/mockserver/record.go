@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultUpstreams are the real provider endpoints --record proxies to.
+var defaultUpstreams = map[string]string{
+	"synthetic": "https://api.synthetic.new/v2/quotas",
+	"zai":       "https://api.z.ai/api/monitor/usage/quota/limit",
+	"anthropic": "https://api.anthropic.com/api/oauth/usage",
+}
+
+// recordedProviders fixes the order providers are written in a recording.
+var recordedProviders = []string{"synthetic", "zai", "anthropic"}
+
+// scenario is one provider's response list, as POSTed to /admin/scenario.
+// A recording is a JSON array of them.
+type scenario struct {
+	Provider  string   `json:"provider"`
+	Responses []string `json:"responses"`
+}
+
+// recorder proxies provider requests to the real APIs and saves each
+// successful response body to a recording file.
+type recorder struct {
+	path      string
+	upstreams map[string]string
+	client    *http.Client
+
+	mu        sync.Mutex
+	responses map[string][]string
+}
+
+func newRecorder(path string, upstreams map[string]string) *recorder {
+	return &recorder{
+		path:      path,
+		upstreams: upstreams,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		responses: map[string][]string{},
+	}
+}
+
+// proxy forwards r, with its credentials, to provider's real endpoint and
+// relays the response. 200 responses are appended to the recording, which is
+// rewritten each time so an interrupted session keeps what it captured.
+func (rec *recorder) proxy(provider string, w http.ResponseWriter, r *http.Request) {
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, rec.upstreams[provider], nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	req.URL.RawQuery = r.URL.RawQuery
+	for name, values := range r.Header {
+		if name == "Accept-Encoding" {
+			continue // let the client negotiate and decompress
+		}
+		req.Header[name] = values
+	}
+
+	resp, err := rec.client.Do(req)
+	if err != nil {
+		log.Printf("record %s: %v", provider, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("record %s: %v", provider, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		if err := rec.add(provider, string(body)); err != nil {
+			log.Printf("record %s: saving %s: %v", provider, rec.path, err)
+		} else {
+			log.Printf("recorded %s response (%d bytes)", provider, len(body))
+		}
+	} else {
+		log.Printf("record %s: upstream returned HTTP %d, not recorded", provider, resp.StatusCode)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body)
+}
+
+// add appends a response to the recording and saves it.
+func (rec *recorder) add(provider, body string) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.responses[provider] = append(rec.responses[provider], body)
+
+	var scenarios []scenario
+	for _, p := range recordedProviders {
+		if len(rec.responses[p]) > 0 {
+			scenarios = append(scenarios, scenario{Provider: p, Responses: rec.responses[p]})
+		}
+	}
+	data, err := json.MarshalIndent(scenarios, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(rec.path), ".recording.*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), rec.path)
+}
+
+// loadRecording reads a recording written by --record.
+func loadRecording(path string) ([]scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var scenarios []scenario
+	if err := json.Unmarshal(data, &scenarios); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return scenarios, nil
+}
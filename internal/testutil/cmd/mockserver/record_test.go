@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	bodies := []string{`{"limits":[{"type":"TOKENS_LIMIT","percentage":10}]}`, `{"limits":[{"type":"TOKENS_LIMIT","percentage":20}]}`}
+	var calls int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "real-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(bodies[calls%len(bodies)]))
+		calls++
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "zai.json")
+	rec := newStandaloneServer("", "zai_test_e2e_key", "")
+	rec.recorder = newRecorder(path, map[string]string{"zai": upstream.URL})
+	get := func(srv *standaloneServer, key string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/monitor/usage/quota/limit", nil)
+		req.Header.Set("Authorization", key)
+		rr := httptest.NewRecorder()
+		srv.mux.ServeHTTP(rr, req)
+		body, _ := io.ReadAll(rr.Body)
+		return rr.Code, string(body)
+	}
+
+	// Proxied with the caller's credentials, and only 200s are kept
+	for i := range bodies {
+		if code, body := get(rec, "real-key"); code != http.StatusOK || body != bodies[i] {
+			t.Fatalf("recorded request %d = %d %q, want %q", i, code, body, bodies[i])
+		}
+	}
+	if code, _ := get(rec, "wrong-key"); code != http.StatusUnauthorized {
+		t.Errorf("upstream error status = %d, want 401", code)
+	}
+
+	scenarios, err := loadRecording(path)
+	if err != nil {
+		t.Fatalf("loadRecording: %v", err)
+	}
+	if len(scenarios) != 1 || scenarios[0].Provider != "zai" || len(scenarios[0].Responses) != 2 {
+		t.Fatalf("recording = %+v, want two zai responses", scenarios)
+	}
+
+	replay := newStandaloneServer("", "", "")
+	for _, sc := range scenarios {
+		if err := replay.setScenario(sc.Provider, sc.Responses); err != nil {
+			t.Fatalf("setScenario: %v", err)
+		}
+	}
+	for i := range bodies {
+		if _, body := get(replay, ""); body != bodies[i] {
+			t.Errorf("replayed response %d = %q, want %q", i, body, bodies[i])
+		}
+	}
+}
+
+func TestSetScenario_Invalid(t *testing.T) {
+	srv := newStandaloneServer("", "", "")
+	if err := srv.setScenario("copilot", []string{"{}"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+	if err := srv.setScenario("zai", nil); err == nil {
+		t.Error("expected an error for an empty response list")
+	}
+}
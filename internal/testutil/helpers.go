@@ -116,13 +116,15 @@ func InMemoryStoreWithSnapshots(t *testing.T, synCount, zaiCount, anthCount int,
 }
 
 // TestConfig creates a Config suitable for testing.
-// The baseURL is used to set the Synthetic API base URL, Z.ai base URL, etc.
+// The baseURL is used to set the Synthetic, Z.ai and Anthropic base URLs.
 func TestConfig(baseURL string) *config.Config {
 	return &config.Config{
 		SyntheticAPIKey:    "syn_test_key_12345",
+		SyntheticBaseURL:   baseURL,
 		ZaiAPIKey:          "zai_test_key",
 		ZaiBaseURL:         baseURL,
 		AnthropicToken:     "anth_test_token",
+		AnthropicBaseURL:   baseURL,
 		CopilotToken:       "ghp_test_token",
 		PollInterval:       10 * time.Second,
 		Port:               9211,
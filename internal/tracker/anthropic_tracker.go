@@ -18,6 +18,7 @@ type AnthropicTracker struct {
 	lastValues map[string]float64 // quota_name -> last utilization %
 	lastResets map[string]string  // quota_name -> last resets_at string
 	hasLast    bool
+	lastSeen   time.Time
 
 	onReset func(quotaName string) // called when a quota reset is detected
 }
@@ -29,17 +30,19 @@ func (t *AnthropicTracker) SetOnReset(fn func(string)) {
 
 // AnthropicSummary contains computed usage statistics for an Anthropic quota.
 type AnthropicSummary struct {
-	QuotaName       string
-	CurrentUtil     float64
-	ResetsAt        *time.Time
-	TimeUntilReset  time.Duration
-	CurrentRate     float64 // utilization % per hour
-	ProjectedUtil   float64
-	CompletedCycles int
-	AvgPerCycle     float64
-	PeakCycle       float64
-	TotalTracked    float64
-	TrackingSince   time.Time
+	QuotaName            string
+	CurrentUtil          float64
+	ResetsAt             *time.Time
+	TimeUntilReset       time.Duration
+	CurrentRate          float64 // utilization % per hour
+	ProjectedUtil        float64
+	ProjectionConfidence float64 // 0-1, how far ProjectedUtil can be trusted
+	CompletedCycles      int
+	AvgPerCycle          float64
+	PeakCycle            float64
+	TotalTracked         float64
+	TrackingSince        time.Time
+	Pace                 *CyclePace // nil until a cycle has completed
 }
 
 // NewAnthropicTracker creates a new AnthropicTracker.
@@ -57,6 +60,11 @@ func NewAnthropicTracker(store *store.Store, logger *slog.Logger) *AnthropicTrac
 
 // Process iterates over all quotas in the snapshot, detects resets, and updates cycles.
 func (t *AnthropicTracker) Process(snapshot *api.AnthropicSnapshot) error {
+	if outOfOrder(&t.lastSeen, snapshot.CapturedAt) {
+		t.logger.Warn("Ignoring out-of-order snapshot", "capturedAt", snapshot.CapturedAt, "lastSeen", t.lastSeen)
+		return nil
+	}
+
 	for _, quota := range snapshot.Quotas {
 		if err := t.processQuota(quota, snapshot.CapturedAt); err != nil {
 			return fmt.Errorf("anthropic tracker: %s: %w", quota.Name, err)
@@ -133,6 +141,20 @@ func (t *AnthropicTracker) processQuota(quota api.AnthropicQuota, capturedAt tim
 		}
 	}
 
+	// Only trust a timestamp-based reset when utilization actually dropped
+	if resetDetected {
+		lastUtil, ok := t.lastValues[quotaName]
+		if !resetConfirmed(t.hasLast && ok, lastUtil, currentUtil) {
+			t.logger.Debug("Ignoring Anthropic reset signal without usage drop",
+				"quota", quotaName,
+				"reason", resetReason,
+				"lastUtil", lastUtil,
+				"currentUtil", currentUtil,
+			)
+			resetDetected = false
+		}
+	}
+
 	if resetDetected {
 		// Determine the actual cycle end time:
 		// - If we have a stored ResetsAt and it's in the past, use it as cycle end
@@ -242,6 +264,7 @@ func (t *AnthropicTracker) UsageSummary(quotaName string) (*AnthropicSummary, er
 		CompletedCycles: len(history),
 	}
 
+	cycleTotals := make([]float64, 0, len(history))
 	// Calculate stats from completed cycles
 	if len(history) > 0 {
 		var totalDelta float64
@@ -249,6 +272,7 @@ func (t *AnthropicTracker) UsageSummary(quotaName string) (*AnthropicSummary, er
 
 		for _, cycle := range history {
 			totalDelta += cycle.TotalDelta
+			cycleTotals = append(cycleTotals, float64(cycle.TotalDelta))
 			if cycle.PeakUtilization > summary.PeakCycle {
 				summary.PeakCycle = cycle.PeakUtilization
 			}
@@ -300,11 +324,48 @@ func (t *AnthropicTracker) UsageSummary(quotaName string) (*AnthropicSummary, er
 							projected = 100
 						}
 						summary.ProjectedUtil = projected
+						summary.ProjectionConfidence = projectionConfidence(t.store, "anthropic", activeCycle.CycleStart, hoursLeft, cycleTotals)
 					}
 				}
 			}
 		}
 	}
 
+	if activeCycle != nil {
+		summary.Pace = cyclePace(activeCycle.CycleStart, summary.ResetsAt, activeCycle.TotalDelta, summary.AvgPerCycle, time.Now())
+	}
+
 	return summary, nil
 }
+
+// rebuildPageSize is how many snapshots RebuildAnthropicCycles loads at a
+// time, so a long history is never held in memory at once.
+var rebuildPageSize = 1000
+
+// RebuildAnthropicCycles discards all Anthropic reset cycles and replays every
+// stored snapshot, oldest first, through a fresh tracker. It is used after
+// backfilling snapshots that predate the existing cycles.
+func RebuildAnthropicCycles(s *store.Store, logger *slog.Logger) error {
+	if err := s.DeleteAnthropicCycles(); err != nil {
+		return fmt.Errorf("anthropic tracker: rebuild: %w", err)
+	}
+	t := NewAnthropicTracker(s, logger)
+	var after time.Time
+	var afterID int64
+	for {
+		page, err := s.QueryAnthropicSnapshotsAfter(after, afterID, rebuildPageSize)
+		if err != nil {
+			return fmt.Errorf("anthropic tracker: rebuild: %w", err)
+		}
+		for _, snap := range page {
+			if err := t.Process(snap); err != nil {
+				return err
+			}
+		}
+		if len(page) < rebuildPageSize {
+			return nil
+		}
+		last := page[len(page)-1]
+		after, afterID = last.CapturedAt, last.ID
+	}
+}
@@ -414,6 +414,63 @@ func TestAnthropicTracker_OnResetCallback_Called(t *testing.T) {
 	}
 }
 
+func TestRebuildAnthropicCycles_IncludesBackfilledSnapshots(t *testing.T) {
+	// Page through the snapshots a few at a time
+	defer func(n int) { rebuildPageSize = n }(rebuildPageSize)
+	rebuildPageSize = 3
+
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	firstReset := base.Add(5 * time.Hour)
+	secondReset := base.Add(10 * time.Hour)
+
+	// Live polling started in the second window and already has a cycle
+	live := NewAnthropicTracker(s, nil)
+	for _, snap := range []*api.AnthropicSnapshot{
+		makeAnthropicSnapshot(base.Add(6*time.Hour), "five_hour", 10, &secondReset),
+		makeAnthropicSnapshot(base.Add(7*time.Hour), "five_hour", 30, &secondReset),
+	} {
+		s.InsertAnthropicSnapshot(snap)
+		live.Process(snap)
+	}
+
+	// Backfill the first window afterwards
+	for _, snap := range []*api.AnthropicSnapshot{
+		makeAnthropicSnapshot(base, "five_hour", 20, &firstReset),
+		makeAnthropicSnapshot(base.Add(time.Hour), "five_hour", 60, &firstReset),
+	} {
+		s.InsertAnthropicSnapshot(snap)
+	}
+
+	if err := RebuildAnthropicCycles(s, nil); err != nil {
+		t.Fatalf("RebuildAnthropicCycles: %v", err)
+	}
+
+	history, err := s.QueryAnthropicCycleHistory("five_hour")
+	if err != nil {
+		t.Fatalf("QueryAnthropicCycleHistory: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("expected 1 completed cycle from backfill, got %d", len(history))
+	}
+	if !history[0].CycleStart.Equal(base) || history[0].PeakUtilization != 60 {
+		t.Errorf("unexpected backfilled cycle: start %v peak %.0f", history[0].CycleStart, history[0].PeakUtilization)
+	}
+
+	active, err := s.QueryActiveAnthropicCycle("five_hour")
+	if err != nil || active == nil {
+		t.Fatalf("expected an active cycle, got %v, %v", active, err)
+	}
+	if active.PeakUtilization != 30 {
+		t.Errorf("active cycle peak = %.0f, want 30", active.PeakUtilization)
+	}
+}
+
 func TestAnthropicTracker_UsageSummary_WithHistory(t *testing.T) {
 	s, err := store.New(":memory:")
 	if err != nil {
@@ -16,6 +16,7 @@ type AntigravityTracker struct {
 	lastFractions  map[string]float64   // model_id -> last remaining fraction
 	lastResetTimes map[string]time.Time // model_id -> last reset time
 	hasLastValues  bool
+	lastSeen       time.Time
 
 	onReset func(modelID string) // called when a model reset is detected
 }
@@ -27,20 +28,22 @@ func (t *AntigravityTracker) SetOnReset(fn func(string)) {
 
 // AntigravitySummary contains computed usage statistics for an Antigravity model.
 type AntigravitySummary struct {
-	ModelID           string
-	Label             string
-	RemainingFraction float64
-	UsagePercent      float64
-	IsExhausted       bool
-	ResetTime         *time.Time
-	TimeUntilReset    time.Duration
-	CurrentRate       float64 // usage per hour (0.0-1.0 scale)
-	ProjectedUsage    float64 // projected usage at reset (0.0-1.0 scale)
-	CompletedCycles   int
-	AvgPerCycle       float64
-	PeakCycle         float64
-	TotalTracked      float64
-	TrackingSince     time.Time
+	ModelID              string
+	Label                string
+	RemainingFraction    float64
+	UsagePercent         float64
+	IsExhausted          bool
+	ResetTime            *time.Time
+	TimeUntilReset       time.Duration
+	CurrentRate          float64 // usage per hour (0.0-1.0 scale)
+	ProjectedUsage       float64 // projected usage at reset (0.0-1.0 scale)
+	ProjectionConfidence float64 // 0-1, how far ProjectedUsage can be trusted
+	CompletedCycles      int
+	AvgPerCycle          float64
+	PeakCycle            float64
+	TotalTracked         float64
+	TrackingSince        time.Time
+	Pace                 *CyclePace // nil until a cycle has completed
 }
 
 // NewAntigravityTracker creates a new AntigravityTracker.
@@ -58,6 +61,11 @@ func NewAntigravityTracker(store *store.Store, logger *slog.Logger) *Antigravity
 
 // Process iterates over all models in the snapshot, detects resets, and updates cycles.
 func (t *AntigravityTracker) Process(snapshot *api.AntigravitySnapshot) error {
+	if outOfOrder(&t.lastSeen, snapshot.CapturedAt) {
+		t.logger.Warn("Ignoring out-of-order snapshot", "capturedAt", snapshot.CapturedAt, "lastSeen", t.lastSeen)
+		return nil
+	}
+
 	for _, model := range snapshot.Models {
 		if err := t.processModel(model, snapshot.CapturedAt); err != nil {
 			return fmt.Errorf("antigravity tracker: %s: %w", model.ModelID, err)
@@ -116,7 +124,9 @@ func (t *AntigravityTracker) processModel(model api.AntigravityModelQuota, captu
 			if diff < 0 {
 				diff = -diff
 			}
-			if diff > 10*time.Minute {
+			// Only trust the changed time when usage actually dropped
+			lastFraction, ok := t.lastFractions[modelID]
+			if diff > 10*time.Minute && resetConfirmed(t.hasLastValues && ok, 1.0-lastFraction, currentUsage) {
 				resetDetected = true
 				resetReason = "reset_time changed"
 			}
@@ -234,6 +244,7 @@ func (t *AntigravityTracker) UsageSummary(modelID string) (*AntigravitySummary,
 		CompletedCycles: len(history),
 	}
 
+	cycleTotals := make([]float64, 0, len(history))
 	// Calculate stats from completed cycles
 	if len(history) > 0 {
 		var totalDelta float64
@@ -241,6 +252,7 @@ func (t *AntigravityTracker) UsageSummary(modelID string) (*AntigravitySummary,
 
 		for _, cycle := range history {
 			totalDelta += cycle.TotalDelta
+			cycleTotals = append(cycleTotals, float64(cycle.TotalDelta))
 			if cycle.PeakUsage > summary.PeakCycle {
 				summary.PeakCycle = cycle.PeakUsage
 			}
@@ -272,7 +284,7 @@ func (t *AntigravityTracker) UsageSummary(modelID string) (*AntigravitySummary,
 		if latest != nil {
 			for _, m := range latest.Models {
 				if m.ModelID == modelID {
-					summary.Label = m.Label
+					summary.Label = api.AntigravityModelLabel(m.ModelID, m.Label)
 					summary.RemainingFraction = m.RemainingFraction
 					summary.UsagePercent = (1.0 - m.RemainingFraction) * 100
 					summary.IsExhausted = m.IsExhausted
@@ -300,11 +312,16 @@ func (t *AntigravityTracker) UsageSummary(modelID string) (*AntigravitySummary,
 							projected = 1.0
 						}
 						summary.ProjectedUsage = projected
+						summary.ProjectionConfidence = projectionConfidence(t.store, "antigravity", activeCycle.CycleStart, hoursLeft, cycleTotals)
 					}
 				}
 			}
 		}
 	}
 
+	if activeCycle != nil {
+		summary.Pace = cyclePace(activeCycle.CycleStart, summary.ResetTime, activeCycle.TotalDelta, summary.AvgPerCycle, time.Now())
+	}
+
 	return summary, nil
 }
@@ -16,6 +16,7 @@ type CodexTracker struct {
 	lastValues map[string]float64
 	lastResets map[string]time.Time
 	hasLast    bool
+	lastSeen   time.Time
 
 	onReset func(quotaName string)
 }
@@ -24,17 +25,19 @@ const codexResetShiftThreshold = 60 * time.Minute
 
 // CodexSummary contains computed usage statistics for a Codex quota.
 type CodexSummary struct {
-	QuotaName       string
-	CurrentUtil     float64
-	ResetsAt        *time.Time
-	TimeUntilReset  time.Duration
-	CurrentRate     float64
-	ProjectedUtil   float64
-	CompletedCycles int
-	AvgPerCycle     float64
-	PeakCycle       float64
-	TotalTracked    float64
-	TrackingSince   time.Time
+	QuotaName            string
+	CurrentUtil          float64
+	ResetsAt             *time.Time
+	TimeUntilReset       time.Duration
+	CurrentRate          float64
+	ProjectedUtil        float64
+	ProjectionConfidence float64 // 0-1, how far ProjectedUtil can be trusted
+	CompletedCycles      int
+	AvgPerCycle          float64
+	PeakCycle            float64
+	TotalTracked         float64
+	TrackingSince        time.Time
+	Pace                 *CyclePace // nil until a cycle has completed
 }
 
 // NewCodexTracker creates a new CodexTracker.
@@ -57,6 +60,11 @@ func (t *CodexTracker) SetOnReset(fn func(string)) {
 
 // Process iterates over all quotas in the snapshot, detects resets, and updates cycles.
 func (t *CodexTracker) Process(snapshot *api.CodexSnapshot) error {
+	if outOfOrder(&t.lastSeen, snapshot.CapturedAt) {
+		t.logger.Warn("Ignoring out-of-order snapshot", "capturedAt", snapshot.CapturedAt, "lastSeen", t.lastSeen)
+		return nil
+	}
+
 	for _, quota := range snapshot.Quotas {
 		if err := t.processQuota(quota, snapshot.CapturedAt); err != nil {
 			return fmt.Errorf("codex tracker: %s: %w", quota.Name, err)
@@ -93,7 +101,9 @@ func (t *CodexTracker) processQuota(quota api.CodexQuota, capturedAt time.Time)
 	resetDetected := false
 	updateCycleResetAt := false
 	if cycle.ResetsAt != nil && capturedAt.After(cycle.ResetsAt.Add(2*time.Minute)) {
-		resetDetected = true
+		// Trust the stored reset time only when utilization actually dropped
+		lastUtil, ok := t.lastValues[quotaName]
+		resetDetected = resetConfirmed(t.hasLast && ok, lastUtil, currentUtil)
 	}
 	if !resetDetected {
 		if quota.ResetsAt != nil && cycle.ResetsAt != nil {
@@ -207,11 +217,13 @@ func (t *CodexTracker) UsageSummary(quotaName string) (*CodexSummary, error) {
 
 	summary := &CodexSummary{QuotaName: quotaName, CompletedCycles: len(history)}
 
+	cycleTotals := make([]float64, 0, len(history))
 	if len(history) > 0 {
 		var totalDelta float64
 		summary.TrackingSince = history[len(history)-1].CycleStart
 		for _, cycle := range history {
 			totalDelta += cycle.TotalDelta
+			cycleTotals = append(cycleTotals, float64(cycle.TotalDelta))
 			if cycle.PeakUtilization > summary.PeakCycle {
 				summary.PeakCycle = cycle.PeakUtilization
 			}
@@ -257,11 +269,16 @@ func (t *CodexTracker) UsageSummary(quotaName string) (*CodexSummary, error) {
 							projected = 100
 						}
 						summary.ProjectedUtil = projected
+						summary.ProjectionConfidence = projectionConfidence(t.store, "codex", activeCycle.CycleStart, hoursLeft, cycleTotals)
 					}
 				}
 			}
 		}
 	}
 
+	if activeCycle != nil {
+		summary.Pace = cyclePace(activeCycle.CycleStart, summary.ResetsAt, activeCycle.TotalDelta, summary.AvgPerCycle, time.Now())
+	}
+
 	return summary, nil
 }
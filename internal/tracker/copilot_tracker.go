@@ -17,6 +17,7 @@ type CopilotTracker struct {
 	lastValues    map[string]int    // quota_name → last remaining count
 	lastResets    map[string]string // quota_name → last reset date string
 	hasLastValues bool
+	lastSeen      time.Time
 
 	onReset func(quotaName string) // called when a quota reset is detected
 }
@@ -28,21 +29,23 @@ func (t *CopilotTracker) SetOnReset(fn func(string)) {
 
 // CopilotSummary contains computed usage statistics for a Copilot quota.
 type CopilotSummary struct {
-	QuotaName        string
-	Entitlement      int
-	CurrentRemaining int
-	CurrentUsed      int
-	UsagePercent     float64 // 100 - percent_remaining
-	Unlimited        bool
-	ResetDate        *time.Time
-	TimeUntilReset   time.Duration
-	CurrentRate      float64 // used per hour
-	ProjectedUsage   int
-	CompletedCycles  int
-	AvgPerCycle      float64
-	PeakCycle        int
-	TotalTracked     int
-	TrackingSince    time.Time
+	QuotaName            string
+	Entitlement          int
+	CurrentRemaining     int
+	CurrentUsed          int
+	UsagePercent         float64 // 100 - percent_remaining
+	Unlimited            bool
+	ResetDate            *time.Time
+	TimeUntilReset       time.Duration
+	CurrentRate          float64 // used per hour
+	ProjectedUsage       int
+	ProjectionConfidence float64 // 0-1, how far ProjectedUsage can be trusted
+	CompletedCycles      int
+	AvgPerCycle          float64
+	PeakCycle            int
+	TotalTracked         int
+	TrackingSince        time.Time
+	Pace                 *CyclePace // nil until a cycle has completed
 }
 
 // NewCopilotTracker creates a new CopilotTracker.
@@ -60,6 +63,11 @@ func NewCopilotTracker(store *store.Store, logger *slog.Logger) *CopilotTracker
 
 // Process iterates over all quotas in the snapshot, detects resets, and updates cycles.
 func (t *CopilotTracker) Process(snapshot *api.CopilotSnapshot) error {
+	if outOfOrder(&t.lastSeen, snapshot.CapturedAt) {
+		t.logger.Warn("Ignoring out-of-order snapshot", "capturedAt", snapshot.CapturedAt, "lastSeen", t.lastSeen)
+		return nil
+	}
+
 	resetDateStr := ""
 	if snapshot.ResetDate != nil {
 		resetDateStr = snapshot.ResetDate.Format(time.RFC3339Nano)
@@ -110,8 +118,12 @@ func (t *CopilotTracker) processQuota(quota api.CopilotQuota, capturedAt time.Ti
 	resetReason := ""
 
 	if lastResetStr, ok := t.lastResets[quotaName]; ok && lastResetStr != "" && resetDateStr != "" && resetDateStr != lastResetStr {
-		resetDetected = true
-		resetReason = "reset_date changed"
+		// Only trust the changed date when remaining actually went up
+		lastRemaining, ok := t.lastValues[quotaName]
+		if resetConfirmed(t.hasLastValues && ok, float64(quota.Entitlement-lastRemaining), float64(currentUsed)) {
+			resetDetected = true
+			resetReason = "reset_date changed"
+		}
 	}
 
 	// Also detect reset via time-based check: if resetDate passed and remaining went up
@@ -207,6 +219,7 @@ func (t *CopilotTracker) UsageSummary(quotaName string) (*CopilotSummary, error)
 		CompletedCycles: len(history),
 	}
 
+	cycleTotals := make([]float64, 0, len(history))
 	// Calculate stats from completed cycles
 	if len(history) > 0 {
 		var totalDelta int
@@ -214,6 +227,7 @@ func (t *CopilotTracker) UsageSummary(quotaName string) (*CopilotSummary, error)
 
 		for _, cycle := range history {
 			totalDelta += cycle.TotalDelta
+			cycleTotals = append(cycleTotals, float64(cycle.TotalDelta))
 			if cycle.PeakUsed > summary.PeakCycle {
 				summary.PeakCycle = cycle.PeakUsed
 			}
@@ -267,11 +281,16 @@ func (t *CopilotTracker) UsageSummary(quotaName string) (*CopilotSummary, error)
 							projected = summary.Entitlement
 						}
 						summary.ProjectedUsage = projected
+						summary.ProjectionConfidence = projectionConfidence(t.store, "copilot", activeCycle.CycleStart, hoursLeft, cycleTotals)
 					}
 				}
 			}
 		}
 	}
 
+	if activeCycle != nil {
+		summary.Pace = cyclePace(activeCycle.CycleStart, summary.ResetDate, float64(activeCycle.TotalDelta), summary.AvgPerCycle, time.Now())
+	}
+
 	return summary, nil
 }
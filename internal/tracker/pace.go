@@ -0,0 +1,40 @@
+package tracker
+
+import (
+	"math"
+	"time"
+)
+
+// CyclePace compares the active cycle with the average completed one: how far
+// through the cycle it is against how much of a typical cycle's usage has
+// already occurred. 30% elapsed with 60% consumed is a Ratio of 2.
+type CyclePace struct {
+	ElapsedPercent  float64 // share of the active cycle's duration that has passed
+	ConsumedPercent float64 // usage so far as a share of AvgPerCycle; can pass 100
+	Ratio           float64 // ConsumedPercent / ElapsedPercent; above 1 is ahead of the typical pace
+}
+
+// cyclePace computes the pace of the active cycle that started at start and
+// resets at resetsAt, with used consumed so far. It returns nil without a
+// completed cycle to compare against, without a reset time, or before the
+// cycle has started.
+func cyclePace(start time.Time, resetsAt *time.Time, used, avgPerCycle float64, now time.Time) *CyclePace {
+	if avgPerCycle <= 0 || resetsAt == nil || !resetsAt.After(start) {
+		return nil
+	}
+	elapsed := now.Sub(start)
+	if elapsed <= 0 {
+		return nil
+	}
+	elapsedPct := math.Min(100, float64(elapsed)/float64(resetsAt.Sub(start))*100)
+	consumedPct := math.Max(0, used/avgPerCycle*100)
+	return &CyclePace{
+		ElapsedPercent:  round2(elapsedPct),
+		ConsumedPercent: round2(consumedPct),
+		Ratio:           round2(consumedPct / elapsedPct),
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
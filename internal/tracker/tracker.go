@@ -3,6 +3,7 @@ package tracker
 import (
 	"fmt"
 	"log/slog"
+	"math"
 	"time"
 
 	"github.com/onllm-dev/onwatch/internal/api"
@@ -19,6 +20,7 @@ type Tracker struct {
 	lastSearchRequests float64
 	lastToolRequests   float64
 	hasLastValues      bool
+	lastSeen           time.Time
 
 	onReset func(quotaName string) // called when a quota reset is detected
 }
@@ -43,6 +45,7 @@ type Summary struct {
 	PeakCycle       float64
 	TotalTracked    float64
 	TrackingSince   time.Time
+	Pace            *CyclePace // nil until a cycle has completed
 }
 
 // New creates a new Tracker
@@ -58,6 +61,11 @@ func New(store *store.Store, logger *slog.Logger) *Tracker {
 
 // Process compares current snapshot with previous, detects resets, updates cycles
 func (t *Tracker) Process(snapshot *api.Snapshot) error {
+	if outOfOrder(&t.lastSeen, snapshot.CapturedAt) {
+		t.logger.Warn("Ignoring out-of-order snapshot", "capturedAt", snapshot.CapturedAt, "lastSeen", t.lastSeen)
+		return nil
+	}
+
 	// Process each quota type
 	if err := t.processQuota("subscription", snapshot.CapturedAt, snapshot.Sub, &t.lastSubRequests); err != nil {
 		return fmt.Errorf("tracker: subscription: %w", err)
@@ -126,6 +134,18 @@ func (t *Tracker) processQuota(quotaType string, capturedAt time.Time, info api.
 		}
 	}
 
+	// A moved timestamp alone is not a reset: clock skew after sleep can shift
+	// it while usage keeps climbing in the same cycle.
+	if resetDetected && !resetConfirmed(t.hasLastValues, *lastRequests, info.Requests) {
+		t.logger.Debug("Ignoring reset signal without usage drop",
+			"quotaType", quotaType,
+			"reason", resetReason,
+			"lastRequests", *lastRequests,
+			"requests", info.Requests,
+		)
+		resetDetected = false
+	}
+
 	if resetDetected {
 		// Determine the actual cycle end time:
 		// - If we have a stored RenewsAt and it's in the past, use it as cycle end
@@ -157,11 +177,27 @@ func (t *Tracker) processQuota(quotaType string, capturedAt time.Time, info api.
 		}
 
 		// Create new cycle starting from capturedAt (when we actually detected it)
-		_, err = t.store.CreateCycle(quotaType, capturedAt, info.RenewsAt)
+		newID, err := t.store.CreateCycle(quotaType, capturedAt, info.RenewsAt)
 		if err != nil {
 			return fmt.Errorf("failed to create new cycle: %w", err)
 		}
 
+		// The renewal time moves forward by one reset period, which classifies
+		// both the new cycle and, if still unknown, the one just closed. After
+		// downtime it may have moved by several periods, so only a reset seen
+		// promptly is used.
+		period := cyclePeriod(info.RenewsAt.Sub(cycle.RenewsAt))
+		if period != "" && capturedAt.Sub(cycle.RenewsAt) <= time.Hour {
+			if err := t.store.SetCyclePeriod(newID, period); err != nil {
+				return err
+			}
+			if cycle.Period == "" {
+				if err := t.store.SetCyclePeriod(cycle.ID, period); err != nil {
+					return err
+				}
+			}
+		}
+
 		// Set initial peak for new cycle
 		err = t.store.UpdateCycle(quotaType, info.Requests, 0)
 		if err != nil {
@@ -282,8 +318,119 @@ func (t *Tracker) UsageSummary(quotaType string) (*Summary, error) {
 			if summary.CurrentLimit > 0 {
 				summary.UsagePercent = (summary.CurrentUsage / summary.CurrentLimit) * 100
 			}
+
+			// Calculate rate from tracked usage within this cycle
+			elapsed := time.Since(activeCycle.CycleStart)
+			if elapsed.Minutes() >= 30 && activeCycle.TotalDelta > 0 {
+				summary.CurrentRate = activeCycle.TotalDelta / elapsed.Hours()
+				if hoursLeft := summary.TimeUntilReset.Hours(); hoursLeft > 0 {
+					summary.ProjectedUsage = summary.CurrentUsage + summary.CurrentRate*hoursLeft
+				}
+			}
 		}
 	}
 
+	if activeCycle != nil {
+		summary.Pace = cyclePace(activeCycle.CycleStart, &activeCycle.RenewsAt, activeCycle.TotalDelta, summary.AvgPerCycle, time.Now())
+	}
+
 	return summary, nil
 }
+
+// Projection confidence thresholds: a projection is fully trusted once it
+// rests on confidentSamples snapshots and confidentElapsed of the cycle.
+const (
+	confidentSamples = 12
+	confidentElapsed = 0.25
+)
+
+// projectionConfidence rates a projection for the active cycle starting at
+// cycleStart, with hoursLeft until reset, from 0 (a guess) to 1. See
+// confidenceScore.
+func projectionConfidence(s *store.Store, provider string, cycleStart time.Time, hoursLeft float64, cycleTotals []float64) float64 {
+	// A failed count leaves the projection unrated rather than failing the summary
+	samples, _ := s.CountSnapshotsSince(provider, cycleStart)
+	return confidenceScore(samples, time.Since(cycleStart), hoursLeft, cycleTotals)
+}
+
+// confidenceScore grows with the number of samples in the cycle and the share
+// of the cycle elapsed, and is discounted by how much the totals of completed
+// cycles varied (their coefficient of variation), since an erratic user makes
+// any extrapolation less reliable. Rounded to two decimals.
+func confidenceScore(samples int, elapsed time.Duration, hoursLeft float64, cycleTotals []float64) float64 {
+	if samples < 2 || elapsed <= 0 {
+		return 0
+	}
+	score := min(1, float64(samples)/confidentSamples)
+	if total := elapsed.Hours() + max(0, hoursLeft); total > 0 {
+		score *= min(1, elapsed.Hours()/total/confidentElapsed)
+	}
+	if len(cycleTotals) >= 3 {
+		var sum, sumSq float64
+		for _, v := range cycleTotals {
+			sum += v
+		}
+		mean := sum / float64(len(cycleTotals))
+		if mean > 0 {
+			for _, v := range cycleTotals {
+				sumSq += (v - mean) * (v - mean)
+			}
+			score /= 1 + math.Sqrt(sumSq/float64(len(cycleTotals)))/mean
+		}
+	}
+	return math.Round(score*100) / 100
+}
+
+// ProjectionQuality labels a projection confidence "low" (below 0.4),
+// "medium" (below 0.7) or "high".
+func ProjectionQuality(confidence float64) string {
+	switch {
+	case confidence < 0.4:
+		return "low"
+	case confidence < 0.7:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// cyclePeriod classifies the time between two renewals as a reset period:
+// "hourly", "five_hour", "daily", "weekly" or "monthly". Returns "" for
+// spans too short to be a reset.
+func cyclePeriod(d time.Duration) string {
+	switch {
+	case d < 30*time.Minute:
+		return ""
+	case d < 3*time.Hour:
+		return "hourly"
+	case d < 12*time.Hour:
+		return "five_hour"
+	case d < 3*24*time.Hour:
+		return "daily"
+	case d < 14*24*time.Hour:
+		return "weekly"
+	default:
+		return "monthly"
+	}
+}
+
+// outOfOrder reports whether capturedAt is earlier than the last processed
+// snapshot, as happens when the system clock is set back after sleep.
+// Otherwise it records capturedAt as the latest.
+func outOfOrder(lastSeen *time.Time, capturedAt time.Time) bool {
+	if capturedAt.Before(*lastSeen) {
+		return true
+	}
+	*lastSeen = capturedAt
+	return false
+}
+
+// resetConfirmed reports whether a reset signalled by a renewal timestamp is
+// backed by usage actually dropping. Without a previous reading, or when
+// nothing had been used, there is nothing to compare and the signal is trusted.
+func resetConfirmed(hasPrevious bool, previous, current float64) bool {
+	if !hasPrevious || previous <= 0 {
+		return true
+	}
+	return current < previous
+}
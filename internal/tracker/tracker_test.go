@@ -128,6 +128,66 @@ func TestTracker_DetectsSubscriptionReset(t *testing.T) {
 	}
 }
 
+func TestTracker_ClassifiesCyclePeriod(t *testing.T) {
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	tracker := New(s, nil)
+	baseTime := time.Now()
+	renewsAt := baseTime.Add(time.Minute)
+
+	tracker.Process(&api.Snapshot{
+		CapturedAt: baseTime,
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 100, RenewsAt: renewsAt},
+		Search:     api.QuotaInfo{Limit: 250, Requests: 10, RenewsAt: baseTime.Add(1 * time.Hour)},
+		ToolCall:   api.QuotaInfo{Limit: 5000, Requests: 500, RenewsAt: baseTime.Add(3 * time.Hour)},
+	})
+
+	// Renewal moves forward by five hours right at the reset
+	if err := tracker.Process(&api.Snapshot{
+		CapturedAt: baseTime.Add(2 * time.Minute),
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 5, RenewsAt: renewsAt.Add(5 * time.Hour)},
+		Search:     api.QuotaInfo{Limit: 250, Requests: 10, RenewsAt: baseTime.Add(1 * time.Hour)},
+		ToolCall:   api.QuotaInfo{Limit: 5000, Requests: 500, RenewsAt: baseTime.Add(3 * time.Hour)},
+	}); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	active, err := s.QueryActiveCycle("subscription")
+	if err != nil || active == nil {
+		t.Fatalf("QueryActiveCycle failed: %v", err)
+	}
+	if active.Period != "five_hour" {
+		t.Errorf("active cycle Period = %q, want five_hour", active.Period)
+	}
+	history, err := s.QueryCycleHistory("subscription")
+	if err != nil || len(history) != 1 {
+		t.Fatalf("QueryCycleHistory = %d cycles, err %v", len(history), err)
+	}
+	if history[0].Period != "five_hour" {
+		t.Errorf("closed cycle Period = %q, want five_hour", history[0].Period)
+	}
+}
+
+func TestCyclePeriod(t *testing.T) {
+	tests := []struct {
+		shift time.Duration
+		want  string
+	}{
+		{10 * time.Minute, ""},
+		{time.Hour, "hourly"},
+		{5 * time.Hour, "five_hour"},
+		{24 * time.Hour, "daily"},
+		{7 * 24 * time.Hour, "weekly"},
+		{30 * 24 * time.Hour, "monthly"},
+	}
+	for _, tt := range tests {
+		if got := cyclePeriod(tt.shift); got != tt.want {
+			t.Errorf("cyclePeriod(%v) = %q, want %q", tt.shift, got, tt.want)
+		}
+	}
+}
+
 func TestTracker_DetectsSearchReset(t *testing.T) {
 	s, _ := store.New(":memory:")
 	defer s.Close()
@@ -270,10 +330,18 @@ func TestTracker_UsageSummary_SingleCycle(t *testing.T) {
 	}
 	tracker.Process(snapshot1)
 
+	// Use 100 more requests within the cycle
+	tracker.Process(&api.Snapshot{
+		CapturedAt: baseTime.Add(30 * time.Second),
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 200, RenewsAt: baseTime.Add(5 * time.Hour)},
+		Search:     snapshot1.Search,
+		ToolCall:   snapshot1.ToolCall,
+	})
+
 	// Close the cycle by triggering a reset
 	snapshot2 := &api.Snapshot{
 		CapturedAt: baseTime.Add(1 * time.Minute),
-		Sub:        api.QuotaInfo{Limit: 1000, Requests: 200, RenewsAt: baseTime.Add(10 * time.Hour)}, // Reset
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 20, RenewsAt: baseTime.Add(10 * time.Hour)}, // Reset
 		Search:     api.QuotaInfo{Limit: 250, Requests: 15, RenewsAt: baseTime.Add(2 * time.Hour)},
 		ToolCall:   api.QuotaInfo{Limit: 5000, Requests: 150, RenewsAt: baseTime.Add(6 * time.Hour)},
 	}
@@ -298,6 +366,37 @@ func TestTracker_UsageSummary_SingleCycle(t *testing.T) {
 	}
 }
 
+func TestTracker_UsageSummary_Projection(t *testing.T) {
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	tracker := New(s, nil)
+	now := time.Now().UTC()
+	renewsAt := now.Add(2 * time.Hour)
+
+	// 200 requests over the two hours since the cycle started
+	for _, snap := range []*api.Snapshot{
+		{CapturedAt: now.Add(-2 * time.Hour), Sub: api.QuotaInfo{Limit: 1000, Requests: 100, RenewsAt: renewsAt}},
+		{CapturedAt: now, Sub: api.QuotaInfo{Limit: 1000, Requests: 300, RenewsAt: renewsAt}},
+	} {
+		if _, err := s.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot failed: %v", err)
+		}
+		tracker.Process(snap)
+	}
+
+	summary, err := tracker.UsageSummary("subscription")
+	if err != nil {
+		t.Fatalf("UsageSummary failed: %v", err)
+	}
+	if summary.CurrentRate < 99 || summary.CurrentRate > 101 {
+		t.Errorf("CurrentRate = %v, want ~100/h", summary.CurrentRate)
+	}
+	if summary.ProjectedUsage < 495 || summary.ProjectedUsage > 505 {
+		t.Errorf("ProjectedUsage = %v, want ~500", summary.ProjectedUsage)
+	}
+}
+
 func TestTracker_UsageSummary_MultipleCycles(t *testing.T) {
 	s, _ := store.New(":memory:")
 	defer s.Close()
@@ -415,3 +514,139 @@ func TestTracker_MinuteLevelJitter_IgnoredAsNonReset(t *testing.T) {
 		t.Errorf("TotalDelta = %v, want %v", subCycle.TotalDelta, expectedDelta)
 	}
 }
+
+func TestTracker_ClockSkew_OutOfOrderAndTimestampOnlyChanges(t *testing.T) {
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	tracker := New(s, nil)
+	baseTime := time.Now().Truncate(time.Hour)
+	search := api.QuotaInfo{Limit: 250, Requests: 10, RenewsAt: baseTime.Add(1 * time.Hour)}
+	toolCall := api.QuotaInfo{Limit: 5000, Requests: 500, RenewsAt: baseTime.Add(3 * time.Hour)}
+
+	tracker.Process(&api.Snapshot{
+		CapturedAt: baseTime,
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 100, RenewsAt: baseTime.Add(5 * time.Hour)},
+		Search:     search,
+		ToolCall:   toolCall,
+	})
+
+	// Wake from sleep with a skewed clock: RenewsAt jumps but usage keeps climbing
+	tracker.Process(&api.Snapshot{
+		CapturedAt: baseTime.Add(1 * time.Minute),
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 120, RenewsAt: baseTime.Add(9 * time.Hour)},
+		Search:     search,
+		ToolCall:   toolCall,
+	})
+
+	// Clock set back: this snapshot is older than the last one and must be ignored
+	tracker.Process(&api.Snapshot{
+		CapturedAt: baseTime.Add(-10 * time.Minute),
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 5, RenewsAt: baseTime.Add(15 * time.Hour)},
+		Search:     search,
+		ToolCall:   toolCall,
+	})
+
+	history, _ := s.QueryCycleHistory("subscription")
+	if len(history) != 0 {
+		t.Fatalf("Expected no phantom cycles, got %d", len(history))
+	}
+	cycle, _ := s.QueryActiveCycle("subscription")
+	if cycle == nil {
+		t.Fatal("Expected active subscription cycle")
+	}
+	if cycle.TotalDelta != 20 {
+		t.Errorf("TotalDelta = %v, want 20", cycle.TotalDelta)
+	}
+
+	// A real reset: the timestamp moves and usage drops
+	tracker.Process(&api.Snapshot{
+		CapturedAt: baseTime.Add(2 * time.Minute),
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 3, RenewsAt: baseTime.Add(10 * time.Hour)},
+		Search:     search,
+		ToolCall:   toolCall,
+	})
+	history, _ = s.QueryCycleHistory("subscription")
+	if len(history) != 1 {
+		t.Errorf("Expected 1 completed cycle after usage dropped, got %d", len(history))
+	}
+}
+
+func TestConfidenceScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		samples   int
+		elapsed   time.Duration
+		hoursLeft float64
+		totals    []float64
+		want      float64
+	}{
+		{"two points right after reset", 2, 10 * time.Minute, 4.8, nil, 0.02},
+		{"single sample", 1, 2 * time.Hour, 3, nil, 0},
+		{"plenty of data", 60, 2 * time.Hour, 3, nil, 1},
+		{"few samples late in cycle", 6, 3 * time.Hour, 2, nil, 0.5},
+		{"steady history", 60, 2 * time.Hour, 3, []float64{100, 100, 100}, 1},
+		{"erratic history", 60, 2 * time.Hour, 3, []float64{10, 100, 190}, 0.58},
+	}
+	for _, tt := range tests {
+		if got := confidenceScore(tt.samples, tt.elapsed, tt.hoursLeft, tt.totals); got != tt.want {
+			t.Errorf("%s: confidenceScore = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if q := ProjectionQuality(0.2); q != "low" {
+		t.Errorf("ProjectionQuality(0.2) = %q, want low", q)
+	}
+	if q := ProjectionQuality(0.5); q != "medium" {
+		t.Errorf("ProjectionQuality(0.5) = %q, want medium", q)
+	}
+	if q := ProjectionQuality(0.9); q != "high" {
+		t.Errorf("ProjectionQuality(0.9) = %q, want high", q)
+	}
+}
+
+func TestCyclePace(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	resets := start.Add(10 * time.Hour)
+
+	pace := cyclePace(start, &resets, 60, 100, start.Add(3*time.Hour))
+	if pace == nil || pace.ElapsedPercent != 30 || pace.ConsumedPercent != 60 || pace.Ratio != 2 {
+		t.Errorf("expected 30%% elapsed, 60%% consumed, ratio 2, got %+v", pace)
+	}
+	if pace := cyclePace(start, &resets, 60, 0, start.Add(3*time.Hour)); pace != nil {
+		t.Errorf("expected no pace without a completed cycle, got %+v", pace)
+	}
+	if pace := cyclePace(start, nil, 60, 100, start.Add(3*time.Hour)); pace != nil {
+		t.Errorf("expected no pace without a reset time, got %+v", pace)
+	}
+	if pace := cyclePace(start, &resets, 60, 100, start); pace != nil {
+		t.Errorf("expected no pace at the cycle start, got %+v", pace)
+	}
+}
+
+func TestTracker_UsageSummary_Pace(t *testing.T) {
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	tr := New(s, nil)
+
+	now := time.Now().UTC()
+	prev := now.Add(-15 * time.Hour)
+	s.CreateCycle("subscription", prev, prev.Add(10*time.Hour))
+	s.CloseCycle("subscription", prev.Add(10*time.Hour), 200, 200)
+	s.CreateCycle("subscription", now.Add(-5*time.Hour), now.Add(5*time.Hour))
+	s.UpdateCycle("subscription", 150, 150)
+
+	summary, err := tr.UsageSummary("subscription")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Pace == nil || summary.Pace.ConsumedPercent != 75 {
+		t.Fatalf("expected 75%% of the typical cycle consumed, got %+v", summary.Pace)
+	}
+	if summary.Pace.ElapsedPercent < 49 || summary.Pace.ElapsedPercent > 51 {
+		t.Errorf("expected about half the cycle elapsed, got %v", summary.Pace.ElapsedPercent)
+	}
+}
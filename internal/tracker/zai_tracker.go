@@ -18,6 +18,7 @@ type ZaiTracker struct {
 	lastTokensValue float64
 	lastTimeValue   float64
 	hasLastValues   bool
+	lastSeen        time.Time
 
 	onReset func(quotaName string) // called when a quota reset is detected
 }
@@ -29,19 +30,21 @@ func (t *ZaiTracker) SetOnReset(fn func(string)) {
 
 // ZaiSummary contains computed usage statistics for a Z.ai quota type.
 type ZaiSummary struct {
-	QuotaType       string
-	CurrentUsage    float64
-	CurrentLimit    float64
-	UsagePercent    float64
-	RenewsAt        *time.Time
-	TimeUntilReset  time.Duration
-	CurrentRate     float64 // per hour
-	ProjectedUsage  float64
-	CompletedCycles int
-	AvgPerCycle     float64
-	PeakCycle       float64
-	TotalTracked    float64
-	TrackingSince   time.Time
+	QuotaType            string
+	CurrentUsage         float64
+	CurrentLimit         float64
+	UsagePercent         float64
+	RenewsAt             *time.Time
+	TimeUntilReset       time.Duration
+	CurrentRate          float64 // per hour
+	ProjectedUsage       float64
+	ProjectionConfidence float64 // 0-1, how far ProjectedUsage can be trusted
+	CompletedCycles      int
+	AvgPerCycle          float64
+	PeakCycle            float64
+	TotalTracked         float64
+	TrackingSince        time.Time
+	Pace                 *CyclePace // nil until a cycle has completed
 }
 
 // NewZaiTracker creates a new ZaiTracker.
@@ -57,6 +60,11 @@ func NewZaiTracker(store *store.Store, logger *slog.Logger) *ZaiTracker {
 
 // Process compares current snapshot with previous, detects resets, updates cycles.
 func (t *ZaiTracker) Process(snapshot *api.ZaiSnapshot) error {
+	if outOfOrder(&t.lastSeen, snapshot.CapturedAt) {
+		t.logger.Warn("Ignoring out-of-order snapshot", "capturedAt", snapshot.CapturedAt, "lastSeen", t.lastSeen)
+		return nil
+	}
+
 	if err := t.processTokensQuota(snapshot); err != nil {
 		return fmt.Errorf("zai tracker: tokens: %w", err)
 	}
@@ -120,6 +128,16 @@ func (t *ZaiTracker) processTokensQuota(snapshot *api.ZaiSnapshot) error {
 		}
 	}
 
+	// Only trust a timestamp-based reset when token usage actually dropped
+	if resetDetected && !resetConfirmed(t.hasLastValues, t.lastTokensValue, currentValue) {
+		t.logger.Debug("Ignoring Z.ai tokens reset signal without usage drop",
+			"reason", resetReason,
+			"lastValue", t.lastTokensValue,
+			"currentValue", currentValue,
+		)
+		resetDetected = false
+	}
+
 	if resetDetected {
 		// Determine the actual cycle end time:
 		// - If we have a stored NextReset and it's in the past, use it as cycle end
@@ -194,7 +212,8 @@ func (t *ZaiTracker) processTokensQuota(snapshot *api.ZaiSnapshot) error {
 }
 
 // processTimeQuota tracks the time quota cycle.
-// Reset detection: value drops significantly (TIME_LIMIT has no nextResetTime).
+// Reset detection: value drops significantly, or the nextResetTime TIME_LIMIT
+// sometimes reports passes or changes while usage drops.
 func (t *ZaiTracker) processTimeQuota(snapshot *api.ZaiSnapshot) error {
 	quotaType := "time"
 	currentValue := snapshot.TimeCurrentValue
@@ -205,8 +224,8 @@ func (t *ZaiTracker) processTimeQuota(snapshot *api.ZaiSnapshot) error {
 	}
 
 	if cycle == nil {
-		// First snapshot — create new cycle (no nextReset for TIME_LIMIT)
-		_, err := t.store.CreateZaiCycle(quotaType, snapshot.CapturedAt, nil)
+		// First snapshot — create new cycle
+		_, err := t.store.CreateZaiCycle(quotaType, snapshot.CapturedAt, snapshot.TimeNextResetTime)
 		if err != nil {
 			return fmt.Errorf("failed to create cycle: %w", err)
 		}
@@ -222,8 +241,28 @@ func (t *ZaiTracker) processTimeQuota(snapshot *api.ZaiSnapshot) error {
 
 	// Check for reset: detect significant drop in value
 	resetDetected := false
+	resetReason := ""
 	if t.hasLastValues && t.lastTimeValue > 0 && currentValue < t.lastTimeValue*0.5 {
 		resetDetected = true
+		resetReason = "value dropped"
+	}
+
+	// The reset timestamp, when reported, is only trusted with a usage drop,
+	// as for the tokens quota
+	if !resetDetected && cycle.NextReset != nil {
+		timestampReset := snapshot.CapturedAt.After(cycle.NextReset.Add(2*time.Minute)) ||
+			(snapshot.TimeNextResetTime != nil && !snapshot.TimeNextResetTime.Equal(*cycle.NextReset))
+		if timestampReset {
+			if resetConfirmed(t.hasLastValues, t.lastTimeValue, currentValue) {
+				resetDetected = true
+				resetReason = "timestamp-based (NextReset passed or changed)"
+			} else {
+				t.logger.Debug("Ignoring Z.ai time reset signal without usage drop",
+					"lastValue", t.lastTimeValue,
+					"currentValue", currentValue,
+				)
+			}
+		}
 	}
 
 	if resetDetected {
@@ -233,7 +272,7 @@ func (t *ZaiTracker) processTimeQuota(snapshot *api.ZaiSnapshot) error {
 		}
 
 		// Create new cycle
-		if _, err := t.store.CreateZaiCycle(quotaType, snapshot.CapturedAt, nil); err != nil {
+		if _, err := t.store.CreateZaiCycle(quotaType, snapshot.CapturedAt, snapshot.TimeNextResetTime); err != nil {
 			return fmt.Errorf("failed to create new cycle: %w", err)
 		}
 		if err := t.store.UpdateZaiCycle(quotaType, int64(currentValue), 0); err != nil {
@@ -242,6 +281,7 @@ func (t *ZaiTracker) processTimeQuota(snapshot *api.ZaiSnapshot) error {
 
 		t.lastTimeValue = currentValue
 		t.logger.Info("Detected Z.ai time reset",
+			"reason", resetReason,
 			"lastValue", t.lastTimeValue,
 			"newValue", currentValue,
 			"totalDelta", cycle.TotalDelta,
@@ -294,6 +334,7 @@ func (t *ZaiTracker) UsageSummary(quotaType string) (*ZaiSummary, error) {
 		CompletedCycles: len(history),
 	}
 
+	cycleTotals := make([]float64, 0, len(history))
 	// Calculate stats from completed cycles
 	if len(history) > 0 {
 		var totalDelta int64
@@ -301,6 +342,7 @@ func (t *ZaiTracker) UsageSummary(quotaType string) (*ZaiSummary, error) {
 
 		for _, cycle := range history {
 			totalDelta += cycle.TotalDelta
+			cycleTotals = append(cycleTotals, float64(cycle.TotalDelta))
 			if float64(cycle.TotalDelta) > summary.PeakCycle {
 				summary.PeakCycle = float64(cycle.TotalDelta)
 			}
@@ -349,11 +391,16 @@ func (t *ZaiTracker) UsageSummary(quotaType string) (*ZaiSummary, error) {
 					hoursLeft := time.Until(*summary.RenewsAt).Hours()
 					if hoursLeft > 0 {
 						summary.ProjectedUsage = summary.CurrentUsage + (summary.CurrentRate * hoursLeft)
+						summary.ProjectionConfidence = projectionConfidence(t.store, "zai", activeCycle.CycleStart, hoursLeft, cycleTotals)
 					}
 				}
 			}
 		}
 	}
 
+	if activeCycle != nil {
+		summary.Pace = cyclePace(activeCycle.CycleStart, summary.RenewsAt, float64(activeCycle.TotalDelta), summary.AvgPerCycle, time.Now())
+	}
+
 	return summary, nil
 }
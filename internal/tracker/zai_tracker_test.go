@@ -147,6 +147,40 @@ func TestZaiTracker_DetectsTimeReset_ValueDrop(t *testing.T) {
 	}
 }
 
+func TestZaiTracker_TimeResetTimestamp_RequiresUsageDrop(t *testing.T) {
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	tr := NewZaiTracker(s, nil)
+	baseTime := time.Now()
+	tokensReset := baseTime.Add(24 * time.Hour)
+	timeReset := baseTime.Add(time.Hour)
+
+	s1 := makeZaiSnapshot(baseTime, 50000, 400, &tokensReset)
+	s1.TimeNextResetTime = &timeReset
+	tr.Process(s1)
+
+	// The time reset timestamp moves but usage keeps climbing: not a reset
+	skewed := baseTime.Add(2 * time.Hour)
+	s2 := makeZaiSnapshot(baseTime.Add(time.Minute), 50000, 450, &tokensReset)
+	s2.TimeNextResetTime = &skewed
+	tr.Process(s2)
+
+	if history, _ := s.QueryZaiCycleHistory("time"); len(history) != 0 {
+		t.Fatalf("Expected no closed time cycle without a usage drop, got %d", len(history))
+	}
+
+	// The timestamp moves and usage drops, though by less than half
+	next := baseTime.Add(3 * time.Hour)
+	s3 := makeZaiSnapshot(baseTime.Add(2*time.Minute), 50000, 300, &tokensReset)
+	s3.TimeNextResetTime = &next
+	tr.Process(s3)
+
+	if history, _ := s.QueryZaiCycleHistory("time"); len(history) != 1 {
+		t.Errorf("Expected 1 closed time cycle after usage dropped, got %d", len(history))
+	}
+}
+
 func TestZaiTracker_NegativeDelta_Ignored(t *testing.T) {
 	s, _ := store.New(":memory:")
 	defer s.Close()
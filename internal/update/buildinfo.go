@@ -0,0 +1,47 @@
+package update
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// BuildInfo describes the running binary for bug reports: the release
+// version plus the toolchain, commit and platform it was built with.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Revision  string `json:"revision,omitempty"`  // VCS commit, when built from a checkout
+	Modified  bool   `json:"modified,omitempty"`  // the checkout had uncommitted changes
+	BuildDate string `json:"buildDate,omitempty"` // set at link time, else the commit time
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// ReadBuildInfo returns the build details of the running binary. buildDate is
+// the link-time build date, if any; without it the commit time is used.
+func ReadBuildInfo(version, buildDate string) BuildInfo {
+	info := BuildInfo{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		BuildDate: buildDate,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = s.Value
+			}
+		}
+	}
+	return info
+}
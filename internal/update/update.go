@@ -1,7 +1,10 @@
 package update
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -18,8 +21,86 @@ import (
 
 const (
 	githubReleasesURL = "https://api.github.com/repos/onllm-dev/onwatch/releases/latest"
+	githubReleaseList = "https://api.github.com/repos/onllm-dev/onwatch/releases?per_page=30"
 	downloadBaseURL   = "https://github.com/onllm-dev/onwatch/releases/download"
 	defaultCacheTTL   = 1 * time.Hour
+
+	// checksumsFile is the release asset listing the SHA-256 of each binary,
+	// in sha256sum format.
+	checksumsFile = "SHA256SUMS"
+
+	// Apply keeps the binary it replaces at the binary's path plus prevSuffix
+	// for Rollback, with its version in a file with prevVersionSuffix.
+	prevSuffix        = ".prev"
+	prevVersionSuffix = ".prev.version"
+
+	// downloadAttempts is how many times Apply tries the binary download
+	// before giving up.
+	downloadAttempts = 4
+)
+
+// downloadRetryDelay is the wait before the first download retry; it doubles
+// after each further failure.
+var downloadRetryDelay = 2 * time.Second
+
+// Update channels. Stable only sees full releases; beta also sees
+// pre-releases.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// TriggerManual marks an update someone started from the CLI or dashboard.
+const TriggerManual = "manual"
+
+// AppliedUpdate describes a successfully applied update, passed to the
+// OnApplied hook.
+type AppliedUpdate struct {
+	FromVersion string
+	ToVersion   string
+	Trigger     string
+}
+
+// Apply stages reported through Progress.
+const (
+	StageDownload = "download"
+	StageInstall  = "install"
+)
+
+// Progress is how far an update has got, passed to ApplyWithProgress's
+// callback.
+type Progress struct {
+	Stage      string // StageDownload or StageInstall
+	Downloaded int64
+	Total      int64 // download size, 0 if the server didn't say
+}
+
+// Percent returns the downloaded percentage, 0 when the size is unknown.
+func (p Progress) Percent() int {
+	if p.Total <= 0 {
+		return 0
+	}
+	if p.Downloaded >= p.Total {
+		return 100
+	}
+	return int(p.Downloaded * 100 / p.Total)
+}
+
+// IsValidChannel reports whether ch is a known update channel.
+func IsValidChannel(ch string) bool {
+	return ch == ChannelStable || ch == ChannelBeta
+}
+
+var (
+	// ErrChecksumUnavailable is returned by Apply when the release's published
+	// checksum for this platform cannot be fetched. The update is not applied.
+	ErrChecksumUnavailable = errors.New("update: release checksum unavailable")
+	// ErrChecksumMismatch is returned by Apply when the downloaded binary does
+	// not match the published checksum. The update is not applied.
+	ErrChecksumMismatch = errors.New("update: checksum mismatch")
+	// ErrNoPreviousVersion is returned by Rollback when no earlier binary
+	// was kept by a previous update.
+	ErrNoPreviousVersion = errors.New("update: no previous version to roll back to")
 )
 
 // UpdateInfo holds the result of a version check.
@@ -27,7 +108,12 @@ type UpdateInfo struct {
 	Available      bool   `json:"available"`
 	CurrentVersion string `json:"current_version"`
 	LatestVersion  string `json:"latest_version"`
+	Channel        string `json:"channel"`
+	Prerelease     bool   `json:"prerelease,omitempty"`
 	DownloadURL    string `json:"download_url,omitempty"`
+	// Checksum is the hex SHA-256 the release publishes for DownloadURL.
+	// Empty if the release has no checksum for this platform.
+	Checksum string `json:"checksum,omitempty"`
 }
 
 // Updater checks for and applies self-updates from GitHub releases.
@@ -36,16 +122,27 @@ type Updater struct {
 	logger         *slog.Logger
 	httpClient     *http.Client
 
-	mu            sync.Mutex
-	cachedVersion string
-	cachedAt      time.Time
-	cacheTTL      time.Duration
+	mu             sync.Mutex
+	channel        string
+	cachedVersion  string
+	cachedPre      bool
+	cachedChecksum string
+	cachedAt       time.Time
+	cacheTTL       time.Duration
+
+	// Percentage of the binary Apply has downloaded, -1 when no download
+	// is running
+	downloadPercent int
 
 	// Set by Apply() for Restart() to use (avoids /proc/self/exe issues)
 	lastAppliedPath string
 
-	// For testing: override the GitHub API URL and download base URL
+	// Called by Apply() after the new binary is in place
+	onApplied func(AppliedUpdate)
+
+	// For testing: override the GitHub API URLs and download base URL
 	apiURL      string
+	listURL     string
 	downloadURL string
 }
 
@@ -65,22 +162,80 @@ func NewUpdater(version string, logger *slog.Logger) *Updater {
 				IdleConnTimeout:     30 * time.Second,
 			},
 		},
-		cacheTTL:    defaultCacheTTL,
-		apiURL:      githubReleasesURL,
-		downloadURL: downloadBaseURL,
+		channel:         ChannelStable,
+		cacheTTL:        defaultCacheTTL,
+		downloadPercent: -1,
+		apiURL:          githubReleasesURL,
+		listURL:         githubReleaseList,
+		downloadURL:     downloadBaseURL,
+	}
+}
+
+// SetChannel selects the update channel Check and Apply use. Switching
+// channels drops the cached version check.
+func (u *Updater) SetChannel(ch string) error {
+	if !IsValidChannel(ch) {
+		return fmt.Errorf("update.SetChannel: unknown channel %q", ch)
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.channel != ch {
+		u.channel = ch
+		u.cachedVersion = ""
+		u.cachedChecksum = ""
+		u.cachedAt = time.Time{}
+	}
+	return nil
+}
+
+// OnApplied registers fn to be called after Apply replaces the binary, e.g.
+// to report the update to a webhook. fn runs before Apply returns.
+func (u *Updater) OnApplied(fn func(AppliedUpdate)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.onApplied = fn
+}
+
+// Channel returns the current update channel.
+func (u *Updater) Channel() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.channel
+}
+
+// DownloadProgress returns the percentage of the update binary downloaded so
+// far, and false when Apply isn't downloading.
+func (u *Updater) DownloadProgress() (int, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.downloadPercent < 0 {
+		return 0, false
 	}
+	return u.downloadPercent, true
+}
+
+// setDownloadProgress records the download percentage for DownloadProgress.
+func (u *Updater) setDownloadProgress(percent int) {
+	u.mu.Lock()
+	u.downloadPercent = percent
+	u.mu.Unlock()
+	u.logger.Debug("Downloading update", "percent", percent)
 }
 
 // githubRelease is a minimal struct for parsing the GitHub API response.
 type githubRelease struct {
-	TagName string `json:"tag_name"`
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
 }
 
 // Check queries GitHub for the latest release and compares with current version.
 // Results are cached for cacheTTL duration.
 func (u *Updater) Check() (UpdateInfo, error) {
+	channel := u.Channel()
 	info := UpdateInfo{
 		CurrentVersion: u.currentVersion,
+		Channel:        channel,
 	}
 
 	// Dev builds can't update
@@ -91,56 +246,63 @@ func (u *Updater) Check() (UpdateInfo, error) {
 	// Check cache
 	u.mu.Lock()
 	if u.cachedVersion != "" && time.Since(u.cachedAt) < u.cacheTTL {
-		latest := u.cachedVersion
+		latest, pre, checksum := u.cachedVersion, u.cachedPre, u.cachedChecksum
 		u.mu.Unlock()
 
 		info.LatestVersion = latest
+		info.Prerelease = pre
 		info.Available = compareVersions(latest, u.currentVersion) > 0
 		if info.Available {
 			info.DownloadURL = u.binaryDownloadURL(latest)
+			info.Checksum = checksum
 		}
 		return info, nil
 	}
 	u.mu.Unlock()
 
-	// Fetch from GitHub
-	req, err := http.NewRequest("GET", u.apiURL, nil)
-	if err != nil {
-		return info, fmt.Errorf("update.Check: %w", err)
-	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	req.Header.Set("User-Agent", "onwatch/"+u.currentVersion)
-
-	resp, err := u.httpClient.Do(req)
-	if err != nil {
-		return info, fmt.Errorf("update.Check: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return info, fmt.Errorf("update.Check: GitHub API returned %d", resp.StatusCode)
-	}
-
+	// Fetch from GitHub. The latest-release endpoint never returns
+	// pre-releases, so the beta channel picks the newest from the list.
 	var release githubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+	if channel == ChannelBeta {
+		var releases []githubRelease
+		if err := u.getJSON(u.listURL, &releases); err != nil {
+			return info, fmt.Errorf("update.Check: %w", err)
+		}
+		release = newestRelease(releases)
+	} else if err := u.getJSON(u.apiURL, &release); err != nil {
 		return info, fmt.Errorf("update.Check: %w", err)
 	}
 
 	latest := strings.TrimPrefix(release.TagName, "v")
-
-	// Update cache
-	u.mu.Lock()
-	u.cachedVersion = latest
-	u.cachedAt = time.Now()
-	u.mu.Unlock()
+	if latest == "" {
+		return info, fmt.Errorf("update.Check: no releases found")
+	}
 
 	info.LatestVersion = latest
+	info.Prerelease = release.Prerelease
 	info.Available = compareVersions(latest, u.currentVersion) > 0
 	if info.Available {
 		info.DownloadURL = u.binaryDownloadURL(latest)
+		// A missing checksum doesn't fail the check; Apply refuses instead
+		checksum, err := u.fetchChecksum(latest)
+		if err != nil {
+			u.logger.Warn("Could not fetch release checksum", "version", latest, "error", err)
+		}
+		info.Checksum = checksum
 	}
 
+	// Update cache
+	u.mu.Lock()
+	if u.channel == channel {
+		u.cachedVersion = latest
+		u.cachedPre = release.Prerelease
+		u.cachedChecksum = info.Checksum
+		u.cachedAt = time.Now()
+	}
+	u.mu.Unlock()
+
 	u.logger.Info("Version check complete",
+		"channel", channel,
 		"current", u.currentVersion,
 		"latest", latest,
 		"available", info.Available)
@@ -148,10 +310,53 @@ func (u *Updater) Check() (UpdateInfo, error) {
 	return info, nil
 }
 
+// getJSON fetches a GitHub API URL and decodes the JSON response into v.
+func (u *Updater) getJSON(url string, v any) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("User-Agent", "onwatch/"+u.currentVersion)
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// newestRelease returns the highest-versioned non-draft release, including
+// pre-releases.
+func newestRelease(releases []githubRelease) githubRelease {
+	var newest githubRelease
+	for _, r := range releases {
+		if r.Draft || r.TagName == "" {
+			continue
+		}
+		if newest.TagName == "" || compareVersions(r.TagName, newest.TagName) > 0 {
+			newest = r
+		}
+	}
+	return newest
+}
+
 // Apply downloads the latest binary and replaces the current one.
 // On Unix, uses remove+rename (safe for running binaries since the kernel
 // keeps the inode alive). Falls back to backup-rename on Windows.
 func (u *Updater) Apply() error {
+	return u.ApplyWithProgress(nil)
+}
+
+// ApplyWithProgress is Apply, calling progress (if non-nil) as the download
+// advances and once more when the new binary is being installed. progress
+// runs on the calling goroutine.
+func (u *Updater) ApplyWithProgress(progress func(Progress)) error {
 	if u.currentVersion == "dev" || u.currentVersion == "" {
 		return fmt.Errorf("update.Apply: cannot update dev build")
 	}
@@ -159,6 +364,7 @@ func (u *Updater) Apply() error {
 	// Force a fresh check (bypass cache) to avoid stale version data
 	u.mu.Lock()
 	u.cachedVersion = ""
+	u.cachedChecksum = ""
 	u.cachedAt = time.Time{}
 	u.mu.Unlock()
 
@@ -169,6 +375,14 @@ func (u *Updater) Apply() error {
 	if !info.Available {
 		return fmt.Errorf("update.Apply: already at latest version %s", u.currentVersion)
 	}
+	if info.Checksum == "" {
+		// Fetch again so the error says why
+		_, err := u.fetchChecksum(info.LatestVersion)
+		if err == nil {
+			err = errors.New("empty checksum")
+		}
+		return fmt.Errorf("update.Apply: %w: %v", ErrChecksumUnavailable, err)
+	}
 
 	// Get current binary path
 	exePath, err := os.Executable()
@@ -190,46 +404,28 @@ func (u *Updater) Apply() error {
 		"from", u.currentVersion,
 		"to", info.LatestVersion,
 		"binary", exePath,
-		"url", info.DownloadURL)
+		"url", info.DownloadURL,
+		"sha256", info.Checksum)
 
 	// Download to temp file in same directory (required for atomic rename)
-	tmpFile, err := os.CreateTemp(exeDir, "onwatch.tmp.*")
+	u.setDownloadProgress(0)
+	tmpPath, err := downloadVerified(info.DownloadURL, info.Checksum, exeDir, u.logger, func(written, total int64) {
+		p := Progress{Stage: StageDownload, Downloaded: written, Total: total}
+		u.setDownloadProgress(p.Percent())
+		if progress != nil {
+			progress(p)
+		}
+	})
+	u.mu.Lock()
+	u.downloadPercent = -1
+	u.mu.Unlock()
 	if err != nil {
-		return fmt.Errorf("update.Apply: CreateTemp in %s: %w", exeDir, err)
+		return fmt.Errorf("update.Apply: %w", err)
 	}
-	tmpPath := tmpFile.Name()
 	defer os.Remove(tmpPath) // cleanup on error
 
-	// Stream download (2 min timeout for large binaries on slow connections)
-	dlClient := &http.Client{Timeout: 2 * time.Minute}
-	resp, err := dlClient.Get(info.DownloadURL)
-	if err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("update.Apply: download failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		tmpFile.Close()
-		return fmt.Errorf("update.Apply: download returned HTTP %d", resp.StatusCode)
-	}
-
-	written, err := io.Copy(tmpFile, resp.Body)
-	if err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("update.Apply: download write failed: %w", err)
-	}
-	tmpFile.Close()
-
-	if written == 0 {
-		return fmt.Errorf("update.Apply: downloaded file is empty")
-	}
-
-	u.logger.Info("Download complete", "bytes", written, "path", tmpPath)
-
-	// Validate: check magic bytes (ELF, Mach-O, or PE)
-	if err := validateBinary(tmpPath); err != nil {
-		return fmt.Errorf("update.Apply: %w", err)
+	if progress != nil {
+		progress(Progress{Stage: StageInstall})
 	}
 
 	// Set executable permission
@@ -237,6 +433,11 @@ func (u *Updater) Apply() error {
 		return fmt.Errorf("update.Apply: chmod: %w", err)
 	}
 
+	// Keep the current binary so a bad update can be rolled back
+	if err := keepPrevious(exePath, u.currentVersion); err != nil {
+		u.logger.Warn("Could not keep the current binary for rollback", "error", err)
+	}
+
 	// Replace the binary.
 	// Strategy 1 (Unix): remove current binary then rename temp into place.
 	// On Unix, deleting a running binary is safe — the kernel keeps the inode
@@ -249,12 +450,21 @@ func (u *Updater) Apply() error {
 	// Store path for Restart() — after Apply, /proc/self/exe may show "(deleted)"
 	u.mu.Lock()
 	u.lastAppliedPath = exePath
+	onApplied := u.onApplied
 	u.mu.Unlock()
 
 	u.logger.Info("Update applied successfully",
 		"from", u.currentVersion,
 		"to", info.LatestVersion)
 
+	if onApplied != nil {
+		onApplied(AppliedUpdate{
+			FromVersion: u.currentVersion,
+			ToVersion:   info.LatestVersion,
+			Trigger:     TriggerManual,
+		})
+	}
+
 	// Fix systemd unit file NOW, while we're still alive and before Restart().
 	// This ensures the unit has Restart=always before the process exits,
 	// so systemd will restart the service regardless of how Restart() works.
@@ -263,6 +473,411 @@ func (u *Updater) Apply() error {
 	return nil
 }
 
+// Rollback swaps the current binary with the one the last Apply replaced and
+// returns the version it reverts to ("unknown" if it wasn't recorded). The
+// binary being replaced is kept in turn, so a second Rollback undoes the
+// first. Call Restart afterwards to run the restored binary.
+func (u *Updater) Rollback() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("update.Rollback: os.Executable: %w", err)
+	}
+	exePath, err = filepath.EvalSymlinks(exePath)
+	if err != nil {
+		return "", fmt.Errorf("update.Rollback: EvalSymlinks(%s): %w", exePath, err)
+	}
+
+	version, err := swapPrevious(exePath, u.currentVersion, u.logger)
+	if err != nil {
+		return "", fmt.Errorf("update.Rollback: %w", err)
+	}
+
+	// Store path for Restart(), and drop the version check made for the
+	// binary that is no longer installed
+	u.mu.Lock()
+	u.lastAppliedPath = exePath
+	u.cachedVersion = ""
+	u.cachedChecksum = ""
+	u.cachedAt = time.Time{}
+	u.mu.Unlock()
+
+	u.logger.Info("Rolled back to previous binary",
+		"from", u.currentVersion,
+		"to", version)
+	return version, nil
+}
+
+// PreviousVersion returns the version Rollback would revert to, or "" if no
+// previous binary was kept.
+func (u *Updater) PreviousVersion() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	if _, err := os.Stat(exePath + prevSuffix); err != nil {
+		return ""
+	}
+	return readPreviousVersion(exePath)
+}
+
+// keepPrevious copies the binary at exePath to its rollback location and
+// records its version.
+func keepPrevious(exePath, version string) error {
+	tmp, err := copyToTemp(exePath, filepath.Dir(exePath))
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, exePath+prevSuffix); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.WriteFile(exePath+prevVersionSuffix, []byte(version+"\n"), 0644)
+}
+
+// swapPrevious replaces the binary at exePath with the kept previous one and
+// keeps the replaced binary (recorded as currentVersion) in its place. It
+// returns the restored version.
+func swapPrevious(exePath, currentVersion string, logger *slog.Logger) (string, error) {
+	prevPath := exePath + prevSuffix
+	if _, err := os.Stat(prevPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrNoPreviousVersion
+		}
+		return "", err
+	}
+	if err := validateBinary(prevPath); err != nil {
+		return "", fmt.Errorf("previous binary: %w", err)
+	}
+	version := readPreviousVersion(exePath)
+
+	// Stage copies of both binaries before touching the current one
+	dir := filepath.Dir(exePath)
+	restore, err := copyToTemp(prevPath, dir)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(restore)
+	current, err := copyToTemp(exePath, dir)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(current)
+
+	if err := replaceBinary(exePath, restore, logger); err != nil {
+		return "", err
+	}
+	if err := os.Rename(current, prevPath); err != nil {
+		logger.Warn("Could not keep the replaced binary", "error", err)
+	} else if err := os.WriteFile(exePath+prevVersionSuffix, []byte(currentVersion+"\n"), 0644); err != nil {
+		logger.Warn("Could not record the replaced binary's version", "error", err)
+	}
+	return version, nil
+}
+
+// readPreviousVersion returns the recorded version of the binary kept for
+// exePath, or "unknown".
+func readPreviousVersion(exePath string) string {
+	data, err := os.ReadFile(exePath + prevVersionSuffix)
+	if v := strings.TrimSpace(string(data)); err == nil && v != "" {
+		return v
+	}
+	return "unknown"
+}
+
+// copyToTemp copies src to a new executable temp file in dir and returns its
+// path.
+func copyToTemp(src, dir string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(dir, "onwatch.tmp.*")
+	if err != nil {
+		return "", fmt.Errorf("CreateTemp in %s: %w", dir, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", fmt.Errorf("copy %s: %w", src, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := os.Chmod(out.Name(), 0755); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// downloadVerified downloads url into a temp file in dir and checks it
+// against the expected hex SHA-256 and the executable magic bytes. Transient
+// failures are retried up to downloadAttempts times with a growing delay,
+// resuming from the bytes already written with a Range request when the
+// server supports it. progress, if non-nil, is called with the bytes
+// downloaded and the total size (0 if unknown) whenever the percentage
+// changes, or every progressStep bytes when the size is unknown. It returns
+// the temp file's path; on error the file is removed.
+func downloadVerified(url, checksum, dir string, logger *slog.Logger, progress func(written, total int64)) (_ string, err error) {
+	tmpFile, err := os.CreateTemp(dir, "onwatch.tmp.*")
+	if err != nil {
+		return "", fmt.Errorf("CreateTemp in %s: %w", dir, err)
+	}
+	name := tmpFile.Name()
+	defer func() {
+		tmpFile.Close()
+		if err != nil {
+			os.Remove(name)
+		}
+	}()
+
+	// Stream download (2 min timeout per attempt for large binaries on slow connections)
+	dlClient := &http.Client{Timeout: 2 * time.Minute}
+	dl := &download{file: tmpFile, progress: progress, lastReport: -1}
+	delay := downloadRetryDelay
+	for attempt := 1; ; attempt++ {
+		err = dl.fetch(dlClient, url)
+		if err == nil {
+			break
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= downloadAttempts {
+			return "", err
+		}
+		logger.Warn("Download interrupted, retrying",
+			"attempt", attempt,
+			"bytes", dl.written,
+			"retry_in", delay,
+			"error", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("download write failed: %w", err)
+	}
+
+	if dl.written == 0 {
+		return "", fmt.Errorf("downloaded file is empty")
+	}
+
+	logger.Info("Download complete", "bytes", dl.written, "path", name)
+
+	got, err := fileSHA256(name)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(got, checksum) {
+		return "", fmt.Errorf("%w: expected sha256 %s, got %s", ErrChecksumMismatch, checksum, got)
+	}
+
+	// Validate: check magic bytes (ELF, Mach-O, or PE)
+	if err := validateBinary(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// permanentError marks a download failure that retrying won't fix.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// download is a binary download in progress, resumable across attempts.
+type download struct {
+	file       *os.File
+	written    int64
+	total      int64 // 0 if the server didn't say
+	progress   func(written, total int64)
+	lastReport int64 // percentage, or progressStep multiple without a total
+}
+
+// progressStep is how often a download of unknown size reports progress.
+const progressStep = 1 << 20
+
+// fetch makes one download attempt, continuing from the bytes already
+// written when the server honours Range requests and starting over when it
+// doesn't.
+func (d *download) fetch(client *http.Client, url string) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return &permanentError{fmt.Errorf("download failed: %w", err)}
+	}
+	if d.written > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.written))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if d.written == 0 {
+			break
+		}
+		if start, total, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && start == d.written {
+			d.total = total
+		} else if err := d.restart(); err != nil {
+			return err
+		}
+	case http.StatusOK:
+		// Range ignored (or first attempt): take the whole body
+		if err := d.restart(); err != nil {
+			return err
+		}
+		if resp.ContentLength > 0 {
+			d.total = resp.ContentLength
+		}
+	default:
+		err := fmt.Errorf("download returned HTTP %d", resp.StatusCode)
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests {
+			return err
+		}
+		return &permanentError{err}
+	}
+	if d.written == 0 && d.total == 0 && resp.ContentLength > 0 {
+		d.total = resp.ContentLength
+	}
+
+	buf := make([]byte, 32<<10)
+	for {
+		n, rerr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := d.file.Write(buf[:n]); err != nil {
+				return &permanentError{fmt.Errorf("download write failed: %w", err)}
+			}
+			d.written += int64(n)
+			d.report()
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return fmt.Errorf("download failed: %w", rerr)
+		}
+	}
+	if d.total > 0 && d.written < d.total {
+		return fmt.Errorf("download ended early: got %d of %d bytes", d.written, d.total)
+	}
+	return nil
+}
+
+// restart discards what was downloaded so far.
+func (d *download) restart() error {
+	if d.written == 0 {
+		return nil
+	}
+	if err := d.file.Truncate(0); err != nil {
+		return &permanentError{fmt.Errorf("download write failed: %w", err)}
+	}
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return &permanentError{fmt.Errorf("download write failed: %w", err)}
+	}
+	d.written = 0
+	d.lastReport = -1
+	return nil
+}
+
+// report passes the download's progress to the callback when the
+// percentage changes, or each progressStep bytes if the size is unknown.
+func (d *download) report() {
+	if d.progress == nil {
+		return
+	}
+	mark := d.written / progressStep
+	if d.total > 0 {
+		mark = min(d.written*100/d.total, 100)
+	}
+	if mark != d.lastReport {
+		d.lastReport = mark
+		d.progress(d.written, d.total)
+	}
+}
+
+// parseContentRange parses a "bytes start-end/total" Content-Range header.
+// total is 0 when the server sent "*".
+func parseContentRange(v string) (start, total int64, ok bool) {
+	rest, found := strings.CutPrefix(v, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	span, size, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("hash download: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fetchChecksum downloads the release's SHA256SUMS and returns the checksum
+// of this platform's binary.
+func (u *Updater) fetchChecksum(version string) (string, error) {
+	url := fmt.Sprintf("%s/v%s/%s", u.downloadURL, version, checksumsFile)
+	resp, err := u.httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned HTTP %d", checksumsFile, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", err
+	}
+	return parseChecksums(string(data), binaryName())
+}
+
+// parseChecksums returns the checksum listed for name in sha256sum output
+// ("<hex>  <name>", or "<hex> *<name>" for binary mode).
+func parseChecksums(data, name string) (string, error) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		if _, err := hex.DecodeString(fields[0]); err != nil || len(fields[0]) != sha256.Size*2 {
+			return "", fmt.Errorf("malformed checksum for %s", name)
+		}
+		return strings.ToLower(fields[0]), nil
+	}
+	return "", fmt.Errorf("no checksum for %s in %s", name, checksumsFile)
+}
+
 // replaceBinary replaces the binary at exePath with the one at tmpPath.
 // Tries remove+rename first (works on Unix), falls back to backup-rename (Windows).
 func replaceBinary(exePath, tmpPath string, logger *slog.Logger) error {
@@ -493,16 +1108,22 @@ func filterArgs(args []string) []string {
 
 // binaryDownloadURL constructs the download URL for the current platform.
 func (u *Updater) binaryDownloadURL(version string) string {
+	return fmt.Sprintf("%s/v%s/%s", u.downloadURL, version, binaryName())
+}
+
+// binaryName is the release asset name of the binary for this platform.
+func binaryName() string {
 	name := fmt.Sprintf("onwatch-%s-%s", runtime.GOOS, runtime.GOARCH)
 	if runtime.GOOS == "windows" {
 		name += ".exe"
 	}
-	return fmt.Sprintf("%s/v%s/%s", u.downloadURL, version, name)
+	return name
 }
 
 // compareVersions compares two semver strings.
 // Returns: 1 if a > b, -1 if a < b, 0 if equal.
-// Handles pre-release suffixes like "2.2.5-test" by extracting numeric parts.
+// Handles pre-release suffixes like "2.2.5-test": the numeric parts are
+// compared first, and on a tie a release ranks above its pre-releases.
 func compareVersions(a, b string) int {
 	a = strings.TrimPrefix(a, "v")
 	b = strings.TrimPrefix(b, "v")
@@ -528,6 +1149,58 @@ func compareVersions(a, b string) int {
 			return -1
 		}
 	}
+	return comparePrerelease(prereleaseOf(a), prereleaseOf(b))
+}
+
+// prereleaseOf returns the pre-release part of a version ("beta.2" for
+// "2.3.0-beta.2"), or "" for a release.
+func prereleaseOf(v string) string {
+	if idx := strings.IndexByte(v, '-'); idx >= 0 {
+		return v[idx+1:]
+	}
+	return ""
+}
+
+// comparePrerelease compares pre-release parts by semver precedence: a
+// release ("") ranks highest, numeric identifiers compare numerically and
+// below alphanumeric ones, and a shorter prefix ranks lower.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	idsA, idsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		numA, errA := strconv.Atoi(idsA[i])
+		numB, errB := strconv.Atoi(idsB[i])
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA > numB {
+					return 1
+				}
+				return -1
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(idsA[i], idsB[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(idsA) > len(idsB):
+		return 1
+	case len(idsA) < len(idsB):
+		return -1
+	}
 	return 0
 }
 
@@ -1,12 +1,18 @@
 package update
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 )
@@ -31,6 +37,11 @@ func TestCompareVersions(t *testing.T) {
 		{"single digit", "3", "2.9.9", 1},
 		{"pre-release suffix", "2.2.6-test", "2.2.5-test", 1},
 		{"pre-release vs release", "2.2.6-beta", "2.2.5", 1},
+		{"release above its pre-release", "2.3.0", "2.3.0-beta.1", 1},
+		{"pre-release below its release", "2.3.0-rc.1", "2.3.0", -1},
+		{"pre-release numeric identifiers", "2.3.0-beta.10", "2.3.0-beta.2", 1},
+		{"pre-release alphanumeric order", "2.3.0-alpha", "2.3.0-beta", -1},
+		{"pre-release longer wins", "2.3.0-beta.1", "2.3.0-beta", 1},
 	}
 
 	for _, tt := range tests {
@@ -68,14 +79,31 @@ func TestCheck_EmptyVersion(t *testing.T) {
 	}
 }
 
+// testChecksum is the SHA256SUMS checksum served for this platform's binary.
+const testChecksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+// serveChecksums answers requests for a release's SHA256SUMS and reports
+// whether r was one.
+func serveChecksums(w http.ResponseWriter, r *http.Request) bool {
+	if !strings.HasSuffix(r.URL.Path, "/"+checksumsFile) {
+		return false
+	}
+	w.Write([]byte(testChecksum + "  " + binaryName() + "\n"))
+	return true
+}
+
 func TestCheck_UpdateAvailable(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveChecksums(w, r) {
+			return
+		}
 		json.NewEncoder(w).Encode(githubRelease{TagName: "v3.0.0"})
 	}))
 	defer srv.Close()
 
 	u := NewUpdater("2.2.0", slog.Default())
 	u.apiURL = srv.URL
+	u.downloadURL = srv.URL
 
 	info, err := u.Check()
 	if err != nil {
@@ -90,6 +118,9 @@ func TestCheck_UpdateAvailable(t *testing.T) {
 	if info.DownloadURL == "" {
 		t.Error("expected download URL to be set")
 	}
+	if info.Checksum != testChecksum {
+		t.Errorf("got checksum=%q, want %q", info.Checksum, testChecksum)
+	}
 }
 
 func TestCheck_AlreadyLatest(t *testing.T) {
@@ -113,9 +144,65 @@ func TestCheck_AlreadyLatest(t *testing.T) {
 	}
 }
 
+func TestCheck_BetaChannel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveChecksums(w, r) {
+			return
+		}
+		if r.URL.Path == "/list" {
+			json.NewEncoder(w).Encode([]githubRelease{
+				{TagName: "v2.3.0-beta.1", Prerelease: true},
+				{TagName: "v2.4.0-beta.1", Draft: true, Prerelease: true},
+				{TagName: "v2.3.0-beta.2", Prerelease: true},
+				{TagName: "v2.2.1"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(githubRelease{TagName: "v2.2.1"})
+	}))
+	defer srv.Close()
+
+	u := NewUpdater("2.2.0", slog.Default())
+	u.apiURL = srv.URL
+	u.listURL = srv.URL + "/list"
+	u.downloadURL = srv.URL
+
+	info, err := u.Check()
+	if err != nil {
+		t.Fatalf("stable check: %v", err)
+	}
+	if info.Channel != ChannelStable || info.LatestVersion != "2.2.1" || info.Prerelease {
+		t.Errorf("stable channel got %+v, want latest 2.2.1", info)
+	}
+
+	if err := u.SetChannel(ChannelBeta); err != nil {
+		t.Fatalf("SetChannel: %v", err)
+	}
+	info, err = u.Check()
+	if err != nil {
+		t.Fatalf("beta check: %v", err)
+	}
+	if info.Channel != ChannelBeta || info.LatestVersion != "2.3.0-beta.2" || !info.Prerelease {
+		t.Errorf("beta channel got %+v, want pre-release 2.3.0-beta.2", info)
+	}
+	if !strings.Contains(info.DownloadURL, "/v2.3.0-beta.2/") {
+		t.Errorf("download URL %q should point at the pre-release", info.DownloadURL)
+	}
+
+	if err := u.SetChannel("nightly"); err == nil {
+		t.Error("expected error for unknown channel")
+	}
+	if u.Channel() != ChannelBeta {
+		t.Errorf("invalid channel should not change the current one, got %q", u.Channel())
+	}
+}
+
 func TestCheck_CacheTTL(t *testing.T) {
 	callCount := 0
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveChecksums(w, r) {
+			return
+		}
 		callCount++
 		json.NewEncoder(w).Encode(githubRelease{TagName: "v3.0.0"})
 	}))
@@ -123,6 +210,7 @@ func TestCheck_CacheTTL(t *testing.T) {
 
 	u := NewUpdater("2.2.0", slog.Default())
 	u.apiURL = srv.URL
+	u.downloadURL = srv.URL
 	u.cacheTTL = 1 * time.Hour
 
 	// First call hits the server
@@ -144,11 +232,17 @@ func TestCheck_CacheTTL(t *testing.T) {
 	if !info.Available {
 		t.Error("cached result should still show update available")
 	}
+	if info.Checksum != testChecksum {
+		t.Errorf("cached result lost the checksum: %q", info.Checksum)
+	}
 }
 
 func TestCheck_CacheExpiry(t *testing.T) {
 	callCount := 0
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveChecksums(w, r) {
+			return
+		}
 		callCount++
 		json.NewEncoder(w).Encode(githubRelease{TagName: "v3.0.0"})
 	}))
@@ -156,6 +250,7 @@ func TestCheck_CacheExpiry(t *testing.T) {
 
 	u := NewUpdater("2.2.0", slog.Default())
 	u.apiURL = srv.URL
+	u.downloadURL = srv.URL
 	u.cacheTTL = 1 * time.Millisecond
 
 	// First call
@@ -304,6 +399,159 @@ func TestApply_AlreadyLatest(t *testing.T) {
 	}
 }
 
+func TestApply_ChecksumUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/"+checksumsFile) {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(githubRelease{TagName: "v3.0.0"})
+	}))
+	defer srv.Close()
+
+	u := NewUpdater("2.2.0", slog.Default())
+	u.apiURL = srv.URL
+	u.downloadURL = srv.URL
+
+	if err := u.Apply(); !errors.Is(err, ErrChecksumUnavailable) {
+		t.Errorf("Apply without a published checksum = %v, want ErrChecksumUnavailable", err)
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	sums := testChecksum + "  onwatch-linux-amd64\n" +
+		strings.ToUpper(testChecksum) + " *onwatch-windows-amd64.exe\n" +
+		"zz  onwatch-darwin-arm64\n"
+
+	if got, err := parseChecksums(sums, "onwatch-linux-amd64"); err != nil || got != testChecksum {
+		t.Errorf("text mode: got %q, %v", got, err)
+	}
+	if got, err := parseChecksums(sums, "onwatch-windows-amd64.exe"); err != nil || got != testChecksum {
+		t.Errorf("binary mode: got %q, %v", got, err)
+	}
+	if _, err := parseChecksums(sums, "onwatch-darwin-arm64"); err == nil {
+		t.Error("expected error for malformed checksum")
+	}
+	if _, err := parseChecksums(sums, "onwatch-linux-arm64"); err == nil {
+		t.Error("expected error for missing entry")
+	}
+}
+
+func TestDownloadVerified(t *testing.T) {
+	binary := append([]byte{0x7f, 'E', 'L', 'F'}, []byte("rest-of-binary")...)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256(binary)
+	dir := t.TempDir()
+
+	path, err := downloadVerified(srv.URL, hex.EncodeToString(sum[:]), dir, slog.Default(), nil)
+	if err != nil {
+		t.Fatalf("downloadVerified with matching checksum: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != string(binary) {
+		t.Errorf("downloaded content mismatch")
+	}
+	os.Remove(path)
+
+	_, err = downloadVerified(srv.URL, testChecksum, dir, slog.Default(), nil)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("downloadVerified with wrong checksum = %v, want ErrChecksumMismatch", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("rejected download should be removed, found %d files", len(entries))
+	}
+}
+
+func TestDownloadVerified_ResumesAfterInterruption(t *testing.T) {
+	defer func(d time.Duration) { downloadRetryDelay = d }(downloadRetryDelay)
+	downloadRetryDelay = 0
+
+	binary := append([]byte{0x7f, 'E', 'L', 'F'}, []byte(strings.Repeat("x", 1000))...)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// Promise the whole binary but drop the connection halfway
+			w.Header().Set("Content-Length", fmt.Sprint(len(binary)))
+			w.Write(binary[:400])
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 400-%d/%d", len(binary)-1, len(binary)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(binary[400:])
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256(binary)
+	var percents []int
+	path, err := downloadVerified(srv.URL, hex.EncodeToString(sum[:]), t.TempDir(), slog.Default(), func(written, total int64) {
+		percents = append(percents, Progress{Downloaded: written, Total: total}.Percent())
+	})
+	if err != nil {
+		t.Fatalf("downloadVerified: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != string(binary) {
+		t.Errorf("resumed download content mismatch")
+	}
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != "bytes=400-" {
+		t.Errorf("Range headers = %q, want none then bytes=400-", ranges)
+	}
+	if len(percents) == 0 || percents[len(percents)-1] != 100 {
+		t.Errorf("progress = %v, want it to end at 100", percents)
+	}
+}
+
+func TestProgress_Percent(t *testing.T) {
+	tests := []struct {
+		p    Progress
+		want int
+	}{
+		{Progress{Downloaded: 0, Total: 200}, 0},
+		{Progress{Downloaded: 50, Total: 200}, 25},
+		{Progress{Downloaded: 200, Total: 200}, 100},
+		{Progress{Downloaded: 300, Total: 200}, 100},
+		{Progress{Downloaded: 50}, 0}, // size unknown
+	}
+	for _, tt := range tests {
+		if got := tt.p.Percent(); got != tt.want {
+			t.Errorf("%+v.Percent() = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadVerified_GivesUp(t *testing.T) {
+	defer func(d time.Duration) { downloadRetryDelay = d }(downloadRetryDelay)
+	downloadRetryDelay = 0
+
+	for _, tc := range []struct {
+		status   int
+		attempts int
+	}{
+		{http.StatusServiceUnavailable, downloadAttempts},
+		{http.StatusNotFound, 1}, // not transient
+	} {
+		attempts := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(tc.status)
+		}))
+		dir := t.TempDir()
+		if _, err := downloadVerified(srv.URL, testChecksum, dir, slog.Default(), nil); err == nil {
+			t.Errorf("HTTP %d: expected an error", tc.status)
+		}
+		srv.Close()
+		if attempts != tc.attempts {
+			t.Errorf("HTTP %d: %d attempts, want %d", tc.status, attempts, tc.attempts)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("HTTP %d: failed download should be removed, found %d files", tc.status, len(entries))
+		}
+	}
+}
+
 func TestCheckWritable(t *testing.T) {
 	dir := t.TempDir()
 	if err := checkWritable(dir); err != nil {
@@ -322,6 +570,9 @@ func TestNewUpdater_Defaults(t *testing.T) {
 	if u.apiURL != githubReleasesURL {
 		t.Errorf("got apiURL=%q, want default", u.apiURL)
 	}
+	if u.Channel() != ChannelStable {
+		t.Errorf("got channel=%q, want %q", u.Channel(), ChannelStable)
+	}
 }
 
 func TestFilterArgs(t *testing.T) {
@@ -421,3 +672,64 @@ func TestReplaceBinary_LeftoverOldFile(t *testing.T) {
 		t.Errorf("got %q, want %q", string(content), "new")
 	}
 }
+
+func TestRollback_SwapsPreviousBinary(t *testing.T) {
+	dir := t.TempDir()
+	exePath := filepath.Join(dir, "onwatch")
+	v1 := []byte{0x7f, 'E', 'L', 'F', '1'}
+	v2 := []byte{0x7f, 'E', 'L', 'F', '2'}
+	if err := os.WriteFile(exePath, v1, 0755); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.Default()
+
+	if _, err := swapPrevious(exePath, "1.0.0", logger); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Fatalf("swap without a kept binary = %v, want ErrNoPreviousVersion", err)
+	}
+
+	// Simulate Apply updating 1.0.0 to 2.0.0
+	if err := keepPrevious(exePath, "1.0.0"); err != nil {
+		t.Fatalf("keepPrevious: %v", err)
+	}
+	if err := os.WriteFile(exePath, v2, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := swapPrevious(exePath, "2.0.0", logger)
+	if err != nil {
+		t.Fatalf("swapPrevious: %v", err)
+	}
+	if version != "1.0.0" {
+		t.Errorf("rolled back to %q, want 1.0.0", version)
+	}
+	if content, _ := os.ReadFile(exePath); string(content) != string(v1) {
+		t.Errorf("binary not restored, got %q", content)
+	}
+	if content, _ := os.ReadFile(exePath + prevSuffix); string(content) != string(v2) {
+		t.Errorf("replaced binary not kept, got %q", content)
+	}
+	if got := readPreviousVersion(exePath); got != "2.0.0" {
+		t.Errorf("kept version = %q, want 2.0.0", got)
+	}
+
+	// Rolling back again restores the update
+	if version, err := swapPrevious(exePath, "1.0.0", logger); err != nil || version != "2.0.0" {
+		t.Errorf("second swap = %q, %v; want 2.0.0", version, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("expected only the binary, its copy and version file, got %d entries", len(entries))
+	}
+}
+
+func TestReadBuildInfo(t *testing.T) {
+	info := ReadBuildInfo("2.11.4", "2026-03-01T10:00:00Z")
+	if info.Version != "2.11.4" || info.GoVersion != runtime.Version() {
+		t.Errorf("version = %q, go = %q", info.Version, info.GoVersion)
+	}
+	if info.OS != runtime.GOOS || info.Arch != runtime.GOARCH {
+		t.Errorf("platform = %s/%s, want %s/%s", info.OS, info.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+	if info.BuildDate != "2026-03-01T10:00:00Z" {
+		t.Errorf("build date = %q, want the link-time date over the commit time", info.BuildDate)
+	}
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// bothProviders are the providers the combined ("both") responses can
+// include, and so the valid both_exclude entries.
+var bothProviders = []string{"synthetic", "zai", "anthropic", "copilot", "codex", "antigravity"}
+
+// normalizeBothExclude validates the providers to leave out of the combined
+// responses, lowercasing and de-duplicating them in bothProviders order.
+func normalizeBothExclude(providers []string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, p := range providers {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if !slices.Contains(bothProviders, p) {
+			return nil, fmt.Errorf("both_exclude: unknown provider %q", p)
+		}
+		seen[p] = true
+	}
+	out := []string{}
+	for _, p := range bothProviders {
+		if seen[p] {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// bothExclude returns the saved both_exclude setting; empty keeps every
+// configured provider in the combined responses.
+func (h *Handler) bothExclude() []string {
+	excluded := []string{}
+	if h.store != nil {
+		if v, _ := h.store.GetSetting("both_exclude"); v != "" {
+			_ = json.Unmarshal([]byte(v), &excluded)
+		}
+	}
+	return excluded
+}
+
+// inBoth reports whether provider belongs in the combined responses: it is
+// configured and not listed in both_exclude. Excluded providers keep their
+// own tabs and endpoints.
+func (h *Handler) inBoth(provider string) bool {
+	return h.config.HasProvider(provider) && !slices.Contains(h.bothExclude(), provider)
+}
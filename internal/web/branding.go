@@ -0,0 +1,104 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Branding is the JSON shape stored under the "branding" settings key. Empty
+// fields keep the onWatch defaults.
+type Branding struct {
+	Title       string `json:"title"`        // replaces "onWatch" in headers and page titles
+	LogoURL     string `json:"logo_url"`     // http(s) URL or same-origin path, also used as the favicon
+	AccentColor string `json:"accent_color"` // #RRGGBB
+}
+
+const (
+	defaultBrandTitle  = "onWatch"
+	defaultAccentColor = "#0D9488"
+	maxBrandTitleLen   = 60
+)
+
+var accentColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// validate checks the branding, trimming its fields.
+func (b *Branding) validate() error {
+	b.Title = strings.TrimSpace(b.Title)
+	b.LogoURL = strings.TrimSpace(b.LogoURL)
+	b.AccentColor = strings.TrimSpace(b.AccentColor)
+	if utf8.RuneCountInString(b.Title) > maxBrandTitleLen {
+		return fmt.Errorf("branding.title must be at most %d characters", maxBrandTitleLen)
+	}
+	if b.LogoURL != "" {
+		u, err := url.Parse(b.LogoURL)
+		switch {
+		case err != nil:
+			return fmt.Errorf("branding.logo_url is not a valid URL")
+		case u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/"):
+			// Same-origin path, e.g. /static/favicon.svg
+		case (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil:
+		default:
+			return fmt.Errorf("branding.logo_url must be an http(s) URL or a path starting with /")
+		}
+	}
+	if b.AccentColor != "" && !accentColorPattern.MatchString(b.AccentColor) {
+		return fmt.Errorf("branding.accent_color must be a hex color such as #0D9488")
+	}
+	return nil
+}
+
+// logoOrigin returns the origin of an external logo URL, for the page's
+// Content-Security-Policy, or "" for same-origin logos.
+func (b Branding) logoOrigin() string {
+	u, err := url.Parse(b.LogoURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// branding returns the saved branding setting, or no overrides when none is
+// saved or it is invalid.
+func (h *Handler) branding() Branding {
+	var b Branding
+	if h.store == nil {
+		return b
+	}
+	if v, _ := h.store.GetSetting("branding"); v != "" {
+		if err := json.Unmarshal([]byte(v), &b); err != nil || b.validate() != nil {
+			return Branding{}
+		}
+	}
+	return b
+}
+
+// pageBrand is the branding the layout, dashboard, login and settings
+// templates render, with the defaults filled in.
+type pageBrand struct {
+	Title       string
+	LogoURL     string
+	AccentColor string
+	Custom      bool // an accent color was set
+}
+
+// pageBrand returns the template branding and lets the page load an
+// external logo.
+func (h *Handler) pageBrand(w http.ResponseWriter) pageBrand {
+	b := h.branding()
+	p := pageBrand{Title: b.Title, LogoURL: b.LogoURL, AccentColor: b.AccentColor, Custom: b.AccentColor != ""}
+	if p.Title == "" {
+		p.Title = defaultBrandTitle
+	}
+	if p.AccentColor == "" {
+		p.AccentColor = defaultAccentColor
+	}
+	if origin := b.logoOrigin(); origin != "" {
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy(origin))
+	}
+	return p
+}
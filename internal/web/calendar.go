@@ -0,0 +1,135 @@
+package web
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// calendarTokenSetting holds the token that lets calendar apps fetch
+// /api/calendar.ics without logging in. Empty or unset disables it.
+const calendarTokenSetting = "calendar_token"
+
+// calendarEventLength is how long a reset event lasts in the calendar.
+const calendarEventLength = 15 * time.Minute
+
+// calendarTokenValid reports whether token matches the calendar token.
+func (s *SessionStore) calendarTokenValid(token string) bool {
+	if s == nil || s.store == nil || token == "" {
+		return false
+	}
+	want, err := s.store.GetSetting(calendarTokenSetting)
+	return err == nil && want != "" && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
+// Calendar handles GET /api/calendar.ics: an iCalendar feed with one event
+// per upcoming quota reset, read from the latest snapshots on every fetch.
+// ?provider= limits it to one provider. Calendar apps that cannot log in use
+// ?token= with the calendar token (see CalendarToken).
+func (h *Handler) Calendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.config == nil {
+		respondError(w, http.StatusInternalServerError, "configuration not available")
+		return
+	}
+	provider := r.URL.Query().Get("provider")
+	if provider != "" && !h.config.HasProvider(provider) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("provider '%s' is not configured", provider))
+		return
+	}
+
+	now := time.Now().UTC()
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//onWatch//Quota Resets//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:onWatch quota resets")
+	writeICSLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	writeICSLine(&b, "X-PUBLISHED-TTL:PT1H")
+	for _, level := range h.currentQuotaLevels() {
+		if level.ResetsAt == nil || !level.ResetsAt.After(now) || (provider != "" && level.Provider != provider) {
+			continue
+		}
+		at := level.ResetsAt.UTC()
+		writeICSLine(&b, "BEGIN:VEVENT")
+		// The UID is stable per reset, so a refresh updates rather than duplicates
+		writeICSLine(&b, fmt.Sprintf("UID:%s-%s-%d@onwatch", level.Provider, level.Quota, at.Unix()))
+		writeICSLine(&b, "DTSTAMP:"+now.Format(icsTimeFormat))
+		writeICSLine(&b, "DTSTART:"+at.Format(icsTimeFormat))
+		writeICSLine(&b, "DTEND:"+at.Add(calendarEventLength).Format(icsTimeFormat))
+		writeICSLine(&b, "SUMMARY:"+icsEscape(fmt.Sprintf("%s %s resets", providerDisplayNames[level.Provider], level.Name)))
+		writeICSLine(&b, "DESCRIPTION:"+icsEscape(fmt.Sprintf("Usage at %s: %.1f%% (%s)", now.Format("2006-01-02 15:04 UTC"), level.Percent, level.Status)))
+		writeICSLine(&b, "TRANSP:TRANSPARENT")
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="onwatch-resets.ics"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(b.String()))
+}
+
+const icsTimeFormat = "20060102T150405Z"
+
+// writeICSLine writes a content line, folded at 75 octets as RFC 5545
+// requires, with CRLF line endings.
+func writeICSLine(b *strings.Builder, line string) {
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut-- // don't split a UTF-8 sequence
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// icsEscape escapes a TEXT value.
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// CalendarToken handles /api/calendar/token (admin only): GET shows the
+// calendar token, POST replaces it with a new one and DELETE revokes it.
+func (h *Handler) CalendarToken(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	var token string
+	switch r.Method {
+	case http.MethodGet:
+		token, _ = h.store.GetSetting(calendarTokenSetting)
+	case http.MethodPost:
+		token = generateToken()
+		if err := h.store.SetSetting(calendarTokenSetting, token); err != nil {
+			h.logger.Error("failed to save calendar token", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save calendar token")
+			return
+		}
+	case http.MethodDelete:
+		if err := h.store.SetSetting(calendarTokenSetting, ""); err != nil {
+			h.logger.Error("failed to revoke calendar token", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to revoke calendar token")
+			return
+		}
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	resp := map[string]interface{}{"token": token}
+	if token != "" {
+		resp["url"] = "/api/calendar.ics?token=" + token
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,180 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// compareMaxWindow bounds each window of /api/compare.
+const compareMaxWindow = 90 * 24 * time.Hour
+
+// compareChunk is how much of a window compareWindow reads per query.
+const compareChunk = 24 * time.Hour
+
+// compareResetDrop is the fall in usage, in percentage points, between two
+// consecutive snapshots that counts as a quota reset.
+const compareResetDrop = 10.0
+
+// compareStats summarizes one quota over one window, in 0-100% usage.
+type compareStats struct {
+	Label      string  `json:"label"`
+	Samples    int     `json:"samples"`
+	Peak       float64 `json:"peak"`
+	Average    float64 `json:"average"`
+	TotalDelta float64 `json:"totalDelta"` // usage added, summed across resets
+	Resets     int     `json:"resets"`
+}
+
+type compareWindow struct {
+	From   time.Time                `json:"from"`
+	To     time.Time                `json:"to"`
+	Quotas map[string]*compareStats `json:"quotas"`
+}
+
+type compareDelta struct {
+	Peak       float64 `json:"peak"`
+	Average    float64 `json:"average"`
+	TotalDelta float64 `json:"totalDelta"`
+	Resets     int     `json:"resets"`
+}
+
+// Compare handles GET /api/compare?provider=&a_from=&a_to=&b_from=&b_to=:
+// side-by-side usage stats of one provider's quotas over two windows, e.g.
+// this week (a) against last week (b). Times are RFC 3339 or YYYY-MM-DD
+// (UTC midnight). Stats come from the normalized 0-100% history, so
+// utilization providers compare the same way as request-count ones. delta
+// is a minus b for the quotas present in both windows.
+func (h *Handler) Compare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	provider, err := h.getProviderFromRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if provider == "both" {
+		respondError(w, http.StatusBadRequest, "compare one provider at a time")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	var windows [2]*compareWindow
+	for i, name := range []string{"a", "b"} {
+		from, to, err := parseCompareWindow(r, name)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		windows[i] = h.compareWindow(provider, from, to)
+	}
+
+	delta := map[string]compareDelta{}
+	for quota, a := range windows[0].Quotas {
+		if b, ok := windows[1].Quotas[quota]; ok {
+			delta[quota] = compareDelta{
+				Peak:       round2(a.Peak - b.Peak),
+				Average:    round2(a.Average - b.Average),
+				TotalDelta: round2(a.TotalDelta - b.TotalDelta),
+				Resets:     a.Resets - b.Resets,
+			}
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"provider": provider,
+		"a":        windows[0],
+		"b":        windows[1],
+		"delta":    delta,
+	})
+}
+
+// parseCompareWindow reads and validates the <name>_from and <name>_to
+// query parameters.
+func parseCompareWindow(r *http.Request, name string) (time.Time, time.Time, error) {
+	var bounds [2]time.Time
+	for i, suffix := range []string{"_from", "_to"} {
+		param := name + suffix
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("%s is required", param)
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", v); err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid %s: use RFC 3339 or YYYY-MM-DD", param)
+			}
+		}
+		bounds[i] = t.UTC()
+	}
+	if !bounds[0].Before(bounds[1]) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s_from must be before %s_to", name, name)
+	}
+	if bounds[1].Sub(bounds[0]) > compareMaxWindow {
+		return time.Time{}, time.Time{}, fmt.Errorf("window %s is longer than 90 days", name)
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// compareWindow computes the stats of each of provider's quotas between
+// from and to. Snapshots are read one compareChunk at a time and folded into
+// the stats, so a long window never holds all of its rows at once.
+func (h *Handler) compareWindow(provider string, from, to time.Time) *compareWindow {
+	window := &compareWindow{From: from, To: to, Quotas: map[string]*compareStats{}}
+	sums := map[string]float64{}
+	prev := map[string]float64{}
+	var lastAt time.Time
+	for chunkStart := from; chunkStart.Before(to); chunkStart = chunkStart.Add(compareChunk) {
+		chunkEnd := chunkStart.Add(compareChunk)
+		if chunkEnd.After(to) {
+			chunkEnd = to
+		}
+		samples, ok := h.providerPercentSamples(provider, chunkStart, chunkEnd)
+		if !ok {
+			break
+		}
+		for _, s := range samples {
+			// Range bounds are inclusive, so a snapshot on a chunk boundary
+			// is read twice
+			if !lastAt.IsZero() && !s.at.After(lastAt) {
+				continue
+			}
+			lastAt = s.at
+			for quota, v := range s.values {
+				stats := window.Quotas[quota]
+				if stats == nil {
+					stats = &compareStats{Label: percentSeriesLabel(provider, quota)}
+					window.Quotas[quota] = stats
+				} else {
+					switch p := prev[quota]; {
+					case v > p:
+						stats.TotalDelta += v - p
+					case p-v >= compareResetDrop:
+						stats.Resets++
+						stats.TotalDelta += v // usage since the reset
+					}
+				}
+				stats.Samples++
+				sums[quota] += v
+				stats.Peak = max(stats.Peak, v)
+				prev[quota] = v
+			}
+		}
+	}
+	for quota, stats := range window.Quotas {
+		stats.Average = round2(sums[quota] / float64(stats.Samples))
+		stats.Peak = round2(stats.Peak)
+		stats.TotalDelta = round2(stats.TotalDelta)
+	}
+	return window
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -102,6 +102,14 @@ func ReEncryptAllData(store interface {
 		errors["smtp"] = err.Error()
 	}
 
+	// Re-encrypt Matrix and Twilio credentials and provider keys (all fields
+	// stored with "enc:" prefix)
+	for _, setting := range []string{"matrix", "twilio", providerKeysSetting} {
+		if err := reEncryptPrefixedSetting(store, setting, oldKey, newKey); err != nil {
+			errors[setting] = err.Error()
+		}
+	}
+
 	return errors
 }
 
@@ -172,3 +180,49 @@ func reEncryptSMTPPassword(store interface {
 
 	return nil
 }
+
+// reEncryptPrefixedSetting re-encrypts every "enc:"-prefixed string field of a
+// JSON settings blob when admin password changes.
+func reEncryptPrefixedSetting(store interface {
+	GetSetting(key string) (string, error)
+	SetSetting(key, value string) error
+}, settingKey, oldKey, newKey string) error {
+	settingJSON, err := store.GetSetting(settingKey)
+	if err != nil || settingJSON == "" {
+		return nil // Nothing to re-encrypt
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(settingJSON), &fields); err != nil {
+		return fmt.Errorf("failed to parse %s settings: %w", settingKey, err)
+	}
+
+	for name, val := range fields {
+		encrypted, ok := val.(string)
+		if !ok || !IsEncryptedValue(encrypted) {
+			continue
+		}
+		plaintext, err := notify.DecryptFromStorage(encrypted, oldKey)
+		if err != nil {
+			// Might already be encrypted with the new key
+			if _, tryNewErr := notify.DecryptFromStorage(encrypted, newKey); tryNewErr == nil {
+				continue
+			}
+			return fmt.Errorf("failed to decrypt %s.%s with old key: %w", settingKey, name, err)
+		}
+		newEncrypted, err := notify.EncryptForStorage(plaintext, newKey)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt %s.%s: %w", settingKey, name, err)
+		}
+		fields[name] = newEncrypted
+	}
+
+	newJSON, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s settings: %w", settingKey, err)
+	}
+	if err := store.SetSetting(settingKey, string(newJSON)); err != nil {
+		return fmt.Errorf("failed to save %s settings: %w", settingKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/onllm-dev/onwatch/internal/tracker"
+)
+
+// addCyclePace adds a summary's cycle pace to its /api/summary response as
+// "cyclePace", null until a cycle has completed.
+func addCyclePace(m map[string]interface{}, pace *tracker.CyclePace) {
+	if pace == nil {
+		m["cyclePace"] = nil
+		return
+	}
+	m["cyclePace"] = map[string]interface{}{
+		"elapsedPercent":  pace.ElapsedPercent,
+		"consumedPercent": pace.ConsumedPercent,
+		"ratio":           pace.Ratio,
+	}
+}
+
+// cyclePaceQuota is one quota considered for the cycle pace insight.
+type cyclePaceQuota struct {
+	name string // display name
+	pace *tracker.CyclePace
+}
+
+// buildCyclePaceInsight builds the "cycle_pace" insight from the quota
+// furthest ahead of its typical cycle, or reports false when no quota has
+// a pace yet.
+func buildCyclePaceInsight(quotas []cyclePaceQuota) (insightItem, bool) {
+	var top *cyclePaceQuota
+	for i := range quotas {
+		if quotas[i].pace != nil && (top == nil || quotas[i].pace.Ratio > top.pace.Ratio) {
+			top = &quotas[i]
+		}
+	}
+	if top == nil {
+		return insightItem{}, false
+	}
+
+	p := top.pace
+	item := insightItem{
+		Key:      "cycle_pace",
+		Type:     "forecast",
+		Title:    "Cycle Pace",
+		Metric:   fmt.Sprintf("%.0f%%", p.ConsumedPercent),
+		Sublabel: fmt.Sprintf("of a typical %s cycle", top.name),
+	}
+	lead := fmt.Sprintf("You're %.0f%% through the %s cycle and have used %.0f%% of your typical consumption.", p.ElapsedPercent, top.name, p.ConsumedPercent)
+	switch {
+	case p.Ratio >= 1.5:
+		item.Severity = "negative"
+		item.Desc = lead + " Well ahead of your usual pace."
+	case p.Ratio >= 1.1:
+		item.Severity = "warning"
+		item.Desc = lead + " Slightly ahead of your usual pace."
+	case p.Ratio >= 0.9:
+		item.Severity = "info"
+		item.Desc = lead + " On your usual pace."
+	default:
+		item.Severity = "positive"
+		item.Desc = lead + " Behind your usual pace."
+	}
+	return item, true
+}
+
+// cyclePaceQuotas lists the paces of a provider's quota summaries, in name
+// order.
+func cyclePaceQuotas[S any](summaries map[string]S, displayName func(string) string, pace func(S) *tracker.CyclePace) []cyclePaceQuota {
+	names := make([]string, 0, len(summaries))
+	for name := range summaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	quotas := make([]cyclePaceQuota, 0, len(names))
+	for _, name := range names {
+		quotas = append(quotas, cyclePaceQuota{name: displayName(name), pace: pace(summaries[name])})
+	}
+	return quotas
+}
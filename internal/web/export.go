@@ -0,0 +1,111 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/export"
+)
+
+// dataExport is the JSON export document.
+type dataExport struct {
+	ExportedAt time.Time          `json:"exportedAt"`
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Series     []dataExportSeries `json:"series"`
+}
+
+type dataExportSeries struct {
+	Provider string            `json:"provider"`
+	Quota    string            `json:"quota"`
+	Label    string            `json:"label"`
+	Points   []dataExportPoint `json:"points"`
+}
+
+type dataExportPoint struct {
+	At      time.Time `json:"at"`
+	Percent float64   `json:"percent"`
+}
+
+// WriteDataExport writes every configured provider's quota usage between
+// start and end as CSV (one row per quota per snapshot) or JSON (one series
+// per quota). Usage is 0-100%, as in the normalized history. Used by
+// /api/export and the scheduled exports.
+func (h *Handler) WriteDataExport(w io.Writer, format string, start, end time.Time) error {
+	samples, series := h.percentHistory(start, end)
+
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"provider", "quota", "label", "captured_at", "percent"})
+		for _, ser := range series {
+			for _, s := range samples[ser.provider] {
+				if v, ok := s.values[ser.quota]; ok {
+					cw.Write([]string{ser.provider, ser.quota, ser.label, s.at.UTC().Format(time.RFC3339), strconv.FormatFloat(v, 'f', 2, 64)})
+				}
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		doc := dataExport{ExportedAt: time.Now().UTC(), From: start.UTC(), To: end.UTC(), Series: []dataExportSeries{}}
+		for _, ser := range series {
+			out := dataExportSeries{Provider: ser.provider, Quota: ser.quota, Label: ser.label, Points: []dataExportPoint{}}
+			for _, s := range samples[ser.provider] {
+				if v, ok := s.values[ser.quota]; ok {
+					out.Points = append(out.Points, dataExportPoint{At: s.at.UTC(), Percent: v})
+				}
+			}
+			doc.Series = append(doc.Series, out)
+		}
+		return json.NewEncoder(w).Encode(doc)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// ExportData handles GET /api/export?format=csv|json&range=7d, downloading
+// the usage history as a file.
+func (h *Handler) ExportData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil || h.config == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		respondError(w, http.StatusBadRequest, "format must be csv or json")
+		return
+	}
+	rangeStr := r.URL.Query().Get("range")
+	if rangeStr == "" {
+		rangeStr = "7d"
+	}
+	duration, err := parseTimeRange(rangeStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	end := time.Now().UTC()
+	contentType := "text/csv; charset=utf-8"
+	if format == "json" {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.FileName(end, format)))
+	if err := h.WriteDataExport(w, format, end.Add(-duration), end); err != nil {
+		h.logger.Error("failed to write data export", "error", err)
+	}
+}
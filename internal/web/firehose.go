@@ -0,0 +1,106 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/onllm-dev/onwatch/internal/agent"
+	"github.com/onllm-dev/onwatch/internal/notify"
+)
+
+// firehoseEnabled reports whether the firehose_enabled setting is on. The
+// firehose posts every stored snapshot, so it is off unless turned on.
+func (h *Handler) firehoseEnabled() bool {
+	if h.store == nil {
+		return false
+	}
+	v, _ := h.store.GetSetting("firehose_enabled")
+	return v == "true"
+}
+
+// firehoseURL returns the firehose_url setting, empty when unset.
+func (h *Handler) firehoseURL() string {
+	if h.store == nil {
+		return ""
+	}
+	v, _ := h.store.GetSetting("firehose_url")
+	return v
+}
+
+// firehoseSecret returns the decrypted firehose_secret setting, empty when
+// unset or when it cannot be decrypted.
+func (h *Handler) firehoseSecret() string {
+	if h.store == nil {
+		return ""
+	}
+	v, _ := h.store.GetSetting("firehose_secret")
+	if v == "" {
+		return ""
+	}
+	secret, err := notify.DecryptFromStorage(v, h.settingsEncryptionKey())
+	if err != nil {
+		return ""
+	}
+	return secret
+}
+
+// firehoseProviders returns the firehose_providers setting; empty sends
+// every provider.
+func (h *Handler) firehoseProviders() []string {
+	providers := []string{}
+	if h.store != nil {
+		if v, _ := h.store.GetSetting("firehose_providers"); v != "" {
+			_ = json.Unmarshal([]byte(v), &providers)
+		}
+	}
+	return providers
+}
+
+// FirehoseConfig returns the snapshot firehose configuration from the
+// dashboard settings, for agent.NewFirehose.
+func (h *Handler) FirehoseConfig() agent.FirehoseConfig {
+	return agent.FirehoseConfig{
+		Enabled:   h.firehoseEnabled(),
+		URL:       h.firehoseURL(),
+		Providers: h.firehoseProviders(),
+		Secret:    h.firehoseSecret(),
+	}
+}
+
+// validateFirehoseURL checks that rawURL, if set, is an http(s) URL.
+func validateFirehoseURL(rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("firehose_url must be an http or https URL")
+	}
+	return nil
+}
+
+// normalizeFirehoseProviders validates the providers to send, lowercasing
+// and de-duplicating them in bothProviders order.
+func normalizeFirehoseProviders(providers []string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, p := range providers {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if !slices.Contains(bothProviders, p) {
+			return nil, fmt.Errorf("firehose_providers: unknown provider %q", p)
+		}
+		seen[p] = true
+	}
+	out := []string{}
+	for _, p := range bothProviders {
+		if seen[p] {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
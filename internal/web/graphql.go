@@ -0,0 +1,423 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// graphqlCycle is one reset cycle in the GraphQL schema, in a shape shared by
+// every provider.
+type graphqlCycle struct {
+	ID         int64
+	Quota      string
+	Start      time.Time
+	End        *time.Time
+	ResetsAt   *time.Time
+	Peak       float64
+	TotalDelta float64
+	Active     bool
+}
+
+var (
+	nonNullString = graphql.NewNonNull(graphql.String)
+	nonNullFloat  = graphql.NewNonNull(graphql.Float)
+	nonNullInt    = graphql.NewNonNull(graphql.Int)
+)
+
+var graphqlProviderType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Provider",
+	Fields: graphql.Fields{
+		"name":        &graphql.Field{Type: nonNullString},
+		"displayName": &graphql.Field{Type: nonNullString},
+	},
+})
+
+var graphqlQuotaType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Quota",
+	Description: "Current usage of one quota, as on the dashboard cards.",
+	Fields: graphql.Fields{
+		"provider": &graphql.Field{Type: nonNullString},
+		"quota":    &graphql.Field{Type: nonNullString, Description: "Quota key, e.g. subscription or five_hour"},
+		"name":     &graphql.Field{Type: nonNullString},
+		"percent":  &graphql.Field{Type: nonNullFloat},
+		"status":   &graphql.Field{Type: nonNullString},
+		"resetsAt": &graphql.Field{Type: graphql.DateTime},
+	},
+})
+
+var graphqlPointType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Point",
+	Fields: graphql.Fields{
+		"at":      &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"percent": &graphql.Field{Type: nonNullFloat},
+	},
+})
+
+var graphqlSeriesType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Series",
+	Description: "Usage of one quota over time, as 0-100%.",
+	Fields: graphql.Fields{
+		"provider": &graphql.Field{Type: nonNullString},
+		"quota":    &graphql.Field{Type: nonNullString},
+		"label":    &graphql.Field{Type: nonNullString},
+		"points":   &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphqlPointType)))},
+	},
+})
+
+var graphqlCycleType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Cycle",
+	Fields: graphql.Fields{
+		"id":         &graphql.Field{Type: nonNullInt},
+		"quota":      &graphql.Field{Type: nonNullString},
+		"start":      &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"end":        &graphql.Field{Type: graphql.DateTime},
+		"resetsAt":   &graphql.Field{Type: graphql.DateTime},
+		"peak":       &graphql.Field{Type: nonNullFloat, Description: "Peak usage in the provider's unit (requests, tokens or percent)"},
+		"totalDelta": &graphql.Field{Type: nonNullFloat},
+		"active":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+var graphqlSessionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Session",
+	Fields: graphql.Fields{
+		"id":            &graphql.Field{Type: nonNullString},
+		"startedAt":     &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"endedAt":       &graphql.Field{Type: graphql.DateTime},
+		"pollInterval":  &graphql.Field{Type: nonNullInt},
+		"snapshotCount": &graphql.Field{Type: nonNullInt},
+	},
+})
+
+var graphqlInsightsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Insights",
+	Fields: graphql.Fields{
+		"stats": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.NewObject(graphql.ObjectConfig{
+			Name: "InsightStat",
+			Fields: graphql.Fields{
+				"value":    &graphql.Field{Type: nonNullString},
+				"label":    &graphql.Field{Type: nonNullString},
+				"sublabel": &graphql.Field{Type: graphql.String},
+			},
+		}))))},
+		"insights": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.NewObject(graphql.ObjectConfig{
+			Name: "Insight",
+			Fields: graphql.Fields{
+				"key":         &graphql.Field{Type: nonNullString},
+				"type":        &graphql.Field{Type: nonNullString},
+				"severity":    &graphql.Field{Type: nonNullString},
+				"title":       &graphql.Field{Type: nonNullString},
+				"metric":      &graphql.Field{Type: graphql.String},
+				"sublabel":    &graphql.Field{Type: graphql.String},
+				"description": &graphql.Field{Type: nonNullString},
+			},
+		}))))},
+	},
+})
+
+// graphqlProviderArg reads a provider argument and checks it is configured.
+func (h *Handler) graphqlProviderArg(p graphql.ResolveParams) (string, error) {
+	provider, _ := p.Args["provider"].(string)
+	if !h.config.HasProvider(provider) {
+		return "", fmt.Errorf("provider '%s' is not configured", provider)
+	}
+	return provider, nil
+}
+
+// buildGraphQLSchema builds the read-only schema. Resolvers call the same
+// store queries and builders as the REST endpoints.
+func (h *Handler) buildGraphQLSchema() (graphql.Schema, error) {
+	providerArg := &graphql.ArgumentConfig{Type: nonNullString}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"providers": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphqlProviderType))),
+				Description: "Configured providers.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var out []map[string]interface{}
+					for _, name := range h.config.AvailableProviders() {
+						out = append(out, map[string]interface{}{"name": name, "displayName": providerDisplayNames[name]})
+					}
+					return out, nil
+				},
+			},
+			"quotas": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphqlQuotaType))),
+				Description: "Current usage of every quota, optionally of one provider.",
+				Args:        graphql.FieldConfigArgument{"provider": &graphql.ArgumentConfig{Type: graphql.String}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					provider, _ := p.Args["provider"].(string)
+					levels := []quotaLevel{}
+					for _, level := range h.currentQuotaLevels() {
+						if provider == "" || level.Provider == provider {
+							levels = append(levels, level)
+						}
+					}
+					return levels, nil
+				},
+			},
+			"history": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphqlSeriesType))),
+				Description: "Usage over time as 0-100% per quota.",
+				Args: graphql.FieldConfigArgument{
+					"provider": providerArg,
+					"range":    &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: "6h", Description: "1h, 6h, 24h, 7d or 30d"},
+					"quota":    &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					provider, err := h.graphqlProviderArg(p)
+					if err != nil {
+						return nil, err
+					}
+					duration, err := parseTimeRange(p.Args["range"].(string))
+					if err != nil {
+						return nil, err
+					}
+					quota, _ := p.Args["quota"].(string)
+					now := time.Now().UTC()
+					samples, series := h.percentHistory(now.Add(-duration), now)
+
+					out := []map[string]interface{}{}
+					for _, ser := range series {
+						if ser.provider != provider || (quota != "" && ser.quota != quota) {
+							continue
+						}
+						points := []map[string]interface{}{}
+						for _, s := range samples[provider] {
+							if v, ok := s.values[ser.quota]; ok {
+								points = append(points, map[string]interface{}{"at": s.at, "percent": v})
+							}
+						}
+						out = append(out, map[string]interface{}{
+							"provider": ser.provider, "quota": ser.quota, "label": ser.label, "points": points,
+						})
+					}
+					return out, nil
+				},
+			},
+			"cycles": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphqlCycleType))),
+				Description: "Reset cycles of a quota, the active one first.",
+				Args: graphql.FieldConfigArgument{
+					"provider": providerArg,
+					"quota":    &graphql.ArgumentConfig{Type: nonNullString},
+					"limit":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 50},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					provider, err := h.graphqlProviderArg(p)
+					if err != nil {
+						return nil, err
+					}
+					limit := p.Args["limit"].(int)
+					if limit < 1 || limit > 500 {
+						return nil, fmt.Errorf("limit must be between 1 and 500")
+					}
+					return h.graphqlCycles(provider, p.Args["quota"].(string), limit)
+				},
+			},
+			"sessions": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphqlSessionType))),
+				Description: "Usage sessions, newest first.",
+				Args: graphql.FieldConfigArgument{
+					"provider": providerArg,
+					"limit":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 50},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					provider, err := h.graphqlProviderArg(p)
+					if err != nil {
+						return nil, err
+					}
+					sessions, err := h.store.QuerySessionHistory(provider)
+					if err != nil {
+						return nil, fmt.Errorf("failed to query sessions")
+					}
+					if limit := p.Args["limit"].(int); limit > 0 && len(sessions) > limit {
+						sessions = sessions[:limit]
+					}
+					return sessions, nil
+				},
+			},
+			"insights": &graphql.Field{
+				Type:        graphql.NewNonNull(graphqlInsightsType),
+				Description: "Stat cards and insights, as on the dashboard.",
+				Args: graphql.FieldConfigArgument{
+					"provider": providerArg,
+					"range":    &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: "7d", Description: "1d, 7d or 30d"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					provider, err := h.graphqlProviderArg(p)
+					if err != nil {
+						return nil, err
+					}
+					hidden := h.getHiddenInsightKeys()
+					rangeDur := parseInsightsRange(p.Args["range"].(string))
+					switch provider {
+					case "synthetic":
+						return h.buildSyntheticInsights(hidden, rangeDur), nil
+					case "zai":
+						return h.buildZaiInsights(hidden, rangeDur), nil
+					case "anthropic":
+						return h.buildAnthropicInsights(hidden, rangeDur), nil
+					case "copilot":
+						return h.buildCopilotInsights(hidden, rangeDur), nil
+					case "codex":
+						return h.buildCodexInsights(hidden, rangeDur), nil
+					default:
+						return h.buildAntigravityInsights(hidden, rangeDur), nil
+					}
+				},
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// graphqlCycles returns a quota's active cycle followed by up to limit
+// completed ones, newest first.
+func (h *Handler) graphqlCycles(provider, quota string, limit int) ([]graphqlCycle, error) {
+	cycles := []graphqlCycle{}
+	var err error
+	switch provider {
+	case "synthetic":
+		c, e1 := h.store.QueryActiveCycle(quota)
+		if c != nil {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaType, c.CycleStart, c.CycleEnd, &c.RenewsAt, c.PeakRequests, c.TotalDelta, true})
+		}
+		rows, e2 := h.store.QueryCycleHistory(quota, limit)
+		for _, c := range rows {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaType, c.CycleStart, c.CycleEnd, &c.RenewsAt, c.PeakRequests, c.TotalDelta, false})
+		}
+		err = firstError(e1, e2)
+	case "zai":
+		c, e1 := h.store.QueryActiveZaiCycle(quota)
+		if c != nil {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaType, c.CycleStart, c.CycleEnd, c.NextReset, float64(c.PeakValue), float64(c.TotalDelta), true})
+		}
+		rows, e2 := h.store.QueryZaiCycleHistory(quota, limit)
+		for _, c := range rows {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaType, c.CycleStart, c.CycleEnd, c.NextReset, float64(c.PeakValue), float64(c.TotalDelta), false})
+		}
+		err = firstError(e1, e2)
+	case "anthropic":
+		c, e1 := h.store.QueryActiveAnthropicCycle(quota)
+		if c != nil {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaName, c.CycleStart, c.CycleEnd, c.ResetsAt, c.PeakUtilization, c.TotalDelta, true})
+		}
+		rows, e2 := h.store.QueryAnthropicCycleHistory(quota, limit)
+		for _, c := range rows {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaName, c.CycleStart, c.CycleEnd, c.ResetsAt, c.PeakUtilization, c.TotalDelta, false})
+		}
+		err = firstError(e1, e2)
+	case "copilot":
+		c, e1 := h.store.QueryActiveCopilotCycle(quota)
+		if c != nil {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaName, c.CycleStart, c.CycleEnd, c.ResetDate, float64(c.PeakUsed), float64(c.TotalDelta), true})
+		}
+		rows, e2 := h.store.QueryCopilotCycleHistory(quota, limit)
+		for _, c := range rows {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaName, c.CycleStart, c.CycleEnd, c.ResetDate, float64(c.PeakUsed), float64(c.TotalDelta), false})
+		}
+		err = firstError(e1, e2)
+	case "codex":
+		c, e1 := h.store.QueryActiveCodexCycle(quota)
+		if c != nil {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaName, c.CycleStart, c.CycleEnd, c.ResetsAt, c.PeakUtilization, c.TotalDelta, true})
+		}
+		rows, e2 := h.store.QueryCodexCycleHistory(quota, limit)
+		for _, c := range rows {
+			cycles = append(cycles, graphqlCycle{c.ID, c.QuotaName, c.CycleStart, c.CycleEnd, c.ResetsAt, c.PeakUtilization, c.TotalDelta, false})
+		}
+		err = firstError(e1, e2)
+	case "antigravity":
+		c, e1 := h.store.QueryActiveAntigravityCycle(quota)
+		if c != nil {
+			cycles = append(cycles, graphqlCycle{c.ID, c.ModelID, c.CycleStart, c.CycleEnd, c.ResetTime, c.PeakUsage, c.TotalDelta, true})
+		}
+		rows, e2 := h.store.QueryAntigravityCycleHistory(quota, limit)
+		for _, c := range rows {
+			cycles = append(cycles, graphqlCycle{c.ID, c.ModelID, c.CycleStart, c.CycleEnd, c.ResetTime, c.PeakUsage, c.TotalDelta, false})
+		}
+		err = firstError(e1, e2)
+	}
+	if err != nil {
+		h.logger.Error("failed to query cycles for GraphQL", "provider", provider, "error", err)
+		return nil, fmt.Errorf("failed to query cycles")
+	}
+	return cycles, nil
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GraphQL serves read-only GraphQL queries (GET ?query= or POST
+// {"query","variables","operationName"}) over providers, current quotas,
+// history, cycles, sessions and insights.
+func (h *Handler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	if h.config == nil || h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	var req struct {
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables"`
+		OperationName string                 `json:"operationName"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				respondError(w, http.StatusBadRequest, "invalid variables")
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if req.Query == "" {
+		respondError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	h.graphqlOnce.Do(func() {
+		h.graphqlSchema, h.graphqlErr = h.buildGraphQLSchema()
+	})
+	if h.graphqlErr != nil {
+		h.logger.Error("failed to build GraphQL schema", "error", h.graphqlErr)
+		respondError(w, http.StatusInternalServerError, "GraphQL not available")
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.graphqlSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+	status := http.StatusOK
+	if result.Data == nil && result.HasErrors() {
+		status = http.StatusBadRequest // the query did not parse or validate
+	}
+	respondJSON(w, status, result)
+}
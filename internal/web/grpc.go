@@ -0,0 +1,241 @@
+package web
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/web/onwatchpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NewGRPCServer returns a gRPC server offering the Quotas service (see
+// onwatchpb/onwatch.proto). Calls from addresses outside the IP allowlist are
+// refused, then authenticate like the HTTP API: with dashboard credentials or
+// a session token in the "authorization" metadata. opts are passed to
+// grpc.NewServer, e.g. grpc.Creds to serve TLS.
+func NewGRPCServer(h *Handler, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := h.grpcCheckAllowlist(ctx); err != nil {
+				return nil, err
+			}
+			if err := h.grpcAuthenticate(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := h.grpcCheckAllowlist(ss.Context()); err != nil {
+				return err
+			}
+			if err := h.grpcAuthenticate(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	srv := grpc.NewServer(opts...)
+	onwatchpb.RegisterQuotasServer(srv, &grpcQuotas{h: h})
+	return srv
+}
+
+// grpcPeerIP returns the address of the client calling over ctx. gRPC has no
+// forwarded headers, so this is always the connecting address.
+func grpcPeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// grpcCheckAllowlist refuses callers outside the allowed_ips setting, like
+// the HTTP server does before authentication.
+func (h *Handler) grpcCheckAllowlist(ctx context.Context) error {
+	if h.ipAllowlist == nil {
+		return nil
+	}
+	if ip := grpcPeerIP(ctx); !h.ipAllowlist.Allows(ip) {
+		h.logger.Info("IP not in whitelist", "ip", ip, "path", "grpc")
+		return status.Error(codes.PermissionDenied, "address not allowed")
+	}
+	return nil
+}
+
+// grpcAuthenticate checks the "authorization" metadata: "Basic <base64
+// user:pass>" or "Bearer <session token>". Like the HTTP API, nothing is
+// checked when no admin credentials are configured. Failed attempts count
+// against the caller's address in the login rate limiter, so passwords can't
+// be guessed faster here than on the login page.
+func (h *Handler) grpcAuthenticate(ctx context.Context) error {
+	if h.sessions == nil {
+		return nil
+	}
+	ip := grpcPeerIP(ctx)
+	if h.rateLimiter != nil && h.rateLimiter.IsBlocked(ip) {
+		return status.Error(codes.ResourceExhausted, "too many failed attempts, try again later")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) > 0 {
+		scheme, credentials, _ := strings.Cut(values[0], " ")
+		switch strings.ToLower(scheme) {
+		case "basic":
+			if decoded, err := base64.StdEncoding.DecodeString(credentials); err == nil {
+				if user, pass, ok := strings.Cut(string(decoded), ":"); ok {
+					if _, ok := h.sessions.checkCredentials(user, pass); ok {
+						if h.rateLimiter != nil {
+							h.rateLimiter.Clear(ip)
+						}
+						return nil
+					}
+				}
+			}
+		case "bearer":
+			if _, ok := h.sessions.Session(credentials); ok {
+				return nil
+			}
+		}
+		if h.rateLimiter != nil {
+			h.rateLimiter.RecordFailure(ip)
+		}
+	}
+	return status.Error(codes.Unauthenticated, "valid credentials required")
+}
+
+// grpcQuotas implements onwatchpb.QuotasServer on top of the handler's
+// current quota builders and history queries.
+type grpcQuotas struct {
+	onwatchpb.UnimplementedQuotasServer
+	h *Handler
+}
+
+func (q *grpcQuotas) checkProvider(provider string) error {
+	if q.h.config == nil || !q.h.config.HasProvider(provider) {
+		return status.Errorf(codes.InvalidArgument, "provider '%s' is not configured", provider)
+	}
+	return nil
+}
+
+// snapshot reads a provider's current quotas, preferring the latest polled
+// snapshot over the last stored one like /api/current.
+func (q *grpcQuotas) snapshot(provider string) *onwatchpb.Snapshot {
+	resp := q.h.buildProviderCurrent(provider)
+	snap := &onwatchpb.Snapshot{Provider: provider}
+	if at, err := time.Parse(time.RFC3339, asString(resp["capturedAt"])); err == nil {
+		snap.CapturedAt = timestamppb.New(at)
+	}
+	for _, level := range providerQuotaLevels(provider, resp) {
+		quota := &onwatchpb.Quota{Quota: level.Quota, Name: level.Name, Percent: level.Percent, Status: level.Status}
+		if level.ResetsAt != nil {
+			quota.ResetsAt = timestamppb.New(*level.ResetsAt)
+		}
+		snap.Quotas = append(snap.Quotas, quota)
+	}
+	return snap
+}
+
+func asString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func (q *grpcQuotas) GetCurrent(_ context.Context, req *onwatchpb.GetCurrentRequest) (*onwatchpb.GetCurrentResponse, error) {
+	if req.GetProvider() != "" {
+		if err := q.checkProvider(req.GetProvider()); err != nil {
+			return nil, err
+		}
+		return &onwatchpb.GetCurrentResponse{Snapshots: []*onwatchpb.Snapshot{q.snapshot(req.GetProvider())}}, nil
+	}
+	resp := &onwatchpb.GetCurrentResponse{}
+	if q.h.config != nil {
+		for _, provider := range q.h.config.AvailableProviders() {
+			resp.Snapshots = append(resp.Snapshots, q.snapshot(provider))
+		}
+	}
+	return resp, nil
+}
+
+func (q *grpcQuotas) GetHistory(_ context.Context, req *onwatchpb.GetHistoryRequest) (*onwatchpb.GetHistoryResponse, error) {
+	if err := q.checkProvider(req.GetProvider()); err != nil {
+		return nil, err
+	}
+	rangeStr := req.GetRange()
+	if rangeStr == "" {
+		rangeStr = "6h"
+	}
+	duration, err := parseTimeRange(rangeStr)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	now := time.Now().UTC()
+	resp := &onwatchpb.GetHistoryResponse{}
+	samples, ok := q.h.providerPercentSamples(req.GetProvider(), now.Add(-duration), now)
+	if !ok {
+		return resp, nil
+	}
+
+	maxPoints := q.h.chartMaxPoints()
+	for _, ser := range percentSeries(req.GetProvider(), samples) {
+		if req.GetQuota() != "" && ser.quota != req.GetQuota() {
+			continue
+		}
+		out := &onwatchpb.Series{Provider: ser.provider, Quota: ser.quota, Label: ser.label}
+		var values []float64
+		for _, s := range samples {
+			if v, ok := s.values[ser.quota]; ok {
+				out.Points = append(out.Points, &onwatchpb.Point{At: timestamppb.New(s.at), Percent: v})
+				values = append(values, v)
+			}
+		}
+		// Long ranges are downsampled like the dashboard charts
+		if len(out.Points) > maxPoints {
+			kept := make([]*onwatchpb.Point, 0, maxPoints)
+			for _, i := range lttbIndices(len(values), maxPoints, [][]float64{values}) {
+				kept = append(kept, out.Points[i])
+			}
+			out.Points = kept
+		}
+		resp.Series = append(resp.Series, out)
+	}
+	return resp, nil
+}
+
+// Watch streams the provider's snapshot now and after each of its polls.
+func (q *grpcQuotas) Watch(req *onwatchpb.WatchRequest, stream grpc.ServerStreamingServer[onwatchpb.Snapshot]) error {
+	provider := req.GetProvider()
+	if err := q.checkProvider(provider); err != nil {
+		return err
+	}
+	polled, unsubscribe := q.h.latest.Subscribe()
+	defer unsubscribe()
+
+	if err := stream.Send(q.snapshot(provider)); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case p := <-polled:
+			if p != provider {
+				continue
+			}
+			if err := stream.Send(q.snapshot(provider)); err != nil {
+				return err
+			}
+		}
+	}
+}
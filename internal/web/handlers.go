@@ -1,18 +1,29 @@
 package web
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log/slog"
+	"maps"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/graphql-go/graphql"
+	"github.com/onllm-dev/onwatch/internal/agent"
 	"github.com/onllm-dev/onwatch/internal/api"
 	"github.com/onllm-dev/onwatch/internal/config"
 	"github.com/onllm-dev/onwatch/internal/notify"
@@ -27,6 +38,7 @@ const (
 	LoginErrorExpired   = "expired"
 	LoginErrorRequired  = "required"
 	LoginErrorRateLimit = "ratelimit"
+	LoginErrorGitHub    = "github"
 )
 
 // loginErrors maps whitelisted error codes to user-friendly messages
@@ -35,6 +47,7 @@ var loginErrors = map[string]string{
 	LoginErrorExpired:   "Session expired, please log in again",
 	LoginErrorRequired:  "Authentication required",
 	LoginErrorRateLimit: "Too many login attempts. Please try again later.",
+	LoginErrorGitHub:    "GitHub sign-in failed or your account is not allowed",
 }
 
 // Notifier defines the interface for the notification engine.
@@ -45,10 +58,20 @@ type Notifier interface {
 	ConfigurePush() error
 	SendTestEmail() error
 	SendTestPush() error
+	ConfigureMatrix() error
+	SendTestMatrix() error
+	ConfigureTwilio() error
+	SendTestSMS() error
 	SetEncryptionKey(key string)
 	GetVAPIDPublicKey() string
 }
 
+// SnapshotInjector feeds a provider API response through that provider's
+// agent as if it had just been polled. See DebugSnapshot.
+type SnapshotInjector interface {
+	InjectSnapshot(data []byte) (time.Time, error)
+}
+
 // Handler handles HTTP requests for the web dashboard
 type Handler struct {
 	store              *store.Store
@@ -64,14 +87,34 @@ type Handler struct {
 	dashboardTmpl      *template.Template
 	loginTmpl          *template.Template
 	settingsTmpl       *template.Template
+	statusTmpl         *template.Template
 	sessions           *SessionStore
 	config             *config.Config
 	version            string
+	buildInfo          *update.BuildInfo
+	logLevel           *slog.LevelVar // shared with the process logger; nil if not runtime-adjustable
 	smtpTestMu         sync.Mutex
 	smtpTestLastSent   time.Time
 	pushTestMu         sync.Mutex
 	pushTestLastSent   time.Time
-	rateLimiter        *LoginRateLimiter // Per-IP rate limiting for login attempts
+	matrixTestMu       sync.Mutex
+	matrixTestLastSent time.Time
+	smsTestMu          sync.Mutex
+	smsTestLastSent    time.Time
+	pollLimitMu        sync.Mutex
+	pollLastRun        map[string]time.Time // provider -> last on-demand poll
+	rateLimiter        *LoginRateLimiter    // Per-IP rate limiting for login attempts
+	breakers           *agent.CircuitBreakers
+	latest             *agent.LatestSnapshots
+	sessionManagers    []*agent.SessionManager
+	injectors          map[string]SnapshotInjector
+	pollers            map[string]agent.Poller
+	github             *githubOAuth // nil unless GitHub login is configured
+	ipAllowlist        *IPWhitelistMiddleware
+	reload             func() error // restarts polling after a provider key change
+	graphqlOnce        sync.Once
+	graphqlSchema      graphql.Schema
+	graphqlErr         error
 }
 
 // NewHandler creates a new Handler instance
@@ -101,6 +144,13 @@ func NewHandler(store *store.Store, tracker *tracker.Tracker, logger *slog.Logge
 		settingsTmpl = template.New("empty")
 	}
 
+	// Parse status page and widget templates (standalone, no dashboard assets)
+	statusTmpl, err := template.New("").ParseFS(templatesFS, "templates/status.html", "templates/widget.html")
+	if err != nil {
+		logger.Error("failed to parse status template", "error", err)
+		statusTmpl = template.New("empty")
+	}
+
 	h := &Handler{
 		store:         store,
 		tracker:       tracker,
@@ -108,12 +158,19 @@ func NewHandler(store *store.Store, tracker *tracker.Tracker, logger *slog.Logge
 		dashboardTmpl: dashboardTmpl,
 		loginTmpl:     loginTmpl,
 		settingsTmpl:  settingsTmpl,
+		statusTmpl:    statusTmpl,
 		sessions:      sessions,
 		config:        cfg,
+		github:        newGitHubOAuth(cfg),
 	}
 	if len(zaiTracker) > 0 && zaiTracker[0] != nil {
 		h.zaiTracker = zaiTracker[0]
 	}
+	h.loadStatusBands()
+	h.ipAllowlist = NewIPWhitelistMiddleware(h.allowedIPs(), logger)
+	if cfg != nil {
+		h.ipAllowlist.SetTrustedProxies(cfg.TrustedProxies)
+	}
 	return h
 }
 
@@ -122,6 +179,17 @@ func (h *Handler) SetVersion(v string) {
 	h.version = v
 }
 
+// SetBuildInfo sets the build details reported by GET /api/version.
+func (h *Handler) SetBuildInfo(b update.BuildInfo) {
+	h.buildInfo = &b
+}
+
+// SetLogLevel sets the level variable the process logger uses, so
+// /api/log-level can change verbosity without a restart.
+func (h *Handler) SetLogLevel(v *slog.LevelVar) {
+	h.logLevel = v
+}
+
 // SetAnthropicTracker sets the Anthropic tracker for usage summary enrichment.
 func (h *Handler) SetAnthropicTracker(t *tracker.AnthropicTracker) {
 	h.anthropicTracker = t
@@ -142,9 +210,17 @@ func (h *Handler) SetAntigravityTracker(t *tracker.AntigravityTracker) {
 	h.antigravityTracker = t
 }
 
-// SetUpdater sets the updater for self-update functionality.
+// SetUpdater sets the updater for self-update functionality and applies the
+// saved update channel.
 func (h *Handler) SetUpdater(u *update.Updater) {
 	h.updater = u
+	if u != nil && h.store != nil {
+		if ch, _ := h.store.GetSetting("update_channel"); ch != "" {
+			if err := u.SetChannel(ch); err != nil {
+				h.logger.Warn("Ignoring saved update channel", "error", err)
+			}
+		}
+	}
 }
 
 // SetNotifier sets the notification engine for alert management.
@@ -157,6 +233,39 @@ func (h *Handler) GetSessionStore() *SessionStore {
 	return h.sessions
 }
 
+// SetSessionManagers sets the agents' session managers, whose idle timeout
+// SessionTimeout updates live.
+func (h *Handler) SetSessionManagers(sms ...*agent.SessionManager) {
+	h.sessionManagers = sms
+}
+
+// SetLatestSnapshots sets the agents' in-memory latest snapshots, which the
+// current quota endpoints prefer when they are newer than the stored ones.
+func (h *Handler) SetLatestSnapshots(l *agent.LatestSnapshots) {
+	h.latest = l
+}
+
+// SetSnapshotInjector registers the agent that DebugSnapshot uses for provider.
+func (h *Handler) SetSnapshotInjector(provider string, inj SnapshotInjector) {
+	if h.injectors == nil {
+		h.injectors = make(map[string]SnapshotInjector)
+	}
+	h.injectors[provider] = inj
+}
+
+// SetPoller registers the agent that /api/poll and /api/overview poll for provider.
+func (h *Handler) SetPoller(provider string, p agent.Poller) {
+	if h.pollers == nil {
+		h.pollers = make(map[string]agent.Poller)
+	}
+	h.pollers[provider] = p
+}
+
+// SetCircuitBreakers sets the per-provider circuit breakers reported by AgentStatus.
+func (h *Handler) SetCircuitBreakers(b *agent.CircuitBreakers) {
+	h.breakers = b
+}
+
 // SetRateLimiter sets the login rate limiter for brute force protection.
 func (h *Handler) SetRateLimiter(l *LoginRateLimiter) {
 	h.rateLimiter = l
@@ -167,6 +276,7 @@ func (h *Handler) SettingsPage(w http.ResponseWriter, r *http.Request) {
 	data := map[string]interface{}{
 		"Title":   "Settings",
 		"Version": h.version,
+		"Brand":   h.pageBrand(w),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -246,11 +356,48 @@ func parseTimeRange(rangeStr string) (time.Duration, error) {
 	}
 }
 
-// maxChartPoints is the target number of data points for chart responses.
+// maxChartPoints is the default target number of data points for chart responses.
 // Charts beyond this density add no visual value on typical displays (~1000px wide)
 // but increase JSON size and browser rendering time.
 const maxChartPoints = 500
 
+// Bounds for the chart_max_points setting.
+const (
+	minChartMaxPoints = 100
+	maxChartMaxPoints = 5000
+)
+
+// chartMaxPoints returns the chart_max_points setting, or maxChartPoints when
+// it is unset or out of bounds. Large monitors can ask for denser charts and
+// low-power devices for sparser ones.
+func (h *Handler) chartMaxPoints() int {
+	if h.store == nil {
+		return maxChartPoints
+	}
+	v, _ := h.store.GetSetting("chart_max_points")
+	n, err := strconv.Atoi(v)
+	if err != nil || n < minChartMaxPoints || n > maxChartMaxPoints {
+		return maxChartPoints
+	}
+	return n
+}
+
+// rememberMeDays returns the lifetime in days of a "remember me" session.
+func (h *Handler) rememberMeDays() int {
+	if h.sessions == nil {
+		return defaultRememberMeDays
+	}
+	return int(h.sessions.RememberMeTTL() / (24 * time.Hour))
+}
+
+// sessionTTLMinutes returns the absolute session lifetime in minutes.
+func (h *Handler) sessionTTLMinutes() int {
+	if h.sessions == nil {
+		return defaultSessionTTLMinutes
+	}
+	return int(h.sessions.SessionTTL() / time.Minute)
+}
+
 // downsampleStep returns the step size to reduce n items to at most max items.
 // Returns 1 if no downsampling is needed.
 func downsampleStep(n, max int) int {
@@ -260,6 +407,293 @@ func downsampleStep(n, max int) int {
 	return (n + max - 1) / max // ceil division
 }
 
+// chartGapPolls is how many poll intervals may separate two chart points
+// before gaps=break treats the stretch between them as missing data.
+const chartGapPolls = 3
+
+// Chart smoothing (smooth=true) defaults: each value is compared with the
+// median of smoothWindow points on either side of it.
+const (
+	smoothWindow        = 2
+	defaultSmoothFactor = 0.5
+)
+
+// parseChartSmoothing reads smooth=true and the optional smooth_factor (the
+// allowed deviation from the local median, as a multiple of that median).
+func parseChartSmoothing(r *http.Request) (bool, float64, error) {
+	if r.URL.Query().Get("smooth") != "true" {
+		return false, 0, nil
+	}
+	factor := defaultSmoothFactor
+	if v := r.URL.Query().Get("smooth_factor"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 10 {
+			return false, 0, fmt.Errorf("smooth_factor must be a number between 0 and 10")
+		}
+		factor = f
+	}
+	return true, factor, nil
+}
+
+// downsamplePoints reduces points to at most max with lttbIndices, keyed on
+// the percentage series (fields ending in "Percent", or every numeric field
+// for providers whose points only hold per-quota percentages). Points with no
+// numeric fields fall back to keeping every step-th point.
+func downsamplePoints(points []map[string]interface{}, max int) []map[string]interface{} {
+	if len(points) <= max || max <= 0 {
+		return points
+	}
+
+	keys := map[string]bool{}
+	percentOnly := false
+	for _, p := range points {
+		for k, v := range p {
+			if _, ok := v.(float64); !ok {
+				continue
+			}
+			if strings.HasSuffix(k, "Percent") {
+				if !percentOnly {
+					keys, percentOnly = map[string]bool{}, true
+				}
+				keys[k] = true
+			} else if !percentOnly {
+				keys[k] = true
+			}
+		}
+	}
+
+	if len(keys) == 0 || max < 3 {
+		step := downsampleStep(len(points), max)
+		last := len(points) - 1
+		out := make([]map[string]interface{}, 0, max)
+		for i, p := range points {
+			if i != 0 && i != last && i%step != 0 {
+				continue
+			}
+			out = append(out, p)
+		}
+		return out
+	}
+
+	series := make([][]float64, 0, len(keys))
+	for k := range keys {
+		values := make([]float64, len(points))
+		for i, p := range points {
+			values[i], _ = p[k].(float64) // missing quotas count as 0
+		}
+		series = append(series, values)
+	}
+	out := make([]map[string]interface{}, 0, max)
+	for _, i := range lttbIndices(len(points), max, series) {
+		out = append(out, points[i])
+	}
+	return out
+}
+
+// downsampleSeries is downsamplePoints for label/dataset shaped charts.
+func downsampleSeries(labels []string, series map[string][]float64, max int) ([]string, map[string][]float64) {
+	if len(labels) <= max || max < 3 {
+		return labels, series
+	}
+	ys := make([][]float64, 0, len(series))
+	for _, values := range series {
+		ys = append(ys, values)
+	}
+	idx := lttbIndices(len(labels), max, ys)
+
+	keptLabels := make([]string, len(idx))
+	for j, i := range idx {
+		keptLabels[j] = labels[i]
+	}
+	kept := make(map[string][]float64, len(series))
+	for key, values := range series {
+		vals := make([]float64, len(idx))
+		for j, i := range idx {
+			vals[j] = values[i]
+		}
+		kept[key] = vals
+	}
+	return keptLabels, kept
+}
+
+// lttbIndices picks max of n points with Largest-Triangle-Three-Buckets, which
+// keeps the peaks and troughs that plain stride sampling skips over. The first
+// and last points are always kept; the rest are split into max-2 buckets and
+// each bucket keeps the point forming the largest triangle with the point kept
+// before it and the average of the next bucket. Every series must have n
+// values; triangle areas are summed across series so a spike in any of them
+// survives. Points are treated as evenly spaced. Requires n > max >= 3.
+func lttbIndices(n, max int, series [][]float64) []int {
+	idx := make([]int, 0, max)
+	idx = append(idx, 0)
+
+	every := float64(n-2) / float64(max-2)
+	prev := 0
+	for b := 0; b < max-2; b++ {
+		start := int(float64(b)*every) + 1
+		end := int(float64(b+1)*every) + 1
+
+		nextStart, nextEnd := end, min(int(float64(b+2)*every)+1, n)
+		if b == max-3 {
+			nextStart, nextEnd = n-1, n
+		}
+		avgX := float64(nextStart+nextEnd-1) / 2
+		avgY := make([]float64, len(series))
+		for s, ys := range series {
+			for _, y := range ys[nextStart:nextEnd] {
+				avgY[s] += y
+			}
+			avgY[s] /= float64(nextEnd - nextStart)
+		}
+
+		best, bestArea := start, -1.0
+		for i := start; i < end; i++ {
+			area := 0.0
+			for s, ys := range series {
+				area += math.Abs((float64(prev)-avgX)*(ys[i]-ys[prev]) - (float64(prev)-float64(i))*(avgY[s]-ys[prev]))
+			}
+			if area > bestArea {
+				best, bestArea = i, area
+			}
+		}
+		idx = append(idx, best)
+		prev = best
+	}
+	return append(idx, n-1)
+}
+
+// smoothSeries replaces values that deviate from the median of their
+// neighbours by more than factor times that median with the median itself.
+// A zero median, such as usage just after a reset, gives no scale to compare
+// against, so those values are kept. It is presentation-only; stored
+// snapshots are never changed.
+func smoothSeries(values []float64, factor float64) []float64 {
+	out := make([]float64, len(values))
+	window := make([]float64, 0, 2*smoothWindow+1)
+	for i, v := range values {
+		window = window[:0]
+		for j := max(0, i-smoothWindow); j <= min(len(values)-1, i+smoothWindow); j++ {
+			window = append(window, values[j])
+		}
+		sort.Float64s(window)
+		median := window[len(window)/2]
+		out[i] = v
+		if len(window) > 2 && median != 0 && math.Abs(v-median) > factor*math.Abs(median) {
+			out[i] = median
+		}
+	}
+	return out
+}
+
+// smoothChartPoints applies smoothSeries to every numeric field of points.
+func smoothChartPoints(points []map[string]interface{}, factor float64) []map[string]interface{} {
+	series := map[string][]float64{}
+	for _, p := range points {
+		for k, v := range p {
+			if _, ok := v.(float64); ok && series[k] == nil {
+				series[k] = make([]float64, 0, len(points))
+			}
+		}
+	}
+	for k := range series {
+		values := make([]float64, len(points))
+		present := true
+		for i, p := range points {
+			f, ok := p[k].(float64)
+			if !ok {
+				present = false
+				break
+			}
+			values[i] = f
+		}
+		// Fields missing from some points (e.g. a quota that appeared later) are left as-is
+		if !present {
+			continue
+		}
+		for i, v := range smoothSeries(values, factor) {
+			points[i][k] = v
+		}
+	}
+	return points
+}
+
+// breakSeriesGaps is breakChartGaps for label/dataset shaped charts: it adds a
+// label and a null value to every series wherever labels are further apart
+// than threshold.
+func breakSeriesGaps(labels []string, series map[string][]float64, threshold time.Duration) ([]string, map[string][]interface{}) {
+	outLabels := make([]string, 0, len(labels))
+	out := make(map[string][]interface{}, len(series))
+	var prevAt time.Time
+	for i, label := range labels {
+		at, _ := time.Parse(time.RFC3339, label)
+		if i > 0 && at.Sub(prevAt) > threshold {
+			outLabels = append(outLabels, prevAt.Add(at.Sub(prevAt)/2).Format(time.RFC3339))
+			for key := range series {
+				out[key] = append(out[key], nil)
+			}
+		}
+		outLabels = append(outLabels, label)
+		for key, values := range series {
+			if i < len(values) {
+				out[key] = append(out[key], values[i])
+			}
+		}
+		prevAt = at
+	}
+	return outLabels, out
+}
+
+// wantChartGaps reports whether the request asked for gap markers (gaps=break).
+func wantChartGaps(r *http.Request) bool {
+	return r.URL.Query().Get("gaps") == "break"
+}
+
+// chartGapThreshold returns the spacing between chart points beyond which data
+// counts as missing, scaled by the downsampling step for n snapshots.
+func (h *Handler) chartGapThreshold(n int) time.Duration {
+	return h.snapshotInterval() * time.Duration(chartGapPolls*downsampleStep(n, h.chartMaxPoints()))
+}
+
+// snapshotInterval returns the expected spacing between stored snapshots:
+// the poll interval, or the store interval when snapshots are stored less
+// often than polled.
+func (h *Handler) snapshotInterval() time.Duration {
+	interval := time.Minute
+	if h.config != nil && h.config.PollInterval > 0 {
+		interval = max(h.config.PollInterval, h.config.StoreInterval)
+	}
+	return interval
+}
+
+// breakChartGaps inserts a point with null values (and "gap": true) between
+// consecutive points further apart than threshold, so the frontend breaks the
+// line instead of drawing straight across missed polls.
+func breakChartGaps(points []map[string]interface{}, threshold time.Duration) []map[string]interface{} {
+	if len(points) < 2 {
+		return points
+	}
+	out := make([]map[string]interface{}, 0, len(points))
+	var prevAt time.Time
+	for i, p := range points {
+		at, _ := time.Parse(time.RFC3339, fmt.Sprint(p["capturedAt"]))
+		if i > 0 && at.Sub(prevAt) > threshold {
+			gap := map[string]interface{}{
+				"capturedAt": prevAt.Add(at.Sub(prevAt) / 2).Format(time.RFC3339),
+				"gap":        true,
+			}
+			for k := range out[len(out)-1] {
+				if k != "capturedAt" {
+					gap[k] = nil
+				}
+			}
+			out = append(out, gap)
+		}
+		out = append(out, p)
+		prevAt = at
+	}
+	return out
+}
+
 // parseInsightsRange parses the insights range param, defaulting to 7d.
 func parseInsightsRange(rangeStr string) time.Duration {
 	switch rangeStr {
@@ -457,6 +891,7 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 		"HasCopilot":      hasCopilot,
 		"HasCodex":        hasCodex,
 		"HasAntigravity":  hasAntigravity,
+		"Brand":           h.pageBrand(w),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -468,6 +903,8 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 }
 
 // Current returns current quota status (API endpoint)
+// With ?sparkline=true each quota also carries a short usage trend over its
+// current cycle (see addSparklines).
 func (h *Handler) Current(w http.ResponseWriter, r *http.Request) {
 	provider, err := h.getProviderFromRequest(r)
 	if err != nil {
@@ -497,6 +934,22 @@ func (h *Handler) Current(w http.ResponseWriter, r *http.Request) {
 
 // currentBoth returns combined quota status for all configured providers.
 func (h *Handler) currentBoth(w http.ResponseWriter, r *http.Request) {
+	resp := h.buildAllCurrent()
+	for _, p := range h.bothExclude() {
+		delete(resp, p)
+	}
+	if wantSparklines(r) {
+		for provider, v := range resp {
+			if m, ok := v.(map[string]interface{}); ok {
+				h.addSparklines(provider, m)
+			}
+		}
+	}
+	respondJSON(w, http.StatusOK, h.numberFormat().addDisplayFields(resp))
+}
+
+// buildAllCurrent builds the current quota response of every configured provider.
+func (h *Handler) buildAllCurrent() map[string]interface{} {
 	response := map[string]interface{}{}
 	if h.config.HasProvider("synthetic") {
 		response["synthetic"] = h.buildSyntheticCurrent()
@@ -516,12 +969,228 @@ func (h *Handler) currentBoth(w http.ResponseWriter, r *http.Request) {
 	if h.config.HasProvider("antigravity") {
 		response["antigravity"] = h.buildAntigravityCurrent()
 	}
-	respondJSON(w, http.StatusOK, response)
+	return response
+}
+
+// buildProviderCurrent builds one provider's current quota response, or nil
+// for an unknown provider.
+func (h *Handler) buildProviderCurrent(provider string) map[string]interface{} {
+	switch provider {
+	case "synthetic":
+		return h.buildSyntheticCurrent()
+	case "zai":
+		return h.buildZaiCurrent()
+	case "anthropic":
+		return h.buildAnthropicCurrent()
+	case "copilot":
+		return h.buildCopilotCurrent()
+	case "codex":
+		return h.buildCodexCurrent()
+	case "antigravity":
+		return h.buildAntigravityCurrent()
+	}
+	return nil
+}
+
+// markNeverPolled flags a current response whose provider has no stored
+// snapshots yet, so the dashboard can show "waiting for first poll" instead of
+// empty cards. pollingDisabled tells that apart from a provider whose polling
+// is switched off in provider_visibility.
+func (h *Handler) markNeverPolled(response map[string]interface{}, provider string) {
+	response["neverPolled"] = true
+	response["pollingDisabled"] = !h.pollingEnabled(provider)
+}
+
+// pollingEnabled reports whether provider_visibility leaves polling on for
+// provider. Polling is on unless explicitly disabled.
+func (h *Handler) pollingEnabled(provider string) bool {
+	if h.store == nil {
+		return true
+	}
+	visJSON, _ := h.store.GetSetting("provider_visibility")
+	if visJSON == "" {
+		return true
+	}
+	var vis map[string]map[string]bool
+	if json.Unmarshal([]byte(visJSON), &vis) != nil {
+		return true
+	}
+	if polling, ok := vis[provider]["polling"]; ok {
+		return polling
+	}
+	return true
+}
+
+// NextReset handles GET /api/next-reset: the single soonest upcoming reset
+// across every configured provider and quota, read from the latest
+// snapshots. The body is null when no provider reports a reset time.
+func (h *Handler) NextReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	now := time.Now().UTC()
+	var next *quotaLevel
+	levels := h.currentQuotaLevels()
+	for i := range levels {
+		level := &levels[i]
+		if level.ResetsAt == nil || !level.ResetsAt.After(now) {
+			continue
+		}
+		if next == nil || level.ResetsAt.Before(*next.ResetsAt) {
+			next = level
+		}
+	}
+	if next == nil {
+		respondJSON(w, http.StatusOK, nil)
+		return
+	}
+
+	until := next.ResetsAt.Sub(now)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"provider":              next.Provider,
+		"providerName":          providerDisplayNames[next.Provider],
+		"quota":                 next.Quota,
+		"name":                  next.Name,
+		"percent":               next.Percent,
+		"status":                next.Status,
+		"resetsAt":              next.ResetsAt.UTC().Format(time.RFC3339),
+		"timeUntilReset":        formatDuration(until),
+		"timeUntilResetSeconds": int64(until.Seconds()),
+	})
+}
+
+// quotaLevel is one quota's usage as reported by the current quota builders,
+// normalized across providers.
+type quotaLevel struct {
+	Provider string
+	Quota    string // quota key, e.g. "subscription" or "five_hour"
+	Name     string // display name
+	Percent  float64
+	Status   string
+	ResetsAt *time.Time // nil when the provider reports no upcoming reset
+}
+
+// currentQuotaLevels flattens buildAllCurrent into one quotaLevel per quota,
+// ordered by provider. Quotas without data, without a limit, or unlimited
+// are left out.
+func (h *Handler) currentQuotaLevels() []quotaLevel {
+	current := h.buildAllCurrent()
+	providers := make([]string, 0, len(current))
+	for p := range current {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+
+	var levels []quotaLevel
+	for _, provider := range providers {
+		if resp, ok := current[provider].(map[string]interface{}); ok {
+			levels = append(levels, providerQuotaLevels(provider, resp)...)
+		}
+	}
+	return levels
+}
+
+// providerQuotaLevels reads the quotas of one provider's current quota
+// response.
+func providerQuotaLevels(provider string, resp map[string]interface{}) []quotaLevel {
+	var levels []quotaLevel
+	// Providers with a dynamic set of quotas list them under "quotas";
+	// the others keep each quota under its own key
+	list, _ := resp["quotas"].([]map[string]interface{})
+	for _, m := range list {
+		if level, ok := quotaLevelFrom(provider, "", m); ok {
+			levels = append(levels, level)
+		}
+	}
+	keys := make([]string, 0, len(resp))
+	for key := range resp {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if m, ok := resp[key].(map[string]interface{}); ok {
+			if level, ok := quotaLevelFrom(provider, key, m); ok {
+				levels = append(levels, level)
+			}
+		}
+	}
+	return levels
+}
+
+// quotaLevelFrom reads a quota map from a current quota builder, reporting
+// false for maps that are not quotas or carry no usable data. key is the
+// map's key in the provider response, or empty for entries of "quotas".
+func quotaLevelFrom(provider, key string, m map[string]interface{}) (quotaLevel, bool) {
+	level := quotaLevel{Provider: provider, Quota: key}
+	found := false
+	for _, k := range []string{"percent", "utilization", "usagePercent"} {
+		if v, ok := m[k].(float64); ok {
+			level.Percent, found = v, true
+			break
+		}
+	}
+	if !found {
+		return level, false
+	}
+	if unlimited, _ := m["unlimited"].(bool); unlimited {
+		return level, false
+	}
+	if limit, ok := m["limit"].(float64); ok && limit <= 0 {
+		return level, false
+	}
+
+	if level.Quota == "" {
+		level.Quota, _ = m["quotaGroup"].(string)
+	}
+	if level.Quota == "" {
+		level.Quota, _ = m["name"].(string)
+	}
+	level.Name = level.Quota
+	for _, k := range []string{"displayName", "name", "label"} {
+		if v, ok := m[k].(string); ok && v != "" {
+			level.Name = v
+			break
+		}
+	}
+	level.Status, _ = m["status"].(string)
+
+	// Builders without a real reset report zero seconds until it
+	if secs, _ := m["timeUntilResetSeconds"].(int64); secs > 0 {
+		for _, k := range []string{"resetsAt", "renewsAt", "resetDate", "resetTime"} {
+			if v, ok := m[k].(string); ok {
+				if t, err := time.Parse(time.RFC3339, v); err == nil {
+					level.ResetsAt = &t
+					break
+				}
+			}
+		}
+	}
+	return level, true
 }
 
 // currentSynthetic returns Synthetic quota status
 func (h *Handler) currentSynthetic(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, h.buildSyntheticCurrent())
+	resp := h.buildSyntheticCurrent()
+	if wantSparklines(r) {
+		h.addSparklines("synthetic", resp)
+	}
+	respondJSON(w, http.StatusOK, h.numberFormat().addDisplayFields(resp))
+}
+
+// newerPolled returns provider's in-memory snapshot when it was captured after
+// stored, the latest persisted snapshot; otherwise stored. Snapshots are only
+// written every store interval, so the polled one is often fresher.
+func newerPolled[T any](latest *agent.LatestSnapshots, provider string, stored *T, capturedAt func(*T) time.Time) *T {
+	polled, ok := latest.Get(provider).(*T)
+	if !ok || polled == nil {
+		return stored
+	}
+	if stored == nil || capturedAt(polled).After(capturedAt(stored)) {
+		return polled
+	}
+	return stored
 }
 
 // buildSyntheticCurrent builds the Synthetic current quota response map.
@@ -532,6 +1201,7 @@ func (h *Handler) buildSyntheticCurrent() map[string]interface{} {
 		"subscription": buildEmptyQuotaResponse("Subscription", "Main API request quota for your plan"),
 		"search":       buildEmptyQuotaResponse("Search (Hourly)", "Search endpoint calls, resets every hour"),
 		"toolCalls":    buildEmptyQuotaResponse("Tool Call Discounts", "Discounted tool call requests"),
+		"neverPolled":  false,
 	}
 
 	if h.store != nil && h.tracker != nil {
@@ -540,12 +1210,25 @@ func (h *Handler) buildSyntheticCurrent() map[string]interface{} {
 			h.logger.Error("failed to query latest snapshot", "error", err)
 			return response
 		}
+		latest = newerPolled(h.latest, "synthetic", latest, func(s *api.Snapshot) time.Time { return s.CapturedAt })
+		if latest == nil {
+			h.markNeverPolled(response, "synthetic")
+		}
 
 		if latest != nil {
 			response["capturedAt"] = latest.CapturedAt.Format(time.RFC3339)
 			response["subscription"] = buildQuotaResponse("Subscription", "Main API request quota for your plan", latest.Sub, h.tracker, "subscription")
 			response["search"] = buildQuotaResponse("Search (Hourly)", "Search endpoint calls, resets every hour", latest.Search, h.tracker, "search")
 			response["toolCalls"] = buildQuotaResponse("Tool Call Discounts", "Discounted tool call requests", latest.ToolCall, h.tracker, "toolcall")
+			// Only plans with prepaid credits report a balance; without one
+			// the credits card is left out.
+			if latest.CreditsBalance != nil {
+				response["credits"] = map[string]interface{}{
+					"name":        "Credits",
+					"description": "Prepaid credit balance",
+					"balance":     *latest.CreditsBalance,
+				}
+			}
 		}
 	}
 
@@ -554,7 +1237,11 @@ func (h *Handler) buildSyntheticCurrent() map[string]interface{} {
 
 // currentZai returns Z.ai quota status
 func (h *Handler) currentZai(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, h.buildZaiCurrent())
+	resp := h.buildZaiCurrent()
+	if wantSparklines(r) {
+		h.addSparklines("zai", resp)
+	}
+	respondJSON(w, http.StatusOK, h.numberFormat().addDisplayFields(resp))
 }
 
 // buildZaiCurrent builds the Z.ai current quota response map.
@@ -565,6 +1252,7 @@ func (h *Handler) buildZaiCurrent() map[string]interface{} {
 		"tokensLimit": buildEmptyZaiQuotaResponse("Tokens Limit", "Token consumption budget"),
 		"timeLimit":   buildEmptyZaiQuotaResponse("Time Limit", "Tool call time budget"),
 		"toolCalls":   buildEmptyZaiQuotaResponse("Tool Calls", "Individual tool call breakdown"),
+		"neverPolled": false,
 	}
 
 	if h.store != nil {
@@ -573,6 +1261,10 @@ func (h *Handler) buildZaiCurrent() map[string]interface{} {
 			h.logger.Error("failed to query latest Z.ai snapshot", "error", err)
 			return response
 		}
+		latest = newerPolled(h.latest, "zai", latest, func(s *api.ZaiSnapshot) time.Time { return s.CapturedAt })
+		if latest == nil {
+			h.markNeverPolled(response, "zai")
+		}
 
 		if latest != nil {
 			response["capturedAt"] = latest.CapturedAt.Format(time.RFC3339)
@@ -584,10 +1276,12 @@ func (h *Handler) buildZaiCurrent() map[string]interface{} {
 				if tokensSummary, err := h.zaiTracker.UsageSummary("tokens"); err == nil && tokensSummary != nil {
 					tokensResp["currentRate"] = tokensSummary.CurrentRate
 					tokensResp["projectedUsage"] = tokensSummary.ProjectedUsage
+					addProjectionConfidence(tokensResp, tokensSummary.ProjectionConfidence)
 				}
 				if timeSummary, err := h.zaiTracker.UsageSummary("time"); err == nil && timeSummary != nil {
 					timeResp["currentRate"] = timeSummary.CurrentRate
 					timeResp["projectedUsage"] = timeSummary.ProjectedUsage
+					addProjectionConfidence(timeResp, timeSummary.ProjectionConfidence)
 				}
 			}
 
@@ -637,14 +1331,7 @@ func buildZaiTokensQuotaResponse(snapshot *api.ZaiSnapshot) map[string]interface
 	currentUsage := snapshot.TokensCurrentValue // API's "currentValue" = actual usage
 	percent := float64(snapshot.TokensPercentage)
 
-	status := "healthy"
-	if percent >= 95 {
-		status = "critical"
-	} else if percent >= 80 {
-		status = "danger"
-	} else if percent >= 50 {
-		status = "warning"
-	}
+	status := usageStatus(percent)
 
 	result := map[string]interface{}{
 		"name":        "Tokens Limit",
@@ -675,14 +1362,7 @@ func buildZaiTimeQuotaResponse(snapshot *api.ZaiSnapshot) map[string]interface{}
 	currentUsage := snapshot.TimeCurrentValue // API's "currentValue" = actual usage
 	percent := float64(snapshot.TimePercentage)
 
-	status := "healthy"
-	if percent >= 95 {
-		status = "critical"
-	} else if percent >= 80 {
-		status = "danger"
-	} else if percent >= 50 {
-		status = "warning"
-	}
+	status := usageStatus(percent)
 
 	return map[string]interface{}{
 		"name":                  "Time Limit",
@@ -715,14 +1395,7 @@ func buildZaiToolCallsResponse(snapshot *api.ZaiSnapshot) map[string]interface{}
 		percent = (totalCalls / budget) * 100
 	}
 
-	status := "healthy"
-	if percent >= 95 {
-		status = "critical"
-	} else if percent >= 80 {
-		status = "danger"
-	} else if percent >= 50 {
-		status = "warning"
-	}
+	status := usageStatus(percent)
 
 	result := map[string]interface{}{
 		"name":                  "Tool Calls",
@@ -767,14 +1440,7 @@ func buildQuotaResponse(name, description string, info api.QuotaInfo, tr *tracke
 		percent = (info.Requests / info.Limit) * 100
 	}
 
-	status := "healthy"
-	if percent >= 95 {
-		status = "critical"
-	} else if percent >= 80 {
-		status = "danger"
-	} else if percent >= 50 {
-		status = "warning"
-	}
+	status := usageStatus(percent)
 
 	result := map[string]interface{}{
 		"name":                  name,
@@ -832,6 +1498,10 @@ func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if _, _, err := parseChartSmoothing(r); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	switch provider {
 	case "both":
@@ -866,17 +1536,17 @@ func (h *Handler) historyBoth(w http.ResponseWriter, r *http.Request) {
 
 	now := time.Now().UTC()
 	start := now.Add(-duration)
+	if r.URL.Query().Get("normalized") == "true" {
+		h.historyNormalized(w, start, now)
+		return
+	}
+	smooth, smoothFactor, _ := parseChartSmoothing(r)
 
-	if h.config.HasProvider("synthetic") && h.store != nil {
+	if h.inBoth("synthetic") && h.store != nil {
 		snapshots, err := h.store.QueryRange(start, now)
 		if err == nil {
-			step := downsampleStep(len(snapshots), maxChartPoints)
-			last := len(snapshots) - 1
-			synData := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-			for i, s := range snapshots {
-				if step > 1 && i != 0 && i != last && i%step != 0 {
-					continue
-				}
+			synData := make([]map[string]interface{}, 0, len(snapshots))
+			for _, s := range snapshots {
 				subPct, searchPct, toolPct := 0.0, 0.0, 0.0
 				if s.Sub.Limit > 0 {
 					subPct = (s.Sub.Requests / s.Sub.Limit) * 100
@@ -887,7 +1557,7 @@ func (h *Handler) historyBoth(w http.ResponseWriter, r *http.Request) {
 				if s.ToolCall.Limit > 0 {
 					toolPct = (s.ToolCall.Requests / s.ToolCall.Limit) * 100
 				}
-				synData = append(synData, map[string]interface{}{
+				point := map[string]interface{}{
 					"capturedAt":          s.CapturedAt.Format(time.RFC3339),
 					"subscription":        s.Sub.Requests,
 					"subscriptionLimit":   s.Sub.Limit,
@@ -898,23 +1568,29 @@ func (h *Handler) historyBoth(w http.ResponseWriter, r *http.Request) {
 					"toolCalls":           s.ToolCall.Requests,
 					"toolCallsLimit":      s.ToolCall.Limit,
 					"toolCallsPercent":    toolPct,
-				})
+				}
+				if s.Injected {
+					point["injected"] = true
+				}
+				synData = append(synData, point)
+			}
+			if smooth {
+				synData = smoothChartPoints(synData, smoothFactor)
+			}
+			synData = downsamplePoints(synData, h.chartMaxPoints())
+			if wantChartGaps(r) {
+				synData = breakChartGaps(synData, h.chartGapThreshold(len(snapshots)))
 			}
 			response["synthetic"] = synData
 		}
 	}
 
-	if h.config.HasProvider("zai") && h.store != nil {
+	if h.inBoth("zai") && h.store != nil {
 		snapshots, err := h.store.QueryZaiRange(start, now)
 		if err == nil {
-			step := downsampleStep(len(snapshots), maxChartPoints)
-			last := len(snapshots) - 1
-			zaiData := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-			for i, s := range snapshots {
-				if step > 1 && i != 0 && i != last && i%step != 0 {
-					continue
-				}
-				zaiData = append(zaiData, map[string]interface{}{
+			zaiData := make([]map[string]interface{}, 0, len(snapshots))
+			for _, s := range snapshots {
+				point := map[string]interface{}{
 					"capturedAt":       s.CapturedAt.Format(time.RFC3339),
 					"tokensLimit":      s.TokensUsage,
 					"tokensUsage":      s.TokensCurrentValue,
@@ -923,44 +1599,55 @@ func (h *Handler) historyBoth(w http.ResponseWriter, r *http.Request) {
 					"timeUsage":        s.TimeCurrentValue,
 					"timePercent":      float64(s.TimePercentage),
 					"toolCallsPercent": zaiToolCallsPercent(s),
-				})
+				}
+				if s.Injected {
+					point["injected"] = true
+				}
+				zaiData = append(zaiData, point)
+			}
+			if smooth {
+				zaiData = smoothChartPoints(zaiData, smoothFactor)
+			}
+			zaiData = downsamplePoints(zaiData, h.chartMaxPoints())
+			if wantChartGaps(r) {
+				zaiData = breakChartGaps(zaiData, h.chartGapThreshold(len(snapshots)))
 			}
 			response["zai"] = zaiData
 		}
 	}
 
-	if h.config.HasProvider("anthropic") && h.store != nil {
+	if h.inBoth("anthropic") && h.store != nil {
 		snapshots, err := h.store.QueryAnthropicRange(start, now)
 		if err == nil {
-			step := downsampleStep(len(snapshots), maxChartPoints)
-			last := len(snapshots) - 1
-			anthData := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-			for i, snap := range snapshots {
-				if step > 1 && i != 0 && i != last && i%step != 0 {
-					continue
-				}
+			anthData := make([]map[string]interface{}, 0, len(snapshots))
+			for _, snap := range snapshots {
 				entry := map[string]interface{}{
 					"capturedAt": snap.CapturedAt.Format(time.RFC3339),
 				}
 				for _, q := range snap.Quotas {
 					entry[q.Name] = q.Utilization
 				}
+				if snap.Injected {
+					entry["injected"] = true
+				}
 				anthData = append(anthData, entry)
 			}
+			if smooth {
+				anthData = smoothChartPoints(anthData, smoothFactor)
+			}
+			anthData = downsamplePoints(anthData, h.chartMaxPoints())
+			if wantChartGaps(r) {
+				anthData = breakChartGaps(anthData, h.chartGapThreshold(len(snapshots)))
+			}
 			response["anthropic"] = anthData
 		}
 	}
 
-	if h.config.HasProvider("copilot") && h.store != nil {
+	if h.inBoth("copilot") && h.store != nil {
 		snapshots, err := h.store.QueryCopilotRange(start, now)
 		if err == nil {
-			step := downsampleStep(len(snapshots), maxChartPoints)
-			last := len(snapshots) - 1
-			copData := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-			for i, snap := range snapshots {
-				if step > 1 && i != 0 && i != last && i%step != 0 {
-					continue
-				}
+			copData := make([]map[string]interface{}, 0, len(snapshots))
+			for _, snap := range snapshots {
 				entry := map[string]interface{}{
 					"capturedAt": snap.CapturedAt.Format(time.RFC3339),
 				}
@@ -971,20 +1658,22 @@ func (h *Handler) historyBoth(w http.ResponseWriter, r *http.Request) {
 				}
 				copData = append(copData, entry)
 			}
+			if smooth {
+				copData = smoothChartPoints(copData, smoothFactor)
+			}
+			copData = downsamplePoints(copData, h.chartMaxPoints())
+			if wantChartGaps(r) {
+				copData = breakChartGaps(copData, h.chartGapThreshold(len(snapshots)))
+			}
 			response["copilot"] = copData
 		}
 	}
 
-	if h.config.HasProvider("codex") && h.store != nil {
+	if h.inBoth("codex") && h.store != nil {
 		snapshots, err := h.store.QueryCodexRange(start, now)
 		if err == nil {
-			step := downsampleStep(len(snapshots), maxChartPoints)
-			last := len(snapshots) - 1
-			codexData := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-			for i, snap := range snapshots {
-				if step > 1 && i != 0 && i != last && i%step != 0 {
-					continue
-				}
+			codexData := make([]map[string]interface{}, 0, len(snapshots))
+			for _, snap := range snapshots {
 				entry := map[string]interface{}{
 					"capturedAt": snap.CapturedAt.Format(time.RFC3339),
 				}
@@ -993,6 +1682,13 @@ func (h *Handler) historyBoth(w http.ResponseWriter, r *http.Request) {
 				}
 				codexData = append(codexData, entry)
 			}
+			if smooth {
+				codexData = smoothChartPoints(codexData, smoothFactor)
+			}
+			codexData = downsamplePoints(codexData, h.chartMaxPoints())
+			if wantChartGaps(r) {
+				codexData = breakChartGaps(codexData, h.chartGapThreshold(len(snapshots)))
+			}
 			response["codex"] = codexData
 		}
 	}
@@ -1000,7 +1696,269 @@ func (h *Handler) historyBoth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, response)
 }
 
-// historySynthetic returns Synthetic usage history
+// percentSample is one snapshot's quota usage percentages, keyed by quota.
+type percentSample struct {
+	at     time.Time
+	values map[string]float64
+}
+
+// normalizedSeries is one provider quota in the normalized history.
+type normalizedSeries struct {
+	provider string
+	quota    string
+	label    string
+}
+
+// providerDisplayNames are the provider names shown to users.
+var providerDisplayNames = map[string]string{
+	"synthetic":   "Synthetic",
+	"zai":         "Z.ai",
+	"anthropic":   "Anthropic",
+	"copilot":     "Copilot",
+	"codex":       "Codex",
+	"antigravity": "Antigravity",
+}
+
+// historyNormalized writes the ?normalized=true form of the "both" history:
+// every configured provider's quotas, less both_exclude, as 0-100% usage on one shared time axis,
+// so they can be overlaid on a single chart. Samples are averaged into buckets
+// as wide as the coarsest provider's polling cadence; buckets a quota has no
+// sample for are null.
+func (h *Handler) historyNormalized(w http.ResponseWriter, start, end time.Time) {
+	samples, series := h.percentHistory(start, end)
+	excluded := h.bothExclude()
+	for _, p := range excluded {
+		delete(samples, p)
+	}
+	series = slices.DeleteFunc(series, func(s normalizedSeries) bool {
+		return slices.Contains(excluded, s.provider)
+	})
+
+	interval := normalizedBucketInterval(samples, end.Sub(start), h.chartMaxPoints())
+	n := int(end.Sub(start)/interval) + 1
+	labels := make([]string, n)
+	for i := range labels {
+		labels[i] = start.Add(time.Duration(i) * interval).Format(time.RFC3339)
+	}
+
+	datasets := make([]map[string]interface{}, 0, len(series))
+	for _, ser := range series {
+		sums := make([]float64, n)
+		counts := make([]int, n)
+		for _, s := range samples[ser.provider] {
+			v, ok := s.values[ser.quota]
+			i := int(s.at.Sub(start) / interval)
+			if !ok || i < 0 || i >= n {
+				continue
+			}
+			sums[i] += v
+			counts[i]++
+		}
+		data := make([]interface{}, n)
+		for i := range data {
+			if counts[i] > 0 {
+				data[i] = math.Min(math.Max(sums[i]/float64(counts[i]), 0), 100)
+			}
+		}
+		datasets = append(datasets, map[string]interface{}{
+			"provider": ser.provider,
+			"quota":    ser.quota,
+			"label":    ser.label,
+			"data":     data,
+		})
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"normalized":      true,
+		"intervalSeconds": int64(interval.Seconds()),
+		"labels":          labels,
+		"datasets":        datasets,
+	})
+}
+
+// normalizedBucketInterval picks the shared bucket width for a normalized
+// history: the coarsest provider cadence (median gap between its samples),
+// widened so the span fits in at most maxPoints buckets, rounded up to a
+// whole minute.
+func normalizedBucketInterval(samples map[string][]percentSample, span time.Duration, maxPoints int) time.Duration {
+	interval := time.Minute
+	for _, ss := range samples {
+		if len(ss) < 2 {
+			continue
+		}
+		gaps := make([]time.Duration, 0, len(ss)-1)
+		for i := 1; i < len(ss); i++ {
+			gaps = append(gaps, ss[i].at.Sub(ss[i-1].at))
+		}
+		slices.Sort(gaps)
+		interval = max(interval, gaps[len(gaps)/2])
+	}
+	if maxPoints > 0 {
+		interval = max(interval, span/time.Duration(maxPoints))
+	}
+	if rem := interval % time.Minute; rem != 0 {
+		interval += time.Minute - rem
+	}
+	return interval
+}
+
+// percentHistory collects every configured provider's quota usage between
+// start and end as 0-100% samples, with one series per quota seen. Used by
+// the normalized history, GraphQL and data exports.
+func (h *Handler) percentHistory(start, end time.Time) (map[string][]percentSample, []normalizedSeries) {
+	samples := map[string][]percentSample{}
+	var series []normalizedSeries
+	for _, provider := range []string{"synthetic", "zai", "anthropic", "copilot", "codex"} {
+		if !h.config.HasProvider(provider) {
+			continue
+		}
+		ss, ok := h.providerPercentSamples(provider, start, end)
+		if !ok {
+			continue
+		}
+		if len(ss) > 0 {
+			samples[provider] = ss
+		}
+		series = append(series, percentSeries(provider, ss)...)
+	}
+
+	return samples, series
+}
+
+// percentSeries returns provider's series in the normalized history: its
+// fixed quotas, or for providers with dynamic quotas every quota in samples.
+func percentSeries(provider string, samples []percentSample) []normalizedSeries {
+	var quotas []string
+	switch provider {
+	case "synthetic":
+		quotas = []string{"subscription", "search", "toolCalls"}
+	case "zai":
+		quotas = []string{"tokens", "time", "toolCalls"}
+	default:
+		keys := map[string]bool{}
+		for _, s := range samples {
+			for k := range s.values {
+				keys[k] = true
+			}
+		}
+		for k := range keys {
+			quotas = append(quotas, k)
+		}
+		sort.Strings(quotas)
+	}
+	series := make([]normalizedSeries, 0, len(quotas))
+	for _, q := range quotas {
+		series = append(series, normalizedSeries{
+			provider: provider,
+			quota:    q,
+			label:    percentSeriesLabel(provider, q),
+		})
+	}
+	return series
+}
+
+// percentSeriesLabel returns the display label of provider's quota.
+func percentSeriesLabel(provider, quota string) string {
+	var name string
+	switch provider {
+	case "synthetic":
+		name = map[string]string{"subscription": "Subscription", "search": "Search", "toolCalls": "Tool Calls"}[quota]
+	case "zai":
+		name = map[string]string{"tokens": "Tokens", "time": "Time", "toolCalls": "Tool Calls"}[quota]
+	case "anthropic":
+		name = api.AnthropicDisplayName(quota)
+	case "copilot":
+		name = api.CopilotDisplayName(quota)
+	case "codex":
+		name = api.CodexDisplayName(quota)
+	}
+	return providerDisplayNames[provider] + " · " + name
+}
+
+// providerPercentSamples reads one provider's snapshots between start and end
+// as 0-100% samples keyed by quota. It reports false when the provider has no
+// percentage history or the query fails.
+func (h *Handler) providerPercentSamples(provider string, start, end time.Time) ([]percentSample, bool) {
+	if h.store == nil {
+		return nil, false
+	}
+	var samples []percentSample
+	switch provider {
+	case "synthetic":
+		snapshots, err := h.store.QueryRange(start, end)
+		if err != nil {
+			return nil, false
+		}
+		for _, s := range snapshots {
+			values := map[string]float64{}
+			if s.Sub.Limit > 0 {
+				values["subscription"] = s.Sub.Requests / s.Sub.Limit * 100
+			}
+			if s.Search.Limit > 0 {
+				values["search"] = s.Search.Requests / s.Search.Limit * 100
+			}
+			if s.ToolCall.Limit > 0 {
+				values["toolCalls"] = s.ToolCall.Requests / s.ToolCall.Limit * 100
+			}
+			samples = append(samples, percentSample{s.CapturedAt, values})
+		}
+	case "zai":
+		snapshots, err := h.store.QueryZaiRange(start, end)
+		if err != nil {
+			return nil, false
+		}
+		for _, s := range snapshots {
+			samples = append(samples, percentSample{s.CapturedAt, map[string]float64{
+				"tokens":    float64(s.TokensPercentage),
+				"time":      float64(s.TimePercentage),
+				"toolCalls": zaiToolCallsPercent(s),
+			}})
+		}
+	case "anthropic":
+		snapshots, err := h.store.QueryAnthropicRange(start, end)
+		if err != nil {
+			return nil, false
+		}
+		for _, snap := range snapshots {
+			values := map[string]float64{}
+			for _, q := range snap.Quotas {
+				values[q.Name] = q.Utilization
+			}
+			samples = append(samples, percentSample{snap.CapturedAt, values})
+		}
+	case "copilot":
+		snapshots, err := h.store.QueryCopilotRange(start, end)
+		if err != nil {
+			return nil, false
+		}
+		for _, snap := range snapshots {
+			values := map[string]float64{}
+			for _, q := range snap.Quotas {
+				if q.Entitlement > 0 {
+					values[q.Name] = float64(q.Entitlement-q.Remaining) / float64(q.Entitlement) * 100
+				}
+			}
+			samples = append(samples, percentSample{snap.CapturedAt, values})
+		}
+	case "codex":
+		snapshots, err := h.store.QueryCodexRange(start, end)
+		if err != nil {
+			return nil, false
+		}
+		for _, snap := range snapshots {
+			values := map[string]float64{}
+			for _, q := range snap.Quotas {
+				values[q.Name] = q.Utilization
+			}
+			samples = append(samples, percentSample{snap.CapturedAt, values})
+		}
+	default:
+		return nil, false
+	}
+	return samples, true
+}
+
+// historySynthetic returns Synthetic usage history
 func (h *Handler) historySynthetic(w http.ResponseWriter, r *http.Request) {
 	if h.store == nil {
 		respondJSON(w, http.StatusOK, []interface{}{})
@@ -1025,13 +1983,9 @@ func (h *Handler) historySynthetic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	step := downsampleStep(len(snapshots), maxChartPoints)
-	last := len(snapshots) - 1
-	response := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-	for i, snapshot := range snapshots {
-		if step > 1 && i != 0 && i != last && i%step != 0 {
-			continue
-		}
+	smooth, smoothFactor, _ := parseChartSmoothing(r)
+	response := make([]map[string]interface{}, 0, len(snapshots))
+	for _, snapshot := range snapshots {
 
 		subPercent := 0.0
 		if snapshot.Sub.Limit > 0 {
@@ -1048,7 +2002,7 @@ func (h *Handler) historySynthetic(w http.ResponseWriter, r *http.Request) {
 			toolPercent = (snapshot.ToolCall.Requests / snapshot.ToolCall.Limit) * 100
 		}
 
-		response = append(response, map[string]interface{}{
+		point := map[string]interface{}{
 			"capturedAt":          snapshot.CapturedAt.Format(time.RFC3339),
 			"subscription":        snapshot.Sub.Requests,
 			"subscriptionLimit":   snapshot.Sub.Limit,
@@ -1059,9 +2013,20 @@ func (h *Handler) historySynthetic(w http.ResponseWriter, r *http.Request) {
 			"toolCalls":           snapshot.ToolCall.Requests,
 			"toolCallsLimit":      snapshot.ToolCall.Limit,
 			"toolCallsPercent":    toolPercent,
-		})
+		}
+		if snapshot.Injected {
+			point["injected"] = true // fed through /api/debug/snapshot
+		}
+		response = append(response, point)
 	}
 
+	if smooth {
+		response = smoothChartPoints(response, smoothFactor)
+	}
+	response = downsamplePoints(response, h.chartMaxPoints())
+	if wantChartGaps(r) {
+		response = breakChartGaps(response, h.chartGapThreshold(len(snapshots)))
+	}
 	respondJSON(w, http.StatusOK, response)
 }
 
@@ -1090,15 +2055,11 @@ func (h *Handler) historyZai(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	step := downsampleStep(len(snapshots), maxChartPoints)
-	last := len(snapshots) - 1
-	response := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-	for i, snapshot := range snapshots {
-		if step > 1 && i != 0 && i != last && i%step != 0 {
-			continue
-		}
+	smooth, smoothFactor, _ := parseChartSmoothing(r)
+	response := make([]map[string]interface{}, 0, len(snapshots))
+	for _, snapshot := range snapshots {
 		// Z.ai API: "usage" = budget, "currentValue" = actual usage, "percentage" = server %
-		response = append(response, map[string]interface{}{
+		point := map[string]interface{}{
 			"capturedAt":       snapshot.CapturedAt.Format(time.RFC3339),
 			"tokensLimit":      snapshot.TokensUsage,        // budget
 			"tokensUsage":      snapshot.TokensCurrentValue, // actual usage
@@ -1107,9 +2068,20 @@ func (h *Handler) historyZai(w http.ResponseWriter, r *http.Request) {
 			"timeUsage":        snapshot.TimeCurrentValue, // actual usage
 			"timePercent":      float64(snapshot.TimePercentage),
 			"toolCallsPercent": zaiToolCallsPercent(snapshot),
-		})
+		}
+		if snapshot.Injected {
+			point["injected"] = true
+		}
+		response = append(response, point)
 	}
 
+	if smooth {
+		response = smoothChartPoints(response, smoothFactor)
+	}
+	response = downsamplePoints(response, h.chartMaxPoints())
+	if wantChartGaps(r) {
+		response = breakChartGaps(response, h.chartGapThreshold(len(snapshots)))
+	}
 	respondJSON(w, http.StatusOK, response)
 }
 
@@ -1149,58 +2121,88 @@ func (h *Handler) cyclesBoth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.config.HasProvider("synthetic") {
+	limit, err := parseCycleLimit(r, 0)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Without a limit each provider keeps its own default
+	historyLimit := func(def int) int {
+		if limit > 0 {
+			return limit
+		}
+		return def
+	}
+
+	if h.inBoth("synthetic") {
 		quotaType := r.URL.Query().Get("type")
 		if quotaType == "" {
 			quotaType = "subscription"
 		}
 		var synCycles []map[string]interface{}
 		if active, err := h.store.QueryActiveCycle(quotaType); err == nil && active != nil {
-			synCycles = append(synCycles, cycleToMap(active))
+			synCycles = append(synCycles, h.withCycleStats(cycleToMap(active), "synthetic", active.QuotaType, active.CycleStart, active.CycleEnd))
 		}
-		if history, err := h.store.QueryCycleHistory(quotaType, 50); err == nil {
+		if history, err := h.store.QueryCycleHistory(quotaType, historyLimit(50)); err == nil {
 			for _, c := range history {
-				synCycles = append(synCycles, cycleToMap(c))
+				synCycles = append(synCycles, h.withCycleStats(cycleToMap(c), "synthetic", c.QuotaType, c.CycleStart, c.CycleEnd))
 			}
 		}
 		response["synthetic"] = synCycles
 	}
 
-	if h.config.HasProvider("zai") {
+	if h.inBoth("zai") {
 		zaiType := r.URL.Query().Get("zaiType")
 		if zaiType == "" {
 			zaiType = "tokens"
 		}
 		var zaiCycles []map[string]interface{}
 		if active, err := h.store.QueryActiveZaiCycle(zaiType); err == nil && active != nil {
-			zaiCycles = append(zaiCycles, zaiCycleToMap(active))
+			zaiCycles = append(zaiCycles, h.withCycleStats(zaiCycleToMap(active), "zai", active.QuotaType, active.CycleStart, active.CycleEnd))
 		}
-		if history, err := h.store.QueryZaiCycleHistory(zaiType, 50); err == nil {
+		if history, err := h.store.QueryZaiCycleHistory(zaiType, historyLimit(50)); err == nil {
 			for _, c := range history {
-				zaiCycles = append(zaiCycles, zaiCycleToMap(c))
+				zaiCycles = append(zaiCycles, h.withCycleStats(zaiCycleToMap(c), "zai", c.QuotaType, c.CycleStart, c.CycleEnd))
 			}
 		}
 		response["zai"] = zaiCycles
 	}
 
-	if h.config.HasProvider("anthropic") {
+	if h.inBoth("anthropic") {
 		anthType := r.URL.Query().Get("anthropicType")
 		if anthType == "" {
 			anthType = "five_hour"
 		}
 		var anthCycles []map[string]interface{}
 		if active, err := h.store.QueryActiveAnthropicCycle(anthType); err == nil && active != nil {
-			anthCycles = append(anthCycles, anthropicCycleToMap(active))
+			anthCycles = append(anthCycles, h.withCycleStats(anthropicCycleToMap(active), "anthropic", active.QuotaName, active.CycleStart, active.CycleEnd))
 		}
-		if history, err := h.store.QueryAnthropicCycleHistory(anthType, 200); err == nil {
+		if history, err := h.store.QueryAnthropicCycleHistory(anthType, historyLimit(200)); err == nil {
 			for _, c := range history {
-				anthCycles = append(anthCycles, anthropicCycleToMap(c))
+				anthCycles = append(anthCycles, h.withCycleStats(anthropicCycleToMap(c), "anthropic", c.QuotaName, c.CycleStart, c.CycleEnd))
 			}
 		}
 		response["anthropic"] = anthCycles
 	}
 
-	if h.config.HasProvider("codex") {
+	if h.inBoth("copilot") {
+		copilotType := r.URL.Query().Get("copilotType")
+		if copilotType == "" {
+			copilotType = "premium_interactions"
+		}
+		var copilotCycles []map[string]interface{}
+		if active, err := h.store.QueryActiveCopilotCycle(copilotType); err == nil && active != nil {
+			copilotCycles = append(copilotCycles, h.withCycleStats(copilotCycleToMap(active), "copilot", active.QuotaName, active.CycleStart, active.CycleEnd))
+		}
+		if history, err := h.store.QueryCopilotCycleHistory(copilotType, historyLimit(200)); err == nil {
+			for _, c := range history {
+				copilotCycles = append(copilotCycles, h.withCycleStats(copilotCycleToMap(c), "copilot", c.QuotaName, c.CycleStart, c.CycleEnd))
+			}
+		}
+		response["copilot"] = copilotCycles
+	}
+
+	if h.inBoth("codex") {
 		codexType := r.URL.Query().Get("codexType")
 		if codexType == "" {
 			codexType = r.URL.Query().Get("type")
@@ -1210,27 +2212,27 @@ func (h *Handler) cyclesBoth(w http.ResponseWriter, r *http.Request) {
 		}
 		var codexCycles []map[string]interface{}
 		if active, err := h.store.QueryActiveCodexCycle(codexType); err == nil && active != nil {
-			codexCycles = append(codexCycles, codexCycleToMap(active))
+			codexCycles = append(codexCycles, h.withCycleStats(codexCycleToMap(active), "codex", active.QuotaName, active.CycleStart, active.CycleEnd))
 		}
-		if history, err := h.store.QueryCodexCycleHistory(codexType, 200); err == nil {
+		if history, err := h.store.QueryCodexCycleHistory(codexType, historyLimit(200)); err == nil {
 			for _, c := range history {
-				codexCycles = append(codexCycles, codexCycleToMap(c))
+				codexCycles = append(codexCycles, h.withCycleStats(codexCycleToMap(c), "codex", c.QuotaName, c.CycleStart, c.CycleEnd))
 			}
 		}
 		response["codex"] = codexCycles
 	}
 
-	if h.config.HasProvider("antigravity") {
+	if h.inBoth("antigravity") {
 		modelIDs, err := h.store.QueryAllAntigravityModelIDs()
 		if err == nil {
 			var antigravityCycles []map[string]interface{}
 			for _, modelID := range modelIDs {
 				if active, err := h.store.QueryActiveAntigravityCycle(modelID); err == nil && active != nil {
-					antigravityCycles = append(antigravityCycles, antigravityCycleToMap(active))
+					antigravityCycles = append(antigravityCycles, h.withCycleStats(antigravityCycleToMap(active), "antigravity", active.ModelID, active.CycleStart, active.CycleEnd))
 				}
-				if history, err := h.store.QueryAntigravityCycleHistory(modelID, 50); err == nil {
+				if history, err := h.store.QueryAntigravityCycleHistory(modelID, historyLimit(50)); err == nil {
 					for _, c := range history {
-						antigravityCycles = append(antigravityCycles, antigravityCycleToMap(c))
+						antigravityCycles = append(antigravityCycles, h.withCycleStats(antigravityCycleToMap(c), "antigravity", c.ModelID, c.CycleStart, c.CycleEnd))
 					}
 				}
 			}
@@ -1248,6 +2250,12 @@ func (h *Handler) cyclesSynthetic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	limit, err := parseCycleLimit(r, 200)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	quotaType := r.URL.Query().Get("type")
 	if quotaType == "" {
 		quotaType = "subscription"
@@ -1275,10 +2283,10 @@ func (h *Handler) cyclesSynthetic(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if active != nil {
-		response = append(response, cycleToMap(active))
+		response = append(response, h.withCycleStats(cycleToMap(active), "synthetic", active.QuotaType, active.CycleStart, active.CycleEnd))
 	}
 
-	history, err := h.store.QueryCycleHistory(quotaType, 200)
+	history, err := h.store.QueryCycleHistory(quotaType, limit)
 	if err != nil {
 		h.logger.Error("failed to query cycle history", "error", err)
 		respondError(w, http.StatusInternalServerError, "failed to query cycles")
@@ -1286,7 +2294,7 @@ func (h *Handler) cyclesSynthetic(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, cycle := range history {
-		response = append(response, cycleToMap(cycle))
+		response = append(response, h.withCycleStats(cycleToMap(cycle), "synthetic", cycle.QuotaType, cycle.CycleStart, cycle.CycleEnd))
 	}
 
 	respondJSON(w, http.StatusOK, response)
@@ -1299,6 +2307,12 @@ func (h *Handler) cyclesZai(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	limit, err := parseCycleLimit(r, 200)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	quotaType := r.URL.Query().Get("type")
 	if quotaType == "" {
 		quotaType = "tokens"
@@ -1325,10 +2339,10 @@ func (h *Handler) cyclesZai(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if active != nil {
-		response = append(response, zaiCycleToMap(active))
+		response = append(response, h.withCycleStats(zaiCycleToMap(active), "zai", active.QuotaType, active.CycleStart, active.CycleEnd))
 	}
 
-	history, err := h.store.QueryZaiCycleHistory(quotaType, 200)
+	history, err := h.store.QueryZaiCycleHistory(quotaType, limit)
 	if err != nil {
 		h.logger.Error("failed to query Z.ai cycle history", "error", err)
 		respondError(w, http.StatusInternalServerError, "failed to query cycles")
@@ -1336,12 +2350,41 @@ func (h *Handler) cyclesZai(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, cycle := range history {
-		response = append(response, zaiCycleToMap(cycle))
+		response = append(response, h.withCycleStats(zaiCycleToMap(cycle), "zai", cycle.QuotaType, cycle.CycleStart, cycle.CycleEnd))
 	}
 
 	respondJSON(w, http.StatusOK, response)
 }
 
+// cycleStatsKeys names the average and median fields of each provider's
+// cycle maps after the unit of its peak field.
+var cycleStatsKeys = map[string][2]string{
+	"synthetic":   {"avgRequests", "medianRequests"},
+	"zai":         {"avgRequests", "medianRequests"},
+	"anthropic":   {"avgUtilization", "medianUtilization"},
+	"copilot":     {"avgUsed", "medianUsed"},
+	"codex":       {"avgUtilization", "medianUtilization"},
+	"antigravity": {"avgUsage", "medianUsage"},
+}
+
+// withCycleStats adds the average and median usage over a cycle's snapshots
+// to its map. They are null when the cycle has no snapshots.
+func (h *Handler) withCycleStats(result map[string]interface{}, provider, quota string, start time.Time, end *time.Time) map[string]interface{} {
+	keys := cycleStatsKeys[provider]
+	result[keys[0]] = nil
+	result[keys[1]] = nil
+	stats, err := h.store.QueryCycleStats(provider, quota, start, end)
+	if err != nil {
+		h.logger.Error("failed to query cycle stats", "provider", provider, "error", err)
+		return result
+	}
+	if stats.Samples > 0 {
+		result[keys[0]] = stats.Avg
+		result[keys[1]] = stats.Median
+	}
+	return result
+}
+
 func cycleToMap(cycle *store.ResetCycle) map[string]interface{} {
 	result := map[string]interface{}{
 		"id":           cycle.ID,
@@ -1388,6 +2431,10 @@ func (h *Handler) Summary(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if quota := r.URL.Query().Get("quota"); quota != "" {
+		h.summaryQuota(w, provider, quota)
+		return
+	}
 
 	switch provider {
 	case "both":
@@ -1402,50 +2449,94 @@ func (h *Handler) Summary(w http.ResponseWriter, r *http.Request) {
 		h.summaryCopilot(w, r)
 	case "codex":
 		h.summaryCodex(w, r)
+	case "antigravity":
+		h.summaryAntigravity(w, r)
 	default:
 		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown provider: %s", provider))
 	}
 }
 
+// summaryQuotaKeys maps the quota names /api/summary?quota= accepts to
+// summary response keys, for providers with a fixed set of quotas. Other
+// providers accept the quotas they currently track, keyed by name.
+var summaryQuotaKeys = map[string]map[string]string{
+	"synthetic": {"subscription": "subscription", "search": "search", "toolcall": "toolCalls"},
+	"zai":       {"tokens": "tokensLimit", "time": "timeLimit"},
+	"codex":     {"five_hour": "five_hour", "seven_day": "seven_day", "code_review": "code_review"},
+}
+
+// summaryQuota responds with a single quota's summary object, for widgets
+// that show one quota. Unknown quota names are a 400; a known quota with no
+// tracked data yet is a 404.
+func (h *Handler) summaryQuota(w http.ResponseWriter, provider, quota string) {
+	var summaries map[string]interface{}
+	switch provider {
+	case "synthetic":
+		summaries = h.buildSyntheticSummaryMap()
+	case "zai":
+		summaries = h.buildZaiSummaryMap()
+	case "anthropic":
+		summaries = h.buildAnthropicSummaryMap()
+	case "copilot":
+		summaries = h.buildCopilotSummaryMap()
+	case "codex":
+		summaries = h.buildCodexSummaryMap()
+	case "antigravity":
+		summaries = h.buildAntigravitySummaryMap()
+	default:
+		respondError(w, http.StatusBadRequest, "quota requires a single provider")
+		return
+	}
+
+	key := quota
+	if keys, ok := summaryQuotaKeys[provider]; ok {
+		if key, ok = keys[quota]; !ok {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid quota: %s", quota))
+			return
+		}
+	} else if _, ok := summaries[quota]; !ok {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid quota: %s", quota))
+		return
+	}
+	summary, ok := summaries[key]
+	if !ok {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("no summary for quota: %s", quota))
+		return
+	}
+	respondJSON(w, http.StatusOK, summary)
+}
+
 // summaryBoth returns combined summaries from all configured providers.
 func (h *Handler) summaryBoth(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{}
-	if h.config.HasProvider("synthetic") {
-		synResp := map[string]interface{}{
-			"subscription": buildEmptySummaryResponse("subscription"),
-			"search":       buildEmptySummaryResponse("search"),
-			"toolCalls":    buildEmptySummaryResponse("toolcall"),
-		}
-		if h.store != nil && h.tracker != nil {
-			for _, qt := range []string{"subscription", "search", "toolcall"} {
-				if s, err := h.tracker.UsageSummary(qt); err == nil && s != nil {
-					key := qt
-					if qt == "toolcall" {
-						key = "toolCalls"
-					}
-					synResp[key] = buildSummaryResponse(s)
-				}
-			}
-		}
-		response["synthetic"] = synResp
+	if h.inBoth("synthetic") {
+		response["synthetic"] = h.buildSyntheticSummaryMap()
 	}
-	if h.config.HasProvider("zai") {
+	if h.inBoth("zai") {
 		response["zai"] = h.buildZaiSummaryMap()
 	}
-	if h.config.HasProvider("anthropic") {
+	if h.inBoth("anthropic") {
 		response["anthropic"] = h.buildAnthropicSummaryMap()
 	}
-	if h.config.HasProvider("copilot") {
+	if h.inBoth("copilot") {
 		response["copilot"] = h.buildCopilotSummaryMap()
 	}
-	if h.config.HasProvider("codex") {
+	if h.inBoth("codex") {
 		response["codex"] = h.buildCodexSummaryMap()
 	}
+	if h.inBoth("antigravity") {
+		response["antigravity"] = h.buildAntigravitySummaryMap()
+	}
 	respondJSON(w, http.StatusOK, response)
 }
 
 // summarySynthetic returns Synthetic usage summary
 func (h *Handler) summarySynthetic(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.buildSyntheticSummaryMap())
+}
+
+// buildSyntheticSummaryMap builds the Synthetic summary response.
+func (h *Handler) buildSyntheticSummaryMap() map[string]interface{} {
 	response := map[string]interface{}{
 		"subscription": buildEmptySummaryResponse("subscription"),
 		"search":       buildEmptySummaryResponse("search"),
@@ -1465,7 +2556,7 @@ func (h *Handler) summarySynthetic(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	respondJSON(w, http.StatusOK, response)
+	return response
 }
 
 // summaryZai returns Z.ai usage summary
@@ -1522,6 +2613,7 @@ func buildEmptySummaryResponse(quotaType string) map[string]interface{} {
 		"peakCycle":       0.0,
 		"totalTracked":    0.0,
 		"trackingSince":   nil,
+		"cyclePace":       nil,
 	}
 }
 
@@ -1541,6 +2633,7 @@ func buildSummaryResponse(summary *tracker.Summary) map[string]interface{} {
 		"totalTracked":    summary.TotalTracked,
 		"trackingSince":   nil,
 	}
+	addCyclePace(result, summary.Pace)
 
 	if !summary.TrackingSince.IsZero() {
 		result["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
@@ -1562,6 +2655,7 @@ func buildEmptyZaiSummaryResponse(quotaType string) map[string]interface{} {
 		"peakCycle":       0.0,
 		"totalTracked":    0.0,
 		"trackingSince":   nil,
+		"cyclePace":       nil,
 	}
 }
 
@@ -1633,6 +2727,8 @@ func buildZaiTrackerSummaryResponse(summary *tracker.ZaiSummary) map[string]inte
 		"totalTracked":    summary.TotalTracked,
 		"trackingSince":   nil,
 	}
+	addCyclePace(result, summary.Pace)
+	addProjectionConfidence(result, summary.ProjectionConfidence)
 
 	if summary.RenewsAt != nil {
 		result["renewsAt"] = summary.RenewsAt.Format(time.RFC3339)
@@ -1732,28 +2828,118 @@ func (h *Handler) sessionsBoth(w http.ResponseWriter, r *http.Request) {
 		return list
 	}
 
-	if h.config.HasProvider("synthetic") {
+	if h.inBoth("synthetic") {
 		response["synthetic"] = buildSessionList("synthetic")
 	}
-	if h.config.HasProvider("zai") {
+	if h.inBoth("zai") {
 		response["zai"] = buildSessionList("zai")
 	}
-	if h.config.HasProvider("anthropic") {
+	if h.inBoth("anthropic") {
 		response["anthropic"] = buildSessionList("anthropic")
 	}
-	if h.config.HasProvider("copilot") {
+	if h.inBoth("copilot") {
 		response["copilot"] = buildSessionList("copilot")
 	}
-	if h.config.HasProvider("codex") {
+	if h.inBoth("codex") {
 		response["codex"] = buildSessionList("codex")
 	}
-	if h.config.HasProvider("antigravity") {
+	if h.inBoth("antigravity") {
 		response["antigravity"] = buildSessionList("antigravity")
 	}
 
 	respondJSON(w, http.StatusOK, response)
 }
 
+// SessionStats handles GET /api/sessions/stats?provider=&range=30d:
+// aggregate numbers about the sessions started in the range, computed in
+// the database rather than from the full session list. Usage is per quota
+// column (sub, search, tool) as max minus start value. provider=both adds
+// totals across providers and a per-provider breakdown.
+func (h *Handler) SessionStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	provider, err := h.getProviderFromRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	rangeStr := r.URL.Query().Get("range")
+	if rangeStr == "" {
+		rangeStr = "30d"
+	}
+	duration, err := parseTimeRange(rangeStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	since := time.Now().UTC().Add(-duration)
+
+	if provider != "both" {
+		stats, err := h.store.QuerySessionStats(provider, since)
+		if err != nil {
+			h.logger.Error("failed to query session stats", "provider", provider, "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to query session stats")
+			return
+		}
+		resp := buildSessionStatsResponse(stats, true)
+		resp["provider"] = provider
+		resp["range"] = rangeStr
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	// Usage units differ between providers, so totals leave it out
+	total, err := h.store.QuerySessionStats("", since)
+	if err != nil {
+		h.logger.Error("failed to query session stats", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query session stats")
+		return
+	}
+	providers := map[string]interface{}{}
+	for _, p := range h.config.AvailableProviders() {
+		stats, err := h.store.QuerySessionStats(p, since)
+		if err != nil {
+			h.logger.Error("failed to query session stats", "provider", p, "error", err)
+			continue
+		}
+		providers[p] = buildSessionStatsResponse(stats, true)
+	}
+	resp := buildSessionStatsResponse(total, false)
+	resp["provider"] = "both"
+	resp["range"] = rangeStr
+	resp["providers"] = providers
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func buildSessionStatsResponse(stats *store.SessionStats, withUsage bool) map[string]interface{} {
+	result := map[string]interface{}{
+		"totalSessions":      stats.Sessions,
+		"avgDurationSeconds": math.Round(stats.AvgDurationSeconds),
+		"avgDuration":        formatDuration(time.Duration(stats.AvgDurationSeconds) * time.Second),
+		"busiestDay":         nil,
+	}
+	if stats.BusiestDay != "" {
+		result["busiestDay"] = map[string]interface{}{"date": stats.BusiestDay, "sessions": stats.BusiestDaySessions}
+	}
+	if withUsage {
+		usage := func(u store.SessionUsageStats) map[string]float64 {
+			return map[string]float64{"avg": u.Avg, "peak": u.Peak}
+		}
+		result["usage"] = map[string]interface{}{
+			"sub":    usage(stats.Sub),
+			"search": usage(stats.Search),
+			"tool":   usage(stats.Tool),
+		}
+	}
+	return result
+}
+
 // ── Deep Insights ──
 
 type insightStat struct {
@@ -1770,6 +2956,9 @@ type insightItem struct {
 	Metric   string `json:"metric,omitempty"`
 	Sublabel string `json:"sublabel,omitempty"`
 	Desc     string `json:"description"`
+	// Confidence is the projection quality ("low", "medium", "high") for
+	// forecasts built on a tracker projection
+	Confidence string `json:"confidence,omitempty"`
 }
 
 // insightCorrelations maps analogous insight keys across providers.
@@ -1856,22 +3045,22 @@ func (h *Handler) insightsBoth(w http.ResponseWriter, r *http.Request, rangeDur
 	hidden := h.getHiddenInsightKeys()
 	response := map[string]interface{}{}
 
-	if h.config.HasProvider("synthetic") {
+	if h.inBoth("synthetic") {
 		response["synthetic"] = h.buildSyntheticInsights(hidden, rangeDur)
 	}
-	if h.config.HasProvider("zai") {
-		response["zai"] = h.buildZaiInsights(hidden)
+	if h.inBoth("zai") {
+		response["zai"] = h.buildZaiInsights(hidden, rangeDur)
 	}
-	if h.config.HasProvider("anthropic") {
+	if h.inBoth("anthropic") {
 		response["anthropic"] = h.buildAnthropicInsights(hidden, rangeDur)
 	}
-	if h.config.HasProvider("copilot") {
+	if h.inBoth("copilot") {
 		response["copilot"] = h.buildCopilotInsights(hidden, rangeDur)
 	}
-	if h.config.HasProvider("codex") {
+	if h.inBoth("codex") {
 		response["codex"] = h.buildCodexInsights(hidden, rangeDur)
 	}
-	if h.config.HasProvider("antigravity") {
+	if h.inBoth("antigravity") {
 		response["antigravity"] = h.buildAntigravityInsights(hidden, rangeDur)
 	}
 
@@ -1884,16 +3073,61 @@ func (h *Handler) insightsSynthetic(w http.ResponseWriter, r *http.Request, rang
 	respondJSON(w, http.StatusOK, h.buildSyntheticInsights(hidden, rangeDur))
 }
 
-// buildSyntheticInsights builds the Synthetic insights response.
-// rangeDur controls the time window for the 4 stat cards.
-func (h *Handler) buildSyntheticInsights(hidden map[string]bool, rangeDur time.Duration) insightsResponse {
-	resp := insightsResponse{Stats: []insightStat{}, Insights: []insightItem{}}
-
-	if h.store == nil {
-		return resp
+// buildTrackingQualityInsight reports how completely provider was sampled
+// over the insights range, so users know whether to trust the numbers.
+// Reports false without snapshots in the range.
+func (h *Handler) buildTrackingQualityInsight(provider string, rangeDur time.Duration) (insightItem, bool) {
+	interval := h.snapshotInterval()
+	coverage, err := h.store.QuerySnapshotCoverage(provider, time.Now().UTC().Add(-rangeDur), interval, interval*chartGapPolls)
+	if err != nil {
+		h.logger.Error("failed to query snapshot coverage", "provider", provider, "error", err)
+		return insightItem{}, false
+	}
+	if coverage == nil {
+		return insightItem{}, false
 	}
 
-	now := time.Now().UTC()
+	pct := coverage.CoveragePercent
+	item := insightItem{
+		Key:      "tracking_quality",
+		Type:     "factual",
+		Title:    "Tracking Quality",
+		Metric:   fmt.Sprintf("%.0f%%", pct),
+		Sublabel: "of range sampled",
+	}
+	if coverage.Gaps == 0 {
+		item.Severity = "positive"
+		item.Desc = "No gaps in sampling over this range. Usage numbers are complete."
+		return item, true
+	}
+	switch {
+	case pct >= 95:
+		item.Severity = "positive"
+	case pct >= 80:
+		item.Severity = "info"
+	case pct >= 50:
+		item.Severity = "warning"
+	default:
+		item.Severity = "negative"
+	}
+	longest := formatDuration(time.Duration(coverage.LongestGapSeconds) * time.Second)
+	item.Desc = fmt.Sprintf("Your data is %.0f%% complete: %d gap(s) in sampling, the longest %s.", pct, coverage.Gaps, longest)
+	if pct < 95 {
+		item.Desc += " Usage during gaps is missed, so totals and rates may read low."
+	}
+	return item, true
+}
+
+// buildSyntheticInsights builds the Synthetic insights response.
+// rangeDur controls the time window for the 4 stat cards.
+func (h *Handler) buildSyntheticInsights(hidden map[string]bool, rangeDur time.Duration) insightsResponse {
+	resp := insightsResponse{Stats: []insightStat{}, Insights: []insightItem{}}
+
+	if h.store == nil {
+		return resp
+	}
+
+	now := time.Now().UTC()
 	rangeStart := now.Add(-rangeDur)
 	d30 := now.Add(-30 * 24 * time.Hour)
 	d7 := now.Add(-7 * 24 * time.Hour)
@@ -2074,6 +3308,30 @@ func (h *Handler) buildSyntheticInsights(hidden map[string]bool, rangeDur time.D
 		}
 	}
 
+	// 5. Credit Burn (plans with prepaid credits only)
+	if !hidden["credits"] && latest != nil && latest.CreditsBalance != nil {
+		balance := *latest.CreditsBalance
+		item := insightItem{
+			Key:  "credits",
+			Type: "factual", Severity: "info",
+			Title:    "Credits",
+			Metric:   fmt.Sprintf("%.2f", balance),
+			Sublabel: "balance",
+			Desc:     "Credit balance has not dropped in the last 7 days.",
+		}
+		if spent, firstAt, err := h.store.QueryCreditsSpent(d7); err == nil && spent > 0 {
+			item.Desc = fmt.Sprintf("Spent %.2f credits in the last 7 days.", spent)
+			if days := latest.CapturedAt.Sub(firstAt).Hours() / 24; days > 0 {
+				daysLeft := balance / (spent / days)
+				item.Desc += fmt.Sprintf(" At this pace the balance lasts ~%.0f more days.", daysLeft)
+				if daysLeft < 7 {
+					item.Severity = "warning"
+				}
+			}
+		}
+		resp.Insights = append(resp.Insights, item)
+	}
+
 	// If no insights at all, add a getting-started message
 	if len(resp.Insights) == 0 {
 		resp.Insights = append(resp.Insights, insightItem{
@@ -2083,17 +3341,32 @@ func (h *Handler) buildSyntheticInsights(hidden map[string]bool, rangeDur time.D
 		})
 	}
 
+	if !hidden["cycle_pace"] && h.tracker != nil {
+		if sub, err := h.tracker.UsageSummary("subscription"); err == nil && sub != nil {
+			if item, ok := buildCyclePaceInsight([]cyclePaceQuota{{name: "Subscription", pace: sub.Pace}}); ok {
+				resp.Insights = append(resp.Insights, item)
+			}
+		}
+	}
+
+	if !hidden["tracking_quality"] {
+		if item, ok := h.buildTrackingQualityInsight("synthetic", rangeDur); ok {
+			resp.Insights = append(resp.Insights, item)
+		}
+	}
+
 	return resp
 }
 
 // insightsZai returns Z.ai deep analytics with historical data
 func (h *Handler) insightsZai(w http.ResponseWriter, r *http.Request, rangeDur time.Duration) {
 	hidden := h.getHiddenInsightKeys()
-	respondJSON(w, http.StatusOK, h.buildZaiInsights(hidden))
+	respondJSON(w, http.StatusOK, h.buildZaiInsights(hidden, rangeDur))
 }
 
-// buildZaiInsights builds the Z.ai insights response.
-func (h *Handler) buildZaiInsights(hidden map[string]bool) insightsResponse {
+// buildZaiInsights builds the Z.ai insights response. rangeDur is the window
+// the tracking quality insight covers.
+func (h *Handler) buildZaiInsights(hidden map[string]bool, rangeDur time.Duration) insightsResponse {
 	resp := insightsResponse{Stats: []insightStat{}, Insights: []insightItem{}}
 
 	if h.store == nil {
@@ -2391,6 +3664,24 @@ func (h *Handler) buildZaiInsights(hidden map[string]bool) insightsResponse {
 		}
 	}
 
+	if !hidden["cycle_pace"] && h.zaiTracker != nil {
+		var quotas []cyclePaceQuota
+		for _, q := range []struct{ key, name string }{{"tokens", "Tokens"}, {"time", "Time"}} {
+			if s, err := h.zaiTracker.UsageSummary(q.key); err == nil && s != nil {
+				quotas = append(quotas, cyclePaceQuota{name: q.name, pace: s.Pace})
+			}
+		}
+		if item, ok := buildCyclePaceInsight(quotas); ok {
+			resp.Insights = append(resp.Insights, item)
+		}
+	}
+
+	if !hidden["tracking_quality"] {
+		if item, ok := h.buildTrackingQualityInsight("zai", rangeDur); ok {
+			resp.Insights = append(resp.Insights, item)
+		}
+	}
+
 	return resp
 }
 
@@ -2398,15 +3689,20 @@ func (h *Handler) buildZaiInsights(hidden map[string]bool) insightsResponse {
 
 // currentAnthropic returns Anthropic quota status.
 func (h *Handler) currentAnthropic(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, h.buildAnthropicCurrent())
+	resp := h.buildAnthropicCurrent()
+	if wantSparklines(r) {
+		h.addSparklines("anthropic", resp)
+	}
+	respondJSON(w, http.StatusOK, h.numberFormat().addDisplayFields(resp))
 }
 
 // buildAnthropicCurrent builds the Anthropic current quota response map.
 func (h *Handler) buildAnthropicCurrent() map[string]interface{} {
 	now := time.Now().UTC()
 	response := map[string]interface{}{
-		"capturedAt": now.Format(time.RFC3339),
-		"quotas":     []interface{}{},
+		"capturedAt":  now.Format(time.RFC3339),
+		"quotas":      []interface{}{},
+		"neverPolled": false,
 	}
 
 	if h.store == nil {
@@ -2418,8 +3714,10 @@ func (h *Handler) buildAnthropicCurrent() map[string]interface{} {
 		h.logger.Error("failed to query latest Anthropic snapshot", "error", err)
 		return response
 	}
+	latest = newerPolled(h.latest, "anthropic", latest, func(s *api.AnthropicSnapshot) time.Time { return s.CapturedAt })
 
 	if latest == nil {
+		h.markNeverPolled(response, "anthropic")
 		return response
 	}
 
@@ -2443,6 +3741,7 @@ func (h *Handler) buildAnthropicCurrent() map[string]interface{} {
 			if summary, err := h.anthropicTracker.UsageSummary(q.Name); err == nil && summary != nil {
 				qMap["currentRate"] = summary.CurrentRate
 				qMap["projectedUtil"] = summary.ProjectedUtil
+				addProjectionConfidence(qMap, summary.ProjectionConfidence)
 			}
 		}
 		quotas = append(quotas, qMap)
@@ -2453,16 +3752,7 @@ func (h *Handler) buildAnthropicCurrent() map[string]interface{} {
 
 // anthropicUtilStatus returns a status string based on utilization percentage.
 func anthropicUtilStatus(util float64) string {
-	switch {
-	case util >= 95:
-		return "critical"
-	case util >= 80:
-		return "danger"
-	case util >= 50:
-		return "warning"
-	default:
-		return "healthy"
-	}
+	return usageStatus(util)
 }
 
 // historyAnthropic returns Anthropic usage history.
@@ -2485,21 +3775,27 @@ func (h *Handler) historyAnthropic(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to query history")
 		return
 	}
-	step := downsampleStep(len(snapshots), maxChartPoints)
-	last := len(snapshots) - 1
-	response := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-	for i, snap := range snapshots {
-		if step > 1 && i != 0 && i != last && i%step != 0 {
-			continue
-		}
+	smooth, smoothFactor, _ := parseChartSmoothing(r)
+	response := make([]map[string]interface{}, 0, len(snapshots))
+	for _, snap := range snapshots {
 		entry := map[string]interface{}{
 			"capturedAt": snap.CapturedAt.Format(time.RFC3339),
 		}
 		for _, q := range snap.Quotas {
 			entry[q.Name] = q.Utilization
 		}
+		if snap.Injected {
+			entry["injected"] = true
+		}
 		response = append(response, entry)
 	}
+	if smooth {
+		response = smoothChartPoints(response, smoothFactor)
+	}
+	response = downsamplePoints(response, h.chartMaxPoints())
+	if wantChartGaps(r) {
+		response = breakChartGaps(response, h.chartGapThreshold(len(snapshots)))
+	}
 	respondJSON(w, http.StatusOK, response)
 }
 
@@ -2510,6 +3806,12 @@ func (h *Handler) cyclesAnthropic(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
+
+	limit, err := parseCycleLimit(r, 0)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	quotaName := r.URL.Query().Get("type")
 	if quotaName == "" {
 		quotaName = "five_hour"
@@ -2552,6 +3854,9 @@ func (h *Handler) cyclesAnthropic(w http.ResponseWriter, r *http.Request) {
 	for i, j := 0, len(response)-1; i < j; i, j = i+1, j-1 {
 		response[i], response[j] = response[j], response[i]
 	}
+	if limit > 0 && len(response) > limit {
+		response = response[:limit]
+	}
 
 	respondJSON(w, http.StatusOK, response)
 }
@@ -2596,6 +3901,14 @@ func (h *Handler) buildAnthropicSummaryMap() map[string]interface{} {
 	return response
 }
 
+// addProjectionConfidence adds a projection's confidence (0-1) and its
+// quality label ("low", "medium", "high") next to the projection, so the UI
+// can dim projections made from too little data.
+func addProjectionConfidence(m map[string]interface{}, confidence float64) {
+	m["projectionConfidence"] = confidence
+	m["projectionQuality"] = tracker.ProjectionQuality(confidence)
+}
+
 // buildAnthropicSummaryResponse builds a summary response from AnthropicTracker data.
 func buildAnthropicSummaryResponse(summary *tracker.AnthropicSummary) map[string]interface{} {
 	result := map[string]interface{}{
@@ -2609,6 +3922,8 @@ func buildAnthropicSummaryResponse(summary *tracker.AnthropicSummary) map[string
 		"totalTracked":    summary.TotalTracked,
 		"trackingSince":   nil,
 	}
+	addCyclePace(result, summary.Pace)
+	addProjectionConfidence(result, summary.ProjectionConfidence)
 	if summary.ResetsAt != nil {
 		result["resetsAt"] = summary.ResetsAt.Format(time.RFC3339)
 		result["timeUntilReset"] = formatDuration(summary.TimeUntilReset)
@@ -2883,6 +4198,19 @@ func (h *Handler) buildAnthropicInsights(hidden map[string]bool, rangeDur time.D
 		})
 	}
 
+	if !hidden["cycle_pace"] {
+		quotas := cyclePaceQuotas(summaries, api.AnthropicDisplayName, func(s *tracker.AnthropicSummary) *tracker.CyclePace { return s.Pace })
+		if item, ok := buildCyclePaceInsight(quotas); ok {
+			resp.Insights = append(resp.Insights, item)
+		}
+	}
+
+	if !hidden["tracking_quality"] {
+		if item, ok := h.buildTrackingQualityInsight("anthropic", rangeDur); ok {
+			resp.Insights = append(resp.Insights, item)
+		}
+	}
+
 	return resp
 }
 
@@ -2950,14 +4278,15 @@ func (h *Handler) computeAnthropicRate(quotaName string, currentUtil float64, su
 	return result
 }
 
-// severityFromPercent returns a severity string based on a usage percentage
+// severityFromPercent returns a severity string based on a usage percentage,
+// one step per status band
 func severityFromPercent(pct float64) string {
-	switch {
-	case pct >= 95:
+	switch usageStatus(pct) {
+	case "critical":
 		return "negative"
-	case pct >= 80:
+	case "danger":
 		return "warning"
-	case pct >= 50:
+	case "warning":
 		return "info"
 	default:
 		return "positive"
@@ -3140,7 +4469,15 @@ func compactNum(v float64) string {
 
 // GetSettings returns current settings as JSON.
 func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.collectSettings(false))
+}
+
+// collectSettings returns all saved settings in the shape GetSettings serves.
+// Credentials are blanked (with a *_set flag) unless includeSecrets is true,
+// in which case they are decrypted to plaintext.
+func (h *Handler) collectSettings(includeSecrets bool) map[string]interface{} {
 	tz := ""
+	updateChannel := update.ChannelStable
 	var hiddenInsights []string
 	if h.store != nil {
 		val, err := h.store.GetSetting("timezone")
@@ -3149,6 +4486,9 @@ func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
 		} else {
 			tz = val
 		}
+		if ch, _ := h.store.GetSetting("update_channel"); update.IsValidChannel(ch) {
+			updateChannel = ch
+		}
 		hiVal, err := h.store.GetSetting("hidden_insights")
 		if err != nil {
 			h.logger.Error("failed to get hidden_insights setting", "error", err)
@@ -3161,8 +4501,31 @@ func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result := map[string]interface{}{
-		"timezone":        tz,
-		"hidden_insights": hiddenInsights,
+		"timezone":                tz,
+		"provider_timezones":      h.providerTimezones(),
+		"hidden_insights":         hiddenInsights,
+		"update_channel":          updateChannel,
+		"status_public":           h.statusPagePublic(),
+		"widget_origins":          h.widgetOrigins(),
+		"number_format":           h.numberFormat(),
+		"chart_max_points":        h.chartMaxPoints(),
+		"status_bands":            h.statusBands(),
+		"remember_me_days":        h.rememberMeDays(),
+		"session_ttl_minutes":     h.sessionTTLMinutes(),
+		"allowed_ips":             h.allowedIPs(),
+		"branding":                h.branding(),
+		"both_exclude":            h.bothExclude(),
+		"poll_min_interval":       h.pollMinInterval(),
+		"snapshot_retention_days": h.snapshotRetentionDays(),
+		"cycle_retention_days":    h.cycleRetentionDays(),
+		"firehose_enabled":        h.firehoseEnabled(),
+		"firehose_url":            h.firehoseURL(),
+		"firehose_providers":      h.firehoseProviders(),
+		"firehose_secret":         "",
+		"firehose_secret_set":     h.firehoseSecret() != "",
+	}
+	if includeSecrets {
+		result["firehose_secret"] = h.firehoseSecret()
 	}
 
 	// SMTP settings (never return the actual password)
@@ -3176,11 +4539,58 @@ func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
 					pwd, _ := smtp["password"].(string)
 					smtp["password"] = ""
 					smtp["password_set"] = pwd != ""
+					if includeSecrets {
+						smtp["password"] = h.decryptSMTPPassword(pwd)
+					}
 				}
 				result["smtp"] = smtp
 			}
 		}
 
+		// Matrix settings (decrypted for display, never return the access token)
+		matrixJSON, _ := h.store.GetSetting("matrix")
+		if matrixJSON != "" {
+			var m matrixSettings
+			if json.Unmarshal([]byte(matrixJSON), &m) == nil {
+				key := h.settingsEncryptionKey()
+				homeserver, _ := notify.DecryptFromStorage(m.HomeserverURL, key)
+				roomID, _ := notify.DecryptFromStorage(m.RoomID, key)
+				token := ""
+				if includeSecrets {
+					token, _ = notify.DecryptFromStorage(m.AccessToken, key)
+				}
+				result["matrix"] = map[string]interface{}{
+					"homeserver_url":   homeserver,
+					"room_id":          roomID,
+					"access_token":     token,
+					"access_token_set": m.AccessToken != "",
+				}
+			}
+		}
+
+		// Twilio settings (decrypted for display, never return the auth token)
+		twilioJSON, _ := h.store.GetSetting("twilio")
+		if twilioJSON != "" {
+			var t twilioSettings
+			if json.Unmarshal([]byte(twilioJSON), &t) == nil {
+				key := h.settingsEncryptionKey()
+				sid, _ := notify.DecryptFromStorage(t.AccountSID, key)
+				from, _ := notify.DecryptFromStorage(t.FromNumber, key)
+				to, _ := notify.DecryptFromStorage(t.ToNumbers, key)
+				token := ""
+				if includeSecrets {
+					token, _ = notify.DecryptFromStorage(t.AuthToken, key)
+				}
+				result["twilio"] = map[string]interface{}{
+					"account_sid":    sid,
+					"auth_token":     token,
+					"auth_token_set": t.AuthToken != "",
+					"from_number":    from,
+					"to_numbers":     to,
+				}
+			}
+		}
+
 		// Notification settings
 		notifJSON, _ := h.store.GetSetting("notifications")
 		if notifJSON != "" {
@@ -3190,6 +4600,23 @@ func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		// Notification message templates
+		tmplJSON, _ := h.store.GetSetting("notification_templates")
+		if tmplJSON != "" {
+			var templates map[string]notify.MessageTemplate
+			if json.Unmarshal([]byte(tmplJSON), &templates) == nil {
+				result["notification_templates"] = templates
+			}
+		}
+
+		// Pricing used for cost projection
+		if pricing, err := h.store.GetPricing(); err == nil && pricing != nil {
+			result["pricing"] = pricing
+		}
+		if budget, err := h.store.GetBudget(); err == nil && budget != nil {
+			result["budget"] = budget
+		}
+
 		// Provider visibility settings
 		visJSON, _ := h.store.GetSetting("provider_visibility")
 		if visJSON != "" {
@@ -3200,11 +4627,79 @@ func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	respondJSON(w, http.StatusOK, result)
+	return result
+}
+
+// decryptSMTPPassword returns the stored SMTP password in plaintext. Values
+// that fail to decrypt are returned as stored, matching how the notifier
+// reads them.
+func (h *Handler) decryptSMTPPassword(stored string) string {
+	key := h.settingsEncryptionKey()
+	if key == "" || stored == "" {
+		return stored
+	}
+	if plain, err := notify.DecryptFromStorage(stored, key); err == nil && IsEncryptedValue(stored) {
+		return plain
+	}
+	if plain, err := notify.Decrypt(stored, key); err == nil {
+		return plain
+	}
+	return stored
+}
+
+// matrixSettings is the JSON shape stored under the "matrix" settings key.
+// All fields are stored encrypted.
+type matrixSettings struct {
+	HomeserverURL string `json:"homeserver_url"`
+	AccessToken   string `json:"access_token"`
+	RoomID        string `json:"room_id"`
+}
+
+// twilioSettings is the JSON shape stored under the "twilio" settings key.
+// All fields are stored encrypted; ToNumbers is comma-separated.
+type twilioSettings struct {
+	AccountSID string `json:"account_sid"`
+	AuthToken  string `json:"auth_token"`
+	FromNumber string `json:"from_number"`
+	ToNumbers  string `json:"to_numbers"`
+}
+
+// twilioSIDRegex validates Twilio account SIDs.
+var twilioSIDRegex = regexp.MustCompile(`^AC[0-9a-fA-F]{32}$`)
+
+// currencyCodeRegex validates ISO 4217 currency codes.
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// e164Regex validates E.164 phone numbers.
+var e164Regex = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// settingsEncryptionKey returns the key used to encrypt credentials stored in settings.
+// Returns "" when auth is not configured.
+func (h *Handler) settingsEncryptionKey() string {
+	if h.sessions == nil {
+		return ""
+	}
+	return DeriveEncryptionKey(h.sessions.passwordHash, nil)
 }
 
-// emailRegex validates email addresses.
-var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+// encryptSettingFields encrypts each non-empty, not-yet-encrypted value in place.
+func (h *Handler) encryptSettingFields(fields ...*string) error {
+	key := h.settingsEncryptionKey()
+	if key == "" {
+		return fmt.Errorf("auth not configured")
+	}
+	for _, field := range fields {
+		if *field == "" || IsEncryptedValue(*field) {
+			continue
+		}
+		enc, err := notify.EncryptForStorage(*field, key)
+		if err != nil {
+			return err
+		}
+		*field = enc
+	}
+	return nil
+}
 
 // UpdateSettings updates settings from JSON body (partial updates supported).
 func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
@@ -3255,6 +4750,37 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		result["timezone"] = tz
 	}
 
+	// Handle provider_timezones (replaces every override; empty values are dropped)
+	if raw, ok := body["provider_timezones"]; ok {
+		var overrides map[string]string
+		if err := json.Unmarshal(raw, &overrides); err != nil {
+			respondError(w, http.StatusBadRequest, "provider_timezones must be an object of provider to timezone")
+			return
+		}
+		clean := map[string]string{}
+		for provider, tz := range overrides {
+			if _, known := providerDisplayNames[provider]; !known {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown provider: %s", provider))
+				return
+			}
+			if tz == "" {
+				continue
+			}
+			if _, err := time.LoadLocation(tz); err != nil {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid timezone for %s: %s", provider, tz))
+				return
+			}
+			clean[provider] = tz
+		}
+		data, _ := json.Marshal(clean)
+		if err := h.store.SetSetting("provider_timezones", string(data)); err != nil {
+			h.logger.Error("failed to save provider_timezones setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["provider_timezones"] = clean
+	}
+
 	// Handle hidden_insights
 	if raw, ok := body["hidden_insights"]; ok {
 		var keys []string
@@ -3300,14 +4826,14 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "SMTP protocol must be tls, starttls, or none")
 			return
 		}
-		if smtp.FromAddress != "" && !emailRegex.MatchString(smtp.FromAddress) {
+		if smtp.FromAddress != "" && !notify.ValidEmailAddress(smtp.FromAddress) {
 			respondError(w, http.StatusBadRequest, "invalid from address")
 			return
 		}
 		if smtp.To != "" {
 			for _, addr := range strings.Split(smtp.To, ",") {
 				addr = strings.TrimSpace(addr)
-				if addr != "" && !emailRegex.MatchString(addr) {
+				if addr != "" && !notify.ValidEmailAddress(addr) {
 					respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid recipient address: %s", addr))
 					return
 				}
@@ -3355,15 +4881,166 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Handle Matrix settings
+	if raw, ok := body["matrix"]; ok {
+		var m matrixSettings
+		if err := json.Unmarshal(raw, &m); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid matrix value")
+			return
+		}
+		m.HomeserverURL = strings.TrimSpace(m.HomeserverURL)
+		m.RoomID = strings.TrimSpace(m.RoomID)
+
+		var matrixJSON []byte
+		if m.HomeserverURL != "" || m.RoomID != "" || m.AccessToken != "" {
+			// Validate
+			if u, err := url.Parse(m.HomeserverURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				respondError(w, http.StatusBadRequest, "Matrix homeserver URL must be an http(s) URL")
+				return
+			}
+			if !strings.HasPrefix(m.RoomID, "!") || !strings.Contains(m.RoomID, ":") {
+				respondError(w, http.StatusBadRequest, "Matrix room ID must look like !room:server")
+				return
+			}
+
+			// If access token is empty, preserve the existing token
+			if m.AccessToken == "" {
+				existingJSON, _ := h.store.GetSetting("matrix")
+				var existing matrixSettings
+				if existingJSON != "" && json.Unmarshal([]byte(existingJSON), &existing) == nil {
+					m.AccessToken = existing.AccessToken
+				}
+			}
+			if m.AccessToken == "" {
+				respondError(w, http.StatusBadRequest, "Matrix access token is required")
+				return
+			}
+
+			// Encrypt all Matrix fields using admin password hash as key
+			if err := h.encryptSettingFields(&m.HomeserverURL, &m.AccessToken, &m.RoomID); err != nil {
+				h.logger.Error("failed to encrypt Matrix settings", "error", err)
+				respondError(w, http.StatusInternalServerError, "failed to encrypt Matrix settings")
+				return
+			}
+			matrixJSON, _ = json.Marshal(m)
+		}
+
+		if err := h.store.SetSetting("matrix", string(matrixJSON)); err != nil {
+			h.logger.Error("failed to save Matrix settings", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save Matrix settings")
+			return
+		}
+		result["matrix"] = "saved"
+
+		// Reconfigure Matrix sender with new settings
+		if h.notifier != nil {
+			if err := h.notifier.ConfigureMatrix(); err != nil {
+				h.logger.Error("failed to reconfigure Matrix after settings update", "error", err)
+			}
+		}
+	}
+
+	// Handle Twilio settings
+	if raw, ok := body["twilio"]; ok {
+		var t twilioSettings
+		if err := json.Unmarshal(raw, &t); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid twilio value")
+			return
+		}
+		t.AccountSID = strings.TrimSpace(t.AccountSID)
+		t.FromNumber = strings.TrimSpace(t.FromNumber)
+
+		var twilioJSON []byte
+		if t.AccountSID != "" || t.FromNumber != "" || strings.TrimSpace(t.ToNumbers) != "" || t.AuthToken != "" {
+			// Validate
+			if !twilioSIDRegex.MatchString(t.AccountSID) {
+				respondError(w, http.StatusBadRequest, "invalid Twilio account SID")
+				return
+			}
+			if !e164Regex.MatchString(t.FromNumber) {
+				respondError(w, http.StatusBadRequest, "from number must be in E.164 format (e.g. +15551234567)")
+				return
+			}
+			var toNumbers []string
+			for _, n := range strings.Split(t.ToNumbers, ",") {
+				n = strings.TrimSpace(n)
+				if n == "" {
+					continue
+				}
+				if !e164Regex.MatchString(n) {
+					respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid recipient number: %s", n))
+					return
+				}
+				toNumbers = append(toNumbers, n)
+			}
+			if len(toNumbers) == 0 {
+				respondError(w, http.StatusBadRequest, "at least one recipient number is required")
+				return
+			}
+			t.ToNumbers = strings.Join(toNumbers, ",")
+
+			// If auth token is empty, preserve the existing token
+			if t.AuthToken == "" {
+				existingJSON, _ := h.store.GetSetting("twilio")
+				var existing twilioSettings
+				if existingJSON != "" && json.Unmarshal([]byte(existingJSON), &existing) == nil {
+					t.AuthToken = existing.AuthToken
+				}
+			}
+			if t.AuthToken == "" {
+				respondError(w, http.StatusBadRequest, "Twilio auth token is required")
+				return
+			}
+
+			// Encrypt all Twilio fields using admin password hash as key
+			if err := h.encryptSettingFields(&t.AccountSID, &t.AuthToken, &t.FromNumber, &t.ToNumbers); err != nil {
+				h.logger.Error("failed to encrypt Twilio settings", "error", err)
+				respondError(w, http.StatusInternalServerError, "failed to encrypt Twilio settings")
+				return
+			}
+			twilioJSON, _ = json.Marshal(t)
+		}
+
+		if err := h.store.SetSetting("twilio", string(twilioJSON)); err != nil {
+			h.logger.Error("failed to save Twilio settings", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save Twilio settings")
+			return
+		}
+		result["twilio"] = "saved"
+
+		// Reconfigure Twilio sender with new settings
+		if h.notifier != nil {
+			if err := h.notifier.ConfigureTwilio(); err != nil {
+				h.logger.Error("failed to reconfigure Twilio after settings update", "error", err)
+			}
+		}
+	}
+
 	// Handle notification settings
 	if raw, ok := body["notifications"]; ok {
 		var notif struct {
-			WarningThreshold  float64 `json:"warning_threshold"`
-			CriticalThreshold float64 `json:"critical_threshold"`
-			NotifyWarning     bool    `json:"notify_warning"`
-			NotifyCritical    bool    `json:"notify_critical"`
-			NotifyReset       bool    `json:"notify_reset"`
-			CooldownMinutes   int     `json:"cooldown_minutes"`
+			WarningThreshold  float64                                `json:"warning_threshold"`
+			CriticalThreshold float64                                `json:"critical_threshold"`
+			NotifyWarning     bool                                   `json:"notify_warning"`
+			NotifyCritical    bool                                   `json:"notify_critical"`
+			NotifyReset       bool                                   `json:"notify_reset"`
+			NotifyExhaustion  bool                                   `json:"notify_exhaustion"`
+			NotifyRecovered   bool                                   `json:"notify_recovered"`
+			CooldownMinutes   int                                    `json:"cooldown_minutes"`
+			AlertGroupSeconds *int                                   `json:"alert_group_seconds,omitempty"`
+			SMSLevels         []string                               `json:"sms_levels,omitempty"`
+			Channels          *notify.NotificationChannels           `json:"channels,omitempty"`
+			Routing           map[string]notify.NotificationChannels `json:"routing,omitempty"`
+			EscalationMinutes int                                    `json:"escalation_minutes,omitempty"`
+			EscalationChannel string                                 `json:"escalation_channel,omitempty"`
+			AlertIncludeChart bool                                   `json:"alert_include_chart"`
+			NotifyLatency     bool                                   `json:"notify_latency"`
+			NotifyBudget      bool                                   `json:"notify_budget"`
+			NotifyCircuit     *bool                                  `json:"notify_circuit,omitempty"` // unset keeps circuit alerts on
+			NotifyUpdate      bool                                   `json:"notify_update"`
+			NotifySchema      *bool                                  `json:"notify_schema,omitempty"` // unset keeps schema alerts on
+			LatencyThreshold  int                                    `json:"latency_threshold_ms,omitempty"`
+			LatencyPolls      int                                    `json:"latency_polls,omitempty"`
 			Overrides         []struct {
 				QuotaKey   string  `json:"quota_key"`
 				Provider   string  `json:"provider"`
@@ -3372,6 +5049,21 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 				IsAbsolute bool    `json:"is_absolute"`
 			} `json:"overrides"`
 		}
+		// Fields absent from the request keep their stored values, so clients
+		// editing only some of them (like the dashboard) don't reset the rest.
+		// Channels merge field by field; routing and overrides are replaced
+		// when sent.
+		if stored, _ := h.store.GetSetting("notifications"); stored != "" {
+			_ = json.Unmarshal([]byte(stored), &notif)
+		}
+		var sent map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &sent); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid notifications value")
+			return
+		}
+		if _, ok := sent["routing"]; ok {
+			notif.Routing = nil
+		}
 		if err := json.Unmarshal(raw, &notif); err != nil {
 			respondError(w, http.StatusBadRequest, "invalid notifications value")
 			return
@@ -3392,6 +5084,75 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		if notif.CooldownMinutes < 1 {
 			notif.CooldownMinutes = 1
 		}
+		// Grouping window: 0 sends each alert immediately, capped at five minutes
+		if g := notif.AlertGroupSeconds; g != nil && (*g < 0 || *g > 300) {
+			respondError(w, http.StatusBadRequest, "alert_group_seconds must be between 0 and 300")
+			return
+		}
+		// Escalation: 0 disables, capped at one day
+		if notif.EscalationMinutes < 0 || notif.EscalationMinutes > 1440 {
+			respondError(w, http.StatusBadRequest, "escalation_minutes must be between 0 and 1440")
+			return
+		}
+		switch notif.EscalationChannel {
+		case "", "email", "push", "matrix", "sms":
+		default:
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown escalation channel: %s", notif.EscalationChannel))
+			return
+		}
+		// Latency alerts: threshold 0 disables, up to 10 minutes; polls default to 3
+		if notif.LatencyThreshold < 0 || notif.LatencyThreshold > 600000 {
+			respondError(w, http.StatusBadRequest, "latency_threshold_ms must be between 0 and 600000")
+			return
+		}
+		if notif.LatencyPolls < 0 || notif.LatencyPolls > 100 {
+			respondError(w, http.StatusBadRequest, "latency_polls must be between 0 and 100")
+			return
+		}
+		// SMS is limited to the most urgent levels to avoid cost
+		for _, level := range notif.SMSLevels {
+			if level != "critical" && level != "exhaustion" {
+				respondError(w, http.StatusBadRequest, "sms_levels may only contain critical and exhaustion")
+				return
+			}
+		}
+		// Validate severity routing: every enabled level needs at least one channel
+		if len(notif.Routing) > 0 {
+			for level, ch := range notif.Routing {
+				switch level {
+				case "warning", "reset", "recovered", "latency", "budget", "circuit", "update", "schema":
+					if ch.SMS {
+						respondError(w, http.StatusBadRequest, fmt.Sprintf("SMS cannot be routed for %s alerts", level))
+						return
+					}
+				case "critical", "exhaustion":
+				default:
+					respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown routing level: %s", level))
+					return
+				}
+			}
+			enabled := map[string]bool{
+				"warning":    notif.NotifyWarning,
+				"critical":   notif.NotifyCritical,
+				"reset":      notif.NotifyReset,
+				"exhaustion": notif.NotifyExhaustion,
+				"recovered":  notif.NotifyRecovered,
+				"latency":    notif.NotifyLatency,
+				"budget":     notif.NotifyBudget,
+				"circuit":    notif.NotifyCircuit != nil && *notif.NotifyCircuit, // unset, they use the global channels
+				"update":     notif.NotifyUpdate,
+				"schema":     notif.NotifySchema != nil && *notif.NotifySchema, // unset, they use the global channels
+			}
+			for _, level := range notify.NotificationLevels {
+				if !enabled[level] {
+					continue
+				}
+				if ch, ok := notif.Routing[level]; !ok || !ch.Any() {
+					respondError(w, http.StatusBadRequest, fmt.Sprintf("at least one channel must be routed for %s alerts", level))
+					return
+				}
+			}
+		}
 		// Validate per-quota overrides
 		for _, o := range notif.Overrides {
 			if o.IsAbsolute {
@@ -3423,82 +5184,2037 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Handle provider visibility
-	if raw, ok := body["provider_visibility"]; ok {
-		var vis map[string]map[string]bool
-		if err := json.Unmarshal(raw, &vis); err != nil {
-			respondError(w, http.StatusBadRequest, "invalid provider_visibility value")
+	// Handle notification message templates
+	if raw, ok := body["notification_templates"]; ok {
+		var templates map[string]notify.MessageTemplate
+		if err := json.Unmarshal(raw, &templates); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid notification_templates value")
 			return
 		}
-		visJSON, _ := json.Marshal(vis)
-		if err := h.store.SetSetting("provider_visibility", string(visJSON)); err != nil {
-			h.logger.Error("failed to save provider visibility settings", "error", err)
-			respondError(w, http.StatusInternalServerError, "failed to save provider visibility settings")
+		for channel, tmpl := range templates {
+			if !isTemplateChannel(channel) {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown template channel: %s", channel))
+				return
+			}
+			if err := notify.ValidateTemplate(tmpl.Subject); err != nil {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s subject template: %v", channel, err))
+				return
+			}
+			if err := notify.ValidateTemplate(tmpl.Body); err != nil {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s body template: %v", channel, err))
+				return
+			}
+			// Drop empty entries so the channel keeps the default wording
+			if tmpl.Subject == "" && tmpl.Body == "" {
+				delete(templates, channel)
+			}
+		}
+
+		tmplJSON, _ := json.Marshal(templates)
+		if err := h.store.SetSetting("notification_templates", string(tmplJSON)); err != nil {
+			h.logger.Error("failed to save notification templates", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save notification templates")
 			return
 		}
-		result["provider_visibility"] = vis
+		result["notification_templates"] = "saved"
+
+		if h.notifier != nil {
+			if err := h.notifier.Reload(); err != nil {
+				h.logger.Error("failed to reload notifier after template update", "error", err)
+			}
+		}
 	}
 
-	respondJSON(w, http.StatusOK, result)
+	// Handle pricing (enables cost projection)
+	if raw, ok := body["pricing"]; ok {
+		var pricing store.PricingConfig
+		if err := json.Unmarshal(raw, &pricing); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid pricing value")
+			return
+		}
+		if pricing.BillingDay == 0 {
+			pricing.BillingDay = 1
+		}
+		if pricing.BillingDay < 1 || pricing.BillingDay > 28 {
+			respondError(w, http.StatusBadRequest, "billing_day must be between 1 and 28")
+			return
+		}
+		if pricing.DisplayCurrency != "" && !currencyCodeRegex.MatchString(pricing.DisplayCurrency) {
+			respondError(w, http.StatusBadRequest, "display_currency must be a 3-letter ISO 4217 code")
+			return
+		}
+		for code, rate := range pricing.FXRates {
+			if !currencyCodeRegex.MatchString(code) || rate <= 0 {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid fx rate for %s", code))
+				return
+			}
+		}
+		for provider, p := range pricing.Providers {
+			if p.Currency != "" && !currencyCodeRegex.MatchString(p.Currency) {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid currency for %s", provider))
+				return
+			}
+			if !store.IsTrackedProvider(provider) {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown provider: %s", provider))
+				return
+			}
+			if p.MonthlyFee < 0 {
+				respondError(w, http.StatusBadRequest, "monthly_fee must be >= 0")
+				return
+			}
+			for quota, price := range p.UnitPrices {
+				if price < 0 {
+					respondError(w, http.StatusBadRequest, fmt.Sprintf("unit price for %s must be >= 0", quota))
+					return
+				}
+			}
+		}
+
+		pricingJSON, _ := json.Marshal(pricing)
+		if err := h.store.SetSetting("pricing", string(pricingJSON)); err != nil {
+			h.logger.Error("failed to save pricing settings", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save pricing settings")
+			return
+		}
+		result["pricing"] = "saved"
+	}
+
+	// Handle budget caps (used by budget alerts)
+	if raw, ok := body["budget"]; ok {
+		var budget store.BudgetConfig
+		if err := json.Unmarshal(raw, &budget); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid budget value")
+			return
+		}
+		if budget.Overall < 0 {
+			respondError(w, http.StatusBadRequest, "overall budget must be >= 0")
+			return
+		}
+		for provider, limit := range budget.Providers {
+			if !store.IsTrackedProvider(provider) {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown provider: %s", provider))
+				return
+			}
+			if limit < 0 {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("budget for %s must be >= 0", provider))
+				return
+			}
+		}
+		if budget.AlertPercent == 0 {
+			budget.AlertPercent = 80
+		}
+		if budget.AlertPercent < 1 || budget.AlertPercent > 100 {
+			respondError(w, http.StatusBadRequest, "alert_percent must be between 1 and 100")
+			return
+		}
+
+		budgetJSON, _ := json.Marshal(budget)
+		if err := h.store.SetSetting("budget", string(budgetJSON)); err != nil {
+			h.logger.Error("failed to save budget settings", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save budget settings")
+			return
+		}
+		result["budget"] = "saved"
+	}
+
+	// Handle provider visibility
+	if raw, ok := body["provider_visibility"]; ok {
+		var vis map[string]map[string]bool
+		if err := json.Unmarshal(raw, &vis); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid provider_visibility value")
+			return
+		}
+		visJSON, _ := json.Marshal(vis)
+		if err := h.store.SetSetting("provider_visibility", string(visJSON)); err != nil {
+			h.logger.Error("failed to save provider visibility settings", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save provider visibility settings")
+			return
+		}
+		result["provider_visibility"] = vis
+	}
+
+	// Handle update_channel
+	if raw, ok := body["update_channel"]; ok {
+		var ch string
+		if err := json.Unmarshal(raw, &ch); err != nil || !update.IsValidChannel(ch) {
+			respondError(w, http.StatusBadRequest, "update_channel must be \"stable\" or \"beta\"")
+			return
+		}
+		if err := h.store.SetSetting("update_channel", ch); err != nil {
+			h.logger.Error("failed to save update_channel setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		if h.updater != nil {
+			h.updater.SetChannel(ch)
+		}
+		result["update_channel"] = ch
+	}
+
+	// Handle chart_max_points
+	if raw, ok := body["chart_max_points"]; ok {
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil || n < minChartMaxPoints || n > maxChartMaxPoints {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("chart_max_points must be between %d and %d", minChartMaxPoints, maxChartMaxPoints))
+			return
+		}
+		if err := h.store.SetSetting("chart_max_points", strconv.Itoa(n)); err != nil {
+			h.logger.Error("failed to save chart_max_points setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["chart_max_points"] = n
+	}
+
+	// Handle poll_min_interval
+	if raw, ok := body["poll_min_interval"]; ok {
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil || n < 0 || n > maxPollMinInterval {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("poll_min_interval must be between 0 and %d", maxPollMinInterval))
+			return
+		}
+		if err := h.store.SetSetting("poll_min_interval", strconv.Itoa(n)); err != nil {
+			h.logger.Error("failed to save poll_min_interval setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["poll_min_interval"] = n
+	}
+
+	// Handle snapshot_retention_days and cycle_retention_days
+	for _, key := range []string{"snapshot_retention_days", "cycle_retention_days"} {
+		raw, ok := body[key]
+		if !ok {
+			continue
+		}
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil || n < 0 || n > maxRetentionDays {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("%s must be between 0 and %d", key, maxRetentionDays))
+			return
+		}
+		if err := h.store.SetSetting(key, strconv.Itoa(n)); err != nil {
+			h.logger.Error("failed to save "+key+" setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result[key] = n
+	}
+
+	// Handle remember_me_days
+	if raw, ok := body["remember_me_days"]; ok {
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil || n < minRememberMeDays || n > maxRememberMeDays {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("remember_me_days must be between %d and %d", minRememberMeDays, maxRememberMeDays))
+			return
+		}
+		if err := h.store.SetSetting("remember_me_days", strconv.Itoa(n)); err != nil {
+			h.logger.Error("failed to save remember_me_days setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		if h.sessions != nil {
+			h.sessions.ReloadTTLs()
+		}
+		h.logger.Info("Remember-me duration changed", "days", n, "by", h.currentUser(r))
+		result["remember_me_days"] = n
+	}
+
+	// Handle session_ttl_minutes
+	if raw, ok := body["session_ttl_minutes"]; ok {
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil || n < minSessionTTLMinutes || n > maxSessionTTLMinutes {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("session_ttl_minutes must be between %d and %d", minSessionTTLMinutes, maxSessionTTLMinutes))
+			return
+		}
+		if err := h.store.SetSetting("session_ttl_minutes", strconv.Itoa(n)); err != nil {
+			h.logger.Error("failed to save session_ttl_minutes setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		if h.sessions != nil {
+			h.sessions.ReloadTTLs()
+		}
+		h.logger.Info("Session lifetime changed", "minutes", n, "by", h.currentUser(r))
+		result["session_ttl_minutes"] = n
+	}
+
+	// Handle number_format
+	if raw, ok := body["number_format"]; ok {
+		var nf NumberFormat
+		if err := json.Unmarshal(raw, &nf); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid number_format")
+			return
+		}
+		if err := nf.validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data, _ := json.Marshal(nf)
+		if err := h.store.SetSetting("number_format", string(data)); err != nil {
+			h.logger.Error("failed to save number_format setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["number_format"] = nf
+	}
+
+	// Handle widget_origins
+	if raw, ok := body["widget_origins"]; ok {
+		var origins []string
+		if err := json.Unmarshal(raw, &origins); err != nil {
+			respondError(w, http.StatusBadRequest, "widget_origins must be a list of origins")
+			return
+		}
+		origins, err := normalizeWidgetOrigins(origins)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data, _ := json.Marshal(origins)
+		if err := h.store.SetSetting("widget_origins", string(data)); err != nil {
+			h.logger.Error("failed to save widget_origins setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["widget_origins"] = origins
+	}
+
+	// Handle status_bands
+	if raw, ok := body["status_bands"]; ok {
+		var bands StatusBands
+		if err := json.Unmarshal(raw, &bands); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid status_bands")
+			return
+		}
+		if err := bands.validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data, _ := json.Marshal(bands)
+		if err := h.store.SetSetting("status_bands", string(data)); err != nil {
+			h.logger.Error("failed to save status_bands setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		h.loadStatusBands()
+		result["status_bands"] = bands
+	}
+
+	// Handle allowed_ips
+	if raw, ok := body["allowed_ips"]; ok {
+		var entries []string
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			respondError(w, http.StatusBadRequest, "allowed_ips must be a list of addresses or CIDRs")
+			return
+		}
+		allowed, err := normalizeAllowedIPs(entries)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Refuse a list that would lock out the caller (imports have no caller)
+		if ip := h.allowlistClientIP(r); len(allowed) > 0 && net.ParseIP(ip) != nil && !NewIPWhitelistMiddleware(allowed, h.logger).isAllowed(ip) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("allowed_ips must include your own address (%s)", ip))
+			return
+		}
+		data, _ := json.Marshal(allowed)
+		if err := h.store.SetSetting("allowed_ips", string(data)); err != nil {
+			h.logger.Error("failed to save allowed_ips setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		if h.ipAllowlist != nil {
+			h.ipAllowlist.SetAllowed(allowed)
+		}
+		h.logger.Info("IP allowlist changed", "allowed", allowed, "by", h.currentUser(r))
+		result["allowed_ips"] = allowed
+	}
+
+	// Handle branding
+	if raw, ok := body["branding"]; ok {
+		var b Branding
+		if err := json.Unmarshal(raw, &b); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid branding")
+			return
+		}
+		if err := b.validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data, _ := json.Marshal(b)
+		if err := h.store.SetSetting("branding", string(data)); err != nil {
+			h.logger.Error("failed to save branding setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["branding"] = b
+	}
+
+	// Handle both_exclude
+	if raw, ok := body["both_exclude"]; ok {
+		var providers []string
+		if err := json.Unmarshal(raw, &providers); err != nil {
+			respondError(w, http.StatusBadRequest, "both_exclude must be a list of providers")
+			return
+		}
+		excluded, err := normalizeBothExclude(providers)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data, _ := json.Marshal(excluded)
+		if err := h.store.SetSetting("both_exclude", string(data)); err != nil {
+			h.logger.Error("failed to save both_exclude setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["both_exclude"] = excluded
+	}
+
+	// Handle status_public
+	if raw, ok := body["status_public"]; ok {
+		var public bool
+		if err := json.Unmarshal(raw, &public); err != nil {
+			respondError(w, http.StatusBadRequest, "status_public must be a boolean")
+			return
+		}
+		if err := h.store.SetSetting("status_public", strconv.FormatBool(public)); err != nil {
+			h.logger.Error("failed to save status_public setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["status_public"] = public
+	}
+
+	// Handle firehose_url
+	if raw, ok := body["firehose_url"]; ok {
+		var firehoseURL string
+		if err := json.Unmarshal(raw, &firehoseURL); err != nil {
+			respondError(w, http.StatusBadRequest, "firehose_url must be a string")
+			return
+		}
+		firehoseURL = strings.TrimSpace(firehoseURL)
+		if err := validateFirehoseURL(firehoseURL); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := h.store.SetSetting("firehose_url", firehoseURL); err != nil {
+			h.logger.Error("failed to save firehose_url setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["firehose_url"] = firehoseURL
+	}
+
+	// Handle firehose_secret: blank keeps the saved secret, null clears it
+	if raw, ok := body["firehose_secret"]; ok {
+		var secret *string
+		if err := json.Unmarshal(raw, &secret); err != nil {
+			respondError(w, http.StatusBadRequest, "firehose_secret must be a string")
+			return
+		}
+		if secret == nil {
+			if err := h.store.SetSetting("firehose_secret", ""); err != nil {
+				h.logger.Error("failed to save firehose_secret setting", "error", err)
+				respondError(w, http.StatusInternalServerError, "failed to save setting")
+				return
+			}
+			result["firehose_secret_set"] = false
+		} else if enc := strings.TrimSpace(*secret); enc != "" {
+			if err := h.encryptSettingFields(&enc); err != nil {
+				h.logger.Error("failed to encrypt firehose secret", "error", err)
+				respondError(w, http.StatusInternalServerError, "failed to encrypt firehose secret")
+				return
+			}
+			if err := h.store.SetSetting("firehose_secret", enc); err != nil {
+				h.logger.Error("failed to save firehose_secret setting", "error", err)
+				respondError(w, http.StatusInternalServerError, "failed to save setting")
+				return
+			}
+			result["firehose_secret_set"] = true
+		}
+	}
+
+	// Handle firehose_providers
+	if raw, ok := body["firehose_providers"]; ok {
+		var providers []string
+		if err := json.Unmarshal(raw, &providers); err != nil {
+			respondError(w, http.StatusBadRequest, "firehose_providers must be a list of providers")
+			return
+		}
+		normalized, err := normalizeFirehoseProviders(providers)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data, _ := json.Marshal(normalized)
+		if err := h.store.SetSetting("firehose_providers", string(data)); err != nil {
+			h.logger.Error("failed to save firehose_providers setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["firehose_providers"] = normalized
+	}
+
+	// Handle firehose_enabled
+	if raw, ok := body["firehose_enabled"]; ok {
+		var enabled bool
+		if err := json.Unmarshal(raw, &enabled); err != nil {
+			respondError(w, http.StatusBadRequest, "firehose_enabled must be a boolean")
+			return
+		}
+		if enabled && h.firehoseURL() == "" {
+			respondError(w, http.StatusBadRequest, "firehose_url is required to enable the firehose")
+			return
+		}
+		if err := h.store.SetSetting("firehose_enabled", strconv.FormatBool(enabled)); err != nil {
+			h.logger.Error("failed to save firehose_enabled setting", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save setting")
+			return
+		}
+		result["firehose_enabled"] = enabled
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// settingsExportVersion is the format version written by settings export.
+const settingsExportVersion = 1
+
+// importableSettings are the settings keys a settings import restores: the
+// same keys UpdateSettings accepts.
+var importableSettings = []string{
+	"timezone", "provider_timezones", "hidden_insights", "smtp", "matrix", "twilio", "notifications",
+	"notification_templates", "pricing", "budget", "provider_visibility",
+	"update_channel", "status_public", "widget_origins", "number_format",
+	"chart_max_points", "status_bands", "remember_me_days", "session_ttl_minutes",
+	"allowed_ips", "branding", "both_exclude", "poll_min_interval",
+	"snapshot_retention_days", "cycle_retention_days",
+	"firehose_url", "firehose_secret", "firehose_providers", "firehose_enabled",
+}
+
+// ErrInvalidSettingsImport is returned when an imported settings document is
+// malformed or fails validation.
+var ErrInvalidSettingsImport = errors.New("invalid settings import")
+
+// SettingsExport is a portable copy of the dashboard settings, for backup or
+// moving onWatch to another machine.
+type SettingsExport struct {
+	Version         int                    `json:"version"`
+	ExportedAt      time.Time              `json:"exported_at"`
+	SecretsIncluded bool                   `json:"secrets_included"`
+	Settings        map[string]interface{} `json:"settings"`
+}
+
+// ExportSettingsData returns all settings. Credentials are blanked unless
+// includeSecrets is true, in which case they are exported in plaintext (stored
+// credentials are encrypted with a key tied to this install's admin password,
+// so they could not be decrypted elsewhere).
+func (h *Handler) ExportSettingsData(includeSecrets bool) *SettingsExport {
+	return &SettingsExport{
+		Version:         settingsExportVersion,
+		ExportedAt:      time.Now().UTC(),
+		SecretsIncluded: includeSecrets,
+		Settings:        h.collectSettings(includeSecrets),
+	}
+}
+
+// ImportSettingsData restores settings from an export document (or a bare
+// settings object), validating every key exactly as UpdateSettings does. The
+// whole document is validated against a scratch store first, so a bad key
+// leaves the current settings untouched. Matrix and Twilio sections exported
+// without their credentials are skipped when this install has none to keep.
+func (h *Handler) ImportSettingsData(data []byte) (imported, skipped []string, err error) {
+	if h.store == nil {
+		return nil, nil, fmt.Errorf("store not available")
+	}
+	var doc struct {
+		Version  int                        `json:"version"`
+		Settings map[string]json.RawMessage `json:"settings"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid JSON", ErrInvalidSettingsImport)
+	}
+	if doc.Settings == nil {
+		// Accept a bare settings object, as returned by GET /api/settings
+		if err := json.Unmarshal(data, &doc.Settings); err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid JSON", ErrInvalidSettingsImport)
+		}
+		delete(doc.Settings, "version")
+	}
+	if doc.Version > settingsExportVersion {
+		return nil, nil, fmt.Errorf("%w: unsupported export version %d", ErrInvalidSettingsImport, doc.Version)
+	}
+	delete(doc.Settings, "firehose_secret_set") // informational, from collectSettings
+	for key := range doc.Settings {
+		if !slices.Contains(importableSettings, key) {
+			return nil, nil, fmt.Errorf("%w: unknown setting %q", ErrInvalidSettingsImport, key)
+		}
+	}
+
+	if raw, ok := doc.Settings["matrix"]; ok && !h.importHasSecret(raw, "matrix", "access_token") {
+		delete(doc.Settings, "matrix")
+		skipped = append(skipped, "matrix")
+	}
+	if raw, ok := doc.Settings["twilio"]; ok && !h.importHasSecret(raw, "twilio", "auth_token") {
+		delete(doc.Settings, "twilio")
+		skipped = append(skipped, "twilio")
+	}
+	if len(doc.Settings) == 0 {
+		return nil, skipped, nil
+	}
+	body, _ := json.Marshal(doc.Settings)
+	// The dry run applies its status bands too; end on the saved ones
+	defer h.loadStatusBands()
+
+	// Dry run against a scratch store seeded with the current settings, so
+	// credentials that are kept when left blank resolve the same way.
+	scratch, err := store.New(":memory:")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create scratch store: %w", err)
+	}
+	defer scratch.Close()
+	for _, key := range importableSettings {
+		if v, err := h.store.GetSetting(key); err == nil && v != "" {
+			scratch.SetSetting(key, v)
+		}
+	}
+	dry := &Handler{store: scratch, logger: h.logger, sessions: h.sessions, config: h.config}
+	if status, msg := runUpdateSettings(dry, body); status != http.StatusOK {
+		if status == http.StatusBadRequest {
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidSettingsImport, msg)
+		}
+		return nil, nil, fmt.Errorf("failed to validate settings: %s", msg)
+	}
+
+	if status, msg := runUpdateSettings(h, body); status != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to import settings: %s", msg)
+	}
+	for _, key := range importableSettings {
+		if _, ok := doc.Settings[key]; ok {
+			imported = append(imported, key)
+		}
+	}
+	return imported, skipped, nil
+}
+
+// importHasSecret reports whether an imported credential section can be
+// saved: it carries its secret field, is empty (clearing the channel), or
+// this install already has a secret that a blank value keeps.
+func (h *Handler) importHasSecret(raw json.RawMessage, key, secretField string) bool {
+	var section map[string]interface{}
+	if json.Unmarshal(raw, &section) != nil {
+		return true // let validation report it
+	}
+	if v, _ := section[secretField].(string); v != "" {
+		return true
+	}
+	configured := false
+	for field, v := range section {
+		if s, ok := v.(string); ok && s != "" && field != secretField {
+			configured = true
+		}
+	}
+	if !configured {
+		return true
+	}
+	existingJSON, _ := h.store.GetSetting(key)
+	var existing map[string]interface{}
+	if existingJSON != "" && json.Unmarshal([]byte(existingJSON), &existing) == nil {
+		v, _ := existing[secretField].(string)
+		return v != ""
+	}
+	return false
+}
+
+// runUpdateSettings applies body through target's UpdateSettings and returns
+// the response status and error message, without writing to any client.
+func runUpdateSettings(target *Handler, body []byte) (int, string) {
+	req, _ := http.NewRequest(http.MethodPut, "/api/settings", bytes.NewReader(body))
+	rec := &bufferedResponse{header: http.Header{}}
+	target.UpdateSettings(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	var resp struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(rec.body.Bytes(), &resp)
+	return rec.status, resp.Error
+}
+
+// bufferedResponse is an http.ResponseWriter that keeps the response in memory.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// ExportSettings handles GET /api/settings/export. Credentials are redacted
+// unless the request has include_secrets=true and confirm=yes.
+func (h *Handler) ExportSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	includeSecrets := r.URL.Query().Get("include_secrets") == "true"
+	if includeSecrets && r.URL.Query().Get("confirm") != "yes" {
+		respondError(w, http.StatusBadRequest, "include_secrets=true exports credentials in plaintext; add confirm=yes to proceed")
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="onwatch-settings.json"`)
+	respondJSON(w, http.StatusOK, h.ExportSettingsData(includeSecrets))
+}
+
+// ImportSettings handles POST /api/settings/import with a settings export
+// as the body.
+func (h *Handler) ImportSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 64*1024))
+	if err != nil {
+		respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	imported, skipped, err := h.ImportSettingsData(data)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSettingsImport) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("failed to import settings", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to import settings")
+		return
+	}
+	if imported == nil {
+		imported = []string{}
+	}
+	if skipped == nil {
+		skipped = []string{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"imported": imported,
+		"skipped":  skipped,
+	})
+}
+
+// isTemplateChannel reports whether channel accepts message templates.
+func isTemplateChannel(channel string) bool {
+	for _, c := range notify.TemplateChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplatePreview renders a message template against sample quota data without sending it.
+// Templates that fail to render fall back to the default wording, and the error is returned.
+func (h *Handler) TemplatePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+
+	var req struct {
+		Channel string `json:"channel"`
+		Type    string `json:"type"`
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.Channel == "" {
+		req.Channel = "email"
+	}
+	if !isTemplateChannel(req.Channel) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown template channel: %s", req.Channel))
+		return
+	}
+	if req.Type == "" {
+		req.Type = "warning"
+	}
+	known := false
+	for _, level := range notify.NotificationLevels {
+		if level == req.Type {
+			known = true
+			break
+		}
+	}
+	if !known {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown notification type: %s", req.Type))
+		return
+	}
+
+	resetAt := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Minute)
+	sample := notify.QuotaStatus{
+		Provider:    "anthropic",
+		QuotaKey:    "five_hour",
+		Utilization: 82.5,
+		Limit:       100,
+		ResetAt:     &resetAt,
+	}
+
+	subject, body, err := notify.RenderMessage(req.Channel, notify.MessageTemplate{Subject: req.Subject, Body: req.Body}, sample, req.Type)
+	result := map[string]interface{}{
+		"subject": subject,
+		"body":    body,
+	}
+	if err != nil {
+		result["error"] = err.Error()
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// SMTPTest sends a test email via the configured SMTP settings.
+func (h *Handler) SMTPTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Rate limit: 30 second cooldown
+	h.smtpTestMu.Lock()
+	elapsed := time.Since(h.smtpTestLastSent)
+	if elapsed < 30*time.Second {
+		h.smtpTestMu.Unlock()
+		remaining := int((30*time.Second - elapsed).Seconds())
+		respondError(w, http.StatusTooManyRequests, fmt.Sprintf("please wait %d seconds before sending another test", remaining))
+		return
+	}
+	h.smtpTestLastSent = time.Now()
+	h.smtpTestMu.Unlock()
+
+	if h.notifier == nil {
+		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+		return
+	}
+
+	if err := h.notifier.SendTestEmail(); err != nil {
+		h.logger.Error("SMTP test failed", "error", err)
+		// Sanitize error message to prevent information leakage
+		errorMsg := sanitizeSMTPError(err)
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": false,
+			"message": errorMsg,
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Test email sent successfully",
+	})
+}
+
+// MatrixTest posts a test message to the configured Matrix room.
+func (h *Handler) MatrixTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Rate limit: 30 second cooldown
+	h.matrixTestMu.Lock()
+	elapsed := time.Since(h.matrixTestLastSent)
+	if elapsed < 30*time.Second {
+		h.matrixTestMu.Unlock()
+		remaining := int((30*time.Second - elapsed).Seconds())
+		respondError(w, http.StatusTooManyRequests, fmt.Sprintf("please wait %d seconds before sending another test", remaining))
+		return
+	}
+	h.matrixTestLastSent = time.Now()
+	h.matrixTestMu.Unlock()
+
+	if h.notifier == nil {
+		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+		return
+	}
+
+	if err := h.notifier.SendTestMatrix(); err != nil {
+		h.logger.Error("Matrix test failed", "error", err)
+		// Return generic error message to prevent information leakage
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": false,
+			"message": "Matrix test failed",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Test message sent successfully",
+	})
+}
+
+// SMSTest sends a test SMS via the configured Twilio settings.
+func (h *Handler) SMSTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Rate limit: 60 second cooldown (each test costs an SMS)
+	h.smsTestMu.Lock()
+	elapsed := time.Since(h.smsTestLastSent)
+	if elapsed < 60*time.Second {
+		h.smsTestMu.Unlock()
+		remaining := int((60*time.Second - elapsed).Seconds())
+		respondError(w, http.StatusTooManyRequests, fmt.Sprintf("please wait %d seconds before sending another test", remaining))
+		return
+	}
+	h.smsTestLastSent = time.Now()
+	h.smsTestMu.Unlock()
+
+	if h.notifier == nil {
+		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+		return
+	}
+
+	if err := h.notifier.SendTestSMS(); err != nil {
+		h.logger.Error("SMS test failed", "error", err)
+		// Return generic error message to prevent information leakage
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": false,
+			"message": "SMS test failed",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Test SMS sent successfully",
+	})
+}
+
+// PushVAPIDKey returns the VAPID public key for push subscription.
+func (h *Handler) PushVAPIDKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.notifier == nil {
+		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+		return
+	}
+	key := h.notifier.GetVAPIDPublicKey()
+	if key == "" {
+		respondError(w, http.StatusServiceUnavailable, "push notifications not configured")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"public_key": key})
+}
+
+// NotificationLog returns recently sent alerts with their acknowledgement state.
+func (h *Handler) NotificationLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.store.QueryNotificationLog(limit)
+	if err != nil {
+		h.logger.Error("failed to query notification log", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query notification log")
+		return
+	}
+	if entries == nil {
+		entries = []store.NotificationLogEntry{}
+	}
+	respondJSON(w, http.StatusOK, entries)
+}
+
+// NotificationAck acknowledges a sent alert, which stops it from escalating.
+func (h *Handler) NotificationAck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+
+	var body struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if body.ID <= 0 {
+		respondError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	found, err := h.store.AcknowledgeNotification(body.ID)
+	if err != nil {
+		h.logger.Error("failed to acknowledge notification", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to acknowledge notification")
+		return
+	}
+	if !found {
+		respondError(w, http.StatusNotFound, "notification not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "acknowledged"})
+}
+
+// notificationPruneLimit caps how many log entries one
+// DELETE /api/notifications removes.
+const notificationPruneLimit = 1000
+
+// NotificationAckAll acknowledges every unacknowledged alert at once.
+func (h *Handler) NotificationAckAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	n, err := h.store.AcknowledgeAllNotifications()
+	if err != nil {
+		h.logger.Error("failed to acknowledge notifications", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to acknowledge notifications")
+		return
+	}
+	h.logger.Info("Notifications acknowledged", "count", n, "by", h.currentUser(r))
+	respondJSON(w, http.StatusOK, map[string]interface{}{"status": "acknowledged", "acknowledged": n})
+}
+
+// Notifications handles DELETE /api/notifications?before=: prunes log
+// entries sent before the given time (RFC 3339 or YYYY-MM-DD, not in the
+// future), at most notificationPruneLimit per call. "more" tells the
+// caller to repeat. Open warning/critical alerts are kept.
+func (h *Handler) Notifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	v := r.URL.Query().Get("before")
+	if v == "" {
+		respondError(w, http.StatusBadRequest, "before is required")
+		return
+	}
+	before, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if before, err = time.Parse("2006-01-02", v); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid before: use RFC 3339 or YYYY-MM-DD")
+			return
+		}
+	}
+	if before.After(time.Now()) {
+		respondError(w, http.StatusBadRequest, "before must not be in the future")
+		return
+	}
+
+	n, err := h.store.PruneNotificationLog(before, notificationPruneLimit)
+	if err != nil {
+		h.logger.Error("failed to prune notification log", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to prune notification log")
+		return
+	}
+	h.logger.Info("Notification log pruned", "before", before.UTC().Format(time.RFC3339), "count", n, "by", h.currentUser(r))
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"deleted": n,
+		"more":    n == notificationPruneLimit,
+	})
+}
+
+// currentUser returns the username of the signed-in user. Requests that did
+// not pass the session middleware are the configured admin's.
+func (h *Handler) currentUser(r *http.Request) string {
+	if sess, ok := sessionFromContext(r); ok {
+		return sess.Username
+	}
+	if h.sessions != nil {
+		return h.sessions.username
+	}
+	if h.config != nil {
+		return h.config.AdminUser
+	}
+	return ""
+}
+
+// NotificationPrefs reads (GET) or replaces (PUT) the signed-in user's own
+// notification preferences. Alerts are sent to their recipients in addition
+// to the global ones; an empty object clears them.
+func (h *Handler) NotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	username := h.currentUser(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		prefs := notify.UserNotificationPrefs{}
+		raw, err := h.store.GetUserNotificationPrefs(username)
+		if err != nil {
+			h.logger.Error("failed to load notification preferences", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to load notification preferences")
+			return
+		}
+		if raw != "" {
+			json.Unmarshal([]byte(raw), &prefs)
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{"username": username, "prefs": prefs})
+	case http.MethodPut:
+		r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+		var prefs notify.UserNotificationPrefs
+		if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+		if err := prefs.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// SMS goes out through the shared Twilio account, so viewers may only
+		// keep numbers already saved for them, not add new ones
+		if sess, ok := sessionFromContext(r); ok && !sess.IsAdmin() {
+			var stored notify.UserNotificationPrefs
+			if v, _ := h.store.GetUserNotificationPrefs(username); v != "" {
+				json.Unmarshal([]byte(v), &stored)
+			}
+			for _, n := range prefs.SMSNumbers {
+				if !slices.Contains(stored.SMSNumbers, n) {
+					respondError(w, http.StatusForbidden, "only admins can add SMS numbers")
+					return
+				}
+			}
+		}
+		raw := ""
+		if data, _ := json.Marshal(prefs); string(data) != "{}" {
+			raw = string(data)
+		}
+		if err := h.store.SetUserNotificationPrefs(username, raw); err != nil {
+			h.logger.Error("failed to save notification preferences", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save notification preferences")
+			return
+		}
+		if h.notifier != nil {
+			if err := h.notifier.Reload(); err != nil {
+				h.logger.Error("failed to reload notifier after preference update", "error", err)
+			}
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{"username": username, "prefs": prefs})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// Availability returns API uptime, outage count and longest outage per provider
+// over the requested range (default 30d). provider=both returns every configured provider.
+func (h *Handler) Availability(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	provider, err := h.getProviderFromRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rangeStr := r.URL.Query().Get("range")
+	if rangeStr == "" {
+		rangeStr = "30d"
+	}
+	duration, err := parseTimeRange(rangeStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	since := time.Now().UTC().Add(-duration)
+
+	providers := []string{provider}
+	if provider == "both" {
+		providers = h.config.AvailableProviders()
+	}
+
+	results := make([]*store.Availability, 0, len(providers))
+	for _, p := range providers {
+		a, err := h.store.QueryAvailability(p, since)
+		if err != nil {
+			h.logger.Error("failed to query availability", "provider", p, "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to query availability")
+			return
+		}
+		results = append(results, a)
+	}
+	respondJSON(w, http.StatusOK, results)
+}
+
+// CostProjection returns spend to date and projected end-of-month spend per
+// provider for the current billing month. Returns 404 until pricing is configured.
+func (h *Handler) CostProjection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	pricing, err := h.store.GetPricing()
+	if err != nil {
+		h.logger.Error("failed to load pricing", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load pricing")
+		return
+	}
+	if pricing == nil {
+		respondError(w, http.StatusNotFound, "pricing not configured")
+		return
+	}
+
+	projection, err := h.store.ProjectCost(*pricing, time.Now().UTC())
+	if err != nil {
+		h.logger.Error("failed to project cost", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to project cost")
+		return
+	}
+	h.numberFormat().addCostDisplay(projection)
+	respondJSON(w, http.StatusOK, projection)
+}
+
+// AgentStatus returns the circuit breaker state of each polling agent, so a
+// provider paused after repeated auth/server failures is visible.
+func (h *Handler) AgentStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	statuses := []agent.CircuitStatus{}
+	if h.breakers != nil {
+		statuses = h.breakers.Statuses()
+	}
+	respondJSON(w, http.StatusOK, statuses)
+}
+
+// statusSeverity ranks quota statuses from best to worst.
+var statusSeverity = map[string]int{"healthy": 0, "warning": 1, "danger": 2, "critical": 3}
+
+// statusPageRow is one provider on the status page.
+type statusPageRow struct {
+	Provider  string
+	Status    string // worst status of the provider's quotas
+	Quota     string // the provider's most used quota
+	Percent   float64
+	NextReset string // countdown to the provider's soonest reset, if known
+
+	resetsAt time.Time
+}
+
+// statusPagePublic reports whether /status may be viewed without logging in.
+func (h *Handler) statusPagePublic() bool {
+	if h.store == nil {
+		return false
+	}
+	v, _ := h.store.GetSetting("status_public")
+	return v == "true"
+}
+
+// StatusPage handles GET /status, a compact read-only page with one health
+// indicator per provider: its worst quota status, most used quota and next
+// reset. With the status_public setting it needs no login and may be framed,
+// so it can be embedded in a wiki or shown on a TV dashboard.
+func (h *Handler) StatusPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rows []statusPageRow
+	now := time.Now()
+	for _, level := range h.currentQuotaLevels() {
+		if len(rows) == 0 || rows[len(rows)-1].Provider != level.Provider {
+			rows = append(rows, statusPageRow{Provider: level.Provider, Status: "healthy", Percent: -1})
+		}
+		row := &rows[len(rows)-1]
+		if statusSeverity[level.Status] > statusSeverity[row.Status] {
+			row.Status = level.Status
+		}
+		if level.Percent > row.Percent {
+			row.Quota, row.Percent = level.Name, level.Percent
+		}
+		if level.ResetsAt != nil && (row.resetsAt.IsZero() || level.ResetsAt.Before(row.resetsAt)) {
+			row.resetsAt = *level.ResetsAt
+		}
+	}
+	for i := range rows {
+		if name, ok := providerDisplayNames[rows[i].Provider]; ok {
+			rows[i].Provider = name
+		}
+		if !rows[i].resetsAt.IsZero() {
+			rows[i].NextReset = formatDuration(rows[i].resetsAt.Sub(now))
+		}
+	}
+
+	data := map[string]interface{}{
+		"Rows":      rows,
+		"UpdatedAt": now.UTC().Format(time.RFC3339),
+	}
+	if h.statusPagePublic() {
+		w.Header().Del("X-Frame-Options")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := h.statusTmpl.ExecuteTemplate(w, "status.html", data); err != nil {
+		h.logger.Error("failed to render status template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// allowlistClientIP returns the caller's address as the IP allowlist sees it,
+// honouring forwarded headers only from ONWATCH_TRUSTED_PROXIES.
+func (h *Handler) allowlistClientIP(r *http.Request) string {
+	if h.ipAllowlist != nil {
+		return h.ipAllowlist.ClientIP(r)
+	}
+	var trusted []string
+	if h.config != nil {
+		trusted = h.config.TrustedProxies
+	}
+	return allowlistClientIP(r, trusted)
+}
+
+// allowedIPs returns the saved allowed_ips setting; empty allows every
+// address.
+func (h *Handler) allowedIPs() []string {
+	allowed := []string{}
+	if h.store != nil {
+		if v, _ := h.store.GetSetting("allowed_ips"); v != "" {
+			_ = json.Unmarshal([]byte(v), &allowed)
+		}
+	}
+	return allowed
+}
+
+// normalizeWidgetOrigins validates the origins allowed to fetch the widget
+// cross-origin. Each must be "*" or a bare http(s) origin such as
+// "https://portal.example.com"; trailing slashes are dropped.
+func normalizeWidgetOrigins(origins []string) ([]string, error) {
+	out := make([]string, 0, len(origins))
+	for _, o := range origins {
+		o = strings.TrimRight(strings.TrimSpace(o), "/")
+		if o == "" {
+			continue
+		}
+		if o != "*" {
+			u, err := url.Parse(o)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+				return nil, fmt.Errorf("invalid widget origin %q: use e.g. https://portal.example.com", o)
+			}
+		}
+		if !slices.Contains(out, o) {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+// providerTimezones returns the per-provider timezone overrides.
+func (h *Handler) providerTimezones() map[string]string {
+	overrides := map[string]string{}
+	if h.store != nil {
+		if v, _ := h.store.GetSetting("provider_timezones"); v != "" {
+			_ = json.Unmarshal([]byte(v), &overrides)
+		}
+	}
+	return overrides
+}
+
+// providerLocation is the timezone a provider's times are shown in: its
+// override, else the global timezone setting, else UTC.
+func (h *Handler) providerLocation(provider string) *time.Location {
+	tz := h.providerTimezones()[provider]
+	if tz == "" && h.store != nil {
+		tz, _ = h.store.GetSetting("timezone")
+	}
+	if loc, err := time.LoadLocation(tz); err == nil && tz != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// widgetOrigins returns the origins allowed to fetch the widget cross-origin.
+func (h *Handler) widgetOrigins() []string {
+	origins := []string{}
+	if h.store != nil {
+		if v, _ := h.store.GetSetting("widget_origins"); v != "" {
+			_ = json.Unmarshal([]byte(v), &origins)
+		}
+	}
+	return origins
+}
+
+// Widget handles GET /api/widget?provider=X&quota=Y, a single quota packaged
+// for embedding in other sites: a tiny self-contained HTML snippet that is
+// safe to iframe, or JSON with format=json for script widgets. Without quota
+// the provider's most used quota is shown. Origins listed in the
+// widget_origins setting get CORS headers with credentials; "*" lets any
+// origin read the widget, but never with credentials.
+func (h *Handler) Widget(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		allowed := h.widgetOrigins()
+		switch {
+		case slices.Contains(allowed, origin):
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		case slices.Contains(allowed, "*"):
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+		}
+		w.Header().Add("Vary", "Origin")
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	provider := strings.ToLower(r.URL.Query().Get("provider"))
+	if provider == "" || !h.config.HasProvider(provider) {
+		respondError(w, http.StatusBadRequest, "provider must be a configured provider")
+		return
+	}
+	quota := r.URL.Query().Get("quota")
+
+	var level *quotaLevel
+	for _, l := range h.currentQuotaLevels() {
+		if l.Provider != provider {
+			continue
+		}
+		if quota == "" {
+			if level == nil || l.Percent > level.Percent {
+				level = &l
+			}
+		} else if strings.EqualFold(l.Quota, quota) {
+			level = &l
+			break
+		}
+	}
+	if level == nil {
+		respondError(w, http.StatusNotFound, "no data for this quota yet")
+		return
+	}
+
+	resetIn := ""
+	if level.ResetsAt != nil {
+		resetIn = formatDuration(time.Until(*level.ResetsAt))
+	}
+	providerName := provider
+	if name, ok := providerDisplayNames[provider]; ok {
+		providerName = name
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Query().Get("format") == "json" {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"provider": provider,
+			"quota":    level.Quota,
+			"name":     level.Name,
+			"percent":  level.Percent,
+			"status":   level.Status,
+			"resetsAt": level.ResetsAt,
+			"resetIn":  resetIn,
+		})
+		return
+	}
+
+	// The snippet carries no scripts or links, so framing it anywhere is harmless
+	w.Header().Del("X-Frame-Options")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := map[string]interface{}{
+		"Provider": providerName,
+		"Name":     level.Name,
+		"Percent":  level.Percent,
+		"Status":   level.Status,
+		"ResetIn":  resetIn,
+	}
+	if err := h.statusTmpl.ExecuteTemplate(w, "widget.html", data); err != nil {
+		h.logger.Error("failed to render widget template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// compactEntry is one provider's line in /api/compact.
+type compactEntry struct {
+	Provider       string  `json:"provider"`
+	TopQuota       string  `json:"topQuota"`
+	Percent        float64 `json:"percent"`
+	Status         string  `json:"status"`
+	ResetCountdown string  `json:"resetCountdown,omitempty"`
+}
+
+// Compact handles GET /api/compact: each provider's most used quota in one
+// small array, for menu-bar and tray apps that have room for a line per
+// provider. Providers without data yet are left out.
+func (h *Handler) Compact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	entries := []compactEntry{}
+	index := map[string]int{}
+	for _, level := range h.currentQuotaLevels() {
+		if i, ok := index[level.Provider]; ok && level.Percent <= entries[i].Percent {
+			continue
+		}
+		entry := compactEntry{
+			Provider: level.Provider,
+			TopQuota: level.Name,
+			Percent:  math.Round(level.Percent*10) / 10,
+			Status:   level.Status,
+		}
+		if level.ResetsAt != nil {
+			entry.ResetCountdown = formatDuration(time.Until(*level.ResetsAt))
+		}
+		if i, ok := index[level.Provider]; ok {
+			entries[i] = entry
+		} else {
+			index[level.Provider] = len(entries)
+			entries = append(entries, entry)
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondJSON(w, http.StatusOK, entries)
+}
+
+// ClearData handles DELETE /api/data?provider=X&confirm=true, which purges
+// every snapshot, cycle and session stored for one provider (e.g. after
+// switching accounts) and leaves the other providers untouched.
+func (h *Handler) ClearData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusServiceUnavailable, "store not available")
+		return
+	}
+
+	provider := strings.ToLower(r.URL.Query().Get("provider"))
+	if !store.IsClearableProvider(provider) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("provider must be one of: %s", strings.Join(store.ClearableProviders(), ", ")))
+		return
+	}
+	if r.URL.Query().Get("confirm") != "true" {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("this permanently deletes all stored %s data; repeat with confirm=true", provider))
+		return
+	}
+
+	deleted, err := h.store.ClearProviderData(provider)
+	if err != nil {
+		h.logger.Error("failed to clear provider data", "provider", provider, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to clear provider data")
+		return
+	}
+	h.latest.Forget(provider)
+	h.logger.Warn("Cleared provider data", "provider", provider, "deleted", deleted)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"provider": provider,
+		"deleted":  deleted,
+	})
+}
+
+// Metrics handles GET /metrics, exposing per-provider poll counters in the
+// Prometheus text format. Counters reset when the daemon restarts.
+func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var counters []agent.PollCounters
+	if h.breakers != nil {
+		counters = h.breakers.Counters()
+	}
+
+	var b strings.Builder
+	b.WriteString("# HELP onwatch_polls_total Provider API polls since the daemon started, by result.\n")
+	b.WriteString("# TYPE onwatch_polls_total counter\n")
+	for _, c := range counters {
+		fmt.Fprintf(&b, "onwatch_polls_total{provider=%q,result=\"success\"} %d\n", c.Provider, c.Successes)
+		fmt.Fprintf(&b, "onwatch_polls_total{provider=%q,result=\"error\"} %d\n", c.Provider, c.Errors)
+	}
+	b.WriteString("# HELP onwatch_poll_errors_total Failed provider API polls since the daemon started.\n")
+	b.WriteString("# TYPE onwatch_poll_errors_total counter\n")
+	for _, c := range counters {
+		fmt.Fprintf(&b, "onwatch_poll_errors_total{provider=%q} %d\n", c.Provider, c.Errors)
+	}
+	b.WriteString("# HELP onwatch_last_poll_timestamp Unix time of the provider's last poll, successful or not.\n")
+	b.WriteString("# TYPE onwatch_last_poll_timestamp gauge\n")
+	for _, c := range counters {
+		if c.LastPollAt.IsZero() {
+			continue
+		}
+		fmt.Fprintf(&b, "onwatch_last_poll_timestamp{provider=%q} %d\n", c.Provider, c.LastPollAt.Unix())
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, b.String())
+}
+
+// DebugSnapshot handles /api/debug/snapshot, which only works when
+// ONWATCH_ALLOW_DEBUG_WRITES is set. POST ?provider=X takes a response body in
+// the provider's own API format and runs it through the normal store, tracker
+// and notifier path, so alerting can be tested without real usage. GET lists
+// past injections, which are recorded separately from polled data.
+func (h *Handler) DebugSnapshot(w http.ResponseWriter, r *http.Request) {
+	if h.config == nil || !h.config.AllowDebugWrites {
+		respondError(w, http.StatusForbidden, "debug writes are disabled; set ONWATCH_ALLOW_DEBUG_WRITES=true")
+		return
+	}
+	provider := r.URL.Query().Get("provider")
+
+	switch r.Method {
+	case http.MethodGet:
+		injected, err := h.store.QueryInjectedSnapshots(provider, 100)
+		if err != nil {
+			h.logger.Error("failed to query injected snapshots", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to query injected snapshots")
+			return
+		}
+		if injected == nil {
+			injected = []store.InjectedSnapshot{}
+		}
+		respondJSON(w, http.StatusOK, injected)
+	case http.MethodPost:
+		inj, ok := h.injectors[provider]
+		if !ok {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("cannot inject for provider %q: supported providers are synthetic, zai and anthropic, and must be configured", provider))
+			return
+		}
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 64*1024))
+		if err != nil {
+			respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		capturedAt, err := inj.InjectSnapshot(data)
+		if errors.Is(err, agent.ErrInvalidInjection) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			h.logger.Error("failed to inject snapshot", "provider", provider, "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to inject snapshot")
+			return
+		}
+		respondJSON(w, http.StatusCreated, map[string]interface{}{
+			"provider":    provider,
+			"captured_at": capturedAt.Format(time.RFC3339Nano),
+			"injected":    true,
+		})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// CopilotInfo returns the plan and per-quota entitlements from the latest
+// Copilot snapshot, e.g. for showing "Individual Pro – Premium Requests:
+// 40/300". Unlimited quotas carry no counts and a usage of "unlimited".
+func (h *Handler) CopilotInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.config == nil || !h.config.HasProvider("copilot") {
+		respondError(w, http.StatusNotFound, "copilot provider not configured")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	latest, err := h.store.QueryLatestCopilot()
+	if err != nil {
+		h.logger.Error("failed to query latest Copilot snapshot", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query Copilot data")
+		return
+	}
+	if latest == nil {
+		respondError(w, http.StatusNotFound, "no Copilot data yet")
+		return
+	}
+
+	quotas := []map[string]interface{}{}
+	for _, q := range latest.Quotas {
+		qMap := map[string]interface{}{
+			"name":        q.Name,
+			"displayName": api.CopilotDisplayName(q.Name),
+			"unlimited":   q.Unlimited,
+		}
+		if q.Unlimited {
+			qMap["usage"] = "unlimited"
+		} else {
+			used := q.Entitlement - q.Remaining
+			qMap["entitlement"] = q.Entitlement
+			qMap["remaining"] = q.Remaining
+			qMap["used"] = used
+			qMap["overageCount"] = q.OverageCount
+			qMap["usage"] = fmt.Sprintf("%d/%d", used, q.Entitlement)
+		}
+		quotas = append(quotas, qMap)
+	}
+
+	response := map[string]interface{}{
+		"plan":       latest.CopilotPlan,
+		"planName":   api.CopilotPlanDisplayName(latest.CopilotPlan),
+		"capturedAt": latest.CapturedAt.Format(time.RFC3339),
+		"quotas":     quotas,
+	}
+	if latest.ResetDate != nil {
+		timeUntilReset := time.Until(*latest.ResetDate)
+		response["resetDate"] = latest.ResetDate.Format(time.RFC3339)
+		response["timeUntilReset"] = formatDuration(timeUntilReset)
+		response["timeUntilResetSeconds"] = int64(timeUntilReset.Seconds())
+	}
+	respondJSON(w, http.StatusOK, response)
 }
 
-// SMTPTest sends a test email via the configured SMTP settings.
-func (h *Handler) SMTPTest(w http.ResponseWriter, r *http.Request) {
+// Limits for on-demand polls: at most forcePollConcurrency providers are
+// fetched at once, and all of them share forcePollTimeout.
+const (
+	forcePollConcurrency = 4
+	forcePollTimeout     = 30 * time.Second
+)
+
+// Bounds and default, in seconds, for the poll_min_interval setting.
+const (
+	defaultPollMinInterval = 30
+	maxPollMinInterval     = 3600
+)
+
+// pollMinInterval returns the poll_min_interval setting: the minimum time
+// between on-demand polls of one provider. Zero turns the limit off.
+func (h *Handler) pollMinInterval() int {
+	if h.store == nil {
+		return defaultPollMinInterval
+	}
+	v, _ := h.store.GetSetting("poll_min_interval")
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > maxPollMinInterval {
+		return defaultPollMinInterval
+	}
+	return n
+}
+
+// reservePolls splits pollers into those due an on-demand poll, which are
+// marked as polled now, and those polled within poll_min_interval, with the
+// wait before each may be polled again. Manual polls that hammer a provider
+// would burn through its API rate limit.
+func (h *Handler) reservePolls(pollers map[string]agent.Poller) (map[string]agent.Poller, map[string]time.Duration) {
+	minInterval := time.Duration(h.pollMinInterval()) * time.Second
+	allowed := make(map[string]agent.Poller, len(pollers))
+	limited := map[string]time.Duration{}
+
+	h.pollLimitMu.Lock()
+	defer h.pollLimitMu.Unlock()
+	if h.pollLastRun == nil {
+		h.pollLastRun = make(map[string]time.Time)
+	}
+	now := time.Now()
+	for provider, p := range pollers {
+		if elapsed := now.Sub(h.pollLastRun[provider]); elapsed < minInterval {
+			limited[provider] = minInterval - elapsed
+			continue
+		}
+		h.pollLastRun[provider] = now
+		allowed[provider] = p
+	}
+	return allowed, limited
+}
+
+// pollProviders polls provider now, or every registered provider for "both"
+// (or an empty provider), concurrently. Providers polled within
+// poll_min_interval are skipped and reported as rate limited; retryAfter is
+// the shortest wait when that leaves nothing to poll.
+func (h *Handler) pollProviders(ctx context.Context, provider string) (results []agent.PollResult, retryAfter time.Duration, err error) {
+	pollers := h.pollers
+	if provider != "" && provider != "both" {
+		p, ok := h.pollers[provider]
+		if !ok {
+			return nil, 0, fmt.Errorf("provider %q is not configured", provider)
+		}
+		pollers = map[string]agent.Poller{provider: p}
+	}
+
+	allowed, limited := h.reservePolls(pollers)
+	if len(allowed) > 0 {
+		results = agent.PollAll(ctx, allowed, forcePollConcurrency, forcePollTimeout)
+	} else if len(limited) > 0 {
+		retryAfter = slices.Min(slices.Collect(maps.Values(limited)))
+	}
+	for p, wait := range limited {
+		results = append(results, agent.PollResult{
+			Provider: p,
+			Error:    fmt.Sprintf("rate limited: polled less than %ds ago, retry in %ds", h.pollMinInterval(), retrySeconds(wait)),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Provider < results[j].Provider })
+	return results, retryAfter, nil
+}
+
+// retrySeconds rounds a wait up to whole seconds for Retry-After.
+func retrySeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// Poll handles POST /api/poll?provider=X, which polls the provider (or all of
+// them with provider=both) right away instead of waiting for the next tick.
+// Providers are fetched in parallel and each reports its own result, so one
+// failing provider does not hide the others. Each provider can be polled
+// once per poll_min_interval; when none is due the answer is 429 with
+// Retry-After.
+func (h *Handler) Poll(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-
-	// Rate limit: 30 second cooldown
-	h.smtpTestMu.Lock()
-	elapsed := time.Since(h.smtpTestLastSent)
-	if elapsed < 30*time.Second {
-		h.smtpTestMu.Unlock()
-		remaining := int((30*time.Second - elapsed).Seconds())
-		respondError(w, http.StatusTooManyRequests, fmt.Sprintf("please wait %d seconds before sending another test", remaining))
+	results, retryAfter, err := h.pollProviders(r.Context(), strings.ToLower(r.URL.Query().Get("provider")))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	h.smtpTestLastSent = time.Now()
-	h.smtpTestMu.Unlock()
-
-	if h.notifier == nil {
-		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+	if retryAfter > 0 {
+		secs := retrySeconds(retryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		respondError(w, http.StatusTooManyRequests, fmt.Sprintf("please wait %d seconds before polling again", secs))
 		return
 	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
 
-	if err := h.notifier.SendTestEmail(); err != nil {
-		h.logger.Error("SMTP test failed", "error", err)
-		// Sanitize error message to prevent information leakage
-		errorMsg := sanitizeSMTPError(err)
-		respondJSON(w, http.StatusOK, map[string]interface{}{
-			"success": false,
-			"message": errorMsg,
-		})
+// Overview handles GET /api/overview: it polls every provider in parallel,
+// then returns each configured provider's current quotas alongside the
+// per-provider poll results.
+func (h *Handler) Overview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-
+	results, _, _ := h.pollProviders(r.Context(), "both")
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "Test email sent successfully",
+		"providers": h.buildAllCurrent(),
+		"poll":      results,
 	})
 }
 
-// PushVAPIDKey returns the VAPID public key for push subscription.
-func (h *Handler) PushVAPIDKey(w http.ResponseWriter, r *http.Request) {
+// AntigravityModels handles GET /api/antigravity/models, listing every model
+// ID seen in Antigravity snapshots with the label the dashboard shows for it.
+// Unknown IDs get a name derived from the ID; ANTIGRAVITY_MODEL_LABELS
+// overrides any of them.
+func (h *Handler) AntigravityModels(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-	if h.notifier == nil {
-		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+	if !h.config.HasProvider("antigravity") || h.store == nil {
+		respondError(w, http.StatusNotFound, "antigravity provider not configured")
 		return
 	}
-	key := h.notifier.GetVAPIDPublicKey()
-	if key == "" {
-		respondError(w, http.StatusServiceUnavailable, "push notifications not configured")
+
+	models, err := h.store.QueryAntigravityModels()
+	if err != nil {
+		h.logger.Error("failed to query antigravity models", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query models")
 		return
 	}
-	respondJSON(w, http.StatusOK, map[string]string{"public_key": key})
+	out := make([]map[string]interface{}, 0, len(models))
+	for _, m := range models {
+		label := api.AntigravityModelLabel(m.ModelID, m.Label)
+		group := api.AntigravityQuotaGroupForModel(m.ModelID, label)
+		out = append(out, map[string]interface{}{
+			"modelId":        m.ModelID,
+			"label":          label,
+			"apiLabel":       m.Label,
+			"quotaGroup":     group,
+			"quotaGroupName": api.AntigravityQuotaGroupDisplayName(group),
+			"firstSeen":      m.FirstSeen.Format(time.RFC3339),
+			"lastSeen":       m.LastSeen.Format(time.RFC3339),
+		})
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"models": out})
+}
+
+// maxSearchQueryLength caps the /api/search query string.
+const maxSearchQueryLength = 200
+
+// Search handles GET /api/search?q=...&provider=X, which looks for q in the
+// stored raw provider responses and returns the matching snapshots, newest
+// first. Without a provider (or with provider=all) every provider is searched.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusServiceUnavailable, "store not available")
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondError(w, http.StatusBadRequest, "missing search query q")
+		return
+	}
+	if len(q) > maxSearchQueryLength {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("search query too long (max %d characters)", maxSearchQueryLength))
+		return
+	}
+	provider := strings.ToLower(r.URL.Query().Get("provider"))
+	if provider == "all" {
+		provider = ""
+	}
+	if provider != "" && !store.IsSearchableProvider(provider) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown provider %q", provider))
+		return
+	}
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	matches, err := h.store.SearchRawSnapshots(provider, q, limit)
+	if err != nil {
+		h.logger.Error("failed to search raw snapshots", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to search snapshots")
+		return
+	}
+	if matches == nil {
+		matches = []store.RawSnapshotMatch{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"query":   q,
+		"matches": matches,
+	})
+}
+
+// Allowed range for the session idle timeout set from the dashboard.
+const (
+	minSessionTimeoutMinutes = 5
+	maxSessionTimeoutMinutes = 240
+)
+
+// SessionTimeout handles GET/PUT /api/settings/session-timeout. PUT applies the
+// new idle timeout to every running session manager and saves it, so it also
+// survives restarts.
+func (h *Handler) SessionTimeout(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"minutes": int(h.sessionIdleTimeout() / time.Minute),
+		})
+	case http.MethodPut:
+		r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+		var req struct {
+			Minutes *int `json:"minutes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Minutes == nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		minutes := *req.Minutes
+		if minutes < minSessionTimeoutMinutes || minutes > maxSessionTimeoutMinutes {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("minutes must be between %d and %d", minSessionTimeoutMinutes, maxSessionTimeoutMinutes))
+			return
+		}
+		timeout := time.Duration(minutes) * time.Minute
+		if err := h.store.SetSessionIdleTimeout(timeout); err != nil {
+			h.logger.Error("failed to save session idle timeout", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save session timeout")
+			return
+		}
+		for _, sm := range h.sessionManagers {
+			sm.SetIdleTimeout(timeout)
+		}
+		h.logger.Info("Session idle timeout updated", "minutes", minutes)
+		respondJSON(w, http.StatusOK, map[string]interface{}{"minutes": minutes})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// sessionIdleTimeout returns the saved idle timeout, falling back to the config.
+func (h *Handler) sessionIdleTimeout() time.Duration {
+	if h.store != nil {
+		if d, err := h.store.GetSessionIdleTimeout(); err == nil && d > 0 {
+			return d
+		}
+	}
+	if h.config != nil {
+		return h.config.SessionIdleTimeout
+	}
+	return 0
 }
 
 // PushSubscribe handles POST (subscribe) and DELETE (unsubscribe) for push notifications.
@@ -3626,9 +7342,12 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	errorMsg := loginErrors[errorCode] // empty string if not in whitelist
 
 	data := map[string]interface{}{
-		"Title":   "Login",
-		"Error":   errorMsg,
-		"Version": h.version,
+		"Title":         "Login",
+		"Error":         errorMsg,
+		"Version":       h.version,
+		"GitHubLogin":   h.github != nil,
+		"PasswordLogin": h.sessions == nil || h.sessions.PasswordLoginEnabled(),
+		"Brand":         h.pageBrand(w),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -3657,12 +7376,17 @@ func (h *Handler) loginPost(w http.ResponseWriter, r *http.Request) {
 	username := r.FormValue("username")
 	password := r.FormValue("password")
 
-	if h.sessions == nil {
+	if h.sessions == nil || !h.sessions.PasswordLoginEnabled() {
 		http.Redirect(w, r, "/login?error="+LoginErrorRequired, http.StatusFound)
 		return
 	}
 
-	token, ok := h.sessions.Authenticate(username, password)
+	ttl := time.Duration(sessionMaxAge) * time.Second
+	if r.FormValue("remember") != "" {
+		ttl = h.sessions.RememberMeTTL()
+	}
+	ttl = min(ttl, h.sessions.SessionTTL())
+	token, ok := h.sessions.AuthenticateFor(username, password, ttl)
 	if !ok {
 		// Record failed attempt for rate limiting
 		if h.rateLimiter != nil {
@@ -3682,17 +7406,58 @@ func (h *Handler) loginPost(w http.ResponseWriter, r *http.Request) {
 		h.rateLimiter.Clear(clientIP)
 	}
 
-	http.SetCookie(w, &http.Cookie{
+	h.setSessionCookie(w, r, token, ttl)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// secureCookies reports whether cookies set in response to r get the Secure
+// flag: as forced by ONWATCH_SECURE_COOKIES, or in auto mode when r came
+// over HTTPS, directly or through a proxy that sets X-Forwarded-Proto.
+func (h *Handler) secureCookies(r *http.Request) bool {
+	if h.config != nil {
+		switch h.config.SecureCookies {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// sessionCookie returns the session cookie with the attributes from
+// ONWATCH_SECURE_COOKIES, ONWATCH_COOKIE_SAMESITE, ONWATCH_COOKIE_DOMAIN and
+// ONWATCH_COOKIE_PATH, so setting and clearing it always match.
+func (h *Handler) sessionCookie(r *http.Request, value string, maxAge int) *http.Cookie {
+	sameSite, path, domain := http.SameSiteLaxMode, "/", ""
+	if h.config != nil {
+		switch h.config.CookieSameSite {
+		case "strict":
+			sameSite = http.SameSiteStrictMode
+		case "none":
+			sameSite = http.SameSiteNoneMode
+		}
+		if h.config.CookiePath != "" {
+			path = h.config.CookiePath
+		}
+		domain = h.config.CookieDomain
+	}
+	return &http.Cookie{
 		Name:     sessionCookieName,
-		Value:    token,
-		Path:     "/",
-		MaxAge:   sessionMaxAge,
+		Value:    value,
+		Path:     path,
+		Domain:   domain,
+		MaxAge:   maxAge,
 		HttpOnly: true,
-		Secure:   h.config.SecureCookies || (h.config.Host != "" && h.config.Host != "0.0.0.0" && h.config.Host != "127.0.0.1"),
-		SameSite: http.SameSiteStrictMode,
-	})
+		Secure:   h.secureCookies(r) || sameSite == http.SameSiteNoneMode,
+		SameSite: sameSite,
+	}
+}
 
-	http.Redirect(w, r, "/", http.StatusFound)
+// setSessionCookie sets the session cookie for a freshly minted token that
+// lasts ttl.
+func (h *Handler) setSessionCookie(w http.ResponseWriter, r *http.Request, token string, ttl time.Duration) {
+	http.SetCookie(w, h.sessionCookie(r, token, int(ttl/time.Second)))
 }
 
 // Logout clears the session and redirects to login.
@@ -3700,12 +7465,7 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	if cookie, err := r.Cookie(sessionCookieName); err == nil && h.sessions != nil {
 		h.sessions.Invalidate(cookie.Value)
 	}
-	http.SetCookie(w, &http.Cookie{
-		Name:   sessionCookieName,
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
-	})
+	http.SetCookie(w, h.sessionCookie(r, "", -1))
 	http.Redirect(w, r, "/login", http.StatusFound)
 }
 
@@ -3747,6 +7507,29 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Users other than the configured admin only change their own hash: the
+	// encryption key is derived from the configured admin's password
+	if username := h.currentUser(r); username != h.sessions.username {
+		if _, ok := h.sessions.checkCredentials(username, req.CurrentPassword); !ok {
+			respondError(w, http.StatusUnauthorized, "current password is incorrect")
+			return
+		}
+		newHash, err := HashPassword(req.NewPassword)
+		if err != nil {
+			h.logger.Error("failed to hash new password", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to process new password")
+			return
+		}
+		if err := h.store.UpsertUser(username, newHash); err != nil {
+			h.logger.Error("failed to update password in database", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save new password")
+			return
+		}
+		h.sessions.InvalidateUser(username)
+		respondJSON(w, http.StatusOK, map[string]string{"message": "password updated successfully"})
+		return
+	}
+
 	// Verify current password and get old hash for re-encryption
 	oldHash := h.sessions.passwordHash
 	_, ok := h.sessions.Authenticate(h.sessions.username, req.CurrentPassword)
@@ -3767,21 +7550,235 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to save new password")
 		return
 	}
-
-	// Update in-memory hash
-	h.sessions.UpdatePassword(newHash)
-
-	// Re-encrypt all encrypted data with new password key
-	reEncryptErrors := ReEncryptAllData(h.store, oldHash, newHash)
-	if len(reEncryptErrors) > 0 {
-		h.logger.Warn("some data could not be re-encrypted during password change", "errors", reEncryptErrors)
-		// Continue anyway - data might need manual re-entry or was already encrypted with new key
+
+	// Update in-memory hash
+	h.sessions.UpdatePassword(newHash)
+
+	// Re-encrypt all encrypted data with new password key
+	reEncryptErrors := ReEncryptAllData(h.store, oldHash, newHash)
+	if len(reEncryptErrors) > 0 {
+		h.logger.Warn("some data could not be re-encrypted during password change", "errors", reEncryptErrors)
+		// Continue anyway - data might need manual re-entry or was already encrypted with new key
+	}
+	if h.notifier != nil {
+		h.notifier.SetEncryptionKey(DeriveEncryptionKey(newHash, nil))
+	}
+
+	// Invalidate all sessions (force re-login)
+	h.sessions.InvalidateAll()
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "password updated successfully"})
+}
+
+// validUsername reports whether a username is 1-64 letters, digits or . _ @ -.
+func validUsername(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("._@-", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Users lists (GET) or creates (POST) dashboard users. Admin only.
+func (h *Handler) Users(w http.ResponseWriter, r *http.Request) {
+	if h.sessions == nil || h.store == nil {
+		respondError(w, http.StatusInternalServerError, "auth not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		users, err := h.store.ListUsers()
+		if err != nil {
+			h.logger.Error("failed to list users", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to list users")
+			return
+		}
+		if users == nil {
+			users = []store.UserAccount{}
+		}
+		respondJSON(w, http.StatusOK, users)
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+		var req struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Role     string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if !validUsername(req.Username) {
+			respondError(w, http.StatusBadRequest, "username must be 1-64 letters, digits or . _ @ -")
+			return
+		}
+		if req.Role == "" {
+			req.Role = RoleViewer
+		}
+		if req.Role != RoleAdmin && req.Role != RoleViewer {
+			respondError(w, http.StatusBadRequest, "role must be admin or viewer")
+			return
+		}
+		if len(req.Password) < 6 {
+			respondError(w, http.StatusBadRequest, "password must be at least 6 characters")
+			return
+		}
+		hash, err := HashPassword(req.Password)
+		if err != nil {
+			h.logger.Error("failed to hash password", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to process password")
+			return
+		}
+		created, err := h.store.CreateUser(req.Username, hash, req.Role)
+		if err != nil {
+			h.logger.Error("failed to create user", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to create user")
+			return
+		}
+		if !created {
+			respondError(w, http.StatusConflict, "user already exists")
+			return
+		}
+		h.logger.Info("User created", "user", req.Username, "role", req.Role, "by", h.currentUser(r))
+		respondJSON(w, http.StatusCreated, map[string]string{"username": req.Username, "role": req.Role})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// User changes the role (PUT {"role": ...}) or deletes (DELETE) the user in
+// /api/users/{name}. Admin only. The configured admin and the signed-in user
+// cannot be demoted or deleted, so at least one admin always remains.
+func (h *Handler) User(w http.ResponseWriter, r *http.Request) {
+	if h.sessions == nil || h.store == nil {
+		respondError(w, http.StatusInternalServerError, "auth not configured")
+		return
+	}
+	username := strings.TrimPrefix(r.URL.Path, "/api/users/")
+	if !validUsername(username) {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if username == h.sessions.username || username == h.currentUser(r) {
+		respondError(w, http.StatusBadRequest, "cannot change your own or the configured admin's account")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.Role != RoleAdmin && req.Role != RoleViewer {
+			respondError(w, http.StatusBadRequest, "role must be admin or viewer")
+			return
+		}
+		found, err := h.store.SetUserRole(username, req.Role)
+		if err != nil {
+			h.logger.Error("failed to set user role", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to set role")
+			return
+		}
+		if !found {
+			respondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		// Signed-in sessions carry the old role
+		h.sessions.InvalidateUser(username)
+		h.logger.Info("User role changed", "user", username, "role", req.Role, "by", h.currentUser(r))
+		respondJSON(w, http.StatusOK, map[string]string{"username": username, "role": req.Role})
+	case http.MethodDelete:
+		found, err := h.store.DeleteUser(username)
+		if err != nil {
+			h.logger.Error("failed to delete user", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to delete user")
+			return
+		}
+		if !found {
+			respondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		h.sessions.InvalidateUser(username)
+		// Drop their notification preferences
+		if h.notifier != nil {
+			if err := h.notifier.Reload(); err != nil {
+				h.logger.Error("failed to reload notifier", "error", err)
+			}
+		}
+		h.logger.Info("User deleted", "user", username, "by", h.currentUser(r))
+		respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// Version handles GET /api/version: the version, Go toolchain, commit, build
+// date and platform of the running binary, for bug reports.
+func (h *Handler) Version(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	info := update.ReadBuildInfo(h.version, "")
+	if h.buildInfo != nil {
+		info = *h.buildInfo
 	}
+	respondJSON(w, http.StatusOK, info)
+}
 
-	// Invalidate all sessions (force re-login)
-	h.sessions.InvalidateAll()
-
-	respondJSON(w, http.StatusOK, map[string]string{"message": "password updated successfully"})
+// LogLevel reports (GET) or changes (PUT {"level": "debug"}) the log level
+// of the running process. Changes take effect immediately, including in
+// daemon mode, and last until restart.
+func (h *Handler) LogLevel(w http.ResponseWriter, r *http.Request) {
+	if h.logLevel == nil {
+		respondError(w, http.StatusServiceUnavailable, "log level not configurable")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+		var level slog.Level
+		switch strings.ToLower(strings.TrimSpace(req.Level)) {
+		case "debug":
+			level = slog.LevelDebug
+		case "info":
+			level = slog.LevelInfo
+		case "warn":
+			level = slog.LevelWarn
+		case "error":
+			level = slog.LevelError
+		default:
+			respondError(w, http.StatusBadRequest, "level must be debug, info, warn or error")
+			return
+		}
+		if level != h.logLevel.Level() {
+			h.logger.Warn("Log level changed", "from", h.logLevel.Level().String(), "to", level.String())
+			h.logLevel.Set(level)
+		}
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{
+		"level": strings.ToLower(h.logLevel.Level().String()),
+	})
 }
 
 // CheckUpdate checks for available updates (GET /api/update/check).
@@ -3803,9 +7800,12 @@ func (h *Handler) CheckUpdate(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, info)
 }
 
-// ApplyUpdate downloads and applies an update (POST /api/update/apply).
+// ApplyUpdate downloads and applies an update (POST /api/update/apply). GET
+// reports the progress of a download in flight. A POST that accepts
+// text/event-stream gets the update's progress as server-sent events instead
+// of a single JSON response.
 func (h *Handler) ApplyUpdate(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
@@ -3813,15 +7813,75 @@ func (h *Handler) ApplyUpdate(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusServiceUnavailable, "updater not configured")
 		return
 	}
+	if r.Method == http.MethodGet {
+		percent, downloading := h.updater.DownloadProgress()
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"downloading": downloading,
+			"percent":     percent,
+		})
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.applyUpdateStream(w)
+		return
+	}
 	if err := h.updater.Apply(); err != nil {
 		h.logger.Error("update apply failed", "error", err)
-		// Return generic error message to prevent information leakage
-		respondError(w, http.StatusInternalServerError, "update failed")
+		status, msg := updateApplyError(err)
+		respondError(w, status, msg)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+	h.restartAfterUpdate()
+}
+
+// applyUpdateStream applies an update, writing "progress" events while it
+// runs and a final "done" ({"status":"updated"}) or "error" ({"error":...})
+// event.
+func (h *Handler) applyUpdateStream(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	send := func(event string, v interface{}) {
+		data, _ := json.Marshal(v)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		rc.Flush()
+	}
+
+	err := h.updater.ApplyWithProgress(func(p update.Progress) {
+		send("progress", map[string]interface{}{
+			"stage":      p.Stage,
+			"downloaded": p.Downloaded,
+			"total":      p.Total,
+			"percent":    p.Percent(),
+		})
+	})
+	if err != nil {
+		h.logger.Error("update apply failed", "error", err)
+		_, msg := updateApplyError(err)
+		send("error", map[string]string{"error": msg})
+		return
+	}
+	send("done", map[string]string{"status": "updated"})
+	h.restartAfterUpdate()
+}
 
-	// Schedule restart after response is flushed
+// updateApplyError maps an Apply error to a response status and a message
+// that doesn't leak internal details.
+func updateApplyError(err error) (int, string) {
+	switch {
+	case errors.Is(err, update.ErrChecksumMismatch):
+		return http.StatusBadGateway, "update refused: downloaded binary does not match the published checksum"
+	case errors.Is(err, update.ErrChecksumUnavailable):
+		return http.StatusBadGateway, "update refused: release checksum could not be verified"
+	default:
+		return http.StatusInternalServerError, "update failed"
+	}
+}
+
+// restartAfterUpdate restarts onWatch once the response has been flushed.
+func (h *Handler) restartAfterUpdate() {
 	go func() {
 		time.Sleep(1 * time.Second)
 		if err := h.updater.Restart(); err != nil {
@@ -3830,6 +7890,38 @@ func (h *Handler) ApplyUpdate(w http.ResponseWriter, r *http.Request) {
 	}()
 }
 
+// RollbackUpdate restores the binary replaced by the last update and
+// restarts (POST /api/update/rollback).
+func (h *Handler) RollbackUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.updater == nil {
+		respondError(w, http.StatusServiceUnavailable, "updater not configured")
+		return
+	}
+	version, err := h.updater.Rollback()
+	if err != nil {
+		if errors.Is(err, update.ErrNoPreviousVersion) {
+			respondError(w, http.StatusConflict, "no previous version to roll back to")
+			return
+		}
+		h.logger.Error("update rollback failed", "error", err)
+		respondError(w, http.StatusInternalServerError, "rollback failed")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "rolled_back", "version": version})
+
+	// Schedule restart after response is flushed
+	go func() {
+		time.Sleep(1 * time.Second)
+		if err := h.updater.Restart(); err != nil {
+			h.logger.Error("restart after rollback failed", "error", err)
+		}
+	}()
+}
+
 // CycleOverview returns cycle overview with cross-quota data at peak moments.
 func (h *Handler) CycleOverview(w http.ResponseWriter, r *http.Request) {
 	provider, err := h.getProviderFromRequest(r)
@@ -3858,6 +7950,25 @@ func (h *Handler) CycleOverview(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// maxCycleLimit caps the limit query param of the cycle endpoints, keeping
+// cycle queries bounded like the rest of the dashboard's.
+const maxCycleLimit = 200
+
+// parseCycleLimit parses the limit query param of the cycle endpoints, the
+// number of completed cycles returned, defaulting to def. Unlike the cycle
+// overview it rejects out-of-range values rather than clamping them.
+func parseCycleLimit(r *http.Request, def int) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(limitStr)
+	if err != nil || n < 1 || n > maxCycleLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxCycleLimit)
+	}
+	return n, nil
+}
+
 // parseCycleOverviewLimit parses the limit query param, defaulting to 50.
 // Caps at 500 to prevent unbounded queries.
 func parseCycleOverviewLimit(r *http.Request) int {
@@ -3873,6 +7984,56 @@ func parseCycleOverviewLimit(r *http.Request) int {
 	return 50
 }
 
+// cycleGroupByDefaults lists each provider's groupBy values in display order.
+// Anthropic, Copilot and Codex quotas are dynamic, so cycleGroupByOptions also
+// adds any quota that has stored cycles.
+var cycleGroupByDefaults = map[string][]string{
+	"synthetic": {"subscription", "search", "toolcall", "weekly"},
+	"zai":       {"tokens", "time"},
+	"anthropic": {"five_hour", "seven_day", "seven_day_sonnet"},
+	"copilot":   {"premium_interactions", "chat", "completions"},
+	"codex":     {"five_hour", "seven_day", "code_review"},
+}
+
+// cycleGroupByOptions returns the valid cycle-overview groupBy values for provider.
+func (h *Handler) cycleGroupByOptions(provider string) []string {
+	if provider == "antigravity" {
+		return api.AntigravityQuotaGroupOrder()
+	}
+	options := append([]string(nil), cycleGroupByDefaults[provider]...)
+	if h.store == nil {
+		return options
+	}
+	var names []string
+	switch provider {
+	case "anthropic":
+		names, _ = h.store.QueryAllAnthropicQuotaNames()
+	case "copilot":
+		names, _ = h.store.QueryAllCopilotQuotaNames()
+	case "codex":
+		names, _ = h.store.QueryAllCodexQuotaNames()
+	}
+	for _, name := range names {
+		if !slices.Contains(options, name) {
+			options = append(options, name)
+		}
+	}
+	return options
+}
+
+// resolveCycleGroupBy returns groupBy (or def when it is empty) and whether
+// it is one of options.
+func resolveCycleGroupBy(groupBy, def string, options []string) (string, bool) {
+	if groupBy == "" {
+		return def, true
+	}
+	return groupBy, slices.Contains(options, groupBy)
+}
+
+func respondInvalidGroupBy(w http.ResponseWriter, provider, groupBy string, options []string) {
+	respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid groupBy %q for %s: must be one of %s", groupBy, provider, strings.Join(options, ", ")))
+}
+
 // cycleOverviewSynthetic returns Synthetic cycle overview with cross-quota data.
 func (h *Handler) cycleOverviewSynthetic(w http.ResponseWriter, r *http.Request) {
 	if h.store == nil {
@@ -3880,9 +8041,16 @@ func (h *Handler) cycleOverviewSynthetic(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	groupBy := r.URL.Query().Get("groupBy")
-	if groupBy == "" {
-		groupBy = "subscription"
+	options := h.cycleGroupByOptions("synthetic")
+	groupBy, ok := resolveCycleGroupBy(r.URL.Query().Get("groupBy"), "subscription", options)
+	if !ok {
+		respondInvalidGroupBy(w, "synthetic", groupBy, options)
+		return
+	}
+
+	if groupBy == "weekly" {
+		h.cycleOverviewSyntheticWeekly(w, r, options)
+		return
 	}
 
 	limit := parseCycleOverviewLimit(r)
@@ -3895,10 +8063,58 @@ func (h *Handler) cycleOverviewSynthetic(w http.ResponseWriter, r *http.Request)
 
 	quotaNames := []string{"subscription", "search", "toolcall"}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"groupBy":    groupBy,
-		"provider":   "synthetic",
-		"quotaNames": quotaNames,
-		"cycles":     cycleOverviewRowsToJSON(rows),
+		"groupBy":        groupBy,
+		"groupByOptions": options,
+		"provider":       "synthetic",
+		"quotaNames":     quotaNames,
+		"cycles":         cycleOverviewRowsToJSON(rows),
+	})
+}
+
+// cycleOverviewSyntheticWeekly aggregates subscription cycles into calendar
+// weeks with peak and average usage per cycle, so long-term Synthetic usage
+// compares with the weekly windows of the utilization providers. limit is the
+// number of weeks (default 12).
+func (h *Handler) cycleOverviewSyntheticWeekly(w http.ResponseWriter, r *http.Request, options []string) {
+	weeks := 12
+	if r.URL.Query().Get("limit") != "" {
+		weeks = parseCycleOverviewLimit(r)
+	}
+	buckets, err := h.store.QuerySyntheticWeeklyCycles("subscription", weeks, time.Now())
+	if err != nil {
+		h.logger.Error("failed to query synthetic weekly cycles", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query cycle overview")
+		return
+	}
+
+	var limit float64
+	if latest, _ := h.store.QueryLatest(); latest != nil {
+		limit = latest.Sub.Limit
+	}
+	weeksJSON := make([]map[string]interface{}, 0, len(buckets))
+	for _, b := range buckets {
+		entry := map[string]interface{}{
+			"weekStart": b.WeekStart.Format(time.RFC3339),
+			"weekEnd":   b.WeekEnd.Format(time.RFC3339),
+			"cycles":    b.Cycles,
+			"peak":      b.Peak,
+			"average":   b.Average,
+			"total":     b.Total,
+			"periods":   b.Periods,
+		}
+		if limit > 0 {
+			entry["peakPercent"] = b.Peak / limit * 100
+			entry["averagePercent"] = b.Average / limit * 100
+		}
+		weeksJSON = append(weeksJSON, entry)
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"groupBy":        "weekly",
+		"groupByOptions": options,
+		"provider":       "synthetic",
+		"quotaType":      "subscription",
+		"limit":          limit,
+		"weeks":          weeksJSON,
 	})
 }
 
@@ -3909,9 +8125,11 @@ func (h *Handler) cycleOverviewZai(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	groupBy := r.URL.Query().Get("groupBy")
-	if groupBy == "" {
-		groupBy = "tokens"
+	options := h.cycleGroupByOptions("zai")
+	groupBy, ok := resolveCycleGroupBy(r.URL.Query().Get("groupBy"), "tokens", options)
+	if !ok {
+		respondInvalidGroupBy(w, "zai", groupBy, options)
+		return
 	}
 
 	limit := parseCycleOverviewLimit(r)
@@ -3924,10 +8142,11 @@ func (h *Handler) cycleOverviewZai(w http.ResponseWriter, r *http.Request) {
 
 	quotaNames := []string{"tokens", "time"}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"groupBy":    groupBy,
-		"provider":   "zai",
-		"quotaNames": quotaNames,
-		"cycles":     cycleOverviewRowsToJSON(rows),
+		"groupBy":        groupBy,
+		"groupByOptions": options,
+		"provider":       "zai",
+		"quotaNames":     quotaNames,
+		"cycles":         cycleOverviewRowsToJSON(rows),
 	})
 }
 
@@ -3938,9 +8157,11 @@ func (h *Handler) cycleOverviewAnthropic(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	groupBy := r.URL.Query().Get("groupBy")
-	if groupBy == "" {
-		groupBy = "five_hour"
+	options := h.cycleGroupByOptions("anthropic")
+	groupBy, ok := resolveCycleGroupBy(r.URL.Query().Get("groupBy"), "five_hour", options)
+	if !ok {
+		respondInvalidGroupBy(w, "anthropic", groupBy, options)
+		return
 	}
 
 	limit := parseCycleOverviewLimit(r)
@@ -3967,10 +8188,11 @@ func (h *Handler) cycleOverviewAnthropic(w http.ResponseWriter, r *http.Request)
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"groupBy":    groupBy,
-		"provider":   "anthropic",
-		"quotaNames": quotaNames,
-		"cycles":     cycleOverviewRowsToJSON(rows),
+		"groupBy":        groupBy,
+		"groupByOptions": options,
+		"provider":       "anthropic",
+		"quotaNames":     quotaNames,
+		"cycles":         cycleOverviewRowsToJSON(rows),
 	})
 }
 
@@ -3984,40 +8206,50 @@ func (h *Handler) cycleOverviewBoth(w http.ResponseWriter, r *http.Request) {
 
 	limit := parseCycleOverviewLimit(r)
 
-	if h.config.HasProvider("synthetic") {
+	if h.inBoth("synthetic") {
+		options := h.cycleGroupByOptions("synthetic")
+		// groupBy is shared with the other providers (the dashboard sends
+		// five_hour), so a quota Synthetic doesn't have falls back to its
+		// default instead of failing the whole request
 		groupBy := r.URL.Query().Get("groupBy")
-		if groupBy == "" {
+		if !slices.Contains(options, groupBy) {
 			groupBy = "subscription"
 		}
 		if rows, err := h.store.QuerySyntheticCycleOverview(groupBy, limit); err == nil {
 			response["synthetic"] = map[string]interface{}{
-				"groupBy":    groupBy,
-				"provider":   "synthetic",
-				"quotaNames": []string{"subscription", "search", "toolcall"},
-				"cycles":     cycleOverviewRowsToJSON(rows),
+				"groupBy":        groupBy,
+				"groupByOptions": options,
+				"provider":       "synthetic",
+				"quotaNames":     []string{"subscription", "search", "toolcall"},
+				"cycles":         cycleOverviewRowsToJSON(rows),
 			}
 		}
 	}
 
-	if h.config.HasProvider("zai") {
-		groupBy := r.URL.Query().Get("zaiGroupBy")
-		if groupBy == "" {
-			groupBy = "tokens"
+	if h.inBoth("zai") {
+		options := h.cycleGroupByOptions("zai")
+		groupBy, ok := resolveCycleGroupBy(r.URL.Query().Get("zaiGroupBy"), "tokens", options)
+		if !ok {
+			respondInvalidGroupBy(w, "zai", groupBy, options)
+			return
 		}
 		if rows, err := h.store.QueryZaiCycleOverview(groupBy, limit); err == nil {
 			response["zai"] = map[string]interface{}{
-				"groupBy":    groupBy,
-				"provider":   "zai",
-				"quotaNames": []string{"tokens", "time"},
-				"cycles":     cycleOverviewRowsToJSON(rows),
+				"groupBy":        groupBy,
+				"groupByOptions": options,
+				"provider":       "zai",
+				"quotaNames":     []string{"tokens", "time"},
+				"cycles":         cycleOverviewRowsToJSON(rows),
 			}
 		}
 	}
 
-	if h.config.HasProvider("anthropic") {
-		groupBy := r.URL.Query().Get("anthropicGroupBy")
-		if groupBy == "" {
-			groupBy = "five_hour"
+	if h.inBoth("anthropic") {
+		options := h.cycleGroupByOptions("anthropic")
+		groupBy, ok := resolveCycleGroupBy(r.URL.Query().Get("anthropicGroupBy"), "five_hour", options)
+		if !ok {
+			respondInvalidGroupBy(w, "anthropic", groupBy, options)
+			return
 		}
 		if rows, err := h.store.QueryAnthropicCycleOverview(groupBy, limit); err == nil {
 			quotaNames := []string{}
@@ -4033,18 +8265,21 @@ func (h *Handler) cycleOverviewBoth(w http.ResponseWriter, r *http.Request) {
 				quotaNames = []string{"five_hour", "seven_day", "seven_day_sonnet"}
 			}
 			response["anthropic"] = map[string]interface{}{
-				"groupBy":    groupBy,
-				"provider":   "anthropic",
-				"quotaNames": quotaNames,
-				"cycles":     cycleOverviewRowsToJSON(rows),
+				"groupBy":        groupBy,
+				"groupByOptions": options,
+				"provider":       "anthropic",
+				"quotaNames":     quotaNames,
+				"cycles":         cycleOverviewRowsToJSON(rows),
 			}
 		}
 	}
 
-	if h.config.HasProvider("copilot") {
-		groupBy := r.URL.Query().Get("copilotGroupBy")
-		if groupBy == "" {
-			groupBy = "premium_interactions"
+	if h.inBoth("copilot") {
+		options := h.cycleGroupByOptions("copilot")
+		groupBy, ok := resolveCycleGroupBy(r.URL.Query().Get("copilotGroupBy"), "premium_interactions", options)
+		if !ok {
+			respondInvalidGroupBy(w, "copilot", groupBy, options)
+			return
 		}
 		if rows, err := h.store.QueryCopilotCycleOverview(groupBy, limit); err == nil {
 			quotaNames := []string{}
@@ -4060,21 +8295,25 @@ func (h *Handler) cycleOverviewBoth(w http.ResponseWriter, r *http.Request) {
 				quotaNames = []string{"premium_interactions", "chat", "completions"}
 			}
 			response["copilot"] = map[string]interface{}{
-				"groupBy":    groupBy,
-				"provider":   "copilot",
-				"quotaNames": quotaNames,
-				"cycles":     cycleOverviewRowsToJSON(rows),
+				"groupBy":        groupBy,
+				"groupByOptions": options,
+				"provider":       "copilot",
+				"quotaNames":     quotaNames,
+				"cycles":         cycleOverviewRowsToJSON(rows),
 			}
 		}
 	}
 
-	if h.config.HasProvider("codex") {
+	if h.inBoth("codex") {
+		options := h.cycleGroupByOptions("codex")
 		groupBy := r.URL.Query().Get("codexGroupBy")
-		if groupBy == "" {
+		if groupBy == "" && slices.Contains(options, r.URL.Query().Get("groupBy")) {
 			groupBy = r.URL.Query().Get("groupBy")
 		}
-		if groupBy == "" {
-			groupBy = "five_hour"
+		groupBy, ok := resolveCycleGroupBy(groupBy, "five_hour", options)
+		if !ok {
+			respondInvalidGroupBy(w, "codex", groupBy, options)
+			return
 		}
 		if rows, err := h.store.QueryCodexCycleOverview(groupBy, limit); err == nil {
 			quotaNames := []string{}
@@ -4090,10 +8329,11 @@ func (h *Handler) cycleOverviewBoth(w http.ResponseWriter, r *http.Request) {
 				quotaNames = []string{"five_hour", "seven_day", "code_review"}
 			}
 			response["codex"] = map[string]interface{}{
-				"groupBy":    groupBy,
-				"provider":   "codex",
-				"quotaNames": quotaNames,
-				"cycles":     cycleOverviewRowsToJSON(rows),
+				"groupBy":        groupBy,
+				"groupByOptions": options,
+				"provider":       "codex",
+				"quotaNames":     quotaNames,
+				"cycles":         cycleOverviewRowsToJSON(rows),
 			}
 		}
 	}
@@ -4118,6 +8358,12 @@ func cycleOverviewRowsToJSON(rows []store.CycleOverviewRow) []map[string]interfa
 		} else {
 			entry["cycleEnd"] = nil
 		}
+		entry["avgValue"] = nil
+		entry["medianValue"] = nil
+		if row.Stats != nil && row.Stats.Samples > 0 {
+			entry["avgValue"] = row.Stats.Avg
+			entry["medianValue"] = row.Stats.Median
+		}
 
 		crossQuotas := make([]map[string]interface{}, 0, len(row.CrossQuotas))
 		for _, cq := range row.CrossQuotas {
@@ -4140,15 +8386,20 @@ func cycleOverviewRowsToJSON(rows []store.CycleOverviewRow) []map[string]interfa
 
 // currentCopilot returns current Copilot quota status.
 func (h *Handler) currentCopilot(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, h.buildCopilotCurrent())
+	resp := h.buildCopilotCurrent()
+	if wantSparklines(r) {
+		h.addSparklines("copilot", resp)
+	}
+	respondJSON(w, http.StatusOK, h.numberFormat().addDisplayFields(resp))
 }
 
 // buildCopilotCurrent builds the Copilot current quota response map.
 func (h *Handler) buildCopilotCurrent() map[string]interface{} {
 	now := time.Now().UTC()
 	response := map[string]interface{}{
-		"capturedAt": now.Format(time.RFC3339),
-		"quotas":     []interface{}{},
+		"capturedAt":  now.Format(time.RFC3339),
+		"quotas":      []interface{}{},
+		"neverPolled": false,
 	}
 
 	if h.store == nil {
@@ -4160,8 +8411,10 @@ func (h *Handler) buildCopilotCurrent() map[string]interface{} {
 		h.logger.Error("failed to query latest Copilot snapshot", "error", err)
 		return response
 	}
+	latest = newerPolled(h.latest, "copilot", latest, func(s *api.CopilotSnapshot) time.Time { return s.CapturedAt })
 
 	if latest == nil {
+		h.markNeverPolled(response, "copilot")
 		return response
 	}
 
@@ -4197,6 +8450,7 @@ func (h *Handler) buildCopilotCurrent() map[string]interface{} {
 			if summary, err := h.copilotTracker.UsageSummary(q.Name); err == nil && summary != nil {
 				qMap["currentRate"] = summary.CurrentRate
 				qMap["projectedUsage"] = summary.ProjectedUsage
+				addProjectionConfidence(qMap, summary.ProjectionConfidence)
 			}
 		}
 		quotas = append(quotas, qMap)
@@ -4210,16 +8464,7 @@ func copilotUsageStatus(usagePercent float64, unlimited bool) string {
 	if unlimited {
 		return "healthy"
 	}
-	switch {
-	case usagePercent >= 95:
-		return "critical"
-	case usagePercent >= 80:
-		return "danger"
-	case usagePercent >= 50:
-		return "warning"
-	default:
-		return "healthy"
-	}
+	return usageStatus(usagePercent)
 }
 
 // historyCopilot returns Copilot usage history.
@@ -4242,13 +8487,9 @@ func (h *Handler) historyCopilot(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to query history")
 		return
 	}
-	step := downsampleStep(len(snapshots), maxChartPoints)
-	last := len(snapshots) - 1
-	response := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-	for i, snap := range snapshots {
-		if step > 1 && i != 0 && i != last && i%step != 0 {
-			continue
-		}
+	smooth, smoothFactor, _ := parseChartSmoothing(r)
+	response := make([]map[string]interface{}, 0, len(snapshots))
+	for _, snap := range snapshots {
 		entry := map[string]interface{}{
 			"capturedAt": snap.CapturedAt.Format(time.RFC3339),
 		}
@@ -4259,6 +8500,13 @@ func (h *Handler) historyCopilot(w http.ResponseWriter, r *http.Request) {
 		}
 		response = append(response, entry)
 	}
+	if smooth {
+		response = smoothChartPoints(response, smoothFactor)
+	}
+	response = downsamplePoints(response, h.chartMaxPoints())
+	if wantChartGaps(r) {
+		response = breakChartGaps(response, h.chartGapThreshold(len(snapshots)))
+	}
 	respondJSON(w, http.StatusOK, response)
 }
 
@@ -4268,6 +8516,12 @@ func (h *Handler) cyclesCopilot(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
+
+	limit, err := parseCycleLimit(r, 0)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	quotaName := r.URL.Query().Get("type")
 	if quotaName == "" {
 		quotaName = "premium_interactions"
@@ -4318,6 +8572,9 @@ func (h *Handler) cyclesCopilot(w http.ResponseWriter, r *http.Request) {
 	for i, j := 0, len(response)-1; i < j; i, j = i+1, j-1 {
 		response[i], response[j] = response[j], response[i]
 	}
+	if limit > 0 && len(response) > limit {
+		response = response[:limit]
+	}
 
 	respondJSON(w, http.StatusOK, response)
 }
@@ -4379,6 +8636,8 @@ func buildCopilotSummaryResponse(summary *tracker.CopilotSummary) map[string]int
 		"totalTracked":     summary.TotalTracked,
 		"trackingSince":    nil,
 	}
+	addCyclePace(result, summary.Pace)
+	addProjectionConfidence(result, summary.ProjectionConfidence)
 	if summary.ResetDate != nil {
 		result["resetDate"] = summary.ResetDate.Format(time.RFC3339)
 		result["timeUntilReset"] = formatDuration(summary.TimeUntilReset)
@@ -4459,9 +8718,10 @@ func (h *Handler) buildCopilotInsights(hidden map[string]bool, rangeDur time.Dur
 		if s != nil && s.CurrentRate > 0 {
 			resp.Insights = append(resp.Insights, insightItem{
 				Key: key, Type: "forecast", Severity: copilotInsightSeverity(usagePercent),
-				Title:  fmt.Sprintf("%s Burn Rate", api.CopilotDisplayName(q.Name)),
-				Metric: fmt.Sprintf("%.1f / hr", s.CurrentRate),
-				Desc:   fmt.Sprintf("Currently at %.0f%% usage (%d/%d). At this rate, projected to use %d by reset.", usagePercent, q.Entitlement-q.Remaining, q.Entitlement, s.ProjectedUsage),
+				Title:      fmt.Sprintf("%s Burn Rate", api.CopilotDisplayName(q.Name)),
+				Metric:     fmt.Sprintf("%.1f / hr", s.CurrentRate),
+				Desc:       fmt.Sprintf("Currently at %.0f%% usage (%d/%d). At this rate, projected to use %d by reset.", usagePercent, q.Entitlement-q.Remaining, q.Entitlement, s.ProjectedUsage),
+				Confidence: tracker.ProjectionQuality(s.ProjectionConfidence),
 			})
 		} else {
 			resp.Insights = append(resp.Insights, insightItem{
@@ -4481,11 +8741,18 @@ func (h *Handler) buildCopilotInsights(hidden map[string]bool, rangeDur time.Dur
 				Key: "reset_countdown", Type: "info", Severity: "info",
 				Title:  "Quota Reset",
 				Metric: formatDuration(timeLeft),
-				Desc:   fmt.Sprintf("Quotas reset on %s.", latest.ResetDate.Format("Jan 2, 2006")),
+				Desc:   fmt.Sprintf("Quotas reset on %s.", latest.ResetDate.In(h.providerLocation("copilot")).Format("Jan 2, 2006")),
 			})
 		}
 	}
 
+	if !hidden["cycle_pace"] {
+		quotas := cyclePaceQuotas(summaries, api.CopilotDisplayName, func(s *tracker.CopilotSummary) *tracker.CyclePace { return s.Pace })
+		if item, ok := buildCyclePaceInsight(quotas); ok {
+			resp.Insights = append(resp.Insights, item)
+		}
+	}
+
 	// 3. Coverage — how long we've been tracking
 	if !hidden["coverage"] {
 		snapCount := 0
@@ -4527,14 +8794,19 @@ func codexInsightSeverity(util float64) string {
 // ── Codex Handlers ──
 
 func (h *Handler) currentCodex(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, h.buildCodexCurrent())
+	resp := h.buildCodexCurrent()
+	if wantSparklines(r) {
+		h.addSparklines("codex", resp)
+	}
+	respondJSON(w, http.StatusOK, h.numberFormat().addDisplayFields(resp))
 }
 
 func (h *Handler) buildCodexCurrent() map[string]interface{} {
 	now := time.Now().UTC()
 	response := map[string]interface{}{
-		"capturedAt": now.Format(time.RFC3339),
-		"quotas":     []interface{}{},
+		"capturedAt":  now.Format(time.RFC3339),
+		"quotas":      []interface{}{},
+		"neverPolled": false,
 	}
 	if h.store == nil {
 		return response
@@ -4545,7 +8817,9 @@ func (h *Handler) buildCodexCurrent() map[string]interface{} {
 		h.logger.Error("failed to query latest Codex snapshot", "error", err)
 		return response
 	}
+	latest = newerPolled(h.latest, "codex", latest, func(s *api.CodexSnapshot) time.Time { return s.CapturedAt })
 	if latest == nil {
+		h.markNeverPolled(response, "codex")
 		return response
 	}
 
@@ -4602,6 +8876,7 @@ func (h *Handler) buildCodexCurrent() map[string]interface{} {
 			if summary, err := h.codexTracker.UsageSummary(q.Name); err == nil && summary != nil {
 				qMap["currentRate"] = summary.CurrentRate
 				qMap["projectedUtil"] = summary.ProjectedUtil
+				addProjectionConfidence(qMap, summary.ProjectionConfidence)
 			}
 		}
 		quotas = append(quotas, qMap)
@@ -4611,16 +8886,7 @@ func (h *Handler) buildCodexCurrent() map[string]interface{} {
 }
 
 func codexUtilStatus(util float64) string {
-	switch {
-	case util >= 95:
-		return "critical"
-	case util >= 80:
-		return "danger"
-	case util >= 50:
-		return "warning"
-	default:
-		return "healthy"
-	}
+	return usageStatus(util)
 }
 
 func codexQuotaDisplayOrder(name string) int {
@@ -4638,16 +8904,17 @@ func codexQuotaDisplayOrder(name string) int {
 
 // currentAntigravity returns current Antigravity quota status.
 func (h *Handler) currentAntigravity(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, h.buildAntigravityCurrent())
+	respondJSON(w, http.StatusOK, h.numberFormat().addDisplayFields(h.buildAntigravityCurrent()))
 }
 
 // buildAntigravityCurrent builds the Antigravity current quota response map.
 func (h *Handler) buildAntigravityCurrent() map[string]interface{} {
 	now := time.Now().UTC()
 	response := map[string]interface{}{
-		"capturedAt": now.Format(time.RFC3339),
-		"quotas":     []interface{}{},
-		"pools":      []interface{}{},
+		"capturedAt":  now.Format(time.RFC3339),
+		"quotas":      []interface{}{},
+		"neverPolled": false,
+		"pools":       []interface{}{},
 	}
 
 	if h.store == nil {
@@ -4659,8 +8926,10 @@ func (h *Handler) buildAntigravityCurrent() map[string]interface{} {
 		h.logger.Error("failed to query latest Antigravity snapshot", "error", err)
 		return response
 	}
+	latest = newerPolled(h.latest, "antigravity", latest, func(s *api.AntigravitySnapshot) time.Time { return s.CapturedAt })
 
 	if latest == nil {
+		h.markNeverPolled(response, "antigravity")
 		return response
 	}
 
@@ -4707,14 +8976,21 @@ func (h *Handler) buildAntigravityCurrent() map[string]interface{} {
 		if h.antigravityTracker != nil {
 			groupRate := 0.0
 			groupProjected := 0.0
+			groupConfidence := 0.0 // the least confident model projection in the pool
 			for _, modelID := range g.ModelIDs {
 				if summary, err := h.antigravityTracker.UsageSummary(modelID); err == nil && summary != nil {
 					groupRate += summary.CurrentRate
+					if summary.ProjectedUsage > 0 {
+						if groupProjected == 0 || summary.ProjectionConfidence < groupConfidence {
+							groupConfidence = summary.ProjectionConfidence
+						}
+					}
 					groupProjected += summary.ProjectedUsage
 				}
 			}
 			qMap["currentRate"] = groupRate
 			qMap["projectedUsage"] = groupProjected
+			addProjectionConfidence(qMap, groupConfidence)
 		}
 		quotas = append(quotas, qMap)
 	}
@@ -4737,16 +9013,7 @@ func (h *Handler) buildAntigravityCurrent() map[string]interface{} {
 }
 
 func antigravityUsageStatus(usagePercent float64) string {
-	switch {
-	case usagePercent >= 95:
-		return "critical"
-	case usagePercent >= 80:
-		return "danger"
-	case usagePercent >= 50:
-		return "warning"
-	default:
-		return "healthy"
-	}
+	return usageStatus(usagePercent)
 }
 
 // insightsAntigravity returns Antigravity-specific deep analytics.
@@ -4922,6 +9189,20 @@ func (h *Handler) buildAntigravityInsights(hidden map[string]bool, rangeDur time
 		})
 	}
 
+	if !hidden["cycle_pace"] && h.antigravityTracker != nil {
+		summaries := map[string]*tracker.AntigravitySummary{}
+		for _, m := range latest.Models {
+			if s, err := h.antigravityTracker.UsageSummary(m.ModelID); err == nil && s != nil {
+				summaries[m.ModelID] = s
+			}
+		}
+		label := func(modelID string) string { return api.AntigravityModelLabel(modelID, summaries[modelID].Label) }
+		quotas := cyclePaceQuotas(summaries, label, func(s *tracker.AntigravitySummary) *tracker.CyclePace { return s.Pace })
+		if item, ok := buildCyclePaceInsight(quotas); ok {
+			resp.Insights = append(resp.Insights, item)
+		}
+	}
+
 	if len(snapshots) >= 2 && !hidden["coverage"] {
 		first := snapshots[0]
 		last := snapshots[len(snapshots)-1]
@@ -4983,10 +9264,10 @@ func (h *Handler) historyAntigravity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	step := downsampleStep(len(snapshots), maxChartPoints)
-	var labels []string
-	for i := 0; i < len(snapshots); i += step {
-		labels = append(labels, snapshots[i].CapturedAt.Format(time.RFC3339))
+	smooth, smoothFactor, _ := parseChartSmoothing(r)
+	labels := make([]string, 0, len(snapshots))
+	for _, snap := range snapshots {
+		labels = append(labels, snap.CapturedAt.Format(time.RFC3339))
 	}
 
 	groupKeys := api.AntigravityQuotaGroupOrder()
@@ -4995,8 +9276,8 @@ func (h *Handler) historyAntigravity(w http.ResponseWriter, r *http.Request) {
 		groupedSeries[key] = make([]float64, 0, len(labels))
 	}
 
-	for i := 0; i < len(snapshots); i += step {
-		groups := api.GroupAntigravityModelsByLogicalQuota(snapshots[i].Models)
+	for _, snap := range snapshots {
+		groups := api.GroupAntigravityModelsByLogicalQuota(snap.Models)
 		valueByGroup := make(map[string]float64, len(groups))
 		for _, g := range groups {
 			valueByGroup[g.GroupKey] = g.UsagePercent
@@ -5006,12 +9287,31 @@ func (h *Handler) historyAntigravity(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if smooth {
+		for key, values := range groupedSeries {
+			groupedSeries[key] = smoothSeries(values, smoothFactor)
+		}
+	}
+	labels, groupedSeries = downsampleSeries(labels, groupedSeries, h.chartMaxPoints())
+
+	seriesData := make(map[string]interface{}, len(groupKeys))
+	for _, key := range groupKeys {
+		seriesData[key] = groupedSeries[key]
+	}
+	if wantChartGaps(r) {
+		var gapped map[string][]interface{}
+		labels, gapped = breakSeriesGaps(labels, groupedSeries, h.chartGapThreshold(len(snapshots)))
+		for key, data := range gapped {
+			seriesData[key] = data
+		}
+	}
+
 	datasets := make([]map[string]interface{}, 0, len(groupKeys))
 	for _, key := range groupKeys {
 		datasets = append(datasets, map[string]interface{}{
 			"modelId":     key,
 			"label":       api.AntigravityQuotaGroupDisplayName(key),
-			"data":        groupedSeries[key],
+			"data":        seriesData[key],
 			"borderColor": api.AntigravityQuotaGroupColor(key),
 			"fill":        false,
 		})
@@ -5024,16 +9324,7 @@ func (h *Handler) historyAntigravity(w http.ResponseWriter, r *http.Request) {
 }
 
 func codexRemainingStatus(remaining float64) string {
-	switch {
-	case remaining <= 5:
-		return "critical"
-	case remaining <= 20:
-		return "danger"
-	case remaining <= 50:
-		return "warning"
-	default:
-		return "healthy"
-	}
+	return usageStatus(100 - remaining)
 }
 
 func (h *Handler) historyCodex(w http.ResponseWriter, r *http.Request) {
@@ -5054,19 +9345,22 @@ func (h *Handler) historyCodex(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to query history")
 		return
 	}
-	step := downsampleStep(len(snapshots), maxChartPoints)
-	last := len(snapshots) - 1
-	response := make([]map[string]interface{}, 0, min(len(snapshots), maxChartPoints))
-	for i, snap := range snapshots {
-		if step > 1 && i != 0 && i != last && i%step != 0 {
-			continue
-		}
+	smooth, smoothFactor, _ := parseChartSmoothing(r)
+	response := make([]map[string]interface{}, 0, len(snapshots))
+	for _, snap := range snapshots {
 		entry := map[string]interface{}{"capturedAt": snap.CapturedAt.Format(time.RFC3339)}
 		for _, q := range snap.Quotas {
 			entry[q.Name] = q.Utilization
 		}
 		response = append(response, entry)
 	}
+	if smooth {
+		response = smoothChartPoints(response, smoothFactor)
+	}
+	response = downsamplePoints(response, h.chartMaxPoints())
+	if wantChartGaps(r) {
+		response = breakChartGaps(response, h.chartGapThreshold(len(snapshots)))
+	}
 	respondJSON(w, http.StatusOK, response)
 }
 
@@ -5076,6 +9370,12 @@ func (h *Handler) cyclesCodex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	limit, err := parseCycleLimit(r, 200)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	quotaName := r.URL.Query().Get("type")
 	if quotaName == "" {
 		quotaName = "five_hour"
@@ -5100,17 +9400,17 @@ func (h *Handler) cyclesCodex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if active != nil {
-		response = append(response, codexCycleToMap(active))
+		response = append(response, h.withCycleStats(codexCycleToMap(active), "codex", active.QuotaName, active.CycleStart, active.CycleEnd))
 	}
 
-	history, err := h.store.QueryCodexCycleHistory(quotaName, 200)
+	history, err := h.store.QueryCodexCycleHistory(quotaName, limit)
 	if err != nil {
 		h.logger.Error("failed to query Codex cycle history", "error", err)
 		respondError(w, http.StatusInternalServerError, "failed to query cycles")
 		return
 	}
 	for _, cycle := range history {
-		response = append(response, codexCycleToMap(cycle))
+		response = append(response, h.withCycleStats(codexCycleToMap(cycle), "codex", cycle.QuotaName, cycle.CycleStart, cycle.CycleEnd))
 	}
 
 	respondJSON(w, http.StatusOK, response)
@@ -5140,6 +9440,12 @@ func (h *Handler) cyclesAntigravity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	limit, err := parseCycleLimit(r, 200)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	modelID := r.URL.Query().Get("type")
 	if modelID == "" {
 		// If no model specified, return empty array
@@ -5156,22 +9462,76 @@ func (h *Handler) cyclesAntigravity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if active != nil {
-		response = append(response, antigravityCycleToMap(active))
+		response = append(response, h.withCycleStats(antigravityCycleToMap(active), "antigravity", active.ModelID, active.CycleStart, active.CycleEnd))
 	}
 
-	history, err := h.store.QueryAntigravityCycleHistory(modelID, 200)
+	history, err := h.store.QueryAntigravityCycleHistory(modelID, limit)
 	if err != nil {
 		h.logger.Error("failed to query Antigravity cycle history", "error", err)
 		respondError(w, http.StatusInternalServerError, "failed to query cycles")
 		return
 	}
 	for _, cycle := range history {
-		response = append(response, antigravityCycleToMap(cycle))
+		response = append(response, h.withCycleStats(antigravityCycleToMap(cycle), "antigravity", cycle.ModelID, cycle.CycleStart, cycle.CycleEnd))
 	}
 
 	respondJSON(w, http.StatusOK, response)
 }
 
+// summaryAntigravity returns Antigravity usage summaries, keyed by model ID.
+func (h *Handler) summaryAntigravity(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.buildAntigravitySummaryMap())
+}
+
+// buildAntigravitySummaryMap builds the Antigravity summary response for the
+// models in the latest snapshot.
+func (h *Handler) buildAntigravitySummaryMap() map[string]interface{} {
+	response := map[string]interface{}{}
+	if h.antigravityTracker == nil || h.store == nil {
+		return response
+	}
+	latest, err := h.store.QueryLatestAntigravity()
+	if err != nil || latest == nil {
+		return response
+	}
+	for _, m := range latest.Models {
+		if summary, err := h.antigravityTracker.UsageSummary(m.ModelID); err == nil && summary != nil {
+			response[m.ModelID] = buildAntigravitySummaryResponse(summary)
+		}
+	}
+	return response
+}
+
+// buildAntigravitySummaryResponse builds a summary response from
+// AntigravityTracker data. Rates and projections are on the 0-1 scale the
+// tracker keeps.
+func buildAntigravitySummaryResponse(summary *tracker.AntigravitySummary) map[string]interface{} {
+	result := map[string]interface{}{
+		"modelId":           summary.ModelID,
+		"label":             api.AntigravityModelLabel(summary.ModelID, summary.Label),
+		"remainingFraction": summary.RemainingFraction,
+		"usagePercent":      summary.UsagePercent,
+		"isExhausted":       summary.IsExhausted,
+		"currentRate":       summary.CurrentRate,
+		"projectedUsage":    summary.ProjectedUsage,
+		"completedCycles":   summary.CompletedCycles,
+		"avgPerCycle":       summary.AvgPerCycle,
+		"peakCycle":         summary.PeakCycle,
+		"totalTracked":      summary.TotalTracked,
+		"trackingSince":     nil,
+	}
+	addCyclePace(result, summary.Pace)
+	addProjectionConfidence(result, summary.ProjectionConfidence)
+	if summary.ResetTime != nil {
+		result["resetTime"] = summary.ResetTime.Format(time.RFC3339)
+		result["timeUntilReset"] = formatDuration(summary.TimeUntilReset)
+	}
+	if !summary.TrackingSince.IsZero() {
+		result["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
+	}
+	return result
+}
+
 func antigravityCycleToMap(cycle *store.AntigravityResetCycle) map[string]interface{} {
 	result := map[string]interface{}{
 		"id":         cycle.ID,
@@ -5223,6 +9583,8 @@ func buildCodexSummaryResponse(summary *tracker.CodexSummary) map[string]interfa
 		"totalTracked":    summary.TotalTracked,
 		"trackingSince":   nil,
 	}
+	addCyclePace(result, summary.Pace)
+	addProjectionConfidence(result, summary.ProjectionConfidence)
 	if summary.ResetsAt != nil {
 		result["resetsAt"] = summary.ResetsAt.Format(time.RFC3339)
 		result["timeUntilReset"] = formatDuration(summary.TimeUntilReset)
@@ -5330,6 +9692,13 @@ func (h *Handler) buildCodexInsights(hidden map[string]bool, rangeDur time.Durat
 		}
 	}
 
+	if !hidden["cycle_pace"] {
+		quotas := cyclePaceQuotas(summaries, api.CodexDisplayName, func(s *tracker.CodexSummary) *tracker.CyclePace { return s.Pace })
+		if item, ok := buildCyclePaceInsight(quotas); ok {
+			resp.Insights = append(resp.Insights, item)
+		}
+	}
+
 	if len(resp.Insights) == 0 {
 		resp.Insights = append(resp.Insights, insightItem{
 			Type:     "info",
@@ -5380,7 +9749,7 @@ func buildCodexQuotaBurnRateInsight(key string, title string, quota *api.CodexQu
 		if summary.ResetsAt != nil && summary.TimeUntilReset > 0 {
 			desc = fmt.Sprintf("Currently at %.0f%%. At this rate, projected %.0f%% by reset in %s.", quota.Utilization, projected, formatDuration(summary.TimeUntilReset))
 		}
-		return insightItem{
+		item := insightItem{
 			Key:      key,
 			Type:     "forecast",
 			Severity: codexInsightSeverity(quota.Utilization),
@@ -5389,6 +9758,10 @@ func buildCodexQuotaBurnRateInsight(key string, title string, quota *api.CodexQu
 			Sublabel: sublabel,
 			Desc:     desc,
 		}
+		if summary.ProjectedUtil > 0 {
+			item.Confidence = tracker.ProjectionQuality(summary.ProjectionConfidence)
+		}
+		return item
 	}
 
 	return insightItem{
@@ -5523,9 +9896,11 @@ func (h *Handler) cycleOverviewCodex(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, http.StatusOK, map[string]interface{}{"cycles": []interface{}{}})
 		return
 	}
-	groupBy := r.URL.Query().Get("groupBy")
-	if groupBy == "" {
-		groupBy = "five_hour"
+	options := h.cycleGroupByOptions("codex")
+	groupBy, ok := resolveCycleGroupBy(r.URL.Query().Get("groupBy"), "five_hour", options)
+	if !ok {
+		respondInvalidGroupBy(w, "codex", groupBy, options)
+		return
 	}
 	rows, err := h.store.QueryCodexCycleOverview(groupBy, parseCycleOverviewLimit(r))
 	if err != nil {
@@ -5546,10 +9921,11 @@ func (h *Handler) cycleOverviewCodex(w http.ResponseWriter, r *http.Request) {
 		quotaNames = []string{"five_hour", "seven_day", "code_review"}
 	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"groupBy":    groupBy,
-		"provider":   "codex",
-		"quotaNames": quotaNames,
-		"cycles":     cycleOverviewRowsToJSON(rows),
+		"groupBy":        groupBy,
+		"groupByOptions": options,
+		"provider":       "codex",
+		"quotaNames":     quotaNames,
+		"cycles":         cycleOverviewRowsToJSON(rows),
 	})
 }
 
@@ -5577,6 +9953,9 @@ func (h *Handler) cycleOverviewAntigravity(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Any model ID is accepted and mapped to its quota group, so there is
+	// nothing to reject here.
+	options := h.cycleGroupByOptions("antigravity")
 	groupBy := normalizeAntigravityGroupBy(r.URL.Query().Get("groupBy"))
 	limit := parseCycleOverviewLimit(r)
 
@@ -5590,10 +9969,11 @@ func (h *Handler) cycleOverviewAntigravity(w http.ResponseWriter, r *http.Reques
 	quotaNames := api.AntigravityQuotaGroupOrder()
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"groupBy":    groupBy,
-		"provider":   "antigravity",
-		"quotaNames": quotaNames,
-		"cycles":     cycleOverviewRowsToJSON(rows),
+		"groupBy":        groupBy,
+		"groupByOptions": options,
+		"provider":       "antigravity",
+		"quotaNames":     quotaNames,
+		"cycles":         cycleOverviewRowsToJSON(rows),
 	})
 }
 
@@ -6059,9 +10439,11 @@ func (h *Handler) cycleOverviewCopilot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	groupBy := r.URL.Query().Get("groupBy")
-	if groupBy == "" {
-		groupBy = "premium_interactions"
+	options := h.cycleGroupByOptions("copilot")
+	groupBy, ok := resolveCycleGroupBy(r.URL.Query().Get("groupBy"), "premium_interactions", options)
+	if !ok {
+		respondInvalidGroupBy(w, "copilot", groupBy, options)
+		return
 	}
 
 	limit := parseCycleOverviewLimit(r)
@@ -6087,9 +10469,10 @@ func (h *Handler) cycleOverviewCopilot(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"groupBy":    groupBy,
-		"provider":   "copilot",
-		"quotaNames": quotaNames,
-		"cycles":     cycleOverviewRowsToJSON(rows),
+		"groupBy":        groupBy,
+		"groupByOptions": options,
+		"provider":       "copilot",
+		"quotaNames":     quotaNames,
+		"cycles":         cycleOverviewRowsToJSON(rows),
 	})
 }
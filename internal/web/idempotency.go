@@ -0,0 +1,215 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// idempotencyKeyHeader lets clients retry a mutating request safely: a
+	// repeat with the same key gets the first response instead of running again.
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyTTL is how long a key's response is remembered.
+	idempotencyTTL = 10 * time.Minute
+	// maxIdempotencyKeys bounds memory; the oldest keys are dropped first.
+	maxIdempotencyKeys = 1000
+	// maxIdempotencyKeyLen and maxIdempotentBody bound what is remembered.
+	maxIdempotencyKeyLen = 255
+	maxIdempotentBody    = 1 << 20
+)
+
+// idempotencyEntry is a remembered request. done is closed once the
+// response is recorded.
+type idempotencyEntry struct {
+	fingerprint string
+	done        chan struct{}
+	status      int
+	header      http.Header
+	body        []byte
+	expires     time.Time
+}
+
+// idempotencyCache remembers responses to requests sent with an
+// Idempotency-Key, in memory only.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{entries: make(map[string]*idempotencyEntry)}
+}
+
+// begin looks up key. Without a live entry it registers one for the caller to
+// fill and returns it with owner set.
+func (c *idempotencyCache) begin(key, fingerprint string) (entry *idempotencyEntry, owner bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+		return e, false
+	}
+	c.evict(now)
+	e := &idempotencyEntry{fingerprint: fingerprint, done: make(chan struct{}), expires: now.Add(idempotencyTTL)}
+	c.entries[key] = e
+	return e, true
+}
+
+// forget drops key, so a retry runs the request again.
+func (c *idempotencyCache) forget(key string, e *idempotencyEntry) {
+	c.mu.Lock()
+	if c.entries[key] == e {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+	close(e.done)
+}
+
+// evict removes expired entries and, when still full, the oldest one.
+// Callers hold c.mu.
+func (c *idempotencyCache) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+			continue
+		}
+		if oldestKey == "" || e.expires.Before(oldest) {
+			oldestKey, oldest = k, e.expires
+		}
+	}
+	if len(c.entries) >= maxIdempotencyKeys && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// idempotencyMiddleware replays the recorded response when a mutating request
+// repeats an Idempotency-Key within idempotencyTTL. Keys are scoped to the
+// signed-in user, method and path. Reusing a key for a different query
+// string or body is rejected with 422, and a repeat that arrives while the first request still
+// runs gets 409. Server errors are not remembered, so those can be retried.
+func idempotencyMiddleware(cache *idempotencyCache) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				respondError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				respondError(w, http.StatusRequestEntityTooLarge, "request body too large for Idempotency-Key")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			// Query parameters select what many endpoints act on, so they are
+			// part of the request like the body.
+			sum := sha256.Sum256(append([]byte(r.URL.RawQuery+"\x00"), body...))
+			fingerprint := hex.EncodeToString(sum[:])
+
+			user := ""
+			if sess, ok := sessionFromContext(r); ok {
+				user = sess.Username
+			}
+			scoped := user + "\x00" + r.Method + "\x00" + r.URL.Path + "\x00" + key
+
+			entry, owner := cache.begin(scoped, fingerprint)
+			if !owner {
+				if entry.fingerprint != fingerprint {
+					respondError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+					return
+				}
+				select {
+				case <-entry.done:
+				default:
+					respondError(w, http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+					return
+				}
+				if entry.status == 0 {
+					// The first attempt was not remembered; run this one
+					next.ServeHTTP(w, r)
+					return
+				}
+				for k, v := range entry.header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(entry.status)
+				w.Write(entry.body)
+				return
+			}
+
+			recorded := false
+			defer func() {
+				if !recorded {
+					cache.forget(scoped, entry)
+				}
+			}()
+			rec := &idempotencyRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			if rec.status >= 500 || rec.overflow {
+				return
+			}
+			entry.status = rec.status
+			entry.header = rec.header
+			entry.body = rec.body.Bytes()
+			recorded = true
+			close(entry.done)
+		})
+	}
+}
+
+// idempotencyRecorder passes a response through while keeping a copy.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+		rec.header = rec.Header().Clone()
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController flush streamed responses.
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *idempotencyRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		if rec.body.Len()+len(p) > maxIdempotentBody {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
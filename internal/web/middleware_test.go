@@ -2,6 +2,7 @@ package web
 
 import (
 	"crypto/subtle"
+	"database/sql"
 	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,9 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/store"
 )
 
 func TestAuth_ValidCredentials(t *testing.T) {
@@ -40,6 +44,211 @@ func TestAuth_ValidCredentials(t *testing.T) {
 	}
 }
 
+func TestAuth_MetricsBasicAuth(t *testing.T) {
+	username := "admin"
+	password := "secret123"
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	sessions := NewSessionStore(username, legacyHashPassword(password), nil)
+	wrapped := SessionAuthMiddleware(sessions, nil)(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	rr := httptest.NewRecorder()
+	wrapped.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected scrapers to authenticate with Basic Auth, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	wrapped.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", rr.Code)
+	}
+}
+
+func TestAuth_StatusPagePublic(t *testing.T) {
+	db, _ := store.New(":memory:")
+	defer db.Close()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	sessions := NewSessionStore("admin", legacyHashPassword("secret123"), db)
+	wrapped := SessionAuthMiddleware(sessions, nil)(handler)
+
+	rr := httptest.NewRecorder()
+	wrapped.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rr.Code != http.StatusFound {
+		t.Errorf("expected redirect to login while status page is private, got %d", rr.Code)
+	}
+
+	db.SetSetting("status_public", "true")
+	rr = httptest.NewRecorder()
+	wrapped.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected public status page without login, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	wrapped.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/widget?provider=synthetic", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected public widget without login, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	wrapped.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/current", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected other endpoints to stay private, got %d", rr.Code)
+	}
+}
+
+func TestAuth_CalendarToken(t *testing.T) {
+	db, _ := store.New(":memory:")
+	defer db.Close()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	wrapped := SessionAuthMiddleware(NewSessionStore("admin", legacyHashPassword("secret123"), db), nil)(handler)
+	serve := func(path string) int {
+		rr := httptest.NewRecorder()
+		wrapped.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr.Code
+	}
+
+	if code := serve("/api/calendar.ics?token="); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a calendar token configured, got %d", code)
+	}
+	db.SetSetting(calendarTokenSetting, "caltoken")
+	if code := serve("/api/calendar.ics?token=caltoken"); code != http.StatusOK {
+		t.Errorf("expected the calendar token to grant the feed, got %d", code)
+	}
+	if code := serve("/api/calendar.ics?token=wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong token, got %d", code)
+	}
+	if code := serve("/api/current?token=caltoken"); code != http.StatusUnauthorized {
+		t.Errorf("expected the calendar token to grant nothing else, got %d", code)
+	}
+}
+
+func TestAuth_ViewerRole(t *testing.T) {
+	db, _ := store.New(":memory:")
+	defer db.Close()
+	hash, _ := HashPassword("viewerpass")
+	db.CreateUser("alice", hash, RoleViewer)
+
+	var seen string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sess, ok := sessionFromContext(r); ok {
+			seen = sess.Username + ":" + sess.Role
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	sessions := NewSessionStore("admin", legacyHashPassword("secret123"), db)
+	wrapped := SessionAuthMiddleware(sessions, nil)(handler)
+
+	token, ok := sessions.Authenticate("alice", "viewerpass")
+	if !ok {
+		t.Fatal("expected the viewer to sign in")
+	}
+	serve := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: token})
+		rr := httptest.NewRecorder()
+		wrapped.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := serve(http.MethodGet, "/api/current"); code != http.StatusOK || seen != "alice:viewer" {
+		t.Errorf("expected viewer to read dashboards, got %d as %q", code, seen)
+	}
+	for _, c := range []struct{ method, path string }{
+		{http.MethodPut, "/api/settings"},
+		{http.MethodPost, "/api/update/apply"},
+		{http.MethodGet, "/api/settings/export"},
+		{http.MethodGet, "/api/users"},
+		{http.MethodDelete, "/api/users/bob"},
+		{http.MethodGet, "/api/calendar/token"},
+	} {
+		if code := serve(c.method, c.path); code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403 for a viewer, got %d", c.method, c.path, code)
+		}
+	}
+	if code := serve(http.MethodPut, "/api/notifications/prefs"); code != http.StatusOK {
+		t.Errorf("expected viewer to save their own alerts, got %d", code)
+	}
+
+	// The role survives a restart through the persisted token
+	restarted := NewSessionStore("admin", legacyHashPassword("secret123"), db)
+	if sess, ok := restarted.Session(token); !ok || sess.Username != "alice" || sess.IsAdmin() {
+		t.Errorf("expected persisted viewer session, got %+v (ok=%v)", sess, ok)
+	}
+
+	// Basic Auth carries the role too
+	req := httptest.NewRequest(http.MethodPost, "/api/poll", nil)
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("alice:viewerpass")))
+	rr := httptest.NewRecorder()
+	wrapped.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a viewer over Basic Auth, got %d", rr.Code)
+	}
+
+	// Removing the user ends their sessions
+	db.DeleteUser("alice")
+	sessions.InvalidateUser("alice")
+	if sessions.ValidateToken(token) {
+		t.Error("expected the deleted user's session to be invalid")
+	}
+}
+
+func TestAuth_LegacyUserRowsMigrateAsViewers(t *testing.T) {
+	dbPath := t.TempDir() + "/legacy-users.db"
+	legacyDB, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open legacy DB: %v", err)
+	}
+	// Before roles, users only held stored password hashes; "old-admin" was
+	// left behind when ONWATCH_ADMIN_USER was renamed
+	if _, err := legacyDB.Exec(`CREATE TABLE users (
+		username TEXT PRIMARY KEY,
+		password_hash TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	)`); err != nil {
+		t.Fatalf("Failed to create legacy users: %v", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, u := range []string{"old-admin", "admin"} {
+		if _, err := legacyDB.Exec(`INSERT INTO users (username, password_hash, updated_at) VALUES (?, ?, ?)`,
+			u, legacyHashPassword("secret123"), now); err != nil {
+			t.Fatalf("Failed to insert legacy user: %v", err)
+		}
+	}
+	legacyDB.Close()
+
+	db, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open migrated store: %v", err)
+	}
+	defer db.Close()
+	if err := db.EnsureAdminUser("admin", legacyHashPassword("secret123")); err != nil {
+		t.Fatalf("EnsureAdminUser failed: %v", err)
+	}
+
+	sessions := NewSessionStore("admin", legacyHashPassword("secret123"), db)
+	token, ok := sessions.Authenticate("old-admin", "secret123")
+	if !ok {
+		t.Fatal("expected the stale user to keep a viewer login")
+	}
+	if sess, _ := sessions.Session(token); sess.IsAdmin() {
+		t.Errorf("expected the stale user to sign in as a viewer, got %+v", sess)
+	}
+	if account, _ := db.GetUserAccount("admin"); account == nil || account.Role != RoleAdmin {
+		t.Errorf("expected the configured admin to be promoted, got %+v", account)
+	}
+}
+
 func TestAuth_InvalidPassword(t *testing.T) {
 	// Arrange
 	username := "admin"
@@ -606,6 +815,59 @@ func TestAuth_ConcurrentRequests(t *testing.T) {
 // Login Rate Limiter Tests
 // =============================================================================
 
+func TestSessionStore_AbsoluteTTL(t *testing.T) {
+	db, _ := store.New(":memory:")
+	defer db.Close()
+	sessions := NewSessionStore("admin", legacyHashPassword("secret123"), db)
+	if sessions.SessionTTL() != 7*24*time.Hour {
+		t.Errorf("default TTL = %v, want 7 days", sessions.SessionTTL())
+	}
+
+	token := sessions.CreateSession("", RoleAdmin)
+	if !sessions.ValidateToken(token) {
+		t.Fatal("new token should be valid")
+	}
+	// Issued ten minutes ago and still well within its expiry
+	sessions.mu.Lock()
+	sess := sessions.tokens[token]
+	sess.created = time.Now().Add(-10 * time.Minute)
+	sessions.tokens[token] = sess
+	sessions.mu.Unlock()
+	if !sessions.ValidateToken(token) {
+		t.Fatal("token within the default lifetime should be valid")
+	}
+
+	// The setting is cached until reloaded (UpdateSettings does so on save)
+	db.SetSetting("session_ttl_minutes", "5")
+	if sessions.SessionTTL() != defaultSessionTTLMinutes*time.Minute {
+		t.Errorf("TTL = %v before reload, want the cached default", sessions.SessionTTL())
+	}
+	sessions.ReloadTTLs()
+	if sessions.SessionTTL() != 5*time.Minute {
+		t.Fatalf("TTL = %v, want 5m", sessions.SessionTTL())
+	}
+	handler := SessionAuthMiddleware(sessions, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: token})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "/login?error=expired" {
+		t.Errorf("expired session = %d %q, want redirect to /login?error=expired", rr.Code, rr.Header().Get("Location"))
+	}
+	if _, found, _ := db.GetAuthTokenInfo(token); found {
+		t.Error("token past its lifetime should be deleted from the store")
+	}
+
+	// Without a cookie the login page gets no error
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Header().Get("Location") != "/login" {
+		t.Errorf("no session redirect = %q, want /login", rr.Header().Get("Location"))
+	}
+}
+
 func TestLoginRateLimit_Blocks_After5Failures(t *testing.T) {
 	limiter := NewLoginRateLimiter(1000)
 	ip := "192.168.1.1"
@@ -738,3 +1000,69 @@ func TestLoginRateLimit_DifferentIPsIndependent(t *testing.T) {
 		}
 	}
 }
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	var calls int32
+	status := http.StatusOK
+	handler := idempotencyMiddleware(newIdempotencyCache())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		respondJSON(w, status, map[string]interface{}{"call": n})
+	}))
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/poll", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := post("abc", `{"provider":"zai"}`)
+	retry := post("abc", `{"provider":"zai"}`)
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected replay of %q, got %q (replayed=%q)", first.Body.String(), retry.Body.String(), retry.Header().Get("Idempotent-Replayed"))
+	}
+
+	if rr := post("abc", `{"provider":"codex"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for key reuse with another body, got %d", rr.Code)
+	}
+	post("other", `{"provider":"zai"}`)
+	post("", `{"provider":"zai"}`)
+	if calls != 3 {
+		t.Errorf("expected new and missing keys to run, got %d calls", calls)
+	}
+
+	// Server errors are not remembered
+	status = http.StatusInternalServerError
+	post("flaky", "")
+	status = http.StatusOK
+	if rr := post("flaky", ""); rr.Code != http.StatusOK || rr.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("expected retry after a server error to run, got %d", rr.Code)
+	}
+
+	// The query string is part of the request
+	del := func(key, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/data?"+query, nil)
+		req.Header.Set("Idempotency-Key", key)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	status = http.StatusBadRequest
+	del("purge", "provider=zai")
+	status = http.StatusOK
+	if rr := del("purge", "provider=zai&confirm=true"); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for key reuse with another query, got %d", rr.Code)
+	}
+	del("purge2", "provider=zai&confirm=true")
+	if rr := del("purge2", "provider=codex&confirm=true"); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for key reuse with another provider, got %d", rr.Code)
+	}
+	if rr := del("purge2", "provider=zai&confirm=true"); rr.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected replay for the same query, got %d", rr.Code)
+	}
+}
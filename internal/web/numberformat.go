@@ -0,0 +1,226 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onllm-dev/onwatch/internal/store"
+)
+
+// numberLocale holds the separators and currency placement of a locale.
+type numberLocale struct {
+	thousands      string
+	decimal        string
+	currencyBefore bool // "$1,234.50" rather than "1.234,50 €"
+}
+
+// numberLocales are the locales the number_format setting accepts.
+var numberLocales = map[string]numberLocale{
+	"en-US": {",", ".", true},
+	"en-GB": {",", ".", true},
+	"ja-JP": {",", ".", true},
+	"de-DE": {".", ",", false},
+	"es-ES": {".", ",", false},
+	"it-IT": {".", ",", false},
+	"nl-NL": {".", ",", true},
+	"pt-BR": {".", ",", true},
+	"fr-FR": {"\u202f", ",", false}, // narrow no-break space
+	"de-CH": {"’", ".", true},
+}
+
+// currencySymbols maps ISO 4217 codes to their display symbol. Other codes
+// are shown as the code itself.
+var currencySymbols = map[string]string{
+	"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "INR": "₹",
+	"BRL": "R$", "CAD": "CA$", "AUD": "A$", "CNY": "CN¥",
+}
+
+// zeroDecimalCurrencies have no minor unit.
+var zeroDecimalCurrencies = map[string]bool{"JPY": true, "KRW": true}
+
+// displayNumberKeys are the usage fields in current quota responses that get
+// a formatted "<key>Display" sibling.
+var displayNumberKeys = []string{"usage", "limit", "entitlement", "remaining", "balance"}
+
+// NumberFormat is the JSON shape stored under the "number_format" settings
+// key. It only affects the *Display strings added to API responses; raw
+// numeric fields are never changed, so charts keep working.
+type NumberFormat struct {
+	Locale     string `json:"locale"`     // one of numberLocales, default "en-US"
+	Abbreviate bool   `json:"abbreviate"` // show large counts as 1.2K, 3.4M, 5.6B
+}
+
+// defaultNumberFormat is used until number_format is saved.
+var defaultNumberFormat = NumberFormat{Locale: "en-US"}
+
+// numberLocaleNames returns the supported number_format locales, sorted.
+func numberLocaleNames() []string {
+	out := make([]string, 0, len(numberLocales))
+	for l := range numberLocales {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// validate checks the locale, defaulting an empty one to en-US.
+func (f *NumberFormat) validate() error {
+	if f.Locale == "" {
+		f.Locale = defaultNumberFormat.Locale
+	}
+	if _, ok := numberLocales[f.Locale]; !ok {
+		return fmt.Errorf("number_format.locale must be one of: %s", strings.Join(numberLocaleNames(), ", "))
+	}
+	return nil
+}
+
+// numberFormat returns the saved number_format setting, or the default.
+func (h *Handler) numberFormat() NumberFormat {
+	f := defaultNumberFormat
+	if h.store == nil {
+		return f
+	}
+	if v, _ := h.store.GetSetting("number_format"); v != "" {
+		if err := json.Unmarshal([]byte(v), &f); err != nil || f.validate() != nil {
+			return defaultNumberFormat
+		}
+	}
+	return f
+}
+
+// Number formats a count, e.g. "1,234,567" or, abbreviated, "1.2M".
+func (f NumberFormat) Number(v float64) string {
+	loc := numberLocales[f.Locale]
+	if f.Abbreviate {
+		abs := math.Abs(v)
+		for _, unit := range []struct {
+			size   float64
+			suffix string
+		}{{1e12, "T"}, {1e9, "B"}, {1e6, "M"}, {1e3, "K"}} {
+			if abs >= unit.size {
+				s := strconv.FormatFloat(v/unit.size, 'f', 1, 64)
+				s = strings.TrimSuffix(s, ".0")
+				return strings.Replace(s, ".", loc.decimal, 1) + unit.suffix
+			}
+		}
+	}
+	decimals := 0
+	if v != math.Trunc(v) {
+		decimals = 2
+	}
+	s := groupDigits(v, decimals, loc)
+	if decimals > 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), loc.decimal)
+	}
+	return s
+}
+
+// Currency formats an amount in the ISO 4217 currency code, e.g. "$1,234.50"
+// or "1.234,50 €". Without a code only the number is formatted.
+func (f NumberFormat) Currency(v float64, code string) string {
+	loc := numberLocales[f.Locale]
+	decimals := 2
+	if zeroDecimalCurrencies[code] {
+		decimals = 0
+	}
+	s := groupDigits(v, decimals, loc)
+	if code == "" {
+		return s
+	}
+	symbol, ok := currencySymbols[code]
+	if !ok {
+		symbol = code
+	}
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	switch {
+	case loc.currencyBefore && ok:
+		s = symbol + s
+	case loc.currencyBefore:
+		s = symbol + " " + s
+	default:
+		s = s + " " + symbol
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// groupDigits formats v with a fixed number of decimals and the locale's
+// separators.
+func groupDigits(v float64, decimals int, loc numberLocale) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	intPart, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	if v < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(loc.thousands)
+		}
+		b.WriteRune(d)
+	}
+	if frac != "" {
+		b.WriteString(loc.decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// addDisplayFields adds a formatted "<key>Display" string next to each usage
+// field of a current quota response, including nested quota maps and
+// "quotas" lists.
+func (f NumberFormat) addDisplayFields(m map[string]interface{}) map[string]interface{} {
+	for _, key := range displayNumberKeys {
+		if v, ok := displayNumber(m[key]); ok {
+			m[key+"Display"] = f.Number(v)
+		}
+	}
+	for _, v := range m {
+		switch child := v.(type) {
+		case map[string]interface{}:
+			f.addDisplayFields(child)
+		case []map[string]interface{}:
+			for _, item := range child {
+				f.addDisplayFields(item)
+			}
+		}
+	}
+	return m
+}
+
+// displayNumber converts the numeric types the quota builders use.
+func displayNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// addCostDisplay fills the *Display fields of a cost projection.
+func (f NumberFormat) addCostDisplay(p *store.CostProjection) {
+	p.ToDateDisplay = f.Currency(p.ToDate, p.Currency)
+	p.ProjectedDisplay = f.Currency(p.Projected, p.Currency)
+	for i := range p.Providers {
+		pc := &p.Providers[i]
+		pc.ToDateDisplay = f.Currency(pc.ToDate, p.Currency)
+		pc.ProjectedDisplay = f.Currency(pc.Projected, p.Currency)
+		currency := pc.Currency
+		if currency == "" {
+			currency = p.Currency
+		}
+		pc.NativeToDateDisplay = f.Currency(pc.NativeToDate, currency)
+		pc.NativeProjectedDisplay = f.Currency(pc.NativeProjected, currency)
+	}
+}
@@ -0,0 +1,252 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/config"
+)
+
+// GitHub OAuth endpoints (overridden in tests).
+const (
+	githubAuthorizeURL = "https://github.com/login/oauth/authorize"
+	githubTokenURL     = "https://github.com/login/oauth/access_token"
+	githubAPIURL       = "https://api.github.com"
+)
+
+const oauthStateCookieName = "onwatch_oauth_state"
+const oauthStateMaxAge = 600 // 10 minutes to complete the GitHub consent screen
+
+// githubOAuth signs users in with GitHub's web application flow. A GitHub
+// user is let in if their login is allowlisted or they belong to an allowed
+// organization. The callback URL is the one registered on the OAuth app.
+type githubOAuth struct {
+	clientID     string
+	clientSecret string
+	allowedUsers map[string]bool
+	allowedOrgs  map[string]bool
+	defaultRole  string
+
+	authorizeURL string
+	tokenURL     string
+	apiURL       string
+	client       *http.Client
+}
+
+// newGitHubOAuth returns the GitHub login settings, or nil when GitHub OAuth
+// is not configured.
+func newGitHubOAuth(cfg *config.Config) *githubOAuth {
+	if cfg == nil || !cfg.GitHubOAuthEnabled() {
+		return nil
+	}
+	g := &githubOAuth{
+		clientID:     cfg.GitHubClientID,
+		clientSecret: cfg.GitHubClientSecret,
+		allowedUsers: make(map[string]bool),
+		allowedOrgs:  make(map[string]bool),
+		defaultRole:  cfg.GitHubDefaultRole,
+		authorizeURL: githubAuthorizeURL,
+		tokenURL:     githubTokenURL,
+		apiURL:       githubAPIURL,
+		client:       &http.Client{Timeout: 15 * time.Second},
+	}
+	for _, u := range cfg.GitHubAllowedUsers {
+		g.allowedUsers[u] = true
+	}
+	for _, o := range cfg.GitHubAllowedOrgs {
+		g.allowedOrgs[o] = true
+	}
+	if g.defaultRole == "" {
+		g.defaultRole = RoleAdmin
+	}
+	return g
+}
+
+// loginURL is GitHub's consent screen for a state value. Organization
+// membership is only visible with the read:org scope.
+func (g *githubOAuth) loginURL(state string) string {
+	q := url.Values{"client_id": {g.clientID}, "state": {state}}
+	if len(g.allowedOrgs) > 0 {
+		q.Set("scope", "read:org")
+	}
+	return g.authorizeURL + "?" + q.Encode()
+}
+
+// exchange trades the callback code for an access token.
+func (g *githubOAuth) exchange(ctx context.Context, code string) (string, error) {
+	form := url.Values{"client_id": {g.clientID}, "client_secret": {g.clientSecret}, "code": {code}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("github oauth: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("github oauth: token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("github oauth: token exchange: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("github oauth: token exchange failed: %s", body.Error)
+	}
+	return body.AccessToken, nil
+}
+
+// getJSON calls the GitHub API with a user's access token.
+func (g *githubOAuth) getJSON(ctx context.Context, token, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("github api: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github api: %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github api: %s: status %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("github api: %s: %w", path, err)
+	}
+	return nil
+}
+
+// authorize returns the token owner's GitHub login and whether the allowlists
+// let them in.
+func (g *githubOAuth) authorize(ctx context.Context, token string) (string, bool, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := g.getJSON(ctx, token, "/user", &user); err != nil {
+		return "", false, err
+	}
+	login := strings.ToLower(user.Login)
+	if login == "" {
+		return "", false, fmt.Errorf("github api: /user: empty login")
+	}
+	if g.allowedUsers[login] {
+		return login, true, nil
+	}
+	if len(g.allowedOrgs) == 0 {
+		return login, false, nil
+	}
+
+	var orgs []struct {
+		Login string `json:"login"`
+	}
+	if err := g.getJSON(ctx, token, "/user/orgs?per_page=100", &orgs); err != nil {
+		return login, false, err
+	}
+	for _, o := range orgs {
+		if g.allowedOrgs[strings.ToLower(o.Login)] {
+			return login, true, nil
+		}
+	}
+	return login, false, nil
+}
+
+// githubUsername is the onWatch account name of a GitHub login.
+func githubUsername(login string) string {
+	return login + "@github"
+}
+
+// GitHubLogin starts GitHub sign-in (GET /auth/github/login). The state is
+// kept in a short-lived cookie and checked on the callback, so a callback
+// cannot be replayed into another browser.
+func (h *Handler) GitHubLogin(w http.ResponseWriter, r *http.Request) {
+	if h.github == nil || h.sessions == nil {
+		http.NotFound(w, r)
+		return
+	}
+	state := generateToken()
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    state,
+		Path:     "/auth/github/",
+		MaxAge:   oauthStateMaxAge,
+		HttpOnly: true,
+		Secure:   h.secureCookies(r),
+		SameSite: http.SameSiteLaxMode, // sent on GitHub's top-level redirect back
+	})
+	http.Redirect(w, r, h.github.loginURL(state), http.StatusFound)
+}
+
+// GitHubCallback completes GitHub sign-in (GET /auth/github/callback): it
+// checks the state, exchanges the code, checks the allowlists and starts a
+// session. A first-time GitHub user gets an account named "login@github" with
+// ONWATCH_GITHUB_DEFAULT_ROLE; admins can change its role like any other.
+func (h *Handler) GitHubCallback(w http.ResponseWriter, r *http.Request) {
+	if h.github == nil || h.sessions == nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookieName, Value: "", Path: "/auth/github/", MaxAge: -1})
+
+	fail := func(msg string, args ...any) {
+		h.logger.Warn(msg, args...)
+		http.Redirect(w, r, "/login?error="+LoginErrorGitHub, http.StatusFound)
+	}
+
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(oauthStateCookieName)
+	if err != nil || state == "" || cookie.Value != state {
+		fail("GitHub login rejected: state mismatch", "remote", r.RemoteAddr)
+		return
+	}
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		fail("GitHub login cancelled", "error", r.URL.Query().Get("error"))
+		return
+	}
+
+	token, err := h.github.exchange(r.Context(), code)
+	if err != nil {
+		fail("GitHub login failed", "error", err)
+		return
+	}
+	login, allowed, err := h.github.authorize(r.Context(), token)
+	if err != nil {
+		fail("GitHub login failed", "error", err)
+		return
+	}
+	if !allowed {
+		fail("GitHub login rejected: user not in allowlist", "github_user", login)
+		return
+	}
+
+	username := githubUsername(login)
+	role := h.github.defaultRole
+	if h.store != nil {
+		account, err := h.store.GetUserAccount(username)
+		if err != nil {
+			fail("GitHub login failed", "error", err)
+			return
+		}
+		if account != nil {
+			role = account.Role
+		} else if _, err := h.store.CreateUser(username, "", role); err != nil {
+			fail("GitHub login failed", "error", err)
+			return
+		}
+	}
+
+	h.logger.Info("GitHub login", "user", username, "role", role)
+	h.setSessionCookie(w, r, h.sessions.CreateSession(username, role), min(time.Duration(sessionMaxAge)*time.Second, h.sessions.SessionTTL()))
+	http.Redirect(w, r, "/", http.StatusFound)
+}
@@ -0,0 +1,639 @@
+// onWatch gRPC API, served on ONWATCH_GRPC_PORT.
+//
+// Regenerate the Go code with:
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//     internal/web/onwatchpb/onwatch.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: internal/web/onwatchpb/onwatch.proto
+
+package onwatchpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Snapshot is one provider's quotas at a point in time.
+type Snapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	CapturedAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=captured_at,json=capturedAt,proto3" json:"captured_at,omitempty"`
+	Quotas        []*Quota               `protobuf:"bytes,3,rep,name=quotas,proto3" json:"quotas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Snapshot) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Snapshot) GetCapturedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CapturedAt
+	}
+	return nil
+}
+
+func (x *Snapshot) GetQuotas() []*Quota {
+	if x != nil {
+		return x.Quotas
+	}
+	return nil
+}
+
+// Quota is one quota's usage, normalized across providers.
+type Quota struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quota         string                 `protobuf:"bytes,1,opt,name=quota,proto3" json:"quota,omitempty"` // quota key, e.g. "subscription" or "five_hour"
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Percent       float64                `protobuf:"fixed64,3,opt,name=percent,proto3" json:"percent,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`                     // healthy, warning, danger or critical
+	ResetsAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=resets_at,json=resetsAt,proto3" json:"resets_at,omitempty"` // unset when no reset is reported
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quota) Reset() {
+	*x = Quota{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quota) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quota) ProtoMessage() {}
+
+func (x *Quota) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quota.ProtoReflect.Descriptor instead.
+func (*Quota) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Quota) GetQuota() string {
+	if x != nil {
+		return x.Quota
+	}
+	return ""
+}
+
+func (x *Quota) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Quota) GetPercent() float64 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *Quota) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Quota) GetResetsAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ResetsAt
+	}
+	return nil
+}
+
+type GetCurrentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"` // empty for every configured provider
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentRequest) Reset() {
+	*x = GetCurrentRequest{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentRequest) ProtoMessage() {}
+
+func (x *GetCurrentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentRequest) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetCurrentRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+type GetCurrentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshots     []*Snapshot            `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentResponse) Reset() {
+	*x = GetCurrentResponse{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentResponse) ProtoMessage() {}
+
+func (x *GetCurrentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentResponse.ProtoReflect.Descriptor instead.
+func (*GetCurrentResponse) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetCurrentResponse) GetSnapshots() []*Snapshot {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+type GetHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Range         string                 `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"` // 1h, 6h, 24h, 7d or 30d; 6h when empty
+	Quota         string                 `protobuf:"bytes,3,opt,name=quota,proto3" json:"quota,omitempty"` // empty for every quota
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHistoryRequest) Reset() {
+	*x = GetHistoryRequest{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHistoryRequest) ProtoMessage() {}
+
+func (x *GetHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetHistoryRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *GetHistoryRequest) GetRange() string {
+	if x != nil {
+		return x.Range
+	}
+	return ""
+}
+
+func (x *GetHistoryRequest) GetQuota() string {
+	if x != nil {
+		return x.Quota
+	}
+	return ""
+}
+
+type GetHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Series        []*Series              `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHistoryResponse) Reset() {
+	*x = GetHistoryResponse{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHistoryResponse) ProtoMessage() {}
+
+func (x *GetHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetHistoryResponse) GetSeries() []*Series {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+// Series is one quota's usage over time.
+type Series struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Quota         string                 `protobuf:"bytes,2,opt,name=quota,proto3" json:"quota,omitempty"`
+	Label         string                 `protobuf:"bytes,3,opt,name=label,proto3" json:"label,omitempty"`
+	Points        []*Point               `protobuf:"bytes,4,rep,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Series) Reset() {
+	*x = Series{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Series) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Series) ProtoMessage() {}
+
+func (x *Series) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Series.ProtoReflect.Descriptor instead.
+func (*Series) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Series) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Series) GetQuota() string {
+	if x != nil {
+		return x.Quota
+	}
+	return ""
+}
+
+func (x *Series) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Series) GetPoints() []*Point {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+type Point struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=at,proto3" json:"at,omitempty"`
+	Percent       float64                `protobuf:"fixed64,2,opt,name=percent,proto3" json:"percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Point.ProtoReflect.Descriptor instead.
+func (*Point) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Point) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *Point) GetPercent() float64 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_web_onwatchpb_onwatch_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+var File_internal_web_onwatchpb_onwatch_proto protoreflect.FileDescriptor
+
+const file_internal_web_onwatchpb_onwatch_proto_rawDesc = "" +
+	"\n" +
+	"$internal/web/onwatchpb/onwatch.proto\x12\n" +
+	"onwatch.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8e\x01\n" +
+	"\bSnapshot\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12;\n" +
+	"\vcaptured_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"capturedAt\x12)\n" +
+	"\x06quotas\x18\x03 \x03(\v2\x11.onwatch.v1.QuotaR\x06quotas\"\x9c\x01\n" +
+	"\x05Quota\x12\x14\n" +
+	"\x05quota\x18\x01 \x01(\tR\x05quota\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\apercent\x18\x03 \x01(\x01R\apercent\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x127\n" +
+	"\tresets_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bresetsAt\"/\n" +
+	"\x11GetCurrentRequest\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\"H\n" +
+	"\x12GetCurrentResponse\x122\n" +
+	"\tsnapshots\x18\x01 \x03(\v2\x14.onwatch.v1.SnapshotR\tsnapshots\"[\n" +
+	"\x11GetHistoryRequest\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05range\x18\x02 \x01(\tR\x05range\x12\x14\n" +
+	"\x05quota\x18\x03 \x01(\tR\x05quota\"@\n" +
+	"\x12GetHistoryResponse\x12*\n" +
+	"\x06series\x18\x01 \x03(\v2\x12.onwatch.v1.SeriesR\x06series\"{\n" +
+	"\x06Series\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05quota\x18\x02 \x01(\tR\x05quota\x12\x14\n" +
+	"\x05label\x18\x03 \x01(\tR\x05label\x12)\n" +
+	"\x06points\x18\x04 \x03(\v2\x11.onwatch.v1.PointR\x06points\"M\n" +
+	"\x05Point\x12*\n" +
+	"\x02at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x18\n" +
+	"\apercent\x18\x02 \x01(\x01R\apercent\"*\n" +
+	"\fWatchRequest\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider2\xdd\x01\n" +
+	"\x06Quotas\x12K\n" +
+	"\n" +
+	"GetCurrent\x12\x1d.onwatch.v1.GetCurrentRequest\x1a\x1e.onwatch.v1.GetCurrentResponse\x12K\n" +
+	"\n" +
+	"GetHistory\x12\x1d.onwatch.v1.GetHistoryRequest\x1a\x1e.onwatch.v1.GetHistoryResponse\x129\n" +
+	"\x05Watch\x12\x18.onwatch.v1.WatchRequest\x1a\x14.onwatch.v1.Snapshot0\x01B5Z3github.com/onllm-dev/onwatch/internal/web/onwatchpbb\x06proto3"
+
+var (
+	file_internal_web_onwatchpb_onwatch_proto_rawDescOnce sync.Once
+	file_internal_web_onwatchpb_onwatch_proto_rawDescData []byte
+)
+
+func file_internal_web_onwatchpb_onwatch_proto_rawDescGZIP() []byte {
+	file_internal_web_onwatchpb_onwatch_proto_rawDescOnce.Do(func() {
+		file_internal_web_onwatchpb_onwatch_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_internal_web_onwatchpb_onwatch_proto_rawDesc), len(file_internal_web_onwatchpb_onwatch_proto_rawDesc)))
+	})
+	return file_internal_web_onwatchpb_onwatch_proto_rawDescData
+}
+
+var file_internal_web_onwatchpb_onwatch_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_internal_web_onwatchpb_onwatch_proto_goTypes = []any{
+	(*Snapshot)(nil),              // 0: onwatch.v1.Snapshot
+	(*Quota)(nil),                 // 1: onwatch.v1.Quota
+	(*GetCurrentRequest)(nil),     // 2: onwatch.v1.GetCurrentRequest
+	(*GetCurrentResponse)(nil),    // 3: onwatch.v1.GetCurrentResponse
+	(*GetHistoryRequest)(nil),     // 4: onwatch.v1.GetHistoryRequest
+	(*GetHistoryResponse)(nil),    // 5: onwatch.v1.GetHistoryResponse
+	(*Series)(nil),                // 6: onwatch.v1.Series
+	(*Point)(nil),                 // 7: onwatch.v1.Point
+	(*WatchRequest)(nil),          // 8: onwatch.v1.WatchRequest
+	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
+}
+var file_internal_web_onwatchpb_onwatch_proto_depIdxs = []int32{
+	9,  // 0: onwatch.v1.Snapshot.captured_at:type_name -> google.protobuf.Timestamp
+	1,  // 1: onwatch.v1.Snapshot.quotas:type_name -> onwatch.v1.Quota
+	9,  // 2: onwatch.v1.Quota.resets_at:type_name -> google.protobuf.Timestamp
+	0,  // 3: onwatch.v1.GetCurrentResponse.snapshots:type_name -> onwatch.v1.Snapshot
+	6,  // 4: onwatch.v1.GetHistoryResponse.series:type_name -> onwatch.v1.Series
+	7,  // 5: onwatch.v1.Series.points:type_name -> onwatch.v1.Point
+	9,  // 6: onwatch.v1.Point.at:type_name -> google.protobuf.Timestamp
+	2,  // 7: onwatch.v1.Quotas.GetCurrent:input_type -> onwatch.v1.GetCurrentRequest
+	4,  // 8: onwatch.v1.Quotas.GetHistory:input_type -> onwatch.v1.GetHistoryRequest
+	8,  // 9: onwatch.v1.Quotas.Watch:input_type -> onwatch.v1.WatchRequest
+	3,  // 10: onwatch.v1.Quotas.GetCurrent:output_type -> onwatch.v1.GetCurrentResponse
+	5,  // 11: onwatch.v1.Quotas.GetHistory:output_type -> onwatch.v1.GetHistoryResponse
+	0,  // 12: onwatch.v1.Quotas.Watch:output_type -> onwatch.v1.Snapshot
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_internal_web_onwatchpb_onwatch_proto_init() }
+func file_internal_web_onwatchpb_onwatch_proto_init() {
+	if File_internal_web_onwatchpb_onwatch_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_web_onwatchpb_onwatch_proto_rawDesc), len(file_internal_web_onwatchpb_onwatch_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_internal_web_onwatchpb_onwatch_proto_goTypes,
+		DependencyIndexes: file_internal_web_onwatchpb_onwatch_proto_depIdxs,
+		MessageInfos:      file_internal_web_onwatchpb_onwatch_proto_msgTypes,
+	}.Build()
+	File_internal_web_onwatchpb_onwatch_proto = out.File
+	file_internal_web_onwatchpb_onwatch_proto_goTypes = nil
+	file_internal_web_onwatchpb_onwatch_proto_depIdxs = nil
+}
@@ -0,0 +1,160 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/onllm-dev/onwatch/internal/notify"
+	"github.com/onllm-dev/onwatch/internal/store"
+)
+
+// providerKeysSetting holds API keys and tokens saved from the dashboard, as
+// a JSON object of provider to encrypted key. Saved keys take precedence over
+// the environment.
+const providerKeysSetting = "provider_keys"
+
+// keyedProviders are the providers that authenticate with a key or token.
+var keyedProviders = []string{"synthetic", "zai", "anthropic", "copilot", "codex"}
+
+// maxProviderKeyLen bounds a saved key.
+const maxProviderKeyLen = 4096
+
+// SetReloadFunc sets the function that restarts polling with the current
+// provider keys, called after a key is saved or removed. Without one the new
+// key applies on the next start.
+func (h *Handler) SetReloadFunc(fn func() error) {
+	h.reload = fn
+}
+
+// ProviderKey handles /api/providers/{provider}/key (admin only): PUT with
+// {"key": "..."} saves the provider's API key encrypted in the database,
+// DELETE removes it so the environment's key applies again. GET reports
+// whether a key is saved, never the key itself. A change reloads the daemon
+// so the provider's API client is re-created with the new key.
+func (h *Handler) ProviderKey(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/api/providers/")
+	provider, suffix, _ := strings.Cut(rest, "/")
+	if suffix != "key" {
+		respondError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if !slices.Contains(keyedProviders, provider) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("provider '%s' does not use an API key", provider))
+		return
+	}
+
+	keys, err := h.loadProviderKeys()
+	if err != nil {
+		h.logger.Error("failed to read provider keys", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to read provider keys")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		_, saved := keys[provider]
+		respondJSON(w, http.StatusOK, map[string]interface{}{"provider": provider, "saved": saved})
+		return
+	case http.MethodPut:
+		r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+		var req struct {
+			Key string `json:"key"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		key := strings.TrimSpace(req.Key)
+		if key == "" {
+			respondError(w, http.StatusBadRequest, "key is required")
+			return
+		}
+		if len(key) > maxProviderKeyLen || strings.ContainsAny(key, " \t\r\n") {
+			respondError(w, http.StatusBadRequest, "invalid key")
+			return
+		}
+		if err := h.encryptSettingFields(&key); err != nil {
+			h.logger.Error("failed to encrypt provider key", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to encrypt key")
+			return
+		}
+		keys[provider] = key
+	case http.MethodDelete:
+		delete(keys, provider)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	data, _ := json.Marshal(keys)
+	if err := h.store.SetSetting(providerKeysSetting, string(data)); err != nil {
+		h.logger.Error("failed to save provider keys", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to save key")
+		return
+	}
+	h.logger.Info("Provider key changed", "provider", provider, "saved", r.Method == http.MethodPut)
+
+	reloading := false
+	if h.reload != nil {
+		if err := h.reload(); err != nil {
+			h.logger.Error("failed to reload after provider key change", "error", err)
+		} else {
+			reloading = true
+		}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"provider":  provider,
+		"saved":     r.Method == http.MethodPut,
+		"reloading": reloading,
+	})
+}
+
+// loadProviderKeys returns the saved provider keys, still encrypted.
+func (h *Handler) loadProviderKeys() (map[string]string, error) {
+	keys := map[string]string{}
+	v, err := h.store.GetSetting(providerKeysSetting)
+	if err != nil || v == "" {
+		return keys, err
+	}
+	if err := json.Unmarshal([]byte(v), &keys); err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", providerKeysSetting, err)
+	}
+	return keys, nil
+}
+
+// StoredProviderKeys returns the provider keys saved from the dashboard,
+// decrypted with the key derived from the admin password hash. Keys that
+// fail to decrypt are skipped and reported in the error.
+func StoredProviderKeys(s *store.Store, passwordHash string) (map[string]string, error) {
+	v, err := s.GetSetting(providerKeysSetting)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	var stored map[string]string
+	if err := json.Unmarshal([]byte(v), &stored); err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", providerKeysSetting, err)
+	}
+	encKey := DeriveEncryptionKey(passwordHash, nil)
+	keys := make(map[string]string, len(stored))
+	var failed []string
+	for provider, enc := range stored {
+		plain, err := notify.DecryptFromStorage(enc, encKey)
+		if err != nil || plain == "" {
+			failed = append(failed, provider)
+			continue
+		}
+		keys[provider] = plain
+	}
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		return keys, fmt.Errorf("could not decrypt saved keys for: %s", strings.Join(failed, ", "))
+	}
+	return keys, nil
+}
@@ -0,0 +1,196 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// resetsMaxCycles bounds the completed cycles read for /api/resets.
+const resetsMaxCycles = 500
+
+// resetDefaultQuota is the quota /api/resets uses when ?quota= is omitted.
+var resetDefaultQuota = map[string]string{
+	"synthetic": "subscription",
+	"zai":       "tokens",
+	"anthropic": "five_hour",
+	"copilot":   "premium_interactions",
+	"codex":     "five_hour",
+}
+
+// resetMarker is one reset cycle on the history chart. End is nil for the
+// cycle still in progress.
+type resetMarker struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end"`
+	Peak  float64    `json:"peak"`
+}
+
+// Resets handles GET /api/resets?provider=&quota=&range=: the reset cycles of
+// one quota that overlap the range (default 30d), oldest first, so the
+// dashboard can mark each reset on the history chart. Each cycle's start is
+// a reset boundary and peak is the highest usage reached in it, in the
+// provider's own unit (as in /api/cycles). Antigravity quotas are model IDs
+// and have no default.
+func (h *Handler) Resets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	provider, err := h.getProviderFromRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if provider == "both" {
+		respondError(w, http.StatusBadRequest, "resets are per provider")
+		return
+	}
+	rangeStr := r.URL.Query().Get("range")
+	if rangeStr == "" {
+		rangeStr = "30d"
+	}
+	window, err := parseTimeRange(rangeStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	quota := r.URL.Query().Get("quota")
+	if quota == "" {
+		quota = resetDefaultQuota[provider]
+	}
+	if quota == "" {
+		respondError(w, http.StatusBadRequest, "quota is required")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	cycles, err := h.resetMarkers(provider, quota)
+	if err != nil {
+		h.logger.Error("failed to query reset cycles", "provider", provider, "quota", quota, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query cycles")
+		return
+	}
+
+	since := time.Now().UTC().Add(-window)
+	resets := []resetMarker{}
+	for _, c := range cycles {
+		if c.End != nil && c.End.Before(since) {
+			continue
+		}
+		resets = append(resets, c)
+	}
+	sort.Slice(resets, func(i, j int) bool { return resets[i].Start.Before(resets[j].Start) })
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"provider": provider,
+		"quota":    quota,
+		"range":    rangeStr,
+		"resets":   resets,
+	})
+}
+
+// resetMarkers returns the completed and active cycles of quota.
+func (h *Handler) resetMarkers(provider, quota string) ([]resetMarker, error) {
+	var markers []resetMarker
+	add := func(start time.Time, end *time.Time, peak float64) {
+		markers = append(markers, resetMarker{Start: start.UTC(), End: end, Peak: peak})
+	}
+
+	switch provider {
+	case "synthetic":
+		history, err := h.store.QueryCycleHistory(quota, resetsMaxCycles)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range history {
+			add(c.CycleStart, c.CycleEnd, c.PeakRequests)
+		}
+		active, err := h.store.QueryActiveCycle(quota)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			add(active.CycleStart, nil, active.PeakRequests)
+		}
+	case "zai":
+		history, err := h.store.QueryZaiCycleHistory(quota, resetsMaxCycles)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range history {
+			add(c.CycleStart, c.CycleEnd, float64(c.PeakValue))
+		}
+		active, err := h.store.QueryActiveZaiCycle(quota)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			add(active.CycleStart, nil, float64(active.PeakValue))
+		}
+	case "anthropic":
+		history, err := h.store.QueryAnthropicCycleHistory(quota, resetsMaxCycles)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range history {
+			add(c.CycleStart, c.CycleEnd, c.PeakUtilization)
+		}
+		active, err := h.store.QueryActiveAnthropicCycle(quota)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			add(active.CycleStart, nil, active.PeakUtilization)
+		}
+	case "copilot":
+		history, err := h.store.QueryCopilotCycleHistory(quota, resetsMaxCycles)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range history {
+			add(c.CycleStart, c.CycleEnd, float64(c.PeakUsed))
+		}
+		active, err := h.store.QueryActiveCopilotCycle(quota)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			add(active.CycleStart, nil, float64(active.PeakUsed))
+		}
+	case "codex":
+		history, err := h.store.QueryCodexCycleHistory(quota, resetsMaxCycles)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range history {
+			add(c.CycleStart, c.CycleEnd, c.PeakUtilization)
+		}
+		active, err := h.store.QueryActiveCodexCycle(quota)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			add(active.CycleStart, nil, active.PeakUtilization)
+		}
+	case "antigravity":
+		history, err := h.store.QueryAntigravityCycleHistory(quota, resetsMaxCycles)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range history {
+			add(c.CycleStart, c.CycleEnd, c.PeakUsage)
+		}
+		active, err := h.store.QueryActiveAntigravityCycle(quota)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			add(active.CycleStart, nil, active.PeakUsage)
+		}
+	}
+	return markers, nil
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"strconv"
+	"time"
+)
+
+// maxRetentionDays bounds the snapshot_retention_days and
+// cycle_retention_days settings (ten years).
+const maxRetentionDays = 3650
+
+// snapshotRetentionDays returns the snapshot_retention_days setting: how
+// many days of raw snapshots to keep. Zero keeps them forever.
+func (h *Handler) snapshotRetentionDays() int {
+	return h.retentionDays("snapshot_retention_days")
+}
+
+// cycleRetentionDays returns the cycle_retention_days setting: how many days
+// of completed reset cycles to keep. Zero keeps them forever.
+func (h *Handler) cycleRetentionDays() int {
+	return h.retentionDays("cycle_retention_days")
+}
+
+func (h *Handler) retentionDays(key string) int {
+	if h.store == nil {
+		return 0
+	}
+	v, _ := h.store.GetSetting(key)
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > maxRetentionDays {
+		return 0
+	}
+	return n
+}
+
+// PruneExpiredData deletes snapshots older than snapshot_retention_days and
+// completed cycles older than cycle_retention_days. The two are independent,
+// so raw snapshots can be pruned aggressively while cycle history, which the
+// insights read, is kept for longer. Errors are logged.
+func (h *Handler) PruneExpiredData(now time.Time) {
+	if h.store == nil {
+		return
+	}
+	if days := h.snapshotRetentionDays(); days > 0 {
+		n, err := h.store.PruneSnapshots(now.AddDate(0, 0, -days))
+		if err != nil {
+			h.logger.Error("failed to prune snapshots", "error", err)
+		} else if n > 0 {
+			h.logger.Info("Pruned expired snapshots", "deleted", n, "retention_days", days)
+		}
+	}
+	if days := h.cycleRetentionDays(); days > 0 {
+		n, err := h.store.PruneCycles(now.AddDate(0, 0, -days))
+		if err != nil {
+			h.logger.Error("failed to prune reset cycles", "error", err)
+		} else if n > 0 {
+			h.logger.Info("Pruned expired reset cycles", "deleted", n, "retention_days", days)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package web
 
 import (
 	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -129,6 +131,51 @@ func getClientIP(r *http.Request) string {
 	return ip
 }
 
+// allowlistClientIP returns the client address the IP allowlist checks.
+// Forwarded headers are only honoured when RemoteAddr is one of the trusted
+// proxies; X-Forwarded-For is then read from the right, skipping trusted
+// proxies, so an entry the client supplied itself is never picked.
+func allowlistClientIP(r *http.Request, trustedProxies []string) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !ipInList(remote, trustedProxies) {
+		return remote
+	}
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop != "" && !ipInList(hop, trustedProxies) {
+				return hop
+			}
+		}
+	}
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-Ip")); xri != "" {
+		return xri
+	}
+	return remote
+}
+
+// ipInList reports whether addr matches one of the addresses or CIDRs in list.
+func ipInList(addr string, list []string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range list {
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // formatDurationSeconds formats a duration as seconds for Retry-After header
 func formatDurationSeconds(d time.Duration) string {
 	return string(rune(int(d.Seconds())))
@@ -136,8 +183,10 @@ func formatDurationSeconds(d time.Duration) string {
 
 // IPWhitelistMiddleware creates a middleware that restricts access by IP
 type IPWhitelistMiddleware struct {
-	allowed []string // CIDR notation
-	logger  interface{ Info(msg string, args ...any) }
+	mu             sync.RWMutex
+	allowed        []string // CIDR notation
+	trustedProxies []string // proxies whose forwarded headers are honoured
+	logger         interface{ Info(msg string, args ...any) }
 }
 
 // NewIPWhitelistMiddleware creates a new IP whitelist middleware
@@ -148,15 +197,52 @@ func NewIPWhitelistMiddleware(allowed []string, logger interface{ Info(msg strin
 	}
 }
 
+// SetAllowed replaces the whitelist. An empty list allows every address.
+func (m *IPWhitelistMiddleware) SetAllowed(allowed []string) {
+	m.mu.Lock()
+	m.allowed = allowed
+	m.mu.Unlock()
+}
+
+// SetTrustedProxies sets the reverse proxies (addresses or CIDRs) whose
+// X-Forwarded-For and X-Real-Ip headers name the client. Headers from any
+// other address are ignored.
+func (m *IPWhitelistMiddleware) SetTrustedProxies(proxies []string) {
+	m.mu.Lock()
+	m.trustedProxies = proxies
+	m.mu.Unlock()
+}
+
+// ClientIP returns the address of the client behind r, as the allowlist
+// sees it.
+func (m *IPWhitelistMiddleware) ClientIP(r *http.Request) string {
+	m.mu.RLock()
+	trusted := m.trustedProxies
+	m.mu.RUnlock()
+	return allowlistClientIP(r, trusted)
+}
+
+// Allows reports whether addr may connect: the whitelist is empty or
+// contains it.
+func (m *IPWhitelistMiddleware) Allows(addr string) bool {
+	m.mu.RLock()
+	empty := len(m.allowed) == 0
+	m.mu.RUnlock()
+	return empty || m.isAllowed(addr)
+}
+
 // Middleware returns the middleware handler
 func (m *IPWhitelistMiddleware) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if len(m.allowed) == 0 {
+		m.mu.RLock()
+		empty := len(m.allowed) == 0
+		m.mu.RUnlock()
+		if empty {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		clientIP := getClientIP(r)
+		clientIP := m.ClientIP(r)
 		if !m.isAllowed(clientIP) {
 			m.logger.Info("IP not in whitelist", "ip", clientIP, "path", r.URL.Path)
 			http.Error(w, "Forbidden", http.StatusForbidden)
@@ -175,6 +261,8 @@ func (m *IPWhitelistMiddleware) isAllowed(clientIP string) bool {
 		return false
 	}
 
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	// Check against each CIDR in whitelist
 	for _, cidr := range m.allowed {
 		_, ipNet, err := net.ParseCIDR(cidr)
@@ -195,6 +283,29 @@ func (m *IPWhitelistMiddleware) isAllowed(clientIP string) bool {
 	return false
 }
 
+// normalizeAllowedIPs validates an allowed_ips list of CIDRs and single
+// addresses, dropping blanks and duplicates.
+func normalizeAllowedIPs(entries []string) ([]string, error) {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(e); err == nil {
+			e = ipNet.String()
+		} else if ip := net.ParseIP(e); ip != nil {
+			e = ip.String()
+		} else {
+			return nil, fmt.Errorf("invalid allowed IP %q: use an address or CIDR such as 192.168.1.0/24", e)
+		}
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
 // isEncryptedValue checks if a string looks like an encrypted value
 // (base64 encoded with minimum length for nonce + ciphertext)
 func isEncryptedValue(value string) bool {
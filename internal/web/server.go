@@ -27,6 +27,9 @@ type Server struct {
 	handler    *Handler
 	logger     *slog.Logger
 	port       int
+
+	mu       sync.Mutex
+	listener net.Listener
 }
 
 // NewServer creates a new Server instance.
@@ -45,13 +48,19 @@ func NewServer(port int, handler *Handler, logger *slog.Logger, username, passwo
 	mux.HandleFunc("/", handler.Dashboard)
 	mux.HandleFunc("/settings", handler.SettingsPage)
 	mux.HandleFunc("/login", handler.Login)
+	mux.HandleFunc("/status", handler.StatusPage)
 	mux.HandleFunc("/logout", handler.Logout)
+	mux.HandleFunc("/auth/github/login", handler.GitHubLogin)
+	mux.HandleFunc("/auth/github/callback", handler.GitHubCallback)
 	mux.HandleFunc("/api/providers", handler.Providers)
+	mux.HandleFunc("/api/providers/", handler.ProviderKey)
 	mux.HandleFunc("/api/current", handler.Current)
 	mux.HandleFunc("/api/history", handler.History)
 	mux.HandleFunc("/api/cycles", handler.Cycles)
 	mux.HandleFunc("/api/summary", handler.Summary)
 	mux.HandleFunc("/api/sessions", handler.Sessions)
+	mux.HandleFunc("/api/sessions/stats", handler.SessionStats)
+	mux.HandleFunc("/api/compare", handler.Compare)
 	mux.HandleFunc("/api/insights", handler.Insights)
 	mux.HandleFunc("/api/settings", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPut {
@@ -61,14 +70,49 @@ func NewServer(port int, handler *Handler, logger *slog.Logger, username, passwo
 		}
 	})
 	mux.HandleFunc("/api/settings/smtp/test", handler.SMTPTest)
+	mux.HandleFunc("/api/settings/matrix/test", handler.MatrixTest)
+	mux.HandleFunc("/api/settings/sms/test", handler.SMSTest)
+	mux.HandleFunc("/api/settings/templates/preview", handler.TemplatePreview)
+	mux.HandleFunc("/api/settings/session-timeout", handler.SessionTimeout)
+	mux.HandleFunc("/api/settings/export", handler.ExportSettings)
+	mux.HandleFunc("/api/settings/import", handler.ImportSettings)
 	mux.HandleFunc("/api/password", handler.ChangePassword)
+	mux.HandleFunc("/api/users", handler.Users)
+	mux.HandleFunc("/api/users/", handler.User)
 	mux.HandleFunc("/api/cycle-overview", handler.CycleOverview)
 	mux.HandleFunc("/api/logging-history", handler.LoggingHistory)
+	mux.HandleFunc("/api/version", handler.Version)
+	mux.HandleFunc("/api/log-level", handler.LogLevel)
 	mux.HandleFunc("/api/update/check", handler.CheckUpdate)
 	mux.HandleFunc("/api/update/apply", handler.ApplyUpdate)
+	mux.HandleFunc("/api/update/rollback", handler.RollbackUpdate)
 	mux.HandleFunc("/api/push/vapid", handler.PushVAPIDKey)
 	mux.HandleFunc("/api/push/subscribe", handler.PushSubscribe)
 	mux.HandleFunc("/api/push/test", handler.PushTest)
+	mux.HandleFunc("/api/notifications/log", handler.NotificationLog)
+	mux.HandleFunc("/api/notifications", handler.Notifications)
+	mux.HandleFunc("/api/notifications/ack", handler.NotificationAck)
+	mux.HandleFunc("/api/notifications/ack-all", handler.NotificationAckAll)
+	mux.HandleFunc("/api/notifications/prefs", handler.NotificationPrefs)
+	mux.HandleFunc("/api/availability", handler.Availability)
+	mux.HandleFunc("/api/cost/projection", handler.CostProjection)
+	mux.HandleFunc("/api/agent-status", handler.AgentStatus)
+	mux.HandleFunc("/api/copilot/info", handler.CopilotInfo)
+	mux.HandleFunc("/api/antigravity/models", handler.AntigravityModels)
+	mux.HandleFunc("/api/poll", handler.Poll)
+	mux.HandleFunc("/api/overview", handler.Overview)
+	mux.HandleFunc("/api/search", handler.Search)
+	mux.HandleFunc("/api/widget", handler.Widget)
+	mux.HandleFunc("/api/compact", handler.Compact)
+	mux.HandleFunc("/api/data", handler.ClearData)
+	mux.HandleFunc("/api/debug/snapshot", handler.DebugSnapshot)
+	mux.HandleFunc("/api/export", handler.ExportData)
+	mux.HandleFunc("/api/next-reset", handler.NextReset)
+	mux.HandleFunc("/api/resets", handler.Resets)
+	mux.HandleFunc("/api/calendar.ics", handler.Calendar)
+	mux.HandleFunc("/api/calendar/token", handler.CalendarToken)
+	mux.HandleFunc("/graphql", handler.GraphQL)
+	mux.HandleFunc("/metrics", handler.Metrics)
 
 	// Service worker (must be served from root scope, no-cache)
 	mux.HandleFunc("/sw.js", func(w http.ResponseWriter, r *http.Request) {
@@ -91,16 +135,23 @@ func NewServer(port int, handler *Handler, logger *slog.Logger, username, passwo
 	staticHandler := http.FileServer(http.FS(staticDir))
 	mux.Handle("/static/", http.StripPrefix("/static/", contentTypeHandler(staticHandler)))
 
-	// Apply middleware chain: security headers -> gzip compression -> auth -> routes
-	var finalHandler http.Handler = mux
+	// Apply middleware chain: IP allowlist -> security headers -> gzip compression -> auth -> idempotency keys -> routes
+	var finalHandler http.Handler = idempotencyMiddleware(newIdempotencyCache())(mux)
 	if username != "" && passwordHash != "" {
 		sessions := NewSessionStore(username, passwordHash, handler.store)
 		handler.sessions = sessions
-		finalHandler = SessionAuthMiddleware(sessions, logger)(mux)
+		if handler.github != nil && handler.config.DisablePasswordLogin {
+			sessions.DisablePasswordLogin()
+		}
+		finalHandler = SessionAuthMiddleware(sessions, logger)(finalHandler)
 	}
 	// Apply security headers and gzip compression (outermost)
 	finalHandler = securityHeadersMiddleware(gzipHandler(finalHandler))
 	finalHandler = csrfMiddleware(finalHandler)
+	// The IP allowlist runs before everything else, auth included
+	if handler.ipAllowlist != nil {
+		finalHandler = handler.ipAllowlist.Middleware(finalHandler)
+	}
 
 	return &Server{
 		httpServer: &http.Server{
@@ -152,6 +203,14 @@ func (grw *gzipResponseWriter) Write(b []byte) (int, error) {
 	return grw.Writer.Write(b)
 }
 
+// Flush sends the compressed bytes written so far, for streamed responses.
+func (grw *gzipResponseWriter) Flush() {
+	if gz, ok := grw.Writer.(*gzip.Writer); ok {
+		gz.Flush()
+	}
+	http.NewResponseController(grw.ResponseWriter).Flush()
+}
+
 var gzipWriterPool = sync.Pool{
 	New: func() any {
 		w, _ := gzip.NewWriterLevel(io.Discard, gzip.BestSpeed)
@@ -214,22 +273,49 @@ func securityHeadersMiddleware(next http.Handler) http.Handler {
 		// Control referrer information
 		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
 		// Content Security Policy
-		w.Header().Set("Content-Security-Policy",
-			"default-src 'self'; "+
-				"script-src 'self' cdn.jsdelivr.net; "+
-				"style-src 'self' 'unsafe-inline' fonts.googleapis.com; "+
-				"font-src 'self' fonts.gstatic.com; "+
-				"img-src 'self' data:; "+
-				"connect-src 'self'; "+
-				"worker-src 'self'")
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy())
 		next.ServeHTTP(w, r)
 	})
 }
 
+// contentSecurityPolicy returns the dashboard's Content-Security-Policy,
+// allowing images from the extra sources too (a custom branding logo).
+func contentSecurityPolicy(imgSources ...string) string {
+	imgSrc := strings.Join(append([]string{"'self'", "data:"}, imgSources...), " ")
+	return "default-src 'self'; " +
+		"script-src 'self' cdn.jsdelivr.net; " +
+		"style-src 'self' 'unsafe-inline' fonts.googleapis.com; " +
+		"font-src 'self' fonts.gstatic.com; " +
+		"img-src " + imgSrc + "; " +
+		"connect-src 'self'; " +
+		"worker-src 'self'"
+}
+
 // Start begins listening for HTTP requests
 func (s *Server) Start() error {
-	s.logger.Info("starting web server", "addr", s.httpServer.Addr)
-	return s.httpServer.ListenAndServe()
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on ln, such as a socket inherited from the
+// previous process during a graceful restart.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
+	s.logger.Info("starting web server", "addr", ln.Addr().String())
+	return s.httpServer.Serve(ln)
+}
+
+// Listener returns the socket the server accepts connections on, or nil
+// before it has started.
+func (s *Server) Listener() net.Listener {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listener
 }
 
 // Shutdown gracefully shuts down the server
@@ -2,6 +2,7 @@ package web
 
 import (
 	"context"
+	"encoding/base64"
 	"io"
 	"log/slog"
 	"net"
@@ -11,6 +12,18 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/onllm-dev/onwatch/internal/agent"
+	"github.com/onllm-dev/onwatch/internal/api"
+	"github.com/onllm-dev/onwatch/internal/store"
+	"github.com/onllm-dev/onwatch/internal/tracker"
+	"github.com/onllm-dev/onwatch/internal/web/onwatchpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 // freePort returns an available TCP port for testing
@@ -216,6 +229,32 @@ func TestServer_GracefulShutdown(t *testing.T) {
 	}
 }
 
+func TestServer_ServeInheritedListener(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewHandler(nil, nil, logger, nil, nil)
+	passHash, _ := HashPassword("test")
+	server := NewServer(0, handler, logger, "admin", passHash, "")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.Serve(ln)
+	defer server.Shutdown(context.Background())
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/static/style.css")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+	if server.Listener() != ln {
+		t.Error("Listener() should return the listener passed to Serve")
+	}
+}
+
 func TestServer_EmbeddedAssets(t *testing.T) {
 	// Test that embedded assets are accessible
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
@@ -373,3 +412,180 @@ func TestServer_AllowsGet_WithoutCSRFHeader(t *testing.T) {
 		t.Error("HEAD request should not be blocked by CSRF middleware")
 	}
 }
+
+func TestGRPCServer(t *testing.T) {
+	s, _ := store.New(":memory:")
+	defer s.Close()
+	now := time.Now().UTC()
+	s.InsertSnapshot(&api.Snapshot{
+		CapturedAt: now,
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 250, RenewsAt: now.Add(2 * time.Hour)},
+		Search:     api.QuotaInfo{Limit: 250, RenewsAt: now.Add(time.Hour)},
+		ToolCall:   api.QuotaInfo{Limit: 16200, RenewsAt: now.Add(3 * time.Hour)},
+	})
+
+	h := NewHandler(s, tracker.New(s, nil), nil, nil, createTestConfigWithSynthetic())
+	h.sessions = NewSessionStore("admin", legacyHashPassword("secret"), s)
+	latest := agent.NewLatestSnapshots(0)
+	h.SetLatestSnapshots(latest)
+
+	ln := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(h)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient failed: %v", err)
+	}
+	defer conn.Close()
+	client := onwatchpb.NewQuotasClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.GetCurrent(ctx, &onwatchpb.GetCurrentRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without credentials, got %v", err)
+	}
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:secret")))
+	resp, err := client.GetCurrent(authCtx, &onwatchpb.GetCurrentRequest{Provider: "synthetic"})
+	if err != nil {
+		t.Fatalf("GetCurrent failed: %v", err)
+	}
+	var found bool
+	for _, q := range resp.GetSnapshots()[0].GetQuotas() {
+		if q.GetQuota() == "subscription" {
+			found = q.GetPercent() == 25 && q.GetResetsAt() != nil
+		}
+	}
+	if !found {
+		t.Errorf("expected subscription at 25%% with a reset time, got %v", resp)
+	}
+	if _, err := client.GetHistory(authCtx, &onwatchpb.GetHistoryRequest{Provider: "zai"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an unconfigured provider, got %v", err)
+	}
+
+	// History reads only the requested provider and is downsampled to
+	// chart_max_points
+	for i := 1; i <= 150; i++ {
+		s.InsertSnapshot(&api.Snapshot{
+			CapturedAt: now.Add(-time.Duration(i) * time.Minute),
+			Sub:        api.QuotaInfo{Limit: 1000, Requests: float64(i), RenewsAt: now.Add(2 * time.Hour)},
+		})
+	}
+	s.SetSetting("chart_max_points", "100")
+	hist, err := client.GetHistory(authCtx, &onwatchpb.GetHistoryRequest{Provider: "synthetic", Quota: "subscription"})
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	if len(hist.GetSeries()) != 1 || len(hist.GetSeries()[0].GetPoints()) != 100 {
+		t.Errorf("expected one subscription series of 100 points, got %v", hist.GetSeries())
+	}
+
+	token := h.sessions.CreateSession("admin", RoleAdmin)
+	stream, err := client.Watch(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), &onwatchpb.WatchRequest{Provider: "synthetic"})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("expected the current snapshot first, got %v", err)
+	}
+	latest.Update("synthetic", &api.Snapshot{
+		CapturedAt: now.Add(time.Minute),
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 500, RenewsAt: now.Add(2 * time.Hour)},
+	}, now.Add(time.Minute))
+	snap, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("expected a snapshot after the poll, got %v", err)
+	}
+	if !snap.GetCapturedAt().AsTime().Equal(now.Add(time.Minute).Truncate(time.Second)) {
+		t.Errorf("expected the polled snapshot, got captured_at %v", snap.GetCapturedAt().AsTime())
+	}
+}
+
+func TestGRPCServer_IPAllowlist(t *testing.T) {
+	s, _ := store.New(":memory:")
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+	h.sessions = NewSessionStore("admin", legacyHashPassword("secret"), s)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := NewGRPCServer(h)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient failed: %v", err)
+	}
+	defer conn.Close()
+	client := onwatchpb.NewQuotasClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:secret")))
+
+	h.ipAllowlist.SetAllowed([]string{"10.0.0.0/8"})
+	if _, err := client.GetCurrent(authCtx, &onwatchpb.GetCurrentRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("address outside the allowlist: expected PermissionDenied, got %v", err)
+	}
+	stream, err := client.Watch(authCtx, &onwatchpb.WatchRequest{Provider: "synthetic"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Watch from outside the allowlist: expected PermissionDenied, got %v", err)
+	}
+
+	h.ipAllowlist.SetAllowed([]string{"127.0.0.1"})
+	if _, err := client.GetCurrent(authCtx, &onwatchpb.GetCurrentRequest{}); err != nil {
+		t.Errorf("allowed address: GetCurrent failed: %v", err)
+	}
+}
+
+func TestGRPCServer_RateLimitsFailedLogins(t *testing.T) {
+	s, _ := store.New(":memory:")
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+	h.sessions = NewSessionStore("admin", legacyHashPassword("secret"), s)
+	h.SetRateLimiter(NewLoginRateLimiter(10))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := NewGRPCServer(h)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient failed: %v", err)
+	}
+	defer conn.Close()
+	client := onwatchpb.NewQuotasClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	basic := func(pass string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:"+pass)))
+	}
+
+	for i := 0; i < maxFailedAttempts; i++ {
+		if _, err := client.GetCurrent(basic("wrong"), &onwatchpb.GetCurrentRequest{}); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("attempt %d: expected Unauthenticated, got %v", i+1, err)
+		}
+	}
+	// Blocked now, even with the right password
+	if _, err := client.GetCurrent(basic("secret"), &onwatchpb.GetCurrentRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted once blocked, got %v", err)
+	}
+	if !h.rateLimiter.IsBlocked("127.0.0.1") {
+		t.Error("expected the peer address to be blocked")
+	}
+}
@@ -0,0 +1,154 @@
+package web
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// sparklinePoints is the most points a quota's sparkline has.
+	sparklinePoints = 20
+	// sparklineMaxLookback bounds how far back a sparkline reads, for quotas
+	// with long cycles.
+	sparklineMaxLookback = 7 * 24 * time.Hour
+	// sparklineFallback is the lookback of quotas without an active cycle.
+	sparklineFallback = 24 * time.Hour
+)
+
+// sparklineQuotaKeys maps the quota keys of the Synthetic and Z.ai current
+// responses to their keys in the percentage history. Providers with a
+// "quotas" list use the quota name for both.
+var sparklineQuotaKeys = map[string]map[string]string{
+	"synthetic": {"subscription": "subscription", "search": "search", "toolCalls": "toolCalls"},
+	"zai":       {"tokensLimit": "tokens", "timeLimit": "time", "toolCalls": "toolCalls"},
+}
+
+// wantSparklines reports whether the request asked for ?sparkline=true.
+func wantSparklines(r *http.Request) bool {
+	return r.URL.Query().Get("sparkline") == "true"
+}
+
+// addSparklines adds a "sparkline" to each quota of provider's current
+// response: up to sparklinePoints usage percentages, oldest first, averaged
+// over even slices of the quota's current cycle. Antigravity has no
+// percentage history and gets none.
+func (h *Handler) addSparklines(provider string, resp map[string]interface{}) {
+	quotas := map[string]map[string]interface{}{}
+	if keys, ok := sparklineQuotaKeys[provider]; ok {
+		for respKey, sampleKey := range keys {
+			if m, ok := resp[respKey].(map[string]interface{}); ok {
+				quotas[sampleKey] = m
+			}
+		}
+	} else {
+		list, _ := resp["quotas"].([]map[string]interface{})
+		for _, m := range list {
+			if name, ok := m["name"].(string); ok {
+				quotas[name] = m
+			}
+		}
+	}
+	if len(quotas) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	starts := map[string]time.Time{}
+	earliest := now
+	for key := range quotas {
+		start, ok := h.activeCycleStart(provider, key)
+		if !ok {
+			start = now.Add(-sparklineFallback)
+		}
+		start = maxTime(start, now.Add(-sparklineMaxLookback))
+		starts[key] = start
+		earliest = minTime(earliest, start)
+	}
+
+	samples, ok := h.providerPercentSamples(provider, earliest, now)
+	if !ok {
+		return
+	}
+	for key, m := range quotas {
+		var values []float64
+		for _, s := range samples {
+			if v, ok := s.values[key]; ok && !s.at.Before(starts[key]) {
+				values = append(values, v)
+			}
+		}
+		m["sparkline"] = downsampleSparkline(values, sparklinePoints)
+	}
+}
+
+// activeCycleStart returns when the active cycle of provider's quota began,
+// keyed as in the percentage history.
+func (h *Handler) activeCycleStart(provider, quota string) (time.Time, bool) {
+	if h.store == nil {
+		return time.Time{}, false
+	}
+	switch provider {
+	case "synthetic":
+		if quota == "toolCalls" {
+			quota = "toolcall"
+		}
+		if c, err := h.store.QueryActiveCycle(quota); err == nil && c != nil {
+			return c.CycleStart, true
+		}
+	case "zai":
+		if quota == "toolCalls" {
+			quota = "time" // tool calls draw from the time budget
+		}
+		if c, err := h.store.QueryActiveZaiCycle(quota); err == nil && c != nil {
+			return c.CycleStart, true
+		}
+	case "anthropic":
+		if c, err := h.store.QueryActiveAnthropicCycle(quota); err == nil && c != nil {
+			return c.CycleStart, true
+		}
+	case "copilot":
+		if c, err := h.store.QueryActiveCopilotCycle(quota); err == nil && c != nil {
+			return c.CycleStart, true
+		}
+	case "codex":
+		if c, err := h.store.QueryActiveCodexCycle(quota); err == nil && c != nil {
+			return c.CycleStart, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// downsampleSparkline averages values into at most n even slices, rounded to
+// two decimals. It never returns nil, so the JSON is always an array.
+func downsampleSparkline(values []float64, n int) []float64 {
+	if len(values) <= n {
+		out := make([]float64, len(values))
+		for i, v := range values {
+			out[i] = round2(v)
+		}
+		return out
+	}
+	out := make([]float64, n)
+	for i := range out {
+		lo, hi := i*len(values)/n, (i+1)*len(values)/n
+		var sum float64
+		for _, v := range values[lo:hi] {
+			sum += v
+		}
+		out[i] = round2(sum / float64(hi-lo))
+	}
+	return out
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -446,7 +446,7 @@ function renderAnthropicQuotaCards(quotas, containerId) {
           <svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${statusCfg.icon}"/></svg>
           ${statusCfg.label}
         </span>
-        <span class="reset-time" id="${resetId}">${q.resetsAt ? 'Resets: ' + formatDateTime(q.resetsAt) : ''}</span>
+        <span class="reset-time" id="${resetId}">${q.resetsAt ? 'Resets: ' + formatDateTime(q.resetsAt, 'anthropic') : ''}</span>
       </footer>
     </article>`;
   }).join('');
@@ -507,7 +507,7 @@ function updateAnthropicCard(quota) {
     statusEl.innerHTML = `<svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${config.icon}"/></svg>${config.label}`;
   }
   if (resetEl) {
-    resetEl.textContent = quota.resetsAt ? `Resets: ${formatDateTime(quota.resetsAt)}` : '';
+    resetEl.textContent = quota.resetsAt ? `Resets: ${formatDateTime(quota.resetsAt, 'anthropic')}` : '';
   }
   if (countdownEl) {
     if (quota.timeUntilResetSeconds > 0) {
@@ -697,7 +697,7 @@ function renderCopilotQuotaCards(quotas, containerId) {
           <svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${statusCfg.icon}"/></svg>
           ${statusCfg.label}
         </span>
-        <span class="reset-time" id="${resetId}">${q.resetDate ? 'Resets: ' + formatDateTime(q.resetDate) : ''}</span>
+        <span class="reset-time" id="${resetId}">${q.resetDate ? 'Resets: ' + formatDateTime(q.resetDate, 'copilot') : ''}</span>
       </footer>
     </article>`;
   }).join('');
@@ -769,7 +769,7 @@ function updateCopilotCard(quota) {
     statusEl.innerHTML = `<svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${config.icon}"/></svg>${config.label}`;
   }
   if (resetEl) {
-    resetEl.textContent = quota.resetDate ? `Resets: ${formatDateTime(quota.resetDate)}` : '';
+    resetEl.textContent = quota.resetDate ? `Resets: ${formatDateTime(quota.resetDate, 'copilot')}` : '';
   }
   if (countdownEl) {
     if (quota.timeUntilResetSeconds > 0) {
@@ -995,7 +995,7 @@ function renderAntigravityQuotaCards(quotas, containerId) {
           <svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${statusCfg.icon}"/></svg>
           ${statusCfg.label}
         </span>
-        <span class="reset-time" id="${resetId}">${q.resetTime ? 'Resets: ' + formatDateTime(q.resetTime) : ''}</span>
+        <span class="reset-time" id="${resetId}">${q.resetTime ? 'Resets: ' + formatDateTime(q.resetTime, 'antigravity') : ''}</span>
       </footer>
     </article>`;
   }).join('');
@@ -1063,7 +1063,7 @@ function updateAntigravityCard(quota) {
     statusEl.innerHTML = `<svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${config.icon}"/></svg>${config.label}`;
   }
   if (resetEl) {
-    resetEl.textContent = quota.resetTime ? `Resets: ${formatDateTime(quota.resetTime)}` : '';
+    resetEl.textContent = quota.resetTime ? `Resets: ${formatDateTime(quota.resetTime, 'antigravity')}` : '';
   }
   if (countdownEl) {
     if (quota.timeUntilResetSeconds > 0) {
@@ -1245,7 +1245,7 @@ function renderCodexQuotaCards(quotas, containerId) {
           <svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${statusCfg.icon}"/></svg>
           ${statusCfg.label}
         </span>
-        <span class="reset-time" id="${resetId}">${q.resetsAt ? 'Resets: ' + formatDateTime(q.resetsAt) : ''}</span>
+        <span class="reset-time" id="${resetId}">${q.resetsAt ? 'Resets: ' + formatDateTime(q.resetsAt, 'codex') : ''}</span>
       </footer>
     </article>`;
   }).join('');
@@ -1311,7 +1311,7 @@ function updateCodexCard(quota) {
     statusEl.innerHTML = `<svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${config.icon}"/></svg>${config.label}`;
   }
   if (resetEl) {
-    resetEl.textContent = quota.resetsAt ? `Resets: ${formatDateTime(quota.resetsAt)}` : '';
+    resetEl.textContent = quota.resetsAt ? `Resets: ${formatDateTime(quota.resetsAt, 'codex')}` : '';
   }
   if (countdownEl) {
     if (quota.timeUntilResetSeconds > 0) {
@@ -1477,11 +1477,11 @@ function formatNumber(num) {
   return num.toLocaleString('en-US', { maximumFractionDigits: 1 });
 }
 
-function formatDateTime(isoString) {
+function formatDateTime(isoString, provider) {
   const d = new Date(isoString);
   const opts = { month: 'short', day: 'numeric', hour: 'numeric', minute: '2-digit' };
   if (typeof getEffectiveTimezone === 'function') {
-    opts.timeZone = getEffectiveTimezone();
+    opts.timeZone = getEffectiveTimezone(provider);
   }
   return d.toLocaleString('en-US', opts);
 }
@@ -1525,6 +1525,11 @@ function getThemeColors() {
 // Active timezone (empty = browser default)
 let activeTimezone = '';
 
+// Per-provider timezone overrides (provider -> tz), from settings
+let providerTimezones = {};
+// Usage percentages where quotas turn warning/danger/critical (status_bands setting)
+let statusBands = { warning: 50, danger: 80, critical: 95 };
+
 // Legacy → canonical timezone aliases
 const TZ_ALIASES = {
   'Asia/Calcutta': 'Asia/Kolkata',
@@ -1591,10 +1596,18 @@ function tzOffsetMin(tz) {
   } catch (e) { return 0; }
 }
 
-function getEffectiveTimezone() {
+function getGlobalTimezone() {
   return activeTimezone || normalizeTz(Intl.DateTimeFormat().resolvedOptions().timeZone);
 }
 
+// Timezone for a provider's times: its override, else the global one.
+// Defaults to the provider being viewed; the "both" view uses the global one.
+function getEffectiveTimezone(provider) {
+  const p = provider || getCurrentProvider();
+  if (p && p !== 'both' && providerTimezones[p]) return providerTimezones[p];
+  return getGlobalTimezone();
+}
+
 function tzAbbr(tz) {
   try {
     return new Date().toLocaleTimeString('en-US', { timeZone: tz, timeZoneName: 'short' }).split(' ').pop();
@@ -1631,13 +1644,18 @@ async function loadTimezoneFromAPI() {
     if (data.timezone) {
       activeTimezone = normalizeTz(data.timezone);
     }
+    providerTimezones = {};
+    Object.entries(data.provider_timezones || {}).forEach(([p, tz]) => {
+      if (tz) providerTimezones[p] = normalizeTz(tz);
+    });
+    if (data.status_bands) statusBands = data.status_bands;
   } catch (e) {}
 }
 
 function updateBadgeText(badge) {
   if (!badge) badge = document.getElementById('timezone-badge');
   if (!badge) return;
-  const tz = getEffectiveTimezone();
+  const tz = getGlobalTimezone();
   const entry = TZ_LIST.find(e => e.tz === tz);
   const label = entry ? entry.label : tz.split('/').pop().replace(/_/g, ' ');
   badge.textContent = `${label} (${tzAbbr(tz)})`;
@@ -1665,7 +1683,7 @@ function toggleTzPicker(badge) {
     TZ_LIST.forEach((entry, i) => {
       const item = document.createElement('div');
       item.className = 'tz-picker-item';
-      if (entry.tz === getEffectiveTimezone()) item.classList.add('active');
+      if (entry.tz === getGlobalTimezone()) item.classList.add('active');
       item.dataset.tz = entry.tz;
       item.dataset.idx = i;
       const abbr = tzAbbr(entry.tz);
@@ -1686,7 +1704,7 @@ function toggleTzPicker(badge) {
   document.body.appendChild(picker);
 
   // Scroll to center current timezone in middle copy
-  const activeIdx = findTzIndex(getEffectiveTimezone());
+  const activeIdx = findTzIndex(getGlobalTimezone());
   const midStart = totalItems; // start of middle copy
   const targetScroll = (midStart + activeIdx) * ITEM_H - Math.floor(VISIBLE / 2) * ITEM_H;
   list.scrollTop = targetScroll;
@@ -1753,6 +1771,20 @@ function initTheme() {
 
 // ── Card Updates ──
 
+// Shows the Synthetic credit balance card. The card stays hidden for plans
+// that report no balance.
+function updateCreditsCard(credits, suffix) {
+  const idSuffix = suffix ? `-${suffix}` : '';
+  const card = document.getElementById(`credits-card${idSuffix}`);
+  if (!card) return;
+  card.hidden = !credits;
+  if (!credits) return;
+  const balanceEl = document.getElementById(`credits-balance${idSuffix}`);
+  if (balanceEl) balanceEl.textContent = credits.balance.toFixed(2);
+  const descEl = document.getElementById(`credits-description${idSuffix}`);
+  if (descEl) descEl.textContent = credits.description || '';
+}
+
 function updateCard(quotaType, data, suffix) {
   const key = suffix ? `${quotaType}_${suffix}` : quotaType;
   const prev = State.currentQuotas[key];
@@ -1796,7 +1828,8 @@ function updateCard(quotaType, data, suffix) {
 
   if (resetEl) {
     if (data.renewsAt && data.timeUntilReset !== 'N/A') {
-      resetEl.textContent = `Resets: ${formatDateTime(data.renewsAt)}`;
+      const provider = suffix === 'syn' ? 'synthetic' : suffix === 'zai' ? 'zai' : undefined;
+      resetEl.textContent = `Resets: ${formatDateTime(data.renewsAt, provider)}`;
       resetEl.style.display = '';
     } else {
       resetEl.textContent = '';
@@ -1877,6 +1910,7 @@ async function fetchCurrent() {
           updateCard('subscription', data.synthetic.subscription);
           updateCard('search', data.synthetic.search);
           updateCard('toolCalls', data.synthetic.toolCalls, 'syn');
+          updateCreditsCard(data.synthetic.credits, 'syn');
         }
         if (data.zai) {
           updateCard('tokensLimit', data.zai.tokensLimit);
@@ -1968,8 +2002,11 @@ async function fetchCurrent() {
         updateCard('subscription', data.subscription);
         updateCard('search', data.search);
         updateCard('toolCalls', data.toolCalls);
+        updateCreditsCard(data.credits);
       }
 
+      updateEmptyStateNotice(provider, data);
+
       const lastUpdated = document.getElementById('last-updated');
       if (lastUpdated) {
         lastUpdated.textContent = `Last updated: ${new Date().toLocaleTimeString()}`;
@@ -1987,6 +2024,35 @@ async function fetchCurrent() {
 }
 
 
+// ── Empty State ──
+
+const EMPTY_STATE_PROVIDER_NAMES = {
+  synthetic: 'Synthetic', zai: 'Z.ai', anthropic: 'Anthropic',
+  copilot: 'Copilot', codex: 'Codex', antigravity: 'Antigravity'
+};
+
+// Explains empty cards for providers that have never been polled: either the
+// first poll is still pending or polling is off in Settings > Providers.
+function updateEmptyStateNotice(provider, data) {
+  const notice = document.getElementById('empty-state-notice');
+  if (!notice) return;
+  const entries = provider === 'both'
+    ? Object.entries(data).filter(([, v]) => v && typeof v === 'object')
+    : [[provider, data]];
+  const waiting = [];
+  const disabled = [];
+  entries.forEach(([key, v]) => {
+    if (!v.neverPolled) return;
+    const name = EMPTY_STATE_PROVIDER_NAMES[key] || key;
+    (v.pollingDisabled ? disabled : waiting).push(name);
+  });
+  const parts = [];
+  if (waiting.length) parts.push(`Waiting for the first poll from ${waiting.join(', ')}. Data appears here once it completes.`);
+  if (disabled.length) parts.push(`Polling is disabled for ${disabled.join(', ')} in Settings > Providers, so no data has been collected.`);
+  notice.textContent = parts.join(' ');
+  notice.hidden = parts.length === 0;
+}
+
 // ── Anthropic Session Table Header Updates ──
 
 // Mapping from sorted quota API keys to the 3 positional session columns (sub, search, tool)
@@ -2263,7 +2329,8 @@ function buildInsightCardsHTML(insights) {
     const hideBtn = i.key ? `<button class="insight-eye-btn" data-key="${i.key}" aria-label="Hide this insight" title="Hide this insight">
         <svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M1 12s4-8 11-8 11 8 11 8-4 8-11 8-11-8-11-8z"/><circle cx="12" cy="12" r="3"/></svg>
       </button>` : '';
-    return `<div class="insight-card severity-${i.severity}" data-insight-idx="${idx}" data-key="${i.key || ''}" role="button" tabindex="0">
+    const confidence = i.confidence ? ` data-confidence="${i.confidence}" title="Projection confidence: ${i.confidence}"` : '';
+    return `<div class="insight-card severity-${i.severity}" data-insight-idx="${idx}" data-key="${i.key || ''}"${confidence} role="button" tabindex="0">
       <div class="insight-card-header">
         <svg class="insight-card-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2">${icon}</svg>
         <span class="insight-card-title">${i.title}</span>
@@ -2370,6 +2437,59 @@ const crosshairPlugin = {
   }
 };
 
+// ── Chart: Reset Markers Plugin ──
+
+// Draws a vertical line at each quota reset, at the chart indexes set by
+// loadResetMarkers.
+const resetMarkersPlugin = {
+  id: 'resetMarkers',
+  afterDatasetsDraw(chart) {
+    const indexes = chart.$resetIndexes;
+    if (!indexes || indexes.length === 0) return;
+    const { ctx, chartArea, scales } = chart;
+    ctx.save();
+    ctx.setLineDash([2, 3]);
+    ctx.strokeStyle = getComputedStyle(document.documentElement).getPropertyValue('--text-muted').trim() || '#9CA3AF';
+    ctx.lineWidth = 1;
+    indexes.forEach(i => {
+      const x = scales.x.getPixelForValue(i);
+      ctx.beginPath();
+      ctx.moveTo(x, chartArea.top);
+      ctx.lineTo(x, chartArea.bottom);
+      ctx.stroke();
+    });
+    ctx.restore();
+  }
+};
+
+// loadResetMarkers fetches the provider's reset cycles for range and marks the
+// first sample of each cycle that began inside the plotted history.
+async function loadResetMarkers(historyRows, range) {
+  const chart = State.chart;
+  if (!chart) return;
+  chart.$resetIndexes = [];
+  const provider = getCurrentProvider();
+  if (provider === 'both' || provider === 'antigravity' || historyRows.length === 0) return;
+  try {
+    const res = await authFetch(`${API_BASE}/api/resets?range=${range}&provider=${provider}`);
+    if (!res.ok) return;
+    const data = await res.json();
+    const times = historyRows.map(d => new Date(d.capturedAt).getTime());
+    const indexes = [];
+    (data.resets || []).forEach(r => {
+      const start = new Date(r.start).getTime();
+      if (start <= times[0]) return;
+      const i = times.findIndex(t => t >= start);
+      if (i > 0) indexes.push(i);
+    });
+    if (State.chart !== chart) return;
+    chart.$resetIndexes = indexes;
+    chart.update('none');
+  } catch (err) {
+    // markers are optional
+  }
+}
+
 // ── Chart Init & Update ──
 
 function computeYMax(datasets, chart) {
@@ -2408,7 +2528,7 @@ function initChart() {
   const ctx = document.getElementById('usage-chart');
   if (!ctx || typeof Chart === 'undefined') return;
 
-  Chart.register(crosshairPlugin);
+  Chart.register(crosshairPlugin, resetMarkersPlugin);
 
   const colors = getThemeColors();
 
@@ -2552,6 +2672,7 @@ async function fetchHistory(range) {
 
     const historyRows = Array.isArray(data) ? data : [];
     State.chart.data.labels = historyRows.map(d => formatChartXAxisLabel(d.capturedAt, range));
+    loadResetMarkers(historyRows, range);
 
     if (provider === 'anthropic') {
       // Anthropic history: array of { capturedAt, five_hour, seven_day, ... }
@@ -4188,9 +4309,9 @@ function getOverviewProvider() {
 
 function getThresholdClass(pct) {
   if (pct < 0) return '';
-  if (pct >= 95) return 'threshold-critical';
-  if (pct >= 80) return 'threshold-danger';
-  if (pct >= 50) return 'threshold-warning';
+  if (pct >= statusBands.critical) return 'threshold-critical';
+  if (pct >= statusBands.danger) return 'threshold-danger';
+  if (pct >= statusBands.warning) return 'threshold-warning';
   return 'threshold-healthy';
 }
 
@@ -4384,7 +4505,7 @@ async function checkForUpdate() {
     if (data.available) {
       const versionSpan = document.getElementById('update-version');
       if (badge && versionSpan) {
-        versionSpan.textContent = data.latest_version;
+        versionSpan.textContent = data.latest_version + (data.prerelease ? ' (beta)' : '');
         badge.hidden = false;
       }
     } else if (badge) {
@@ -4403,23 +4524,73 @@ async function applyUpdate() {
   btn.textContent = 'Updating...';
   btn.disabled = true;
 
+  const fail = (msg) => {
+    const refused = (msg || '').startsWith('update refused');
+    btn.classList.remove('downloading');
+    btn.textContent = refused ? 'Update refused' : 'Update failed';
+    btn.title = msg || '';
+    btn.disabled = false;
+    // update failed — error shown in UI
+    setTimeout(() => { btn.textContent = origText; }, refused ? 6000 : 3000);
+  };
+
   try {
-    const res = await authFetch('/api/update/apply', { method: 'POST' });
+    // The server streams progress events while it downloads and installs
+    const res = await authFetch('/api/update/apply', {
+      method: 'POST',
+      headers: { 'Accept': 'text/event-stream' }
+    });
     if (!res.ok) {
       const data = await res.json();
-      btn.textContent = 'Update failed';
-      btn.disabled = false;
-      // update failed — error shown in UI
-      setTimeout(() => { btn.textContent = origText; }, 3000);
+      fail(data.error);
+      return;
+    }
+    const result = await readUpdateEvents(res, (p) => {
+      if (p.stage === 'install') {
+        btn.classList.remove('downloading');
+        btn.textContent = 'Installing...';
+      } else if (p.total > 0) {
+        btn.classList.add('downloading');
+        btn.style.setProperty('--update-progress', `${p.percent}%`);
+        btn.textContent = `Downloading... ${p.percent}%`;
+      }
+    });
+    if (result.event !== 'done') {
+      fail(result.data.error);
       return;
     }
     btn.textContent = 'Restarting...';
     // Poll until server comes back with new version
     setTimeout(() => pollForRestart(), 3000);
   } catch (e) {
-    btn.textContent = 'Update failed';
-    btn.disabled = false;
-    setTimeout(() => { btn.textContent = origText; }, 3000);
+    fail('');
+  }
+}
+
+// readUpdateEvents reads the server-sent events of an update, passing each
+// progress event to onProgress, and resolves with the final done/error event.
+async function readUpdateEvents(res, onProgress) {
+  const reader = res.body.getReader();
+  const decoder = new TextDecoder();
+  let buf = '';
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) return { event: 'error', data: {} };
+    buf += decoder.decode(value, { stream: true });
+    let sep;
+    while ((sep = buf.indexOf('\n\n')) >= 0) {
+      const block = buf.slice(0, sep);
+      buf = buf.slice(sep + 2);
+      let event = 'message';
+      let data = '';
+      block.split('\n').forEach(line => {
+        if (line.startsWith('event: ')) event = line.slice(7);
+        else if (line.startsWith('data: ')) data += line.slice(6);
+      });
+      const payload = data ? JSON.parse(data) : {};
+      if (event === 'progress') onProgress(payload);
+      else if (event === 'done' || event === 'error') return { event, data: payload };
+    }
   }
 }
 
@@ -4459,8 +4630,11 @@ function initSettingsPage() {
   loadSettings();
   setupSettingsSave();
   setupSMTPTest();
+  setupProviderKeys();
   setupPushNotifications();
   setupSettingsPassword();
+  setupMyAlerts();
+  setupUsers();
   setupThresholdSliders();
   setupOverrides();
   populateTimezoneSelect();
@@ -4508,6 +4682,57 @@ async function loadSettings() {
     // Timezone
     const tzSelect = document.getElementById('settings-timezone');
     if (tzSelect && data.timezone) { tzSelect.value = data.timezone; }
+    const providerTz = data.provider_timezones || {};
+    document.querySelectorAll('[data-provider-timezone]').forEach(sel => {
+      sel.value = providerTz[sel.dataset.providerTimezone] || '';
+    });
+
+    // Update channel
+    const channelSelect = document.getElementById('settings-update-channel');
+    if (channelSelect && data.update_channel) { channelSelect.value = data.update_channel; }
+    loadUpdateStatus();
+
+    // Charts
+    const chartMaxPoints = document.getElementById('settings-chart-max-points');
+    if (chartMaxPoints && data.chart_max_points) { chartMaxPoints.value = data.chart_max_points; }
+
+    // Sessions
+    const rememberMeDays = document.getElementById('settings-remember-me-days');
+    if (rememberMeDays && data.remember_me_days) { rememberMeDays.value = data.remember_me_days; }
+    const sessionTTL = document.getElementById('settings-session-ttl-minutes');
+    if (sessionTTL && data.session_ttl_minutes) { sessionTTL.value = data.session_ttl_minutes; }
+    const allowedIPs = document.getElementById('settings-allowed-ips');
+    if (allowedIPs) { allowedIPs.value = (data.allowed_ips || []).join(', '); }
+
+    // Branding
+    const brandTitle = document.getElementById('settings-brand-title');
+    const brandLogo = document.getElementById('settings-brand-logo');
+    const brandAccent = document.getElementById('settings-brand-accent');
+    const branding = data.branding || {};
+    if (brandTitle) { brandTitle.value = branding.title || ''; }
+    if (brandLogo) { brandLogo.value = branding.logo_url || ''; }
+    if (brandAccent) { brandAccent.value = branding.accent_color || ''; }
+
+    // Number format
+    const numberLocale = document.getElementById('settings-number-locale');
+    const numberAbbreviate = document.getElementById('settings-number-abbreviate');
+    if (data.number_format) {
+      if (numberLocale) { numberLocale.value = data.number_format.locale || 'en-US'; }
+      if (numberAbbreviate) { numberAbbreviate.checked = !!data.number_format.abbreviate; }
+    }
+
+    // Status page
+    const statusPublic = document.getElementById('settings-status-public');
+    if (statusPublic) { statusPublic.checked = !!data.status_public; }
+    const widgetOrigins = document.getElementById('settings-widget-origins');
+    if (widgetOrigins) { widgetOrigins.value = (data.widget_origins || []).join(', '); }
+
+    // Status bands
+    if (data.status_bands) {
+      setVal('settings-band-warning', data.status_bands.warning);
+      setVal('settings-band-danger', data.status_bands.danger);
+      setVal('settings-band-critical', data.status_bands.critical);
+    }
 
     // SMTP
     if (data.smtp) {
@@ -4539,6 +4764,7 @@ async function loadSettings() {
       if (critCheck) critCheck.checked = n.notify_critical !== false;
       if (resetCheck) resetCheck.checked = n.notify_reset !== false;
       setVal('notify-cooldown', n.cooldown_minutes || 30);
+      setVal('notify-group-seconds', n.alert_group_seconds ?? 5);
       // Load channel preferences
       if (n.channels) {
         const emailToggle = document.getElementById('channel-email');
@@ -4553,6 +4779,24 @@ async function loadSettings() {
     }
 
     // Provider visibility
+    const bothExclude = document.getElementById('settings-both-exclude');
+    if (bothExclude) { bothExclude.value = (data.both_exclude || []).join(', '); }
+    const pollMinInterval = document.getElementById('settings-poll-min-interval');
+    if (pollMinInterval && data.poll_min_interval !== undefined) { pollMinInterval.value = data.poll_min_interval; }
+    const snapshotRetention = document.getElementById('settings-snapshot-retention-days');
+    if (snapshotRetention && data.snapshot_retention_days !== undefined) { snapshotRetention.value = data.snapshot_retention_days; }
+    const cycleRetention = document.getElementById('settings-cycle-retention-days');
+    if (cycleRetention && data.cycle_retention_days !== undefined) { cycleRetention.value = data.cycle_retention_days; }
+
+    // Snapshot firehose
+    const firehoseEnabled = document.getElementById('settings-firehose-enabled');
+    if (firehoseEnabled) { firehoseEnabled.checked = !!data.firehose_enabled; }
+    const firehoseURL = document.getElementById('settings-firehose-url');
+    if (firehoseURL) { firehoseURL.value = data.firehose_url || ''; }
+    const firehoseSecret = document.getElementById('settings-firehose-secret');
+    if (firehoseSecret && data.firehose_secret_set) { firehoseSecret.placeholder = '********** (saved)'; }
+    const firehoseProviders = document.getElementById('settings-firehose-providers');
+    if (firehoseProviders) { firehoseProviders.value = (data.firehose_providers || []).join(', '); }
     if (data.provider_visibility) {
       populateProviderToggles(data.provider_visibility);
     } else {
@@ -4569,19 +4813,21 @@ function setVal(id, val) {
 }
 
 function populateTimezoneSelect() {
-  const select = document.getElementById('settings-timezone');
-  if (!select) return;
+  const selects = [document.getElementById('settings-timezone'), ...document.querySelectorAll('[data-provider-timezone]')].filter(Boolean);
+  if (!selects.length) return;
   const zones = [
     'UTC', 'America/New_York', 'America/Chicago', 'America/Denver', 'America/Los_Angeles',
     'America/Sao_Paulo', 'Europe/London', 'Europe/Paris', 'Europe/Berlin', 'Europe/Moscow',
     'Asia/Dubai', 'Asia/Kolkata', 'Asia/Shanghai', 'Asia/Tokyo', 'Asia/Seoul',
     'Australia/Sydney', 'Pacific/Auckland'
   ];
-  zones.forEach(tz => {
-    const opt = document.createElement('option');
-    opt.value = tz;
-    opt.textContent = tz.replace(/_/g, ' ');
-    select.appendChild(opt);
+  selects.forEach(select => {
+    zones.forEach(tz => {
+      const opt = document.createElement('option');
+      opt.value = tz;
+      opt.textContent = tz.replace(/_/g, ' ');
+      select.appendChild(opt);
+    });
   });
 }
 
@@ -4668,6 +4914,7 @@ function gatherSettings() {
       notify_critical: document.getElementById('notify-critical')?.checked ?? true,
       notify_reset: document.getElementById('notify-reset')?.checked ?? true,
       cooldown_minutes: parseInt(document.getElementById('notify-cooldown')?.value) || 30,
+      alert_group_seconds: Math.min(Math.max(parseInt(document.getElementById('notify-group-seconds')?.value) || 0, 0), 300),
       channels: {
         email: document.getElementById('channel-email')?.checked ?? true,
         push: document.getElementById('channel-push')?.checked ?? true,
@@ -4688,16 +4935,146 @@ function gatherSettings() {
     });
     settings.provider_visibility = vis;
   }
+  const bothExclude = document.getElementById('settings-both-exclude');
+  if (bothExclude) {
+    settings.both_exclude = bothExclude.value.split(',').map(p => p.trim().toLowerCase()).filter(Boolean);
+  }
+  const pollMinInterval = document.getElementById('settings-poll-min-interval');
+  if (pollMinInterval && pollMinInterval.value !== '') {
+    settings.poll_min_interval = parseInt(pollMinInterval.value, 10);
+  }
+  const snapshotRetention = document.getElementById('settings-snapshot-retention-days');
+  if (snapshotRetention && snapshotRetention.value !== '') {
+    settings.snapshot_retention_days = parseInt(snapshotRetention.value, 10);
+  }
+  const cycleRetention = document.getElementById('settings-cycle-retention-days');
+  if (cycleRetention && cycleRetention.value !== '') {
+    settings.cycle_retention_days = parseInt(cycleRetention.value, 10);
+  }
+
+  // Snapshot firehose
+  const firehoseURL = document.getElementById('settings-firehose-url');
+  if (firehoseURL) {
+    settings.firehose_url = firehoseURL.value.trim();
+  }
+  const firehoseSecret = document.getElementById('settings-firehose-secret');
+  if (firehoseSecret && firehoseSecret.value.trim()) {
+    settings.firehose_secret = firehoseSecret.value.trim();
+  }
+  const firehoseProviders = document.getElementById('settings-firehose-providers');
+  if (firehoseProviders) {
+    settings.firehose_providers = firehoseProviders.value.split(',').map(p => p.trim().toLowerCase()).filter(Boolean);
+  }
+  const firehoseEnabled = document.getElementById('settings-firehose-enabled');
+  if (firehoseEnabled) {
+    settings.firehose_enabled = firehoseEnabled.checked;
+  }
 
   // Timezone
   const tzSelect = document.getElementById('settings-timezone');
   if (tzSelect) {
     settings.timezone = tzSelect.value;
   }
+  const providerTzSelects = document.querySelectorAll('[data-provider-timezone]');
+  if (providerTzSelects.length) {
+    settings.provider_timezones = {};
+    providerTzSelects.forEach(sel => {
+      if (sel.value) settings.provider_timezones[sel.dataset.providerTimezone] = sel.value;
+    });
+  }
+
+  // Update channel
+  const channelSelect = document.getElementById('settings-update-channel');
+  if (channelSelect) {
+    settings.update_channel = channelSelect.value;
+  }
+
+  // Charts
+  const chartMaxPoints = document.getElementById('settings-chart-max-points');
+  if (chartMaxPoints && chartMaxPoints.value) {
+    settings.chart_max_points = parseInt(chartMaxPoints.value, 10);
+  }
+
+  // Sessions
+  const rememberMeDays = document.getElementById('settings-remember-me-days');
+  if (rememberMeDays && rememberMeDays.value) {
+    settings.remember_me_days = parseInt(rememberMeDays.value, 10);
+  }
+  const sessionTTL = document.getElementById('settings-session-ttl-minutes');
+  if (sessionTTL && sessionTTL.value) {
+    settings.session_ttl_minutes = parseInt(sessionTTL.value, 10);
+  }
+  const allowedIPs = document.getElementById('settings-allowed-ips');
+  if (allowedIPs) {
+    settings.allowed_ips = allowedIPs.value.split(',').map(ip => ip.trim()).filter(Boolean);
+  }
+
+  // Branding
+  const brandTitle = document.getElementById('settings-brand-title');
+  const brandLogo = document.getElementById('settings-brand-logo');
+  const brandAccent = document.getElementById('settings-brand-accent');
+  if (brandTitle && brandLogo && brandAccent) {
+    settings.branding = {
+      title: brandTitle.value.trim(),
+      logo_url: brandLogo.value.trim(),
+      accent_color: brandAccent.value.trim()
+    };
+  }
+
+  // Number format
+  const numberLocale = document.getElementById('settings-number-locale');
+  const numberAbbreviate = document.getElementById('settings-number-abbreviate');
+  if (numberLocale) {
+    settings.number_format = {
+      locale: numberLocale.value,
+      abbreviate: numberAbbreviate ? numberAbbreviate.checked : false
+    };
+  }
+
+  // Status page
+  const statusPublic = document.getElementById('settings-status-public');
+  if (statusPublic) {
+    settings.status_public = statusPublic.checked;
+  }
+  const widgetOrigins = document.getElementById('settings-widget-origins');
+  if (widgetOrigins) {
+    settings.widget_origins = widgetOrigins.value.split(',').map(o => o.trim()).filter(Boolean);
+  }
+
+  // Status bands
+  const bandWarning = document.getElementById('settings-band-warning');
+  const bandDanger = document.getElementById('settings-band-danger');
+  const bandCritical = document.getElementById('settings-band-critical');
+  if (bandWarning && bandDanger && bandCritical && bandWarning.value && bandDanger.value && bandCritical.value) {
+    settings.status_bands = {
+      warning: parseFloat(bandWarning.value),
+      danger: parseFloat(bandDanger.value),
+      critical: parseFloat(bandCritical.value)
+    };
+  }
 
   return settings;
 }
 
+// Shows what the selected update channel currently offers.
+async function loadUpdateStatus() {
+  const status = document.getElementById('settings-update-status');
+  if (!status) return;
+  try {
+    const res = await authFetch('/api/update/check');
+    if (!res.ok) return;
+    const data = await res.json();
+    const channel = data.channel === 'beta' ? 'Beta' : 'Stable';
+    if (data.available) {
+      status.textContent = `${channel} channel: v${data.latest_version}${data.prerelease ? ' (pre-release)' : ''} is available (running v${data.current_version})`;
+    } else if (data.latest_version) {
+      status.textContent = `${channel} channel: up to date (running v${data.current_version})`;
+    }
+  } catch (e) {
+    // Keep the default hint
+  }
+}
+
 function setupSettingsSave() {
   const saveBtn = document.getElementById('settings-save-btn');
   const feedback = document.getElementById('settings-feedback');
@@ -4731,6 +5108,7 @@ function setupSettingsSave() {
         showSettingsFeedback(feedback, data.error || 'Failed to save settings.', 'error');
       } else {
         showSettingsFeedback(feedback, 'Settings saved successfully.', 'success');
+        loadUpdateStatus();
       }
     } catch (e) {
       showSettingsFeedback(feedback, 'Network error. Please try again.', 'error');
@@ -4778,6 +5156,53 @@ function setupSMTPTest() {
   });
 }
 
+// Saves or removes a provider's dashboard-managed API key.
+function setupProviderKeys() {
+  const select = document.getElementById('provider-key-provider');
+  const input = document.getElementById('provider-key-value');
+  const saveBtn = document.getElementById('provider-key-save');
+  const removeBtn = document.getElementById('provider-key-remove');
+  const result = document.getElementById('provider-key-result');
+  if (!select || !input || !saveBtn || !removeBtn) return;
+
+  const send = async (method, body) => {
+    saveBtn.disabled = removeBtn.disabled = true;
+    if (result) { result.textContent = ''; result.className = 'settings-test-result'; }
+    try {
+      const resp = await authFetch(`/api/providers/${encodeURIComponent(select.value)}/key`, {
+        method,
+        headers: body ? { 'Content-Type': 'application/json' } : {},
+        body: body ? JSON.stringify(body) : undefined
+      });
+      const data = await resp.json();
+      if (!resp.ok) throw new Error(data.error || 'Failed to update key.');
+      input.value = '';
+      if (result) {
+        const what = data.saved ? 'Key saved.' : 'Saved key removed; the environment key applies.';
+        result.textContent = what + (data.reloading ? ' Reloading...' : ' Restart onWatch to apply.');
+        result.className = 'settings-test-result success';
+      }
+    } catch (e) {
+      if (result) {
+        result.textContent = e.message || 'Network error.';
+        result.className = 'settings-test-result error';
+      }
+    } finally {
+      saveBtn.disabled = removeBtn.disabled = false;
+    }
+  };
+
+  saveBtn.addEventListener('click', () => {
+    const key = input.value.trim();
+    if (!key) {
+      if (result) { result.textContent = 'Enter a key first.'; result.className = 'settings-test-result error'; }
+      return;
+    }
+    send('PUT', { key });
+  });
+  removeBtn.addEventListener('click', () => send('DELETE'));
+}
+
 function setupPushNotifications() {
   var statusLabel = document.getElementById('push-status-label');
   var subscribeBtn = document.getElementById('push-subscribe-btn');
@@ -4982,6 +5407,153 @@ function setupSettingsPassword() {
   });
 }
 
+// setupMyAlerts loads and saves the signed-in user's own alert recipients.
+async function setupMyAlerts() {
+  const saveBtn = document.getElementById('my-alerts-save-btn');
+  const feedback = document.getElementById('my-alerts-feedback');
+  if (!saveBtn) return;
+
+  const list = (id) => (document.getElementById(id)?.value || '').split(',').map(s => s.trim()).filter(Boolean);
+  const num = (id) => parseFloat(document.getElementById(id)?.value) || 0;
+
+  try {
+    const resp = await authFetch('/api/notifications/prefs');
+    if (resp.ok) {
+      const p = (await resp.json()).prefs || {};
+      setVal('my-alerts-email', (p.email || []).join(', '));
+      setVal('my-alerts-sms', (p.sms_numbers || []).join(', '));
+      setVal('my-alerts-matrix', p.matrix_room || '');
+      setVal('my-alerts-warning', p.warning_threshold || '');
+      setVal('my-alerts-critical', p.critical_threshold || '');
+    }
+  } catch (e) {
+    // Leave the form empty
+  }
+
+  saveBtn.addEventListener('click', async () => {
+    if (feedback) { feedback.hidden = true; }
+    saveBtn.disabled = true;
+    try {
+      const resp = await authFetch('/api/notifications/prefs', {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+          email: list('my-alerts-email'),
+          sms_numbers: list('my-alerts-sms'),
+          matrix_room: document.getElementById('my-alerts-matrix')?.value.trim() || '',
+          warning_threshold: num('my-alerts-warning'),
+          critical_threshold: num('my-alerts-critical'),
+        }),
+      });
+      const data = await resp.json();
+      if (!resp.ok) {
+        showSettingsFeedback(feedback, data.error || 'Failed to save your alerts.', 'error');
+      } else {
+        showSettingsFeedback(feedback, 'Your alert preferences were saved.', 'success');
+      }
+    } catch (e) {
+      showSettingsFeedback(feedback, 'Network error.', 'error');
+    } finally {
+      saveBtn.disabled = false;
+    }
+  });
+}
+
+// setupUsers lists dashboard users for admins; the section stays hidden for
+// viewers, who get 403 from /api/users.
+async function setupUsers() {
+  const section = document.getElementById('users-section');
+  const listEl = document.getElementById('users-list');
+  const addBtn = document.getElementById('add-user-btn');
+  const feedback = document.getElementById('users-feedback');
+  if (!section || !listEl || !addBtn) return;
+
+  const request = async (url, method, body) => {
+    const resp = await authFetch(url, {
+      method,
+      headers: { 'Content-Type': 'application/json' },
+      body: body ? JSON.stringify(body) : undefined,
+    });
+    const data = await resp.json();
+    if (!resp.ok) throw new Error(data.error || 'Request failed');
+    return data;
+  };
+
+  const render = (users) => {
+    listEl.innerHTML = '';
+    users.forEach(u => {
+      const row = document.createElement('div');
+      row.className = 'settings-override-row';
+      const name = document.createElement('span');
+      name.style.flex = '2';
+      name.textContent = u.username;
+      const role = document.createElement('select');
+      role.className = 'settings-input';
+      role.style.flex = '1';
+      ['viewer', 'admin'].forEach(r => role.add(new Option(r === 'admin' ? 'Admin' : 'Viewer', r, false, u.role === r)));
+      role.addEventListener('change', async () => {
+        try {
+          await request('/api/users/' + encodeURIComponent(u.username), 'PUT', { role: role.value });
+          showSettingsFeedback(feedback, `${u.username} is now ${role.value === 'admin' ? 'an admin' : 'a viewer'}.`, 'success');
+        } catch (e) {
+          role.value = u.role;
+          showSettingsFeedback(feedback, e.message, 'error');
+        }
+      });
+      const remove = document.createElement('button');
+      remove.className = 'override-remove';
+      remove.type = 'button';
+      remove.title = 'Delete user';
+      remove.innerHTML = '<svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M18 6L6 18M6 6l12 12"/></svg>';
+      remove.addEventListener('click', async () => {
+        if (!confirm(`Delete user ${u.username}?`)) return;
+        try {
+          await request('/api/users/' + encodeURIComponent(u.username), 'DELETE');
+          row.remove();
+        } catch (e) {
+          showSettingsFeedback(feedback, e.message, 'error');
+        }
+      });
+      row.append(name, role, remove);
+      listEl.appendChild(row);
+    });
+  };
+
+  const load = async () => {
+    const resp = await authFetch('/api/users');
+    if (!resp.ok) return false;
+    render(await resp.json());
+    return true;
+  };
+
+  try {
+    if (!(await load())) return;
+  } catch (e) {
+    return;
+  }
+  section.hidden = false;
+
+  addBtn.addEventListener('click', async () => {
+    if (feedback) { feedback.hidden = true; }
+    addBtn.disabled = true;
+    try {
+      await request('/api/users', 'POST', {
+        username: document.getElementById('new-user-name')?.value.trim() || '',
+        password: document.getElementById('new-user-password')?.value || '',
+        role: document.getElementById('new-user-role')?.value || 'viewer',
+      });
+      setVal('new-user-name', '');
+      setVal('new-user-password', '');
+      await load();
+      showSettingsFeedback(feedback, 'User added.', 'success');
+    } catch (e) {
+      showSettingsFeedback(feedback, e.message, 'error');
+    } finally {
+      addBtn.disabled = false;
+    }
+  });
+}
+
 function setupOverrides() {
   const addBtn = document.getElementById('add-override-btn');
   if (addBtn) {
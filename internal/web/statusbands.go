@@ -0,0 +1,80 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// StatusBands is the JSON shape stored under the "status_bands" settings key:
+// the usage percentages at which a quota turns warning, danger and critical.
+// Below Warning it is healthy.
+type StatusBands struct {
+	Warning  float64 `json:"warning"`
+	Danger   float64 `json:"danger"`
+	Critical float64 `json:"critical"`
+}
+
+// defaultStatusBands are used until status_bands is saved.
+var defaultStatusBands = StatusBands{Warning: 50, Danger: 80, Critical: 95}
+
+// activeStatusBands holds the bands every status classifier uses. The
+// classifiers are plain functions called from many builders, so the saved
+// setting is mirrored here rather than read from the store on each call.
+var activeStatusBands atomic.Pointer[StatusBands]
+
+// currentStatusBands returns the bands in effect.
+func currentStatusBands() StatusBands {
+	if b := activeStatusBands.Load(); b != nil {
+		return *b
+	}
+	return defaultStatusBands
+}
+
+// validate checks that the bands are within 0-100 and strictly increasing.
+func (b StatusBands) validate() error {
+	if b.Warning <= 0 || b.Critical > 100 {
+		return fmt.Errorf("status_bands must be between 0 and 100")
+	}
+	if b.Warning >= b.Danger || b.Danger >= b.Critical {
+		return fmt.Errorf("status_bands must increase: warning < danger < critical")
+	}
+	return nil
+}
+
+// statusBands returns the saved status_bands setting, or the defaults when
+// none is saved or it is invalid.
+func (h *Handler) statusBands() StatusBands {
+	if h.store == nil {
+		return defaultStatusBands
+	}
+	if v, _ := h.store.GetSetting("status_bands"); v != "" {
+		var saved StatusBands
+		if json.Unmarshal([]byte(v), &saved) == nil && saved.validate() == nil {
+			return saved
+		}
+	}
+	return defaultStatusBands
+}
+
+// loadStatusBands makes the saved status bands the ones in effect.
+func (h *Handler) loadStatusBands() {
+	b := h.statusBands()
+	activeStatusBands.Store(&b)
+}
+
+// usageStatus classifies a usage percentage as healthy, warning, danger or
+// critical.
+func usageStatus(percent float64) string {
+	b := currentStatusBands()
+	switch {
+	case percent >= b.Critical:
+		return "critical"
+	case percent >= b.Danger:
+		return "danger"
+	case percent >= b.Warning:
+		return "warning"
+	default:
+		return "healthy"
+	}
+}
@@ -7,9 +7,11 @@ import (
 	_ "embed"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -24,11 +26,14 @@ import (
 	"github.com/onllm-dev/onwatch/internal/agent"
 	"github.com/onllm-dev/onwatch/internal/api"
 	"github.com/onllm-dev/onwatch/internal/config"
+	"github.com/onllm-dev/onwatch/internal/export"
 	"github.com/onllm-dev/onwatch/internal/notify"
 	"github.com/onllm-dev/onwatch/internal/store"
 	"github.com/onllm-dev/onwatch/internal/tracker"
 	"github.com/onllm-dev/onwatch/internal/update"
 	"github.com/onllm-dev/onwatch/internal/web"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 //go:embed VERSION
@@ -36,6 +41,9 @@ var embeddedVersion string
 
 var version = "dev"
 
+// buildTime is set at link time (-X main.buildTime=...) by release builds.
+var buildTime string
+
 func init() {
 	if version == "dev" {
 		version = strings.TrimSpace(embeddedVersion)
@@ -68,6 +76,11 @@ func main() {
 var (
 	pidDir  = defaultPIDDir()
 	pidFile = filepath.Join(pidDir, "onwatch.pid")
+
+	// pidFileCustom is set when ONWATCH_PID_FILE names the PID file. Other
+	// instances may then be running, so stop and status never fall back to
+	// finding an instance by its port.
+	pidFileCustom bool
 )
 
 // hasFlag checks if a flag exists anywhere in os.Args[1:].
@@ -92,6 +105,20 @@ func hasCommand(cmds ...string) bool {
 	return false
 }
 
+// flagValue returns the value of a "--name value" or "--name=value" flag in os.Args[1:].
+func flagValue(name string) string {
+	args := os.Args[1:]
+	for i, arg := range args {
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			return strings.TrimPrefix(arg, name+"=")
+		}
+	}
+	return ""
+}
+
 // stopPreviousInstance stops any running onwatch instance using PID file + port check.
 // In test mode, only PID file is used (no port scanning) to avoid killing production.
 func stopPreviousInstance(port int, testMode bool) {
@@ -210,7 +237,26 @@ func isOnwatchProcess(pid int) bool {
 }
 
 func ensurePIDDir() error {
-	return os.MkdirAll(pidDir, 0755)
+	return os.MkdirAll(filepath.Dir(pidFile), 0755)
+}
+
+// configurePIDFile sets pidFile: ONWATCH_PID_FILE when set, so instances
+// with different values never touch each other's PID file, otherwise
+// onwatch.pid (onwatch-test.pid in test mode) in ONWATCH_DATA_DIR or the
+// default PID directory.
+func configurePIDFile(dataDir, override string, testMode bool) {
+	if dataDir != "" {
+		pidDir = dataDir
+	}
+	pidFileCustom = override != ""
+	switch {
+	case override != "":
+		pidFile = override
+	case testMode:
+		pidFile = filepath.Join(pidDir, "onwatch-test.pid")
+	default:
+		pidFile = filepath.Join(pidDir, "onwatch.pid")
+	}
 }
 
 // sha256hex returns the SHA-256 hex hash of a string.
@@ -219,17 +265,6 @@ func sha256hex(s string) string {
 	return fmt.Sprintf("%x", h)
 }
 
-// deriveEncryptionKey derives a 32-byte encryption key from the admin password hash.
-// The password hash is expected to be a SHA-256 hex string (64 characters).
-func deriveEncryptionKey(passwordHash string) string {
-	if len(passwordHash) == 64 {
-		return passwordHash
-	}
-	// Fallback: hash again if not already hex
-	h := sha256.Sum256([]byte(passwordHash))
-	return fmt.Sprintf("%x", h)
-}
-
 // migrateDBLocation moves the database from old default locations to the new one.
 // Only runs when no explicit --db or ONWATCH_DB_PATH was set.
 func migrateDBLocation(newPath string, logger *slog.Logger) {
@@ -325,10 +360,166 @@ func writePIDFile(port int) error {
 	return os.WriteFile(pidFile, []byte(content), 0644)
 }
 
+// removePIDFile removes the PID file unless it now names another process, as
+// it does once a graceful restart has handed over to a new one.
 func removePIDFile() {
+	if data, err := os.ReadFile(pidFile); err == nil {
+		if pid, _ := parsePIDFile(string(data)); pid > 0 && pid != os.Getpid() {
+			return
+		}
+	}
 	os.Remove(pidFile)
 }
 
+// parsePIDFile parses PID file content in "PID:PORT" or legacy "PID" form.
+func parsePIDFile(content string) (pid, port int) {
+	content = strings.TrimSpace(content)
+	pidStr, portStr, _ := strings.Cut(content, ":")
+	pid, _ = strconv.Atoi(pidStr)
+	port, _ = strconv.Atoi(portStr)
+	return pid, port
+}
+
+// listenFDEnv holds the descriptor of the listening socket inherited by a
+// process started through a graceful restart.
+const listenFDEnv = "_ONWATCH_LISTEN_FD"
+
+// inheritedListener returns the listening socket handed down by a graceful
+// restart, or nil if this process was started normally.
+func inheritedListener() (net.Listener, error) {
+	v := os.Getenv(listenFDEnv)
+	if v == "" {
+		return nil, nil
+	}
+	os.Unsetenv(listenFDEnv)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", listenFDEnv, v)
+	}
+	f := os.NewFile(uintptr(fd), "listener")
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// gracefulRestart starts a new daemon from the current (possibly just
+// updated) binary and hands it the server's listening socket, so it accepts
+// new connections while this process drains in-flight requests and exits.
+// The new process's PID is written to the PID file.
+func gracefulRestart(server *web.Server, port int) (int, error) {
+	tcpLn, ok := server.Listener().(*net.TCPListener)
+	if !ok {
+		return 0, fmt.Errorf("server is not listening on TCP")
+	}
+	lnFile, err := tcpLn.File()
+	if err != nil {
+		return 0, fmt.Errorf("failed to duplicate listener: %w", err)
+	}
+	defer lnFile.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get executable path: %w", err)
+	}
+	// After an update replaces the binary, /proc/self/exe shows "(deleted)"
+	exe = strings.TrimSuffix(exe, " (deleted)")
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// ExtraFiles[0] is fd 3 in the child
+	cmd.ExtraFiles = []*os.File{lnFile}
+	cmd.Env = append(os.Environ(), "_ONWATCH_DAEMON=1", listenFDEnv+"=3")
+	cmd.SysProcAttr = daemonSysProcAttr()
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start new process: %w", err)
+	}
+	childPID := cmd.Process.Pid
+	cmd.Process.Release()
+
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d:%d", childPID, port)), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write PID file: %v\n", err)
+	}
+	return childPID, nil
+}
+
+// requestGracefulRestart signals the daemon with pid to restart in place and
+// waits for the PID file to name its successor. It returns false when the
+// platform cannot pass the socket, or the daemon did not hand over in time
+// (e.g. a version without graceful restart, which just exits on the signal).
+func requestGracefulRestart(pid int) (int, bool) {
+	if !gracefulRestartSupported {
+		return 0, false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil || proc.Signal(restartSignal) != nil {
+		return 0, false
+	}
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		time.Sleep(200 * time.Millisecond)
+		if data, err := os.ReadFile(pidFile); err == nil {
+			if newPID, _ := parsePIDFile(string(data)); newPID > 0 && newPID != pid {
+				return newPID, true
+			}
+		}
+		if proc.Signal(syscall.Signal(0)) != nil {
+			return 0, false // exited without handing over
+		}
+	}
+	return 0, false
+}
+
+// reloadSelf sends this process the restart signal, so it restarts in place
+// and re-creates its API clients with the current provider keys.
+func reloadSelf() error {
+	if !gracefulRestartSupported {
+		return errors.New("reload is not supported on this platform; restart onWatch to apply")
+	}
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return proc.Signal(restartSignal)
+}
+
+const (
+	// instanceLockHeartbeat is how often the running instance refreshes its
+	// database lock; instanceLockStale is when a lock without one is abandoned.
+	instanceLockHeartbeat = time.Minute
+	instanceLockStale     = 3 * time.Minute
+	// instanceLockWait gives a previous instance that is shutting down time to
+	// release the lock.
+	instanceLockWait = 10 * time.Second
+)
+
+// acquireInstanceLock claims the database for this process. A lock left by
+// an instance on this host that no longer runs is taken over, and the
+// successor of a graceful restart takes it from the process handing over.
+func acquireInstanceLock(db *store.Store, hostname string, logger *slog.Logger) error {
+	pid := os.Getpid()
+	takeover := os.Getenv(listenFDEnv) != ""
+	deadline := time.Now().Add(instanceLockWait)
+	for {
+		err := db.AcquireInstanceLock(pid, hostname, instanceLockStale, takeover)
+		var locked *store.InstanceLockedError
+		if !errors.As(err, &locked) {
+			return err
+		}
+		if locked.Holder.Hostname == hostname && !processAlive(locked.Holder.PID) {
+			logger.Warn("Taking over database lock from an instance that is no longer running", "pid", locked.Holder.PID)
+			takeover = true
+			continue
+		}
+		if time.Now().After(deadline) {
+			logger.Error("Another onwatch instance is using the database", "pid", locked.Holder.PID, "host", locked.Holder.Hostname)
+			return fmt.Errorf("%w; stop it first (onwatch stop) or use a different --db", err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
 // daemonize re-executes the current binary as a detached background process.
 // The parent writes the child's PID to .onwatch.pid and exits.
 func daemonize(cfg *config.Config) error {
@@ -344,11 +535,12 @@ func daemonize(cfg *config.Config) error {
 	}
 
 	// Open log file for child's stdout/stderr
-	logName := ".onwatch.log"
-	if cfg.TestMode {
-		logName = ".onwatch-test.log"
+	logPath := cfg.LogPath()
+	if cfg.DataDir != "" {
+		if err := os.MkdirAll(cfg.DataDir, 0700); err != nil {
+			return fmt.Errorf("failed to create data directory: %w", err)
+		}
 	}
-	logPath := filepath.Join(filepath.Dir(cfg.DBPath), logName)
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to open log file for daemon: %w", err)
@@ -385,9 +577,7 @@ func daemonize(cfg *config.Config) error {
 func run() error {
 	// Phase 1: Detect test mode early and configure PID file for isolation
 	testMode := hasFlag("--test")
-	if testMode {
-		pidFile = filepath.Join(pidDir, "onwatch-test.pid")
-	}
+	configurePIDFile(config.DataDirFromEnv(), config.PIDFileFromEnv(), testMode)
 
 	// Phase 2: Handle subcommands (both with and without -- prefix)
 	if hasCommand("stop", "--stop") {
@@ -400,11 +590,29 @@ func run() error {
 		fmt.Printf("onWatch v%s\n", version)
 		fmt.Println("github.com/onllm-dev/onwatch")
 		fmt.Println("Powered by onllm.dev")
+		if hasFlag("--verbose") {
+			printBuildInfo(update.ReadBuildInfo(version, buildTime))
+		}
 		return nil
 	}
 	if hasCommand("update", "--update") {
 		return runUpdate()
 	}
+	if hasCommand("rollback") {
+		return runRollback()
+	}
+	if hasCommand("import-claude") {
+		return runImportClaude()
+	}
+	if hasCommand("settings") {
+		return runSettings()
+	}
+	if hasCommand("clear") {
+		return runClear()
+	}
+	if hasCommand("top") {
+		return runTop()
+	}
 	if hasCommand("--help", "-h") {
 		printHelp()
 		return nil
@@ -452,18 +660,18 @@ func run() error {
 		stopPreviousInstance(cfg.Port, testMode)
 	}
 
-	// Daemonize: if not in debug mode, not already the daemon child, and NOT in Docker, fork
+	// Daemonize: if not in debug or foreground mode, not already the daemon child, and NOT in Docker, fork
 	// Docker containers should always run in foreground mode (logs to stdout)
-	if !cfg.DebugMode && !isDaemonChild && !cfg.IsDockerEnvironment() {
+	if !cfg.RunsInForeground() && !isDaemonChild && !cfg.IsDockerEnvironment() {
 		printBanner(cfg, version)
 		return daemonize(cfg)
 	}
 
-	// From here on, we are either the daemon child or running in --debug mode.
+	// From here on, we are either the daemon child or running in --debug or --foreground mode.
 
 	// In daemon mode, the parent already wrote the PID file with our PID.
-	// In debug mode, we write our own PID file.
-	if cfg.DebugMode {
+	// In debug and foreground mode, we write our own PID file.
+	if cfg.RunsInForeground() {
 		if err := writePIDFile(cfg.Port); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: could not write PID file: %v\n", err)
 		}
@@ -476,23 +684,15 @@ func run() error {
 		return fmt.Errorf("failed to setup logging: %w", err)
 	}
 	defer func() {
-		if closer, ok := logWriter.(interface{ Close() error }); ok && !cfg.DebugMode {
+		if closer, ok := logWriter.(interface{ Close() error }); ok && !cfg.RunsInForeground() {
 			closer.Close()
 		}
 	}()
 
-	// Parse log level
-	var logLevel slog.Level
-	switch cfg.LogLevel {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
-	}
+	// The level is shared with the dashboard so /api/log-level can change it
+	// without a restart, in the daemon as in the foreground
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.StartupLogLevel(isDaemonChild))
 
 	logger := slog.New(slog.NewTextHandler(logWriter, &slog.HandlerOptions{
 		Level: logLevel,
@@ -505,7 +705,7 @@ func run() error {
 	}
 
 	// Print startup banner (only in debug/foreground mode)
-	if cfg.DebugMode {
+	if cfg.RunsInForeground() {
 		printBanner(cfg, version)
 	}
 
@@ -531,6 +731,14 @@ func run() error {
 
 	logger.Info("Database opened", "path", cfg.DBPath)
 
+	// Refuse to share the database with another running instance, which
+	// stopPreviousInstance can miss (e.g. containers without lsof)
+	hostname, _ := os.Hostname()
+	if err := acquireInstanceLock(db, hostname, logger); err != nil {
+		return err
+	}
+	defer db.ReleaseInstanceLock(os.Getpid(), hostname)
+
 	// Initialize or load encryption salt for HKDF key derivation
 	if err := initEncryptionSalt(db, logger); err != nil {
 		logger.Warn("Failed to initialize encryption salt", "error", err)
@@ -550,6 +758,22 @@ func run() error {
 		}
 		logger.Info("Stored initial password hash in database")
 	}
+	// The configured admin is always an admin in the users table, alongside
+	// the users added from the dashboard
+	if err := db.EnsureAdminUser(cfg.AdminUser, cfg.AdminPassHash); err != nil {
+		logger.Warn("Failed to migrate admin user", "error", err)
+	}
+
+	// Provider keys saved from the dashboard take precedence over env
+	storedKeys, err := web.StoredProviderKeys(db, cfg.AdminPassHash)
+	if err != nil {
+		logger.Warn("Failed to load saved provider keys", "error", err)
+	}
+	for provider, key := range storedKeys {
+		if cfg.SetProviderKey(provider, key) {
+			logger.Info("Using database-stored key", "provider", provider)
+		}
+	}
 
 	// Close any orphaned sessions from previous runs (e.g., process was killed)
 	if closed, err := db.CloseOrphanedSessions(); err != nil {
@@ -585,36 +809,82 @@ func run() error {
 		logger.Info("Auto-detected Codex token from Codex credentials")
 	}
 
+	// Shared TLS settings (client certificate, extra CAs) for provider clients
+	tlsCfg, err := api.LoadTLSConfig(cfg.TLSClientCert, cfg.TLSClientKey, cfg.CABundle)
+	if err != nil {
+		logger.Error("Invalid provider TLS settings; check ONWATCH_CA_BUNDLE, ONWATCH_TLS_CLIENT_CERT and ONWATCH_TLS_CLIENT_KEY", "error", err)
+		return fmt.Errorf("failed to load TLS settings: %w", err)
+	}
+	if tlsCfg != nil {
+		api.SetTLSConfig(tlsCfg)
+		logger.Info("Provider TLS configured", "client_cert", cfg.TLSClientCert != "", "ca_bundle", cfg.CABundle)
+	}
+
 	// Create API clients based on configured providers
 	var syntheticClient *api.Client
 	var zaiClient *api.ZaiClient
 
 	if cfg.HasProvider("synthetic") {
-		syntheticClient = api.NewClient(cfg.SyntheticAPIKey, logger)
-		logger.Info("Synthetic API client configured")
+		opts := []api.Option{api.WithBaseURL(strings.TrimRight(cfg.SyntheticBaseURL, "/") + "/v2/quotas")}
+		if cfg.DebugHTTP {
+			opts = append(opts, api.WithDebugHTTP())
+		}
+		if ttl := cfg.CacheTTL["synthetic"]; ttl > 0 {
+			opts = append(opts, api.WithCacheTTL(ttl))
+		}
+		syntheticClient = api.NewClient(cfg.SyntheticAPIKey, logger, opts...)
+		logger.Info("Synthetic API client configured", "base_url", cfg.SyntheticBaseURL)
 	}
 
 	if cfg.HasProvider("zai") {
-		zaiClient = api.NewZaiClient(cfg.ZaiAPIKey, logger)
+		opts := []api.ZaiOption{api.WithZaiBaseURL(strings.TrimRight(cfg.ZaiBaseURL, "/") + "/monitor/usage/quota/limit")}
+		if cfg.DebugHTTP {
+			opts = append(opts, api.WithZaiDebugHTTP())
+		}
+		if ttl := cfg.CacheTTL["zai"]; ttl > 0 {
+			opts = append(opts, api.WithZaiCacheTTL(ttl))
+		}
+		zaiClient = api.NewZaiClient(cfg.ZaiAPIKey, logger, opts...)
 		logger.Info("Z.ai API client configured", "base_url", cfg.ZaiBaseURL)
 	}
 
 	var anthropicClient *api.AnthropicClient
 	if cfg.HasProvider("anthropic") {
-		anthropicClient = api.NewAnthropicClient(cfg.AnthropicToken, logger)
-		logger.Info("Anthropic API client configured")
+		opts := []api.AnthropicOption{api.WithAnthropicBaseURL(strings.TrimRight(cfg.AnthropicBaseURL, "/") + "/api/oauth/usage")}
+		if cfg.DebugHTTP {
+			opts = append(opts, api.WithAnthropicDebugHTTP())
+		}
+		if ttl := cfg.CacheTTL["anthropic"]; ttl > 0 {
+			opts = append(opts, api.WithAnthropicCacheTTL(ttl))
+		}
+		anthropicClient = api.NewAnthropicClient(cfg.AnthropicToken, logger, opts...)
+		logger.Info("Anthropic API client configured", "base_url", cfg.AnthropicBaseURL)
 	}
 
 	var copilotClient *api.CopilotClient
 	if cfg.HasProvider("copilot") {
-		copilotClient = api.NewCopilotClient(cfg.CopilotToken, logger)
+		var opts []api.CopilotOption
+		if cfg.DebugHTTP {
+			opts = append(opts, api.WithCopilotDebugHTTP())
+		}
+		if ttl := cfg.CacheTTL["copilot"]; ttl > 0 {
+			opts = append(opts, api.WithCopilotCacheTTL(ttl))
+		}
+		copilotClient = api.NewCopilotClient(cfg.CopilotToken, logger, opts...)
 		logger.Info("Copilot API client configured")
 	}
 
 	var codexClient *api.CodexClient
 	if cfg.HasProvider("codex") {
 		codexCreds := api.DetectCodexCredentials(logger)
-		codexClient = api.NewCodexClient(cfg.CodexToken, logger)
+		var opts []api.CodexOption
+		if cfg.DebugHTTP {
+			opts = append(opts, api.WithCodexDebugHTTP())
+		}
+		if ttl := cfg.CacheTTL["codex"]; ttl > 0 {
+			opts = append(opts, api.WithCodexCacheTTL(ttl))
+		}
+		codexClient = api.NewCodexClient(cfg.CodexToken, logger, opts...)
 		if codexCreds != nil && codexCreds.AccountID != "" {
 			codexClient.SetAccountID(codexCreds.AccountID)
 		}
@@ -623,6 +893,11 @@ func run() error {
 
 	var antigravityClient *api.AntigravityClient
 	if cfg.HasProvider("antigravity") {
+		api.SetAntigravityModelLabels(cfg.AntigravityModelLabels)
+		var opts []api.AntigravityOption
+		if cfg.DebugHTTP {
+			opts = append(opts, api.WithAntigravityDebugHTTP())
+		}
 		if cfg.AntigravityBaseURL != "" {
 			// Manual configuration (Docker mode)
 			conn := &api.AntigravityConnection{
@@ -630,11 +905,11 @@ func run() error {
 				CSRFToken: cfg.AntigravityCSRFToken,
 				Protocol:  "https",
 			}
-			antigravityClient = api.NewAntigravityClient(logger, api.WithAntigravityConnection(conn))
+			antigravityClient = api.NewAntigravityClient(logger, append(opts, api.WithAntigravityConnection(conn))...)
 			logger.Info("Antigravity API client configured (manual)", "baseURL", cfg.AntigravityBaseURL)
 		} else {
 			// Auto-detection mode
-			antigravityClient = api.NewAntigravityClient(logger)
+			antigravityClient = api.NewAntigravityClient(logger, opts...)
 			logger.Info("Antigravity API client configured (auto-detect)")
 		}
 	}
@@ -644,10 +919,17 @@ func run() error {
 
 	// Create agents with usage-based session managers
 	idleTimeout := cfg.SessionIdleTimeout
+	if saved, err := db.GetSessionIdleTimeout(); err != nil {
+		logger.Warn("Failed to read saved session idle timeout", "error", err)
+	} else if saved > 0 {
+		idleTimeout = saved
+	}
+	var sessionManagers []*agent.SessionManager
 
 	var ag *agent.Agent
 	if syntheticClient != nil {
 		sm := agent.NewSessionManager(db, "synthetic", idleTimeout, logger)
+		sessionManagers = append(sessionManagers, sm)
 		ag = agent.New(syntheticClient, db, tr, cfg.PollInterval, logger, sm)
 	}
 
@@ -660,6 +942,7 @@ func run() error {
 	var zaiAg *agent.ZaiAgent
 	if zaiClient != nil {
 		zaiSm := agent.NewSessionManager(db, "zai", idleTimeout, logger)
+		sessionManagers = append(sessionManagers, zaiSm)
 		zaiAg = agent.NewZaiAgent(zaiClient, db, zaiTr, cfg.PollInterval, logger, zaiSm)
 	}
 
@@ -672,6 +955,7 @@ func run() error {
 	var anthropicAg *agent.AnthropicAgent
 	if anthropicClient != nil {
 		anthropicSm := agent.NewSessionManager(db, "anthropic", idleTimeout, logger)
+		sessionManagers = append(sessionManagers, anthropicSm)
 		anthropicAg = agent.NewAnthropicAgent(anthropicClient, db, anthropicTr, cfg.PollInterval, logger, anthropicSm)
 		// Enable automatic token refresh — re-reads credentials before each poll
 		// so expired OAuth tokens get picked up when Claude Code rotates them.
@@ -694,6 +978,7 @@ func run() error {
 	var copilotAg *agent.CopilotAgent
 	if copilotClient != nil {
 		copilotSm := agent.NewSessionManager(db, "copilot", idleTimeout, logger)
+		sessionManagers = append(sessionManagers, copilotSm)
 		copilotAg = agent.NewCopilotAgent(copilotClient, db, copilotTr, cfg.PollInterval, logger, copilotSm)
 	}
 
@@ -706,6 +991,7 @@ func run() error {
 	var codexAg *agent.CodexAgent
 	if codexClient != nil {
 		codexSm := agent.NewSessionManager(db, "codex", idleTimeout, logger)
+		sessionManagers = append(sessionManagers, codexSm)
 		codexAg = agent.NewCodexAgent(codexClient, db, codexTr, cfg.PollInterval, logger, codexSm)
 		codexAg.SetTokenRefresh(func() string {
 			return api.DetectCodexToken(logger)
@@ -721,15 +1007,12 @@ func run() error {
 	var antigravityAg *agent.AntigravityAgent
 	if antigravityClient != nil {
 		antigravitySm := agent.NewSessionManager(db, "antigravity", idleTimeout, logger)
+		sessionManagers = append(sessionManagers, antigravitySm)
 		antigravityAg = agent.NewAntigravityAgent(antigravityClient, db, antigravityTr, cfg.PollInterval, logger, antigravitySm)
 	}
 
 	// Create notification engine
-	notifier := notify.New(db, logger)
-	notifier.SetEncryptionKey(deriveEncryptionKey(cfg.AdminPassHash))
-	notifier.Reload()
-	notifier.ConfigureSMTP()
-	notifier.ConfigurePush()
+	notifier := newNotifier(db, cfg, cfg.AdminPassHash, logger)
 
 	// Wire notifier to agents
 	if ag != nil {
@@ -813,12 +1096,14 @@ func run() error {
 	}
 	if antigravityTr != nil {
 		antigravityTr.SetOnReset(func(modelID string) {
-			notifier.Check(notify.QuotaStatus{Provider: "antigravity", QuotaKey: modelID, ResetOccurred: true})
+			notifier.Check(notify.QuotaStatus{Provider: "antigravity", QuotaKey: modelID, QuotaLabel: api.AntigravityModelLabel(modelID, ""), ResetOccurred: true})
 		})
 	}
 
 	handler := web.NewHandler(db, tr, logger, nil, cfg, zaiTr)
 	handler.SetVersion(version)
+	handler.SetBuildInfo(update.ReadBuildInfo(version, buildTime))
+	handler.SetLogLevel(logLevel)
 	handler.SetNotifier(notifier)
 	if anthropicTr != nil {
 		handler.SetAnthropicTracker(anthropicTr)
@@ -833,11 +1118,15 @@ func run() error {
 		handler.SetAntigravityTracker(antigravityTr)
 	}
 	updater := update.NewUpdater(version, logger)
+	updater.OnApplied(func(ev update.AppliedUpdate) {
+		notifier.NotifyUpdateApplied(updateEvent(ev, "dashboard"))
+	})
 	handler.SetUpdater(updater)
 
 	// Create login rate limiter for brute force protection
 	loginRateLimiter := web.NewLoginRateLimiter(1000)
 	handler.SetRateLimiter(loginRateLimiter)
+	handler.SetReloadFunc(reloadSelf)
 
 	server := web.NewServer(cfg.Port, handler, logger, cfg.AdminUser, cfg.AdminPassHash, cfg.Host)
 
@@ -847,8 +1136,87 @@ func run() error {
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// Start agents in goroutines (staggered to avoid SQLite contention on session creation)
+	restartChan := make(chan os.Signal, 1)
+	if gracefulRestartSupported {
+		signal.Notify(restartChan, restartSignal)
+	}
+
+	// Start all agents at once; their first snapshot writes take turns through
+	// a shared gate instead of contending for SQLite
+	startGate := agent.NewStartGate()
+	breakers := agent.NewCircuitBreakers(cfg.CircuitFailures, cfg.CircuitCooldown)
+	handler.SetCircuitBreakers(breakers)
+	// Agents poll every PollInterval but store at most every StoreInterval;
+	// /api/current reads the in-memory latest in between
+	latest := agent.NewLatestSnapshots(cfg.StoreInterval)
+	handler.SetLatestSnapshots(latest)
+	snapshotHook := agent.NewSnapshotHook(cfg.OnSnapshotCommand, cfg.OnSnapshotTimeout, logger)
+	if snapshotHook != nil {
+		logger.Warn("Snapshot hook enabled: a command runs after each stored snapshot")
+	}
+	// Off until firehose_enabled is set in the dashboard settings
+	firehose := agent.NewFirehose(handler.FirehoseConfig, agent.FirehoseFlushInterval, logger)
+	handler.SetSessionManagers(sessionManagers...)
+	if cfg.AllowDebugWrites {
+		logger.Warn("Debug writes enabled: POST /api/debug/snapshot can inject fake readings")
+		if ag != nil {
+			handler.SetSnapshotInjector("synthetic", ag)
+		}
+		if zaiAg != nil {
+			handler.SetSnapshotInjector("zai", zaiAg)
+		}
+		if anthropicAg != nil {
+			handler.SetSnapshotInjector("anthropic", anthropicAg)
+		}
+	}
+	if ag != nil {
+		ag.SetStartGate(startGate)
+		ag.SetCircuitBreaker(breakers.For("synthetic"))
+		ag.SetLatestSnapshots(latest)
+		ag.SetSnapshotHook(snapshotHook)
+		ag.SetFirehose(firehose)
+		handler.SetPoller("synthetic", ag)
+	}
+	if zaiAg != nil {
+		zaiAg.SetStartGate(startGate)
+		zaiAg.SetCircuitBreaker(breakers.For("zai"))
+		zaiAg.SetLatestSnapshots(latest)
+		zaiAg.SetSnapshotHook(snapshotHook)
+		zaiAg.SetFirehose(firehose)
+		handler.SetPoller("zai", zaiAg)
+	}
+	if anthropicAg != nil {
+		anthropicAg.SetStartGate(startGate)
+		anthropicAg.SetCircuitBreaker(breakers.For("anthropic"))
+		anthropicAg.SetLatestSnapshots(latest)
+		anthropicAg.SetSnapshotHook(snapshotHook)
+		anthropicAg.SetFirehose(firehose)
+		handler.SetPoller("anthropic", anthropicAg)
+	}
+	if copilotAg != nil {
+		copilotAg.SetStartGate(startGate)
+		copilotAg.SetCircuitBreaker(breakers.For("copilot"))
+		copilotAg.SetLatestSnapshots(latest)
+		copilotAg.SetSnapshotHook(snapshotHook)
+		copilotAg.SetFirehose(firehose)
+		handler.SetPoller("copilot", copilotAg)
+	}
+	if codexAg != nil {
+		codexAg.SetStartGate(startGate)
+		codexAg.SetCircuitBreaker(breakers.For("codex"))
+		codexAg.SetLatestSnapshots(latest)
+		codexAg.SetSnapshotHook(snapshotHook)
+		codexAg.SetFirehose(firehose)
+		handler.SetPoller("codex", codexAg)
+	}
+	if antigravityAg != nil {
+		antigravityAg.SetStartGate(startGate)
+		antigravityAg.SetCircuitBreaker(breakers.For("antigravity"))
+		antigravityAg.SetLatestSnapshots(latest)
+		antigravityAg.SetSnapshotHook(snapshotHook)
+		antigravityAg.SetFirehose(firehose)
+		handler.SetPoller("antigravity", antigravityAg)
+	}
 	agentErr := make(chan error, 5)
 	if ag != nil {
 		go func() {
@@ -873,7 +1241,6 @@ func run() error {
 					agentErr <- fmt.Errorf("zai agent panic: %v", r)
 				}
 			}()
-			time.Sleep(200 * time.Millisecond) // stagger to avoid SQLite BUSY
 			logger.Info("Starting Z.ai agent", "interval", cfg.PollInterval)
 			if err := zaiAg.Run(ctx); err != nil {
 				agentErr <- fmt.Errorf("zai agent error: %w", err)
@@ -889,7 +1256,6 @@ func run() error {
 					agentErr <- fmt.Errorf("anthropic agent panic: %v", r)
 				}
 			}()
-			time.Sleep(400 * time.Millisecond) // stagger to avoid SQLite BUSY
 			logger.Info("Starting Anthropic agent", "interval", cfg.PollInterval)
 			if err := anthropicAg.Run(ctx); err != nil {
 				agentErr <- fmt.Errorf("anthropic agent error: %w", err)
@@ -905,7 +1271,6 @@ func run() error {
 					agentErr <- fmt.Errorf("copilot agent panic: %v", r)
 				}
 			}()
-			time.Sleep(600 * time.Millisecond) // stagger to avoid SQLite BUSY
 			logger.Info("Starting Copilot agent", "interval", cfg.PollInterval)
 			if err := copilotAg.Run(ctx); err != nil {
 				agentErr <- fmt.Errorf("copilot agent error: %w", err)
@@ -921,7 +1286,6 @@ func run() error {
 					agentErr <- fmt.Errorf("codex agent panic: %v", r)
 				}
 			}()
-			time.Sleep(800 * time.Millisecond) // stagger to avoid SQLite BUSY
 			logger.Info("Starting Codex agent", "interval", cfg.PollInterval)
 			if err := codexAg.Run(ctx); err != nil {
 				agentErr <- fmt.Errorf("codex agent error: %w", err)
@@ -937,7 +1301,6 @@ func run() error {
 					agentErr <- fmt.Errorf("antigravity agent panic: %v", r)
 				}
 			}()
-			time.Sleep(1000 * time.Millisecond) // stagger to avoid SQLite BUSY
 			logger.Info("Starting Antigravity agent", "interval", cfg.PollInterval)
 			if err := antigravityAg.Run(ctx); err != nil {
 				agentErr <- fmt.Errorf("antigravity agent error: %w", err)
@@ -949,15 +1312,114 @@ func run() error {
 		logger.Info("No agents configured")
 	}
 
-	// Start web server in goroutine
+	// Start web server in goroutine, on the socket handed over by a graceful
+	// restart if there is one
+	ln, err := inheritedListener()
+	if err != nil {
+		logger.Warn("Ignoring inherited listener", "error", err)
+	}
+	if ln != nil {
+		if addr, ok := ln.Addr().(*net.TCPAddr); !ok || addr.Port != cfg.Port {
+			logger.Info("Inherited listener does not match the configured port, opening a new one", "addr", ln.Addr().String())
+			ln.Close()
+			ln = nil
+		}
+	}
 	serverErr := make(chan error, 1)
 	go func() {
-		logger.Info("Starting web server", "port", cfg.Port)
-		if err := server.Start(); err != nil {
+		logger.Info("Starting web server", "port", cfg.Port, "inherited", ln != nil)
+		serve := server.Start
+		if ln != nil {
+			serve = func() error { return server.Serve(ln) }
+		}
+		if err := serve(); err != nil {
 			serverErr <- fmt.Errorf("server error: %w", err)
 		}
 	}()
 
+	// Optional gRPC API on its own port
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != 0 {
+		host := cfg.Host
+		if host == "" {
+			host = "0.0.0.0"
+		}
+		var grpcOpts []grpc.ServerOption
+		if cfg.GRPCTLSCert != "" {
+			creds, err := credentials.NewServerTLSFromFile(cfg.GRPCTLSCert, cfg.GRPCTLSKey)
+			if err != nil {
+				return fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+			}
+			grpcOpts = append(grpcOpts, grpc.Creds(creds))
+		}
+		grpcLn, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(cfg.GRPCPort)))
+		if err != nil {
+			return fmt.Errorf("failed to listen on gRPC port %d: %w", cfg.GRPCPort, err)
+		}
+		grpcServer = web.NewGRPCServer(handler, grpcOpts...)
+		go func() {
+			logger.Info("Starting gRPC server", "port", cfg.GRPCPort, "tls", cfg.GRPCTLSCert != "")
+			if err := grpcServer.Serve(grpcLn); err != nil {
+				serverErr <- fmt.Errorf("gRPC server error: %w", err)
+			}
+		}()
+	}
+
+	// Scheduled data exports
+	if cfg.ExportInterval != 0 {
+		var target export.Target = export.DirTarget{Dir: cfg.ExportDir}
+		if cfg.ExportS3Bucket != "" {
+			target = &export.S3Target{
+				Endpoint:  cfg.ExportS3Endpoint,
+				Bucket:    cfg.ExportS3Bucket,
+				Region:    cfg.ExportS3Region,
+				Prefix:    cfg.ExportS3Prefix,
+				AccessKey: cfg.ExportS3AccessKey,
+				SecretKey: cfg.ExportS3SecretKey,
+			}
+		}
+		scheduler := export.NewScheduler(cfg.ExportInterval, cfg.ExportFormat, cfg.ExportRetention, target, handler.WriteDataExport, logger)
+		logger.Info("Scheduled exports enabled", "interval", cfg.ExportInterval, "format", cfg.ExportFormat, "retention", cfg.ExportRetention)
+		go scheduler.Run(ctx)
+	}
+
+	// Keep the database lock fresh so a later start can tell it is held
+	go func() {
+		ticker := time.NewTicker(instanceLockHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if held, err := db.RefreshInstanceLock(os.Getpid(), hostname); err != nil {
+					logger.Warn("Failed to refresh database lock", "error", err)
+				} else if !held {
+					logger.Error("Database lock was taken by another onwatch instance; two instances may now write the same database")
+				}
+			}
+		}
+	}()
+
+	// Post batches of stored snapshots to the firehose webhook, if enabled
+	go firehose.Run(ctx)
+
+	// Prune snapshots and cycles past their retention settings, at startup
+	// and then hourly
+	go func() {
+		handler.PruneExpiredData(time.Now())
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				handler.PruneExpiredData(now)
+			}
+		}
+	}()
+
 	// Periodically return freed memory to the OS. On macOS, MADV_FREE pages
 	// are reclaimable but still counted in RSS. FreeOSMemory forces MADV_DONTNEED.
 	// Also evict stale rate limiter entries and expired session tokens to prevent memory growth.
@@ -979,18 +1441,39 @@ func run() error {
 		}
 	}()
 
-	// Wait for signal or error
-	select {
-	case sig := <-sigChan:
-		logger.Info("Received signal, shutting down gracefully", "signal", sig)
-	case err := <-agentErr:
-		if err != nil {
-			logger.Error("Agent failed", "error", err)
+	// Wait for signal or error. The restart signal first hands the listening
+	// socket to a new process, then shuts this one down so it drains its
+	// in-flight requests while the new one takes new connections.
+wait:
+	for {
+		select {
+		case sig := <-sigChan:
+			logger.Info("Received signal, shutting down gracefully", "signal", sig)
+			break wait
+		case sig := <-restartChan:
+			if cfg.IsDockerEnvironment() || cfg.Foreground || update.IsSystemd() {
+				// The container or service manager restarts us instead
+				logger.Info("Received restart signal, shutting down for the supervisor to restart", "signal", sig)
+				break wait
+			}
+			pid, err := gracefulRestart(server, cfg.Port)
+			if err != nil {
+				logger.Error("Graceful restart failed, continuing to run", "error", err)
+				continue
+			}
+			logger.Info("Handed listener to new process, draining connections", "pid", pid)
+			break wait
+		case err := <-agentErr:
+			if err != nil {
+				logger.Error("Agent failed", "error", err)
+				cancel()
+			}
+			break wait
+		case err := <-serverErr:
+			logger.Error("Server failed", "error", err)
 			cancel()
+			break wait
 		}
-	case err := <-serverErr:
-		logger.Error("Server failed", "error", err)
-		cancel()
 	}
 
 	// Graceful shutdown sequence
@@ -1002,6 +1485,9 @@ func run() error {
 	// Give agent a moment to clean up
 	time.Sleep(100 * time.Millisecond)
 
+	// Send alerts still waiting in the grouping window
+	notifier.FlushAlerts()
+
 	// Shutdown server with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
@@ -1009,6 +1495,9 @@ func run() error {
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		logger.Error("Server shutdown error", "error", err)
 	}
+	if grpcServer != nil {
+		grpcServer.Stop() // also ends open Watch streams
+	}
 
 	// Close database
 	if err := db.Close(); err != nil {
@@ -1020,7 +1509,8 @@ func run() error {
 }
 
 // runStop stops any running onwatch instance.
-// In test mode, only the test PID file is used (no port scanning) to avoid killing production.
+// In test mode or with ONWATCH_PID_FILE, only the PID file is used (no port
+// scanning) to avoid killing other instances.
 func runStop(testMode bool) error {
 	myPID := os.Getpid()
 	stopped := false
@@ -1085,8 +1575,8 @@ func runStop(testMode bool) error {
 	}
 
 	// Method 2: Port-based fallback — check default ports
-	// Skip in test mode to avoid killing production instances
-	if !testMode && !stopped {
+	// Skip in test mode or with a custom PID file to avoid killing other instances
+	if !testMode && !pidFileCustom && !stopped {
 		// Check both old (8932) and new (9211) default ports for backwards compatibility
 		for _, port := range []int{9211, 8932} {
 			conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 500*time.Millisecond)
@@ -1117,7 +1607,8 @@ func runStop(testMode bool) error {
 }
 
 // runStatus reports the status of any running onwatch instance.
-// In test mode, only the test PID file is checked (no port scanning).
+// In test mode or with ONWATCH_PID_FILE, only the PID file is checked (no
+// port scanning).
 func runStatus(testMode bool) error {
 	myPID := os.Getpid()
 	label := "onwatch"
@@ -1157,6 +1648,7 @@ func runStatus(testMode bool) error {
 								for _, p := range pids {
 									if p == pid {
 										fmt.Printf("  Dashboard: http://localhost:%d\n", checkPort)
+										port = checkPort
 										break
 									}
 								}
@@ -1172,6 +1664,12 @@ func runStatus(testMode bool) error {
 					if testMode {
 						logPath = ".onwatch-test.log"
 					}
+					if dir := os.Getenv("ONWATCH_DATA_DIR"); dir != "" {
+						logPath = filepath.Join(dir, logPath)
+					}
+					if env := os.Getenv("ONWATCH_LOG_FILE"); env != "" {
+						logPath = env
+					}
 					if info, err := os.Stat(logPath); err == nil {
 						fmt.Printf("  Log file:  %s (%s)\n", logPath, humanSize(info.Size()))
 					}
@@ -1189,6 +1687,9 @@ func runStatus(testMode bool) error {
 						}
 					}
 
+					if port > 0 {
+						printAgentHealth(port)
+					}
 					return nil
 				}
 			}
@@ -1198,8 +1699,9 @@ func runStatus(testMode bool) error {
 		}
 	}
 
-	// No PID file — try port check (skip in test mode to avoid confusion with production)
-	if !testMode {
+	// No PID file — try port check (skip in test mode or with a custom PID file
+	// to avoid confusion with other instances)
+	if !testMode && !pidFileCustom {
 		for _, port := range []int{9211, 8932} {
 			conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 500*time.Millisecond)
 			if err != nil {
@@ -1213,6 +1715,7 @@ func runStatus(testMode bool) error {
 					}
 					fmt.Printf("%s is running (PID %d) on port %d\n", label, pid, port)
 					fmt.Printf("  Dashboard: http://localhost:%d\n", port)
+					printAgentHealth(port)
 					return nil
 				}
 			}
@@ -1223,6 +1726,97 @@ func runStatus(testMode bool) error {
 	return nil
 }
 
+// errAgentHealthUnsupported means the daemon predates /api/agent-status.
+var errAgentHealthUnsupported = errors.New("not reported by this version of the daemon")
+
+// printAgentHealth prints each provider's last poll and error, read from the
+// daemon on port, so status shows whether data is actually flowing.
+func printAgentHealth(port int) {
+	user, pass := "admin", ""
+	if cfg, err := config.Load(); err == nil {
+		user, pass = cfg.AdminUser, cfg.AdminPass
+	} else {
+		if v := os.Getenv("ONWATCH_ADMIN_USER"); v != "" {
+			user = v
+		}
+		pass = os.Getenv("ONWATCH_ADMIN_PASS")
+	}
+	statuses, err := fetchAgentHealth(fmt.Sprintf("http://127.0.0.1:%d", port), user, pass)
+	if err != nil {
+		fmt.Printf("  Health:    %v\n", err)
+		return
+	}
+	fmt.Print(formatAgentHealth(statuses, time.Now()))
+}
+
+// fetchAgentHealth reads /api/agent-status from the daemon at url.
+func fetchAgentHealth(url, user, pass string) ([]agent.CircuitStatus, error) {
+	req, err := http.NewRequest(http.MethodGet, url+"/api/agent-status", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(user, pass)
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("daemon not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, errors.New("unauthorized: check ONWATCH_ADMIN_USER/ONWATCH_ADMIN_PASS")
+	case http.StatusNotFound:
+		return nil, errAgentHealthUnsupported
+	default:
+		return nil, fmt.Errorf("daemon returned %s", resp.Status)
+	}
+	var statuses []agent.CircuitStatus
+	// Older daemons answer unknown paths with the dashboard page
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, errAgentHealthUnsupported
+	}
+	return statuses, nil
+}
+
+// formatAgentHealth renders one line per provider: when it was last polled
+// and whether that worked, plus the circuit breaker state when it is open.
+func formatAgentHealth(statuses []agent.CircuitStatus, now time.Time) string {
+	if len(statuses) == 0 {
+		return "  Health:    no providers polling\n"
+	}
+	var b strings.Builder
+	b.WriteString("  Health:\n")
+	for _, st := range statuses {
+		line := "not polled yet"
+		if st.LastPollAt != nil {
+			line = "polled " + formatCountdown(now.Sub(*st.LastPollAt)) + " ago, "
+			if st.LastPollError == "" {
+				line += "ok"
+			} else {
+				line += "error: " + st.LastPollError
+			}
+		} else if st.LastError != "" {
+			line = "error: " + st.LastError
+		}
+		if st.State != agent.CircuitClosed {
+			line += " (circuit " + st.State
+			if st.RetryAt != nil && st.RetryAt.After(now) {
+				line += ", retry in " + formatCountdown(st.RetryAt.Sub(now))
+			}
+			line += ")"
+		}
+		if st.SchemaWarning != "" {
+			line += "; " + st.SchemaWarning
+		}
+		if st.Token != nil && st.Token.Warning != "" {
+			line += "; " + st.Token.Warning
+		}
+		fmt.Fprintf(&b, "    %-12s %s\n", st.Provider, line)
+	}
+	return b.String()
+}
+
 // humanSize returns a human-readable file size.
 func humanSize(bytes int64) string {
 	if bytes < 1024 {
@@ -1238,7 +1832,31 @@ func runUpdate() error {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	u := update.NewUpdater(version, logger)
 
-	fmt.Printf("onWatch v%s — checking for updates...\n", version)
+	// Honor the update channel chosen in the dashboard settings, and report
+	// the update through the same webhook and notifications as the daemon
+	if cfg, err := config.Load(); err == nil {
+		if db, err := store.New(cfg.DBPath); err == nil {
+			defer db.Close()
+			if ch, _ := db.GetSetting("update_channel"); ch != "" {
+				if err := u.SetChannel(ch); err != nil {
+					logger.Warn("Ignoring saved update channel", "error", err)
+				}
+			}
+			if err := initEncryptionSalt(db, logger); err != nil {
+				logger.Warn("Failed to initialize encryption salt", "error", err)
+			}
+			passHash, err := db.GetUser(cfg.AdminUser)
+			if err != nil || passHash == "" {
+				passHash = sha256hex(cfg.AdminPass)
+			}
+			notifier := newNotifier(db, cfg, passHash, logger)
+			u.OnApplied(func(ev update.AppliedUpdate) {
+				notifier.NotifyUpdateApplied(updateEvent(ev, "cli"))
+			})
+		}
+	}
+
+	fmt.Printf("onWatch v%s — checking for updates (%s channel)...\n", version, u.Channel())
 
 	info, err := u.Check()
 	if err != nil {
@@ -1252,27 +1870,107 @@ func runUpdate() error {
 
 	fmt.Printf("Update available: v%s → v%s\n", info.CurrentVersion, info.LatestVersion)
 	fmt.Printf("Downloading from %s\n", info.DownloadURL)
+	if info.Checksum != "" {
+		fmt.Printf("Expected SHA-256: %s\n", info.Checksum)
+	}
 
-	if err := u.Apply(); err != nil {
+	if err := u.ApplyWithProgress(printUpdateProgress); err != nil {
+		fmt.Println()
+		switch {
+		case errors.Is(err, update.ErrChecksumMismatch):
+			return fmt.Errorf("update refused, the downloaded binary failed checksum verification and was discarded: %w", err)
+		case errors.Is(err, update.ErrChecksumUnavailable):
+			return fmt.Errorf("update refused, the release's SHA256SUMS could not be verified: %w", err)
+		}
 		return fmt.Errorf("update failed: %w", err)
 	}
 
 	fmt.Printf("Updated successfully to v%s\n", info.LatestVersion)
+	fmt.Println("Run 'onwatch rollback' to return to this version if the update misbehaves.")
+
+	restartRunningDaemon()
+	return nil
+}
+
+// printUpdateProgress redraws the update's progress line in place.
+func printUpdateProgress(p update.Progress) {
+	const width = 30
+	switch {
+	case p.Stage == update.StageInstall:
+		fmt.Println("Installing...")
+	case p.Total > 0:
+		filled := p.Percent() * width / 100
+		fmt.Printf("\r[%s%s] %3d%%  %s / %s", strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+			p.Percent(), humanSize(p.Downloaded), humanSize(p.Total))
+		if p.Downloaded >= p.Total {
+			fmt.Println()
+		}
+	default:
+		fmt.Printf("\rDownloaded %s", humanSize(p.Downloaded))
+	}
+}
+
+// newNotifier creates the notification engine with every configured delivery
+// channel. passHash is the admin password hash the stored credentials are
+// encrypted with.
+func newNotifier(db *store.Store, cfg *config.Config, passHash string, logger *slog.Logger) *notify.NotificationEngine {
+	notifier := notify.New(db, logger)
+	notifier.SetEncryptionKey(web.DeriveEncryptionKey(passHash, nil))
+	notifier.Reload()
+	notifier.ConfigureSMTP()
+	notifier.ConfigurePush()
+	notifier.ConfigureMatrix()
+	notifier.ConfigureTwilio()
+	notifier.ConfigureWebhook(cfg.WebhookURL)
+	return notifier
+}
+
+// updateEvent converts an applied update into the webhook payload; source
+// says where the update was started.
+func updateEvent(ev update.AppliedUpdate, source string) notify.UpdateEvent {
+	return notify.UpdateEvent{
+		FromVersion: ev.FromVersion,
+		ToVersion:   ev.ToVersion,
+		Trigger:     ev.Trigger,
+		Source:      source,
+		AppliedAt:   time.Now().UTC(),
+	}
+}
+
+// runRollback restores the binary replaced by the last update and restarts
+// a running daemon on it.
+func runRollback() error {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	u := update.NewUpdater(version, logger)
+
+	prev := u.PreviousVersion()
+	if prev == "" {
+		return fmt.Errorf("nothing to roll back: no previous binary was kept by an update")
+	}
+	fmt.Printf("Rolling back onWatch v%s → v%s...\n", version, prev)
+
+	restored, err := u.Rollback()
+	if err != nil {
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+	fmt.Printf("Rolled back to v%s (run 'onwatch rollback' again to undo)\n", restored)
 
+	restartRunningDaemon()
+	return nil
+}
+
+// restartRunningDaemon restarts the daemon named in the PID file, if any, so
+// it runs the binary now on disk.
+func restartRunningDaemon() {
 	// If a daemon is running, stop it and start a fresh one
 	if data, err := os.ReadFile(pidFile); err == nil {
-		content := strings.TrimSpace(string(data))
-		var pid int
-		if strings.Contains(content, ":") {
-			parts := strings.Split(content, ":")
-			if len(parts) >= 1 {
-				pid, _ = strconv.Atoi(parts[0])
-			}
-		} else {
-			pid, _ = strconv.Atoi(content)
-		}
+		pid, _ := parsePIDFile(string(data))
 		if pid > 0 && pid != os.Getpid() {
 			fmt.Println("Restarting daemon...")
+			if newPID, ok := requestGracefulRestart(pid); ok {
+				fmt.Printf("Daemon restarted in place (PID %d); open connections were kept\n", newPID)
+				return
+			}
 			// Stop old daemon
 			if proc, err := os.FindProcess(pid); err == nil {
 				_ = proc.Signal(syscall.SIGTERM)
@@ -1293,10 +1991,274 @@ func runUpdate() error {
 			}
 		}
 	}
+}
+
+func runImportClaude() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	root := flagValue("--path")
+	if root == "" || strings.HasPrefix(root, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("cannot find home directory: %w", err)
+		}
+		if root == "" {
+			root = filepath.Join(home, ".claude")
+		} else {
+			root = filepath.Join(home, root[2:])
+		}
+	}
+
+	var fiveHourTokens float64
+	if v := flagValue("--five-hour-tokens"); v != "" {
+		fiveHourTokens, err = strconv.ParseFloat(v, 64)
+		if err != nil || fiveHourTokens <= 0 {
+			return fmt.Errorf("--five-hour-tokens must be a positive number")
+		}
+	}
+
+	db, err := store.New(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	fmt.Printf("Importing Claude Code usage from %s into %s\n", root, cfg.DBPath)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	result, err := importClaudeLogs(db, root, fiveHourTokens, logger)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Read %d usage entries (five-hour budget: %.0f tokens)\n", result.entries, result.fiveHourTokens)
+	fmt.Printf("Imported %d snapshots, skipped %d already covered\n", result.imported, result.skipped)
+	return nil
+}
+
+// runSettings handles "onwatch settings export|import". Export writes the
+// dashboard settings as JSON to --output (default stdout); import restores
+// them from a file, validated as the dashboard would.
+func runSettings() error {
+	var action, file string
+	for i, arg := range os.Args[1:] {
+		if arg == "settings" && i+2 < len(os.Args) {
+			action = os.Args[i+2]
+			if i+3 < len(os.Args) && !strings.HasPrefix(os.Args[i+3], "--") {
+				file = os.Args[i+3]
+			}
+			break
+		}
+	}
+	if action != "export" && action != "import" {
+		return fmt.Errorf("usage: onwatch settings export [--include-secrets] [--output FILE] | onwatch settings import FILE")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	db, err := store.New(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// Stored credentials are encrypted with a key derived from the admin
+	// password hash, so resolve it the same way the server does.
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	if err := initEncryptionSalt(db, logger); err != nil {
+		logger.Warn("Failed to initialize encryption salt", "error", err)
+	}
+	passHash, err := db.GetUser(cfg.AdminUser)
+	if err != nil || passHash == "" {
+		passHash = sha256hex(cfg.AdminPass)
+	}
+	handler := web.NewHandler(db, nil, logger, web.NewSessionStore(cfg.AdminUser, passHash, db), cfg)
+
+	if action == "export" {
+		data, err := json.MarshalIndent(handler.ExportSettingsData(hasFlag("--include-secrets")), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode settings: %w", err)
+		}
+		data = append(data, '\n')
+		output := flagValue("--output")
+		if output == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(output, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		fmt.Fprintf(os.Stderr, "Exported settings to %s\n", output)
+		return nil
+	}
+
+	if file == "" {
+		return fmt.Errorf("usage: onwatch settings import FILE")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	imported, skipped, err := handler.ImportSettingsData(data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported settings: %s\n", strings.Join(imported, ", "))
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped (credentials not included in the export): %s\n", strings.Join(skipped, ", "))
+	}
+	fmt.Println("Restart onwatch for a running instance to pick up the imported settings.")
+	return nil
+}
+
+// runClear handles "onwatch clear --provider NAME", which deletes every
+// snapshot, cycle and session stored for one provider after asking for
+// confirmation (skipped with --yes).
+func runClear() error {
+	provider := strings.ToLower(flagValue("--provider"))
+	if !store.IsClearableProvider(provider) {
+		return fmt.Errorf("usage: onwatch clear --provider %s [--yes]", strings.Join(store.ClearableProviders(), "|"))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if !hasFlag("--yes") {
+		fmt.Printf("Delete all stored %s snapshots, cycles and sessions from %s? [y/N] ", provider, cfg.DBPath)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	db, err := store.New(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
 
+	deleted, err := db.ClearProviderData(provider)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, n := range deleted {
+		total += n
+	}
+	fmt.Printf("Cleared %s data: %d rows deleted\n", provider, total)
 	return nil
 }
 
+// claudeImportResult summarises an import-claude run.
+type claudeImportResult struct {
+	entries        int
+	imported       int
+	skipped        int
+	fiveHourTokens float64
+}
+
+// importClaudeLogs backfills Anthropic five_hour snapshots from the Claude Code
+// logs under root, then rebuilds Anthropic cycles. Only history older than the
+// first stored snapshot is imported and existing timestamps are skipped, so
+// running it again is a no-op. Without fiveHourTokens, the budget is estimated
+// from the latest polled five_hour utilization and the tokens logged in that
+// window.
+func importClaudeLogs(db *store.Store, root string, fiveHourTokens float64, logger *slog.Logger) (*claudeImportResult, error) {
+	entries, err := api.ReadClaudeUsageLogs(root)
+	if err != nil {
+		return nil, err
+	}
+	result := &claudeImportResult{entries: len(entries), fiveHourTokens: fiveHourTokens}
+	if len(entries) == 0 {
+		return result, nil
+	}
+
+	if result.fiveHourTokens <= 0 {
+		result.fiveHourTokens, err = estimateFiveHourTokens(db, entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cutoff, err := db.QueryFirstAnthropicTime()
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range api.ClaudeUsageSnapshots(entries, result.fiveHourTokens) {
+		if !cutoff.IsZero() && !snap.CapturedAt.Before(cutoff) {
+			result.skipped++
+			continue
+		}
+		exists, err := db.AnthropicSnapshotExists(snap.CapturedAt)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			result.skipped++
+			continue
+		}
+		if _, err := db.InsertAnthropicSnapshot(snap); err != nil {
+			return nil, err
+		}
+		result.imported++
+	}
+
+	if result.imported > 0 {
+		if err := tracker.RebuildAnthropicCycles(db, logger); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// estimateFiveHourTokens derives the five-hour token budget from the latest
+// stored five_hour reading and the tokens the logs show for that window.
+func estimateFiveHourTokens(db *store.Store, entries []api.ClaudeUsageEntry) (float64, error) {
+	latest, err := db.QueryLatestAnthropic()
+	if err != nil {
+		return 0, err
+	}
+	if latest != nil {
+		for _, q := range latest.Quotas {
+			if q.Name != "five_hour" || q.ResetsAt == nil || q.Utilization < 1 {
+				continue
+			}
+			used := api.ClaudeWindowTokens(entries, q.ResetsAt.Add(-5*time.Hour), latest.CapturedAt)
+			if used > 0 {
+				return float64(used) * 100 / q.Utilization, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot estimate the five-hour token budget from existing data; pass --five-hour-tokens")
+}
+
+// printBuildInfo prints the details `onwatch --version --verbose` adds for
+// bug reports.
+func printBuildInfo(info update.BuildInfo) {
+	revision := info.Revision
+	if revision == "" {
+		revision = "unknown"
+	} else if info.Modified {
+		revision += " (modified)"
+	}
+	buildDate := info.BuildDate
+	if buildDate == "" {
+		buildDate = "unknown"
+	}
+	fmt.Println()
+	fmt.Printf("  Go:       %s\n", info.GoVersion)
+	fmt.Printf("  Commit:   %s\n", revision)
+	fmt.Printf("  Built:    %s\n", buildDate)
+	fmt.Printf("  Platform: %s/%s\n", info.OS, info.Arch)
+}
+
 func printBanner(cfg *config.Config, version string) {
 	fmt.Println()
 	fmt.Println("╔══════════════════════════════════════╗")
@@ -1382,40 +2344,72 @@ func printHelp() {
 	fmt.Println("  stop, --stop       Stop the running onwatch instance")
 	fmt.Println("  status, --status   Show status of the running instance")
 	fmt.Println("  update, --update   Check for updates and self-update")
+	fmt.Println("  rollback           Restore the binary replaced by the last update")
+	fmt.Println("  import-claude      Backfill Anthropic history from Claude Code logs")
+	fmt.Println("                     (--path DIR, default ~/.claude; --five-hour-tokens N)")
+	fmt.Println("  settings export    Write dashboard settings as JSON (--output FILE; --include-secrets")
+	fmt.Println("                     adds credentials in plaintext)")
+	fmt.Println("  settings import F  Restore dashboard settings from an export file")
+	fmt.Println("  clear --provider P Delete all stored data for one provider (--yes skips the prompt)")
+	fmt.Println("  top                Live quota bars in the terminal, refreshed every poll interval")
+	fmt.Println("                     (--url URL for a remote daemon; --user/--pass credentials)")
 	fmt.Println()
 	fmt.Println("Options:")
-	fmt.Println("  version, --version Print version and exit")
+	fmt.Println("  version, --version Print version and exit (--verbose adds Go version, commit and platform)")
 	fmt.Println("  --help             Print this help message")
 	fmt.Println("  --interval SEC     Polling interval in seconds (default: 60)")
 	fmt.Println("  --port PORT        Dashboard HTTP port (default: 9211)")
 	fmt.Println("  --db PATH          SQLite database file path (default: ~/.onwatch/data/onwatch.db)")
 	fmt.Println("  --debug            Run in foreground mode, log to stdout")
+	fmt.Println("  --foreground       Run in the foreground for a process supervisor (runit, s6), log to stdout")
 	fmt.Println("  --test             Test mode: isolated PID/log files, won't affect production")
 	fmt.Println()
 	fmt.Println("Environment Variables:")
 	fmt.Println("  SYNTHETIC_API_KEY       Synthetic API key (configure at least one provider)")
+	fmt.Println("  SYNTHETIC_BASE_URL      Synthetic base URL (default: https://api.synthetic.new)")
 	fmt.Println("  ZAI_API_KEY            Z.ai API key")
 	fmt.Println("  ZAI_BASE_URL           Z.ai base URL (default: https://api.z.ai/api)")
 	fmt.Println("  ANTHROPIC_TOKEN         Anthropic token (auto-detected if not set)")
+	fmt.Println("  ANTHROPIC_BASE_URL      Anthropic base URL (default: https://api.anthropic.com)")
 	fmt.Println("  COPILOT_TOKEN           GitHub Copilot token (PAT with copilot scope)")
 	fmt.Println("  CODEX_TOKEN             Codex OAuth token (recommended; required for Codex-only)")
 	fmt.Println("  CODEX_HOME              Optional Codex auth directory (uses CODEX_HOME/auth.json)")
 	fmt.Println("  ONWATCH_POLL_INTERVAL   Polling interval in seconds")
+	fmt.Println("  ONWATCH_STORE_INTERVAL  Minimum seconds between stored snapshots (default: every poll)")
 	fmt.Println("  ONWATCH_PORT            Dashboard HTTP port")
 	fmt.Println("  ONWATCH_ADMIN_USER      Dashboard admin username")
 	fmt.Println("  ONWATCH_ADMIN_PASS      Dashboard admin password")
 	fmt.Println("  ONWATCH_DB_PATH         SQLite database file path")
+	fmt.Println("  ONWATCH_PID_FILE        PID file path (default: onwatch.pid in ~/.onwatch)")
 	fmt.Println("  ONWATCH_LOG_LEVEL       Log level: debug, info, warn, error")
+	fmt.Println("  ONWATCH_DAEMON_LOG_LEVEL Log level for the background daemon (default: ONWATCH_LOG_LEVEL)")
+	fmt.Println("  ONWATCH_DEBUG_HTTP      Log provider requests (set log level to debug for bodies)")
+	fmt.Println("  ONWATCH_ALLOW_DEBUG_WRITES Enable POST /api/debug/snapshot (testing only)")
+	fmt.Println("  ONWATCH_TLS_CLIENT_CERT Client certificate (PEM) for mTLS to provider APIs")
+	fmt.Println("  ONWATCH_TLS_CLIENT_KEY  Private key (PEM) for ONWATCH_TLS_CLIENT_CERT")
+	fmt.Println("  ONWATCH_GRPC_TLS_CERT   Certificate (PEM) to serve the gRPC API over TLS")
+	fmt.Println("  ONWATCH_GRPC_TLS_KEY    Private key (PEM) for ONWATCH_GRPC_TLS_CERT")
+	fmt.Println("  ONWATCH_CA_BUNDLE       Extra CA certificates (PEM) trusted for provider APIs")
+	fmt.Println("  ONWATCH_TRUSTED_PROXIES Reverse proxies whose X-Forwarded-For the IP allowlist honours")
+	fmt.Println("  ONWATCH_WEBHOOK_URL     JSON webhook notified of events such as applied updates")
+	fmt.Println("  ONWATCH_ON_SNAPSHOT_COMMAND Command run with each stored snapshot as JSON on stdin")
+	fmt.Println("  <PROVIDER>_CACHE_TTL    Reuse a provider's response for N seconds (e.g. ZAI_CACHE_TTL)")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  onwatch                           # Run in background mode")
 	fmt.Println("  onwatch --debug                   # Run in foreground mode")
+	fmt.Println("  onwatch --foreground              # Run under runit/s6 without daemonizing")
 	fmt.Println("  onwatch --interval 30 --port 8080 # Custom interval and port")
 	fmt.Println("  onwatch stop                      # Stop running instance")
 	fmt.Println("  onwatch --stop                    # Same as 'stop'")
 	fmt.Println("  onwatch status                    # Check if running")
 	fmt.Println("  onwatch --status                  # Same as 'status'")
 	fmt.Println("  onwatch update                    # Check for updates and self-update")
+	fmt.Println("  onwatch rollback                  # Undo the last update")
+	fmt.Println("  onwatch import-claude             # Backfill history from ~/.claude logs")
+	fmt.Println("  onwatch settings export --output settings.json # Back up settings")
+	fmt.Println("  onwatch clear --provider zai      # Forget Z.ai history after switching accounts")
+	fmt.Println("  onwatch top --url http://nas:9211 # Terminal dashboard for a remote instance")
 	fmt.Println("  onwatch --test --debug            # Run test instance (isolated)")
 	fmt.Println("  onwatch --test stop               # Stop only test instance")
 	fmt.Println("  onwatch --test status             # Check test instance status")
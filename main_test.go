@@ -1,12 +1,20 @@
 package main
 
 import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/onllm-dev/onwatch/internal/agent"
+	"github.com/onllm-dev/onwatch/internal/api"
 	"github.com/onllm-dev/onwatch/internal/config"
+	"github.com/onllm-dev/onwatch/internal/store"
 )
 
 func TestConfigLoad_WithOnlyCodexAuthFile_ReturnsValidationError(t *testing.T) {
@@ -36,3 +44,166 @@ func TestConfigLoad_WithOnlyCodexAuthFile_ReturnsValidationError(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestImportClaudeLogs_BackfillsOnceBeforeLiveData(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "projects", "-home-user-project")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	logLines := []string{
+		`{"type":"assistant","timestamp":"2026-01-10T12:10:00Z","requestId":"r1","message":{"id":"m1","usage":{"input_tokens":1000,"output_tokens":1000}}}`,
+		`{"type":"assistant","timestamp":"2026-01-10T12:40:00Z","requestId":"r2","message":{"id":"m2","usage":{"input_tokens":1000,"output_tokens":1000}}}`,
+		`{"type":"assistant","timestamp":"2026-01-11T09:05:00Z","requestId":"r3","message":{"id":"m3","usage":{"input_tokens":1000,"output_tokens":1000}}}`,
+		`{"type":"assistant","timestamp":"2026-01-11T09:45:00Z","requestId":"r4","message":{"id":"m4","usage":{"input_tokens":1000,"output_tokens":1000}}}`,
+	}
+	if err := os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(strings.Join(logLines, "\n")), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	db, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer db.Close()
+	logger := slog.New(slog.DiscardHandler)
+
+	// Nothing polled yet and no budget given: cannot estimate
+	if _, err := importClaudeLogs(db, root, 0, logger); err == nil {
+		t.Fatal("expected an error without --five-hour-tokens or polled data")
+	}
+
+	// Live polling began on the 11th; the window holding r3 was 20% used
+	liveAt := time.Date(2026, 1, 11, 9, 30, 0, 0, time.UTC)
+	resetsAt := time.Date(2026, 1, 11, 14, 0, 0, 0, time.UTC)
+	db.InsertAnthropicSnapshot(&api.AnthropicSnapshot{
+		CapturedAt: liveAt,
+		Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: 20, ResetsAt: &resetsAt}},
+	})
+
+	result, err := importClaudeLogs(db, root, 0, logger)
+	if err != nil {
+		t.Fatalf("importClaudeLogs: %v", err)
+	}
+	if result.fiveHourTokens != 10000 {
+		t.Errorf("estimated budget = %.0f, want 10000", result.fiveHourTokens)
+	}
+	if result.imported != 3 || result.skipped != 1 {
+		t.Errorf("imported %d skipped %d, want 3 and 1", result.imported, result.skipped)
+	}
+
+	history, err := db.QueryAnthropicCycleHistory("five_hour")
+	if err != nil || len(history) != 1 || history[0].PeakUtilization != 40 {
+		t.Fatalf("expected one backfilled cycle peaking at 40%%, got %+v, %v", history, err)
+	}
+
+	// Running again changes nothing
+	result, err = importClaudeLogs(db, root, 0, logger)
+	if err != nil {
+		t.Fatalf("second importClaudeLogs: %v", err)
+	}
+	if result.imported != 0 {
+		t.Errorf("second run imported %d snapshots, want 0", result.imported)
+	}
+}
+
+func TestRemovePIDFile_KeepsSuccessorPID(t *testing.T) {
+	orig := pidFile
+	pidFile = filepath.Join(t.TempDir(), "onwatch.pid")
+	defer func() { pidFile = orig }()
+
+	// A graceful restart has written the new process's PID: leave it alone
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d:9211", os.Getpid()+1)), 0o644); err != nil {
+		t.Fatalf("write pid file: %v", err)
+	}
+	removePIDFile()
+	if _, err := os.Stat(pidFile); err != nil {
+		t.Fatalf("PID file naming another process should be kept: %v", err)
+	}
+
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d:9211", os.Getpid())), 0o644); err != nil {
+		t.Fatalf("write pid file: %v", err)
+	}
+	removePIDFile()
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Fatalf("own PID file should be removed, stat err = %v", err)
+	}
+
+	if pid, port := parsePIDFile("1234\n"); pid != 1234 || port != 0 {
+		t.Errorf("parsePIDFile(legacy) = %d, %d", pid, port)
+	}
+}
+
+func TestConfigurePIDFile(t *testing.T) {
+	origDir, origFile, origCustom := pidDir, pidFile, pidFileCustom
+	defer func() { pidDir, pidFile, pidFileCustom = origDir, origFile, origCustom }()
+
+	configurePIDFile("/srv/onwatch", "", false)
+	if pidFile != filepath.Join("/srv/onwatch", "onwatch.pid") || pidFileCustom {
+		t.Errorf("data dir: pidFile = %q, custom = %v", pidFile, pidFileCustom)
+	}
+	configurePIDFile("", "", true)
+	if pidFile != filepath.Join("/srv/onwatch", "onwatch-test.pid") {
+		t.Errorf("test mode: pidFile = %q", pidFile)
+	}
+
+	custom := filepath.Join(t.TempDir(), "run", "work.pid")
+	configurePIDFile("/srv/onwatch", custom, true)
+	if pidFile != custom || !pidFileCustom {
+		t.Fatalf("override: pidFile = %q, custom = %v", pidFile, pidFileCustom)
+	}
+	if err := writePIDFile(9300); err != nil {
+		t.Fatalf("writePIDFile: %v", err)
+	}
+	if data, err := os.ReadFile(custom); err != nil || string(data) != fmt.Sprintf("%d:9300", os.Getpid()) {
+		t.Fatalf("PID file at override = %q, %v", data, err)
+	}
+	removePIDFile()
+	if _, err := os.Stat(custom); !os.IsNotExist(err) {
+		t.Errorf("PID file at override should be removed, stat err = %v", err)
+	}
+}
+
+func TestFormatAgentHealth(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	polled := now.Add(-3 * time.Minute)
+	retry := now.Add(5 * time.Minute)
+	out := formatAgentHealth([]agent.CircuitStatus{
+		{Provider: "anthropic", State: agent.CircuitClosed, LastPollAt: &polled,
+			Token: &agent.TokenStatus{Warning: "token expired and refresh failed: invalid_grant"}},
+		{Provider: "codex", State: agent.CircuitOpen, LastPollAt: &polled, LastPollError: "401 unauthorized", RetryAt: &retry},
+		{Provider: "zai", State: agent.CircuitClosed},
+		{Provider: "copilot", State: agent.CircuitClosed, LastPollAt: &polled, SchemaWarning: "possible provider schema change: missing quota_snapshots"},
+	}, now)
+	for _, want := range []string{
+		"anthropic    polled 3m 00s ago, ok; token expired and refresh failed: invalid_grant",
+		"codex        polled 3m 00s ago, error: 401 unauthorized (circuit open, retry in 5m 00s)",
+		"zai          not polled yet",
+		"copilot      polled 3m 00s ago, ok; possible provider schema change: missing quota_snapshots",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if got := formatAgentHealth(nil, now); !strings.Contains(got, "no providers polling") {
+		t.Errorf("empty statuses: got %q", got)
+	}
+}
+
+func TestFetchAgentHealth_OlderDaemon(t *testing.T) {
+	// Older daemons serve the dashboard page for unknown paths
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer srv.Close()
+	if _, err := fetchAgentHealth(srv.URL, "admin", "x"); err != errAgentHealthUnsupported {
+		t.Fatalf("expected errAgentHealthUnsupported, got %v", err)
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := fetchAgentHealth(notFound.URL, "admin", "x"); err != errAgentHealthUnsupported {
+		t.Fatalf("expected errAgentHealthUnsupported on 404, got %v", err)
+	}
+}
@@ -8,6 +8,13 @@ import (
 	"syscall"
 )
 
+// gracefulRestartSupported reports whether a restart can hand the listening
+// socket to the new process.
+const gracefulRestartSupported = true
+
+// restartSignal asks a running daemon to restart in place.
+var restartSignal os.Signal = syscall.SIGHUP
+
 func daemonSysProcAttr() *syscall.SysProcAttr {
 	return &syscall.SysProcAttr{Setsid: true}
 }
@@ -15,3 +22,9 @@ func daemonSysProcAttr() *syscall.SysProcAttr {
 func defaultPIDDir() string {
 	return filepath.Join(os.Getenv("HOME"), ".onwatch")
 }
+
+// processAlive reports whether a process with pid is running.
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	return err == nil && proc.Signal(syscall.Signal(0)) == nil
+}
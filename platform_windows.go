@@ -8,6 +8,14 @@ import (
 	"syscall"
 )
 
+// gracefulRestartSupported reports whether a restart can hand the listening
+// socket to the new process. Windows cannot pass sockets this way, so updates
+// fall back to stopping and starting the daemon.
+const gracefulRestartSupported = false
+
+// restartSignal is unused on Windows.
+var restartSignal os.Signal
+
 func daemonSysProcAttr() *syscall.SysProcAttr {
 	return &syscall.SysProcAttr{
 		HideWindow:    true,
@@ -21,3 +29,14 @@ func defaultPIDDir() string {
 	}
 	return filepath.Join(os.Getenv("USERPROFILE"), ".onwatch")
 }
+
+// processAlive reports whether a process with pid is running. FindProcess
+// opens a handle on Windows, which fails once the process is gone.
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	proc.Release()
+	return true
+}
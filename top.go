@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/onllm-dev/onwatch/internal/config"
+)
+
+// topBarWidth is the number of cells in a quota bar.
+const topBarWidth = 30
+
+// ANSI sequences used by the terminal dashboard.
+const (
+	ansiClear      = "\033[H\033[2J"
+	ansiHideCursor = "\033[?25l"
+	ansiShowCursor = "\033[?25h"
+	ansiReset      = "\033[0m"
+	ansiBold       = "\033[1m"
+	ansiDim        = "\033[2m"
+)
+
+// topStatusColors maps a quota status from /api/current to its bar color.
+var topStatusColors = map[string]string{
+	"healthy":  "\033[32m",
+	"warning":  "\033[33m",
+	"danger":   "\033[31m",
+	"critical": "\033[1;31m",
+}
+
+// topQuota is one quota row in the terminal dashboard.
+type topQuota struct {
+	name      string
+	percent   float64
+	status    string
+	unlimited bool
+	resetsAt  time.Time // zero when the provider reports no reset time
+}
+
+// runTop handles "onwatch top", a terminal dashboard that polls the daemon's
+// /api/current on the poll interval and redraws quota bars with live reset
+// countdowns every second. --url points it at a remote daemon; --user and
+// --pass override the configured dashboard credentials.
+func runTop() error {
+	url, user, pass, interval := "http://localhost:9211", "admin", "", 60*time.Second
+	if cfg, err := config.Load(); err == nil {
+		url = fmt.Sprintf("http://localhost:%d", cfg.Port)
+		user, pass, interval = cfg.AdminUser, cfg.AdminPass, cfg.PollInterval
+	} else {
+		// A remote daemon needs no local provider configuration
+		if v := os.Getenv("ONWATCH_ADMIN_USER"); v != "" {
+			user = v
+		}
+		pass = os.Getenv("ONWATCH_ADMIN_PASS")
+		if v, err := strconv.Atoi(flagValue("--interval")); err == nil && v > 0 {
+			interval = time.Duration(v) * time.Second
+		}
+	}
+	if v := flagValue("--url"); v != "" {
+		url = strings.TrimRight(v, "/")
+	}
+	if v := flagValue("--user"); v != "" {
+		user = v
+	}
+	if v := flagValue("--pass"); v != "" {
+		pass = v
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	fetch := func() (map[string][]topQuota, error) {
+		req, err := http.NewRequest(http.MethodGet, url+"/api/current?provider=both", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(user, pass)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("unauthorized: check --user/--pass or ONWATCH_ADMIN_USER/ONWATCH_ADMIN_PASS")
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		return parseTopCurrent(resp.Body)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	fmt.Print(ansiHideCursor)
+	defer fmt.Print(ansiShowCursor)
+
+	var (
+		providers map[string][]topQuota
+		fetchErr  error
+		fetchedAt time.Time
+	)
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		if time.Since(fetchedAt) >= interval {
+			if p, err := fetch(); err == nil {
+				providers, fetchErr = p, nil
+			} else {
+				fetchErr = err
+			}
+			fetchedAt = time.Now()
+		}
+		fmt.Print(ansiClear + renderTop(url, providers, fetchErr, fetchedAt, interval, time.Now()))
+
+		select {
+		case <-tick.C:
+		case <-sigChan:
+			fmt.Println()
+			return nil
+		}
+	}
+}
+
+// parseTopCurrent reads a /api/current?provider=both response into quota rows
+// per provider. Every provider's quota maps carry a name, a usage percentage
+// (under one of a few keys) and usually a reset time, which is all the
+// dashboard needs.
+func parseTopCurrent(r io.Reader) (map[string][]topQuota, error) {
+	var raw map[string]map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid /api/current response: %w", err)
+	}
+
+	providers := make(map[string][]topQuota, len(raw))
+	for provider, current := range raw {
+		var quotas []topQuota
+		if list, ok := current["quotas"].([]interface{}); ok {
+			for _, item := range list {
+				if m, ok := item.(map[string]interface{}); ok {
+					if q, ok := topQuotaFrom(m); ok {
+						quotas = append(quotas, q)
+					}
+				}
+			}
+		}
+		keys := make([]string, 0, len(current))
+		for key := range current {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if m, ok := current[key].(map[string]interface{}); ok {
+				if q, ok := topQuotaFrom(m); ok {
+					quotas = append(quotas, q)
+				}
+			}
+		}
+		providers[provider] = quotas
+	}
+	return providers, nil
+}
+
+// topQuotaFrom extracts a quota row from one quota map, reporting false for
+// maps without a usage percentage.
+func topQuotaFrom(m map[string]interface{}) (topQuota, bool) {
+	q := topQuota{}
+	found := false
+	for _, key := range []string{"percent", "utilization", "usagePercent"} {
+		if v, ok := m[key].(float64); ok {
+			q.percent, found = v, true
+			break
+		}
+	}
+	if !found {
+		return q, false
+	}
+	for _, key := range []string{"displayName", "name", "label"} {
+		if v, ok := m[key].(string); ok && v != "" {
+			q.name = v
+			break
+		}
+	}
+	q.status, _ = m["status"].(string)
+	q.unlimited, _ = m["unlimited"].(bool)
+	for _, key := range []string{"resetsAt", "renewsAt", "resetDate", "resetTime"} {
+		if v, ok := m[key].(string); ok {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				q.resetsAt = t
+				break
+			}
+		}
+	}
+	return q, true
+}
+
+// renderTop draws one frame of the terminal dashboard.
+func renderTop(url string, providers map[string][]topQuota, fetchErr error, fetchedAt time.Time, interval time.Duration, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sonWatch%s  %s\n", ansiBold, ansiReset, url)
+	if !fetchedAt.IsZero() {
+		next := fetchedAt.Add(interval).Sub(now).Round(time.Second)
+		fmt.Fprintf(&b, "%supdated %s, next refresh in %s (Ctrl+C to quit)%s\n", ansiDim, fetchedAt.Format("15:04:05"), next, ansiReset)
+	}
+	if fetchErr != nil {
+		fmt.Fprintf(&b, "%serror: %v%s\n", topStatusColors["danger"], fetchErr, ansiReset)
+	}
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n%s%s%s\n", ansiBold, name, ansiReset)
+		if len(providers[name]) == 0 {
+			fmt.Fprintf(&b, "  %sno data yet%s\n", ansiDim, ansiReset)
+		}
+		for _, q := range providers[name] {
+			b.WriteString(renderTopQuota(q, now))
+		}
+	}
+	return b.String()
+}
+
+// renderTopQuota draws a single quota row: name, bar, percentage and the
+// countdown to its reset.
+func renderTopQuota(q topQuota, now time.Time) string {
+	name := q.name
+	if r := []rune(name); len(r) > 24 {
+		name = string(r[:23]) + "…"
+	}
+	if q.unlimited {
+		return fmt.Sprintf("  %-24s %s%s%s\n", name, ansiDim, "unlimited", ansiReset)
+	}
+
+	filled := int(q.percent/100*topBarWidth + 0.5)
+	filled = min(max(filled, 0), topBarWidth)
+	color := topStatusColors[q.status]
+	if color == "" {
+		color = topStatusColors["healthy"]
+	}
+	bar := color + strings.Repeat("█", filled) + ansiReset + ansiDim + strings.Repeat("░", topBarWidth-filled) + ansiReset
+
+	reset := ""
+	if !q.resetsAt.IsZero() {
+		if d := q.resetsAt.Sub(now); d > 0 {
+			reset = "resets in " + formatCountdown(d)
+		} else {
+			reset = "reset due"
+		}
+	}
+	return fmt.Sprintf("  %-24s %s %5.1f%%  %s\n", name, bar, q.percent, reset)
+}
+
+// formatCountdown formats d as "2d 3h", "3h 12m" or "12m 05s".
+func formatCountdown(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %02dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm %02ds", minutes, seconds)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTopCurrent(t *testing.T) {
+	body := `{
+		"synthetic": {
+			"capturedAt": "2026-03-01T12:00:00Z",
+			"subscription": {"name": "Subscription", "percent": 42.5, "status": "healthy", "renewsAt": "2026-03-01T15:00:00Z"},
+			"credits": {"name": "Credits", "balance": 12.5}
+		},
+		"anthropic": {
+			"quotas": [{"name": "five_hour", "displayName": "5-Hour Limit", "utilization": 91, "status": "danger", "resetsAt": "2026-03-01T13:30:00Z"}]
+		},
+		"copilot": {
+			"quotas": [{"name": "chat", "usagePercent": 0, "unlimited": true}]
+		}
+	}`
+	providers, err := parseTopCurrent(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parseTopCurrent: %v", err)
+	}
+
+	syn := providers["synthetic"]
+	if len(syn) != 1 || syn[0].name != "Subscription" || syn[0].percent != 42.5 {
+		t.Fatalf("expected only the subscription quota (credits has no percentage), got %+v", syn)
+	}
+	anth := providers["anthropic"]
+	if len(anth) != 1 || anth[0].name != "5-Hour Limit" || anth[0].status != "danger" ||
+		!anth[0].resetsAt.Equal(time.Date(2026, 3, 1, 13, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected anthropic quotas: %+v", anth)
+	}
+	if cop := providers["copilot"]; len(cop) != 1 || !cop[0].unlimited {
+		t.Errorf("unexpected copilot quotas: %+v", cop)
+	}
+
+	if _, err := parseTopCurrent(strings.NewReader("not json")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestRenderTopQuota(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	row := renderTopQuota(topQuota{name: "5-Hour Limit", percent: 50, status: "warning", resetsAt: now.Add(90 * time.Minute)}, now)
+	if !strings.Contains(row, " 50.0%") || !strings.Contains(row, "resets in 1h 30m") {
+		t.Errorf("unexpected row: %q", row)
+	}
+	if got := strings.Count(row, "█"); got != topBarWidth/2 {
+		t.Errorf("expected a half-filled bar, got %d cells", got)
+	}
+	if !strings.Contains(row, topStatusColors["warning"]) {
+		t.Error("expected the bar in the warning color")
+	}
+
+	if row := renderTopQuota(topQuota{name: "Chat", unlimited: true}, now); !strings.Contains(row, "unlimited") {
+		t.Errorf("expected unlimited quota label, got %q", row)
+	}
+	if row := renderTopQuota(topQuota{name: "x", percent: 150, resetsAt: now.Add(-time.Minute)}, now); strings.Count(row, "█") != topBarWidth || !strings.Contains(row, "reset due") {
+		t.Errorf("expected a full bar and a due reset, got %q", row)
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	cases := map[time.Duration]string{
+		50 * time.Second:                 "0m 50s",
+		12*time.Minute + 5*time.Second:   "12m 05s",
+		3*time.Hour + 7*time.Minute:      "3h 07m",
+		2*24*time.Hour + 3*time.Hour + 1: "2d 3h",
+	}
+	for d, want := range cases {
+		if got := formatCountdown(d); got != want {
+			t.Errorf("formatCountdown(%v) = %q, want %q", d, got, want)
+		}
+	}
+}